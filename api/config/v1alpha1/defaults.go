@@ -0,0 +1,190 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"go.goms.io/fleet-networking/pkg/common/drain"
+	"go.goms.io/fleet-networking/pkg/common/freshness"
+	"go.goms.io/fleet-networking/pkg/common/hubconfig"
+	"go.goms.io/fleet-networking/pkg/common/hubschema"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+	"go.goms.io/fleet-networking/pkg/common/namespaceisolation"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
+)
+
+const (
+	defaultFleetSystemNamespace = "fleet-system"
+	defaultCloudConfigFile      = "/etc/kubernetes/provider/azure.json"
+)
+
+// The defaults of the settings of the controllers; the controllers fall back to them as well when they are not set.
+const (
+	// DefaultChurnThreshold is the default number of endpoint changes of a Service within the churn detection window
+	// above which its exports are coalesced; churn protection is disabled by default, as coalescing delays the export
+	// of endpoint changes.
+	DefaultChurnThreshold = 0
+	// DefaultChurnRecoveryThreshold is the default number of endpoint changes of a Service within the churn detection
+	// window at or below which its exports are no longer coalesced.
+	DefaultChurnRecoveryThreshold = 20
+	// DefaultChurnWindow is the default period over which the endpoint changes of a Service are counted.
+	DefaultChurnWindow = time.Minute
+	// DefaultChurnCoalescingInterval is the default interval at which the EndpointSlices of a Service whose exports
+	// are coalesced are exported.
+	DefaultChurnCoalescingInterval = 30 * time.Second
+
+	// DefaultEndpointSliceRequeueJitter is the default upper bound of the random delay added to the requeues of
+	// EndpointSlices.
+	DefaultEndpointSliceRequeueJitter = 5 * time.Second
+
+	// DefaultResourcePressureThreshold is the default utilization, in percent, of the Pods a node can hold or of the
+	// CPU of the member cluster above which the exports are paused; the resource pressure is not checked by default,
+	// as pausing the exports delays the endpoint changes of every exported Service.
+	DefaultResourcePressureThreshold = 0
+	// DefaultResourcePressureCheckInterval is the default interval between two checks of the resource pressure.
+	DefaultResourcePressureCheckInterval = 30 * time.Second
+
+	// DefaultServiceNotFoundRequeueAfter is the default interval to requeue a ServiceExport whose Service is not
+	// found.
+	DefaultServiceNotFoundRequeueAfter = 30 * time.Second
+
+	// DefaultFleetServiceNetworkingStatusBatchInterval is the default wait time before a change of the sources of a
+	// FleetServiceNetworkingStatus is summarized, so that a burst of changes, e.g. a rollout updating EndpointSlices
+	// in many clusters, results in a single status update.
+	DefaultFleetServiceNetworkingStatusBatchInterval = 5 * time.Second
+)
+
+// MCSAPICompatibilityMode is the migration mode of the mcs-api compatibility.
+type MCSAPICompatibilityMode string
+
+const (
+	// MCSAPICompatibilityModeDualWrite honors the upstream ServiceExports: a fleet ServiceExport is created for every
+	// upstream one and the fleet ServiceExport conditions are written back to the upstream object.
+	MCSAPICompatibilityModeDualWrite MCSAPICompatibilityMode = "DualWrite"
+	// MCSAPICompatibilityModeCutover stops honoring the upstream ServiceExports: the fleet ServiceExports created for
+	// them are detached from the upstream objects, so that deleting the upstream objects no longer unexports the
+	// services.
+	MCSAPICompatibilityModeCutover MCSAPICompatibilityMode = "Cutover"
+)
+
+// DefaultMemberAgentConfiguration returns the configuration of the member agent with every setting defaulted; the
+// settings of a configuration file are applied on top of it.
+func DefaultMemberAgentConfiguration() *MemberAgentConfiguration {
+	return &MemberAgentConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersion.String(),
+			Kind:       MemberAgentConfigurationKind,
+		},
+		Hub: MemberAgentHubConfiguration{
+			ManagerConfiguration: ManagerConfiguration{
+				MetricsBindAddress:     ":8080",
+				HealthProbeBindAddress: ":8081",
+			},
+			QPS:                 hubconfig.DefaultHubAPIQPS,
+			Burst:               hubconfig.DefaultHubAPIBurst,
+			SchemaCheckInterval: metav1.Duration{Duration: hubschema.DefaultCheckInterval},
+			WriteRetryBudget: RetryBudgetConfiguration{
+				Rate:  retrybudget.DefaultRate,
+				Burst: retrybudget.DefaultBurst,
+			},
+			WriteDrainTimeout: metav1.Duration{Duration: drain.DefaultTimeout},
+		},
+		Member: ManagerConfiguration{
+			MetricsBindAddress:     ":8090",
+			HealthProbeBindAddress: ":8091",
+		},
+		LeaderElection: LeaderElectionConfiguration{
+			LeaderElect:       true,
+			ResourceNamespace: defaultFleetSystemNamespace,
+		},
+		FleetSystemNamespace: defaultFleetSystemNamespace,
+		EnableV1Alpha1APIs:   true,
+		TrafficManager: MemberAgentTrafficManagerConfiguration{
+			CloudConfigFile: defaultCloudConfigFile,
+		},
+		MCSAPICompatibility: MCSAPICompatibilityConfiguration{
+			Mode: string(MCSAPICompatibilityModeDualWrite),
+		},
+		EndpointSlice: EndpointSliceConfiguration{
+			MaxConcurrentReconciles: 1,
+			CircuitBreakerThreshold: 10,
+			CircuitBreakerCoolDown:  metav1.Duration{Duration: 5 * time.Minute},
+			ChurnProtection: ChurnProtectionConfiguration{
+				Threshold:          DefaultChurnThreshold,
+				RecoveryThreshold:  DefaultChurnRecoveryThreshold,
+				Window:             metav1.Duration{Duration: DefaultChurnWindow},
+				CoalescingInterval: metav1.Duration{Duration: DefaultChurnCoalescingInterval},
+			},
+			ExportHeartbeatInterval: metav1.Duration{Duration: 5 * time.Minute},
+			RequeueJitter:           metav1.Duration{Duration: DefaultEndpointSliceRequeueJitter},
+			ResourcePressure: ResourcePressureConfiguration{
+				PodCIDRUtilizationThreshold: DefaultResourcePressureThreshold,
+				CPUUtilizationThreshold:     DefaultResourcePressureThreshold,
+				CheckInterval:               metav1.Duration{Duration: DefaultResourcePressureCheckInterval},
+			},
+		},
+		ServiceExport: ServiceExportConfiguration{
+			MaxConcurrentReconciles:     1,
+			ServiceNotFoundRequeueAfter: metav1.Duration{Duration: DefaultServiceNotFoundRequeueAfter},
+			CleanupFinalizer:            objectmeta.ServiceExportFinalizer,
+		},
+		NamespaceIsolation: NamespaceIsolationConfiguration{
+			FailureThreshold:     namespaceisolation.DefaultFailureThreshold,
+			Window:               metav1.Duration{Duration: namespaceisolation.DefaultWindow},
+			DemotedRetryInterval: metav1.Duration{Duration: namespaceisolation.DefaultDemotedRetryInterval},
+		},
+		InitialSync: InitialSyncConfiguration{
+			Burst:     initialsync.DefaultBurst,
+			Threshold: initialsync.DefaultThreshold,
+		},
+	}
+}
+
+// DefaultHubAgentConfiguration returns the configuration of the hub agent with every setting defaulted; the
+// settings of a configuration file are applied on top of it.
+func DefaultHubAgentConfiguration() *HubAgentConfiguration {
+	return &HubAgentConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: GroupVersion.String(),
+			Kind:       HubAgentConfigurationKind,
+		},
+		Manager: ManagerConfiguration{
+			MetricsBindAddress:     ":8080",
+			HealthProbeBindAddress: ":8081",
+		},
+		LeaderElection: LeaderElectionConfiguration{
+			LeaderElect:       true,
+			ResourceNamespace: defaultFleetSystemNamespace,
+		},
+		EnableV1Beta1APIs:                  true,
+		InternalServiceExportRetryInterval: metav1.Duration{Duration: 2 * time.Second},
+		ForceDeleteWaitTime:                metav1.Duration{Duration: 15 * time.Minute},
+		TrafficManager: HubAgentTrafficManagerConfiguration{
+			CloudConfigFile:                defaultCloudConfigFile,
+			ProfileCircuitBreakerThreshold: 5,
+			ProfileCircuitBreakerCoolDown:  metav1.Duration{Duration: 5 * time.Minute},
+			ProfileDriftDetectionInterval:  metav1.Duration{Duration: 5 * time.Minute},
+		},
+		FleetServiceNetworkingStatus: FleetServiceNetworkingStatusConfiguration{
+			BatchInterval: metav1.Duration{Duration: DefaultFleetServiceNetworkingStatusBatchInterval},
+		},
+		EndpointFreshness: EndpointFreshnessConfiguration{
+			Threshold:         metav1.Duration{Duration: freshness.DefaultThreshold},
+			RecoveryThreshold: metav1.Duration{Duration: freshness.DefaultRecoveryThreshold},
+			Window:            metav1.Duration{Duration: freshness.DefaultWindow},
+		},
+		MemberNamespaceGarbageCollection: MemberNamespaceGarbageCollectionConfiguration{
+			RetryInterval: metav1.Duration{Duration: 5 * time.Second},
+		},
+		EndpointSliceExportGarbageCollection: EndpointSliceExportGarbageCollectionConfiguration{
+			TTL: metav1.Duration{Duration: time.Hour},
+		},
+	}
+}
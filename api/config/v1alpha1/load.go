@@ -0,0 +1,83 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadMemberAgentConfiguration reads the configuration file of the member agent; the settings missing from the file
+// are defaulted. The configuration is not validated.
+func LoadMemberAgentConfiguration(path string) (*MemberAgentConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration file: %w", err)
+	}
+	return DecodeMemberAgentConfiguration(data)
+}
+
+// DecodeMemberAgentConfiguration decodes the content of a configuration file of the member agent; the settings
+// missing from the content are defaulted. The configuration is not validated.
+func DecodeMemberAgentConfiguration(data []byte) (*MemberAgentConfiguration, error) {
+	c := DefaultMemberAgentConfiguration()
+	if err := decode(data, &c.TypeMeta, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadHubAgentConfiguration reads the configuration file of the hub agent; the settings missing from the file are
+// defaulted. The configuration is not validated.
+func LoadHubAgentConfiguration(path string) (*HubAgentConfiguration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration file: %w", err)
+	}
+	return DecodeHubAgentConfiguration(data)
+}
+
+// DecodeHubAgentConfiguration decodes the content of a configuration file of the hub agent; the settings missing
+// from the content are defaulted. The configuration is not validated.
+func DecodeHubAgentConfiguration(data []byte) (*HubAgentConfiguration, error) {
+	c := DefaultHubAgentConfiguration()
+	if err := decode(data, &c.TypeMeta, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// decode decodes the content of a configuration file on top of the defaulted configuration; unknown fields are
+// rejected so that misspelled settings do not go unnoticed.
+func decode(data []byte, typeMeta *metav1.TypeMeta, into interface{}) error {
+	// The file must declare its apiVersion and kind rather than inherit the defaulted ones.
+	*typeMeta = metav1.TypeMeta{}
+	if err := yaml.UnmarshalStrict(data, into); err != nil {
+		return fmt.Errorf("failed to decode the configuration file: %w", err)
+	}
+	return nil
+}
+
+// Redacted returns a copy of the configuration with the passwords of the proxy URLs redacted, e.g. to be logged.
+func (c *MemberAgentConfiguration) Redacted() *MemberAgentConfiguration {
+	redacted := *c
+	redacted.Hub.Proxy.URL = redactURL(c.Hub.Proxy.URL)
+	redacted.TrafficManager.ARMProxy.URL = redactURL(c.TrafficManager.ARMProxy.URL)
+	return &redacted
+}
+
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		// The URL is invalid and fails the validation; it is dropped as it cannot be redacted.
+		return "<invalid>"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,343 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package v1alpha1 contains the v1alpha1 configuration file types of the fleet networking agents.
+// +kubebuilder:skip
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// MemberAgentConfigurationKind is the kind of the configuration file of the member agent.
+	MemberAgentConfigurationKind = "MemberAgentConfiguration"
+	// HubAgentConfigurationKind is the kind of the configuration file of the hub agent.
+	HubAgentConfigurationKind = "HubAgentConfiguration"
+)
+
+var (
+	// GroupVersion is the group version of the configuration files.
+	GroupVersion = schema.GroupVersion{Group: "config.networking.fleet.azure.com", Version: "v1alpha1"}
+)
+
+// MemberAgentConfiguration is the configuration of the member agent (member-net-controller-manager).
+//
+// Every setting can be overridden by the command-line flag of the same meaning; the flags set on the command line
+// take precedence over the configuration file.
+type MemberAgentConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// LogVerbosity is the verbosity of the logs; it is reloaded when the configuration file changes.
+	LogVerbosity int32 `json:"logVerbosity"`
+
+	// Hub configures the connection of the agent to the hub cluster and the controller manager watching it.
+	Hub MemberAgentHubConfiguration `json:"hub"`
+	// Member configures the controller manager watching the member cluster.
+	Member ManagerConfiguration `json:"member"`
+	// LeaderElection configures the leader election of both controller managers.
+	LeaderElection LeaderElectionConfiguration `json:"leaderElection"`
+
+	// FleetSystemNamespace is the reserved system namespace used by fleet.
+	FleetSystemNamespace string `json:"fleetSystemNamespace"`
+	// EnableV1Alpha1APIs makes the agent watch for the v1alpha1 fleet APIs.
+	EnableV1Alpha1APIs bool `json:"enableV1Alpha1APIs"`
+	// EnableV1Beta1APIs makes the agent watch for the v1beta1 fleet APIs.
+	EnableV1Beta1APIs bool `json:"enableV1Beta1APIs"`
+
+	// TrafficManager configures the Azure Traffic Manager feature.
+	TrafficManager MemberAgentTrafficManagerConfiguration `json:"trafficManager"`
+	// EnableServiceExportWebhook serves the webhook rejecting the ServiceExports of ExternalName services.
+	EnableServiceExportWebhook bool `json:"enableServiceExportWebhook"`
+	// MCSAPICompatibility configures the translation of the upstream mcs-api ServiceExports.
+	MCSAPICompatibility MCSAPICompatibilityConfiguration `json:"mcsAPICompatibility"`
+
+	// EndpointSlice configures the endpointslice controller.
+	EndpointSlice EndpointSliceConfiguration `json:"endpointSlice"`
+	// ServiceExport configures the serviceexport controller.
+	ServiceExport ServiceExportConfiguration `json:"serviceExport"`
+	// NamespaceIsolation configures how the retries of the namespaces whose objects keep failing are slowed down.
+	NamespaceIsolation NamespaceIsolationConfiguration `json:"namespaceIsolation"`
+	// InitialSync configures the pacing of the initial export of a member cluster joining the fleet.
+	InitialSync InitialSyncConfiguration `json:"initialSync"`
+}
+
+// ManagerConfiguration configures the endpoints of a controller manager.
+type ManagerConfiguration struct {
+	// MetricsBindAddress is the address the metric endpoint binds to.
+	MetricsBindAddress string `json:"metricsBindAddress"`
+	// HealthProbeBindAddress is the address the probe endpoint binds to.
+	HealthProbeBindAddress string `json:"healthProbeBindAddress"`
+}
+
+// LeaderElectionConfiguration configures the leader election of a controller manager.
+type LeaderElectionConfiguration struct {
+	// LeaderElect enables the leader election, so that there is only one active controller manager.
+	LeaderElect bool `json:"leaderElect"`
+	// ResourceNamespace is the namespace in which the leader election resource is created.
+	ResourceNamespace string `json:"resourceNamespace"`
+}
+
+// ProxyConfiguration configures the egress proxy of a connectivity target.
+type ProxyConfiguration struct {
+	// URL is the URL of the proxy, with the http or https scheme. If it is empty, the HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY environment variables apply.
+	URL string `json:"url,omitempty"`
+	// NoProxy is a comma-separated list of the hosts, domains, IP addresses and CIDRs which are reached without the
+	// proxy.
+	NoProxy string `json:"noProxy,omitempty"`
+}
+
+// MemberAgentHubConfiguration configures the connection of the member agent to the hub cluster.
+type MemberAgentHubConfiguration struct {
+	ManagerConfiguration `json:",inline"`
+
+	// TLSInsecure skips the verification of the hub API server certificate; for testing only.
+	TLSInsecure bool `json:"tlsInsecure"`
+	// QPS is the maximum number of queries per second the controllers send to the hub API server.
+	QPS float64 `json:"qps"`
+	// Burst is the maximum burst of queries the controllers send to the hub API server.
+	Burst int `json:"burst"`
+	// Proxy is the proxy through which the agent connects to the hub cluster.
+	Proxy ProxyConfiguration `json:"proxy"`
+	// SchemaCheckInterval is the interval to check whether the hub CRDs support the enabled features and the fields of
+	// the exports.
+	SchemaCheckInterval metav1.Duration `json:"schemaCheckInterval"`
+	// WriteRetryBudget is the retry budget of the failed writes to the hub cluster the controllers share; it is
+	// reloaded when the configuration file changes.
+	WriteRetryBudget RetryBudgetConfiguration `json:"writeRetryBudget"`
+	// WriteDrainTimeout is the period the in-flight writes to the hub cluster are given to complete when the agent
+	// shuts down; zero cancels them right away.
+	WriteDrainTimeout metav1.Duration `json:"writeDrainTimeout"`
+}
+
+// RetryBudgetConfiguration configures a retry budget.
+type RetryBudgetConfiguration struct {
+	// Rate is the number of retries per second; the budget is disabled if it is zero. The budget cannot be
+	// enabled or disabled by a reload.
+	Rate float64 `json:"rate"`
+	// Burst is the number of retries that may be made in a burst.
+	Burst int `json:"burst"`
+}
+
+// MemberAgentTrafficManagerConfiguration configures the Azure Traffic Manager feature of the member agent.
+type MemberAgentTrafficManagerConfiguration struct {
+	// Enabled enables the feature.
+	Enabled bool `json:"enabled"`
+	// CloudConfigFile is the path to the cloud config file used to access the Azure resources.
+	CloudConfigFile string `json:"cloudConfigFile"`
+	// ARMProxy is the proxy through which the agent connects to Azure Resource Manager and Microsoft Entra ID.
+	ARMProxy ProxyConfiguration `json:"armProxy"`
+}
+
+// MCSAPICompatibilityConfiguration configures the translation of the upstream mcs-api ServiceExports.
+type MCSAPICompatibilityConfiguration struct {
+	// Enabled enables the translation.
+	Enabled bool `json:"enabled"`
+	// Mode is the migration mode, either DualWrite or Cutover.
+	Mode string `json:"mode"`
+}
+
+// EndpointSliceConfiguration configures the endpointslice controller.
+type EndpointSliceConfiguration struct {
+	// MaxConcurrentReconciles is the maximum number of EndpointSlices exported at the same time.
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles"`
+	// CircuitBreakerThreshold is the number of consecutive failures to write to the hub namespace before the
+	// controller stops reconciling.
+	CircuitBreakerThreshold int `json:"circuitBreakerThreshold"`
+	// CircuitBreakerCoolDown is the wait time for the controller to write to the hub namespace again after it stops
+	// reconciling.
+	CircuitBreakerCoolDown metav1.Duration `json:"circuitBreakerCoolDown"`
+	// ExportNotReadyAddresses exports the endpoints regardless of their readiness.
+	ExportNotReadyAddresses bool `json:"exportNotReadyAddresses"`
+	// EnableEndpointSelector honors the endpoint selectors of ServiceExports, for which the metadata of all the Pods
+	// is cached; the ServiceExports which set an endpoint selector export no endpoint if it is not set.
+	EnableEndpointSelector bool `json:"enableEndpointSelector"`
+	// ChurnProtection configures how the exports of the Services whose endpoints change too often are coalesced.
+	ChurnProtection ChurnProtectionConfiguration `json:"churnProtection"`
+	// ExportHeartbeatInterval is the interval at which the EndpointSliceExports whose EndpointSlices still exist are
+	// annotated with the time their EndpointSlices were last seen; it must be well below the TTL of the
+	// EndpointSliceExports set on the hub agent.
+	ExportHeartbeatInterval metav1.Duration `json:"exportHeartbeatInterval"`
+	// RequeueJitter is the upper bound of the random delay added to the requeues of EndpointSlices for a later time,
+	// e.g. while the circuit for the hub namespace is open; failed writes to the hub cluster are retried with the
+	// backoff of the workqueue instead. Requeues are not jittered if it is zero.
+	RequeueJitter metav1.Duration `json:"requeueJitter"`
+	// ResourcePressure configures how the exports are paused while the member cluster is under resource pressure.
+	ResourcePressure ResourcePressureConfiguration `json:"resourcePressure"`
+}
+
+// ResourcePressureConfiguration configures how the exports are paused while the member cluster is under resource
+// pressure; unexports, and exports which only remove endpoints, go on while the exports are paused.
+type ResourcePressureConfiguration struct {
+	// PodCIDRUtilizationThreshold is the percentage of the Pods any node can hold, i.e. its allocatable Pods capped by
+	// the addresses of its pod CIDR, in use above which the exports are paused; the Pods are not checked if it is zero.
+	PodCIDRUtilizationThreshold int `json:"podCIDRUtilizationThreshold"`
+	// CPUUtilizationThreshold is the percentage of the allocatable CPU of the nodes in use, as reported by the Metrics
+	// Server, above which the exports are paused; the CPU utilization is not checked if it is zero.
+	CPUUtilizationThreshold int `json:"cpuUtilizationThreshold"`
+	// CheckInterval is the interval between two checks of the resource pressure.
+	CheckInterval metav1.Duration `json:"checkInterval"`
+}
+
+// ChurnProtectionConfiguration configures how the exports of the Services whose endpoints change too often are
+// coalesced.
+type ChurnProtectionConfiguration struct {
+	// Threshold is the number of endpoint changes of a Service within the window above which its exports are
+	// coalesced; churn protection is disabled if it is zero.
+	Threshold int `json:"threshold"`
+	// RecoveryThreshold is the number of endpoint changes of a Service within the window at or below which its
+	// endpoint changes are exported as they happen again.
+	RecoveryThreshold int `json:"recoveryThreshold"`
+	// Window is the period over which the endpoint changes of a Service are counted.
+	Window metav1.Duration `json:"window"`
+	// CoalescingInterval is the interval at which the EndpointSlices of a Service whose exports are coalesced are
+	// exported.
+	CoalescingInterval metav1.Duration `json:"coalescingInterval"`
+}
+
+// ServiceExportConfiguration configures the serviceexport controller.
+type ServiceExportConfiguration struct {
+	// MaxConcurrentReconciles is the maximum number of ServiceExports exported at the same time.
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles"`
+	// ServiceNotFoundRequeueAfter is the interval to requeue a ServiceExport whose Service is not found.
+	ServiceNotFoundRequeueAfter metav1.Duration `json:"serviceNotFoundRequeueAfter"`
+	// UnexportGracePeriod is the period the unexport of a deleted ServiceExport is delayed while member clusters
+	// still import its service.
+	UnexportGracePeriod metav1.Duration `json:"unexportGracePeriod"`
+	// CleanupFinalizer is the finalizer added to the exported ServiceExports.
+	CleanupFinalizer string `json:"cleanupFinalizer"`
+}
+
+// NamespaceIsolationConfiguration configures how the retries of the namespaces whose objects keep failing are
+// slowed down.
+type NamespaceIsolationConfiguration struct {
+	// FailureThreshold is the number of failed reconciliations of a namespace within the window above which its
+	// retries are slowed down; the isolation is disabled if it is zero.
+	FailureThreshold int `json:"failureThreshold"`
+	// Window is the period over which the failed reconciliations of a namespace are counted.
+	Window metav1.Duration `json:"window"`
+	// DemotedRetryInterval is the interval between the retries of a namespace whose retries are slowed down.
+	DemotedRetryInterval metav1.Duration `json:"demotedRetryInterval"`
+}
+
+// InitialSyncConfiguration configures the pacing of the initial export of a member cluster joining the fleet.
+type InitialSyncConfiguration struct {
+	// Rate is the number of objects exported per second; the initial export is not paced if it is zero.
+	Rate float64 `json:"rate"`
+	// Burst is the number of objects exported right away when the paced initial export starts.
+	Burst int `json:"burst"`
+	// Threshold is the number of ServiceExports from which the initial export is paced.
+	Threshold int `json:"threshold"`
+}
+
+// HubAgentConfiguration is the configuration of the hub agent (hub-net-controller-manager).
+//
+// Every setting can be overridden by the command-line flag of the same meaning; the flags set on the command line
+// take precedence over the configuration file.
+type HubAgentConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// LogVerbosity is the verbosity of the logs; it is reloaded when the configuration file changes.
+	LogVerbosity int32 `json:"logVerbosity"`
+
+	// Manager configures the endpoints of the controller manager.
+	Manager ManagerConfiguration `json:"manager"`
+	// LeaderElection configures the leader election of the controller manager.
+	LeaderElection LeaderElectionConfiguration `json:"leaderElection"`
+
+	// EnableV1Beta1APIs makes the agent watch for the v1beta1 fleet APIs.
+	EnableV1Beta1APIs bool `json:"enableV1Beta1APIs"`
+	// InternalServiceExportRetryInterval is the wait time for the internalserviceexport controller to requeue a
+	// request while the ServiceImport controller resolves the service spec.
+	InternalServiceExportRetryInterval metav1.Duration `json:"internalServiceExportRetryInterval"`
+	// ForceDeleteWaitTime is the wait time before the agent tries to force delete a member cluster.
+	ForceDeleteWaitTime metav1.Duration `json:"forceDeleteWaitTime"`
+
+	// TrafficManager configures the Azure Traffic Manager feature.
+	TrafficManager HubAgentTrafficManagerConfiguration `json:"trafficManager"`
+	// FleetServiceNetworkingStatus configures the summaries of the networking pipeline of the exported services.
+	FleetServiceNetworkingStatus FleetServiceNetworkingStatusConfiguration `json:"fleetServiceNetworkingStatus"`
+	// EndpointFreshness configures the measurement of the lag of the endpoint changes of the exported services.
+	EndpointFreshness EndpointFreshnessConfiguration `json:"endpointFreshness"`
+	// MemberNamespaceGarbageCollection configures the cleanup of the objects a member cluster leaves in the fleet.
+	MemberNamespaceGarbageCollection MemberNamespaceGarbageCollectionConfiguration `json:"memberNamespaceGarbageCollection"`
+	// EndpointSliceExportGarbageCollection configures the cleanup of the EndpointSliceExports whose EndpointSlices
+	// the member agents no longer see.
+	EndpointSliceExportGarbageCollection EndpointSliceExportGarbageCollectionConfiguration `json:"endpointSliceExportGarbageCollection"`
+	// EnableMCSAPICompatibility mirrors the fleet ServiceImports into the upstream mcs-api ServiceImports.
+	EnableMCSAPICompatibility bool `json:"enableMCSAPICompatibility"`
+	// Webhook configures the webhooks.
+	Webhook HubAgentWebhookConfiguration `json:"webhook"`
+}
+
+// HubAgentTrafficManagerConfiguration configures the Azure Traffic Manager feature of the hub agent.
+type HubAgentTrafficManagerConfiguration struct {
+	// Enabled enables the feature.
+	Enabled bool `json:"enabled"`
+	// CloudConfigFile is the path to the cloud config file used to access the Azure resources.
+	CloudConfigFile string `json:"cloudConfigFile"`
+	// ProfileCircuitBreakerThreshold is the number of consecutive Azure server errors for a profile before the
+	// controller stops calling Azure for the profile.
+	ProfileCircuitBreakerThreshold int `json:"profileCircuitBreakerThreshold"`
+	// ProfileCircuitBreakerCoolDown is the wait time for the controller to call Azure again for a profile.
+	ProfileCircuitBreakerCoolDown metav1.Duration `json:"profileCircuitBreakerCoolDown"`
+	// ProfileDriftDetectionInterval is the interval at which the changes made out of band to the profiles are
+	// corrected; the drift detection is disabled if it is zero.
+	ProfileDriftDetectionInterval metav1.Duration `json:"profileDriftDetectionInterval"`
+	// MetricsAllowedHosts are the hosts the auto weight sources and the SLA monitors of the profiles may point to,
+	// either host names or wildcards of the form "*.example.com"; neither is ever queried if it is empty.
+	MetricsAllowedHosts []string `json:"metricsAllowedHosts,omitempty"`
+}
+
+// FleetServiceNetworkingStatusConfiguration configures the summaries of the networking pipeline of the exported
+// services.
+type FleetServiceNetworkingStatusConfiguration struct {
+	// Enabled enables the summaries.
+	Enabled bool `json:"enabled"`
+	// BatchInterval is the wait time to batch the changes of a service before summarizing them.
+	BatchInterval metav1.Duration `json:"batchInterval"`
+}
+
+// EndpointFreshnessConfiguration configures the measurement of the lag of the endpoint changes of the exported
+// services.
+type EndpointFreshnessConfiguration struct {
+	// Threshold is the lag above which the endpoint changes are reported as not fresh; the measurement is disabled
+	// if it is zero.
+	Threshold metav1.Duration `json:"threshold"`
+	// RecoveryThreshold is the lag at or below which the endpoint changes are reported as fresh again.
+	RecoveryThreshold metav1.Duration `json:"recoveryThreshold"`
+	// Window is the period over which the lag is measured.
+	Window metav1.Duration `json:"window"`
+}
+
+// MemberNamespaceGarbageCollectionConfiguration configures the cleanup of the objects a member cluster leaves in
+// the fleet.
+type MemberNamespaceGarbageCollectionConfiguration struct {
+	// Enabled enables the cleanup.
+	Enabled bool `json:"enabled"`
+	// RetryInterval is the wait time to check again whether the objects in a deleted member namespace are cleaned
+	// up.
+	RetryInterval metav1.Duration `json:"retryInterval"`
+}
+
+// EndpointSliceExportGarbageCollectionConfiguration configures the cleanup of the EndpointSliceExports whose
+// EndpointSlices the member agents no longer see.
+type EndpointSliceExportGarbageCollectionConfiguration struct {
+	// TTL is the period after which an EndpointSliceExport whose EndpointSlice has not been seen is deleted; the
+	// cleanup is disabled if it is zero.
+	TTL metav1.Duration `json:"ttl"`
+}
+
+// HubAgentWebhookConfiguration configures the webhooks of the hub agent.
+type HubAgentWebhookConfiguration struct {
+	// Enabled serves the validating and defaulting webhooks.
+	Enabled bool `json:"enabled"`
+	// EnableServiceExportCompatibilityCheck serves the endpoint predicting whether proposed ports of an exported
+	// service conflict with the other exports; it requires the webhooks to be enabled. The endpoint serves only the
+	// callers allowed to list the InternalServiceExports of all the member clusters.
+	EnableServiceExportCompatibilityCheck bool `json:"enableServiceExportCompatibilityCheck"`
+}
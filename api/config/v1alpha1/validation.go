@@ -0,0 +1,224 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import (
+	"net"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"go.goms.io/fleet-networking/pkg/common/egressproxy"
+)
+
+// ValidateMemberAgentConfiguration validates the configuration of the member agent.
+func ValidateMemberAgentConfiguration(c *MemberAgentConfiguration) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateTypeMeta(c.TypeMeta, MemberAgentConfigurationKind)...)
+	allErrs = append(allErrs, validateLogVerbosity(c.LogVerbosity, field.NewPath("logVerbosity"))...)
+
+	hubPath := field.NewPath("hub")
+	if c.Hub.QPS <= 0 {
+		allErrs = append(allErrs, field.Invalid(hubPath.Child("qps"), c.Hub.QPS, "must be greater than zero"))
+	}
+	if c.Hub.Burst <= 0 {
+		allErrs = append(allErrs, field.Invalid(hubPath.Child("burst"), c.Hub.Burst, "must be greater than zero"))
+	}
+	allErrs = append(allErrs, validateProxy(c.Hub.Proxy, hubPath.Child("proxy"))...)
+	allErrs = append(allErrs, validatePositiveDuration(c.Hub.SchemaCheckInterval, hubPath.Child("schemaCheckInterval"))...)
+	budgetPath := hubPath.Child("writeRetryBudget")
+	if c.Hub.WriteRetryBudget.Rate < 0 {
+		allErrs = append(allErrs, field.Invalid(budgetPath.Child("rate"), c.Hub.WriteRetryBudget.Rate, "must not be negative"))
+	}
+	if c.Hub.WriteRetryBudget.Rate > 0 && c.Hub.WriteRetryBudget.Burst < 1 {
+		allErrs = append(allErrs, field.Invalid(budgetPath.Child("burst"), c.Hub.WriteRetryBudget.Burst, "must be at least 1 when the retry budget is enabled"))
+	}
+	allErrs = append(allErrs, validateNonNegativeDuration(c.Hub.WriteDrainTimeout, hubPath.Child("writeDrainTimeout"))...)
+
+	allErrs = append(allErrs, validateLeaderElection(c.LeaderElection, field.NewPath("leaderElection"))...)
+	if c.FleetSystemNamespace == "" {
+		allErrs = append(allErrs, field.Required(field.NewPath("fleetSystemNamespace"), ""))
+	}
+
+	tmPath := field.NewPath("trafficManager")
+	if c.TrafficManager.Enabled && c.TrafficManager.CloudConfigFile == "" {
+		allErrs = append(allErrs, field.Required(tmPath.Child("cloudConfigFile"), "required when the traffic manager feature is enabled"))
+	}
+	allErrs = append(allErrs, validateProxy(c.TrafficManager.ARMProxy, tmPath.Child("armProxy"))...)
+
+	mode := MCSAPICompatibilityMode(c.MCSAPICompatibility.Mode)
+	if mode != MCSAPICompatibilityModeDualWrite && mode != MCSAPICompatibilityModeCutover {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("mcsAPICompatibility", "mode"), c.MCSAPICompatibility.Mode,
+			[]string{string(MCSAPICompatibilityModeDualWrite), string(MCSAPICompatibilityModeCutover)}))
+	}
+
+	esPath := field.NewPath("endpointSlice")
+	allErrs = append(allErrs, validatePositive(c.EndpointSlice.MaxConcurrentReconciles, esPath.Child("maxConcurrentReconciles"))...)
+	allErrs = append(allErrs, validatePositive(c.EndpointSlice.CircuitBreakerThreshold, esPath.Child("circuitBreakerThreshold"))...)
+	allErrs = append(allErrs, validatePositiveDuration(c.EndpointSlice.CircuitBreakerCoolDown, esPath.Child("circuitBreakerCoolDown"))...)
+	allErrs = append(allErrs, validatePositiveDuration(c.EndpointSlice.ExportHeartbeatInterval, esPath.Child("exportHeartbeatInterval"))...)
+	allErrs = append(allErrs, validateNonNegativeDuration(c.EndpointSlice.RequeueJitter, esPath.Child("requeueJitter"))...)
+	if churn := c.EndpointSlice.ChurnProtection; churn.Threshold > 0 {
+		cpPath := esPath.Child("churnProtection")
+		if churn.RecoveryThreshold < 0 || churn.RecoveryThreshold > churn.Threshold {
+			allErrs = append(allErrs, field.Invalid(cpPath.Child("recoveryThreshold"), churn.RecoveryThreshold, "must be between zero and the threshold"))
+		}
+		allErrs = append(allErrs, validatePositiveDuration(churn.Window, cpPath.Child("window"))...)
+		allErrs = append(allErrs, validatePositiveDuration(churn.CoalescingInterval, cpPath.Child("coalescingInterval"))...)
+	}
+	rpPath := esPath.Child("resourcePressure")
+	rp := c.EndpointSlice.ResourcePressure
+	allErrs = append(allErrs, validatePercentage(rp.PodCIDRUtilizationThreshold, rpPath.Child("podCIDRUtilizationThreshold"))...)
+	allErrs = append(allErrs, validatePercentage(rp.CPUUtilizationThreshold, rpPath.Child("cpuUtilizationThreshold"))...)
+	if rp.PodCIDRUtilizationThreshold > 0 || rp.CPUUtilizationThreshold > 0 {
+		allErrs = append(allErrs, validatePositiveDuration(rp.CheckInterval, rpPath.Child("checkInterval"))...)
+	}
+
+	sePath := field.NewPath("serviceExport")
+	allErrs = append(allErrs, validatePositive(c.ServiceExport.MaxConcurrentReconciles, sePath.Child("maxConcurrentReconciles"))...)
+	allErrs = append(allErrs, validatePositiveDuration(c.ServiceExport.ServiceNotFoundRequeueAfter, sePath.Child("serviceNotFoundRequeueAfter"))...)
+	allErrs = append(allErrs, validateNonNegativeDuration(c.ServiceExport.UnexportGracePeriod, sePath.Child("unexportGracePeriod"))...)
+	for _, msg := range validation.IsQualifiedName(c.ServiceExport.CleanupFinalizer) {
+		allErrs = append(allErrs, field.Invalid(sePath.Child("cleanupFinalizer"), c.ServiceExport.CleanupFinalizer, msg))
+	}
+
+	if c.NamespaceIsolation.FailureThreshold > 0 {
+		nsPath := field.NewPath("namespaceIsolation")
+		allErrs = append(allErrs, validatePositiveDuration(c.NamespaceIsolation.Window, nsPath.Child("window"))...)
+		allErrs = append(allErrs, validatePositiveDuration(c.NamespaceIsolation.DemotedRetryInterval, nsPath.Child("demotedRetryInterval"))...)
+	}
+
+	isPath := field.NewPath("initialSync")
+	if c.InitialSync.Rate < 0 {
+		allErrs = append(allErrs, field.Invalid(isPath.Child("rate"), c.InitialSync.Rate, "must not be negative"))
+	}
+	if c.InitialSync.Rate > 0 {
+		allErrs = append(allErrs, validatePositive(c.InitialSync.Burst, isPath.Child("burst"))...)
+		allErrs = append(allErrs, validatePositive(c.InitialSync.Threshold, isPath.Child("threshold"))...)
+	}
+	return allErrs
+}
+
+// ValidateHubAgentConfiguration validates the configuration of the hub agent.
+func ValidateHubAgentConfiguration(c *HubAgentConfiguration) field.ErrorList {
+	var allErrs field.ErrorList
+	allErrs = append(allErrs, validateTypeMeta(c.TypeMeta, HubAgentConfigurationKind)...)
+	allErrs = append(allErrs, validateLogVerbosity(c.LogVerbosity, field.NewPath("logVerbosity"))...)
+	allErrs = append(allErrs, validateLeaderElection(c.LeaderElection, field.NewPath("leaderElection"))...)
+	allErrs = append(allErrs, validatePositiveDuration(c.InternalServiceExportRetryInterval, field.NewPath("internalServiceExportRetryInterval"))...)
+	allErrs = append(allErrs, validateNonNegativeDuration(c.ForceDeleteWaitTime, field.NewPath("forceDeleteWaitTime"))...)
+
+	if c.TrafficManager.Enabled {
+		tmPath := field.NewPath("trafficManager")
+		if c.TrafficManager.CloudConfigFile == "" {
+			allErrs = append(allErrs, field.Required(tmPath.Child("cloudConfigFile"), "required when the traffic manager feature is enabled"))
+		}
+		allErrs = append(allErrs, validatePositive(c.TrafficManager.ProfileCircuitBreakerThreshold, tmPath.Child("profileCircuitBreakerThreshold"))...)
+		allErrs = append(allErrs, validatePositiveDuration(c.TrafficManager.ProfileCircuitBreakerCoolDown, tmPath.Child("profileCircuitBreakerCoolDown"))...)
+		allErrs = append(allErrs, validateNonNegativeDuration(c.TrafficManager.ProfileDriftDetectionInterval, tmPath.Child("profileDriftDetectionInterval"))...)
+		allErrs = append(allErrs, validateAllowedHosts(c.TrafficManager.MetricsAllowedHosts, tmPath.Child("metricsAllowedHosts"))...)
+	}
+
+	if c.FleetServiceNetworkingStatus.Enabled {
+		allErrs = append(allErrs, validatePositiveDuration(c.FleetServiceNetworkingStatus.BatchInterval, field.NewPath("fleetServiceNetworkingStatus", "batchInterval"))...)
+	}
+
+	if c.EndpointFreshness.Threshold.Duration > 0 {
+		efPath := field.NewPath("endpointFreshness")
+		allErrs = append(allErrs, validateNonNegativeDuration(c.EndpointFreshness.RecoveryThreshold, efPath.Child("recoveryThreshold"))...)
+		if c.EndpointFreshness.RecoveryThreshold.Duration > c.EndpointFreshness.Threshold.Duration {
+			allErrs = append(allErrs, field.Invalid(efPath.Child("recoveryThreshold"), c.EndpointFreshness.RecoveryThreshold.Duration.String(), "must not exceed the threshold"))
+		}
+		allErrs = append(allErrs, validatePositiveDuration(c.EndpointFreshness.Window, efPath.Child("window"))...)
+	}
+
+	if c.MemberNamespaceGarbageCollection.Enabled {
+		allErrs = append(allErrs, validatePositiveDuration(c.MemberNamespaceGarbageCollection.RetryInterval, field.NewPath("memberNamespaceGarbageCollection", "retryInterval"))...)
+	}
+
+	allErrs = append(allErrs, validateNonNegativeDuration(c.EndpointSliceExportGarbageCollection.TTL, field.NewPath("endpointSliceExportGarbageCollection", "ttl"))...)
+
+	if c.Webhook.EnableServiceExportCompatibilityCheck && !c.Webhook.Enabled {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("webhook", "enableServiceExportCompatibilityCheck"), true, "requires the webhooks to be enabled"))
+	}
+	return allErrs
+}
+
+func validateTypeMeta(typeMeta metav1.TypeMeta, kind string) field.ErrorList {
+	var allErrs field.ErrorList
+	if typeMeta.APIVersion != GroupVersion.String() {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("apiVersion"), typeMeta.APIVersion, []string{GroupVersion.String()}))
+	}
+	if typeMeta.Kind != kind {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("kind"), typeMeta.Kind, []string{kind}))
+	}
+	return allErrs
+}
+
+func validateLogVerbosity(v int32, fldPath *field.Path) field.ErrorList {
+	if v < 0 {
+		return field.ErrorList{field.Invalid(fldPath, v, "must not be negative")}
+	}
+	return nil
+}
+
+func validateLeaderElection(c LeaderElectionConfiguration, fldPath *field.Path) field.ErrorList {
+	if c.LeaderElect && c.ResourceNamespace == "" {
+		return field.ErrorList{field.Required(fldPath.Child("resourceNamespace"), "required when the leader election is enabled")}
+	}
+	return nil
+}
+
+func validateProxy(c ProxyConfiguration, fldPath *field.Path) field.ErrorList {
+	if err := (&egressproxy.URLValue{}).Set(c.URL); err != nil {
+		// The URL may hold a password; the error redacts it.
+		return field.ErrorList{field.Invalid(fldPath.Child("url"), field.OmitValueType{}, err.Error())}
+	}
+	return nil
+}
+
+func validatePositive(v int, fldPath *field.Path) field.ErrorList {
+	if v <= 0 {
+		return field.ErrorList{field.Invalid(fldPath, v, "must be greater than zero")}
+	}
+	return nil
+}
+
+func validatePercentage(v int, fldPath *field.Path) field.ErrorList {
+	if v < 0 || v > 100 {
+		return field.ErrorList{field.Invalid(fldPath, v, "must be between 0 and 100")}
+	}
+	return nil
+}
+
+func validatePositiveDuration(d metav1.Duration, fldPath *field.Path) field.ErrorList {
+	if d.Duration <= 0 {
+		return field.ErrorList{field.Invalid(fldPath, d.Duration.String(), "must be greater than zero")}
+	}
+	return nil
+}
+
+func validateNonNegativeDuration(d metav1.Duration, fldPath *field.Path) field.ErrorList {
+	if d.Duration < 0 {
+		return field.ErrorList{field.Invalid(fldPath, d.Duration.String(), "must not be negative")}
+	}
+	return nil
+}
+
+// validateAllowedHosts validates that every allowed host is either a host name or a wildcard of the form
+// "*.example.com".
+func validateAllowedHosts(hosts []string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, host := range hosts {
+		name := strings.TrimPrefix(host, "*.")
+		if errs := validation.IsDNS1123Subdomain(strings.ToLower(name)); len(errs) > 0 && net.ParseIP(host) == nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), host, "must be a host name, an IP address, or a wildcard of the form *.example.com"))
+		}
+	}
+	return allErrs
+}
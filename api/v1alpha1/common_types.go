@@ -8,6 +8,7 @@ package v1alpha1
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 // ExportedObjectReference helps operators identify the source of an exported object, e.g. an EndpointSliceExport.
@@ -44,21 +45,27 @@ type ExportedObjectReference struct {
 	// This field is marked as optional for backwards compatibility reasons.
 	// +kubebuilder:validation:Optional
 	ExportedSince metav1.Time `json:"exportedSince,omitempty"`
+	// The creation timestamp of the referred object; conflicts between the exports of the same object from
+	// different clusters are resolved in favour of the oldest object.
+	// This field is marked as optional for backwards compatibility reasons.
+	// +kubebuilder:validation:Optional
+	CreationTimestamp metav1.Time `json:"creationTimestamp,omitempty"`
 }
 
 // FromMetaObjects builds a new ExportedObjectReference using TypeMeta and ObjectMeta fields from an object.
 func FromMetaObjects(clusterID string, typeMeta metav1.TypeMeta, objMeta metav1.ObjectMeta, exportedSince metav1.Time) ExportedObjectReference {
 	return ExportedObjectReference{
-		ClusterID:       clusterID,
-		APIVersion:      typeMeta.APIVersion,
-		Kind:            typeMeta.Kind,
-		Namespace:       objMeta.Namespace,
-		Name:            objMeta.Name,
-		ResourceVersion: objMeta.ResourceVersion,
-		Generation:      objMeta.Generation,
-		UID:             objMeta.UID,
-		NamespacedName:  types.NamespacedName{Namespace: objMeta.Namespace, Name: objMeta.Name}.String(),
-		ExportedSince:   exportedSince,
+		ClusterID:         clusterID,
+		APIVersion:        typeMeta.APIVersion,
+		Kind:              typeMeta.Kind,
+		Namespace:         objMeta.Namespace,
+		Name:              objMeta.Name,
+		ResourceVersion:   objMeta.ResourceVersion,
+		Generation:        objMeta.Generation,
+		UID:               objMeta.UID,
+		NamespacedName:    types.NamespacedName{Namespace: objMeta.Namespace, Name: objMeta.Name}.String(),
+		ExportedSince:     exportedSince,
+		CreationTimestamp: objMeta.CreationTimestamp,
 	}
 }
 
@@ -68,6 +75,28 @@ func (e *ExportedObjectReference) UpdateFromMetaObject(objMeta metav1.ObjectMeta
 	e.ResourceVersion = objMeta.ResourceVersion
 	e.ExportedSince = exportedSince
 	e.Generation = objMeta.Generation
+	// The creation timestamp is set here as well, so that the references created before it was introduced carry it.
+	e.CreationTimestamp = objMeta.CreationTimestamp
+}
+
+// ValidateExportedObjectReference returns an error listing the required fields, i.e. the cluster ID, namespace, name
+// and UID, which are missing from an ExportedObjectReference at the given path; a reference without them cannot
+// identify the source of the exported object.
+func ValidateExportedObjectReference(e *ExportedObjectReference, fldPath *field.Path) error {
+	var allErrs field.ErrorList
+	if e.ClusterID == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("clusterId"), ""))
+	}
+	if e.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("namespace"), ""))
+	}
+	if e.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), ""))
+	}
+	if e.UID == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("uid"), ""))
+	}
+	return allErrs.ToAggregate()
 }
 
 // ClusterID is the ID of a member cluster.
@@ -85,4 +114,25 @@ type ClusterNamespace string
 //     have requested to import the Service.
 type ServiceInUseBy struct {
 	MemberClusters map[ClusterNamespace]ClusterID
+	// IncludeLocalEndpoints marks the member clusters which import the endpoints they export themselves as well.
+	IncludeLocalEndpoints map[ClusterNamespace]bool `json:",omitempty"`
+}
+
+// OpenAPISpecCatalog describes where the member clusters exporting a Service keep the OpenAPI specs of its API.
+// This object is not provided directly as a part of fleet networking API, but provided as a contract for
+// marshaling/unmarshaling ServiceImport annotations, specifically for the InternalServiceExport controller to
+// annotate on a ServiceImport the OpenAPI specs of the unconflicted exports of the Service.
+type OpenAPISpecCatalog struct {
+	// MemberClusters maps the ID of a member cluster to the OpenAPI spec the member cluster exports.
+	MemberClusters map[ClusterID]OpenAPISpecReference
+}
+
+// OpenAPISpecReference describes the OpenAPI spec a member cluster exports.
+type OpenAPISpecReference struct {
+	// ConfigMap is the namespaced name ("namespace/name") of the ConfigMap which keeps the OpenAPI spec in the member
+	// cluster.
+	ConfigMap string
+	// Hash is the SHA256 hash of the data of the ConfigMap, which tells whether the member clusters export the same
+	// spec and when the spec changes.
+	Hash string
 }
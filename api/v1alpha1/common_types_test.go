@@ -0,0 +1,99 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateExportedObjectReference(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mutate     func(e *ExportedObjectReference)
+		wantFields []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(_ *ExportedObjectReference) {},
+		},
+		{
+			name:       "missing cluster ID",
+			mutate:     func(e *ExportedObjectReference) { e.ClusterID = "" },
+			wantFields: []string{"spec.endpointSliceReference.clusterId"},
+		},
+		{
+			name:       "missing namespace",
+			mutate:     func(e *ExportedObjectReference) { e.Namespace = "" },
+			wantFields: []string{"spec.endpointSliceReference.namespace"},
+		},
+		{
+			name:       "missing name",
+			mutate:     func(e *ExportedObjectReference) { e.Name = "" },
+			wantFields: []string{"spec.endpointSliceReference.name"},
+		},
+		{
+			name:       "missing UID",
+			mutate:     func(e *ExportedObjectReference) { e.UID = "" },
+			wantFields: []string{"spec.endpointSliceReference.uid"},
+		},
+		{
+			name:   "optional fields are not required",
+			mutate: func(e *ExportedObjectReference) { e.APIVersion, e.ResourceVersion, e.NamespacedName = "", "", "" },
+		},
+		{
+			name: "all required fields missing",
+			mutate: func(e *ExportedObjectReference) {
+				*e = ExportedObjectReference{}
+			},
+			wantFields: []string{
+				"spec.endpointSliceReference.clusterId", "spec.endpointSliceReference.namespace",
+				"spec.endpointSliceReference.name", "spec.endpointSliceReference.uid",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref := ExportedObjectReference{
+				ClusterID:       "member-1",
+				APIVersion:      "discovery.k8s.io/v1",
+				Kind:            "EndpointSlice",
+				Namespace:       "work",
+				Name:            "app-endpointslice",
+				ResourceVersion: "1",
+				Generation:      1,
+				UID:             "app-endpointslice-uid",
+				NamespacedName:  "work/app-endpointslice",
+			}
+			tc.mutate(&ref)
+			err := ValidateExportedObjectReference(&ref, field.NewPath("spec", "endpointSliceReference"))
+			var gotFields []string
+			if err != nil {
+				var agg utilerrors.Aggregate
+				if !errors.As(err, &agg) {
+					t.Fatalf("ValidateExportedObjectReference() = %v, want an aggregate of field errors", err)
+				}
+				for _, e := range agg.Errors() {
+					var fieldErr *field.Error
+					if !errors.As(e, &fieldErr) || fieldErr.Type != field.ErrorTypeRequired {
+						t.Errorf("ValidateExportedObjectReference() error %v, want a required field error", e)
+						continue
+					}
+					gotFields = append(gotFields, fieldErr.Field)
+				}
+			}
+			if diff := cmp.Diff(tc.wantFields, gotFields, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("ValidateExportedObjectReference() invalid fields mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
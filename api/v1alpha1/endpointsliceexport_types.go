@@ -20,6 +20,22 @@ type Endpoint struct {
 	// +kubebuilder:validation:MinItems:1
 	// +kubebuilder:validation:MaxItems:100
 	Addresses []string `json:"addresses"`
+	// Zone is the name of the zone the Endpoint exists in, as reported by the exported EndpointSlice.
+	// +optional
+	Zone *string `json:"zone,omitempty"`
+	// Hints contains information associated with how the Endpoint should be consumed, e.g. for topology aware
+	// routing, as reported by the exported EndpointSlice.
+	// +optional
+	Hints *discoveryv1.EndpointHints `json:"hints,omitempty"`
+	// IsFQDN is true if the addresses of the Endpoint are fully qualified domain names rather than IP addresses,
+	// i.e. the Endpoint is exported from an EndpointSlice of the FQDN address type; importing clusters may front
+	// such Endpoints with an ExternalName Service instead of a ClusterIP Service.
+	// +optional
+	IsFQDN bool `json:"isFQDN,omitempty"`
+	// Ready is false if the Endpoint is exported while it is not ready, which happens only when its Service is
+	// exported with the not-ready addresses; an unset value means the Endpoint is ready.
+	// +optional
+	Ready *bool `json:"ready,omitempty"`
 }
 
 // OwnerServiceReference points to the Service that owns the exported EndpointSlice.
@@ -38,11 +54,13 @@ type OwnerServiceReference struct {
 // EndpointSliceExportSpec specifies the spec of an exported EndpointSlice.
 type EndpointSliceExportSpec struct {
 	// The type of addresses carried by this EndpointSliceExport.
-	// At this stage only IPv4 addresses are supported.
-	// +kubebuilder:validation:Enum:="IPv4"
+	// At this stage only IPv4 addresses and FQDNs are supported.
+	// +kubebuilder:validation:Enum:="IPv4";"FQDN"
 	// +kubebuilder:default:="IPv4"
 	AddressType discoveryv1.AddressType `json:"addressType"`
 	// A list of unique endpoints in the exported EndpointSlice.
+	// The list is empty, rather than the EndpointSliceExport withdrawn, when the EndpointSlice has no endpoints,
+	// e.g. for a Service scaled to zero, so that the importing clusters converge to zero endpoints.
 	// +kubebuilder:validation:Required
 	// +listType=atomic
 	Endpoints []Endpoint `json:"endpoints"`
@@ -0,0 +1,127 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package v1alpha1
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+const (
+	FleetServiceNetworkingStatusKind = "FleetServiceNetworkingStatus"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,categories={fleet-networking},shortName=fsns
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:JSONPath=`.status.exportingClusterCount`,name="Exporting",type=integer
+// +kubebuilder:printcolumn:JSONPath=`.status.importingClusterCount`,name="Importing",type=integer
+// +kubebuilder:printcolumn:JSONPath=`.status.conflicted`,name="Conflicted",type=boolean
+// +kubebuilder:printcolumn:JSONPath=`.status.trafficManagerHealthy`,name="Traffic-Manager-Healthy",type=boolean
+// +kubebuilder:printcolumn:JSONPath=`.metadata.creationTimestamp`,name="Age",type=date
+
+// FleetServiceNetworkingStatus summarizes the health of the networking pipeline of a Service exported to the fleet.
+// There is one FleetServiceNetworkingStatus per exported Service on the hub cluster, with the same namespace and name
+// as its ServiceImport.
+//
+// It is maintained by the hub networking controller manager and is rebuilt from ServiceImports,
+// InternalServiceExports, EndpointSliceExports, InternalServiceImports and TrafficManagerBackends; it never holds
+// authoritative state and can be safely deleted at any time.
+type FleetServiceNetworkingStatus struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The observed status of the networking pipeline of the Service.
+	// +optional
+	Status ServiceNetworkingStatus `json:"status,omitempty"`
+}
+
+// ServiceNetworkingStatus describes the observed status of the networking pipeline of an exported Service.
+type ServiceNetworkingStatus struct {
+	// ExportingClusters is the sorted list of clusters which export the Service.
+	// +listType=set
+	// +optional
+	ExportingClusters []string `json:"exportingClusters,omitempty"`
+
+	// ExportingClusterCount is the number of clusters which export the Service.
+	// +optional
+	ExportingClusterCount int32 `json:"exportingClusterCount"`
+
+	// ConflictedClusters is the sorted list of exporting clusters whose Service spec conflicts with the Service
+	// exported from other clusters.
+	// +listType=set
+	// +optional
+	ConflictedClusters []string `json:"conflictedClusters,omitempty"`
+
+	// Conflicted is true if the Service exported from any cluster is in conflict.
+	// +optional
+	Conflicted bool `json:"conflicted"`
+
+	// ServiceImportResolved is true if the ServiceImport of the Service exists and its spec has been resolved.
+	// +optional
+	ServiceImportResolved bool `json:"serviceImportResolved"`
+
+	// ImportingClusters is the sorted list of clusters which import the Service.
+	// +listType=set
+	// +optional
+	ImportingClusters []string `json:"importingClusters,omitempty"`
+
+	// ImportingClusterCount is the number of clusters which import the Service.
+	// +optional
+	ImportingClusterCount int32 `json:"importingClusterCount"`
+
+	// EndpointSliceExportCount is the number of EndpointSlices exported for the Service from all the clusters.
+	// +optional
+	EndpointSliceExportCount int32 `json:"endpointSliceExportCount"`
+
+	// EndpointsLastChangedTime is the last time when any exported EndpointSlice of the Service changed, as
+	// reported by the exporting clusters.
+	// +optional
+	EndpointsLastChangedTime *metav1.Time `json:"endpointsLastChangedTime,omitempty"`
+
+	// TrafficManagerBackends summarizes the TrafficManagerBackends which front the Service.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	TrafficManagerBackends []TrafficManagerBackendSummary `json:"trafficManagerBackends,omitempty"`
+
+	// TrafficManagerHealthy is true if all the TrafficManagerBackends which front the Service have been accepted and
+	// have endpoints; it is not set if no TrafficManagerBackend fronts the Service.
+	// +optional
+	TrafficManagerHealthy *bool `json:"trafficManagerHealthy,omitempty"`
+}
+
+// TrafficManagerBackendSummary summarizes a TrafficManagerBackend which fronts an exported Service.
+type TrafficManagerBackendSummary struct {
+	// Name of the TrafficManagerBackend.
+	// +required
+	Name string `json:"name"`
+
+	// Profile is the name of the TrafficManagerProfile the backend is attached to.
+	// +optional
+	Profile string `json:"profile,omitempty"`
+
+	// Accepted is the status of the Accepted condition of the TrafficManagerBackend for its current generation;
+	// it is Unknown if the condition has not been reported yet.
+	// +optional
+	Accepted metav1.ConditionStatus `json:"accepted,omitempty"`
+
+	// EndpointCount is the number of Azure Traffic Manager endpoints accepted for the backend.
+	// +optional
+	EndpointCount int32 `json:"endpointCount"`
+}
+
+//+kubebuilder:object:root=true
+
+// FleetServiceNetworkingStatusList contains a list of FleetServiceNetworkingStatus.
+type FleetServiceNetworkingStatusList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FleetServiceNetworkingStatus `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FleetServiceNetworkingStatus{}, &FleetServiceNetworkingStatusList{})
+}
@@ -12,6 +12,7 @@ import (
 
 // InternalServiceExportSpec specifies the spec of an exported Service; at this stage only the ports of an
 // exported Service are sync'd.
+// +kubebuilder:validation:XValidation:rule="!has(self.sessionAffinity) || self.sessionAffinity != 'ClientIP' || !has(self.sessionAffinityConfig) || !has(self.sessionAffinityConfig.clientIP) || !has(self.sessionAffinityConfig.clientIP.timeoutSeconds) || (self.sessionAffinityConfig.clientIP.timeoutSeconds >= 1 && self.sessionAffinityConfig.clientIP.timeoutSeconds <= 86400)",message="sessionAffinityConfig.clientIP.timeoutSeconds must be between 1 and 86400 for the ClientIP session affinity"
 type InternalServiceExportSpec struct {
 	// A list of ports exposed by the exported Service.
 	// +listType=atomic
@@ -36,6 +37,19 @@ type InternalServiceExportSpec struct {
 	// If unspecified, weight defaults to 1.
 	// The value is from serviceExport "networking.fleet.azure.com/weight" annotation and should be in the range [0, 1000].
 	Weight *int64 `json:"weight,omitempty"`
+	// SessionAffinity is the session affinity of the Service, which makes the requests from the same client IP
+	// address go to the same endpoint when set to ClientIP.
+	// +optional
+	// +kubebuilder:validation:Enum=ClientIP;None
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// SessionAffinityConfig contains the configurations of the session affinity of the Service.
+	// +optional
+	SessionAffinityConfig *corev1.SessionAffinityConfig `json:"sessionAffinityConfig,omitempty"`
+	// ImportType is the type of the ServiceImport the Service is imported as: Headless if the Service has no cluster
+	// IP, and ClusterSetIP otherwise. Exports which do not report it, e.g. from older agents, are ClusterSetIP.
+	// +optional
+	// +kubebuilder:validation:Enum=ClusterSetIP;Headless
+	ImportType ServiceImportType `json:"importType,omitempty"`
 }
 
 // InternalServiceExportStatus contains the current status of an InternalServiceExport.
@@ -46,6 +60,12 @@ type InternalServiceExportStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// InUseBy lists the IDs of the member clusters which import the exported Service, e.g. with a
+	// MultiClusterService, so that the member cluster exporting the Service can tell whether unexporting it disrupts
+	// the traffic of others. It is maintained by the hub cluster.
+	// +optional
+	// +listType=set
+	InUseBy []ClusterID `json:"inUseBy,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -35,6 +35,11 @@ type InternalServiceImportSpec struct {
 	// The reference to the source ServiceImport.
 	// +kubebuilder:validation:Required
 	ServiceImportReference ExportedObjectReference `json:"serviceImportReference"`
+
+	// IncludeLocalEndpoints imports the endpoints the importing member cluster exports itself; it is set from the
+	// MultiClusterService which imports the Service.
+	// +optional
+	IncludeLocalEndpoints bool `json:"includeLocalEndpoints,omitempty"`
 }
 
 // +kubebuilder:object:root=true
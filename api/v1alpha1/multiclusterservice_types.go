@@ -14,6 +14,26 @@ import (
 type MultiClusterServiceSpec struct {
 	// ServiceImport is the reference to the Service with the same name exported in the member clusters.
 	ServiceImport ServiceImportRef `json:"serviceImport,omitempty"`
+
+	// ReadinessPolicy is the policy deciding when the multi-cluster service is ready; the Ready condition is not
+	// reported if it is not set.
+	// +optional
+	ReadinessPolicy *ReadinessPolicy `json:"readinessPolicy,omitempty"`
+
+	// IncludeLocalEndpoints imports the endpoints the importing member cluster exports itself, along with those of
+	// the other member clusters; by default they are excluded, as the importing cluster reaches them directly.
+	// +optional
+	IncludeLocalEndpoints bool `json:"includeLocalEndpoints,omitempty"`
+}
+
+// ReadinessPolicy decides when a multi-cluster service is ready.
+type ReadinessPolicy struct {
+	// MinReadyClusters is the minimum number of member clusters which must export the Service without conflicts for
+	// the multi-cluster service to be ready.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	MinReadyClusters int32 `json:"minReadyClusters"`
 }
 
 // ServiceImportRef is the reference to the ServiceImport. To consume multi-cluster service, users are expected to use
@@ -52,6 +72,15 @@ const (
 	// multi-cluster service and its configurations have been recognized as valid by a mcs-controller.
 	// This will be false if the ServiceImport is not found in the hub cluster.
 	MultiClusterServiceValid MultiClusterServiceConditionType = "Valid"
+
+	// MultiClusterServiceEndpointsReady means that at least one ready endpoint has been imported for the Service
+	// referenced by this multi-cluster service, across all the clusters exporting it, i.e. traffic can flow. Its
+	// message tells how many of the imported endpoints are ready.
+	MultiClusterServiceEndpointsReady MultiClusterServiceConditionType = "EndpointsReady"
+
+	// MultiClusterServiceReady means that the readiness policy of this multi-cluster service is satisfied, i.e.
+	// enough member clusters export the Service without conflicts. It is only reported if the policy is set.
+	MultiClusterServiceReady MultiClusterServiceConditionType = "Ready"
 )
 
 // +kubebuilder:object:root=true
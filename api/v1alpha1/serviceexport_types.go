@@ -21,8 +21,53 @@ const (
 	// field(s) under contention, which cluster won, and why.
 	// Users should not expect detailed per-cluster information in the conflict message.
 	ServiceExportConflict ServiceExportConditionType = "Conflict"
+	// ServiceExportFeatureUnavailableOnHub means that a feature enabled in the member agent cannot apply to the
+	// export, as the CRDs served by the hub cluster are older than the member agent expects.
+	// When "True", the condition message lists the fields missing from the hub CRDs.
+	ServiceExportFeatureUnavailableOnHub ServiceExportConditionType = "FeatureUnavailableOnHub"
+	// ServiceExportSuspended means that the export of the Service has been suspended with the suspend annotation;
+	// the Service is withdrawn from the fleet, while the ServiceExport is kept so that removing the annotation
+	// resumes the export.
+	ServiceExportSuspended ServiceExportConditionType = "Suspended"
+	// ServiceExportFreshnessDegraded means that the endpoint changes of the exported Service take longer than the
+	// freshness objective of the fleet to become visible across the fleet.
+	// When "True", the condition message contains the measured lag.
+	ServiceExportFreshnessDegraded ServiceExportConditionType = "FreshnessDegraded"
+	// ServiceExportHighEndpointChurn means that the endpoints of the exported Service change so often that their
+	// exports are coalesced and propagated to the fleet on a longer interval, rather than as each change happens.
+	// When "True", the condition message contains the number of changes observed and the coalescing interval.
+	ServiceExportHighEndpointChurn ServiceExportConditionType = "HighEndpointChurn"
+	// ServiceExportHubFieldRejected means that the hub cluster rejects the exports of the Service, as they carry
+	// fields its CRDs do not accept.
+	// When "True", the condition message names the kind of the exports rejected and the offending fields.
+	ServiceExportHubFieldRejected ServiceExportConditionType = "HubFieldRejected"
+	// ServiceExportWouldConflict means that the export of the Service conflicts with the exports of the same service
+	// from other clusters. It is advisory: unlike ServiceExportConflict, it is reported for the exports merged into
+	// the imported service as well, and the export proceeds regardless. The condition is removed once the conflicts
+	// are resolved.
+	// When "True", the condition message lists the clusters whose exports are on the other side of the conflict.
+	ServiceExportWouldConflict ServiceExportConditionType = "WouldConflict"
 )
 
+// ServiceExportSpec specifies how a Service is exported.
+type ServiceExportSpec struct {
+	// Version is the version of the exported Service, e.g. "v2", which allows a cluster to export multiple versions
+	// of a service side by side. The version is appended to the name of the export in the hub cluster and is added
+	// to it as the service version label. The field is immutable.
+	// +optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Version string `json:"version,omitempty"`
+
+	// IncludeOnlyEndpointsWithLabels restricts the endpoints exported to the fleet to those whose Pods match the
+	// selector, e.g. to export only the Pods of one of the Deployments a Service selects; the Service itself keeps
+	// serving all its endpoints in the member cluster. Endpoints which do not reference a Pod are not exported when
+	// it is set. All the endpoints are exported if it is not set.
+	// The member agent must enable the endpoint selectors; no endpoint is exported otherwise.
+	// +optional
+	IncludeOnlyEndpointsWithLabels *metav1.LabelSelector `json:"includeOnlyEndpointsWithLabels,omitempty"`
+}
+
 // ServiceExportStatus contains the current status of an export.
 type ServiceExportStatus struct {
 	// +optional
@@ -49,11 +94,14 @@ type ServiceExportStatus struct {
 // The value should be in the range [0, 1000].
 // Any invalid value will default to default value.
 // +kubebuilder:validation:XValidation:rule="size(self.metadata.name) < 64",message="metadata.name max length is 63"
+// +kubebuilder:validation:XValidation:rule="has(self.spec) && has(self.spec.version) ? has(oldSelf.spec) && has(oldSelf.spec.version) && self.spec.version == oldSelf.spec.version : !has(oldSelf.spec) || !has(oldSelf.spec.version)",message="spec.version is immutable"
 type ServiceExport struct {
 	metav1.TypeMeta `json:",inline"`
 	// +optional
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	// +optional
+	Spec ServiceExportSpec `json:"spec,omitempty"`
+	// +optional
 	Status ServiceExportStatus `json:"status,omitempty"`
 }
 
@@ -11,6 +11,10 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	ServiceImportKind = "ServiceImport"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Namespaced,categories={fleet-networking},shortName=svcimport
 // +kubebuilder:subresource:status
@@ -107,19 +111,38 @@ type ServiceImportStatus struct {
 	// +optional
 	SessionAffinityConfig *corev1.SessionAffinityConfig `json:"sessionAffinityConfig,omitempty"`
 
+	// ports are the ports merged from the exporting clusters, keyed by port and protocol and sorted by them.
 	// +listType=atomic
 	// +optional
 	Ports []ServicePort `json:"ports,omitempty"`
 
-	// clusters is the list of exporting clusters from which this service was derived.
+	// clusters is the list of exporting clusters from which this service was derived, sorted by cluster name.
 	// +optional
 	// +patchStrategy=merge
 	// +patchMergeKey=cluster
 	// +listType=map
 	// +listMapKey=cluster
 	Clusters []ClusterStatus `json:"clusters,omitempty"`
+
+	// conditions are the current conditions of the imported service, e.g. whether it has endpoints available.
+	// +optional
+	// +patchStrategy=merge
+	// +patchMergeKey=type
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
+// ServiceImportConditionType identifies a specific condition.
+type ServiceImportConditionType string
+
+const (
+	// ServiceImportEndpointsAvailable means that the EndpointSlices exported for the service have at least one ready
+	// endpoint across the exporting clusters. It is false for a service scaled to zero, whose EndpointSlices are
+	// still exported, without endpoints, so that the importing clusters converge to zero endpoints.
+	ServiceImportEndpointsAvailable ServiceImportConditionType = "EndpointsAvailable"
+)
+
 // ClusterStatus contains service configuration mapped to a specific source cluster.
 type ClusterStatus struct {
 	// cluster is the name of the exporting cluster. Must be a valid RFC-1123 DNS label.
@@ -58,8 +58,8 @@ type MonitorConfig struct {
 	IntervalInSeconds *int64 `json:"intervalInSeconds,omitempty"`
 
 	// The path relative to the endpoint domain name used to probe for endpoint health.
+	// It defaults to "/" if the protocol is HTTP or HTTPS, and is cleared if the protocol is TCP, which does not use it.
 	// +optional
-	// +kubebuilder:default="/"
 	Path *string `json:"path,omitempty"`
 
 	// The TCP port used to probe for endpoint health.
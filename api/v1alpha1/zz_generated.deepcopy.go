@@ -39,6 +39,21 @@ func (in *Endpoint) DeepCopyInto(out *Endpoint) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Zone != nil {
+		in, out := &in.Zone, &out.Zone
+		*out = new(string)
+		**out = **in
+	}
+	if in.Hints != nil {
+		in, out := &in.Hints, &out.Hints
+		*out = new(v1.EndpointHints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ready != nil {
+		in, out := &in.Ready, &out.Ready
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Endpoint.
@@ -202,6 +217,7 @@ func (in *EndpointSliceImportList) DeepCopyObject() runtime.Object {
 func (in *ExportedObjectReference) DeepCopyInto(out *ExportedObjectReference) {
 	*out = *in
 	in.ExportedSince.DeepCopyInto(&out.ExportedSince)
+	in.CreationTimestamp.DeepCopyInto(&out.CreationTimestamp)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedObjectReference.
@@ -235,6 +251,64 @@ func (in *FromCluster) DeepCopy() *FromCluster {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetServiceNetworkingStatus) DeepCopyInto(out *FleetServiceNetworkingStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetServiceNetworkingStatus.
+func (in *FleetServiceNetworkingStatus) DeepCopy() *FleetServiceNetworkingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetServiceNetworkingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FleetServiceNetworkingStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetServiceNetworkingStatusList) DeepCopyInto(out *FleetServiceNetworkingStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FleetServiceNetworkingStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetServiceNetworkingStatusList.
+func (in *FleetServiceNetworkingStatusList) DeepCopy() *FleetServiceNetworkingStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetServiceNetworkingStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FleetServiceNetworkingStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *InternalServiceExport) DeepCopyInto(out *InternalServiceExport) {
 	*out = *in
@@ -315,6 +389,11 @@ func (in *InternalServiceExportSpec) DeepCopyInto(out *InternalServiceExportSpec
 		*out = new(int64)
 		**out = **in
 	}
+	if in.SessionAffinityConfig != nil {
+		in, out := &in.SessionAffinityConfig, &out.SessionAffinityConfig
+		*out = new(corev1.SessionAffinityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternalServiceExportSpec.
@@ -337,6 +416,11 @@ func (in *InternalServiceExportStatus) DeepCopyInto(out *InternalServiceExportSt
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.InUseBy != nil {
+		in, out := &in.InUseBy, &out.InUseBy
+		*out = make([]ClusterID, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InternalServiceExportStatus.
@@ -474,7 +558,7 @@ func (in *MultiClusterService) DeepCopyInto(out *MultiClusterService) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -532,6 +616,11 @@ func (in *MultiClusterServiceList) DeepCopyObject() runtime.Object {
 func (in *MultiClusterServiceSpec) DeepCopyInto(out *MultiClusterServiceSpec) {
 	*out = *in
 	out.ServiceImport = in.ServiceImport
+	if in.ReadinessPolicy != nil {
+		in, out := &in.ReadinessPolicy, &out.ReadinessPolicy
+		*out = new(ReadinessPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterServiceSpec.
@@ -567,6 +656,43 @@ func (in *MultiClusterServiceStatus) DeepCopy() *MultiClusterServiceStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenAPISpecCatalog) DeepCopyInto(out *OpenAPISpecCatalog) {
+	*out = *in
+	if in.MemberClusters != nil {
+		in, out := &in.MemberClusters, &out.MemberClusters
+		*out = make(map[ClusterID]OpenAPISpecReference, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenAPISpecCatalog.
+func (in *OpenAPISpecCatalog) DeepCopy() *OpenAPISpecCatalog {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenAPISpecCatalog)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpenAPISpecReference) DeepCopyInto(out *OpenAPISpecReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenAPISpecReference.
+func (in *OpenAPISpecReference) DeepCopy() *OpenAPISpecReference {
+	if in == nil {
+		return nil
+	}
+	out := new(OpenAPISpecReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OwnerServiceReference) DeepCopyInto(out *OwnerServiceReference) {
 	*out = *in
@@ -582,11 +708,27 @@ func (in *OwnerServiceReference) DeepCopy() *OwnerServiceReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReadinessPolicy) DeepCopyInto(out *ReadinessPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessPolicy.
+func (in *ReadinessPolicy) DeepCopy() *ReadinessPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ReadinessPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceExport) DeepCopyInto(out *ServiceExport) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -640,6 +782,26 @@ func (in *ServiceExportList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceExportSpec) DeepCopyInto(out *ServiceExportSpec) {
+	*out = *in
+	if in.IncludeOnlyEndpointsWithLabels != nil {
+		in, out := &in.IncludeOnlyEndpointsWithLabels, &out.IncludeOnlyEndpointsWithLabels
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceExportSpec.
+func (in *ServiceExportSpec) DeepCopy() *ServiceExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceExportStatus) DeepCopyInto(out *ServiceExportStatus) {
 	*out = *in
@@ -760,6 +922,13 @@ func (in *ServiceImportStatus) DeepCopyInto(out *ServiceImportStatus) {
 		*out = make([]ClusterStatus, len(*in))
 		copy(*out, *in)
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceImportStatus.
@@ -782,6 +951,13 @@ func (in *ServiceInUseBy) DeepCopyInto(out *ServiceInUseBy) {
 			(*out)[key] = val
 		}
 	}
+	if in.IncludeLocalEndpoints != nil {
+		in, out := &in.IncludeLocalEndpoints, &out.IncludeLocalEndpoints
+		*out = make(map[ClusterNamespace]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceInUseBy.
@@ -794,6 +970,50 @@ func (in *ServiceInUseBy) DeepCopy() *ServiceInUseBy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceNetworkingStatus) DeepCopyInto(out *ServiceNetworkingStatus) {
+	*out = *in
+	if in.ExportingClusters != nil {
+		in, out := &in.ExportingClusters, &out.ExportingClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConflictedClusters != nil {
+		in, out := &in.ConflictedClusters, &out.ConflictedClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImportingClusters != nil {
+		in, out := &in.ImportingClusters, &out.ImportingClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EndpointsLastChangedTime != nil {
+		in, out := &in.EndpointsLastChangedTime, &out.EndpointsLastChangedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.TrafficManagerBackends != nil {
+		in, out := &in.TrafficManagerBackends, &out.TrafficManagerBackends
+		*out = make([]TrafficManagerBackendSummary, len(*in))
+		copy(*out, *in)
+	}
+	if in.TrafficManagerHealthy != nil {
+		in, out := &in.TrafficManagerHealthy, &out.TrafficManagerHealthy
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceNetworkingStatus.
+func (in *ServiceNetworkingStatus) DeepCopy() *ServiceNetworkingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceNetworkingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServicePort) DeepCopyInto(out *ServicePort) {
 	*out = *in
@@ -940,6 +1160,21 @@ func (in *TrafficManagerBackendStatus) DeepCopy() *TrafficManagerBackendStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficManagerBackendSummary) DeepCopyInto(out *TrafficManagerBackendSummary) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficManagerBackendSummary.
+func (in *TrafficManagerBackendSummary) DeepCopy() *TrafficManagerBackendSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficManagerBackendSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficManagerEndpointStatus) DeepCopyInto(out *TrafficManagerEndpointStatus) {
 	*out = *in
@@ -19,6 +19,7 @@ const (
 // +kubebuilder:storageversion
 // +kubebuilder:printcolumn:JSONPath=`.status.dnsName`,name="DNS-Name",type=string
 // +kubebuilder:printcolumn:JSONPath=`.status.conditions[?(@.type=='Programmed')].status`,name="Is-Programmed",type=string
+// +kubebuilder:printcolumn:JSONPath=`.status.healthyEndpoints`,name="Healthy-Endpoints",type=integer
 // +kubebuilder:printcolumn:JSONPath=`.metadata.creationTimestamp`,name="Age",type=date
 
 // TrafficManagerProfile is used to manage a simple Azure Traffic Manager Profile using cloud native way.
@@ -38,7 +39,12 @@ type TrafficManagerProfile struct {
 }
 
 // TrafficManagerProfileSpec defines the desired state of TrafficManagerProfile.
-// For now, only the "Weighted" traffic routing method is supported.
+// For now, only the "Weighted", "Subnet" and "Priority" traffic routing methods are supported.
+// +kubebuilder:validation:XValidation:rule="(has(self.routingMethod) && self.routingMethod == 'Subnet') == (has(self.subnetConfig) && size(self.subnetConfig) > 0)",message="subnetConfig must be set if and only if routingMethod is Subnet"
+// +kubebuilder:validation:XValidation:rule="(has(self.routingMethod) && self.routingMethod == 'Priority') == (has(self.priorityConfig) && size(self.priorityConfig) > 0)",message="priorityConfig must be set if and only if routingMethod is Priority"
+// +kubebuilder:validation:XValidation:rule="!has(self.slaMonitorURL) || (has(self.routingMethod) && self.routingMethod == 'Priority')",message="slaMonitorURL can only be set when routingMethod is Priority"
+// +kubebuilder:validation:XValidation:rule="!has(self.ddosPlanResourceID) || (has(self.ddosProtectionEnabled) && self.ddosProtectionEnabled)",message="ddosPlanResourceID can only be set when ddosProtectionEnabled is true"
+// +kubebuilder:validation:XValidation:rule="(has(self.dnsConfig) && has(self.dnsConfig.relativeName)) == (has(oldSelf.dnsConfig) && has(oldSelf.dnsConfig.relativeName))",message="dnsConfig.relativeName cannot be added or removed"
 type TrafficManagerProfileSpec struct {
 	// The name of the resource group to contain the Azure Traffic Manager resource corresponding to this profile.
 	// When this profile is created, updated, or deleted, the corresponding traffic manager with the same name will be created, updated, or deleted
@@ -50,6 +56,185 @@ type TrafficManagerProfileSpec struct {
 	// The endpoint monitoring settings of the Traffic Manager profile.
 	// +optional
 	MonitorConfig *MonitorConfig `json:"monitorConfig,omitempty"`
+
+	// RoutingMethod is the traffic routing method of the Traffic Manager profile: "Weighted" distributes the traffic
+	// across the endpoints by their weights, "Subnet" maps the client IP address ranges to the endpoints as per
+	// SubnetConfig, and "Priority" sends the traffic to the healthy endpoint of the highest priority as per
+	// PriorityConfig.
+	// Changing the routing method recreates the Azure Traffic Manager profile, and so its endpoints.
+	// https://learn.microsoft.com/en-us/azure/traffic-manager/traffic-manager-routing-methods
+	// +optional
+	// +kubebuilder:default=Weighted
+	// +kubebuilder:validation:Enum=Weighted;Subnet;Priority
+	RoutingMethod *TrafficRoutingMethod `json:"routingMethod,omitempty"`
+
+	// SubnetConfig maps the client IP address ranges to the endpoints of the Traffic Manager profile. It is required
+	// when the routing method is "Subnet", and must not be set otherwise.
+	// The DNS queries from the clients whose IP addresses are not mapped to any endpoint get no answer.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	SubnetConfig []SubnetRoutingRule `json:"subnetConfig,omitempty"`
+
+	// PriorityConfig assigns the priorities of the endpoints of the Traffic Manager profile. It is required when the
+	// routing method is "Priority", and must not be set otherwise.
+	// Azure rejects the endpoints of a profile using the Priority routing method which are not assigned a priority.
+	// +optional
+	// +kubebuilder:validation:MaxItems=100
+	// +kubebuilder:validation:XValidation:rule="self.all(x, self.exists_one(y, y.endpointName.lowerAscii() == x.endpointName.lowerAscii()))",message="endpointName must be unique"
+	// +kubebuilder:validation:XValidation:rule="self.all(x, self.exists_one(y, y.priority == x.priority))",message="priority must be unique"
+	PriorityConfig []EndpointPriority `json:"priorityConfig,omitempty"`
+
+	// SLAMonitorURL is the URL of the external SLA monitoring system which reports the availability of the endpoints
+	// of the Traffic Manager profile; it can only be set when the routing method is "Priority".
+	// When set, the endpoints whose availability drops below the DemotionThresholdBasisPoints are demoted to a lower
+	// priority band, rather than removed from the profile, and get their priorities back once their availability
+	// recovers.
+	//
+	// The URL is queried with a GET request, and must respond with a JSON body listing the availability of the
+	// endpoints, named as reported in the status of the TrafficManagerBackends which create them, as a ratio, e.g.
+	// {"items": [{"endpoint": "<endpoint-name>", "availability": 0.995}]}; the endpoints which are not reported keep
+	// their current priority band.
+	// Its host must be one of the metrics hosts the hub agent allows (--traffic-manager-metrics-allowed-hosts);
+	// otherwise it is never queried. Redirects are not followed.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="isURL(self) && (self.startsWith('http://') || self.startsWith('https://'))",message="slaMonitorURL must be an http or https URL"
+	SLAMonitorURL string `json:"slaMonitorURL,omitempty"`
+
+	// DemotionThresholdBasisPoints is the availability, in basis points (e.g. 9900 = 99% availability), below which
+	// an endpoint is demoted to a lower priority band; 0 never demotes any endpoint. It defaults to 9900 when the
+	// SLAMonitorURL is set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10000
+	DemotionThresholdBasisPoints *int64 `json:"demotionThresholdBasisPoints,omitempty"`
+
+	// DDoSProtectionEnabled enables the Azure DDoS Protection on the public IP addresses behind the endpoints of
+	// the Traffic Manager profile. Disabling it does not change the DDoS protection settings of the public IP addresses.
+	// https://learn.microsoft.com/en-us/azure/ddos-protection/ddos-protection-overview
+	// +optional
+	DDoSProtectionEnabled *bool `json:"ddosProtectionEnabled,omitempty"`
+
+	// DDoSPlanResourceID is the resource ID of the Azure DDoS protection plan associated with the public IP addresses
+	// behind the endpoints of the Traffic Manager profile.
+	// When DDoSProtectionEnabled is true and the plan is not specified, the public IP addresses are protected by the
+	// DDoS IP protection.
+	// +optional
+	DDoSPlanResourceID *string `json:"ddosPlanResourceID,omitempty"`
+
+	// The DNS settings of the Traffic Manager profile.
+	// +optional
+	DNSConfig *DNSConfig `json:"dnsConfig,omitempty"`
+
+	// AutoWeightSource configures the source of the cluster load metrics from which the weights of the endpoints of
+	// the Traffic Manager profile are derived. When set, each endpoint is weighted by the available capacity of its
+	// cluster instead of the weight of its exported service; services exported with a weight of 0 still get no endpoint.
+	// The weights of the exported services are used while the capacity of any of the clusters cannot be queried.
+	// +optional
+	AutoWeightSource *AutoWeightSourceConfig `json:"autoWeightSource,omitempty"`
+
+	// AlertRuleConfig configures an Azure Monitor metric alert rule which fires when none of the endpoints of the
+	// Traffic Manager profile is online. Removing it deletes the alert rule.
+	// https://learn.microsoft.com/en-us/azure/traffic-manager/traffic-manager-metrics-alerts
+	// +optional
+	AlertRuleConfig *AlertRuleConfig `json:"alertRuleConfig,omitempty"`
+}
+
+// AlertRuleConfig defines the Azure Monitor metric alert rule of the Traffic Manager profile.
+type AlertRuleConfig struct {
+	// ActionGroupResourceID is the resource ID of the Azure Monitor action group notified when the alert rule fires
+	// and when it is resolved.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	ActionGroupResourceID string `json:"actionGroupResourceID"`
+
+	// SeverityLevel is the severity of the alerts, from 0 (critical) to 4 (verbose).
+	// +required
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4
+	SeverityLevel int32 `json:"severityLevel"`
+}
+
+// TrafficRoutingMethod defines the traffic routing method of the Traffic Manager profile.
+type TrafficRoutingMethod string
+
+const (
+	TrafficRoutingMethodWeighted TrafficRoutingMethod = "Weighted"
+	TrafficRoutingMethodSubnet   TrafficRoutingMethod = "Subnet"
+	TrafficRoutingMethodPriority TrafficRoutingMethod = "Priority"
+)
+
+const (
+	// MaxEndpointPriority is the lowest priority which can be assigned to an endpoint; the priorities above it, up
+	// to the lowest one accepted by Azure, form the band the endpoints are demoted to on SLA breach.
+	MaxEndpointPriority = 500
+)
+
+// EndpointPriority assigns the priority of an endpoint of the Traffic Manager profile.
+type EndpointPriority struct {
+	// EndpointName is the name of the Azure Traffic Manager endpoint, as reported in the status of the
+	// TrafficManagerBackend which creates it.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	EndpointName string `json:"endpointName"`
+
+	// Priority is the priority of the endpoint, from 1 (the highest) to 500; the endpoints demoted on SLA breach get
+	// their priority plus 500.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=500
+	Priority int64 `json:"priority"`
+}
+
+// SubnetRoutingRule maps a range of client IP addresses to an endpoint of the Traffic Manager profile.
+type SubnetRoutingRule struct {
+	// CIDR is the range of the client IP addresses, e.g. "10.1.0.0/16", whose DNS queries are answered with the
+	// endpoint.
+	// +required
+	// +kubebuilder:validation:Format=cidr
+	CIDR string `json:"cidr"`
+
+	// EndpointName is the name of the Azure Traffic Manager endpoint, as reported in the status of the
+	// TrafficManagerBackend which creates it.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	EndpointName string `json:"endpointName"`
+}
+
+// DNSConfig defines the DNS settings of the Traffic Manager profile.
+type DNSConfig struct {
+	// RelativeName is the relative DNS name of the Traffic Manager profile, which is combined with the DNS domain name
+	// used by Azure Traffic Manager to form the fully-qualified domain name (FQDN) of the profile, e.g.
+	// "<RelativeName>.trafficmanager.net"; it must be unique across Azure Traffic Manager.
+	// It defaults to "<TrafficManagerProfileNamespace>-<TrafficManagerProfileName>", and cannot be changed after the
+	// profile is created.
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?$`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="relativeName is immutable"
+	RelativeName *string `json:"relativeName,omitempty"`
+}
+
+// AutoWeightSourceConfig defines where to query the available capacity of the member clusters.
+//
+// The metrics endpoint is queried with a GET request with the metric name as the "metricName" query parameter, and
+// must respond with a JSON body listing the available capacity of each cluster as a percentage, e.g.
+// (allocatable_CPU - requested_CPU) / total_allocatable_CPU * 100:
+//
+//	{"items": [{"cluster": "member-1", "value": 42.5}, {"cluster": "member-2", "value": 10}]}
+type AutoWeightSourceConfig struct {
+	// MetricsEndpoint is the URL of the Kubernetes Metrics Server aggregation or custom metrics adapter which reports
+	// the available capacity of the member clusters.
+	// Its host must be one of the metrics hosts the hub agent allows (--traffic-manager-metrics-allowed-hosts);
+	// otherwise it is never queried. Redirects are not followed.
+	// +required
+	// +kubebuilder:validation:XValidation:rule="isURL(self) && (self.startsWith('http://') || self.startsWith('https://'))",message="metricsEndpoint must be an http or https URL"
+	MetricsEndpoint string `json:"metricsEndpoint"`
+
+	// MetricName is the name of the metric which reports the available capacity of a cluster.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	MetricName string `json:"metricName"`
 }
 
 // MonitorConfig defines the endpoint monitoring settings of the Traffic Manager profile.
@@ -64,8 +249,8 @@ type MonitorConfig struct {
 	IntervalInSeconds *int64 `json:"intervalInSeconds,omitempty"`
 
 	// The path relative to the endpoint domain name used to probe for endpoint health.
+	// It defaults to "/" if the protocol is HTTP or HTTPS, and is cleared if the protocol is TCP, which does not use it.
 	// +optional
-	// +kubebuilder:default="/"
 	Path *string `json:"path,omitempty"`
 
 	// The TCP port used to probe for endpoint health.
@@ -110,8 +295,8 @@ const (
 
 type TrafficManagerProfileStatus struct {
 	// DNSName is the fully-qualified domain name (FQDN) of the Traffic Manager profile.
-	// It consists of profile name and the DNS domain name used by Azure Traffic Manager to form the fully-qualified
-	// domain name (FQDN) of the profile.
+	// It consists of the relative DNS name of the profile and the DNS domain name used by Azure Traffic Manager to form
+	// the fully-qualified domain name (FQDN) of the profile.
 	// For example, "<TrafficManagerProfileNamespace>-<TrafficManagerProfileName>.trafficmanager.net"
 	// +optional
 	DNSName *string `json:"dnsName,omitempty"`
@@ -120,6 +305,36 @@ type TrafficManagerProfileStatus struct {
 	// Ex - /subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Network/trafficManagerProfiles/{resourceName}
 	ResourceID string `json:"resourceID,omitempty"`
 
+	// AlertRuleResourceID is the resource ID of the Azure Monitor metric alert rule created for the Traffic Manager
+	// profile as per its AlertRuleConfig.
+	// +optional
+	AlertRuleResourceID string `json:"alertRuleResourceID,omitempty"`
+
+	// HealthyEndpoints is the number of endpoints of the Azure Traffic Manager profile whose monitor status is
+	// online, as observed when the profile was last configured.
+	// +optional
+	HealthyEndpoints int32 `json:"healthyEndpoints"`
+
+	// DegradedEndpoints is the number of endpoints of the Azure Traffic Manager profile whose monitor status is
+	// degraded, i.e. which fail their health checks, as observed when the profile was last configured.
+	// +optional
+	DegradedEndpoints int32 `json:"degradedEndpoints"`
+
+	// CheckingEndpoints is the number of endpoints of the Azure Traffic Manager profile whose health is still being
+	// checked, as observed when the profile was last configured.
+	// +optional
+	CheckingEndpoints int32 `json:"checkingEndpoints"`
+
+	// TotalEndpoints is the number of endpoints of the Azure Traffic Manager profile, as observed when the profile
+	// was last configured.
+	// +optional
+	TotalEndpoints int32 `json:"totalEndpoints"`
+
+	// ManagedAzureTags is the sorted keys of the tags which have been applied to the Azure Traffic Manager profile
+	// from the azure-tag annotations of the profile, so that a tag is deleted once its annotation is removed.
+	// +optional
+	ManagedAzureTags []string `json:"managedAzureTags,omitempty"`
+
 	// Current profile status.
 	// +optional
 	// +patchMergeKey=type
@@ -153,6 +368,7 @@ const (
 	//
 	// * "Invalid"
 	// * "DNSNameNotAvailable"
+	// * "Pending"
 	//
 	// Possible reasons for this condition to be Unknown are:
 	//
@@ -171,6 +387,7 @@ const (
 
 	// TrafficManagerProfileReasonPending is used with the "Programmed" when creating or updating the profile hits an internal error
 	// with more details in the message and the controller will keep retry.
+	// The condition is false when the controller has stopped calling Azure for a while after repeated internal errors.
 	TrafficManagerProfileReasonPending TrafficManagerProfileConditionReason = "Pending"
 )
 
@@ -14,6 +14,36 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertRuleConfig) DeepCopyInto(out *AlertRuleConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertRuleConfig.
+func (in *AlertRuleConfig) DeepCopy() *AlertRuleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertRuleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoWeightSourceConfig) DeepCopyInto(out *AutoWeightSourceConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoWeightSourceConfig.
+func (in *AutoWeightSourceConfig) DeepCopy() *AutoWeightSourceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoWeightSourceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
@@ -29,6 +59,41 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DNSConfig) DeepCopyInto(out *DNSConfig) {
+	*out = *in
+	if in.RelativeName != nil {
+		in, out := &in.RelativeName, &out.RelativeName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DNSConfig.
+func (in *DNSConfig) DeepCopy() *DNSConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DNSConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndpointPriority) DeepCopyInto(out *EndpointPriority) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointPriority.
+func (in *EndpointPriority) DeepCopy() *EndpointPriority {
+	if in == nil {
+		return nil
+	}
+	out := new(EndpointPriority)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FromCluster) DeepCopyInto(out *FromCluster) {
 	*out = *in
@@ -95,6 +160,21 @@ func (in *MonitorConfig) DeepCopy() *MonitorConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SubnetRoutingRule) DeepCopyInto(out *SubnetRoutingRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SubnetRoutingRule.
+func (in *SubnetRoutingRule) DeepCopy() *SubnetRoutingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(SubnetRoutingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficManagerBackend) DeepCopyInto(out *TrafficManagerBackend) {
 	*out = *in
@@ -332,6 +412,51 @@ func (in *TrafficManagerProfileSpec) DeepCopyInto(out *TrafficManagerProfileSpec
 		*out = new(MonitorConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RoutingMethod != nil {
+		in, out := &in.RoutingMethod, &out.RoutingMethod
+		*out = new(TrafficRoutingMethod)
+		**out = **in
+	}
+	if in.SubnetConfig != nil {
+		in, out := &in.SubnetConfig, &out.SubnetConfig
+		*out = make([]SubnetRoutingRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.PriorityConfig != nil {
+		in, out := &in.PriorityConfig, &out.PriorityConfig
+		*out = make([]EndpointPriority, len(*in))
+		copy(*out, *in)
+	}
+	if in.DemotionThresholdBasisPoints != nil {
+		in, out := &in.DemotionThresholdBasisPoints, &out.DemotionThresholdBasisPoints
+		*out = new(int64)
+		**out = **in
+	}
+	if in.DDoSProtectionEnabled != nil {
+		in, out := &in.DDoSProtectionEnabled, &out.DDoSProtectionEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DDoSPlanResourceID != nil {
+		in, out := &in.DDoSPlanResourceID, &out.DDoSPlanResourceID
+		*out = new(string)
+		**out = **in
+	}
+	if in.DNSConfig != nil {
+		in, out := &in.DNSConfig, &out.DNSConfig
+		*out = new(DNSConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoWeightSource != nil {
+		in, out := &in.AutoWeightSource, &out.AutoWeightSource
+		*out = new(AutoWeightSourceConfig)
+		**out = **in
+	}
+	if in.AlertRuleConfig != nil {
+		in, out := &in.AlertRuleConfig, &out.AlertRuleConfig
+		*out = new(AlertRuleConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficManagerProfileSpec.
@@ -352,6 +477,11 @@ func (in *TrafficManagerProfileStatus) DeepCopyInto(out *TrafficManagerProfileSt
 		*out = new(string)
 		**out = **in
 	}
+	if in.ManagedAzureTags != nil {
+		in, out := &in.ManagedAzureTags, &out.ManagedAzureTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -0,0 +1,119 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package main
+
+import (
+	"flag"
+
+	"k8s.io/klog/v2"
+
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/agentconfig"
+)
+
+var (
+	configFile = flag.String("config", "",
+		"The path to the HubAgentConfiguration file of the agent. The flags set on the command line override the settings of the file; the log verbosity is reloaded when the file changes.")
+	removeMemberNamespaceFinalizers = flag.Bool("remove-member-namespace-finalizers", false,
+		"If set, the agent removes the finalizers of the member namespace garbage collection from all the member namespaces and exits, e.g. before it is uninstalled.")
+
+	// cfg is the effective configuration of the agent; the flags are bound to its defaulted settings and it is
+	// replaced by the configuration file, if any, on startup.
+	cfg = configv1alpha1.DefaultHubAgentConfiguration()
+
+	configLoader = &agentconfig.Loader[*configv1alpha1.HubAgentConfiguration]{
+		BindFlags:    bindFlags,
+		Decode:       configv1alpha1.DecodeHubAgentConfiguration,
+		Validate:     configv1alpha1.ValidateHubAgentConfiguration,
+		LogVerbosity: func(c *configv1alpha1.HubAgentConfiguration) *int32 { return &c.LogVerbosity },
+	}
+)
+
+func init() {
+	bindFlags(flag.CommandLine, cfg)
+}
+
+// bindFlags defines the flags which set the settings of the configuration, with the settings as the defaults.
+func bindFlags(fs *flag.FlagSet, c *configv1alpha1.HubAgentConfiguration) {
+	fs.StringVar(&c.Manager.MetricsBindAddress, "metrics-bind-address", c.Manager.MetricsBindAddress, "The address the metric endpoint binds to.")
+	fs.StringVar(&c.Manager.HealthProbeBindAddress, "health-probe-bind-address", c.Manager.HealthProbeBindAddress, "The address the probe endpoint binds to.")
+
+	fs.BoolVar(&c.LeaderElection.LeaderElect, "leader-elect", c.LeaderElection.LeaderElect,
+		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	fs.StringVar(&c.LeaderElection.ResourceNamespace, "leader-election-namespace", c.LeaderElection.ResourceNamespace, "The namespace in which the leader election resource will be created.")
+
+	fs.DurationVar(&c.InternalServiceExportRetryInterval.Duration, "internalserviceexport-retry-interval", c.InternalServiceExportRetryInterval.Duration,
+		"The wait time for the internalserviceexport controller to requeue the request and to wait for the"+
+			"ServiceImport controller to resolve the service Spec")
+
+	fs.DurationVar(&c.ForceDeleteWaitTime.Duration, "force-delete-wait-time", c.ForceDeleteWaitTime.Duration, "The duration the fleet hub agent waits before trying to force delete a member cluster.")
+
+	fs.BoolVar(&c.EnableV1Beta1APIs, "enable-v1beta1-apis", c.EnableV1Beta1APIs, "If set, the agents will watch for the v1beta1 APIs.")
+
+	fs.BoolVar(&c.TrafficManager.Enabled, "enable-traffic-manager-feature", c.TrafficManager.Enabled, "If set, the traffic manager feature will be enabled.")
+
+	fs.StringVar(&c.TrafficManager.CloudConfigFile, "cloud-config", c.TrafficManager.CloudConfigFile, "The path to the cloud config file which will be used to access the Azure resource.")
+
+	fs.IntVar(&c.TrafficManager.ProfileCircuitBreakerThreshold, "trafficmanagerprofile-circuit-breaker-threshold", c.TrafficManager.ProfileCircuitBreakerThreshold,
+		"The number of consecutive Azure server errors for a trafficManagerProfile before the controller stops calling Azure for the profile.")
+	fs.DurationVar(&c.TrafficManager.ProfileCircuitBreakerCoolDown.Duration, "trafficmanagerprofile-circuit-breaker-cool-down", c.TrafficManager.ProfileCircuitBreakerCoolDown.Duration,
+		"The wait time for the trafficManagerProfile controller to call Azure again after it stops calling Azure for a profile.")
+	fs.DurationVar(&c.TrafficManager.ProfileDriftDetectionInterval.Duration, "trafficmanagerprofile-drift-detection-interval", c.TrafficManager.ProfileDriftDetectionInterval.Duration,
+		"The interval at which the trafficManagerProfile controller corrects the changes made out of band to the Azure Traffic Manager profiles; set to 0 to disable the drift detection.")
+	fs.Var(agentconfig.CommaSeparatedStrings{Values: &c.TrafficManager.MetricsAllowedHosts}, "traffic-manager-metrics-allowed-hosts",
+		"The comma-separated hosts the auto weight sources and the SLA monitors of the trafficManagerProfiles may point to, either host names or wildcards of the form *.example.com; neither is ever queried if none is set.")
+
+	fs.BoolVar(&c.FleetServiceNetworkingStatus.Enabled, "enable-fleet-service-networking-status", c.FleetServiceNetworkingStatus.Enabled,
+		"If set, the networking pipeline of every exported service will be summarized in a FleetServiceNetworkingStatus.")
+	fs.DurationVar(&c.FleetServiceNetworkingStatus.BatchInterval.Duration, "fleetservicenetworkingstatus-batch-interval", c.FleetServiceNetworkingStatus.BatchInterval.Duration,
+		"The wait time for the FleetServiceNetworkingStatus controller to batch the changes of a service before summarizing them.")
+
+	fs.DurationVar(&c.EndpointFreshness.Threshold.Duration, "endpoint-freshness-threshold", c.EndpointFreshness.Threshold.Duration,
+		"The lag above which the endpoint changes of an exported service are reported as not fresh on its export; set to 0 to disable the freshness measurement.")
+	fs.DurationVar(&c.EndpointFreshness.RecoveryThreshold.Duration, "endpoint-freshness-recovery-threshold", c.EndpointFreshness.RecoveryThreshold.Duration,
+		"The lag at or below which an exported service whose endpoint changes are not fresh is reported as fresh again; it must not exceed the freshness threshold.")
+	fs.DurationVar(&c.EndpointFreshness.Window.Duration, "endpoint-freshness-window", c.EndpointFreshness.Window.Duration,
+		"The period over which the lag of the endpoint changes of an exported service is measured.")
+
+	fs.BoolVar(&c.MemberNamespaceGarbageCollection.Enabled, "enable-member-namespace-garbage-collection", c.MemberNamespaceGarbageCollection.Enabled,
+		"If set, the objects a member cluster leaves in the rest of the fleet will be cleaned up when its reserved namespace is deleted.")
+	fs.DurationVar(&c.MemberNamespaceGarbageCollection.RetryInterval.Duration, "membernamespace-retry-interval", c.MemberNamespaceGarbageCollection.RetryInterval.Duration,
+		"The wait time for the member namespace controller to check again whether the exports and imports in a deleted member namespace have been cleaned up.")
+
+	fs.DurationVar(&c.EndpointSliceExportGarbageCollection.TTL.Duration, "endpointsliceexport-ttl", c.EndpointSliceExportGarbageCollection.TTL.Duration,
+		"The period after which an EndpointSliceExport whose EndpointSlice the member agent has not seen is deleted; it must be well above the --endpointsliceexport-heartbeat-interval of the member agents. Set to 0 to disable the cleanup.")
+
+	fs.BoolVar(&c.EnableMCSAPICompatibility, "enable-mcs-api-compatibility", c.EnableMCSAPICompatibility,
+		"If set, the fleet ServiceImports will be mirrored into the upstream multicluster.x-k8s.io ServiceImports; a no-op if the upstream CRDs are not installed.")
+
+	fs.BoolVar(&c.Webhook.Enabled, "enable-webhook", c.Webhook.Enabled,
+		"If set, the validating and defaulting webhooks will be served; the serving certificates must be provisioned in the webhook certificate directory.")
+	fs.BoolVar(&c.Webhook.EnableServiceExportCompatibilityCheck, "enable-serviceexport-compatibility-check", c.Webhook.EnableServiceExportCompatibilityCheck,
+		"If set, the webhook server will serve an endpoint which predicts whether proposed ports of an exported service conflict with the other exports to the callers allowed to list all the InternalServiceExports; requires the webhook to be enabled.")
+}
+
+// loadConfiguration sets up the effective configuration of the agent from the configuration file, if any, and the
+// flags set on the command line; it returns the content of the configuration file.
+func loadConfiguration() ([]byte, error) {
+	c, data, err := configLoader.Load(*configFile, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg = c
+	return data, nil
+}
+
+// reloadConfiguration applies the settings of the changed configuration file which are safe to change at runtime,
+// i.e. the log verbosity; the other settings apply on restart.
+func reloadConfiguration(data []byte) error {
+	c, err := configLoader.Reload(data)
+	if err != nil {
+		return err
+	}
+	cfg.LogVerbosity = c.LogVerbosity
+	klog.InfoS("Reloaded the configuration", "logVerbosity", cfg.LogVerbosity)
+	return nil
+}
@@ -8,6 +8,7 @@ Licensed under the MIT license.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
@@ -15,11 +16,16 @@ import (
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/rand"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
@@ -27,7 +33,9 @@ import (
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/policy/ratelimit"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -38,36 +46,27 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/agentconfig"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/configreload"
+	"go.goms.io/fleet-networking/pkg/common/freshness"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/endpointsliceexport"
+	"go.goms.io/fleet-networking/pkg/controllers/hub/fleetservicenetworkingstatus"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/internalserviceexport"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/internalserviceimport"
+	"go.goms.io/fleet-networking/pkg/controllers/hub/mcsserviceimport"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/membercluster"
+	"go.goms.io/fleet-networking/pkg/controllers/hub/membernamespace"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/serviceimport"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/trafficmanagerbackend"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/trafficmanagerprofile"
+	internalserviceexportwebhook "go.goms.io/fleet-networking/pkg/webhook/internalserviceexport"
+	"go.goms.io/fleet-networking/pkg/webhook/serviceexportcompatibility"
+	trafficmanagerprofilewebhook "go.goms.io/fleet-networking/pkg/webhook/trafficmanagerprofile"
 )
 
 var (
 	scheme = runtime.NewScheme()
-
-	metricsAddr = flag.String("metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
-	probeAddr   = flag.String("health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
-
-	enableLeaderElection = flag.Bool("leader-elect", true,
-		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
-	leaderElectionNamespace = flag.String("leader-election-namespace", "fleet-system", "The namespace in which the leader election resource will be created.")
-
-	internalServiceExportRetryInterval = flag.Duration("internalserviceexport-retry-interval", 2*time.Second,
-		"The wait time for the internalserviceexport controller to requeue the request and to wait for the"+
-			"ServiceImport controller to resolve the service Spec")
-
-	forceDeleteWaitTime = flag.Duration("force-delete-wait-time", 15*time.Minute, "The duration the fleet hub agent waits before trying to force delete a member cluster.")
-
-	enableV1Beta1APIs = flag.Bool("enable-v1beta1-apis", true, "If set, the agents will watch for the v1beta1 APIs.")
-
-	enableTrafficManagerFeature = flag.Bool("enable-traffic-manager-feature", false, "If set, the traffic manager feature will be enabled.")
-
-	cloudConfigFile = flag.String("cloud-config", "/etc/kubernetes/provider/azure.json", "The path to the cloud config file which will be used to access the Azure resource.")
 )
 
 var (
@@ -101,22 +100,38 @@ func main() {
 
 	defer handleExitFunc()
 
-	flag.VisitAll(func(f *flag.Flag) {
-		klog.InfoS("flag:", "name", f.Name, "value", f.Value)
-	})
+	loadedConfig, err := loadConfiguration()
+	if err != nil {
+		klog.ErrorS(err, "Unable to load the configuration")
+		exitWithErrorFunc()
+	}
+	agentconfig.Log(cfg)
 
 	hubConfig := ctrl.GetConfigOrDie()
+	if *removeMemberNamespaceFinalizers {
+		// The agent is being uninstalled; the finalizers would block the deletion of the member namespaces forever.
+		hubClient, err := client.New(hubConfig, client.Options{Scheme: scheme})
+		if err != nil {
+			klog.ErrorS(err, "Unable to create the hub client")
+			exitWithErrorFunc()
+		}
+		if err := membernamespace.RemoveFinalizers(ctrl.SetupSignalHandler(), hubClient, hubClient); err != nil {
+			klog.ErrorS(err, "Unable to remove the member namespace finalizers")
+			exitWithErrorFunc()
+		}
+		return
+	}
 	mgr, err := ctrl.NewManager(hubConfig, ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
-			BindAddress: *metricsAddr,
+			BindAddress: cfg.Manager.MetricsBindAddress,
 		},
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 9443,
 		}),
-		HealthProbeBindAddress:  *probeAddr,
-		LeaderElection:          *enableLeaderElection,
-		LeaderElectionNamespace: *leaderElectionNamespace,
+		HealthProbeBindAddress:  cfg.Manager.HealthProbeBindAddress,
+		LeaderElection:          cfg.LeaderElection.LeaderElect,
+		LeaderElectionNamespace: cfg.LeaderElection.ResourceNamespace,
 		LeaderElectionID:        "2bf2b407.hub.networking.fleet.azure.com",
 	})
 	if err != nil {
@@ -135,20 +150,51 @@ func main() {
 		exitWithErrorFunc()
 	}
 
+	if *configFile != "" {
+		// Reload the settings which are safe to change at runtime when the configuration file changes.
+		if err := mgr.Add(&configreload.Watcher{
+			Path:   *configFile,
+			Reload: reloadConfiguration,
+			Loaded: loadedConfig,
+		}); err != nil {
+			klog.ErrorS(err, "Unable to add the configuration file watcher")
+			exitWithErrorFunc()
+		}
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 
 	klog.V(1).InfoS("Start to setup EndpointsliceExport controller")
+	var freshnessTracker *freshness.Tracker
+	if cfg.EndpointFreshness.Threshold.Duration > 0 {
+		freshnessTracker = freshness.New(freshness.Config{
+			Threshold:         cfg.EndpointFreshness.Threshold.Duration,
+			RecoveryThreshold: cfg.EndpointFreshness.RecoveryThreshold.Duration,
+			Window:            cfg.EndpointFreshness.Window.Duration,
+		})
+	}
 	if err := (&endpointsliceexport.Reconciler{
 		HubClient: mgr.GetClient(),
+		Freshness: freshnessTracker,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		klog.ErrorS(err, "Unable to create EndpointsliceExport controller")
 		exitWithErrorFunc()
 	}
+	if cfg.EndpointSliceExportGarbageCollection.TTL.Duration > 0 {
+		klog.V(1).InfoS("Start to setup EndpointsliceExport janitor")
+		if err := (&endpointsliceexport.Janitor{
+			HubClient: mgr.GetClient(),
+			TTL:       cfg.EndpointSliceExportGarbageCollection.TTL.Duration,
+		}).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "Unable to create EndpointsliceExport janitor")
+			exitWithErrorFunc()
+		}
+	}
 
 	klog.V(1).InfoS("Start to setup InternalServiceExport controller")
 	if err := (&internalserviceexport.Reconciler{
 		Client:        mgr.GetClient(),
-		RetryInternal: *internalServiceExportRetryInterval,
+		RetryInternal: cfg.InternalServiceExportRetryInterval.Duration,
 	}).SetupWithManager(mgr); err != nil {
 		klog.ErrorS(err, "Unable to create InternalServiceExport controller")
 		exitWithErrorFunc()
@@ -162,6 +208,25 @@ func main() {
 		exitWithErrorFunc()
 	}
 
+	if cfg.Webhook.Enabled {
+		klog.V(1).InfoS("Start to setup InternalServiceExport webhook")
+		if err := internalserviceexportwebhook.SetupWebhookWithManager(mgr); err != nil {
+			klog.ErrorS(err, "Unable to create InternalServiceExport webhook")
+			exitWithErrorFunc()
+		}
+		if cfg.TrafficManager.Enabled {
+			klog.V(1).InfoS("Start to setup TrafficManagerProfile webhook")
+			if err := trafficmanagerprofilewebhook.SetupWebhookWithManager(mgr); err != nil {
+				klog.ErrorS(err, "Unable to create TrafficManagerProfile webhook")
+				exitWithErrorFunc()
+			}
+		}
+		if cfg.Webhook.EnableServiceExportCompatibilityCheck {
+			klog.V(1).InfoS("Start to setup ServiceExport compatibility check endpoint", "path", serviceexportcompatibility.Path)
+			serviceexportcompatibility.SetupWithManager(mgr)
+		}
+	}
+
 	klog.V(1).InfoS("Start to setup ServiceImport controller")
 	if err := (&serviceimport.Reconciler{
 		Client:   mgr.GetClient(),
@@ -171,22 +236,56 @@ func main() {
 		exitWithErrorFunc()
 	}
 
+	if cfg.EnableMCSAPICompatibility {
+		klog.V(1).InfoS("Start to setup MCS ServiceImport controller")
+		if err := (&mcsserviceimport.Reconciler{
+			Client: mgr.GetClient(),
+		}).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "Unable to create MCS ServiceImport controller")
+			exitWithErrorFunc()
+		}
+	}
+
 	discoverClient := discovery.NewDiscoveryClientForConfigOrDie(hubConfig)
-	if *enableV1Beta1APIs {
+	if cfg.EnableV1Beta1APIs {
 		gvk := clusterv1beta1.GroupVersion.WithKind(clusterv1beta1.MemberClusterKind)
 		if utils.CheckCRDInstalled(discoverClient, gvk) == nil {
 			klog.V(1).InfoS("Start to setup MemberCluster controller")
 			if err := (&membercluster.Reconciler{
 				Client:              mgr.GetClient(),
 				Recorder:            mgr.GetEventRecorderFor(membercluster.ControllerName),
-				ForceDeleteWaitTime: *forceDeleteWaitTime,
+				ForceDeleteWaitTime: cfg.ForceDeleteWaitTime.Duration,
 			}).SetupWithManager(mgr); err != nil {
 				klog.ErrorS(err, "Unable to create MemberCluster controller")
 				exitWithErrorFunc()
 			}
 		}
 	}
-	if *enableTrafficManagerFeature {
+	if cfg.MemberNamespaceGarbageCollection.Enabled {
+		// The controller relies on the internalServiceExport index set up by the ServiceImport controller.
+		klog.V(1).InfoS("Start to setup MemberNamespace controller")
+		if err := (&membernamespace.Reconciler{
+			Client:        mgr.GetClient(),
+			RetryInterval: cfg.MemberNamespaceGarbageCollection.RetryInterval.Duration,
+		}).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "Unable to create MemberNamespace controller")
+			exitWithErrorFunc()
+		}
+	} else {
+		// The finalizers added while the garbage collection was enabled would block the deletion of the member
+		// namespaces forever; they are removed until it succeeds, or the manager stops.
+		klog.V(1).InfoS("Start to remove the MemberNamespace finalizers")
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			_ = wait.PollUntilContextCancel(ctx, cfg.MemberNamespaceGarbageCollection.RetryInterval.Duration, true, func(ctx context.Context) (bool, error) {
+				return membernamespace.RemoveFinalizers(ctx, mgr.GetAPIReader(), mgr.GetClient()) == nil, nil
+			})
+			return nil
+		})); err != nil {
+			klog.ErrorS(err, "Unable to add the MemberNamespace finalizer remover")
+			exitWithErrorFunc()
+		}
+	}
+	if cfg.TrafficManager.Enabled {
 		klog.V(1).InfoS("Traffic manager feature is enabled, checking the required CRDs")
 		for _, gvk := range trafficManagerFeatureRequiredGVKs {
 			if err = utils.CheckCRDInstalled(discoverClient, gvk); err != nil {
@@ -195,25 +294,48 @@ func main() {
 			}
 		}
 
-		klog.V(1).InfoS("Traffic manager feature is enabled, loading cloud config and creating azure clients", "cloudConfigFile", *cloudConfigFile)
-		cloudConfig, err := azure.NewCloudConfigFromFile(*cloudConfigFile)
+		klog.V(1).InfoS("Traffic manager feature is enabled, loading cloud config and creating azure clients", "cloudConfigFile", cfg.TrafficManager.CloudConfigFile)
+		cloudConfig, err := azure.NewCloudConfigFromFile(cfg.TrafficManager.CloudConfigFile)
 		if err != nil {
-			klog.ErrorS(err, "Unable to load cloud config", "file name", *cloudConfigFile)
+			klog.ErrorS(err, "Unable to load cloud config", "file name", cfg.TrafficManager.CloudConfigFile)
 			exitWithErrorFunc()
 		}
 		cloudConfig.SetUserAgent("fleet-hub-net-controller-manager")
 		klog.V(1).InfoS("Cloud config loaded", "cloudConfig", cloudConfig)
 
-		profilesClient, endpointsClient, err := initAzureTrafficManagerClients(cloudConfig) // profilesClient, endpointsClient, err
+		credential, clientOptions, err := initAzureClientOptions(cloudConfig)
+		if err != nil {
+			klog.ErrorS(err, "Unable to create Azure client options")
+			exitWithErrorFunc()
+		}
+		profilesClient, endpointsClient, err := initAzureTrafficManagerClients(cloudConfig, credential, clientOptions)
 		if err != nil {
 			klog.ErrorS(err, "Unable to create Azure Traffic Manager clients")
 			exitWithErrorFunc()
 		}
+		publicIPAddressesClient, err := armnetwork.NewPublicIPAddressesClient(cloudConfig.SubscriptionID, credential, clientOptions)
+		if err != nil {
+			klog.ErrorS(err, "Unable to create Azure public IP addresses client")
+			exitWithErrorFunc()
+		}
+		metricAlertsClient, err := armmonitor.NewMetricAlertsClient(cloudConfig.SubscriptionID, credential, clientOptions)
+		if err != nil {
+			klog.ErrorS(err, "Unable to create Azure Monitor metric alerts client")
+			exitWithErrorFunc()
+		}
 		klog.V(1).InfoS("Start to setup TrafficManagerProfile controller")
 		if err := (&trafficmanagerprofile.Reconciler{
 			Client:            mgr.GetClient(),
+			Recorder:          mgr.GetEventRecorderFor(trafficmanagerprofile.ControllerName),
 			ProfilesClient:    profilesClient,
+			EndpointsClient:   endpointsClient,
 			ResourceGroupName: cloudConfig.ResourceGroup,
+			CircuitBreaker:    circuitbreaker.New(cfg.TrafficManager.ProfileCircuitBreakerThreshold, cfg.TrafficManager.ProfileCircuitBreakerCoolDown.Duration),
+			// Used to configure the DDoS protection on the public IP addresses behind the profile endpoints.
+			PublicIPAddressesClient: publicIPAddressesClient,
+			// Used to manage the alert rules of the profiles.
+			MetricAlertsClient:     metricAlertsClient,
+			DriftDetectionInterval: cfg.TrafficManager.ProfileDriftDetectionInterval.Duration,
 		}).SetupWithManager(mgr); err != nil {
 			klog.ErrorS(err, "Unable to create TrafficManagerProfile controller")
 			exitWithErrorFunc()
@@ -225,6 +347,11 @@ func main() {
 			ProfilesClient:    profilesClient,
 			EndpointsClient:   endpointsClient,
 			ResourceGroupName: cloudConfig.ResourceGroup,
+			// The auto weight sources and the SLA monitors are queried with a client which refuses to reach loopback
+			// and link-local addresses, e.g. the instance metadata service, and only if they point to the allowed
+			// hosts.
+			MetricsAllowedHosts: cfg.TrafficManager.MetricsAllowedHosts,
+			Recorder:            mgr.GetEventRecorderFor(trafficmanagerbackend.ControllerName),
 			// serviceImport controller has already enabled the internalServiceExportIndexer.
 			// Therefore, no need to setup it again.
 		}).SetupWithManager(ctx, mgr, true); err != nil {
@@ -233,6 +360,24 @@ func main() {
 		}
 	}
 
+	if cfg.FleetServiceNetworkingStatus.Enabled {
+		gvk := fleetnetv1alpha1.GroupVersion.WithKind(fleetnetv1alpha1.FleetServiceNetworkingStatusKind)
+		if err = utils.CheckCRDInstalled(discoverClient, gvk); err != nil {
+			klog.ErrorS(err, "Unable to find the required CRD", "GVK", gvk)
+			exitWithErrorFunc()
+		}
+		// The controller relies on the indexes set up by the controllers above.
+		klog.V(1).InfoS("Start to setup FleetServiceNetworkingStatus controller")
+		if err := (&fleetservicenetworkingstatus.Reconciler{
+			Client:                      mgr.GetClient(),
+			EnableTrafficManagerFeature: cfg.TrafficManager.Enabled,
+			BatchInterval:               cfg.FleetServiceNetworkingStatus.BatchInterval.Duration,
+		}).SetupWithManager(mgr); err != nil {
+			klog.ErrorS(err, "Unable to create FleetServiceNetworkingStatus controller")
+			exitWithErrorFunc()
+		}
+	}
+
 	klog.V(1).InfoS("Starting ServiceExportImport controller manager")
 	if err := mgr.Start(ctx); err != nil {
 		klog.ErrorS(err, "Problem running manager")
@@ -241,7 +386,21 @@ func main() {
 }
 
 // initAzureTrafficManagerClients initializes the Azure Traffic Manager profiles and endpoints clients.
-func initAzureTrafficManagerClients(cloudConfig *azure.CloudConfig) (*armtrafficmanager.ProfilesClient, *armtrafficmanager.EndpointsClient, error) {
+func initAzureTrafficManagerClients(cloudConfig *azure.CloudConfig, credential azcore.TokenCredential, options *arm.ClientOptions) (*armtrafficmanager.ProfilesClient, *armtrafficmanager.EndpointsClient, error) {
+	profilesClient, err := armtrafficmanager.NewProfilesClient(cloudConfig.SubscriptionID, credential, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Azure trafficManager profiles client: %w", err)
+	}
+
+	endpointsClient, err := armtrafficmanager.NewEndpointsClient(cloudConfig.SubscriptionID, credential, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create Azure trafficManager endpoints client: %w", err)
+	}
+	return profilesClient, endpointsClient, nil
+}
+
+// initAzureClientOptions initializes the credential and the client options shared by the Azure clients.
+func initAzureClientOptions(cloudConfig *azure.CloudConfig) (azcore.TokenCredential, *arm.ClientOptions, error) {
 	authProvider, err := azclient.NewAuthProvider(&cloudConfig.ARMClientConfig, &cloudConfig.AzureAuthConfig)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create Azure auth provider: %w", err)
@@ -259,15 +418,5 @@ func initAzureTrafficManagerClients(cloudConfig *azure.CloudConfig) (*armtraffic
 	if rateLimitPolicy := ratelimit.NewRateLimitPolicy(cloudConfig.Config); rateLimitPolicy != nil {
 		options.ClientOptions.PerCallPolicies = append(options.ClientOptions.PerCallPolicies, rateLimitPolicy)
 	}
-
-	profilesClient, err := armtrafficmanager.NewProfilesClient(cloudConfig.SubscriptionID, authProvider.GetAzIdentity(), options)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Azure trafficManager profiles client: %w", err)
-	}
-
-	endpointsClient, err := armtrafficmanager.NewEndpointsClient(cloudConfig.SubscriptionID, authProvider.GetAzIdentity(), options)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create Azure trafficManager endpoints client: %w", err)
-	}
-	return profilesClient, endpointsClient, nil
+	return authProvider.GetAzIdentity(), options, nil
 }
@@ -0,0 +1,179 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package main
+
+import (
+	"flag"
+
+	"k8s.io/klog/v2"
+
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/agentconfig"
+	"go.goms.io/fleet-networking/pkg/common/egressproxy"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
+)
+
+var (
+	configFile = flag.String("config", "",
+		"The path to the MemberAgentConfiguration file of the agent. The flags set on the command line override the settings of the file; the log verbosity and the hub write retry budget are reloaded when the file changes.")
+
+	// cfg is the effective configuration of the agent; the flags are bound to its defaulted settings and it is
+	// replaced by the configuration file, if any, on startup.
+	cfg = configv1alpha1.DefaultMemberAgentConfiguration()
+
+	configLoader = &agentconfig.Loader[*configv1alpha1.MemberAgentConfiguration]{
+		BindFlags:    bindFlags,
+		Decode:       configv1alpha1.DecodeMemberAgentConfiguration,
+		Validate:     configv1alpha1.ValidateMemberAgentConfiguration,
+		LogVerbosity: func(c *configv1alpha1.MemberAgentConfiguration) *int32 { return &c.LogVerbosity },
+	}
+)
+
+func init() {
+	bindFlags(flag.CommandLine, cfg)
+}
+
+// bindFlags defines the flags which set the settings of the configuration, with the settings as the defaults.
+func bindFlags(fs *flag.FlagSet, c *configv1alpha1.MemberAgentConfiguration) {
+	fs.StringVar(&c.Hub.MetricsBindAddress, "hub-metrics-bind-address", c.Hub.MetricsBindAddress, "The address of hub controller manager the metric endpoint binds to.")
+	fs.StringVar(&c.Hub.HealthProbeBindAddress, "hub-health-probe-bind-address", c.Hub.HealthProbeBindAddress, "The address of hub controller manager the probe endpoint binds to.")
+	fs.StringVar(&c.Member.MetricsBindAddress, "member-metrics-bind-address", c.Member.MetricsBindAddress, "The address of member controller manager the metric endpoint binds to.")
+	fs.StringVar(&c.Member.HealthProbeBindAddress, "member-health-probe-bind-address", c.Member.HealthProbeBindAddress, "The address of member controller manager the probe endpoint binds to.")
+
+	fs.BoolVar(&c.LeaderElection.LeaderElect, "leader-elect", c.LeaderElection.LeaderElect, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	fs.StringVar(&c.LeaderElection.ResourceNamespace, "leader-election-namespace", c.LeaderElection.ResourceNamespace, "The namespace in which the leader election resource will be created.")
+
+	fs.BoolVar(&c.Hub.TLSInsecure, "tls-insecure", c.Hub.TLSInsecure, "Enable TLSClientConfig.Insecure property. Enabling this will make the connection inSecure (should be 'true' for testing purpose only.)")
+	fs.StringVar(&c.FleetSystemNamespace, "fleet-system-namespace", c.FleetSystemNamespace, "The reserved system namespace used by fleet.")
+
+	fs.BoolVar(&c.EnableV1Alpha1APIs, "enable-v1alpha1-apis", c.EnableV1Alpha1APIs, "If set, the agents will watch for the v1alpha1 APIs.")
+	fs.BoolVar(&c.EnableV1Beta1APIs, "enable-v1beta1-apis", c.EnableV1Beta1APIs, "If set, the agents will watch for the v1beta1 APIs.")
+
+	fs.BoolVar(&c.TrafficManager.Enabled, "enable-traffic-manager-feature", c.TrafficManager.Enabled, "If set, the traffic manager feature will be enabled.")
+
+	fs.DurationVar(&c.Hub.SchemaCheckInterval.Duration, "hub-schema-check-interval", c.Hub.SchemaCheckInterval.Duration,
+		"The interval to check whether the hub CRDs support the enabled features and the fields of the exports; the features are disabled, and the unsupported fields are stripped from the exports, while the hub CRDs differ from what the agent expects.")
+
+	fs.DurationVar(&c.ServiceExport.ServiceNotFoundRequeueAfter.Duration, "serviceexport-service-not-found-requeue-after", c.ServiceExport.ServiceNotFoundRequeueAfter.Duration, "The interval to requeue a ServiceExport whose Service is not found.")
+	fs.DurationVar(&c.ServiceExport.UnexportGracePeriod.Duration, "serviceexport-unexport-grace-period", c.ServiceExport.UnexportGracePeriod.Duration, "The period the unexport of a deleted ServiceExport is delayed while member clusters still import its service. The service is unexported right away, with a warning event, if it is not positive.")
+	fs.StringVar(&c.ServiceExport.CleanupFinalizer, "serviceexport-cleanup-finalizer", c.ServiceExport.CleanupFinalizer, "The finalizer added to the exported ServiceExports so that their services are unexported before they are deleted. Installations running side by side against the same hub cluster must use different finalizers.")
+
+	fs.IntVar(&c.EndpointSlice.MaxConcurrentReconciles, "endpointslice-max-concurrent-reconciles", c.EndpointSlice.MaxConcurrentReconciles,
+		"The maximum number of EndpointSlices the endpointslice controller exports at the same time.")
+	fs.IntVar(&c.ServiceExport.MaxConcurrentReconciles, "serviceexport-max-concurrent-reconciles", c.ServiceExport.MaxConcurrentReconciles,
+		"The maximum number of ServiceExports the serviceexport controller exports at the same time.")
+
+	fs.IntVar(&c.EndpointSlice.CircuitBreakerThreshold, "endpointslice-circuit-breaker-threshold", c.EndpointSlice.CircuitBreakerThreshold,
+		"The number of consecutive failures to write to the hub namespace before the endpointslice controller stops reconciling.")
+	fs.DurationVar(&c.EndpointSlice.CircuitBreakerCoolDown.Duration, "endpointslice-circuit-breaker-cool-down", c.EndpointSlice.CircuitBreakerCoolDown.Duration,
+		"The wait time for the endpointslice controller to write to the hub namespace again after it stops reconciling.")
+	fs.IntVar(&c.EndpointSlice.ChurnProtection.Threshold, "endpointslice-churn-threshold", c.EndpointSlice.ChurnProtection.Threshold,
+		"The number of endpoint changes of an exported Service within --endpointslice-churn-window above which the endpointslice controller coalesces its exports and raises the HighEndpointChurn condition on its ServiceExport. Churn protection is disabled if it is not positive.")
+	fs.IntVar(&c.EndpointSlice.ChurnProtection.RecoveryThreshold, "endpointslice-churn-recovery-threshold", c.EndpointSlice.ChurnProtection.RecoveryThreshold,
+		"The number of endpoint changes of an exported Service within --endpointslice-churn-window at or below which the endpointslice controller exports its endpoint changes as they happen again.")
+	fs.DurationVar(&c.EndpointSlice.ChurnProtection.Window.Duration, "endpointslice-churn-window", c.EndpointSlice.ChurnProtection.Window.Duration,
+		"The period over which the endpoint changes of an exported Service are counted for churn protection.")
+	fs.DurationVar(&c.EndpointSlice.ChurnProtection.CoalescingInterval.Duration, "endpointslice-churn-coalescing-interval", c.EndpointSlice.ChurnProtection.CoalescingInterval.Duration,
+		"The interval at which the endpointslice controller exports the EndpointSlices of a Service whose exports are coalesced for its endpoint churn.")
+	fs.DurationVar(&c.EndpointSlice.ExportHeartbeatInterval.Duration, "endpointsliceexport-heartbeat-interval", c.EndpointSlice.ExportHeartbeatInterval.Duration,
+		"The interval at which the endpointsliceexport controller annotates the EndpointSliceExports whose EndpointSlices still exist with the time they were last seen; it must be well below the --endpointsliceexport-ttl of the hub agent.")
+	fs.DurationVar(&c.EndpointSlice.RequeueJitter.Duration, "endpointslice-requeue-jitter", c.EndpointSlice.RequeueJitter.Duration,
+		"The upper bound of the random delay the endpointslice controller adds when it requeues an EndpointSlice for a later time, e.g. while the circuit for the hub namespace is open, so that EndpointSlices skipped together are not retried together. Failed writes to the hub cluster are retried with the backoff of the workqueue instead. Requeues are not jittered if it is 0.")
+	fs.IntVar(&c.EndpointSlice.ResourcePressure.PodCIDRUtilizationThreshold, "endpointslice-resource-pressure-pod-cidr-threshold", c.EndpointSlice.ResourcePressure.PodCIDRUtilizationThreshold,
+		"The percentage of the Pods any node can hold, i.e. its allocatable Pods capped by the addresses of its pod CIDR, in use above which the endpointslice controller pauses its exports, reporting the ExportPausedDueToResourcePressure agent condition, until the utilization drops; unexports, and exports which only remove endpoints, go on. The Pods are not checked if it is 0.")
+	fs.IntVar(&c.EndpointSlice.ResourcePressure.CPUUtilizationThreshold, "endpointslice-resource-pressure-cpu-threshold", c.EndpointSlice.ResourcePressure.CPUUtilizationThreshold,
+		"The percentage of the allocatable CPU of the nodes in use, as reported by the Metrics Server, above which the endpointslice controller pauses its exports until the utilization drops. The CPU utilization is not checked if it is 0, or while the Metrics Server is not available.")
+	fs.DurationVar(&c.EndpointSlice.ResourcePressure.CheckInterval.Duration, "endpointslice-resource-pressure-check-interval", c.EndpointSlice.ResourcePressure.CheckInterval.Duration,
+		"The interval between two checks of the resource pressure of the member cluster.")
+	fs.Float64Var(&c.Hub.WriteRetryBudget.Rate, "hub-write-retry-budget-rate", c.Hub.WriteRetryBudget.Rate,
+		"The number of retries of failed writes to the hub cluster per second the controllers share before they requeue with growing delays. The retry budget is disabled if it is not positive.")
+	fs.IntVar(&c.Hub.WriteRetryBudget.Burst, "hub-write-retry-budget-burst", c.Hub.WriteRetryBudget.Burst,
+		"The number of retries of failed writes to the hub cluster the controllers may make in a burst before the retry budget is depleted.")
+	fs.Float64Var(&c.Hub.QPS, "hub-qps", c.Hub.QPS,
+		"The maximum number of queries per second the controllers send to the hub API server.")
+	fs.IntVar(&c.Hub.Burst, "hub-burst", c.Hub.Burst,
+		"The maximum burst of queries the controllers send to the hub API server.")
+	fs.IntVar(&c.NamespaceIsolation.FailureThreshold, "namespace-isolation-failure-threshold", c.NamespaceIsolation.FailureThreshold,
+		"The number of failed reconciliations of the objects of a namespace within the window above which the endpointslice and serviceexport controllers slow down the retries of the namespace, so that they do not delay the other namespaces. The isolation is disabled if it is not positive.")
+	fs.DurationVar(&c.NamespaceIsolation.Window.Duration, "namespace-isolation-window", c.NamespaceIsolation.Window.Duration,
+		"The period over which the failed reconciliations of a namespace are counted; a namespace whose retries are slowed down recovers once none of its objects has failed for the period.")
+	fs.DurationVar(&c.NamespaceIsolation.DemotedRetryInterval.Duration, "namespace-isolation-demoted-retry-interval", c.NamespaceIsolation.DemotedRetryInterval.Duration,
+		"The interval between the retries of the objects of a namespace whose retries are slowed down.")
+	fs.DurationVar(&c.Hub.WriteDrainTimeout.Duration, "hub-write-drain-timeout", c.Hub.WriteDrainTimeout.Duration,
+		"The period the in-flight writes to the hub cluster are given to complete when the agent shuts down; zero cancels them right away.")
+	fs.Float64Var(&c.InitialSync.Rate, "initial-sync-rate", c.InitialSync.Rate,
+		"The number of ServiceExports and EndpointSlices per second the agent exports when the member cluster joins the fleet with at least --initial-sync-threshold ServiceExports and no prior exports; ServiceExports are exported before EndpointSlices, and unexports are never delayed. The initial export is not paced if it is not positive.")
+	fs.IntVar(&c.InitialSync.Burst, "initial-sync-burst", c.InitialSync.Burst,
+		"The number of objects exported right away when the paced initial export starts.")
+	fs.IntVar(&c.InitialSync.Threshold, "initial-sync-threshold", c.InitialSync.Threshold,
+		"The number of ServiceExports from which the initial export of a member cluster with no prior exports is paced.")
+	fs.BoolVar(&c.EndpointSlice.ExportNotReadyAddresses, "export-not-ready-addresses", c.EndpointSlice.ExportNotReadyAddresses,
+		"If set, the endpointslice controller exports the endpoints of EndpointSlices regardless of their readiness, like the publishNotReadyAddresses field of Services does.")
+	fs.BoolVar(&c.EndpointSlice.EnableEndpointSelector, "enable-endpoint-selector", c.EndpointSlice.EnableEndpointSelector,
+		"If set, the endpointslice controller exports only the endpoints whose Pods match the endpoint selectors of ServiceExports, and caches the metadata of all the Pods to do so. Otherwise, the ServiceExports which set an endpoint selector export no endpoint.")
+
+	fs.BoolVar(&c.EnableServiceExportWebhook, "enable-serviceexport-webhook", c.EnableServiceExportWebhook,
+		"If set, the webhook rejecting the ServiceExports of ExternalName services will be served; the serving certificates must be provisioned in the webhook certificate directory.")
+
+	fs.BoolVar(&c.MCSAPICompatibility.Enabled, "enable-mcs-api-compatibility", c.MCSAPICompatibility.Enabled, "If set, the agent will watch for the upstream multicluster.x-k8s.io ServiceExports and translate them into fleet ServiceExports.")
+	fs.StringVar(&c.MCSAPICompatibility.Mode, "mcs-api-compatibility-mode", c.MCSAPICompatibility.Mode, "The migration mode of the mcs-api compatibility, either DualWrite or Cutover. In the Cutover mode, the upstream ServiceExports are no longer honored.")
+
+	fs.StringVar(&c.TrafficManager.CloudConfigFile, "cloud-config", c.TrafficManager.CloudConfigFile, "The path to the cloud config file which will be used to access the Azure resource.")
+
+	fs.StringVar(&c.Hub.Proxy.URL, "hub-proxy-url", c.Hub.Proxy.URL, "The URL of the proxy through which the agent connects to the hub cluster. If not set, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply.")
+	fs.StringVar(&c.Hub.Proxy.NoProxy, "hub-no-proxy", c.Hub.Proxy.NoProxy, "A comma-separated list of the hosts, domains, IP addresses and CIDRs of the hub cluster which are reached without the proxy set by --hub-proxy-url.")
+	fs.StringVar(&c.TrafficManager.ARMProxy.URL, "arm-proxy-url", c.TrafficManager.ARMProxy.URL, "The URL of the proxy through which the agent connects to Azure Resource Manager and Microsoft Entra ID. If not set, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply.")
+	fs.StringVar(&c.TrafficManager.ARMProxy.NoProxy, "arm-no-proxy", c.TrafficManager.ARMProxy.NoProxy, "A comma-separated list of the hosts, domains, IP addresses and CIDRs which are reached without the proxy set by --arm-proxy-url.")
+}
+
+// loadConfiguration sets up the effective configuration of the agent from the configuration file, if any, and the
+// flags set on the command line; it returns the content of the configuration file.
+func loadConfiguration() ([]byte, error) {
+	c, data, err := configLoader.Load(*configFile, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg = c
+	return data, nil
+}
+
+// reloadConfiguration applies the settings of the changed configuration file which are safe to change at runtime,
+// i.e. the log verbosity and the limits of the hub write retry budget; the other settings apply on restart.
+func reloadConfiguration(data []byte, hubWriteRetryBudget *retrybudget.Budget) error {
+	c, err := configLoader.Reload(data)
+	if err != nil {
+		return err
+	}
+	cfg.LogVerbosity = c.LogVerbosity
+
+	budget := c.Hub.WriteRetryBudget
+	switch {
+	case (hubWriteRetryBudget != nil) != (budget.Rate > 0):
+		klog.InfoS("The hub write retry budget cannot be enabled or disabled at runtime; restart the agent to apply the change")
+	case hubWriteRetryBudget != nil:
+		hubWriteRetryBudget.SetLimits(budget.Rate, budget.Burst)
+		cfg.Hub.WriteRetryBudget = budget
+	}
+	klog.InfoS("Reloaded the configuration", "logVerbosity", cfg.LogVerbosity, "hubWriteRetryBudget", cfg.Hub.WriteRetryBudget)
+	return nil
+}
+
+// hubProxyConfig returns the egress proxy config of the hub cluster.
+func hubProxyConfig() *egressproxy.Config {
+	return proxyConfig(cfg.Hub.Proxy)
+}
+
+// armProxyConfig returns the egress proxy config of Azure Resource Manager and Microsoft Entra ID.
+func armProxyConfig() *egressproxy.Config {
+	return proxyConfig(cfg.TrafficManager.ARMProxy)
+}
+
+func proxyConfig(c configv1alpha1.ProxyConfiguration) *egressproxy.Config {
+	// The URL is validated with the configuration.
+	u := &egressproxy.URLValue{}
+	_ = u.Set(c.URL)
+	return &egressproxy.Config{URL: u.URL, NoProxy: c.NoProxy}
+}
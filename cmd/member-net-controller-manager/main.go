@@ -11,29 +11,40 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	azpolicy "github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/rand"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/policy/ratelimit"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/publicipaddressclient"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/utils"
+	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/utils/armbalancer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	//+kubebuilder:scaffold:imports
@@ -42,39 +53,42 @@ import (
 	"go.goms.io/fleet/pkg/utils/cloudconfig/azure"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/agentconfig"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/configreload"
+	"go.goms.io/fleet-networking/pkg/common/egressproxy"
 	"go.goms.io/fleet-networking/pkg/common/env"
 	"go.goms.io/fleet-networking/pkg/common/hubconfig"
+	"go.goms.io/fleet-networking/pkg/common/hubschema"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+	"go.goms.io/fleet-networking/pkg/common/namespaceisolation"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
 	"go.goms.io/fleet-networking/pkg/controllers/member/endpointslice"
 	"go.goms.io/fleet-networking/pkg/controllers/member/endpointsliceexport"
 	"go.goms.io/fleet-networking/pkg/controllers/member/endpointsliceimport"
+	imc "go.goms.io/fleet-networking/pkg/controllers/member/internalmembercluster"
 	imcv1alpha1 "go.goms.io/fleet-networking/pkg/controllers/member/internalmembercluster/v1alpha1"
 	imcv1beta1 "go.goms.io/fleet-networking/pkg/controllers/member/internalmembercluster/v1beta1"
 	"go.goms.io/fleet-networking/pkg/controllers/member/internalserviceexport"
 	"go.goms.io/fleet-networking/pkg/controllers/member/internalserviceimport"
+	"go.goms.io/fleet-networking/pkg/controllers/member/mcsserviceexport"
 	"go.goms.io/fleet-networking/pkg/controllers/member/serviceexport"
 	"go.goms.io/fleet-networking/pkg/controllers/member/serviceimport"
+	serviceexportwebhook "go.goms.io/fleet-networking/pkg/webhook/serviceexport"
 )
 
 var (
 	scheme = runtime.NewScheme()
+)
 
-	hubMetricsAddr = flag.String("hub-metrics-bind-address", ":8080", "The address of hub controller manager the metric endpoint binds to.")
-	hubProbeAddr   = flag.String("hub-health-probe-bind-address", ":8081", "The address of hub controller manager the probe endpoint binds to.")
-	metricsAddr    = flag.String("member-metrics-bind-address", ":8090", "The address of member controller manager the metric endpoint binds to.")
-	probeAddr      = flag.String("member-health-probe-bind-address", ":8091", "The address of member controller manager the probe endpoint binds to.")
-
-	enableLeaderElection    = flag.Bool("leader-elect", true, "Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
-	leaderElectionNamespace = flag.String("leader-election-namespace", "fleet-system", "The namespace in which the leader election resource will be created.")
-
-	tlsClientInsecure    = flag.Bool("tls-insecure", false, "Enable TLSClientConfig.Insecure property. Enabling this will make the connection inSecure (should be 'true' for testing purpose only.)")
-	fleetSystemNamespace = flag.String("fleet-system-namespace", "fleet-system", "The reserved system namespace used by fleet.")
-
-	isV1Alpha1APIEnabled = flag.Bool("enable-v1alpha1-apis", true, "If set, the agents will watch for the v1alpha1 APIs.")
-	isV1Beta1APIEnabled  = flag.Bool("enable-v1beta1-apis", false, "If set, the agents will watch for the v1beta1 APIs.")
-
-	enableTrafficManagerFeature = flag.Bool("enable-traffic-manager-feature", false, "If set, the traffic manager feature will be enabled.")
+const (
+	// connectivitySelfTestTimeout is the timeout of the startup connectivity self-test of each target reached
+	// through a proxy.
+	connectivitySelfTestTimeout = 30 * time.Second
 
-	cloudConfigFile = flag.String("cloud-config", "/etc/kubernetes/provider/azure.json", "The path to the cloud config file which will be used to access the Azure resource.")
+	// gracefulShutdownMargin is the time the member manager is given to stop, on top of the drain timeout of the
+	// in-flight hub writes.
+	gracefulShutdownMargin = 10 * time.Second
 )
 
 func init() {
@@ -84,6 +98,8 @@ func init() {
 	utilruntime.Must(fleetnetv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(fleetv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(clusterv1beta1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	utilruntime.Must(metricsv1beta1.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
@@ -103,9 +119,13 @@ func main() {
 
 	defer handleExitFunc()
 
-	flag.VisitAll(func(f *flag.Flag) {
-		klog.InfoS("flag:", "name", f.Name, "value", f.Value)
-	})
+	loadedConfig, err := loadConfiguration()
+	if err != nil {
+		klog.ErrorS(err, "Unable to load the configuration")
+		exitWithErrorFunc()
+	}
+	// The passwords of the proxy URLs are redacted.
+	agentconfig.Log(cfg.Redacted())
 
 	memberConfig, memberOptions := prepareMemberParameters()
 
@@ -147,7 +167,7 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	klog.V(1).InfoS("Setup controllers with controller manager")
-	if err := setupControllersWithManager(ctx, hubMgr, memberMgr); err != nil {
+	if err := setupControllersWithManager(ctx, hubMgr, memberMgr, loadedConfig); err != nil {
 		klog.ErrorS(err, "Unable to setup controllers with manager")
 		exitWithErrorFunc()
 	}
@@ -198,11 +218,23 @@ func main() {
 }
 
 func prepareHubParameters(memberConfig *rest.Config) (*rest.Config, *ctrl.Options, error) {
-	hubConfig, err := hubconfig.PrepareHubConfig(*tlsClientInsecure)
+	hubConfig, err := hubconfig.PrepareHubConfig(cfg.Hub.TLSInsecure)
 	if err != nil {
 		klog.ErrorS(err, "Failed to get hub config")
 		return nil, nil, err
 	}
+	hubconfig.ClientConfig{
+		HubAPIQPS:   float32(cfg.Hub.QPS),
+		HubAPIBurst: cfg.Hub.Burst,
+	}.ApplyTo(hubConfig)
+	if hubProxy := hubProxyConfig(); hubProxy.IsSet() {
+		hubConfig.Proxy = hubProxy.ProxyFunc()
+		if err := selfTestHubConnectivity(hubConfig); err != nil {
+			klog.ErrorS(err, "Failed to connect to the hub cluster through the proxy", "proxy", hubProxy)
+			return nil, nil, err
+		}
+		klog.InfoS("Connected to the hub cluster through the proxy", "proxy", hubProxy)
+	}
 
 	mcHubNamespace, err := hubconfig.FetchMemberClusterNamespace()
 	if err != nil {
@@ -213,15 +245,15 @@ func prepareHubParameters(memberConfig *rest.Config) (*rest.Config, *ctrl.Option
 	hubOptions := &ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
-			BindAddress: *hubMetricsAddr,
+			BindAddress: cfg.Hub.MetricsBindAddress,
 		},
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 9443,
 		}),
-		HealthProbeBindAddress:  *hubProbeAddr,
-		LeaderElection:          *enableLeaderElection,
+		HealthProbeBindAddress:  cfg.Hub.HealthProbeBindAddress,
+		LeaderElection:          cfg.LeaderElection.LeaderElect,
 		LeaderElectionID:        "2bf2b407.hub.networking.fleet.azure.com",
-		LeaderElectionNamespace: *leaderElectionNamespace, // This requires we have access to resource "leases" in API group "coordination.k8s.io" under leaderElectionNamespace.
+		LeaderElectionNamespace: cfg.LeaderElection.ResourceNamespace, // This requires we have access to resource "leases" in API group "coordination.k8s.io" under leaderElectionNamespace.
 		LeaderElectionConfig:    memberConfig,
 		// Restricts the manager's cache to watch objects in the member hub namespace.
 		Cache: cache.Options{
@@ -237,20 +269,26 @@ func prepareMemberParameters() (*rest.Config, *ctrl.Options) {
 	memberOpts := &ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
-			BindAddress: *metricsAddr,
+			BindAddress: cfg.Member.MetricsBindAddress,
 		},
 		WebhookServer: webhook.NewServer(webhook.Options{
 			Port: 8443,
 		}),
-		HealthProbeBindAddress:  *probeAddr,
-		LeaderElection:          *enableLeaderElection,
-		LeaderElectionNamespace: *leaderElectionNamespace,
+		HealthProbeBindAddress:  cfg.Member.HealthProbeBindAddress,
+		LeaderElection:          cfg.LeaderElection.LeaderElect,
+		LeaderElectionNamespace: cfg.LeaderElection.ResourceNamespace,
 		LeaderElectionID:        "2bf2b407.member.networking.fleet.azure.com",
+		// The controllers drain their in-flight writes to the hub cluster on shutdown; give them the time to.
+		GracefulShutdownTimeout: ptr.To(cfg.Hub.WriteDrainTimeout.Duration + gracefulShutdownMargin),
+		// Managed fields are never read by the controllers; strip them to reduce the memory used by the cache.
+		Cache: cache.Options{
+			DefaultTransform: cache.TransformStripManagedFields(),
+		},
 	}
 	return ctrl.GetConfigOrDie(), memberOpts
 }
 
-func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.Manager) error {
+func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.Manager, loadedConfig []byte) error {
 	klog.V(1).InfoS("Begin to setup controllers with controller manager")
 
 	mcName, err := env.LookupMemberClusterName()
@@ -266,23 +304,148 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 	}
 
 	memberClient := memberMgr.GetClient()
+	// Reads of the hub client, e.g. the EndpointSliceExport lookups on every EndpointSlice reconciliation, are
+	// served from the informer cache of the hub manager, which watches the member cluster hub namespace only;
+	// writes go to the API server.
 	hubClient := hubMgr.GetClient()
 
+	// The controllers writing to the hub cluster share one retry budget, so that they back off together when the
+	// hub API server is under pressure.
+	var hubWriteRetryBudget *retrybudget.Budget
+	if cfg.Hub.WriteRetryBudget.Rate > 0 {
+		hubWriteRetryBudget = retrybudget.New(cfg.Hub.WriteRetryBudget.Rate, cfg.Hub.WriteRetryBudget.Burst)
+	}
+
+	if *configFile != "" {
+		// Reload the settings which are safe to change at runtime when the configuration file changes.
+		if err := memberMgr.Add(&configreload.Watcher{
+			Path:   *configFile,
+			Reload: func(data []byte) error { return reloadConfiguration(data, hubWriteRetryBudget) },
+			Loaded: loadedConfig,
+		}); err != nil {
+			klog.ErrorS(err, "Unable to add the configuration file watcher")
+			return err
+		}
+	}
+
+	// Pace the initial export if the member cluster joins the fleet with a large backlog, so that it does not
+	// saturate the hub cluster; the managers have not started yet, so the backlog is read from the API servers.
+	//
+	// The pacing only protects the hub cluster; the member agent starts without it rather than waiting for the hub
+	// cluster if the backlog cannot be detected.
+	var initialSyncPacer *initialsync.Pacer
+	if cfg.InitialSync.Rate > 0 {
+		detectCtx, cancel := context.WithTimeout(ctx, initialsync.DetectBacklogTimeout)
+		backlog, err := initialsync.DetectBacklog(detectCtx, memberMgr.GetAPIReader(), hubMgr.GetAPIReader(), mcHubNamespace, cfg.InitialSync.Threshold)
+		cancel()
+		if err != nil {
+			klog.ErrorS(err, "Unable to detect the backlog of the initial export; the initial export is not paced")
+		}
+		initialSyncPacer = initialsync.New(mcName, cfg.InitialSync.Rate, cfg.InitialSync.Burst, backlog)
+		if err := memberMgr.Add(&initialsync.StatusReporter{
+			Pacer:     initialSyncPacer,
+			Client:    memberClient,
+			Namespace: cfg.FleetSystemNamespace,
+		}); err != nil {
+			klog.ErrorS(err, "Unable to add the initial export status reporter")
+			return err
+		}
+	}
+
+	// Check whether the hub CRDs support the enabled features and learn the schemas of the exported objects at startup
+	// and periodically afterwards, so that the features are disabled and the unsupported fields are stripped from the
+	// exports while the hub cluster is not upgraded yet.
+	hubSchemaChecker := &hubschema.Checker{
+		HubClient: hubMgr.GetAPIReader(),
+		Interval:  cfg.Hub.SchemaCheckInterval.Duration,
+	}
+	if cfg.TrafficManager.Enabled {
+		hubSchemaChecker.Features = []hubschema.Feature{hubschema.FeatureTrafficManager}
+	}
+	if err := hubSchemaChecker.Check(ctx); err != nil {
+		// The features are considered available, and the exports are written without strict field validation, until
+		// the check succeeds.
+		klog.ErrorS(err, "Failed to check the hub CRDs at startup; will retry periodically")
+	}
+	if err := hubMgr.Add(hubSchemaChecker); err != nil {
+		klog.ErrorS(err, "Unable to add the hub schema checker")
+		return err
+	}
+	exportHubClient := hubSchemaChecker.WithFieldValidation(hubClient)
+
+	// Pause the exports while the member cluster is under resource pressure; the nodes and Pods are read from a cache
+	// of their own, and the node metrics, which cannot be watched, from the API server.
+	var resourcePressureMonitor *endpointslice.ResourcePressureMonitor
+	if rp := cfg.EndpointSlice.ResourcePressure; rp.PodCIDRUtilizationThreshold > 0 || rp.CPUUtilizationThreshold > 0 {
+		resourcePressureCache, err := endpointslice.NewResourcePressureCache(memberMgr)
+		if err != nil {
+			klog.ErrorS(err, "Unable to create the resource pressure cache")
+			return err
+		}
+		if err := memberMgr.Add(resourcePressureCache); err != nil {
+			klog.ErrorS(err, "Unable to add the resource pressure cache")
+			return err
+		}
+		resourcePressureMonitor = &endpointslice.ResourcePressureMonitor{
+			MemberClient:                resourcePressureCache,
+			MetricsClient:               memberMgr.GetAPIReader(),
+			PodCIDRUtilizationThreshold: rp.PodCIDRUtilizationThreshold,
+			CPUUtilizationThreshold:     rp.CPUUtilizationThreshold,
+			Interval:                    rp.CheckInterval.Duration,
+		}
+		if err := memberMgr.Add(resourcePressureMonitor); err != nil {
+			klog.ErrorS(err, "Unable to add the resource pressure monitor")
+			return err
+		}
+	}
+
+	// Fail fast if the member cluster is too old to serve the v1 EndpointSlices the controllers watch.
+	memberDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(memberMgr.GetConfig())
+	if err != nil {
+		klog.ErrorS(err, "Unable to create the member cluster discovery client")
+		return err
+	}
+	if err := endpointslice.CheckAPIVersion(memberDiscoveryClient); err != nil {
+		klog.ErrorS(err, "The member cluster does not serve the EndpointSlice API version the controllers work with")
+		return err
+	}
+
 	klog.V(1).InfoS("Create endpointslice controller")
-	if err := (&endpointslice.Reconciler{
-		MemberClusterID: mcName,
-		MemberClient:    memberClient,
-		HubClient:       hubClient,
-		HubNamespace:    mcHubNamespace,
-	}).SetupWithManager(ctx, memberMgr); err != nil {
+	endpointSliceReconciler := &endpointslice.Reconciler{
+		MemberClusterID:         mcName,
+		MemberClient:            memberClient,
+		MemberAPIReader:         memberMgr.GetAPIReader(),
+		HubClient:               exportHubClient,
+		HubNamespace:            mcHubNamespace,
+		Recorder:                memberMgr.GetEventRecorderFor(endpointslice.ControllerName),
+		CircuitBreaker:          circuitbreaker.New(cfg.EndpointSlice.CircuitBreakerThreshold, cfg.EndpointSlice.CircuitBreakerCoolDown.Duration),
+		ExportNotReadyAddresses: cfg.EndpointSlice.ExportNotReadyAddresses,
+		EnableEndpointSelector:  cfg.EndpointSlice.EnableEndpointSelector,
+		RetryBudget:             hubWriteRetryBudget,
+		RateLimiter:             newNamespaceIsolationRateLimiter(endpointslice.ControllerName),
+		DrainTimeout:            cfg.Hub.WriteDrainTimeout.Duration,
+		InitialSyncPacer:        initialSyncPacer,
+		MaxConcurrentReconciles: cfg.EndpointSlice.MaxConcurrentReconciles,
+		HubSchemaChecker:        hubSchemaChecker,
+		RequeueJitter:           cfg.EndpointSlice.RequeueJitter.Duration,
+		ResourcePressureMonitor: resourcePressureMonitor,
+		ChurnProtection: endpointslice.ChurnProtection{
+			Threshold:          cfg.EndpointSlice.ChurnProtection.Threshold,
+			RecoveryThreshold:  cfg.EndpointSlice.ChurnProtection.RecoveryThreshold,
+			Window:             cfg.EndpointSlice.ChurnProtection.Window.Duration,
+			CoalescingInterval: cfg.EndpointSlice.ChurnProtection.CoalescingInterval.Duration,
+		},
+	}
+	if err := endpointSliceReconciler.SetupWithManager(ctx, memberMgr); err != nil {
 		klog.ErrorS(err, "Unable to create endpointslice controller")
 		return err
 	}
 
 	klog.V(1).InfoS("Create endpointsliceexport controller")
 	if err := (&endpointsliceexport.Reconciler{
-		MemberClient: memberClient,
-		HubClient:    hubClient,
+		MemberClient:      memberClient,
+		HubClient:         hubClient,
+		HeartbeatInterval: cfg.EndpointSlice.ExportHeartbeatInterval.Duration,
 	}).SetupWithManager(hubMgr); err != nil {
 		klog.ErrorS(err, "Unable to create endpointsliceexport controller")
 		return err
@@ -293,7 +456,8 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 		MemberClusterID:      mcName,
 		MemberClient:         memberClient,
 		HubClient:            hubClient,
-		FleetSystemNamespace: *fleetSystemNamespace,
+		FleetSystemNamespace: cfg.FleetSystemNamespace,
+		HubNamespace:         mcHubNamespace,
 	}).SetupWithManager(ctx, memberMgr, hubMgr); err != nil {
 		klog.ErrorS(err, "Unable to create endpointsliceimport controller")
 		return err
@@ -321,17 +485,17 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 
 	var azurePublicIPAddressClient publicipaddressclient.Interface
 	var resourceGroupName string
-	if *enableTrafficManagerFeature {
-		klog.V(1).InfoS("Traffic manager feature is enabled, loading cloud config and creating azure clients", "cloudConfigFile", *cloudConfigFile)
-		cloudConfig, err := azure.NewCloudConfigFromFile(*cloudConfigFile)
+	if cfg.TrafficManager.Enabled {
+		klog.V(1).InfoS("Traffic manager feature is enabled, loading cloud config and creating azure clients", "cloudConfigFile", cfg.TrafficManager.CloudConfigFile)
+		cloudConfig, err := azure.NewCloudConfigFromFile(cfg.TrafficManager.CloudConfigFile)
 		if err != nil {
-			klog.ErrorS(err, "Unable to load cloud config", "file name", *cloudConfigFile)
+			klog.ErrorS(err, "Unable to load cloud config", "file name", cfg.TrafficManager.CloudConfigFile)
 			return err
 		}
 		cloudConfig.SetUserAgent("fleet-member-net-controller-manager")
 		klog.V(1).InfoS("Cloud config loaded", "cloudConfig", cloudConfig)
 
-		azurePublicIPAddressClient, err = initAzureNetworkClients(cloudConfig)
+		azurePublicIPAddressClient, err = initAzureNetworkClients(ctx, cloudConfig)
 		if err != nil {
 			klog.ErrorS(err, "Unable to create Azure Traffic Manager clients")
 			return err
@@ -340,21 +504,40 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 		resourceGroupName = cloudConfig.ResourceGroup
 	}
 
-	klog.V(1).InfoS("Create serviceexport reconciler", "enableTrafficManagerFeature", *enableTrafficManagerFeature)
-	if err := (&serviceexport.Reconciler{
+	klog.V(1).InfoS("Create serviceexport reconciler", "enableTrafficManagerFeature", cfg.TrafficManager.Enabled)
+	svcExportReconciler := &serviceexport.Reconciler{
 		MemberClient:                memberClient,
-		HubClient:                   hubClient,
+		HubClient:                   exportHubClient,
 		MemberClusterID:             mcName,
 		HubNamespace:                mcHubNamespace,
 		Recorder:                    memberMgr.GetEventRecorderFor(serviceexport.ControllerName),
-		EnableTrafficManagerFeature: *enableTrafficManagerFeature,
+		EnableTrafficManagerFeature: cfg.TrafficManager.Enabled,
+		HubSchemaChecker:            hubSchemaChecker,
+		OpenAPISpecReader:           memberMgr.GetAPIReader(),
 		ResourceGroupName:           resourceGroupName,
 		AzurePublicIPAddressClient:  azurePublicIPAddressClient,
-	}).SetupWithManager(memberMgr); err != nil {
+		ServiceNotFoundRequeueAfter: cfg.ServiceExport.ServiceNotFoundRequeueAfter.Duration,
+		CleanupFinalizer:            cfg.ServiceExport.CleanupFinalizer,
+		RetryBudget:                 hubWriteRetryBudget,
+		RateLimiter:                 newNamespaceIsolationRateLimiter(serviceexport.ControllerName),
+		DrainTimeout:                cfg.Hub.WriteDrainTimeout.Duration,
+		InitialSyncPacer:            initialSyncPacer,
+		UnexportGracePeriod:         cfg.ServiceExport.UnexportGracePeriod.Duration,
+		MaxConcurrentReconciles:     cfg.ServiceExport.MaxConcurrentReconciles,
+	}
+	if err := svcExportReconciler.SetupWithManager(memberMgr); err != nil {
 		klog.ErrorS(err, "Unable to create serviceexport reconciler")
 		return err
 	}
 
+	if cfg.EnableServiceExportWebhook {
+		klog.V(1).InfoS("Start to setup ServiceExport webhook")
+		if err := serviceexportwebhook.SetupWebhookWithManager(memberMgr); err != nil {
+			klog.ErrorS(err, "Unable to create ServiceExport webhook")
+			return err
+		}
+	}
+
 	klog.V(1).InfoS("Create serviceimport reconciler")
 	if err := (&serviceimport.Reconciler{
 		MemberClient:    memberClient,
@@ -366,25 +549,50 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 		return err
 	}
 
-	if *isV1Alpha1APIEnabled {
-		klog.V(1).InfoS("Create internalmembercluster (v1alpha1 API) reconciler")
-		if err := (&imcv1alpha1.Reconciler{
+	if cfg.MCSAPICompatibility.Enabled {
+		mode := mcsserviceexport.Mode(cfg.MCSAPICompatibility.Mode)
+		klog.V(1).InfoS("Create mcs serviceexport reconciler", "mode", mode)
+		if err := (&mcsserviceexport.Reconciler{
 			MemberClient: memberClient,
-			HubClient:    hubClient,
-			AgentType:    fleetv1alpha1.ServiceExportImportAgent,
-		}).SetupWithManager(hubMgr); err != nil {
+			Recorder:     memberMgr.GetEventRecorderFor(mcsserviceexport.ControllerName),
+			Mode:         mode,
+		}).SetupWithManager(memberMgr); err != nil {
+			klog.ErrorS(err, "Unable to create mcs serviceexport reconciler")
+			return err
+		}
+	}
+
+	if cfg.EnableV1Alpha1APIs {
+		klog.V(1).InfoS("Create internalmembercluster (v1alpha1 API) reconciler")
+		imcReconciler := &imcv1alpha1.Reconciler{
+			MemberClient:   memberClient,
+			HubClient:      hubClient,
+			AgentType:      fleetv1alpha1.ServiceExportImportAgent,
+			ExportCleaners: []imc.ExportCleaner{svcExportReconciler, endpointSliceReconciler},
+		}
+		imcReconciler.AgentConditionReporters = []imcv1alpha1.AgentConditionReporter{hubSchemaChecker}
+		if resourcePressureMonitor != nil {
+			imcReconciler.AgentConditionReporters = append(imcReconciler.AgentConditionReporters, resourcePressureMonitor)
+		}
+		if err := imcReconciler.SetupWithManager(hubMgr); err != nil {
 			klog.ErrorS(err, "Unable to create internalmembercluster (v1alpha1 API) reconciler")
 			return err
 		}
 	}
 
-	if *isV1Beta1APIEnabled {
+	if cfg.EnableV1Beta1APIs {
 		klog.V(1).InfoS("Create internalmembercluster (v1beta1 API) reconciler")
-		if err := (&imcv1beta1.Reconciler{
-			MemberClient: memberClient,
-			HubClient:    hubClient,
-			AgentType:    clusterv1beta1.ServiceExportImportAgent,
-		}).SetupWithManager(hubMgr); err != nil {
+		imcReconciler := &imcv1beta1.Reconciler{
+			MemberClient:   memberClient,
+			HubClient:      hubClient,
+			AgentType:      clusterv1beta1.ServiceExportImportAgent,
+			ExportCleaners: []imc.ExportCleaner{svcExportReconciler, endpointSliceReconciler},
+		}
+		imcReconciler.AgentConditionReporters = []imcv1beta1.AgentConditionReporter{hubSchemaChecker}
+		if resourcePressureMonitor != nil {
+			imcReconciler.AgentConditionReporters = append(imcReconciler.AgentConditionReporters, resourcePressureMonitor)
+		}
+		if err := imcReconciler.SetupWithManager(hubMgr); err != nil {
 			klog.ErrorS(err, "Unable to create internalmembercluster (v1beta1 API) reconciler")
 			return err
 		}
@@ -394,9 +602,49 @@ func setupControllersWithManager(ctx context.Context, hubMgr, memberMgr manager.
 	return nil
 }
 
+// newNamespaceIsolationRateLimiter returns the workqueue rate limiter of the named controller, which slows down the
+// retries of the namespaces whose objects keep failing to reconcile; it returns nil, i.e. the default rate limiter of
+// the controller, if the isolation is disabled.
+func newNamespaceIsolationRateLimiter(controllerName string) workqueue.TypedRateLimiter[reconcile.Request] {
+	if cfg.NamespaceIsolation.FailureThreshold <= 0 {
+		return nil
+	}
+	return namespaceisolation.New(controllerName, namespaceisolation.Config{
+		FailureThreshold:     cfg.NamespaceIsolation.FailureThreshold,
+		Window:               cfg.NamespaceIsolation.Window.Duration,
+		DemotedRetryInterval: cfg.NamespaceIsolation.DemotedRetryInterval.Duration,
+	}, workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+}
+
+// selfTestHubConnectivity checks that the hub cluster is reachable with the hub config.
+func selfTestHubConnectivity(hubConfig *rest.Config) error {
+	client, err := rest.HTTPClientFor(hubConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create the hub cluster HTTP client: %w", err)
+	}
+	serverURL, _, err := rest.DefaultServerUrlFor(hubConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get the hub cluster server URL: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectivitySelfTestTimeout)
+	defer cancel()
+	return egressproxy.SelfTest(ctx, client, serverURL.JoinPath("version").String())
+}
+
 // initAzureNetworkClients initializes the Azure network resource clients, currently only publicIPAddressClient.
-func initAzureNetworkClients(cloudConfig *azure.CloudConfig) (publicipaddressclient.Interface, error) {
-	authProvider, err := azclient.NewAuthProvider(&cloudConfig.ARMClientConfig, &cloudConfig.AzureAuthConfig)
+func initAzureNetworkClients(ctx context.Context, cloudConfig *azure.CloudConfig) (publicipaddressclient.Interface, error) {
+	armProxy := armProxyConfig()
+	var armTransport *http.Transport
+	var authOptionsMutFns []func(*azpolicy.ClientOptions)
+	if armProxy.IsSet() {
+		armTransport = armProxy.Transport(utils.DefaultTransport)
+		// The token requests to Microsoft Entra ID go through the same proxy as the requests to Azure Resource Manager.
+		authOptionsMutFns = append(authOptionsMutFns, func(options *azpolicy.ClientOptions) {
+			options.Transport = &http.Client{Transport: armTransport}
+		})
+	}
+
+	authProvider, err := azclient.NewAuthProvider(&cloudConfig.ARMClientConfig, &cloudConfig.AzureAuthConfig, authOptionsMutFns...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Azure auth provider: %w", err)
 	}
@@ -414,6 +662,26 @@ func initAzureNetworkClients(cloudConfig *azure.CloudConfig) (publicipaddresscli
 		options.ClientOptions.PerCallPolicies = append(options.ClientOptions.PerCallPolicies, rateLimitPolicy)
 	}
 
+	if armProxy.IsSet() {
+		// Keep the connection pool of the default resource client transport, on top of the proxied transport.
+		options.ClientOptions.Transport = &http.Client{
+			Transport: armbalancer.New(ctx, armbalancer.Options{Transport: armTransport, PoolSize: 100}),
+		}
+		targets := []string{
+			options.ClientOptions.Cloud.Services[cloud.ResourceManager].Endpoint,
+			options.ClientOptions.Cloud.ActiveDirectoryAuthorityHost,
+		}
+		for _, target := range targets {
+			selfTestCtx, cancel := context.WithTimeout(ctx, connectivitySelfTestTimeout)
+			err := egressproxy.SelfTest(selfTestCtx, &http.Client{Transport: armTransport}, target)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to Azure through the proxy %s: %w", armProxy, err)
+			}
+			klog.InfoS("Connected to Azure through the proxy", "target", target, "proxy", armProxy)
+		}
+	}
+
 	pipClient, err := publicipaddressclient.New(cloudConfig.SubscriptionID, authProvider.GetAzIdentity(), options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Azure PublicIPAddress client: %w", err)
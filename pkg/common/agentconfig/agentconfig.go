@@ -0,0 +1,189 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package agentconfig features the loading of the configuration of an agent from its configuration file, if any, and
+// the flags set on the command line, which override the settings of the file.
+package agentconfig
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Loader loads the configuration of an agent; T is the pointer type of the configuration, e.g.
+// *configv1alpha1.HubAgentConfiguration.
+type Loader[T any] struct {
+	// Flags is the flag set of the command line; flag.CommandLine is used if it is not set.
+	Flags *flag.FlagSet
+	// BindFlags defines the flags which set the settings of a configuration, with the settings as the defaults.
+	BindFlags func(fs *flag.FlagSet, c T)
+	// Decode decodes the content of a configuration file, with the settings it does not set defaulted.
+	Decode func(data []byte) (T, error)
+	// Validate validates a configuration.
+	Validate func(c T) field.ErrorList
+	// LogVerbosity returns the log verbosity setting of a configuration.
+	LogVerbosity func(c T) *int32
+
+	recordLogVerbosityFlagOnce sync.Once
+	// logVerbosityFlagSet is whether the -v flag is set on the command line; it is recorded before the log verbosity
+	// of a configuration is first applied, which sets the flag too.
+	logVerbosityFlagSet bool
+}
+
+// Load returns the effective configuration of the agent, i.e. the configuration file, if any, with the flags set on
+// the command line applied, or the configuration the flags are bound to otherwise; it returns the content of the
+// configuration file as well. The log verbosity of the effective configuration is applied.
+func (l *Loader[T]) Load(configFile string, flagBound T) (T, []byte, error) {
+	var zero T
+	l.recordLogVerbosityFlag()
+	c := flagBound
+	var data []byte
+	if configFile != "" {
+		var err error
+		if data, err = os.ReadFile(configFile); err != nil {
+			return zero, nil, fmt.Errorf("failed to read the configuration file: %w", err)
+		}
+		if c, err = l.decode(data); err != nil {
+			return zero, nil, err
+		}
+	} else if err := l.validate(c); err != nil {
+		return zero, nil, err
+	}
+	if err := l.SetLogVerbosity(c); err != nil {
+		return zero, nil, err
+	}
+	return c, data, nil
+}
+
+// Reload returns the configuration of the changed content of the configuration file, with the flags set on the
+// command line applied, and applies its log verbosity; the agent applies the other settings which are safe to change
+// at runtime.
+func (l *Loader[T]) Reload(data []byte) (T, error) {
+	var zero T
+	c, err := l.decode(data)
+	if err != nil {
+		return zero, err
+	}
+	if err := l.SetLogVerbosity(c); err != nil {
+		return zero, err
+	}
+	return c, nil
+}
+
+// decode decodes the content of the configuration file and overrides its settings with the flags set on the command
+// line; the resulting configuration is validated.
+func (l *Loader[T]) decode(data []byte) (T, error) {
+	var zero T
+	c, err := l.Decode(data)
+	if err != nil {
+		return zero, err
+	}
+	overrides := flag.NewFlagSet("overrides", flag.ContinueOnError)
+	l.BindFlags(overrides, c)
+	var overrideErr error
+	l.flags().Visit(func(f *flag.Flag) {
+		if overrides.Lookup(f.Name) != nil && overrideErr == nil {
+			overrideErr = overrides.Set(f.Name, f.Value.String())
+		}
+	})
+	if overrideErr != nil {
+		return zero, fmt.Errorf("failed to override the configuration file with the flags: %w", overrideErr)
+	}
+	if err := l.validate(c); err != nil {
+		return zero, err
+	}
+	return c, nil
+}
+
+func (l *Loader[T]) validate(c T) error {
+	if err := l.Validate(c).ToAggregate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+// SetLogVerbosity applies the log verbosity of the configuration, unless it is set by the -v flag on the command
+// line, which then sets the verbosity of the configuration.
+func (l *Loader[T]) SetLogVerbosity(c T) error {
+	v := l.flags().Lookup("v")
+	if v == nil {
+		return fmt.Errorf("the -v flag is not defined")
+	}
+	if l.recordLogVerbosityFlag() {
+		verbosity, err := strconv.ParseInt(v.Value.String(), 10, 32)
+		if err != nil {
+			return fmt.Errorf("failed to parse the log verbosity: %w", err)
+		}
+		*l.LogVerbosity(c) = int32(verbosity)
+		return nil
+	}
+	return l.flags().Set("v", strconv.Itoa(int(*l.LogVerbosity(c))))
+}
+
+// recordLogVerbosityFlag records, on its first call, whether the -v flag is set on the command line, and returns it.
+func (l *Loader[T]) recordLogVerbosityFlag() bool {
+	l.recordLogVerbosityFlagOnce.Do(func() {
+		l.logVerbosityFlagSet = l.IsFlagSet("v")
+	})
+	return l.logVerbosityFlagSet
+}
+
+// IsFlagSet returns whether the named flag is set on the command line.
+func (l *Loader[T]) IsFlagSet(name string) bool {
+	set := false
+	l.flags().Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func (l *Loader[T]) flags() *flag.FlagSet {
+	if l.Flags == nil {
+		return flag.CommandLine
+	}
+	return l.Flags
+}
+
+// Log logs the effective configuration of an agent; the secrets it may hold must be redacted first.
+func Log(c interface{}) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		klog.ErrorS(err, "Failed to marshal the effective configuration")
+		return
+	}
+	klog.InfoS("Effective configuration", "config", string(data))
+}
+
+// CommaSeparatedStrings is a flag which sets a list of strings from a comma-separated value.
+type CommaSeparatedStrings struct {
+	Values *[]string
+}
+
+func (s CommaSeparatedStrings) String() string {
+	if s.Values == nil {
+		return ""
+	}
+	return strings.Join(*s.Values, ",")
+}
+
+func (s CommaSeparatedStrings) Set(value string) error {
+	*s.Values = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s.Values = append(*s.Values, item)
+		}
+	}
+	return nil
+}
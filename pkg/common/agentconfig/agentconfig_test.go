@@ -0,0 +1,153 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package agentconfig
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+type testConfiguration struct {
+	LogVerbosity int32    `json:"logVerbosity"`
+	Name         string   `json:"name"`
+	Hosts        []string `json:"hosts"`
+}
+
+func newTestLoader(fs *flag.FlagSet, flagBound *testConfiguration) *Loader[*testConfiguration] {
+	l := &Loader[*testConfiguration]{
+		Flags: fs,
+		BindFlags: func(fs *flag.FlagSet, c *testConfiguration) {
+			fs.StringVar(&c.Name, "name", c.Name, "")
+			fs.Var(CommaSeparatedStrings{Values: &c.Hosts}, "hosts", "")
+		},
+		Decode: func(data []byte) (*testConfiguration, error) {
+			c := &testConfiguration{Name: "default"}
+			return c, yaml.UnmarshalStrict(data, c)
+		},
+		Validate: func(c *testConfiguration) field.ErrorList {
+			if c.Name == "" {
+				return field.ErrorList{field.Required(field.NewPath("name"), "")}
+			}
+			return nil
+		},
+		LogVerbosity: func(c *testConfiguration) *int32 { return &c.LogVerbosity },
+	}
+	fs.String("v", "0", "")
+	l.BindFlags(fs, flagBound)
+	return l
+}
+
+func TestLoad(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("logVerbosity: 4\nname: file\nhosts: [a.example.com]\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() = %v, want no error", err)
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		configFile string
+		want       *testConfiguration
+		wantErr    bool
+	}{
+		{
+			name: "flags only",
+			args: []string{"--name=flag", "--hosts=a.example.com, b.example.com"},
+			want: &testConfiguration{Name: "flag", Hosts: []string{"a.example.com", "b.example.com"}},
+		},
+		{
+			name:       "configuration file",
+			configFile: configFile,
+			want:       &testConfiguration{LogVerbosity: 4, Name: "file", Hosts: []string{"a.example.com"}},
+		},
+		{
+			name:       "flags override the configuration file",
+			args:       []string{"--v=2", "--hosts=b.example.com"},
+			configFile: configFile,
+			want:       &testConfiguration{LogVerbosity: 2, Name: "file", Hosts: []string{"b.example.com"}},
+		},
+		{
+			name:    "invalid configuration",
+			args:    []string{"--name="},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flagBound := &testConfiguration{Name: "default"}
+			l := newTestLoader(fs, flagBound)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse() = %v, want no error", err)
+			}
+			got, _, err := l.Load(tc.configFile, flagBound)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Load() = %v, want error %t", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Load() mismatch (-want, +got):\n%s", diff)
+			}
+			// The log verbosity of the effective configuration is applied.
+			if got, want := fs.Lookup("v").Value.String(), strconv.Itoa(int(tc.want.LogVerbosity)); got != want {
+				t.Errorf("-v = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("logVerbosity: 4\nname: file\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() = %v, want no error", err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		want *testConfiguration
+	}{
+		{
+			name: "log verbosity of the changed file is applied",
+			want: &testConfiguration{LogVerbosity: 6, Name: "reloaded"},
+		},
+		{
+			name: "log verbosity set on the command line overrides the changed file",
+			args: []string{"--v=2"},
+			want: &testConfiguration{LogVerbosity: 2, Name: "reloaded"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flagBound := &testConfiguration{Name: "default"}
+			l := newTestLoader(fs, flagBound)
+			if err := fs.Parse(tc.args); err != nil {
+				t.Fatalf("Parse() = %v, want no error", err)
+			}
+			if _, _, err := l.Load(configFile, flagBound); err != nil {
+				t.Fatalf("Load() = %v, want no error", err)
+			}
+			got, err := l.Reload([]byte("logVerbosity: 6\nname: reloaded\n"))
+			if err != nil {
+				t.Fatalf("Reload() = %v, want no error", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Reload() mismatch (-want, +got):\n%s", diff)
+			}
+			if got, want := fs.Lookup("v").Value.String(), strconv.Itoa(int(tc.want.LogVerbosity)); got != want {
+				t.Errorf("-v = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -19,6 +19,12 @@ func IsNotFound(err error) bool {
 	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusNotFound
 }
 
+// IsBadRequest determines if the error is a http 400 error returned by the azure server.
+func IsBadRequest(err error) bool {
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusBadRequest
+}
+
 // IsClientError returns true if the error is a client error (400-499) returned by the azure server.
 func IsClientError(err error) bool {
 	var responseError *azcore.ResponseError
@@ -37,3 +43,9 @@ func IsThrottled(err error) bool {
 	var responseError *azcore.ResponseError
 	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusTooManyRequests
 }
+
+// IsServerError determines if the error is a server error (500-599) returned by the azure server.
+func IsServerError(err error) bool {
+	var responseError *azcore.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode >= http.StatusInternalServerError
+}
@@ -86,6 +86,43 @@ func TestIsClientError(t *testing.T) {
 	}
 }
 
+func TestIsBadRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "not azure error",
+			err:  errors.New("not azure error"),
+			want: false,
+		},
+		{
+			name: "bad request error",
+			err:  &azcore.ResponseError{StatusCode: 400},
+			want: true,
+		},
+		{
+			name: "conflict error",
+			err:  &azcore.ResponseError{StatusCode: 409},
+			want: false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := IsBadRequest(tc.err)
+			if got != tc.want {
+				t.Errorf("IsBadRequest() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestIsConflict(t *testing.T) {
 	tests := []struct {
 		name string
@@ -159,3 +196,45 @@ func TestIsThrottled(t *testing.T) {
 		})
 	}
 }
+
+func TestIsServerError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "not azure error",
+			err:  errors.New("not azure error"),
+			want: false,
+		},
+		{
+			name: "throttled error",
+			err:  &azcore.ResponseError{StatusCode: 429},
+			want: false,
+		},
+		{
+			name: "internal server error",
+			err:  &azcore.ResponseError{StatusCode: 500},
+			want: true,
+		},
+		{
+			name: "service unavailable error",
+			err:  &azcore.ResponseError{StatusCode: 503},
+			want: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := IsServerError(tc.err)
+			if got != tc.want {
+				t.Errorf("IsServerError() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package azuretags features the conversion of the azure-tag annotations of fleet networking objects into the tags
+// of the Azure resources created for them, e.g. for cost allocation and governance.
+package azuretags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// MaxTags is the maximum number of azure-tag annotations an object may have: Azure allows 50 tags on a resource,
+	// one of which is the tag marking the resource as created by fleet.
+	MaxTags = 49
+	// MaxKeyLength is the maximum length of the key of an Azure resource tag.
+	MaxKeyLength = 512
+	// MaxValueLength is the maximum length of the value of an Azure resource tag.
+	MaxValueLength = 256
+)
+
+// FromAnnotations returns the Azure resource tags defined by the azure-tag annotations, keyed by the annotation
+// suffix, e.g. the annotation "networking.fleet.azure.com/azure-tag-costCenter: 1234" defines the tag
+// "costCenter: 1234".
+//
+// Tags whose keys or values exceed the Azure limits are left out of the tags, and an error describing each of them is
+// returned, sorted by key, so that the caller can warn about them.
+func FromAnnotations(annotations map[string]string) (map[string]string, []error) {
+	tags := map[string]string{}
+	var invalid []error
+	for _, key := range annotationKeys(annotations) {
+		tagKey := strings.TrimPrefix(key, objectmeta.AzureTagAnnotationPrefix)
+		value := annotations[key]
+		switch {
+		case tagKey == "":
+			invalid = append(invalid, fmt.Errorf("annotation %s defines an Azure tag with an empty key", key))
+		case len(tagKey) > MaxKeyLength:
+			invalid = append(invalid, fmt.Errorf("the key of Azure tag %q is longer than %d characters", tagKey, MaxKeyLength))
+		case len(value) > MaxValueLength:
+			invalid = append(invalid, fmt.Errorf("the value of Azure tag %q is longer than %d characters", tagKey, MaxValueLength))
+		default:
+			tags[tagKey] = value
+		}
+	}
+	return tags, invalid
+}
+
+// Count returns the number of azure-tag annotations.
+func Count(annotations map[string]string) int {
+	return len(annotationKeys(annotations))
+}
+
+// annotationKeys returns the sorted keys of the azure-tag annotations.
+func annotationKeys(annotations map[string]string) []string {
+	var keys []string
+	for key := range annotations {
+		if strings.HasPrefix(key, objectmeta.AzureTagAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,67 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package azuretags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+func TestFromAnnotations(t *testing.T) {
+	longValue := strings.Repeat("v", MaxValueLength+1)
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantTags    map[string]string
+		wantInvalid []string
+	}{
+		{
+			name:     "no annotations",
+			wantTags: map[string]string{},
+		},
+		{
+			name: "tags among other annotations",
+			annotations: map[string]string{
+				objectmeta.AzureTagAnnotationPrefix + "costCenter": "1234",
+				objectmeta.AzureTagAnnotationPrefix + "env":        "",
+				objectmeta.ServiceExportAnnotationWeight:           "10",
+			},
+			wantTags: map[string]string{"costCenter": "1234", "env": ""},
+		},
+		{
+			name: "invalid tags are left out",
+			annotations: map[string]string{
+				objectmeta.AzureTagAnnotationPrefix:                 "no key",
+				objectmeta.AzureTagAnnotationPrefix + "description": longValue,
+				objectmeta.AzureTagAnnotationPrefix + "owner":       "team-a",
+			},
+			wantTags: map[string]string{"owner": "team-a"},
+			wantInvalid: []string{
+				"annotation networking.fleet.azure.com/azure-tag- defines an Azure tag with an empty key",
+				`the value of Azure tag "description" is longer than 256 characters`,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gotTags, gotInvalid := FromAnnotations(tc.annotations)
+			if diff := cmp.Diff(tc.wantTags, gotTags); diff != "" {
+				t.Errorf("FromAnnotations() tags mismatch (-want, +got):\n%s", diff)
+			}
+			var gotInvalidMsgs []string
+			for _, err := range gotInvalid {
+				gotInvalidMsgs = append(gotInvalidMsgs, err.Error())
+			}
+			if diff := cmp.Diff(tc.wantInvalid, gotInvalidMsgs); diff != "" {
+				t.Errorf("FromAnnotations() invalid tags mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
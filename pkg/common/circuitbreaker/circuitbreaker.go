@@ -0,0 +1,166 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package circuitbreaker features a simple in-memory, per-key circuit breaker which controllers use to stop
+// calling a dependency (e.g. Azure) that keeps failing for a specific object.
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// State is the state of a circuit.
+type State string
+
+const (
+	// StateClosed means that calls are allowed.
+	StateClosed State = "Closed"
+	// StateOpen means that calls are rejected until the cool-down period ends.
+	StateOpen State = "Open"
+	// StateHalfOpen means that the cool-down period has ended and exactly one trial call is allowed; the result
+	// of the trial call decides whether the circuit closes or opens again.
+	StateHalfOpen State = "HalfOpen"
+)
+
+type circuit struct {
+	state               State
+	consecutiveFailures int
+	openedAt            time.Time
+}
+
+// CircuitBreaker tracks consecutive failures per key; once the number of consecutive failures reaches the
+// threshold, the circuit for the key opens and rejects calls for the cool-down period. After the cool-down
+// period, the circuit half-opens and allows a single trial call.
+//
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	failureThreshold int
+	coolDown         time.Duration
+	clock            clock.PassiveClock
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New returns a CircuitBreaker which opens after failureThreshold consecutive failures and stays open for the
+// coolDown period.
+func New(failureThreshold int, coolDown time.Duration) *CircuitBreaker {
+	return NewWithClock(failureThreshold, coolDown, clock.RealClock{})
+}
+
+// NewWithClock returns a CircuitBreaker which uses the given clock; it is mostly used in tests.
+func NewWithClock(failureThreshold int, coolDown time.Duration, clock clock.PassiveClock) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		coolDown:         coolDown,
+		clock:            clock,
+		circuits:         map[string]*circuit{},
+	}
+}
+
+// CoolDown returns how long a circuit stays open before it half-opens.
+func (cb *CircuitBreaker) CoolDown() time.Duration {
+	return cb.coolDown
+}
+
+// Allow returns whether a call for the key can proceed. If not, it also returns how long the caller should wait
+// before trying again.
+func (cb *CircuitBreaker) Allow(key string) (bool, time.Duration) {
+	allowed, _, retryAfter := cb.AllowTrial(key)
+	return allowed, retryAfter
+}
+
+// AllowTrial is like Allow, but it also returns whether the call is the single trial call of a half-open circuit;
+// the decision is made under the same lock, so that concurrent callers never both consider themselves the trial.
+func (cb *CircuitBreaker) AllowTrial(key string) (allowed, trial bool, retryAfter time.Duration) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		return true, false, 0
+	}
+	switch c.state {
+	case StateOpen:
+		remaining := cb.coolDown - cb.clock.Since(c.openedAt)
+		if remaining > 0 {
+			return false, false, remaining
+		}
+		// The cool-down period has ended; let exactly one trial call through.
+		c.state = StateHalfOpen
+		return true, true, 0
+	case StateHalfOpen:
+		// A trial call is already in flight.
+		return false, false, cb.coolDown
+	default:
+		return true, false, 0
+	}
+}
+
+// RecordSuccess closes the circuit for the key.
+func (cb *CircuitBreaker) RecordSuccess(key string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	// A closed circuit without failures carries no information; drop it so that the map does not grow with
+	// every healthy key.
+	delete(cb.circuits, key)
+}
+
+// RecordFailure records a failed call for the key; it returns true if the circuit is open after the failure.
+func (cb *CircuitBreaker) RecordFailure(key string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		c = &circuit{state: StateClosed}
+		cb.circuits[key] = c
+	}
+	c.consecutiveFailures++
+	if c.state == StateHalfOpen || c.consecutiveFailures >= cb.failureThreshold {
+		c.state = StateOpen
+		c.openedAt = cb.clock.Now()
+	}
+	return c.state == StateOpen
+}
+
+// ReleaseTrial lets another trial call through if the circuit for the key is half-open; callers use it when the
+// trial call they were allowed to make ends up not calling the dependency at all, so that its result is unknown.
+func (cb *CircuitBreaker) ReleaseTrial(key string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.circuits[key]
+	if !ok || c.state != StateHalfOpen {
+		return
+	}
+	// Reopen the circuit as if the cool-down period has just ended.
+	c.state = StateOpen
+	c.openedAt = cb.clock.Now().Add(-cb.coolDown)
+}
+
+// State returns the current state of the circuit for the key.
+func (cb *CircuitBreaker) State(key string) State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[key]
+	if !ok {
+		return StateClosed
+	}
+	return c.state
+}
+
+// Forget removes the circuit for the key, e.g. when the object is deleted.
+func (cb *CircuitBreaker) Forget(key string) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	delete(cb.circuits, key)
+}
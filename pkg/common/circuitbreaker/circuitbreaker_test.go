@@ -0,0 +1,157 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package circuitbreaker
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const (
+	testKey      = "test-ns/test-profile"
+	testCoolDown = time.Minute
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	cb := NewWithClock(3, testCoolDown, fakeClock)
+
+	// The circuit stays closed until the threshold is reached.
+	for i := 0; i < 2; i++ {
+		if allowed, _ := cb.Allow(testKey); !allowed {
+			t.Fatalf("Allow() = false after %d failures, want true", i)
+		}
+		if isOpen := cb.RecordFailure(testKey); isOpen {
+			t.Fatalf("RecordFailure() = true after %d failures, want false", i+1)
+		}
+	}
+	if got := cb.State(testKey); got != StateClosed {
+		t.Fatalf("State() = %v, want %v", got, StateClosed)
+	}
+
+	// The third failure opens the circuit.
+	if isOpen := cb.RecordFailure(testKey); !isOpen {
+		t.Fatalf("RecordFailure() = false after 3 failures, want true")
+	}
+	if allowed, wait := cb.Allow(testKey); allowed || wait != testCoolDown {
+		t.Fatalf("Allow() = (%v, %v), want (false, %v)", allowed, wait, testCoolDown)
+	}
+
+	// Other keys are not affected.
+	if allowed, _ := cb.Allow("other-key"); !allowed {
+		t.Fatalf("Allow(other-key) = false, want true")
+	}
+
+	// The circuit half-opens after the cool-down period and lets exactly one trial call through.
+	fakeClock.SetTime(fakeClock.Now().Add(testCoolDown))
+	if allowed, _ := cb.Allow(testKey); !allowed {
+		t.Fatalf("Allow() = false after the cool-down period, want true")
+	}
+	if got := cb.State(testKey); got != StateHalfOpen {
+		t.Fatalf("State() = %v, want %v", got, StateHalfOpen)
+	}
+	if allowed, _ := cb.Allow(testKey); allowed {
+		t.Fatalf("Allow() = true when a trial call is in flight, want false")
+	}
+
+	// A failed trial call opens the circuit again.
+	if isOpen := cb.RecordFailure(testKey); !isOpen {
+		t.Fatalf("RecordFailure() = false after a failed trial call, want true")
+	}
+	fakeClock.SetTime(fakeClock.Now().Add(testCoolDown / 2))
+	if allowed, wait := cb.Allow(testKey); allowed || wait != testCoolDown/2 {
+		t.Fatalf("Allow() = (%v, %v), want (false, %v)", allowed, wait, testCoolDown/2)
+	}
+
+	// A successful trial call closes the circuit.
+	fakeClock.SetTime(fakeClock.Now().Add(testCoolDown))
+	if allowed, _ := cb.Allow(testKey); !allowed {
+		t.Fatalf("Allow() = false after the cool-down period, want true")
+	}
+	cb.RecordSuccess(testKey)
+	if got := cb.State(testKey); got != StateClosed {
+		t.Fatalf("State() = %v, want %v", got, StateClosed)
+	}
+
+	// The failure count starts over once the circuit is closed.
+	if isOpen := cb.RecordFailure(testKey); isOpen {
+		t.Fatalf("RecordFailure() = true after the circuit is closed, want false")
+	}
+}
+
+func TestCircuitBreakerForget(t *testing.T) {
+	cb := NewWithClock(1, testCoolDown, clocktesting.NewFakePassiveClock(time.Now()))
+	if isOpen := cb.RecordFailure(testKey); !isOpen {
+		t.Fatalf("RecordFailure() = false, want true")
+	}
+	cb.Forget(testKey)
+	if allowed, _ := cb.Allow(testKey); !allowed {
+		t.Fatalf("Allow() = false after the circuit is forgotten, want true")
+	}
+}
+
+func TestCircuitBreakerReleaseTrial(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	cb := NewWithClock(1, testCoolDown, fakeClock)
+
+	// Releasing a closed circuit is a no-op.
+	cb.ReleaseTrial(testKey)
+	if got := cb.State(testKey); got != StateClosed {
+		t.Fatalf("State() = %v, want %v", got, StateClosed)
+	}
+
+	cb.RecordFailure(testKey)
+	// Releasing an open circuit does not shorten the cool-down period.
+	cb.ReleaseTrial(testKey)
+	if allowed, _ := cb.Allow(testKey); allowed {
+		t.Fatalf("Allow() = true during the cool-down period, want false")
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(testCoolDown))
+	if allowed, _ := cb.Allow(testKey); !allowed {
+		t.Fatalf("Allow() = false after the cool-down period, want true")
+	}
+	// Another trial call is allowed once the first one is released.
+	cb.ReleaseTrial(testKey)
+	if allowed, _ := cb.Allow(testKey); !allowed {
+		t.Fatalf("Allow() = false after the trial call is released, want true")
+	}
+	if got := cb.State(testKey); got != StateHalfOpen {
+		t.Fatalf("State() = %v, want %v", got, StateHalfOpen)
+	}
+}
+
+func TestCircuitBreakerAllowTrial(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	cb := NewWithClock(1, testCoolDown, fakeClock)
+
+	if allowed, trial, _ := cb.AllowTrial(testKey); !allowed || trial {
+		t.Fatalf("AllowTrial() = (%v, %v), want (true, false) for a closed circuit", allowed, trial)
+	}
+	cb.RecordFailure(testKey)
+	fakeClock.SetTime(fakeClock.Now().Add(testCoolDown))
+
+	// Exactly one of the concurrent callers is the trial call.
+	var wg sync.WaitGroup
+	var trials atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if allowed, trial, _ := cb.AllowTrial(testKey); allowed && trial {
+				trials.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := trials.Load(); got != 1 {
+		t.Fatalf("AllowTrial() let %d trial calls through, want 1", got)
+	}
+}
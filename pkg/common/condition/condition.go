@@ -8,6 +8,8 @@ package condition
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -18,6 +20,11 @@ import (
 const (
 	conditionReasonNoConflictFound = "NoConflictFound"
 	conditionReasonConflictFound   = "ConflictFound"
+
+	conditionReasonConflictingExportsFound = "ConflictingExportsFound"
+
+	conditionReasonLagAboveThreshold  = "LagAboveThreshold"
+	conditionReasonLagWithinThreshold = "LagWithinThreshold"
 )
 
 // EqualCondition compares one condition with another; it ignores the LastTransitionTime and Message fields,
@@ -65,17 +72,90 @@ func UnconflictedServiceExportConflictCondition(internalServiceExport fleetnetv1
 	}
 }
 
-// ConflictedServiceExportConflictCondition returns the desired conflicted condition.
-func ConflictedServiceExportConflictCondition(internalServiceExport fleetnetv1alpha1.InternalServiceExport) metav1.Condition {
+// ConflictedServiceExportConflictCondition returns the desired conflicted condition, which names the cluster whose
+// export defines the spec of the ServiceImport, and tells why the export is in conflict, if the reason is not empty.
+func ConflictedServiceExportConflictCondition(internalServiceExport fleetnetv1alpha1.InternalServiceExport, authoritativeClusterID, reason string) metav1.Condition {
 	svcName := types.NamespacedName{
 		Namespace: internalServiceExport.Spec.ServiceReference.Namespace,
 		Name:      internalServiceExport.Spec.ServiceReference.Name,
 	}
+	message := fmt.Sprintf("service %s is in conflict with the service exported first by cluster %s", svcName, authoritativeClusterID)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
 	return metav1.Condition{
 		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
 		Status:             metav1.ConditionTrue,
 		Reason:             conditionReasonConflictFound,
 		ObservedGeneration: internalServiceExport.Spec.ServiceReference.Generation, // use the generation of the original object
-		Message:            fmt.Sprintf("service %s is in conflict with other exported services", svcName),
+		Message:            message,
+	}
+}
+
+// WouldConflictCondition returns the desired advisory condition of an export which conflicts with the exports of
+// the given clusters.
+func WouldConflictCondition(internalServiceExport fleetnetv1alpha1.InternalServiceExport, clusterIDs []string) metav1.Condition {
+	svcName := types.NamespacedName{
+		Namespace: internalServiceExport.Spec.ServiceReference.Namespace,
+		Name:      internalServiceExport.Spec.ServiceReference.Name,
+	}
+	return metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportWouldConflict),
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonConflictingExportsFound,
+		ObservedGeneration: internalServiceExport.Spec.ServiceReference.Generation, // use the generation of the original object
+		Message:            fmt.Sprintf("service %s conflicts with the services exported by clusters %s", svcName, strings.Join(clusterIDs, ", ")),
+	}
+}
+
+// DegradedFreshnessCondition returns the desired condition of an export whose endpoint changes take longer than
+// the freshness objective to become visible across the fleet; the message contains the measured lag.
+func DegradedFreshnessCondition(internalServiceExport fleetnetv1alpha1.InternalServiceExport, lag, threshold time.Duration) metav1.Condition {
+	svcName := types.NamespacedName{
+		Namespace: internalServiceExport.Spec.ServiceReference.Namespace,
+		Name:      internalServiceExport.Spec.ServiceReference.Name,
+	}
+	return metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportFreshnessDegraded),
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonLagAboveThreshold,
+		ObservedGeneration: internalServiceExport.Spec.ServiceReference.Generation, // use the generation of the original object
+		Message: fmt.Sprintf("endpoint changes of service %s took up to %s to become visible across the fleet, longer than the objective of %s",
+			svcName, lag.Round(time.Second), threshold),
+	}
+}
+
+// FreshFreshnessCondition returns the desired condition of an export whose endpoint changes become visible across
+// the fleet within the freshness objective.
+func FreshFreshnessCondition(internalServiceExport fleetnetv1alpha1.InternalServiceExport, threshold time.Duration) metav1.Condition {
+	svcName := types.NamespacedName{
+		Namespace: internalServiceExport.Spec.ServiceReference.Namespace,
+		Name:      internalServiceExport.Spec.ServiceReference.Name,
+	}
+	return metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportFreshnessDegraded),
+		Status:             metav1.ConditionFalse,
+		Reason:             conditionReasonLagWithinThreshold,
+		ObservedGeneration: internalServiceExport.Spec.ServiceReference.Generation, // use the generation of the original object
+		Message:            fmt.Sprintf("endpoint changes of service %s become visible across the fleet within the objective of %s", svcName, threshold),
+	}
+}
+
+// HubFieldRejectedReason returns the reason of the HubFieldRejected condition of a ServiceExport whose exports of a
+// kind, e.g. EndpointSliceExport, the hub cluster rejects.
+func HubFieldRejectedReason(kind string) string {
+	return kind + "Rejected"
+}
+
+// HubFieldRejectedCondition returns the desired condition of a ServiceExport whose exports of a kind the hub cluster
+// rejects for fields its CRDs do not accept; the message names the fields.
+func HubFieldRejectedCondition(svcExport *fleetnetv1alpha1.ServiceExport, kind string, fields []string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportHubFieldRejected),
+		Status:             metav1.ConditionTrue,
+		Reason:             HubFieldRejectedReason(kind),
+		ObservedGeneration: svcExport.Generation,
+		Message: fmt.Sprintf("the hub cluster rejects the %s of service %s/%s, as it does not accept the fields %s",
+			kind, svcExport.Namespace, svcExport.Name, strings.Join(fields, ", ")),
 	}
 }
@@ -7,6 +7,7 @@ package condition
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -305,15 +306,116 @@ func TestConflictedServiceExportConflictCondition(t *testing.T) {
 			},
 		},
 	}
-	want := metav1.Condition{
-		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+	tests := []struct {
+		name        string
+		reason      string
+		wantMessage string
+	}{
+		{
+			name:        "no reason",
+			wantMessage: "service test-ns/test-svc is in conflict with the service exported first by cluster member-2",
+		},
+		{
+			name:        "with reason",
+			reason:      `port 8080/TCP is named "portA", but the other clusters name it "http"`,
+			wantMessage: `service test-ns/test-svc is in conflict with the service exported first by cluster member-2: port 8080/TCP is named "portA", but the other clusters name it "http"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			want := metav1.Condition{
+				Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+				Status:             metav1.ConditionTrue,
+				Reason:             conditionReasonConflictFound,
+				ObservedGeneration: 123,
+				Message:            tc.wantMessage,
+			}
+			got := ConflictedServiceExportConflictCondition(input, "member-2", tc.reason)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("ConflictedServiceExportConflictCondition() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFreshnessConditions(t *testing.T) {
+	input := fleetnetv1alpha1.InternalServiceExport{
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:  testClusterID,
+				Kind:       "Service",
+				Namespace:  "test-ns",
+				Name:       "test-svc",
+				Generation: 123,
+			},
+		},
+	}
+	wantDegraded := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportFreshnessDegraded),
 		Status:             metav1.ConditionTrue,
-		Reason:             conditionReasonConflictFound,
+		Reason:             conditionReasonLagAboveThreshold,
+		ObservedGeneration: 123,
+		Message:            "endpoint changes of service test-ns/test-svc took up to 45s to become visible across the fleet, longer than the objective of 30s",
+	}
+	gotDegraded := DegradedFreshnessCondition(input, 45*time.Second+200*time.Millisecond, 30*time.Second)
+	if diff := cmp.Diff(wantDegraded, gotDegraded); diff != "" {
+		t.Errorf("DegradedFreshnessCondition() mismatch (-want, +got):\n%s", diff)
+	}
+
+	wantFresh := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportFreshnessDegraded),
+		Status:             metav1.ConditionFalse,
+		Reason:             conditionReasonLagWithinThreshold,
 		ObservedGeneration: 123,
-		Message:            "service test-ns/test-svc is in conflict with other exported services",
+		Message:            "endpoint changes of service test-ns/test-svc become visible across the fleet within the objective of 30s",
+	}
+	gotFresh := FreshFreshnessCondition(input, 30*time.Second)
+	if diff := cmp.Diff(wantFresh, gotFresh); diff != "" {
+		t.Errorf("FreshFreshnessCondition() mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestHubFieldRejectedCondition(t *testing.T) {
+	input := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "test-ns",
+			Name:       "test-svc",
+			Generation: 3,
+		},
+	}
+	want := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportHubFieldRejected),
+		Status:             metav1.ConditionTrue,
+		Reason:             "EndpointSliceExportRejected",
+		ObservedGeneration: 3,
+		Message:            "the hub cluster rejects the EndpointSliceExport of service test-ns/test-svc, as it does not accept the fields spec.endpoints[0].hints, spec.weight",
+	}
+	got := HubFieldRejectedCondition(input, "EndpointSliceExport", []string{"spec.endpoints[0].hints", "spec.weight"})
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("HubFieldRejectedCondition() mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWouldConflictCondition(t *testing.T) {
+	input := fleetnetv1alpha1.InternalServiceExport{
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:  "member-1",
+				Namespace:  "test-ns",
+				Name:       "test-svc",
+				Generation: 2,
+			},
+		},
+	}
+	want := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportWouldConflict),
+		Status:             metav1.ConditionTrue,
+		Reason:             "ConflictingExportsFound",
+		ObservedGeneration: 2,
+		Message:            "service test-ns/test-svc conflicts with the services exported by clusters member-2, member-3",
 	}
-	got := ConflictedServiceExportConflictCondition(input)
+	got := WouldConflictCondition(input, []string{"member-2", "member-3"})
 	if diff := cmp.Diff(want, got); diff != "" {
-		t.Errorf("ConflictedServiceExportConflictCondition() mismatch (-want, +got):\n%s", diff)
+		t.Errorf("WouldConflictCondition() mismatch (-want, +got):\n%s", diff)
 	}
 }
@@ -0,0 +1,79 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package configreload features a watcher which reloads the configuration file of an agent when its content changes,
+// so that the settings which are safe to change at runtime, e.g. the log verbosity, apply without a restart.
+//
+// The file is polled rather than watched for file system events, as the configuration files mounted from
+// ConfigMaps are replaced through symbolic links, which the file system events do not follow reliably.
+package configreload
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultInterval is the default interval between two reads of the configuration file.
+	DefaultInterval = 10 * time.Second
+)
+
+// Watcher reads a configuration file periodically and calls Reload with its content whenever it changes.
+type Watcher struct {
+	// Path is the path to the configuration file.
+	Path string
+	// Interval is the interval between two reads of the file; DefaultInterval applies if it is not positive.
+	Interval time.Duration
+	// Reload applies the changed content of the file. If it returns an error, e.g. because the content is invalid,
+	// the previous configuration is kept until the content changes again.
+	Reload func(data []byte) error
+	// Loaded is the content of the file the agent was started with; Reload is not called until the content differs
+	// from it.
+	Loaded []byte
+
+	// last is the content of the file last read.
+	last []byte
+}
+
+// Start reads the file periodically until the context is cancelled; it implements the manager.Runnable interface.
+func (w *Watcher) Start(ctx context.Context) error {
+	w.last = w.Loaded
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		w.check()
+	}, interval)
+	return nil
+}
+
+// NeedLeaderElection returns false, as every replica of the agent applies its own configuration.
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// check reads the file and reloads it if its content has changed.
+func (w *Watcher) check() {
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		// The file may be replaced at the time; it is read again on the next check.
+		klog.ErrorS(err, "Failed to read the configuration file", "path", w.Path)
+		return
+	}
+	if bytes.Equal(data, w.last) {
+		return
+	}
+	w.last = data
+	klog.InfoS("The configuration file has changed; reloading it", "path", w.Path)
+	if err := w.Reload(data); err != nil {
+		klog.ErrorS(err, "Failed to reload the configuration file; keeping the previous configuration", "path", w.Path)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package configreload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFile() = %v, want no error", err)
+		}
+	}
+	writeFile("v1")
+
+	var reloaded []string
+	var reloadErr error
+	w := &Watcher{
+		Path: path,
+		Reload: func(data []byte) error {
+			reloaded = append(reloaded, string(data))
+			return reloadErr
+		},
+		Loaded: []byte("v1"),
+	}
+	w.last = w.Loaded
+
+	// The content the agent was started with is not reloaded.
+	w.check()
+	writeFile("v2")
+	w.check()
+	w.check()
+	// An invalid content is reloaded once, and the next change is reloaded again.
+	reloadErr = errors.New("invalid")
+	writeFile("invalid")
+	w.check()
+	w.check()
+	reloadErr = nil
+	writeFile("v3")
+	w.check()
+	// A file which cannot be read keeps the previous content.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Remove() = %v, want no error", err)
+	}
+	w.check()
+	writeFile("v3")
+	w.check()
+
+	want := []string{"v2", "invalid", "v3"}
+	if diff := cmp.Diff(want, reloaded); diff != "" {
+		t.Errorf("reloaded contents mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -12,6 +12,10 @@ import (
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
 )
 
+// DefaultDemotionThresholdBasisPoints is the default availability, in basis points, below which the endpoints of a
+// TrafficManagerProfile with an SLA monitor are demoted.
+const DefaultDemotionThresholdBasisPoints = 9900
+
 // SetDefaultsTrafficManagerProfile sets the default values for TrafficManagerProfile.
 func SetDefaultsTrafficManagerProfile(obj *fleetnetv1beta1.TrafficManagerProfile) {
 	if obj.Spec.MonitorConfig == nil {
@@ -22,10 +26,6 @@ func SetDefaultsTrafficManagerProfile(obj *fleetnetv1beta1.TrafficManagerProfile
 		obj.Spec.MonitorConfig.IntervalInSeconds = ptr.To(int64(30))
 	}
 
-	if obj.Spec.MonitorConfig.Path == nil {
-		obj.Spec.MonitorConfig.Path = ptr.To("/")
-	}
-
 	if obj.Spec.MonitorConfig.Port == nil {
 		obj.Spec.MonitorConfig.Port = ptr.To(int64(80))
 	}
@@ -34,6 +34,9 @@ func SetDefaultsTrafficManagerProfile(obj *fleetnetv1beta1.TrafficManagerProfile
 		obj.Spec.MonitorConfig.Protocol = ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP)
 	}
 
+	// Path value depends on the Protocol, so that the defaulter MUST handle the Protocol first.
+	SetDefaultsMonitorConfigPath(obj.Spec.MonitorConfig)
+
 	// TimeoutInSeconds value depends on the IntervalInSeconds, so that the defaulter MUST handle the IntervalInSeconds first.
 	// * If the Probing Interval is set to 30 seconds, then you can set the Timeout value between 5 and 10 seconds.
 	//   If no value is specified, it uses a default value of 10 seconds.
@@ -51,4 +54,24 @@ func SetDefaultsTrafficManagerProfile(obj *fleetnetv1beta1.TrafficManagerProfile
 	if obj.Spec.MonitorConfig.ToleratedNumberOfFailures == nil {
 		obj.Spec.MonitorConfig.ToleratedNumberOfFailures = ptr.To(int64(3))
 	}
+
+	if obj.Spec.RoutingMethod == nil {
+		obj.Spec.RoutingMethod = ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted)
+	}
+
+	if obj.Spec.SLAMonitorURL != "" && obj.Spec.DemotionThresholdBasisPoints == nil {
+		obj.Spec.DemotionThresholdBasisPoints = ptr.To(int64(DefaultDemotionThresholdBasisPoints))
+	}
+}
+
+// SetDefaultsMonitorConfigPath sets the default path of the MonitorConfig, which depends on its protocol: the path
+// defaults to "/" for HTTP and HTTPS, and is cleared for TCP, as TCP probes do not use it.
+func SetDefaultsMonitorConfigPath(mc *fleetnetv1beta1.MonitorConfig) {
+	if mc.Protocol != nil && *mc.Protocol == fleetnetv1beta1.TrafficManagerMonitorProtocolTCP {
+		mc.Path = nil
+		return
+	}
+	if mc.Path == nil {
+		mc.Path = ptr.To("/")
+	}
 }
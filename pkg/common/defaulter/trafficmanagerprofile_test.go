@@ -35,6 +35,7 @@ func TestSetTrafficManagerProfile(t *testing.T) {
 						TimeoutInSeconds:          ptr.To(int64(10)),
 						ToleratedNumberOfFailures: ptr.To(int64(3)),
 					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
 				},
 			},
 		},
@@ -57,6 +58,7 @@ func TestSetTrafficManagerProfile(t *testing.T) {
 						TimeoutInSeconds:          ptr.To(int64(9)),
 						ToleratedNumberOfFailures: ptr.To(int64(3)),
 					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
 				},
 			},
 		},
@@ -82,6 +84,7 @@ func TestSetTrafficManagerProfile(t *testing.T) {
 						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
 						ToleratedNumberOfFailures: ptr.To(int64(4)),
 					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
 				},
 			},
 		},
@@ -108,6 +111,7 @@ func TestSetTrafficManagerProfile(t *testing.T) {
 						TimeoutInSeconds:          ptr.To(int64(90)),
 						ToleratedNumberOfFailures: ptr.To(int64(4)),
 					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
 				},
 			},
 		},
@@ -135,6 +139,76 @@ func TestSetTrafficManagerProfile(t *testing.T) {
 						TimeoutInSeconds:          ptr.To(int64(90)),
 						ToleratedNumberOfFailures: ptr.To(int64(4)),
 					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
+				},
+			},
+		},
+		{
+			name: "TrafficManagerProfile with TCP protocol",
+			obj: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						Path:     ptr.To("/healthz"),
+						Port:     ptr.To(int64(8080)),
+						Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+					},
+				},
+			},
+			want: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						IntervalInSeconds:         ptr.To(int64(30)),
+						Port:                      ptr.To(int64(8080)),
+						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+						TimeoutInSeconds:          ptr.To(int64(10)),
+						ToleratedNumberOfFailures: ptr.To(int64(3)),
+					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
+				},
+			},
+		},
+		{
+			name: "TrafficManagerProfile with Subnet routing method",
+			obj: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+				},
+			},
+			want: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						IntervalInSeconds:         ptr.To(int64(30)),
+						Path:                      ptr.To("/"),
+						Port:                      ptr.To(int64(80)),
+						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+						TimeoutInSeconds:          ptr.To(int64(10)),
+						ToleratedNumberOfFailures: ptr.To(int64(3)),
+					},
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+				},
+			},
+		},
+		{
+			name: "TrafficManagerProfile with SLA monitor",
+			obj: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodPriority),
+					SLAMonitorURL: "https://sla.example.com/availability",
+				},
+			},
+			want: &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						IntervalInSeconds:         ptr.To(int64(30)),
+						Path:                      ptr.To("/"),
+						Port:                      ptr.To(int64(80)),
+						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+						TimeoutInSeconds:          ptr.To(int64(10)),
+						ToleratedNumberOfFailures: ptr.To(int64(3)),
+					},
+					RoutingMethod:                ptr.To(fleetnetv1beta1.TrafficRoutingMethodPriority),
+					SLAMonitorURL:                "https://sla.example.com/availability",
+					DemotionThresholdBasisPoints: ptr.To(int64(DefaultDemotionThresholdBasisPoints)),
 				},
 			},
 		},
@@ -0,0 +1,101 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package drain features a tracker of the in-flight writes of a controller to the hub cluster, which lets the writes
+// complete when the controller manager shuts down, rather than cancelling them midway.
+package drain
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultTimeout is the default period the in-flight writes are given to complete when the controller manager
+	// shuts down.
+	DefaultTimeout = 30 * time.Second
+)
+
+// Tracker tracks the in-flight writes of a controller. The writes run with a context which is not cancelled when
+// the controller manager shuts down; instead, the Tracker, which runs with the manager, waits up to the timeout for
+// them to complete once the manager stops, and only cancels the writes still in flight after the timeout.
+//
+// Writes started after the manager has begun to shut down are not tracked, and run with the (cancelled) context of
+// their caller; the objects they were meant for are reconciled again when the controller restarts.
+//
+// A nil Tracker runs the writes with the context of their caller. It is safe for concurrent use.
+type Tracker struct {
+	controllerName string
+	timeout        time.Duration
+
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+
+	// abortCtx is cancelled when the in-flight writes have not completed within the timeout.
+	abortCtx context.Context
+	abort    context.CancelFunc
+}
+
+// New returns a Tracker which gives the in-flight writes of the named controller up to timeout to complete.
+func New(controllerName string, timeout time.Duration) *Tracker {
+	abortCtx, abort := context.WithCancel(context.Background())
+	return &Tracker{
+		controllerName: controllerName,
+		timeout:        timeout,
+		abortCtx:       abortCtx,
+		abort:          abort,
+	}
+}
+
+// Track runs the write, and tracks it until it returns.
+func (t *Tracker) Track(ctx context.Context, write func(ctx context.Context) error) error {
+	if t == nil {
+		return write(ctx)
+	}
+	t.mu.Lock()
+	if t.draining {
+		t.mu.Unlock()
+		return write(ctx)
+	}
+	t.inFlight.Add(1)
+	t.mu.Unlock()
+	defer t.inFlight.Done()
+
+	writeCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(t.abortCtx, cancel)
+	defer stop()
+	return write(writeCtx)
+}
+
+// Start blocks until the context is cancelled, i.e. the controller manager shuts down, and then waits up to the
+// timeout for the in-flight writes to complete; it implements the manager.Runnable interface.
+func (t *Tracker) Start(ctx context.Context) error {
+	<-ctx.Done()
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		t.inFlight.Wait()
+		close(drained)
+	}()
+	startTime := time.Now()
+	timer := time.NewTimer(t.timeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+		klog.V(2).InfoS("In-flight hub writes have been drained", "controller", t.controllerName, "latency", time.Since(startTime))
+	case <-timer.C:
+		klog.InfoS("In-flight hub writes have not completed in time; cancelling them", "controller", t.controllerName, "timeout", t.timeout)
+		t.abort()
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package drain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// startTracker runs the tracker until the returned function is called, which shuts it down and waits for it.
+func startTracker(t *testing.T, tracker *Tracker) (ctx context.Context, shutdown func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if err := tracker.Start(ctx); err != nil {
+			t.Errorf("Start() = %v, want no error", err)
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		<-stopped
+	}
+}
+
+func TestTrack_Drained(t *testing.T) {
+	tracker := New(t.Name(), time.Minute)
+	ctx, shutdown := startTracker(t, tracker)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	writeErr := make(chan error)
+	go func() {
+		writeErr <- tracker.Track(ctx, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return ctx.Err()
+		})
+	}()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		shutdown()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatalf("Start() returned while a write is in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-writeErr; err != nil {
+		t.Errorf("Track() = %v, want the write to complete with a live context", err)
+	}
+	<-stopped
+
+	// Writes started after the shutdown run with the context of their caller.
+	if err := tracker.Track(ctx, func(ctx context.Context) error { return ctx.Err() }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Track() after the shutdown = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestTrack_TimedOut(t *testing.T) {
+	tracker := New(t.Name(), 50*time.Millisecond)
+	ctx, shutdown := startTracker(t, tracker)
+
+	started := make(chan struct{})
+	writeErr := make(chan error)
+	go func() {
+		writeErr <- tracker.Track(ctx, func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}()
+	<-started
+
+	shutdown()
+	select {
+	case err := <-writeErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Track() = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the write is still in flight after the timeout")
+	}
+}
+
+func TestTrack_NilTracker(t *testing.T) {
+	var tracker *Tracker
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tracker.Track(ctx, func(ctx context.Context) error { return ctx.Err() }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Track() = %v, want %v", err, context.Canceled)
+	}
+}
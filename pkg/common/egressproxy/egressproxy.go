@@ -0,0 +1,119 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package egressproxy provides the egress proxy configuration of the connectivity targets of the agents, e.g. the hub
+// cluster and Azure Resource Manager, so that each target can be reached through its own proxy.
+package egressproxy
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// URLValue is a flag value holding the URL of a proxy; the URL is validated when the flag is set and its password
+// is redacted when the flag is printed.
+type URLValue struct {
+	URL *url.URL
+}
+
+var _ flag.Value = &URLValue{}
+
+// String returns the URL with its password redacted.
+func (v *URLValue) String() string {
+	if v == nil || v.URL == nil {
+		return ""
+	}
+	return v.URL.Redacted()
+}
+
+// Set parses and validates the URL of a proxy; an empty value means no proxy.
+func (v *URLValue) Set(s string) error {
+	if s == "" {
+		v.URL = nil
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid proxy URL %q: the scheme must be http or https", u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: the host must not be empty", u.Redacted())
+	}
+	v.URL = u
+	return nil
+}
+
+// Config is the egress proxy configuration of a connectivity target.
+type Config struct {
+	// URL is the URL of the proxy; the proxy settings of the environment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY)
+	// apply if it is nil.
+	URL *url.URL
+	// NoProxy is a comma-separated list of the hosts, domains, IP addresses and CIDRs which are reached without the
+	// proxy, in the same format as the NO_PROXY environment variable.
+	NoProxy string
+}
+
+// IsSet returns whether a proxy is configured for the target.
+func (c *Config) IsSet() bool {
+	return c != nil && c.URL != nil
+}
+
+// String returns the configuration with the password of the proxy redacted, for logging.
+func (c *Config) String() string {
+	if !c.IsSet() {
+		return "from environment"
+	}
+	return fmt.Sprintf("proxy=%s, noProxy=%q", c.URL.Redacted(), c.NoProxy)
+}
+
+// ProxyFunc returns the function which selects the proxy of a request to the target.
+//
+// Note that, as with the NO_PROXY environment variable, requests to localhost and loopback addresses are never
+// proxied.
+func (c *Config) ProxyFunc() func(*http.Request) (*url.URL, error) {
+	if !c.IsSet() {
+		return http.ProxyFromEnvironment
+	}
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  c.URL.String(),
+		HTTPSProxy: c.URL.String(),
+		NoProxy:    c.NoProxy,
+	}).ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+}
+
+// Transport returns a copy of the base transport which reaches the target through the configured proxy.
+func (c *Config) Transport(base *http.Transport) *http.Transport {
+	t := base.Clone()
+	t.Proxy = c.ProxyFunc()
+	return t
+}
+
+// SelfTest checks that the target is reachable with the client by sending a GET request to the URL; any HTTP
+// response, including an error status such as 401 Unauthorized, proves the connectivity.
+func SelfTest(ctx context.Context, client *http.Client, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build the connectivity self-test request to %s: %w", target, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
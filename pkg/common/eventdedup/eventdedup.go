@@ -0,0 +1,80 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package eventdedup features an event recorder which records an event only when its message changes, so that the
+// controllers do not record the same event on every reconciliation of an object whose state has not changed.
+package eventdedup
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Recorder records an event of an object only if the message of the last event of the same reason recorded on the
+// object is different, or has been cleared.
+//
+// The messages recorded last live in memory only; the events are recorded once more after the controller restarts.
+// It is safe for concurrent use.
+type Recorder struct {
+	recorder record.EventRecorder
+
+	mu   sync.Mutex
+	last map[types.NamespacedName]map[string]string
+}
+
+// New returns a Recorder which records the events with the given recorder.
+func New(recorder record.EventRecorder) *Recorder {
+	return &Recorder{
+		recorder: recorder,
+		last:     map[types.NamespacedName]map[string]string{},
+	}
+}
+
+// Eventf records an event on the object unless the last event of the same reason recorded on the object has the
+// same message; it returns if the event is recorded.
+func (r *Recorder) Eventf(obj client.Object, eventType, reason, messageFmt string, args ...interface{}) bool {
+	message := fmt.Sprintf(messageFmt, args...)
+	key := client.ObjectKeyFromObject(obj)
+
+	r.mu.Lock()
+	reasons, found := r.last[key]
+	if found && reasons[reason] == message {
+		r.mu.Unlock()
+		return false
+	}
+	if !found {
+		reasons = map[string]string{}
+		r.last[key] = reasons
+	}
+	reasons[reason] = message
+	r.mu.Unlock()
+
+	r.recorder.Event(obj, eventType, reason, message)
+	return true
+}
+
+// Clear forgets the last event of the reason recorded on the object, e.g. once the problem it reports is resolved, so
+// that the event is recorded again if the problem comes back.
+func (r *Recorder) Clear(obj client.Object, reason string) {
+	key := client.ObjectKeyFromObject(obj)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last[key], reason)
+	if len(r.last[key]) == 0 {
+		delete(r.last, key)
+	}
+}
+
+// Forget forgets all the events recorded on the object of the key, e.g. once the object is deleted.
+func (r *Recorder) Forget(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.last, key)
+}
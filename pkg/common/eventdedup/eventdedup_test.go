@@ -0,0 +1,52 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package eventdedup
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRecorder(t *testing.T) {
+	fakeRecorder := record.NewFakeRecorder(10)
+	r := New(fakeRecorder)
+	obj := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "work", Name: "app"}}
+	other := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "work", Name: "other-app"}}
+
+	steps := []struct {
+		name string
+		obj  *corev1.Service
+		msg  string
+		want bool
+	}{
+		{name: "first event", obj: obj, msg: "invalid", want: true},
+		{name: "same message", obj: obj, msg: "invalid", want: false},
+		{name: "same message on another object", obj: other, msg: "invalid", want: true},
+		{name: "changed message", obj: obj, msg: "still invalid", want: true},
+	}
+	for _, step := range steps {
+		if got := r.Eventf(step.obj, corev1.EventTypeWarning, "Invalid", "%s", step.msg); got != step.want {
+			t.Errorf("Eventf() %s = %t, want %t", step.name, got, step.want)
+		}
+	}
+
+	// The event is recorded again once it is cleared, or once the object is forgotten.
+	r.Clear(obj, "Invalid")
+	if !r.Eventf(obj, corev1.EventTypeWarning, "Invalid", "still invalid") {
+		t.Errorf("Eventf() after Clear() = false, want true")
+	}
+	r.Forget(client.ObjectKeyFromObject(obj))
+	if !r.Eventf(obj, corev1.EventTypeWarning, "Invalid", "still invalid") {
+		t.Errorf("Eventf() after Forget() = false, want true")
+	}
+	if got, want := len(fakeRecorder.Events), 5; got != want {
+		t.Errorf("recorded events, got %d, want %d", got, want)
+	}
+}
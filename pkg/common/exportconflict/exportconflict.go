@@ -0,0 +1,508 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package exportconflict features the comparison of exported services used by the hub controllers to detect
+// conflicts between the exports of the same service from different clusters, and the prediction of the conflict
+// outcome of a proposed change before it is applied.
+package exportconflict
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// EqualServicePorts returns true if two lists of ports are the same, including their order; merged ports are always
+// sorted by MergeServicePorts.
+func EqualServicePorts(a, b []fleetnetv1alpha1.ServicePort) bool {
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// portProtocol identifies a port on which a Service listens; the ports of the exports of the same service are merged
+// by it.
+type portProtocol struct {
+	port     int32
+	protocol corev1.Protocol
+}
+
+func keyOf(port *fleetnetv1alpha1.ServicePort) portProtocol {
+	protocol := port.Protocol
+	// The protocol defaults to TCP.
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	return portProtocol{port: port.Port, protocol: protocol}
+}
+
+// CompatibleServicePorts returns true if the ports of an export can be merged into the merged ports of the other
+// exports of the same service; see PortIncompatibility.
+func CompatibleServicePorts(merged, ports []fleetnetv1alpha1.ServicePort) bool {
+	return PortIncompatibility(merged, ports) == ""
+}
+
+// PortIncompatibility returns why the ports of an export cannot be merged into the merged ports of the other exports
+// of the same service, or an empty string if none of the ports is incompatible with a merged one:
+//   - a port listening on the same port with the same protocol as a merged one must have the same name and
+//     application protocol; the other fields, e.g. the target port, are local to the exporting cluster;
+//   - a port listening on a different port or protocol must not take the name of a merged one, as the ports of the
+//     imported Service must have unique names;
+//   - neither a port listening on a different port or protocol nor the merged ports can be unnamed, as the ports of
+//     a Service with multiple ports must all be named; e.g. a metrics port cannot be added to an unnamed port.
+func PortIncompatibility(merged, ports []fleetnetv1alpha1.ServicePort) string {
+	if len(merged) == 0 {
+		return ""
+	}
+	byKey := make(map[portProtocol]*fleetnetv1alpha1.ServicePort, len(merged))
+	byName := make(map[string]*fleetnetv1alpha1.ServicePort, len(merged))
+	for i := range merged {
+		byKey[keyOf(&merged[i])] = &merged[i]
+		byName[merged[i].Name] = &merged[i]
+	}
+	for i := range ports {
+		port := &ports[i]
+		key := keyOf(port)
+		if mergedPort, ok := byKey[key]; ok {
+			switch {
+			case port.Name != mergedPort.Name:
+				return fmt.Sprintf("port %d/%s is named %q, but the other clusters name it %q", key.port, key.protocol, port.Name, mergedPort.Name)
+			case !ptr.Equal(port.AppProtocol, mergedPort.AppProtocol):
+				return fmt.Sprintf("the application protocol of port %d/%s is %q, but %q for the other clusters",
+					key.port, key.protocol, ptr.Deref(port.AppProtocol, ""), ptr.Deref(mergedPort.AppProtocol, ""))
+			}
+			continue
+		}
+		if port.Name == "" {
+			return fmt.Sprintf("port %d/%s is unnamed, but the ports of a service with multiple ports must all be named", key.port, key.protocol)
+		}
+		if unnamedPort, ok := byName[""]; ok {
+			unnamedKey := keyOf(unnamedPort)
+			return fmt.Sprintf("port %d/%s of the other clusters is unnamed, but the ports of a service with multiple ports must all be named",
+				unnamedKey.port, unnamedKey.protocol)
+		}
+		if mergedPort, ok := byName[port.Name]; ok {
+			mergedKey := keyOf(mergedPort)
+			return fmt.Sprintf("port %d/%s is named %q, which the other clusters already use for port %d/%s",
+				key.port, key.protocol, port.Name, mergedKey.port, mergedKey.protocol)
+		}
+	}
+	return ""
+}
+
+// ImportTypeOf returns the type of the ServiceImport an export asks for; exports which do not report it, e.g. from
+// older agents, ask for a ClusterSetIP one.
+func ImportTypeOf(export *fleetnetv1alpha1.InternalServiceExport) fleetnetv1alpha1.ServiceImportType {
+	if export.Spec.ImportType == "" {
+		return fleetnetv1alpha1.ClusterSetIP
+	}
+	return export.Spec.ImportType
+}
+
+// TypeIncompatibility returns why an export cannot be merged into the exports of the other clusters, which ask for a
+// ServiceImport of the given type, or an empty string if it asks for the same type or the type is empty, i.e. no
+// other cluster exports the service; a Headless service and a ClusterSetIP one cannot be imported as one.
+func TypeIncompatibility(mergedType fleetnetv1alpha1.ServiceImportType, export *fleetnetv1alpha1.InternalServiceExport) string {
+	if mergedType == "" {
+		return ""
+	}
+	if importType := ImportTypeOf(export); importType != mergedType {
+		return fmt.Sprintf("the service is imported as %s, but the other clusters export it to be imported as %s", importType, mergedType)
+	}
+	return ""
+}
+
+// MergeServicePorts returns the union of the lists of ports, keyed by port and protocol and sorted by them; the port
+// listed first wins among the ports sharing the same key, i.e. the lists should be passed in the order the exports
+// are resolved in, and be compatible with each other.
+func MergeServicePorts(portLists ...[]fleetnetv1alpha1.ServicePort) []fleetnetv1alpha1.ServicePort {
+	var merged []fleetnetv1alpha1.ServicePort
+	seen := map[portProtocol]bool{}
+	for _, ports := range portLists {
+		for i := range ports {
+			key := keyOf(&ports[i])
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, ports[i])
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := keyOf(&merged[i]), keyOf(&merged[j])
+		if a.port != b.port {
+			return a.port < b.port
+		}
+		return a.protocol < b.protocol
+	})
+	return merged
+}
+
+// Resolution is the outcome of resolving the spec of a ServiceImport from the exports of the service.
+type Resolution struct {
+	// Ports is the resolved ports of the ServiceImport, i.e. the merged ports of the unconflicted exports; nil if no
+	// export can be used to resolve the spec.
+	Ports *[]fleetnetv1alpha1.ServicePort
+	// Type is the resolved type of the ServiceImport, i.e. the one the canonical export asks for; empty if no export
+	// can be used to resolve the spec.
+	Type fleetnetv1alpha1.ServiceImportType
+	// Canonical is the oldest export, which the other exports are merged into; nil if no export can be used to
+	// resolve the spec.
+	Canonical *fleetnetv1alpha1.InternalServiceExport
+	// Unconflicted is the list of exports whose ports are merged into the resolved ports.
+	Unconflicted []*fleetnetv1alpha1.InternalServiceExport
+	// Conflicted is the list of exports whose type or ports are incompatible with the ones of the older exports.
+	Conflicted []*fleetnetv1alpha1.InternalServiceExport
+	// ConflictReasons tells why each conflicted export is incompatible, keyed by the cluster ID of the export.
+	ConflictReasons map[string]string
+}
+
+// Resolve resolves the spec of a ServiceImport from the exports of the service: starting with the oldest export, the
+// ports of each export are merged into the ports of the older exports, unless they are incompatible with them, in
+// which case the export is conflicted; see CompatibleServicePorts. Exports exposing overlapping port lists, e.g. one
+// with an additional metrics port, never conflict. Exports asking for a different type of ServiceImport from the
+// oldest export are conflicted as well; see TypeIncompatibility.
+// Exports are ordered by the creation timestamps of the exported services, and exports created at the same time by
+// the IDs of their clusters; the unconflicted and conflicted exports are returned in this order, i.e. the canonical
+// export always comes first.
+// Exports which are being deleted or have not been handled by the InternalServiceExport controller yet are skipped.
+func Resolve(exports []fleetnetv1alpha1.InternalServiceExport) Resolution {
+	res := Resolution{
+		Unconflicted:    []*fleetnetv1alpha1.InternalServiceExport{},
+		Conflicted:      []*fleetnetv1alpha1.InternalServiceExport{},
+		ConflictReasons: map[string]string{},
+	}
+	resolvable := make([]*fleetnetv1alpha1.InternalServiceExport, 0, len(exports))
+	for i := range exports {
+		if IsResolvable(&exports[i]) {
+			resolvable = append(resolvable, &exports[i])
+		}
+	}
+	if len(resolvable) == 0 {
+		return res
+	}
+	sort.SliceStable(resolvable, func(i, j int) bool {
+		return exportedBefore(resolvable[i], resolvable[j])
+	})
+	res.Canonical = resolvable[0]
+	res.Type = ImportTypeOf(res.Canonical)
+	var merged []fleetnetv1alpha1.ServicePort
+	for _, v := range resolvable {
+		reason := TypeIncompatibility(res.Type, v)
+		if reason == "" {
+			reason = PortIncompatibility(merged, v.Spec.Ports)
+		}
+		if reason != "" {
+			res.Conflicted = append(res.Conflicted, v)
+			res.ConflictReasons[v.Spec.ServiceReference.ClusterID] = reason
+			continue
+		}
+		merged = MergeServicePorts(merged, v.Spec.Ports)
+		res.Unconflicted = append(res.Unconflicted, v)
+	}
+	res.Ports = &merged
+	return res
+}
+
+// ListedExports returns the exports of the clusters the ServiceImport lists, including the exports being deleted, in
+// the order the exports are resolved in.
+func ListedExports(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport) []*fleetnetv1alpha1.InternalServiceExport {
+	listed := make(map[string]bool, len(serviceImport.Status.Clusters))
+	for _, c := range serviceImport.Status.Clusters {
+		listed[c.Cluster] = true
+	}
+	var res []*fleetnetv1alpha1.InternalServiceExport
+	for i := range exports {
+		if listed[exports[i].Spec.ServiceReference.ClusterID] {
+			res = append(res, &exports[i])
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return exportedBefore(res[i], res[j])
+	})
+	return res
+}
+
+// MergeExportedPorts returns the merged ports of the exports, which are in the order the exports are resolved in.
+func MergeExportedPorts(exports []*fleetnetv1alpha1.InternalServiceExport) []fleetnetv1alpha1.ServicePort {
+	portLists := make([][]fleetnetv1alpha1.ServicePort, 0, len(exports))
+	for _, v := range exports {
+		portLists = append(portLists, v.Spec.Ports)
+	}
+	return MergeServicePorts(portLists...)
+}
+
+// HasUnlistedExports returns true if any export which can be used to resolve the spec of the ServiceImport, except
+// the one of the given cluster, is from a cluster the ServiceImport does not list, i.e. is conflicted.
+func HasUnlistedExports(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport, clusterID string) bool {
+	listed := make(map[string]bool, len(serviceImport.Status.Clusters))
+	for _, c := range serviceImport.Status.Clusters {
+		listed[c.Cluster] = true
+	}
+	for i := range exports {
+		v := &exports[i]
+		if cluster := v.Spec.ServiceReference.ClusterID; cluster != clusterID && !listed[cluster] && IsResolvable(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetResolution clears the fields of the ServiceImport status which are resolved from the exports, i.e. the type,
+// the ports and the clusters, so that the ServiceImport controller resolves the spec again; the fields other
+// controllers own, e.g. the conditions, are kept.
+func ResetResolution(serviceImport *fleetnetv1alpha1.ServiceImport) {
+	serviceImport.Status.Type = ""
+	serviceImport.Status.Ports = nil
+	serviceImport.Status.Clusters = nil
+}
+
+// Merging is the outcome of merging the ports of an export into a ServiceImport whose spec has been resolved.
+type Merging struct {
+	// Conflict is true if the type or the ports of the export are incompatible with the ones of the other clusters the
+	// ServiceImport lists.
+	Conflict bool
+	// Reason tells why the export is incompatible, if it is in conflict.
+	Reason string
+	// Ports is the ports of the ServiceImport after the merge, i.e. the merged ports of the listed clusters, without
+	// those of the export if it is in conflict.
+	Ports []fleetnetv1alpha1.ServicePort
+	// Type is the type of the ServiceImport after the merge, i.e. the one the listed clusters ask for.
+	Type fleetnetv1alpha1.ServiceImportType
+	// ResolveAgain is true if the merge changes the type or the ports of the ServiceImport while exports of other
+	// clusters are in conflict, which may no longer be; the spec of the ServiceImport should then be resolved again
+	// from all the exports.
+	ResolveAgain bool
+}
+
+// Merge merges the ports of the export into the ServiceImport, given the current exports of the service: the ports
+// are merged with the current ports of the other clusters the ServiceImport lists, so that the ports an export no
+// longer exposes are dropped, unless another cluster still exposes them.
+//
+// A listed cluster whose export is missing from the given exports, e.g. as the cache has not observed it yet, is
+// assumed to expose the current ports of the ServiceImport, so that they are not dropped by mistake.
+//
+// Neither the ServiceImport nor the exports are modified.
+func Merge(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport, export *fleetnetv1alpha1.InternalServiceExport) Merging {
+	clusterID := export.Spec.ServiceReference.ClusterID
+	var others []*fleetnetv1alpha1.InternalServiceExport
+	found := map[string]bool{}
+	for _, v := range ListedExports(serviceImport, exports) {
+		if v.Spec.ServiceReference.ClusterID != clusterID {
+			others = append(others, v)
+			found[v.Spec.ServiceReference.ClusterID] = true
+		}
+	}
+	var missingPorts []fleetnetv1alpha1.ServicePort
+	var otherType fleetnetv1alpha1.ServiceImportType
+	for _, c := range serviceImport.Status.Clusters {
+		if c.Cluster != clusterID && !found[c.Cluster] {
+			missingPorts = serviceImport.Status.Ports
+			otherType = serviceImport.Status.Type
+			break
+		}
+	}
+	if len(others) > 0 {
+		otherType = ImportTypeOf(others[0])
+	}
+	otherPorts := MergeServicePorts(MergeExportedPorts(others), missingPorts)
+	reason := TypeIncompatibility(otherType, export)
+	if reason == "" {
+		reason = PortIncompatibility(otherPorts, export.Spec.Ports)
+	}
+	if reason != "" {
+		return Merging{Conflict: true, Reason: reason, Ports: otherPorts, Type: otherType}
+	}
+	importType := ImportTypeOf(export)
+
+	merging := append(others, export)
+	sort.SliceStable(merging, func(i, j int) bool {
+		return exportedBefore(merging[i], merging[j])
+	})
+	ports := MergeServicePorts(MergeExportedPorts(merging), missingPorts)
+	changed := !EqualServicePorts(ports, serviceImport.Status.Ports) || importType != serviceImport.Status.Type
+	return Merging{
+		Ports:        ports,
+		Type:         importType,
+		ResolveAgain: changed && HasUnlistedExports(serviceImport, exports, clusterID),
+	}
+}
+
+// Canonical returns the export the other exports of the ServiceImport are merged into, i.e. the oldest export among
+// the clusters the ServiceImport lists, including an export which is being deleted; nil if none of the exports is from
+// a listed cluster.
+func Canonical(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport) *fleetnetv1alpha1.InternalServiceExport {
+	listed := make(map[string]bool, len(serviceImport.Status.Clusters))
+	for _, c := range serviceImport.Status.Clusters {
+		listed[c.Cluster] = true
+	}
+	var canonical *fleetnetv1alpha1.InternalServiceExport
+	for i := range exports {
+		v := &exports[i]
+		if !listed[v.Spec.ServiceReference.ClusterID] {
+			continue
+		}
+		if canonical == nil || exportedBefore(v, canonical) {
+			canonical = v
+		}
+	}
+	return canonical
+}
+
+// exportedBefore returns true if export a comes before export b, i.e. its service is created before the service of
+// export b, or, if both are created at the same time, its cluster ID sorts before the one of export b.
+//
+// The creation timestamp of the export is used for the exports from agents which do not report the creation
+// timestamp of the service; an export which has not been created yet, e.g. a proposed one, is considered created
+// after all the others.
+func exportedBefore(a, b *fleetnetv1alpha1.InternalServiceExport) bool {
+	aCreated, bCreated := originCreationTimestamp(a), originCreationTimestamp(b)
+	switch {
+	case aCreated.IsZero() != bCreated.IsZero():
+		return bCreated.IsZero()
+	case !aCreated.Equal(&bCreated):
+		return aCreated.Before(&bCreated)
+	default:
+		return a.Spec.ServiceReference.ClusterID < b.Spec.ServiceReference.ClusterID
+	}
+}
+
+// originCreationTimestamp returns the creation timestamp of the exported service, or of the export if the former is
+// not reported.
+func originCreationTimestamp(export *fleetnetv1alpha1.InternalServiceExport) metav1.Time {
+	if created := export.Spec.ServiceReference.CreationTimestamp; !created.IsZero() {
+		return created
+	}
+	return export.CreationTimestamp
+}
+
+// IsResolvable returns true if the export can be used to resolve the spec of the ServiceImport, i.e. it is not being
+// deleted and has been handled by the InternalServiceExport controller.
+func IsResolvable(export *fleetnetv1alpha1.InternalServiceExport) bool {
+	return export.DeletionTimestamp == nil && controllerutil.ContainsFinalizer(export, objectmeta.InternalServiceExportFinalizer)
+}
+
+// Prediction is the predicted conflict outcome of a proposed change of the ports exported by a cluster.
+type Prediction struct {
+	// Conflict is true if the proposed ports would conflict with the ports of the ServiceImport.
+	Conflict bool `json:"conflict"`
+	// ResolvedPorts is the ports of the ServiceImport after the change.
+	ResolvedPorts []fleetnetv1alpha1.ServicePort `json:"resolvedPorts,omitempty"`
+	// ConflictedClusters is the sorted list of clusters whose exports would be in conflict after the change.
+	ConflictedClusters []string `json:"conflictedClusters,omitempty"`
+	// AffectedClusters is the sorted list of clusters whose conflict state would change after the change.
+	AffectedClusters []string `json:"affectedClusters,omitempty"`
+}
+
+// Predict predicts the conflict outcome of the cluster exporting the service with the proposed ports, given the
+// current ServiceImport and the current exports of the service, by following the same steps as the hub controllers:
+//   - while other clusters stay in the ServiceImport, the proposed ports are merged into its ports; see Merge;
+//   - otherwise, or if the merge requires so, the spec of the ServiceImport is resolved again from all the exports.
+//
+// Neither the ServiceImport nor the exports are modified.
+func Predict(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport, clusterID string, proposed []fleetnetv1alpha1.ServicePort) Prediction {
+	// Apply the proposed ports to a copy of the exports.
+	proposedExports := make([]fleetnetv1alpha1.InternalServiceExport, 0, len(exports)+1)
+	found := false
+	for i := range exports {
+		v := exports[i].DeepCopy()
+		if v.Spec.ServiceReference.ClusterID == clusterID {
+			v.Spec.Ports = proposed
+			found = true
+		}
+		proposedExports = append(proposedExports, *v)
+	}
+	if !found {
+		proposedExports = append(proposedExports, fleetnetv1alpha1.InternalServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Finalizers: []string{objectmeta.InternalServiceExportFinalizer},
+			},
+			Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+				Ports:            proposed,
+				ServiceReference: fleetnetv1alpha1.ExportedObjectReference{ClusterID: clusterID},
+			},
+		})
+	}
+
+	conflicted := make(map[string]bool, len(proposedExports))
+	var resolvedPorts []fleetnetv1alpha1.ServicePort
+	var merging *Merging
+	if serviceImport != nil && hasOtherClusters(serviceImport.Status.Clusters, clusterID) {
+		for i := range proposedExports {
+			if proposedExports[i].Spec.ServiceReference.ClusterID == clusterID {
+				m := Merge(serviceImport, proposedExports, &proposedExports[i])
+				merging = &m
+				break
+			}
+		}
+	}
+	if merging != nil && !merging.ResolveAgain {
+		resolvedPorts = merging.Ports
+		conflicted[clusterID] = merging.Conflict
+		listed := make(map[string]bool, len(serviceImport.Status.Clusters))
+		for _, c := range serviceImport.Status.Clusters {
+			listed[c.Cluster] = true
+		}
+		for i := range proposedExports {
+			v := &proposedExports[i]
+			if !IsResolvable(v) {
+				continue
+			}
+			if cluster := v.Spec.ServiceReference.ClusterID; cluster != clusterID {
+				// The exports of the clusters the ServiceImport does not list stay in conflict.
+				conflicted[cluster] = !listed[cluster]
+			}
+		}
+	} else {
+		res := Resolve(proposedExports)
+		if res.Ports != nil {
+			resolvedPorts = *res.Ports
+		}
+		for _, v := range res.Unconflicted {
+			conflicted[v.Spec.ServiceReference.ClusterID] = false
+		}
+		for _, v := range res.Conflicted {
+			conflicted[v.Spec.ServiceReference.ClusterID] = true
+		}
+	}
+
+	prediction := Prediction{
+		Conflict:      conflicted[clusterID],
+		ResolvedPorts: resolvedPorts,
+	}
+	currentlyConflicted := make(map[string]bool, len(exports))
+	for i := range exports {
+		cond := meta.FindStatusCondition(exports[i].Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+		currentlyConflicted[exports[i].Spec.ServiceReference.ClusterID] = cond != nil && cond.Status == metav1.ConditionTrue
+	}
+	for cluster, conflict := range conflicted {
+		if conflict {
+			prediction.ConflictedClusters = append(prediction.ConflictedClusters, cluster)
+		}
+		if conflict != currentlyConflicted[cluster] {
+			prediction.AffectedClusters = append(prediction.AffectedClusters, cluster)
+		}
+	}
+	sort.Strings(prediction.ConflictedClusters)
+	sort.Strings(prediction.AffectedClusters)
+	return prediction
+}
+
+// hasOtherClusters returns true if any cluster other than the given one is in the cluster list.
+func hasOtherClusters(clusters []fleetnetv1alpha1.ClusterStatus, clusterID string) bool {
+	for _, c := range clusters {
+		if c.Cluster != clusterID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,945 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package exportconflict
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	testNamespace = "work"
+	testSvcName   = "app"
+
+	memberClusterID1 = "member-1"
+	memberClusterID2 = "member-2"
+	memberClusterID3 = "member-3"
+)
+
+var (
+	httpPorts = []fleetnetv1alpha1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+	}
+	// webPorts listen on the same port as httpPorts, under another name; they conflict with httpPorts.
+	webPorts = []fleetnetv1alpha1.ServicePort{
+		{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+	}
+)
+
+func internalServiceExport(clusterID string, ports []fleetnetv1alpha1.ServicePort, conflict bool) fleetnetv1alpha1.InternalServiceExport {
+	export := fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  clusterID,
+			Name:       testNamespace + "-" + testSvcName,
+			Finalizers: []string{objectmeta.InternalServiceExportFinalizer},
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: ports,
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID: clusterID,
+				Namespace: testNamespace,
+				Name:      testSvcName,
+			},
+		},
+	}
+	status := metav1.ConditionFalse
+	if conflict {
+		status = metav1.ConditionTrue
+	}
+	export.Status.Conditions = []metav1.Condition{
+		{Type: string(fleetnetv1alpha1.ServiceExportConflict), Status: status},
+	}
+	return export
+}
+
+func serviceImport(ports []fleetnetv1alpha1.ServicePort, clusters ...string) *fleetnetv1alpha1.ServiceImport {
+	svcImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testSvcName,
+		},
+	}
+	if len(clusters) == 0 {
+		return svcImport
+	}
+	svcImport.Status.Type = fleetnetv1alpha1.ClusterSetIP
+	svcImport.Status.Ports = ports
+	for _, cluster := range clusters {
+		svcImport.Status.Clusters = append(svcImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: cluster})
+	}
+	return svcImport
+}
+
+func TestPortIncompatibility(t *testing.T) {
+	metricsPort := fleetnetv1alpha1.ServicePort{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090}
+	tests := []struct {
+		name   string
+		merged []fleetnetv1alpha1.ServicePort
+		ports  []fleetnetv1alpha1.ServicePort
+		// want is the reason of the incompatibility; empty if the ports are compatible.
+		want string
+	}{
+		{
+			name:  "nothing merged yet",
+			ports: webPorts,
+		},
+		{
+			name:   "exact duplicate",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+		},
+		{
+			name:   "protocol defaulting to TCP",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Port: 80}},
+		},
+		{
+			name:   "different target port",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt32(8080)}},
+		},
+		{
+			name:   "additional port",
+			merged: httpPorts,
+			ports:  append([]fleetnetv1alpha1.ServicePort{metricsPort}, httpPorts...),
+		},
+		{
+			name:   "subset of the ports",
+			merged: append([]fleetnetv1alpha1.ServicePort{metricsPort}, httpPorts...),
+			ports:  httpPorts,
+		},
+		{
+			name:   "same port with another protocol",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http-udp", Protocol: corev1.ProtocolUDP, Port: 80}},
+		},
+		{
+			name:   "same key with another name",
+			merged: httpPorts,
+			ports:  webPorts,
+			want:   `port 80/TCP is named "web", but the other clusters name it "http"`,
+		},
+		{
+			name:   "same key with another application protocol",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, AppProtocol: ptr.To("http")}},
+			want:   `the application protocol of port 80/TCP is "http", but "" for the other clusters`,
+		},
+		{
+			name:   "same key with the application protocol unset",
+			merged: []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, AppProtocol: ptr.To("http")}},
+			ports:  httpPorts,
+			want:   `the application protocol of port 80/TCP is "", but "http" for the other clusters`,
+		},
+		{
+			name:   "name taken by another key",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080}},
+			want:   `port 8080/TCP is named "http", which the other clusters already use for port 80/TCP`,
+		},
+		{
+			name:   "unnamed port added to named ports",
+			merged: httpPorts,
+			ports:  []fleetnetv1alpha1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 9090}},
+			want:   "port 9090/TCP is unnamed, but the ports of a service with multiple ports must all be named",
+		},
+		{
+			name:   "named port added to an unnamed port",
+			merged: []fleetnetv1alpha1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}},
+			ports:  []fleetnetv1alpha1.ServicePort{metricsPort},
+			want:   "port 80/TCP of the other clusters is unnamed, but the ports of a service with multiple ports must all be named",
+		},
+		{
+			name:   "unnamed ports with the same key",
+			merged: []fleetnetv1alpha1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}},
+			ports:  []fleetnetv1alpha1.ServicePort{{Port: 80}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := PortIncompatibility(tc.merged, tc.ports); got != tc.want {
+				t.Errorf("PortIncompatibility() = %q, want %q", got, tc.want)
+			}
+			if got, want := CompatibleServicePorts(tc.merged, tc.ports), tc.want == ""; got != want {
+				t.Errorf("CompatibleServicePorts() = %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+func TestMergeServicePorts(t *testing.T) {
+	tests := []struct {
+		name      string
+		portLists [][]fleetnetv1alpha1.ServicePort
+		want      []fleetnetv1alpha1.ServicePort
+	}{
+		{
+			name: "no ports",
+		},
+		{
+			name: "union sorted by port and protocol",
+			portLists: [][]fleetnetv1alpha1.ServicePort{
+				{
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+					{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				},
+				{
+					{Name: "dns-udp", Protocol: corev1.ProtocolUDP, Port: 53},
+					{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				},
+			},
+			want: []fleetnetv1alpha1.ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns-udp", Protocol: corev1.ProtocolUDP, Port: 53},
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+			},
+		},
+		{
+			name:      "exact duplicates",
+			portLists: [][]fleetnetv1alpha1.ServicePort{httpPorts, httpPorts},
+			want:      httpPorts,
+		},
+		{
+			name: "first port wins for the same key",
+			portLists: [][]fleetnetv1alpha1.ServicePort{
+				{{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)}},
+				{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt32(8081)}},
+			},
+			want: []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromInt32(8080)},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, MergeServicePorts(tc.portLists...)); diff != "" {
+				t.Errorf("MergeServicePorts() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	deleting := internalServiceExport(memberClusterID1, webPorts, false)
+	deleting.DeletionTimestamp = &metav1.Time{}
+	unhandled := internalServiceExport(memberClusterID2, webPorts, false)
+	unhandled.Finalizers = nil
+	exports := []fleetnetv1alpha1.InternalServiceExport{
+		deleting,
+		unhandled,
+		internalServiceExport(memberClusterID3, httpPorts, false),
+		internalServiceExport("member-4", webPorts, false),
+		internalServiceExport("member-5", httpPorts, false),
+	}
+
+	res := Resolve(exports)
+	if res.Ports == nil || !EqualServicePorts(*res.Ports, httpPorts) {
+		t.Fatalf("Resolve() ports = %v, want %v", res.Ports, httpPorts)
+	}
+	clusterIDs := func(exports []*fleetnetv1alpha1.InternalServiceExport) []string {
+		res := []string{}
+		for _, v := range exports {
+			res = append(res, v.Spec.ServiceReference.ClusterID)
+		}
+		return res
+	}
+	if diff := cmp.Diff([]string{memberClusterID3, "member-5"}, clusterIDs(res.Unconflicted)); diff != "" {
+		t.Errorf("Resolve() unconflicted mismatch (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"member-4"}, clusterIDs(res.Conflicted)); diff != "" {
+		t.Errorf("Resolve() conflicted mismatch (-want, +got):\n%s", diff)
+	}
+
+	if res := Resolve(exports[:2]); res.Ports != nil {
+		t.Errorf("Resolve() ports = %v, want nil", *res.Ports)
+	}
+}
+
+func TestResolve_OldestExportWins(t *testing.T) {
+	now := time.Now()
+	oldest := internalServiceExport(memberClusterID3, webPorts, true)
+	oldest.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	older := internalServiceExport(memberClusterID1, httpPorts, false)
+	older.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+	newer := internalServiceExport(memberClusterID2, httpPorts, false)
+	newer.CreationTimestamp = metav1.NewTime(now)
+	proposed := internalServiceExport("member-4", httpPorts, false)
+
+	tests := []struct {
+		name          string
+		exports       []fleetnetv1alpha1.InternalServiceExport
+		wantCanonical string
+		wantPorts     []fleetnetv1alpha1.ServicePort
+	}{
+		{
+			name:          "oldest export listed last",
+			exports:       []fleetnetv1alpha1.InternalServiceExport{older, newer, oldest},
+			wantCanonical: memberClusterID3,
+			wantPorts:     webPorts,
+		},
+		{
+			name:          "exports sharing the same ports",
+			exports:       []fleetnetv1alpha1.InternalServiceExport{newer, older},
+			wantCanonical: memberClusterID1,
+			wantPorts:     httpPorts,
+		},
+		{
+			name:          "export not created yet",
+			exports:       []fleetnetv1alpha1.InternalServiceExport{proposed, newer},
+			wantCanonical: memberClusterID2,
+			wantPorts:     httpPorts,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Resolve(tc.exports)
+			if res.Canonical == nil || res.Canonical.Spec.ServiceReference.ClusterID != tc.wantCanonical {
+				t.Fatalf("Resolve() canonical = %v, want %s", res.Canonical, tc.wantCanonical)
+			}
+			if !EqualServicePorts(*res.Ports, tc.wantPorts) {
+				t.Errorf("Resolve() ports = %v, want %v", *res.Ports, tc.wantPorts)
+			}
+			for _, v := range res.Unconflicted {
+				if !EqualServicePorts(v.Spec.Ports, tc.wantPorts) {
+					t.Errorf("Resolve() unconflicted export %s has ports %v, want %v", v.Spec.ServiceReference.ClusterID, v.Spec.Ports, tc.wantPorts)
+				}
+			}
+			if got, want := len(res.Unconflicted)+len(res.Conflicted), len(tc.exports); got != want {
+				t.Errorf("Resolve() resolved %d exports, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestResolve_MergedPorts(t *testing.T) {
+	now := time.Now()
+	exportCreatedAt := func(clusterID string, ports []fleetnetv1alpha1.ServicePort, svcCreated time.Time) fleetnetv1alpha1.InternalServiceExport {
+		export := internalServiceExport(clusterID, ports, false)
+		export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(svcCreated)
+		return export
+	}
+	metricsPorts := append([]fleetnetv1alpha1.ServicePort{{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090}}, httpPorts...)
+	wantMetricsPorts := []fleetnetv1alpha1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+		{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+	}
+
+	tests := []struct {
+		name             string
+		exports          []fleetnetv1alpha1.InternalServiceExport
+		wantPorts        []fleetnetv1alpha1.ServicePort
+		wantUnconflicted []string
+		wantConflicted   []string
+		wantReasons      map[string]string
+	}{
+		{
+			name: "union of overlapping ports",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour)),
+				exportCreatedAt(memberClusterID2, metricsPorts, now),
+			},
+			wantPorts:        wantMetricsPorts,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID2},
+			wantConflicted:   []string{},
+		},
+		{
+			name: "exact duplicates",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour)),
+				exportCreatedAt(memberClusterID2, httpPorts, now),
+			},
+			wantPorts:        httpPorts,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID2},
+			wantConflicted:   []string{},
+		},
+		{
+			name: "conflicting key",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, metricsPorts, now.Add(-time.Hour)),
+				exportCreatedAt(memberClusterID2, webPorts, now.Add(-time.Minute)),
+				exportCreatedAt(memberClusterID3, httpPorts, now),
+			},
+			wantPorts:        wantMetricsPorts,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID3},
+			wantConflicted:   []string{memberClusterID2},
+			wantReasons:      map[string]string{memberClusterID2: `port 80/TCP is named "web", but the other clusters name it "http"`},
+		},
+		{
+			// A Service with a single unnamed port cannot be merged with the ports of another cluster which adds a
+			// metrics port, as the ports of a Service with multiple ports must all be named.
+			name: "unnamed port",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, []fleetnetv1alpha1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}}, now.Add(-time.Hour)),
+				exportCreatedAt(memberClusterID2, []fleetnetv1alpha1.ServicePort{
+					{Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+				}, now),
+			},
+			wantPorts:        []fleetnetv1alpha1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}},
+			wantUnconflicted: []string{memberClusterID1},
+			wantConflicted:   []string{memberClusterID2},
+			wantReasons: map[string]string{
+				memberClusterID2: "port 80/TCP of the other clusters is unnamed, but the ports of a service with multiple ports must all be named",
+			},
+		},
+		{
+			// The merged ports of the imported Service must have unique names.
+			name: "same name on different keys",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour)),
+				exportCreatedAt(memberClusterID2, []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080}}, now),
+			},
+			wantPorts:        httpPorts,
+			wantUnconflicted: []string{memberClusterID1},
+			wantConflicted:   []string{memberClusterID2},
+			wantReasons: map[string]string{
+				memberClusterID2: `port 8080/TCP is named "http", which the other clusters already use for port 80/TCP`,
+			},
+		},
+		{
+			name: "conflicted export not merged",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour)),
+				// The export of member 2 conflicts, so its metrics port is not merged, and does not block the metrics
+				// port of member 3.
+				exportCreatedAt(memberClusterID2, []fleetnetv1alpha1.ServicePort{
+					{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9091},
+				}, now.Add(-time.Minute)),
+				exportCreatedAt(memberClusterID3, metricsPorts, now),
+			},
+			wantPorts:        wantMetricsPorts,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID3},
+			wantConflicted:   []string{memberClusterID2},
+			wantReasons:      map[string]string{memberClusterID2: `port 80/TCP is named "web", but the other clusters name it "http"`},
+		},
+	}
+	clusterIDs := func(exports []*fleetnetv1alpha1.InternalServiceExport) []string {
+		res := []string{}
+		for _, v := range exports {
+			res = append(res, v.Spec.ServiceReference.ClusterID)
+		}
+		return res
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Resolve(tc.exports)
+			if res.Ports == nil {
+				t.Fatalf("Resolve() ports = nil, want %v", tc.wantPorts)
+			}
+			if diff := cmp.Diff(tc.wantPorts, *res.Ports); diff != "" {
+				t.Errorf("Resolve() ports mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantUnconflicted, clusterIDs(res.Unconflicted)); diff != "" {
+				t.Errorf("Resolve() unconflicted mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantConflicted, clusterIDs(res.Conflicted)); diff != "" {
+				t.Errorf("Resolve() conflicted mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantReasons, res.ConflictReasons, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Resolve() conflict reasons mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolve_ImportType(t *testing.T) {
+	now := time.Now()
+	exportCreatedAt := func(clusterID string, svcCreated time.Time, importType fleetnetv1alpha1.ServiceImportType) fleetnetv1alpha1.InternalServiceExport {
+		export := internalServiceExport(clusterID, httpPorts, false)
+		export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(svcCreated)
+		export.Spec.ImportType = importType
+		return export
+	}
+
+	tests := []struct {
+		name             string
+		exports          []fleetnetv1alpha1.InternalServiceExport
+		wantType         fleetnetv1alpha1.ServiceImportType
+		wantUnconflicted []string
+		wantReasons      map[string]string
+	}{
+		{
+			name: "exports not reporting the type",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, now.Add(-time.Hour), ""),
+				exportCreatedAt(memberClusterID2, now, fleetnetv1alpha1.ClusterSetIP),
+			},
+			wantType:         fleetnetv1alpha1.ClusterSetIP,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID2},
+		},
+		{
+			name: "headless exports",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, now.Add(-time.Hour), fleetnetv1alpha1.Headless),
+				exportCreatedAt(memberClusterID2, now, fleetnetv1alpha1.Headless),
+			},
+			wantType:         fleetnetv1alpha1.Headless,
+			wantUnconflicted: []string{memberClusterID1, memberClusterID2},
+		},
+		{
+			name: "clusters disagreeing on the type",
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, now, fleetnetv1alpha1.ClusterSetIP),
+				exportCreatedAt(memberClusterID2, now.Add(-time.Hour), fleetnetv1alpha1.Headless),
+				exportCreatedAt(memberClusterID3, now.Add(time.Minute), fleetnetv1alpha1.Headless),
+			},
+			wantType:         fleetnetv1alpha1.Headless,
+			wantUnconflicted: []string{memberClusterID2, memberClusterID3},
+			wantReasons: map[string]string{
+				memberClusterID1: "the service is imported as ClusterSetIP, but the other clusters export it to be imported as Headless",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Resolve(tc.exports)
+			if res.Type != tc.wantType {
+				t.Errorf("Resolve() type = %q, want %q", res.Type, tc.wantType)
+			}
+			unconflicted := []string{}
+			for _, v := range res.Unconflicted {
+				unconflicted = append(unconflicted, v.Spec.ServiceReference.ClusterID)
+			}
+			if diff := cmp.Diff(tc.wantUnconflicted, unconflicted); diff != "" {
+				t.Errorf("Resolve() unconflicted mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantReasons, res.ConflictReasons, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("Resolve() conflict reasons mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	now := time.Now()
+	exportCreatedAt := func(clusterID string, ports []fleetnetv1alpha1.ServicePort, svcCreated time.Time, conflict bool) fleetnetv1alpha1.InternalServiceExport {
+		export := internalServiceExport(clusterID, ports, conflict)
+		export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(svcCreated)
+		return export
+	}
+	metricsPorts := append([]fleetnetv1alpha1.ServicePort{{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090}}, httpPorts...)
+	wantMetricsPorts := []fleetnetv1alpha1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+		{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+	}
+	headless := func(export fleetnetv1alpha1.InternalServiceExport) fleetnetv1alpha1.InternalServiceExport {
+		export.Spec.ImportType = fleetnetv1alpha1.Headless
+		return export
+	}
+
+	tests := []struct {
+		name          string
+		serviceImport *fleetnetv1alpha1.ServiceImport
+		exports       []fleetnetv1alpha1.InternalServiceExport
+		// export is the index of the export to merge.
+		export int
+		want   Merging
+	}{
+		{
+			name:          "new export adding a port",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour), false),
+				exportCreatedAt(memberClusterID2, metricsPorts, now, false),
+			},
+			export: 1,
+			want:   Merging{Ports: wantMetricsPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "export dropping a port no other cluster exposes",
+			serviceImport: serviceImport(wantMetricsPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour), false),
+				exportCreatedAt(memberClusterID2, httpPorts, now, false),
+			},
+			export: 1,
+			want:   Merging{Ports: httpPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "export dropping a port another cluster exposes",
+			serviceImport: serviceImport(wantMetricsPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, metricsPorts, now.Add(-time.Hour), false),
+				exportCreatedAt(memberClusterID2, httpPorts, now, false),
+			},
+			export: 1,
+			want:   Merging{Ports: wantMetricsPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "export conflicting with the other clusters",
+			serviceImport: serviceImport(wantMetricsPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now, false),
+				exportCreatedAt(memberClusterID2, []fleetnetv1alpha1.ServicePort{
+					{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+				}, now.Add(-time.Hour), false),
+			},
+			export: 1,
+			want:   Merging{Conflict: true, Reason: `port 80/TCP is named "web", but the other clusters name it "http"`, Ports: httpPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "ports changed while another export is in conflict",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, webPorts, now.Add(-time.Hour), false),
+				exportCreatedAt(memberClusterID2, webPorts, now, true),
+			},
+			export: 0,
+			want:   Merging{Ports: webPorts, Type: fleetnetv1alpha1.ClusterSetIP, ResolveAgain: true},
+		},
+		{
+			name:          "ports unchanged while another export is in conflict",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour), false),
+				exportCreatedAt(memberClusterID2, webPorts, now, true),
+			},
+			export: 0,
+			want:   Merging{Ports: httpPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "export conflicting with a listed cluster whose export is missing",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID2, webPorts, now, false),
+			},
+			export: 0,
+			want:   Merging{Conflict: true, Reason: `port 80/TCP is named "web", but the other clusters name it "http"`, Ports: httpPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "export dropping a port while the export of another listed cluster is missing",
+			serviceImport: serviceImport(wantMetricsPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID2, httpPorts, now, false),
+			},
+			export: 0,
+			want:   Merging{Ports: wantMetricsPorts, Type: fleetnetv1alpha1.ClusterSetIP},
+		},
+		{
+			name:          "headless export conflicting with the other clusters",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour), false),
+				headless(exportCreatedAt(memberClusterID2, httpPorts, now, false)),
+			},
+			export: 1,
+			want: Merging{
+				Conflict: true,
+				Reason:   "the service is imported as Headless, but the other clusters export it to be imported as ClusterSetIP",
+				Ports:    httpPorts,
+				Type:     fleetnetv1alpha1.ClusterSetIP,
+			},
+		},
+		{
+			name:          "headless export conflicting with a listed cluster whose export is missing",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				headless(exportCreatedAt(memberClusterID2, httpPorts, now, false)),
+			},
+			export: 0,
+			want: Merging{
+				Conflict: true,
+				Reason:   "the service is imported as Headless, but the other clusters export it to be imported as ClusterSetIP",
+				Ports:    httpPorts,
+				Type:     fleetnetv1alpha1.ClusterSetIP,
+			},
+		},
+		{
+			name:          "only listed export turning headless",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				headless(exportCreatedAt(memberClusterID1, httpPorts, now, false)),
+			},
+			export: 0,
+			want:   Merging{Ports: httpPorts, Type: fleetnetv1alpha1.Headless},
+		},
+		{
+			name:          "type changed while another export is in conflict",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				headless(exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour), false)),
+				headless(exportCreatedAt(memberClusterID2, httpPorts, now, true)),
+			},
+			export: 0,
+			want:   Merging{Ports: httpPorts, Type: fleetnetv1alpha1.Headless, ResolveAgain: true},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Merge(tc.serviceImport, tc.exports, &tc.exports[tc.export])
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Merge() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExportedBefore(t *testing.T) {
+	now := time.Now()
+	exportCreatedAt := func(clusterID string, exportCreated, svcCreated time.Time) *fleetnetv1alpha1.InternalServiceExport {
+		export := internalServiceExport(clusterID, httpPorts, false)
+		if !exportCreated.IsZero() {
+			export.CreationTimestamp = metav1.NewTime(exportCreated)
+		}
+		if !svcCreated.IsZero() {
+			export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(svcCreated)
+		}
+		return &export
+	}
+
+	tests := []struct {
+		name string
+		a    *fleetnetv1alpha1.InternalServiceExport
+		b    *fleetnetv1alpha1.InternalServiceExport
+		want bool
+	}{
+		{
+			name: "service created earlier",
+			a:    exportCreatedAt(memberClusterID2, time.Time{}, now.Add(-time.Hour)),
+			b:    exportCreatedAt(memberClusterID1, time.Time{}, now),
+			want: true,
+		},
+		{
+			name: "service created later",
+			a:    exportCreatedAt(memberClusterID1, time.Time{}, now),
+			b:    exportCreatedAt(memberClusterID2, time.Time{}, now.Add(-time.Hour)),
+		},
+		{
+			name: "services created at the same time, lower cluster ID",
+			a:    exportCreatedAt(memberClusterID1, time.Time{}, now),
+			b:    exportCreatedAt(memberClusterID2, time.Time{}, now),
+			want: true,
+		},
+		{
+			name: "services created at the same time, higher cluster ID",
+			a:    exportCreatedAt(memberClusterID2, time.Time{}, now),
+			b:    exportCreatedAt(memberClusterID1, time.Time{}, now),
+		},
+		{
+			name: "service creation timestamp takes precedence over the one of the export",
+			a:    exportCreatedAt(memberClusterID2, now, now.Add(-time.Hour)),
+			b:    exportCreatedAt(memberClusterID1, now.Add(-time.Hour), now.Add(-time.Minute)),
+			want: true,
+		},
+		{
+			name: "service creation timestamp not reported",
+			a:    exportCreatedAt(memberClusterID2, now.Add(-time.Hour), time.Time{}),
+			b:    exportCreatedAt(memberClusterID1, time.Time{}, now),
+			want: true,
+		},
+		{
+			name: "export not created yet",
+			a:    exportCreatedAt(memberClusterID1, time.Time{}, time.Time{}),
+			b:    exportCreatedAt(memberClusterID2, time.Time{}, now),
+		},
+		{
+			name: "neither export created yet",
+			a:    exportCreatedAt(memberClusterID1, time.Time{}, time.Time{}),
+			b:    exportCreatedAt(memberClusterID2, time.Time{}, time.Time{}),
+			want: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exportedBefore(tc.a, tc.b); got != tc.want {
+				t.Errorf("exportedBefore() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	now := time.Now()
+	exportCreatedAt := func(clusterID string, ports []fleetnetv1alpha1.ServicePort, svcCreated time.Time) fleetnetv1alpha1.InternalServiceExport {
+		export := internalServiceExport(clusterID, ports, false)
+		export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(svcCreated)
+		return export
+	}
+	deleting := exportCreatedAt(memberClusterID1, httpPorts, now.Add(-time.Hour))
+	deleting.DeletionTimestamp = &metav1.Time{Time: now}
+
+	tests := []struct {
+		name          string
+		serviceImport *fleetnetv1alpha1.ServiceImport
+		exports       []fleetnetv1alpha1.InternalServiceExport
+		want          string
+	}{
+		{
+			name:          "oldest export among the listed clusters",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID1, httpPorts, now),
+				exportCreatedAt(memberClusterID2, httpPorts, now.Add(-time.Minute)),
+				// The export of member 3 is older, but conflicts with the ServiceImport.
+				exportCreatedAt(memberClusterID3, webPorts, now.Add(-time.Hour)),
+			},
+			want: memberClusterID2,
+		},
+		{
+			name:          "export being deleted",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				deleting,
+				exportCreatedAt(memberClusterID2, httpPorts, now),
+			},
+			want: memberClusterID1,
+		},
+		{
+			name:          "no export from the listed clusters",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				exportCreatedAt(memberClusterID3, webPorts, now),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ""
+			if canonical := Canonical(tc.serviceImport, tc.exports); canonical != nil {
+				got = canonical.Spec.ServiceReference.ClusterID
+			}
+			if got != tc.want {
+				t.Errorf("Canonical() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPredict(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceImport *fleetnetv1alpha1.ServiceImport
+		exports       []fleetnetv1alpha1.InternalServiceExport
+		clusterID     string
+		proposed      []fleetnetv1alpha1.ServicePort
+		want          Prediction
+	}{
+		{
+			name:          "new export matching the serviceImport",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+			},
+			clusterID: memberClusterID2,
+			proposed:  httpPorts,
+			want: Prediction{
+				ResolvedPorts: httpPorts,
+			},
+		},
+		{
+			name:          "new export conflicting with the serviceImport",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+			},
+			clusterID: memberClusterID2,
+			proposed:  webPorts,
+			want: Prediction{
+				Conflict:           true,
+				ResolvedPorts:      httpPorts,
+				ConflictedClusters: []string{memberClusterID2},
+				AffectedClusters:   []string{memberClusterID2},
+			},
+		},
+		{
+			name:          "changed ports conflicting with the other clusters",
+			serviceImport: serviceImport(httpPorts, memberClusterID1, memberClusterID2),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+				internalServiceExport(memberClusterID2, httpPorts, false),
+			},
+			clusterID: memberClusterID1,
+			proposed:  webPorts,
+			want: Prediction{
+				Conflict:           true,
+				ResolvedPorts:      httpPorts,
+				ConflictedClusters: []string{memberClusterID1},
+				AffectedClusters:   []string{memberClusterID1},
+			},
+		},
+		{
+			name:          "fixing the ports of a conflicted export",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+				internalServiceExport(memberClusterID2, webPorts, true),
+			},
+			clusterID: memberClusterID2,
+			proposed:  httpPorts,
+			want: Prediction{
+				ResolvedPorts:    httpPorts,
+				AffectedClusters: []string{memberClusterID2},
+			},
+		},
+		{
+			name:          "changed ports of the only cluster in the serviceImport are resolved again",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+				internalServiceExport(memberClusterID2, webPorts, true),
+				internalServiceExport(memberClusterID3, httpPorts, true),
+			},
+			clusterID: memberClusterID1,
+			proposed:  webPorts,
+			want: Prediction{
+				ResolvedPorts:      webPorts,
+				ConflictedClusters: []string{memberClusterID3},
+				AffectedClusters:   []string{memberClusterID2},
+			},
+		},
+		{
+			name:          "new export adding a port",
+			serviceImport: serviceImport(httpPorts, memberClusterID1),
+			exports: []fleetnetv1alpha1.InternalServiceExport{
+				internalServiceExport(memberClusterID1, httpPorts, false),
+			},
+			clusterID: memberClusterID2,
+			proposed:  append([]fleetnetv1alpha1.ServicePort{{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090}}, httpPorts...),
+			want: Prediction{
+				ResolvedPorts: []fleetnetv1alpha1.ServicePort{
+					{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+					{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+				},
+			},
+		},
+		{
+			name:      "first export of the service",
+			clusterID: memberClusterID1,
+			proposed:  httpPorts,
+			want: Prediction{
+				ResolvedPorts: httpPorts,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var exports []fleetnetv1alpha1.InternalServiceExport
+			for i := range tc.exports {
+				exports = append(exports, *tc.exports[i].DeepCopy())
+			}
+			got := Predict(tc.serviceImport, tc.exports, tc.clusterID, tc.proposed)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Predict() mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(exports, tc.exports); diff != "" {
+				t.Errorf("Predict() modified the exports (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
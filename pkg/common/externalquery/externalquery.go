@@ -0,0 +1,110 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package externalquery features the restrictions under which the agents query the URLs set by the users, e.g. the
+// metrics endpoints and the SLA monitors of the Traffic Manager profiles, and a simple in-memory querier which runs
+// the queries in the background, so that reconcilers never wait for them.
+package externalquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// azureWireServerIP is the virtual public IP address of the Azure platform, through which the VMs reach the host.
+var azureWireServerIP = net.IPv4(168, 63, 129, 16)
+
+// ValidateURL returns an error unless the URL is an http or https URL whose host is allowed; a host is allowed if it
+// equals one of the allowed hosts, or if it is a subdomain of an allowed host of the form "*.example.com". Hosts are
+// compared case-insensitively and without the port.
+func ValidateURL(rawURL string, allowedHosts []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse the URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL scheme %q is not allowed, want http or https", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("URL must not carry user info")
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return errors.New("URL has no host")
+	}
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) {
+				return nil
+			}
+			continue
+		}
+		if host == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("URL host %q is not in the allowed hosts of the agent", host)
+}
+
+// NewClient returns an HTTP client for querying the URLs set by the users. It gives up after the timeout, does not
+// follow redirects, ignores the proxy settings of the environment, and refuses to connect to loopback, link-local,
+// multicast and unspecified addresses, as well as the Azure wire server; the addresses are checked after the host
+// name is resolved, so that a host name cannot be pointed at them either.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isBlockedIP(ip) {
+				return fmt.Errorf("connecting to %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// isBlockedIP returns if the agents must not connect to the IP address, e.g. the instance metadata service.
+func isBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || ip.IsUnspecified() || ip.Equal(azureWireServerIP)
+}
+
+// Get sends a GET request accepting JSON to the URL with the client, once the URL is validated against the allowed
+// hosts; the caller must close the body of the response.
+func Get(ctx context.Context, httpClient *http.Client, rawURL string, allowedHosts []string) (*http.Response, error) {
+	if err := ValidateURL(rawURL, allowedHosts); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return httpClient.Do(req)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package externalquery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// QueryFunc queries an external system.
+type QueryFunc[T any] func(ctx context.Context) (T, error)
+
+type entry[T any] struct {
+	result     T
+	err        error
+	hasResult  bool
+	queriedAt  time.Time
+	inFlight   bool
+	lastUsedAt time.Time
+}
+
+// Querier runs the queries of each key in the background, at most one at a time, and caches their latest results;
+// a key whose result is older than the refresh period is queried again the next time its result is read.
+//
+// It is safe for concurrent use.
+type Querier[T any] struct {
+	refreshPeriod time.Duration
+	clock         clock.PassiveClock
+
+	mu      sync.Mutex
+	entries map[string]*entry[T]
+}
+
+// NewQuerier returns a Querier which queries each key again once its result is older than the refresh period.
+func NewQuerier[T any](refreshPeriod time.Duration) *Querier[T] {
+	return NewQuerierWithClock[T](refreshPeriod, clock.RealClock{})
+}
+
+// NewQuerierWithClock returns a Querier which uses the given clock; it is mostly used in tests.
+func NewQuerierWithClock[T any](refreshPeriod time.Duration, clock clock.PassiveClock) *Querier[T] {
+	return &Querier[T]{
+		refreshPeriod: refreshPeriod,
+		clock:         clock,
+		entries:       map[string]*entry[T]{},
+	}
+}
+
+// Get returns the result of the latest query for the key, and starts the query in the background if there is no
+// result yet or if the result is older than the refresh period; ok is false until the first query of the key ends.
+func (q *Querier[T]) Get(key string, query QueryFunc[T]) (result T, ok bool, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.clock.Now()
+	q.evictUnusedLocked(now)
+	e, found := q.entries[key]
+	if !found {
+		e = &entry[T]{}
+		q.entries[key] = e
+	}
+	e.lastUsedAt = now
+	if !e.inFlight && (!e.hasResult || now.Sub(e.queriedAt) >= q.refreshPeriod) {
+		e.inFlight = true
+		go q.run(e, query)
+	}
+	return e.result, e.hasResult, e.err
+}
+
+// run runs the query and records its result.
+func (q *Querier[T]) run(e *entry[T], query QueryFunc[T]) {
+	result, err := query(context.Background())
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e.result, e.err, e.hasResult = result, err, true
+	e.queriedAt = q.clock.Now()
+	e.inFlight = false
+}
+
+// Pending returns if the key has been queried but no query of it has ended yet.
+func (q *Querier[T]) Pending(key string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e, found := q.entries[key]
+	return found && !e.hasResult
+}
+
+// evictUnusedLocked removes the results which have not been read for a few refresh periods, e.g. of the URLs which
+// are no longer set, so that the cache does not grow with every URL ever queried.
+func (q *Querier[T]) evictUnusedLocked(now time.Time) {
+	for key, e := range q.entries {
+		if !e.inFlight && now.Sub(e.lastUsedAt) > 3*q.refreshPeriod {
+			delete(q.entries, key)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package externalquery
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const (
+	testKey           = "http://metrics.example.com/capacity"
+	testRefreshPeriod = time.Minute
+)
+
+func TestQuerier(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	q := NewQuerierWithClock[int](testRefreshPeriod, fakeClock)
+
+	release := make(chan struct{})
+	queries := make(chan struct{}, 10)
+	result := 1
+	query := func(context.Context) (int, error) {
+		queries <- struct{}{}
+		<-release
+		return result, nil
+	}
+
+	// The first read starts the query in the background and returns no result.
+	if _, ok, _ := q.Get(testKey, query); ok {
+		t.Fatalf("Get() ok = true before the first query ends, want false")
+	}
+	<-queries
+	if !q.Pending(testKey) {
+		t.Fatalf("Pending() = false while the first query runs, want true")
+	}
+	// A key is queried at most once at a time.
+	if _, ok, _ := q.Get(testKey, query); ok {
+		t.Fatalf("Get() ok = true before the first query ends, want false")
+	}
+	release <- struct{}{}
+	waitForQuery(t, q)
+
+	if got, ok, err := q.Get(testKey, query); !ok || err != nil || got != 1 {
+		t.Fatalf("Get() = (%d, %t, %v), want (1, true, nil)", got, ok, err)
+	}
+	if len(queries) != 0 {
+		t.Fatalf("Get() queried the key again within the refresh period")
+	}
+
+	// The key is queried again once the result is older than the refresh period; the latest result is returned
+	// meanwhile.
+	fakeClock.SetTime(fakeClock.Now().Add(testRefreshPeriod))
+	result = 2
+	if got, ok, _ := q.Get(testKey, query); !ok || got != 1 {
+		t.Fatalf("Get() = (%d, %t), want the latest result (1, true)", got, ok)
+	}
+	<-queries
+	release <- struct{}{}
+	if err := pollUntil(func() bool {
+		got, _, _ := q.Get(testKey, query)
+		return got == 2
+	}); err != nil {
+		t.Fatalf("Get() never returned the result of the second query")
+	}
+
+	// The results which are not read for a while are evicted.
+	fakeClock.SetTime(fakeClock.Now().Add(4 * testRefreshPeriod))
+	if _, ok, _ := q.Get("other-key", func(context.Context) (int, error) { return 0, nil }); ok {
+		t.Fatalf("Get(other-key) ok = true, want false")
+	}
+	if q.Pending(testKey) {
+		t.Fatalf("Pending() = true for an evicted key, want false")
+	}
+}
+
+func TestQuerier_Error(t *testing.T) {
+	q := NewQuerier[int](testRefreshPeriod)
+	wantErr := errors.New("unavailable")
+	query := func(context.Context) (int, error) {
+		return 0, wantErr
+	}
+
+	q.Get(testKey, query)
+	waitForQuery(t, q)
+	if _, ok, err := q.Get(testKey, query); !ok || !errors.Is(err, wantErr) {
+		t.Fatalf("Get() = (%t, %v), want (true, %v)", ok, err, wantErr)
+	}
+}
+
+func waitForQuery(t *testing.T, q *Querier[int]) {
+	t.Helper()
+	if err := pollUntil(func() bool { return !q.Pending(testKey) }); err != nil {
+		t.Fatalf("the query of %s did not end", testKey)
+	}
+}
+
+func pollUntil(cond func() bool) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.New("timed out")
+}
@@ -0,0 +1,156 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package freshness features a simple in-memory tracker of how long the endpoint changes of exported Services take
+// to become visible across the fleet, which flags the Services whose lag exceeds the freshness objective.
+package freshness
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// DefaultThreshold is the default lag above which the freshness of an exported Service is degraded.
+	DefaultThreshold = 30 * time.Second
+	// DefaultRecoveryThreshold is the default lag at or below which a degraded Service recovers.
+	DefaultRecoveryThreshold = 20 * time.Second
+	// DefaultWindow is the default period over which the lag of an exported Service is measured.
+	DefaultWindow = 5 * time.Minute
+)
+
+// Config configures the freshness objective of exported Services.
+type Config struct {
+	// Threshold is the lag above which the freshness of an exported Service is degraded.
+	Threshold time.Duration
+	// RecoveryThreshold is the lag at or below which a degraded Service recovers; it is lower than the threshold,
+	// so that a lag hovering around the threshold does not flip the state of the Service back and forth.
+	RecoveryThreshold time.Duration
+	// Window is how long a measured lag counts towards the rolling lag of the Service.
+	Window time.Duration
+}
+
+// Key identifies an exported Service, i.e. a Service exported by a specific member cluster.
+type Key struct {
+	ClusterID string
+	Service   types.NamespacedName
+}
+
+// State is the freshness state of an exported Service.
+type State struct {
+	// Degraded is true if the rolling lag of the Service has exceeded the threshold, and has not come down to the
+	// recovery threshold since.
+	Degraded bool
+	// Lag is the rolling lag of the Service, i.e. the longest lag measured within the window.
+	Lag time.Duration
+}
+
+type sample struct {
+	observedAt time.Time
+	lag        time.Duration
+}
+
+type series struct {
+	samples  []sample
+	degraded bool
+}
+
+// Tracker keeps the lags measured for each exported Service within the window, and tells whether the freshness
+// of the Service is degraded.
+//
+// The tracker keeps an entry for every Service it has measured; the number of entries is bounded by the number of
+// exported Services. It is safe for concurrent use.
+type Tracker struct {
+	config Config
+	clock  clock.PassiveClock
+
+	mu     sync.Mutex
+	series map[Key]*series
+}
+
+// New returns a Tracker with the given freshness objective.
+func New(config Config) *Tracker {
+	return NewWithClock(config, clock.RealClock{})
+}
+
+// NewWithClock returns a Tracker which uses the given clock; it is mostly used in tests.
+func NewWithClock(config Config, clock clock.PassiveClock) *Tracker {
+	if config.RecoveryThreshold <= 0 || config.RecoveryThreshold > config.Threshold {
+		config.RecoveryThreshold = config.Threshold
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultWindow
+	}
+	return &Tracker{
+		config: config,
+		clock:  clock,
+		series: map[Key]*series{},
+	}
+}
+
+// Threshold returns the lag above which the freshness of an exported Service is degraded.
+func (t *Tracker) Threshold() time.Duration {
+	return t.config.Threshold
+}
+
+// Window returns how long a measured lag counts towards the rolling lag; a degraded Service is evaluated again
+// once the window has passed, as it recovers when the lags measured earlier no longer count.
+func (t *Tracker) Window() time.Duration {
+	return t.config.Window
+}
+
+// Observe records that an endpoint change of the Service, made at changedAt, has become visible across the fleet.
+func (t *Tracker) Observe(key Key, changedAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	lag := now.Sub(changedAt)
+	// Clock skew between the member clusters and the hub cluster may result in negative lags.
+	if lag < 0 {
+		lag = 0
+	}
+	s, ok := t.series[key]
+	if !ok {
+		s = &series{}
+		t.series[key] = s
+	}
+	s.samples = append(s.samples, sample{observedAt: now, lag: lag})
+}
+
+// State evaluates the freshness state of the Service; it returns false if no lag has ever been measured for the
+// Service.
+func (t *Tracker) State(key Key) (State, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.series[key]
+	if !ok {
+		return State{}, false
+	}
+	now := t.clock.Now()
+	var lag time.Duration
+	kept := s.samples[:0]
+	for _, v := range s.samples {
+		if now.Sub(v.observedAt) >= t.config.Window {
+			continue
+		}
+		kept = append(kept, v)
+		if v.lag > lag {
+			lag = v.lag
+		}
+	}
+	s.samples = kept
+
+	if s.degraded {
+		s.degraded = lag > t.config.RecoveryThreshold
+	} else {
+		s.degraded = lag > t.config.Threshold
+	}
+	return State{Degraded: s.degraded, Lag: lag}, true
+}
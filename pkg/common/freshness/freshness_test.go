@@ -0,0 +1,140 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package freshness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+var (
+	testKey = Key{
+		ClusterID: "member-1",
+		Service:   types.NamespacedName{Namespace: "work", Name: "app"},
+	}
+	testConfig = Config{
+		Threshold:         30 * time.Second,
+		RecoveryThreshold: 20 * time.Second,
+		Window:            5 * time.Minute,
+	}
+)
+
+func TestTracker(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	tracker := NewWithClock(testConfig, fakeClock)
+	// observe records a change which has taken the given delay to become visible.
+	observe := func(delay time.Duration) {
+		tracker.Observe(testKey, fakeClock.Now().Add(-delay))
+	}
+	wantState := func(step string, want State) {
+		t.Helper()
+		got, ok := tracker.State(testKey)
+		if !ok {
+			t.Fatalf("%s: State() is not tracked, want tracked", step)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("%s: State() mismatch (-want, +got):\n%s", step, diff)
+		}
+	}
+
+	if _, ok := tracker.State(testKey); ok {
+		t.Fatalf("State() is tracked before any observation, want not tracked")
+	}
+
+	observe(10 * time.Second)
+	wantState("lag within the threshold", State{Lag: 10 * time.Second})
+
+	observe(30 * time.Second)
+	wantState("lag at the threshold", State{Lag: 30 * time.Second})
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	observe(45 * time.Second)
+	wantState("lag above the threshold", State{Degraded: true, Lag: 45 * time.Second})
+
+	// The lag above the threshold counts until the window has passed.
+	fakeClock.SetTime(fakeClock.Now().Add(4 * time.Minute))
+	observe(5 * time.Second)
+	wantState("lag recovered within the window", State{Degraded: true, Lag: 45 * time.Second})
+
+	// The lag measured a minute ago is between the recovery threshold and the threshold; the Service stays
+	// degraded.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	observe(25 * time.Second)
+	wantState("lag above the recovery threshold", State{Degraded: true, Lag: 25 * time.Second})
+
+	fakeClock.SetTime(fakeClock.Now().Add(5 * time.Minute))
+	wantState("no lag measured within the window", State{})
+
+	// A lag between the recovery threshold and the threshold does not degrade a fresh Service.
+	observe(25 * time.Second)
+	wantState("lag between the thresholds", State{Lag: 25 * time.Second})
+
+	// Other Services are not affected.
+	otherKey := Key{ClusterID: "member-2", Service: testKey.Service}
+	if _, ok := tracker.State(otherKey); ok {
+		t.Errorf("State(%v) is tracked, want not tracked", otherKey)
+	}
+}
+
+func TestNewWithClock(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   Config
+	}{
+		{
+			name:   "valid config",
+			config: testConfig,
+			want:   testConfig,
+		},
+		{
+			name: "no recovery threshold",
+			config: Config{
+				Threshold: 30 * time.Second,
+				Window:    time.Minute,
+			},
+			want: Config{
+				Threshold:         30 * time.Second,
+				RecoveryThreshold: 30 * time.Second,
+				Window:            time.Minute,
+			},
+		},
+		{
+			name: "recovery threshold above the threshold",
+			config: Config{
+				Threshold:         30 * time.Second,
+				RecoveryThreshold: time.Minute,
+			},
+			want: Config{
+				Threshold:         30 * time.Second,
+				RecoveryThreshold: 30 * time.Second,
+				Window:            DefaultWindow,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewWithClock(tc.config, clocktesting.NewFakePassiveClock(time.Now()))
+			if diff := cmp.Diff(tc.want, tracker.config); diff != "" {
+				t.Errorf("NewWithClock() config mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestObserve_ClockSkew(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	tracker := NewWithClock(testConfig, fakeClock)
+	tracker.Observe(testKey, fakeClock.Now().Add(time.Minute))
+	got, _ := tracker.State(testKey)
+	if diff := cmp.Diff(State{}, got); diff != "" {
+		t.Errorf("State() mismatch (-want, +got):\n%s", diff)
+	}
+}
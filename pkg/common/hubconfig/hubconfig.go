@@ -35,8 +35,28 @@ const (
 	// Naming pattern of member cluster namespace in hub cluster, should be the same as envValue as defined in
 	// https://github.com/Azure/fleet/blob/main/pkg/utils/common.go
 	HubNamespaceNameFormat = "fleet-member-%s"
+
+	// DefaultHubAPIQPS is the default maximum number of queries per second sent to the hub API server.
+	DefaultHubAPIQPS = 20
+	// DefaultHubAPIBurst is the default maximum burst of queries sent to the hub API server.
+	DefaultHubAPIBurst = 30
 )
 
+// ClientConfig configures the clients with which the controllers of a member cluster request the hub cluster.
+type ClientConfig struct {
+	// HubAPIQPS is the maximum number of queries per second sent to the hub API server.
+	HubAPIQPS float32
+	// HubAPIBurst is the maximum burst of queries sent to the hub API server.
+	HubAPIBurst int
+}
+
+// ApplyTo throttles the requests made with the hub config, so that a member cluster exporting many services at
+// once does not overwhelm the hub API server.
+func (c ClientConfig) ApplyTo(hubConfig *rest.Config) {
+	hubConfig.QPS = c.HubAPIQPS
+	hubConfig.Burst = c.HubAPIBurst
+}
+
 // PrepareHubConfig return the config holding attributes for a Kubernetes client to request hub cluster.
 // Called must make sure all required environment variables are well set.
 func PrepareHubConfig(tlsClientInsecure bool) (*rest.Config, error) {
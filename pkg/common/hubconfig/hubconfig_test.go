@@ -148,6 +148,23 @@ func TestPrepareHubConfig(t *testing.T) {
 	}
 }
 
+func TestClientConfigApplyTo(t *testing.T) {
+	hubConfig := &rest.Config{
+		BearerTokenFile: "testdata/fake-config-path",
+		Host:            "fake-hub-server-url",
+	}
+	ClientConfig{HubAPIQPS: DefaultHubAPIQPS, HubAPIBurst: DefaultHubAPIBurst}.ApplyTo(hubConfig)
+	wantConfig := &rest.Config{
+		BearerTokenFile: "testdata/fake-config-path",
+		Host:            "fake-hub-server-url",
+		QPS:             20,
+		Burst:           30,
+	}
+	if diff := cmp.Diff(wantConfig, hubConfig); diff != "" {
+		t.Errorf("ApplyTo() hub config mismatch (-want, +got):\n%s", diff)
+	}
+}
+
 func TestFetchMemberClusterNamespace(t *testing.T) {
 	memberCluster := "cluster-a"
 	testCases := []struct {
@@ -0,0 +1,57 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	eventuallyTimeout  = time.Second * 10
+	eventuallyInterval = time.Millisecond * 250
+)
+
+var _ = Describe("hub schema checker", func() {
+	It("should disable the feature with an older hub CRD and enable it again once the hub CRD is upgraded", func() {
+		checker := &Checker{
+			HubClient: hubClient,
+			Features:  []Feature{FeatureTrafficManager},
+			Interval:  eventuallyInterval,
+		}
+		checkerCtx, stop := context.WithCancel(ctx)
+		defer stop()
+		go func() {
+			defer GinkgoRecover()
+			Expect(checker.Start(checkerCtx)).Should(Succeed())
+		}()
+
+		By("checking the older hub CRD")
+		Eventually(func() []string {
+			return checker.MissingFields(FeatureTrafficManager)
+		}, eventuallyTimeout, eventuallyInterval).Should(HaveLen(len(DefaultRequirements[FeatureTrafficManager])))
+
+		By("upgrading the hub CRD")
+		Eventually(func() error {
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			if err := hubClient.Get(ctx, types.NamespacedName{Name: internalServiceExportCRDName}, crd); err != nil {
+				return err
+			}
+			crd.Spec = *currentCRD.Spec.DeepCopy()
+			return hubClient.Update(ctx, crd)
+		}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+		By("checking the upgraded hub CRD")
+		Eventually(func() bool {
+			return checker.IsAvailable(FeatureTrafficManager)
+		}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+	})
+})
@@ -0,0 +1,187 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// rejectedFieldRegexp matches the fields named by the strict field validation of the API server, e.g.
+// `strict decoding error: unknown field "spec.foo", duplicate field "bar"`.
+var rejectedFieldRegexp = regexp.MustCompile(`(?:unknown|duplicate) field "([^"]+)"`)
+
+// WithFieldValidation wraps a client of the hub cluster, so that its writes are negotiated against the schemas of the
+// hub CRDs the checker has read.
+//
+// An object whose hub CRD schema is known is stripped of the fields the schema does not declare, e.g. the fields
+// deprecated and removed by newer hub clusters or those older hub clusters do not support yet, and is written with
+// strict field validation, so that any field the hub cluster still does not accept fails the write and is named by
+// the error (see RejectedFields). Objects whose hub CRD schema is not known, e.g. before the first check succeeds, are
+// written with the fields unknown to the hub cluster ignored, as the hub clusters which do not validate fields do.
+func (c *Checker) WithFieldValidation(hubClient client.Client) client.Client {
+	return &fieldValidatingClient{Client: hubClient, checker: c}
+}
+
+// fieldValidatingClient is a client of the hub cluster whose writes are negotiated against the hub CRD schemas; the
+// writes to subresources are left as they are.
+type fieldValidatingClient struct {
+	client.Client
+	checker *Checker
+}
+
+func (c *fieldValidatingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	validation, _, err := c.negotiate(obj)
+	if err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, append([]client.CreateOption{validation}, opts...)...)
+}
+
+func (c *fieldValidatingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	validation, _, err := c.negotiate(obj)
+	if err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, append([]client.UpdateOption{validation}, opts...)...)
+}
+
+func (c *fieldValidatingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	validation, stripped, err := c.negotiate(obj)
+	if err != nil {
+		return err
+	}
+	if stripped {
+		// Raw patches are computed before the object is stripped and may still carry the unsupported fields; let the
+		// hub cluster drop them.
+		validation = metav1.FieldValidationIgnore
+	}
+	return c.Client.Patch(ctx, obj, patch, append([]client.PatchOption{validation}, opts...)...)
+}
+
+// negotiate strips an object of the fields the schema of its hub CRD does not declare, and returns the field
+// validation to write it with and whether any field has been stripped.
+func (c *fieldValidatingClient) negotiate(obj client.Object) (client.FieldValidation, bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return "", false, err
+	}
+	schema := c.checker.schemaFor(gvk)
+	if schema == nil {
+		return metav1.FieldValidationIgnore, false, nil
+	}
+	stripped, err := stripUnknownFields(obj, schema)
+	if err != nil {
+		return "", false, err
+	}
+	if len(stripped) > 0 {
+		klog.V(2).InfoS("Stripped the fields the hub cluster does not support from the object before writing it",
+			"kind", gvk.Kind, "object", klog.KObj(obj), "fields", stripped)
+	}
+	return metav1.FieldValidationStrict, len(stripped) > 0, nil
+}
+
+// stripUnknownFields removes the fields of an object which are not declared in the schema, and returns their paths
+// in the format of FieldRequirement, i.e. with arrays descended into implicitly.
+func stripUnknownFields(obj client.Object, schema *apiextensionsv1.JSONSchemaProps) ([]string, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	stripped := map[string]bool{}
+	for name, val := range u {
+		switch name {
+		case "apiVersion", "kind", "metadata":
+			// The API server handles the type and object metadata of all objects alike.
+			continue
+		}
+		prop, ok := schema.Properties[name]
+		if !ok {
+			delete(u, name)
+			stripped[name] = true
+			continue
+		}
+		stripValue(val, &prop, name, stripped)
+	}
+	if len(stripped) == 0 {
+		return nil, nil
+	}
+
+	// Decode the stripped object into a zero value, so that the fields stripped are not kept from the original one.
+	v := reflect.ValueOf(obj).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(stripped))
+	for path := range stripped {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// stripValue removes the fields of a value at the path which are not declared in its schema.
+func stripValue(val interface{}, schema *apiextensionsv1.JSONSchemaProps, path string, stripped map[string]bool) {
+	if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+		return
+	}
+	switch v := val.(type) {
+	case []interface{}:
+		if schema.Items == nil || schema.Items.Schema == nil {
+			return
+		}
+		for _, item := range v {
+			stripValue(item, schema.Items.Schema, path, stripped)
+		}
+	case map[string]interface{}:
+		for name, fieldVal := range v {
+			fieldPath := path + "." + name
+			if prop, ok := schema.Properties[name]; ok {
+				stripValue(fieldVal, &prop, fieldPath, stripped)
+				continue
+			}
+			if additional := schema.AdditionalProperties; additional != nil && (additional.Allows || additional.Schema != nil) {
+				if additional.Schema != nil {
+					stripValue(fieldVal, additional.Schema, fieldPath, stripped)
+				}
+				continue
+			}
+			delete(v, name)
+			stripped[fieldPath] = true
+		}
+	}
+}
+
+// RejectedFields returns the fields named by an error the hub cluster returns when its strict field validation
+// rejects a write, or nil if the error is not such a rejection.
+func RejectedFields(err error) []string {
+	if !apierrors.IsBadRequest(err) {
+		return nil
+	}
+	var fields []string
+	seen := map[string]bool{}
+	for _, match := range rejectedFieldRegexp.FindAllStringSubmatch(err.Error(), -1) {
+		// The field paths may be prefixed with the path of the object, e.g. ".spec.foo".
+		field := strings.TrimPrefix(match[1], ".")
+		if !seen[field] {
+			seen[field] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
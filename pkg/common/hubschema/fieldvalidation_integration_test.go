@@ -0,0 +1,144 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const hubNamespace = "default"
+
+// applyCRD makes the hub cluster serve a CRD.
+func applyCRD(crd *apiextensionsv1.CustomResourceDefinition) {
+	Eventually(func() error {
+		current := &apiextensionsv1.CustomResourceDefinition{}
+		err := hubClient.Get(ctx, types.NamespacedName{Name: crd.Name}, current)
+		switch {
+		case apierrors.IsNotFound(err):
+			return hubClient.Create(ctx, crd.DeepCopy())
+		case err != nil:
+			return err
+		}
+		current.Spec = *crd.Spec.DeepCopy()
+		return hubClient.Update(ctx, current)
+	}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+}
+
+// agentInternalServiceExport returns an InternalServiceExport as built by the member agent with the Traffic Manager
+// feature enabled.
+func agentInternalServiceExport(name string) *fleetnetv1alpha1.InternalServiceExport {
+	svcMeta := metav1.ObjectMeta{
+		Namespace:       "work",
+		Name:            name,
+		UID:             "00000000-0000-0000-0000-000000000000",
+		ResourceVersion: "1",
+		Generation:      1,
+	}
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: hubNamespace, Name: "work-" + name},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports:                []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+			ServiceReference:     fleetnetv1alpha1.FromMetaObjects("member-1", metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}, svcMeta, metav1.Now()),
+			Type:                 corev1.ServiceTypeLoadBalancer,
+			IsDNSLabelConfigured: true,
+			PublicIPResourceID:   ptr.To("/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/ip"),
+			Weight:               ptr.To[int64](100),
+		},
+	}
+}
+
+// agentEndpointSliceExport returns an EndpointSliceExport as built by the member agent for an EndpointSlice with
+// topology hints.
+func agentEndpointSliceExport(name string) *fleetnetv1alpha1.EndpointSliceExport {
+	endpointSliceMeta := metav1.ObjectMeta{
+		Namespace:       "work",
+		Name:            name,
+		UID:             "00000000-0000-0000-0000-000000000001",
+		ResourceVersion: "1",
+		Generation:      1,
+	}
+	return &fleetnetv1alpha1.EndpointSliceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: hubNamespace, Name: "work-" + name},
+		Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{"1.2.3.4"},
+					Zone:      ptr.To("zone-1"),
+					Hints:     &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-1"}}},
+				},
+			},
+			EndpointSliceReference: fleetnetv1alpha1.FromMetaObjects("member-1",
+				metav1.TypeMeta{APIVersion: "discovery.k8s.io/v1", Kind: "EndpointSlice"}, endpointSliceMeta, metav1.Now()),
+			OwnerServiceReference: fleetnetv1alpha1.OwnerServiceReference{
+				Namespace:      "work",
+				Name:           "app",
+				NamespacedName: "work/app",
+			},
+		},
+	}
+}
+
+var _ = Describe("hub field validation", func() {
+	DescribeTable("should write the exports the member agent builds to the hub cluster of each version",
+		func(profile string, internalSvcExportCRD, endpointSliceExportCRD func() *apiextensionsv1.CustomResourceDefinition, rejectedField string) {
+			// The other specs expect the older InternalServiceExport CRD.
+			DeferCleanup(applyCRD, olderCRD)
+
+			By("serving the CRDs of the hub cluster")
+			applyCRD(internalSvcExportCRD())
+			applyCRD(endpointSliceExportCRD())
+
+			By("rejecting the exports with the fields the hub cluster does not accept")
+			exports := []client.Object{agentInternalServiceExport(profile), agentEndpointSliceExport(profile)}
+			Eventually(func() []string {
+				var rejectedFields []string
+				for _, export := range exports {
+					obj := export.DeepCopyObject().(client.Object)
+					err := hubClient.Create(ctx, obj, client.FieldValidation(metav1.FieldValidationStrict))
+					if err == nil {
+						// The hub cluster does not serve the CRDs of the profile yet.
+						Expect(hubClient.Delete(ctx, obj)).Should(Succeed())
+					}
+					rejectedFields = append(rejectedFields, RejectedFields(err)...)
+				}
+				return rejectedFields
+			}, eventuallyTimeout, eventuallyInterval).Should(ContainElement(rejectedField))
+
+			By("learning the schemas of the hub CRDs")
+			checker := &Checker{HubClient: hubClient}
+			Expect(checker.Check(ctx)).Should(Succeed())
+			fieldValidatingClient := checker.WithFieldValidation(hubClient)
+
+			By("writing the exports with strict field validation")
+			for _, export := range exports {
+				obj := export.DeepCopyObject().(client.Object)
+				Expect(fieldValidatingClient.Create(ctx, obj)).Should(Succeed(), "the %s export", profile)
+				Expect(fieldValidatingClient.Update(ctx, obj)).Should(Succeed(), "the %s export", profile)
+				Expect(hubClient.Delete(ctx, obj)).Should(Succeed())
+			}
+		},
+		Entry("older hub", "older-hub",
+			func() *apiextensionsv1.CustomResourceDefinition { return olderCRD },
+			func() *apiextensionsv1.CustomResourceDefinition { return currentSliceExportCRD },
+			"spec.weight"),
+		Entry("newer hub", "newer-hub",
+			func() *apiextensionsv1.CustomResourceDefinition { return currentCRD },
+			func() *apiextensionsv1.CustomResourceDefinition { return newerSliceExportCRD },
+			"spec.endpoints[0].hints"),
+	)
+})
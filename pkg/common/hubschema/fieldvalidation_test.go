@@ -0,0 +1,253 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// TestWithFieldValidation tests that the writes are stripped of the fields the hub CRDs do not declare and validated
+// strictly once the schemas of the hub CRDs are known.
+func TestWithFieldValidation(t *testing.T) {
+	ctx := context.Background()
+	var gotValidations []string
+	funcs := &interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			createOpts := &client.CreateOptions{}
+			createOpts.ApplyOptions(opts)
+			gotValidations = append(gotValidations, createOpts.FieldValidation)
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updateOpts := &client.UpdateOptions{}
+			updateOpts.ApplyOptions(opts)
+			gotValidations = append(gotValidations, updateOpts.FieldValidation)
+			return c.Update(ctx, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			patchOpts := &client.PatchOptions{}
+			patchOpts.ApplyOptions(opts)
+			gotValidations = append(gotValidations, patchOpts.FieldValidation)
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}
+	hubClient := newFakeHubClient(t, funcs, olderInternalServiceExportCRD(t), newerEndpointSliceExportCRD(t))
+	checker := &Checker{HubClient: hubClient}
+	c := checker.WithFieldValidation(hubClient)
+	checkValidations := func(want ...string) {
+		t.Helper()
+		if diff := cmp.Diff(want, gotValidations); diff != "" {
+			t.Fatalf("field validations mismatch (-want, +got):\n%s", diff)
+		}
+		gotValidations = nil
+	}
+
+	// The fields unknown to the hub cluster are left to it before the hub CRDs are checked.
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "member-1", Name: "work-app"},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports:  []fleetnetv1alpha1.ServicePort{{Name: "http", Port: 80}},
+			Weight: ptr.To[int64](100),
+		},
+	}
+	if err := c.Create(ctx, internalSvcExport); err != nil {
+		t.Fatalf("Create() = %v, want no error", err)
+	}
+	checkValidations(metav1.FieldValidationIgnore)
+	if internalSvcExport.Spec.Weight == nil {
+		t.Fatalf("Create() stripped the weight before the hub CRDs are checked, want it kept")
+	}
+
+	if err := checker.Check(ctx); err != nil {
+		t.Fatalf("Check() = %v, want no error", err)
+	}
+
+	// The older hub cluster does not support the weight yet.
+	if err := c.Update(ctx, internalSvcExport); err != nil {
+		t.Fatalf("Update() = %v, want no error", err)
+	}
+	checkValidations(metav1.FieldValidationStrict)
+	got := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := hubClient.Get(ctx, client.ObjectKeyFromObject(internalSvcExport), got); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	if got.Spec.Weight != nil || len(got.Spec.Ports) != 1 {
+		t.Fatalf("Update() wrote spec %+v, want the ports without the weight", got.Spec)
+	}
+
+	// The newer hub cluster no longer accepts the hints of the endpoints.
+	endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "member-1", Name: "work-app-endpointslice"},
+		Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{"1.2.3.4"},
+					Zone:      ptr.To("zone-1"),
+					Hints:     &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-1"}}},
+				},
+			},
+		},
+	}
+	if err := c.Create(ctx, endpointSliceExport); err != nil {
+		t.Fatalf("Create() = %v, want no error", err)
+	}
+	checkValidations(metav1.FieldValidationStrict)
+	if endpoint := endpointSliceExport.Spec.Endpoints[0]; endpoint.Hints != nil || ptr.Deref(endpoint.Zone, "") != "zone-1" {
+		t.Fatalf("Create() wrote endpoint %+v, want the zone without the hints", endpoint)
+	}
+
+	// Patches which may carry the stripped fields are left to the hub cluster to validate.
+	original := endpointSliceExport.DeepCopy()
+	endpointSliceExport.Spec.Endpoints[0].Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-2"}}}
+	if err := c.Patch(ctx, endpointSliceExport, client.MergeFrom(original)); err != nil {
+		t.Fatalf("Patch() = %v, want no error", err)
+	}
+	checkValidations(metav1.FieldValidationIgnore)
+	original = endpointSliceExport.DeepCopy()
+	endpointSliceExport.Spec.Endpoints[0].Zone = ptr.To("zone-2")
+	if err := c.Patch(ctx, endpointSliceExport, client.MergeFrom(original)); err != nil {
+		t.Fatalf("Patch() = %v, want no error", err)
+	}
+	checkValidations(metav1.FieldValidationStrict)
+}
+
+func TestStripValue(t *testing.T) {
+	stringSchema := apiextensionsv1.JSONSchemaProps{Type: "string"}
+	endpointSchema := apiextensionsv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{"addresses": {Type: "array"}},
+	}
+	tests := []struct {
+		name         string
+		schema       apiextensionsv1.JSONSchemaProps
+		val          map[string]interface{}
+		wantVal      map[string]interface{}
+		wantStripped []string
+	}{
+		{
+			name: "unknown fields of array items",
+			schema: apiextensionsv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"endpoints": {Type: "array", Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &endpointSchema}},
+				},
+			},
+			val: map[string]interface{}{
+				"endpoints": []interface{}{
+					map[string]interface{}{"addresses": []interface{}{"1.2.3.4"}, "hints": map[string]interface{}{}},
+					map[string]interface{}{"addresses": []interface{}{"2.3.4.5"}, "hints": map[string]interface{}{}, "zone": "zone-1"},
+				},
+				"weight": int64(100),
+			},
+			wantVal: map[string]interface{}{
+				"endpoints": []interface{}{
+					map[string]interface{}{"addresses": []interface{}{"1.2.3.4"}},
+					map[string]interface{}{"addresses": []interface{}{"2.3.4.5"}},
+				},
+			},
+			wantStripped: []string{"spec.endpoints.hints", "spec.endpoints.zone", "spec.weight"},
+		},
+		{
+			name: "maps",
+			schema: apiextensionsv1.JSONSchemaProps{
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"selector": {Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &stringSchema}},
+					"byZone":   {Type: "object", AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &endpointSchema}},
+				},
+			},
+			val: map[string]interface{}{
+				"selector": map[string]interface{}{"app": "work"},
+				"byZone":   map[string]interface{}{"zone-1": map[string]interface{}{"addresses": []interface{}{}, "hints": "x"}},
+			},
+			wantVal: map[string]interface{}{
+				"selector": map[string]interface{}{"app": "work"},
+				"byZone":   map[string]interface{}{"zone-1": map[string]interface{}{"addresses": []interface{}{}}},
+			},
+			wantStripped: []string{"spec.byZone.zone-1.hints"},
+		},
+		{
+			name:   "unknown fields preserved",
+			schema: apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: ptr.To(true)},
+			val:    map[string]interface{}{"weight": int64(100)},
+			wantVal: map[string]interface{}{
+				"weight": int64(100),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stripped := map[string]bool{}
+			stripValue(tt.val, &tt.schema, "spec", stripped)
+			if diff := cmp.Diff(tt.wantVal, tt.val); diff != "" {
+				t.Errorf("stripValue() value mismatch (-want, +got):\n%s", diff)
+			}
+			var gotStripped []string
+			for path := range stripped {
+				gotStripped = append(gotStripped, path)
+			}
+			sort.Strings(gotStripped)
+			if diff := cmp.Diff(tt.wantStripped, gotStripped); diff != "" {
+				t.Errorf("stripValue() stripped fields mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectedFields(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{
+			name: "unknown and duplicate fields",
+			err: apierrors.NewBadRequest(`EndpointSliceExport in version "v1alpha1" cannot be handled as a EndpointSliceExport: ` +
+				`strict decoding error: unknown field "spec.endpoints[0].hints", duplicate field "spec.addressType", unknown field "spec.endpoints[0].hints"`),
+			want: []string{"spec.endpoints[0].hints", "spec.addressType"},
+		},
+		{
+			name: "field paths with a leading dot",
+			err:  apierrors.NewBadRequest(`strict decoding error: unknown field ".spec.weight"`),
+			want: []string{"spec.weight"},
+		},
+		{
+			name: "other bad request",
+			err:  apierrors.NewBadRequest("the server rejected our request for an unknown reason"),
+		},
+		{
+			name: "invalid object",
+			err: apierrors.NewInvalid(schema.GroupKind{Group: fleetnetv1alpha1.GroupVersion.Group, Kind: "EndpointSliceExport"}, "work-app",
+				field.ErrorList{field.Invalid(field.NewPath("spec", "endpoints"), nil, `unknown field "spec.endpoints"`)}),
+		},
+		{
+			name: "no error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, RejectedFields(tt.err)); diff != "" {
+				t.Errorf("RejectedFields() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
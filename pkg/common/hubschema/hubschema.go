@@ -0,0 +1,361 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package hubschema features a checker which detects whether the CRDs served by the hub cluster have the optional
+// fields the features of the member agent depend on.
+//
+// After a partial upgrade, the hub cluster may serve CRDs older than the member agent expects; the API server
+// prunes the unknown fields silently, so that the features depending on them appear enabled but do nothing. The
+// checker allows the member agent to disable such features at runtime, and to enable them again once the hub CRDs
+// are upgraded.
+//
+// The checker also learns the schemas of the hub CRDs of the objects the member agent exports, so that the exports
+// can be stripped of the fields the hub cluster does not support and validated strictly by the hub cluster.
+package hubschema
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+// Feature is a feature of the member agent which depends on optional fields of the hub CRDs.
+type Feature string
+
+const (
+	// FeatureTrafficManager exports the Azure related information of Services for Azure Traffic Manager.
+	FeatureTrafficManager Feature = "TrafficManager"
+)
+
+const (
+	// HubSchemaCheckedCondition is the type of the agent condition which reports whether the member agent can read
+	// the hub CRDs; while it cannot, the features depending on them are considered available and the exports are
+	// not validated strictly by the hub cluster.
+	HubSchemaCheckedCondition = "HubSchemaChecked"
+
+	conditionReasonChecked      = "HubCRDsChecked"
+	conditionReasonNotChecked   = "HubCRDsNotCheckedYet"
+	conditionReasonForbidden    = "HubCRDAccessForbidden"
+	conditionReasonCheckFailed  = "HubCRDCheckFailed"
+	forbiddenRemediationMessage = "grant the member agent get access to the fleet networking customresourcedefinitions " +
+		"in the hub cluster, e.g. with the memberAgentHubSchemaReaders value of the hub-net-controller-manager chart"
+)
+
+const (
+	internalServiceExportCRDName = "internalserviceexports.networking.fleet.azure.com"
+	endpointSliceExportCRDName   = "endpointsliceexports.networking.fleet.azure.com"
+
+	// DefaultCheckInterval is the default interval between two checks of the hub CRDs.
+	DefaultCheckInterval = 5 * time.Minute
+)
+
+// FieldRequirement is a field which must exist in the schema of a version of a hub CRD.
+type FieldRequirement struct {
+	// CRDName is the name of the CRD.
+	CRDName string
+	// Version is the version of the CRD which must be served with the field.
+	Version string
+	// Path is the path of the field in the schema; arrays are descended into implicitly.
+	Path []string
+}
+
+// String returns the field requirement in the format CRD_NAME/VERSION:PATH.
+func (r FieldRequirement) String() string {
+	return fmt.Sprintf("%s/%s:%s", r.CRDName, r.Version, strings.Join(r.Path, "."))
+}
+
+// DefaultRequirements are the fields of the hub CRDs each feature depends on.
+var DefaultRequirements = map[Feature][]FieldRequirement{
+	FeatureTrafficManager: {
+		{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "type"}},
+		{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "isDNSLabelConfigured"}},
+		{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "isInternalLoadBalancer"}},
+		{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "publicIPResourceID"}},
+		{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "weight"}},
+	},
+}
+
+// DefaultExportedCRDNames are the hub CRDs of the objects the member agent exports to the hub cluster.
+var DefaultExportedCRDNames = []string{internalServiceExportCRDName, endpointSliceExportCRDName}
+
+var (
+	// hubFeatureAvailable reports whether the hub CRDs support each feature checked.
+	hubFeatureAvailable = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "hub_feature_available",
+			Help:      "Whether the CRDs served by the hub cluster support a feature of the member agent (1) or not (0)",
+		},
+		[]string{
+			// The feature of the member agent.
+			"feature",
+		},
+	)
+)
+
+func init() {
+	// Register hubFeatureAvailable (fleet_networking_hub_feature_available) metric with the controller runtime
+	// global metrics registry.
+	ctrlmetrics.Registry.MustRegister(hubFeatureAvailable)
+}
+
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+
+// Checker periodically checks whether the hub CRDs have the fields the features of the member agent depend on.
+//
+// A nil Checker reports all features as available. Features are considered available until a check proves
+// otherwise, and checks which fail to read the hub CRDs keep the previous results.
+type Checker struct {
+	// HubClient reads the CRDs of the hub cluster.
+	HubClient client.Reader
+	// Features are the enabled features of the member agent to check.
+	Features []Feature
+	// Requirements are the fields each feature depends on; DefaultRequirements are used if it is not set.
+	Requirements map[Feature][]FieldRequirement
+	// Interval is the interval between two checks; DefaultCheckInterval is used if it is not set.
+	Interval time.Duration
+	// ExportedCRDNames are the hub CRDs whose schemas the writes of the client returned by WithFieldValidation are
+	// negotiated against; DefaultExportedCRDNames are used if it is not set.
+	ExportedCRDNames []string
+
+	mu sync.RWMutex
+	// missingFields are the fields missing from the hub CRDs, keyed by the feature depending on them.
+	missingFields map[Feature][]string
+	// schemas are the schemas of the served versions of the exported hub CRDs; the CRDs which are not found are
+	// absent.
+	schemas map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps
+	// checked is whether a check has run; lastErr is the error of the last check, if it has failed.
+	checked bool
+	lastErr error
+}
+
+// IsAvailable returns whether the hub CRDs support a feature.
+func (c *Checker) IsAvailable(feature Feature) bool {
+	return len(c.MissingFields(feature)) == 0
+}
+
+// MissingFields returns the fields a feature depends on which are missing from the hub CRDs.
+func (c *Checker) MissingFields(feature Feature) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.missingFields[feature]
+}
+
+// CheckInterval returns the interval between two checks.
+func (c *Checker) CheckInterval() time.Duration {
+	if c == nil || c.Interval <= 0 {
+		return DefaultCheckInterval
+	}
+	return c.Interval
+}
+
+// AgentCondition returns the HubSchemaChecked condition the member agent reports in its status, so that a check
+// which keeps failing, e.g. as the member agent is not allowed to read the hub CRDs, is visible in the fleet.
+func (c *Checker) AgentCondition() metav1.Condition {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch {
+	case !c.checked:
+		return metav1.Condition{
+			Type:    HubSchemaCheckedCondition,
+			Status:  metav1.ConditionUnknown,
+			Reason:  conditionReasonNotChecked,
+			Message: "the hub CRDs have not been checked yet",
+		}
+	case apierrors.IsForbidden(c.lastErr):
+		return metav1.Condition{
+			Type:    HubSchemaCheckedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonForbidden,
+			Message: fmt.Sprintf("the member agent is not allowed to read the hub CRDs (%v); %s", c.lastErr, forbiddenRemediationMessage),
+		}
+	case c.lastErr != nil:
+		return metav1.Condition{
+			Type:    HubSchemaCheckedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonCheckFailed,
+			Message: fmt.Sprintf("failed to read the hub CRDs; the results of the last successful check, if any, are kept: %v", c.lastErr),
+		}
+	}
+	return metav1.Condition{
+		Type:    HubSchemaCheckedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionReasonChecked,
+		Message: "the hub CRDs have been checked",
+	}
+}
+
+// Check reads the hub CRDs and updates whether each feature is available.
+func (c *Checker) Check(ctx context.Context) error {
+	err := c.check(ctx)
+	if apierrors.IsForbidden(err) {
+		klog.ErrorS(err, "The member agent is not allowed to read the hub CRDs; the features are considered available and the exports are not validated strictly until it is",
+			"remediation", forbiddenRemediationMessage)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked = true
+	c.lastErr = err
+	return err
+}
+
+// check reads the hub CRDs and updates whether each feature is available.
+func (c *Checker) check(ctx context.Context) error {
+	requirements := c.Requirements
+	if requirements == nil {
+		requirements = DefaultRequirements
+	}
+
+	crds := map[string]*apiextensionsv1.CustomResourceDefinition{}
+	getCRD := func(name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+		if crd, ok := crds[name]; ok {
+			return crd, nil
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := c.HubClient.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+			if !apierrors.IsNotFound(err) {
+				klog.ErrorS(err, "Failed to get the hub CRD; keeping the previous results of the check", "crd", name)
+				return nil, err
+			}
+			crd = nil
+		}
+		crds[name] = crd
+		return crd, nil
+	}
+
+	missingFields := make(map[Feature][]string, len(c.Features))
+	for _, feature := range c.Features {
+		for _, req := range requirements[feature] {
+			crd, err := getCRD(req.CRDName)
+			if err != nil {
+				return err
+			}
+			if !hasField(crd, req) {
+				missingFields[feature] = append(missingFields[feature], req.String())
+			}
+		}
+	}
+
+	exportedCRDNames := c.ExportedCRDNames
+	if exportedCRDNames == nil {
+		exportedCRDNames = DefaultExportedCRDNames
+	}
+	schemas := map[schema.GroupVersionKind]*apiextensionsv1.JSONSchemaProps{}
+	for _, name := range exportedCRDNames {
+		crd, err := getCRD(name)
+		if err != nil {
+			return err
+		}
+		if crd == nil {
+			continue
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served || version.Schema == nil || version.Schema.OpenAPIV3Schema == nil {
+				continue
+			}
+			gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+			schemas[gvk] = version.Schema.OpenAPIV3Schema.DeepCopy()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, feature := range c.Features {
+		wasAvailable := len(c.missingFields[feature]) == 0
+		isAvailable := len(missingFields[feature]) == 0
+		switch {
+		case wasAvailable && !isAvailable:
+			klog.ErrorS(fmt.Errorf("the hub CRDs do not have the fields %v", missingFields[feature]),
+				"The hub cluster serves CRDs older than the member agent expects; the feature is disabled until the hub CRDs are upgraded",
+				"feature", feature)
+		case !wasAvailable && isAvailable:
+			klog.InfoS("The hub CRDs have been upgraded; the feature is enabled again", "feature", feature)
+		}
+		available := float64(1)
+		if !isAvailable {
+			available = 0
+		}
+		hubFeatureAvailable.WithLabelValues(string(feature)).Set(available)
+	}
+	c.missingFields = missingFields
+	c.schemas = schemas
+	return nil
+}
+
+// schemaFor returns the schema of the hub CRD of a kind of objects, or nil if it is not known.
+func (c *Checker) schemaFor(gvk schema.GroupVersionKind) *apiextensionsv1.JSONSchemaProps {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schemas[gvk]
+}
+
+// Start checks the hub CRDs periodically until the context is cancelled; it implements the manager.Runnable
+// interface.
+func (c *Checker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// Errors have been logged and the previous results are kept.
+		_ = c.Check(ctx)
+	}, c.CheckInterval())
+	return nil
+}
+
+// NeedLeaderElection returns false, as every replica of the member agent needs the results of the check.
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// hasField returns whether a served version of the CRD has the field in its schema.
+func hasField(crd *apiextensionsv1.CustomResourceDefinition, req FieldRequirement) bool {
+	if crd == nil {
+		return false
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Name != req.Version || !version.Served || version.Schema == nil {
+			continue
+		}
+		schema := version.Schema.OpenAPIV3Schema
+		for _, name := range req.Path {
+			for schema != nil && schema.Type == "array" && schema.Items != nil {
+				schema = schema.Items.Schema
+			}
+			if schema == nil {
+				return false
+			}
+			if schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields {
+				// Unknown fields are not pruned.
+				return true
+			}
+			prop, ok := schema.Properties[name]
+			if !ok {
+				return false
+			}
+			schema = &prop
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+var (
+	weightRequirement = FieldRequirement{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "weight"}}
+	typeRequirement   = FieldRequirement{CRDName: internalServiceExportCRDName, Version: "v1alpha1", Path: []string{"spec", "type"}}
+)
+
+// loadCRD loads a CRD of the current version from the CRD manifests.
+func loadCRD(t *testing.T, name string) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	f, err := os.Open(filepath.Join("..", "..", "..", "config", "crd", "bases", "networking.fleet.azure.com_"+name+".yaml"))
+	if err != nil {
+		t.Fatalf("Open() = %v, want no error", err)
+	}
+	defer f.Close()
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(crd); err != nil {
+		t.Fatalf("Decode() = %v, want no error", err)
+	}
+	return crd
+}
+
+// olderInternalServiceExportCRD returns the InternalServiceExport CRD as served by hub clusters which have not been
+// upgraded for the Traffic Manager feature.
+func olderInternalServiceExportCRD(t *testing.T) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	crd := loadCRD(t, "internalserviceexports")
+	for i := range crd.Spec.Versions {
+		spec := crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"]
+		for _, name := range []string{"type", "isDNSLabelConfigured", "isInternalLoadBalancer", "publicIPResourceID", "weight"} {
+			delete(spec.Properties, name)
+		}
+		crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"] = spec
+	}
+	return crd
+}
+
+// newerEndpointSliceExportCRD returns the EndpointSliceExport CRD as served by hub clusters which no longer accept the
+// hints of the endpoints.
+func newerEndpointSliceExportCRD(t *testing.T) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	crd := loadCRD(t, "endpointsliceexports")
+	for i := range crd.Spec.Versions {
+		spec := crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"]
+		endpoints := spec.Properties["endpoints"]
+		delete(endpoints.Items.Schema.Properties, "hints")
+		spec.Properties["endpoints"] = endpoints
+		crd.Spec.Versions[i].Schema.OpenAPIV3Schema.Properties["spec"] = spec
+	}
+	return crd
+}
+
+func newFakeHubClient(t *testing.T, funcs *interceptor.Funcs, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...)
+	if funcs != nil {
+		builder = builder.WithInterceptorFuncs(*funcs)
+	}
+	return builder.Build()
+}
+
+func TestHasField(t *testing.T) {
+	tests := []struct {
+		name string
+		crd  *apiextensionsv1.CustomResourceDefinition
+		req  FieldRequirement
+		want bool
+	}{
+		{
+			name: "field exists",
+			crd:  loadCRD(t, "internalserviceexports"),
+			req:  weightRequirement,
+			want: true,
+		},
+		{
+			name: "field in an array exists",
+			crd:  loadCRD(t, "endpointsliceexports"),
+			req:  FieldRequirement{Version: "v1alpha1", Path: []string{"spec", "endpoints", "isFQDN"}},
+			want: true,
+		},
+		{
+			name: "field is missing",
+			crd:  olderInternalServiceExportCRD(t),
+			req:  weightRequirement,
+		},
+		{
+			name: "version is not served",
+			crd:  loadCRD(t, "internalserviceexports"),
+			req:  FieldRequirement{Version: "v1beta1", Path: []string{"spec", "weight"}},
+		},
+		{
+			name: "CRD not found",
+			req:  weightRequirement,
+		},
+		{
+			name: "unknown fields are preserved",
+			crd: &apiextensionsv1.CustomResourceDefinition{
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+						{
+							Name:   "v1alpha1",
+							Served: true,
+							Schema: &apiextensionsv1.CustomResourceValidation{
+								OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+									Type: "object",
+									Properties: map[string]apiextensionsv1.JSONSchemaProps{
+										"spec": {Type: "object", XPreserveUnknownFields: ptr.To(true)},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			req:  weightRequirement,
+			want: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasField(tc.crd, tc.req); got != tc.want {
+				t.Errorf("hasField() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	hubClient := newFakeHubClient(t, nil, olderInternalServiceExportCRD(t))
+	c := &Checker{
+		HubClient: hubClient,
+		Features:  []Feature{FeatureTrafficManager},
+		Requirements: map[Feature][]FieldRequirement{
+			FeatureTrafficManager: {typeRequirement, weightRequirement},
+		},
+	}
+
+	// Features are available until checked.
+	if !c.IsAvailable(FeatureTrafficManager) {
+		t.Errorf("IsAvailable() = false before the check, want true")
+	}
+
+	// The hub serves an older CRD.
+	if err := c.Check(ctx); err != nil {
+		t.Fatalf("Check() = %v, want no error", err)
+	}
+	if c.IsAvailable(FeatureTrafficManager) {
+		t.Errorf("IsAvailable() = true with an older hub CRD, want false")
+	}
+	wantMissing := []string{typeRequirement.String(), weightRequirement.String()}
+	if diff := cmp.Diff(wantMissing, c.MissingFields(FeatureTrafficManager)); diff != "" {
+		t.Errorf("MissingFields() mismatch (-want, +got):\n%s", diff)
+	}
+	if got := testutil.ToFloat64(hubFeatureAvailable.WithLabelValues(string(FeatureTrafficManager))); got != 0 {
+		t.Errorf("hub_feature_available = %v, want 0", got)
+	}
+
+	// The hub CRD is upgraded.
+	current := loadCRD(t, "internalserviceexports")
+	older := &apiextensionsv1.CustomResourceDefinition{}
+	if err := hubClient.Get(ctx, client.ObjectKeyFromObject(current), older); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	current.ResourceVersion = older.ResourceVersion
+	if err := hubClient.Update(ctx, current); err != nil {
+		t.Fatalf("Update() = %v, want no error", err)
+	}
+	if err := c.Check(ctx); err != nil {
+		t.Fatalf("Check() = %v, want no error", err)
+	}
+	if !c.IsAvailable(FeatureTrafficManager) {
+		t.Errorf("IsAvailable() = false with an upgraded hub CRD, want true; missing fields %v", c.MissingFields(FeatureTrafficManager))
+	}
+	if got := testutil.ToFloat64(hubFeatureAvailable.WithLabelValues(string(FeatureTrafficManager))); got != 1 {
+		t.Errorf("hub_feature_available = %v, want 1", got)
+	}
+}
+
+func TestCheck_CRDNotFound(t *testing.T) {
+	c := &Checker{
+		HubClient: newFakeHubClient(t, nil),
+		Features:  []Feature{FeatureTrafficManager},
+	}
+	if err := c.Check(context.Background()); err != nil {
+		t.Fatalf("Check() = %v, want no error", err)
+	}
+	if got, want := len(c.MissingFields(FeatureTrafficManager)), len(DefaultRequirements[FeatureTrafficManager]); got != want {
+		t.Errorf("len(MissingFields()) = %d, want %d", got, want)
+	}
+}
+
+func TestCheck_KeepPreviousResultsOnError(t *testing.T) {
+	getErr := errors.New("hub unavailable")
+	failing := false
+	c := &Checker{
+		HubClient: newFakeHubClient(t, &interceptor.Funcs{
+			Get: func(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if failing {
+					return getErr
+				}
+				return client.Get(ctx, key, obj, opts...)
+			},
+		}, olderInternalServiceExportCRD(t)),
+		Features: []Feature{FeatureTrafficManager},
+	}
+	ctx := context.Background()
+	if err := c.Check(ctx); err != nil {
+		t.Fatalf("Check() = %v, want no error", err)
+	}
+	failing = true
+	if err := c.Check(ctx); !errors.Is(err, getErr) {
+		t.Fatalf("Check() = %v, want %v", err, getErr)
+	}
+	if c.IsAvailable(FeatureTrafficManager) {
+		t.Errorf("IsAvailable() = true after a failed check, want the previous result false")
+	}
+}
+
+// TestAgentCondition tests that the agent condition reports the checks which fail, and tells a missing permission
+// apart from the other failures.
+func TestAgentCondition(t *testing.T) {
+	var getErr error
+	c := &Checker{
+		HubClient: newFakeHubClient(t, &interceptor.Funcs{
+			Get: func(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if getErr != nil {
+					return getErr
+				}
+				return client.Get(ctx, key, obj, opts...)
+			},
+		}),
+		Features: []Feature{FeatureTrafficManager},
+	}
+	ctx := context.Background()
+	crdResource := schema.GroupResource{Group: apiextensionsv1.GroupName, Resource: "customresourcedefinitions"}
+	testCases := []struct {
+		name       string
+		getErr     error
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "forbidden",
+			getErr:     apierrors.NewForbidden(crdResource, internalServiceExportCRDName, errors.New("no RBAC policy matched")),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: conditionReasonForbidden,
+		},
+		{
+			name:       "hub unavailable",
+			getErr:     errors.New("hub unavailable"),
+			wantStatus: metav1.ConditionFalse,
+			wantReason: conditionReasonCheckFailed,
+		},
+		{
+			name:       "checked",
+			wantStatus: metav1.ConditionTrue,
+			wantReason: conditionReasonChecked,
+		},
+	}
+
+	if got := c.AgentCondition(); got.Status != metav1.ConditionUnknown || got.Reason != conditionReasonNotChecked {
+		t.Errorf("AgentCondition() before the first check = (%s, %s), want (%s, %s)", got.Status, got.Reason, metav1.ConditionUnknown, conditionReasonNotChecked)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			getErr = tc.getErr
+			_ = c.Check(ctx)
+			got := c.AgentCondition()
+			if got.Type != HubSchemaCheckedCondition || got.Status != tc.wantStatus || got.Reason != tc.wantReason {
+				t.Errorf("AgentCondition() = (%s, %s, %s), want (%s, %s, %s)", got.Type, got.Status, got.Reason, HubSchemaCheckedCondition, tc.wantStatus, tc.wantReason)
+			}
+		})
+	}
+}
+
+func TestNilChecker(t *testing.T) {
+	var c *Checker
+	if !c.IsAvailable(FeatureTrafficManager) {
+		t.Errorf("IsAvailable() = false, want true")
+	}
+	if got := c.CheckInterval(); got != DefaultCheckInterval {
+		t.Errorf("CheckInterval() = %v, want %v", got, DefaultCheckInterval)
+	}
+}
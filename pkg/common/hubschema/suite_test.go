@@ -0,0 +1,81 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package hubschema
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+var (
+	hubTestEnv *envtest.Environment
+	hubClient  client.Client
+	// olderCRD is the InternalServiceExport CRD as served by hub clusters which have not been upgraded.
+	olderCRD *apiextensionsv1.CustomResourceDefinition
+	// currentCRD is the InternalServiceExport CRD the member agent expects.
+	currentCRD *apiextensionsv1.CustomResourceDefinition
+	// currentSliceExportCRD is the EndpointSliceExport CRD the member agent expects.
+	currentSliceExportCRD *apiextensionsv1.CustomResourceDefinition
+	// newerSliceExportCRD is the EndpointSliceExport CRD as served by hub clusters which have removed a field
+	// the member agent still sends.
+	newerSliceExportCRD *apiextensionsv1.CustomResourceDefinition
+	ctx                 context.Context
+	cancel              context.CancelFunc
+)
+
+func TestHubSchemaAPIs(t *testing.T) {
+	olderCRD = olderInternalServiceExportCRD(t)
+	currentCRD = loadCRD(t, "internalserviceexports")
+	currentSliceExportCRD = loadCRD(t, "endpointsliceexports")
+	newerSliceExportCRD = newerEndpointSliceExportCRD(t)
+
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Hub Schema Checker Suite")
+}
+
+var _ = BeforeSuite(func() {
+	logger := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
+	klog.SetLogger(logger)
+	log.SetLogger(logger)
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	By("bootstrap the test environment with an older hub CRD")
+	hubTestEnv = &envtest.Environment{
+		CRDs: []*apiextensionsv1.CustomResourceDefinition{olderCRD.DeepCopy()},
+	}
+	hubCfg, err := hubTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(hubCfg).NotTo(BeNil())
+
+	scheme := runtime.NewScheme()
+	Expect(apiextensionsv1.AddToScheme(scheme)).Should(Succeed())
+	Expect(fleetnetv1alpha1.AddToScheme(scheme)).Should(Succeed())
+	hubClient, err = client.New(hubCfg, client.Options{Scheme: scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(hubClient).NotTo(BeNil())
+})
+
+var _ = AfterSuite(func() {
+	defer klog.Flush()
+	cancel()
+
+	By("tearing down the test environment")
+	Expect(hubTestEnv.Stop()).Should(Succeed())
+})
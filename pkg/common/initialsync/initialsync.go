@@ -0,0 +1,466 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package initialsync features the pacing of the initial export of a member cluster which joins the fleet with a
+// large number of Services to export, so that its cold start does not saturate the hub cluster and starve the
+// incremental updates of the member clusters which have joined before.
+package initialsync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+const (
+	// DefaultRate is the default number of objects exported per second while the initial export is paced.
+	DefaultRate = 50
+	// DefaultBurst is the default number of objects exported right away when the initial export starts.
+	DefaultBurst = 100
+	// DefaultThreshold is the default number of ServiceExports from which the initial export of a member cluster
+	// with no prior exports is paced.
+	DefaultThreshold = 500
+	// DefaultIdleTimeout is the default period after which the pacing ends once every ServiceExport has been
+	// exported, even if some EndpointSlices have not, e.g. as their ServiceExports turn out to be invalid.
+	DefaultIdleTimeout = time.Minute
+	// DefaultStatusInterval is the default interval between two updates of the status of the initial export.
+	DefaultStatusInterval = 10 * time.Second
+	// DetectBacklogTimeout is the period the member agent waits at startup for the backlog to be detected; the
+	// initial export is not paced if the backlog cannot be detected in time, e.g. as the hub cluster is unreachable.
+	DetectBacklogTimeout = 30 * time.Second
+
+	// StatusConfigMapName is the name of the ConfigMap which reports the status of the initial export.
+	StatusConfigMapName = "fleet-networking-initial-sync"
+
+	// minEndpointSliceWait is the shortest period an EndpointSlice waits for the ServiceExports to be exported first.
+	minEndpointSliceWait = time.Second
+)
+
+// Kind is the kind of the objects in the backlog of the initial export.
+type Kind string
+
+const (
+	// KindServiceExport is the kind of the ServiceExports in the backlog, which are exported first.
+	KindServiceExport Kind = "ServiceExport"
+	// KindEndpointSlice is the kind of the EndpointSlices in the backlog, which are exported after the
+	// ServiceExports.
+	KindEndpointSlice Kind = "EndpointSlice"
+)
+
+// The keys of the status ConfigMap.
+const (
+	statusKeyPhase    = "phase"
+	statusKeyExported = "exported"
+	statusKeyTotal    = "total"
+
+	statusPhasePacing    = "Pacing"
+	statusPhaseCompleted = "Completed"
+)
+
+var (
+	// initialSyncPacing is a Prometheus gauge metric which is 1 while the initial export of the member cluster is
+	// paced, and 0 otherwise.
+	initialSyncPacing = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "initial_sync_pacing",
+			Help:      "Whether the initial export of the member cluster is paced",
+		},
+		[]string{"origin_cluster_id"},
+	)
+
+	// initialSyncObjects is a Prometheus gauge metric which tracks the number of objects in the backlog of the
+	// initial export, by kind and by whether they have been exported.
+	initialSyncObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "initial_sync_objects",
+			Help:      "The number of objects in the backlog of the initial export of the member cluster, by kind and state",
+		},
+		[]string{
+			"origin_cluster_id",
+			// The kind of the objects: ServiceExport or EndpointSlice.
+			"kind",
+			// The state of the objects: exported or total.
+			"state",
+		},
+	)
+)
+
+func init() {
+	// Register initialSyncPacing (fleet_networking_initial_sync_pacing) and initialSyncObjects
+	// (fleet_networking_initial_sync_objects) metrics with the controller runtime global metrics registry.
+	ctrlmetrics.Registry.MustRegister(initialSyncPacing, initialSyncObjects)
+}
+
+// Backlog is the set of objects a member cluster exports when it joins the fleet.
+type Backlog struct {
+	ServiceExports []types.NamespacedName
+	EndpointSlices []types.NamespacedName
+}
+
+// DetectBacklog returns the backlog of the initial export if the member cluster is cold starting, i.e. it has not
+// exported any Service to the hub cluster yet and it has at least threshold ServiceExports to export; it returns
+// nil otherwise.
+//
+// The EndpointSlices in the backlog are those of the Services with a ServiceExport.
+func DetectBacklog(ctx context.Context, memberReader, hubReader client.Reader, hubNamespace string, threshold int) (*Backlog, error) {
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := hubReader.List(ctx, internalSvcExportList, client.InNamespace(hubNamespace), client.Limit(1)); err != nil {
+		return nil, fmt.Errorf("failed to list the internalServiceExports of the member cluster: %w", err)
+	}
+	if len(internalSvcExportList.Items) > 0 {
+		return nil, nil
+	}
+
+	svcExportList := &fleetnetv1alpha1.ServiceExportList{}
+	if err := memberReader.List(ctx, svcExportList); err != nil {
+		return nil, fmt.Errorf("failed to list the serviceExports: %w", err)
+	}
+	if len(svcExportList.Items) < threshold {
+		return nil, nil
+	}
+	backlog := &Backlog{ServiceExports: make([]types.NamespacedName, 0, len(svcExportList.Items))}
+	svcExportKeys := make(map[types.NamespacedName]bool, len(svcExportList.Items))
+	for i := range svcExportList.Items {
+		key := types.NamespacedName{Namespace: svcExportList.Items[i].Namespace, Name: svcExportList.Items[i].Name}
+		backlog.ServiceExports = append(backlog.ServiceExports, key)
+		svcExportKeys[key] = true
+	}
+
+	// Only the metadata of the EndpointSlices is needed.
+	endpointSliceList := &metav1.PartialObjectMetadataList{}
+	endpointSliceList.SetGroupVersionKind(discoveryv1.SchemeGroupVersion.WithKind("EndpointSliceList"))
+	hasServiceName, err := labels.NewRequirement(discoveryv1.LabelServiceName, selection.Exists, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := memberReader.List(ctx, endpointSliceList, client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*hasServiceName)}); err != nil {
+		return nil, fmt.Errorf("failed to list the endpointSlices: %w", err)
+	}
+	for i := range endpointSliceList.Items {
+		endpointSlice := &endpointSliceList.Items[i]
+		svcKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Labels[discoveryv1.LabelServiceName]}
+		if svcExportKeys[svcKey] {
+			backlog.EndpointSlices = append(backlog.EndpointSlices, types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name})
+		}
+	}
+	return backlog, nil
+}
+
+// objectKey is the key of an object in the backlog.
+type objectKey struct {
+	kind Kind
+	key  types.NamespacedName
+}
+
+// Pacer paces the export of the objects in the backlog of a cold starting member cluster with a token bucket which
+// holds up to burst tokens and regains rate tokens per second; every object of the backlog spends a token when it
+// is first exported. ServiceExports are exported before EndpointSlices, and the objects which are not in the
+// backlog, e.g. those created after the member cluster starts, are never paced.
+//
+// The pacing ends once every object of the backlog has been exported, or once every ServiceExport has been exported
+// and no EndpointSlice has asked to be exported for the idle timeout; the latter happens when the ServiceExports of
+// some EndpointSlices turn out to be invalid, in which case the EndpointSlices are never exported.
+//
+// A nil Pacer never paces exports. It is safe for concurrent use.
+type Pacer struct {
+	clusterID   string
+	rate        float64
+	burst       float64
+	idleTimeout time.Duration
+	clock       clock.PassiveClock
+
+	mu sync.Mutex
+	// tokens is the number of tokens in the bucket at lastUpdated; it is negative when the tokens of the objects
+	// which wait for their turn have been spent in advance.
+	tokens      float64
+	lastUpdated time.Time
+	// lastActive is the last time an object of the backlog asked to be exported.
+	lastActive time.Time
+	// pending are the objects of the backlog which have not been exported yet, keyed by kind.
+	pending map[Kind]map[types.NamespacedName]bool
+	// totals are the numbers of objects in the backlog, keyed by kind.
+	totals map[Kind]int
+	// reservations are the times from which the objects which have spent their tokens in advance are exported.
+	reservations map[objectKey]time.Time
+	completed    bool
+}
+
+// New returns a Pacer which paces the export of a backlog; it returns nil if there is no backlog to pace.
+func New(clusterID string, rate float64, burst int, backlog *Backlog) *Pacer {
+	return NewWithClock(clusterID, rate, burst, DefaultIdleTimeout, backlog, clock.RealClock{})
+}
+
+// NewWithClock returns a Pacer which uses the given idle timeout and clock; it is mostly used in tests.
+func NewWithClock(clusterID string, rate float64, burst int, idleTimeout time.Duration, backlog *Backlog, clock clock.PassiveClock) *Pacer {
+	if backlog == nil {
+		return nil
+	}
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	now := clock.Now()
+	p := &Pacer{
+		clusterID:   clusterID,
+		rate:        rate,
+		burst:       float64(burst),
+		idleTimeout: idleTimeout,
+		clock:       clock,
+		tokens:      float64(burst),
+		lastUpdated: now,
+		lastActive:  now,
+		pending: map[Kind]map[types.NamespacedName]bool{
+			KindServiceExport: make(map[types.NamespacedName]bool, len(backlog.ServiceExports)),
+			KindEndpointSlice: make(map[types.NamespacedName]bool, len(backlog.EndpointSlices)),
+		},
+		totals:       map[Kind]int{},
+		reservations: map[objectKey]time.Time{},
+	}
+	for _, key := range backlog.ServiceExports {
+		p.pending[KindServiceExport][key] = true
+	}
+	for _, key := range backlog.EndpointSlices {
+		p.pending[KindEndpointSlice][key] = true
+	}
+	for kind, pending := range p.pending {
+		p.totals[kind] = len(pending)
+	}
+	klog.InfoS("The member cluster is cold starting; its initial export is paced",
+		"serviceExports", p.totals[KindServiceExport], "endpointSlices", p.totals[KindEndpointSlice], "rate", rate)
+	p.updateMetricsLocked()
+	return p
+}
+
+// Delay returns how long the export of an object should wait; it returns zero if the object is not in the backlog,
+// or if it is its turn to be exported, in which case it is removed from the backlog.
+//
+// Unexports and cleanups should never ask, so that they are not delayed.
+func (p *Pacer) Delay(kind Kind, key types.NamespacedName) time.Duration {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.clock.Now()
+	if p.completeIfDrainedLocked(now) || !p.pending[kind][key] {
+		return 0
+	}
+	p.lastActive = now
+
+	objKey := objectKey{kind: kind, key: key}
+	if readyAt, ok := p.reservations[objKey]; ok {
+		if now.Before(readyAt) {
+			return readyAt.Sub(now)
+		}
+		p.exportedLocked(objKey, now)
+		return 0
+	}
+
+	// EndpointSlices wait until every ServiceExport has been exported.
+	if kind == KindEndpointSlice && len(p.pending[KindServiceExport]) > 0 {
+		wait := time.Duration(float64(len(p.pending[KindServiceExport])) / p.rate * float64(time.Second))
+		if wait < minEndpointSliceWait {
+			wait = minEndpointSliceWait
+		}
+		return wait
+	}
+
+	p.tokens += now.Sub(p.lastUpdated).Seconds() * p.rate
+	if p.tokens > p.burst {
+		p.tokens = p.burst
+	}
+	p.lastUpdated = now
+	p.tokens--
+	if p.tokens >= 0 {
+		p.exportedLocked(objKey, now)
+		return 0
+	}
+	readyAt := now.Add(time.Duration(-p.tokens / p.rate * float64(time.Second)))
+	p.reservations[objKey] = readyAt
+	return readyAt.Sub(now)
+}
+
+// Forget removes an object from the backlog without exporting it, e.g. when it is deleted.
+func (p *Pacer) Forget(kind Kind, key types.NamespacedName) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.completed || !p.pending[kind][key] {
+		return
+	}
+	// The token spent in advance, if any, is not returned; the objects waiting behind keep their turns.
+	delete(p.reservations, objectKey{kind: kind, key: key})
+	delete(p.pending[kind], key)
+	p.totals[kind]--
+	p.completeIfDrainedLocked(p.clock.Now())
+	p.updateMetricsLocked()
+}
+
+// IsPacing returns whether the initial export is still paced.
+func (p *Pacer) IsPacing() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.completeIfDrainedLocked(p.clock.Now())
+}
+
+// Progress returns the numbers of objects of the backlog which have been exported and of all the objects of the
+// backlog.
+func (p *Pacer) Progress() (exported, total int) {
+	if p == nil {
+		return 0, 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for kind, pending := range p.pending {
+		exported += p.totals[kind] - len(pending)
+		total += p.totals[kind]
+	}
+	return exported, total
+}
+
+// exportedLocked removes an object whose turn has come from the backlog; p.mu must be held.
+func (p *Pacer) exportedLocked(objKey objectKey, now time.Time) {
+	delete(p.reservations, objKey)
+	delete(p.pending[objKey.kind], objKey.key)
+	p.completeIfDrainedLocked(now)
+	p.updateMetricsLocked()
+}
+
+// completeIfDrainedLocked ends the pacing if the backlog has drained and returns whether the pacing has ended;
+// p.mu must be held.
+func (p *Pacer) completeIfDrainedLocked(now time.Time) bool {
+	if p.completed {
+		return true
+	}
+	if len(p.pending[KindServiceExport]) > 0 {
+		return false
+	}
+	if len(p.pending[KindEndpointSlice]) > 0 && now.Sub(p.lastActive) < p.idleTimeout {
+		return false
+	}
+	p.completed = true
+	p.reservations = nil
+	klog.InfoS("The initial export of the member cluster has completed; it is no longer paced",
+		"serviceExports", p.totals[KindServiceExport]-len(p.pending[KindServiceExport]),
+		"endpointSlices", p.totals[KindEndpointSlice]-len(p.pending[KindEndpointSlice]),
+		"endpointSlicesNotExported", len(p.pending[KindEndpointSlice]))
+	p.updateMetricsLocked()
+	return true
+}
+
+// updateMetricsLocked updates the metrics of the initial export; p.mu must be held.
+func (p *Pacer) updateMetricsLocked() {
+	pacing := float64(1)
+	if p.completed {
+		pacing = 0
+	}
+	initialSyncPacing.WithLabelValues(p.clusterID).Set(pacing)
+	for kind, pending := range p.pending {
+		initialSyncObjects.WithLabelValues(p.clusterID, string(kind), "exported").Set(float64(p.totals[kind] - len(pending)))
+		initialSyncObjects.WithLabelValues(p.clusterID, string(kind), "total").Set(float64(p.totals[kind]))
+	}
+}
+
+// The ConfigMap is kept in the fleet system namespace; the member agent may only update this very ConfigMap, as the
+// name of the object to create cannot be restricted.
+//+kubebuilder:rbac:groups="",namespace=fleet-system,resources=configmaps,verbs=create
+//+kubebuilder:rbac:groups="",namespace=fleet-system,resources=configmaps,resourceNames=fleet-networking-initial-sync,verbs=update
+
+// StatusReporter periodically reports the status of the initial export in a ConfigMap named StatusConfigMapName,
+// until the pacing ends.
+type StatusReporter struct {
+	// Pacer is the pacer of the initial export; nothing is reported if it is not set.
+	Pacer *Pacer
+	// Client writes the status ConfigMap.
+	Client client.Client
+	// Namespace is the namespace of the status ConfigMap.
+	Namespace string
+	// Interval is the interval between two updates of the status; DefaultStatusInterval is used if it is not set.
+	Interval time.Duration
+}
+
+// Report writes the current status of the initial export.
+func (s *StatusReporter) Report(ctx context.Context) error {
+	exported, total := s.Pacer.Progress()
+	phase := statusPhasePacing
+	if !s.Pacer.IsPacing() {
+		phase = statusPhaseCompleted
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: s.Namespace,
+			Name:      StatusConfigMapName,
+		},
+		Data: map[string]string{
+			statusKeyPhase:    phase,
+			statusKeyExported: strconv.Itoa(exported),
+			statusKeyTotal:    strconv.Itoa(total),
+		},
+	}
+	// The ConfigMap is owned by the member agent alone; overwrite it without reading it first.
+	err := s.Client.Update(ctx, configMap)
+	if apierrors.IsNotFound(err) {
+		err = s.Client.Create(ctx, configMap)
+	}
+	return err
+}
+
+// Start reports the status of the initial export periodically until the pacing ends or the context is cancelled;
+// it implements the manager.Runnable interface.
+func (s *StatusReporter) Start(ctx context.Context) error {
+	if s.Pacer == nil {
+		return nil
+	}
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultStatusInterval
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// The pacing is checked before the report, so that the last report tells the pacing has ended.
+		isPacing := s.Pacer.IsPacing()
+		if err := s.Report(ctx); err != nil {
+			klog.ErrorS(err, "Failed to report the status of the initial export", "configMap", klog.KRef(s.Namespace, StatusConfigMapName))
+			return
+		}
+		if !isPacing {
+			cancel()
+		}
+	}, interval)
+	return nil
+}
@@ -0,0 +1,341 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package initialsync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	memberClusterID = "bravelion"
+	hubNamespace    = "fleet-member-bravelion"
+	memberUserNS    = "work"
+)
+
+func serviceExportKey(i int) types.NamespacedName {
+	return types.NamespacedName{Namespace: memberUserNS, Name: fmt.Sprintf("app-%d", i)}
+}
+
+func endpointSliceKey(i int) types.NamespacedName {
+	return types.NamespacedName{Namespace: memberUserNS, Name: fmt.Sprintf("app-%d-x2yz", i)}
+}
+
+func testBacklog(svcExports, endpointSlices int) *Backlog {
+	backlog := &Backlog{}
+	for i := 0; i < svcExports; i++ {
+		backlog.ServiceExports = append(backlog.ServiceExports, serviceExportKey(i))
+	}
+	for i := 0; i < endpointSlices; i++ {
+		backlog.EndpointSlices = append(backlog.EndpointSlices, endpointSliceKey(i))
+	}
+	return backlog
+}
+
+func TestNilPacer(t *testing.T) {
+	var p *Pacer
+	if got := New(memberClusterID, 1, 1, nil); got != nil {
+		t.Fatalf("New() with no backlog = %v, want nil", got)
+	}
+	if got := p.Delay(KindServiceExport, serviceExportKey(0)); got != 0 {
+		t.Fatalf("Delay() of a nil pacer = %v, want 0", got)
+	}
+	p.Forget(KindServiceExport, serviceExportKey(0))
+	if p.IsPacing() {
+		t.Fatalf("IsPacing() of a nil pacer = true, want false")
+	}
+}
+
+func TestDelay(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	p := NewWithClock(memberClusterID, 1, 2, time.Minute, testBacklog(4, 1), fakeClock)
+
+	// The burst is exported right away; the objects afterwards wait for their turns.
+	for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second} {
+		if got := p.Delay(KindServiceExport, serviceExportKey(i)); got != want {
+			t.Fatalf("Delay(%v) = %v, want %v", serviceExportKey(i), got, want)
+		}
+	}
+	// Asking again before the turn comes does not spend another token.
+	if got := p.Delay(KindServiceExport, serviceExportKey(2)); got != time.Second {
+		t.Fatalf("Delay(%v) again = %v, want %v", serviceExportKey(2), got, time.Second)
+	}
+	// The objects not in the backlog are never paced.
+	if got := p.Delay(KindServiceExport, serviceExportKey(10)); got != 0 {
+		t.Fatalf("Delay(%v) of an object not in the backlog = %v, want 0", serviceExportKey(10), got)
+	}
+	// The EndpointSlices wait for the ServiceExports.
+	if got := p.Delay(KindEndpointSlice, endpointSliceKey(0)); got != 2*time.Second {
+		t.Fatalf("Delay(%v) before the service exports are exported = %v, want %v", endpointSliceKey(0), got, 2*time.Second)
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(2 * time.Second))
+	for i := 2; i < 4; i++ {
+		if got := p.Delay(KindServiceExport, serviceExportKey(i)); got != 0 {
+			t.Fatalf("Delay(%v) after its turn comes = %v, want 0", serviceExportKey(i), got)
+		}
+	}
+	if exported, total := p.Progress(); exported != 4 || total != 5 {
+		t.Fatalf("Progress() = (%d, %d), want (4, 5)", exported, total)
+	}
+	if got := p.Delay(KindEndpointSlice, endpointSliceKey(0)); got != time.Second {
+		t.Fatalf("Delay(%v) = %v, want %v", endpointSliceKey(0), got, time.Second)
+	}
+	if !p.IsPacing() {
+		t.Fatalf("IsPacing() with an endpoint slice left = false, want true")
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	if got := p.Delay(KindEndpointSlice, endpointSliceKey(0)); got != 0 {
+		t.Fatalf("Delay(%v) after its turn comes = %v, want 0", endpointSliceKey(0), got)
+	}
+	if p.IsPacing() {
+		t.Fatalf("IsPacing() after the backlog drains = true, want false")
+	}
+	if got := testutil.ToFloat64(initialSyncPacing.WithLabelValues(memberClusterID)); got != 0 {
+		t.Fatalf("initial sync pacing metric = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(initialSyncObjects.WithLabelValues(memberClusterID, string(KindServiceExport), "exported")); got != 4 {
+		t.Fatalf("initial sync exported service exports metric = %v, want 4", got)
+	}
+}
+
+func TestForget(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	p := NewWithClock(memberClusterID, 1, 1, time.Minute, testBacklog(2, 0), fakeClock)
+
+	if got := p.Delay(KindServiceExport, serviceExportKey(0)); got != 0 {
+		t.Fatalf("Delay(%v) = %v, want 0", serviceExportKey(0), got)
+	}
+	// The deleted ServiceExport leaves the backlog, which drains.
+	p.Forget(KindServiceExport, serviceExportKey(1))
+	if p.IsPacing() {
+		t.Fatalf("IsPacing() after the last object is forgotten = true, want false")
+	}
+	if exported, total := p.Progress(); exported != 1 || total != 1 {
+		t.Fatalf("Progress() = (%d, %d), want (1, 1)", exported, total)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	p := NewWithClock(memberClusterID, 10, 10, time.Minute, testBacklog(1, 2), fakeClock)
+
+	if got := p.Delay(KindServiceExport, serviceExportKey(0)); got != 0 {
+		t.Fatalf("Delay(%v) = %v, want 0", serviceExportKey(0), got)
+	}
+	if got := p.Delay(KindEndpointSlice, endpointSliceKey(0)); got != 0 {
+		t.Fatalf("Delay(%v) = %v, want 0", endpointSliceKey(0), got)
+	}
+
+	// The other EndpointSlice never asks, e.g. as its ServiceExport is invalid.
+	fakeClock.SetTime(fakeClock.Now().Add(59 * time.Second))
+	if !p.IsPacing() {
+		t.Fatalf("IsPacing() before the idle timeout = false, want true")
+	}
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	if p.IsPacing() {
+		t.Fatalf("IsPacing() after the idle timeout = true, want false")
+	}
+	if got := p.Delay(KindEndpointSlice, endpointSliceKey(1)); got != 0 {
+		t.Fatalf("Delay(%v) after the pacing ends = %v, want 0", endpointSliceKey(1), got)
+	}
+}
+
+// TestColdStart simulates the cold start of a member cluster with 5k Services, each with an EndpointSlice, whose
+// controllers requeue the objects as the pacer asks.
+func TestColdStart(t *testing.T) {
+	const (
+		objects = 5000
+		rate    = 100
+		burst   = 50
+	)
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	start := fakeClock.Now()
+	p := NewWithClock(memberClusterID, rate, burst, time.Minute, testBacklog(objects, objects), fakeClock)
+
+	// requeueAt is the time each object is reconciled next, keyed by kind and index.
+	type item struct {
+		kind Kind
+		i    int
+	}
+	requeueAt := map[item]time.Time{}
+	for i := 0; i < objects; i++ {
+		requeueAt[item{kind: KindServiceExport, i: i}] = start
+		requeueAt[item{kind: KindEndpointSlice, i: i}] = start
+	}
+	keyOf := func(it item) types.NamespacedName {
+		if it.kind == KindServiceExport {
+			return serviceExportKey(it.i)
+		}
+		return endpointSliceKey(it.i)
+	}
+
+	// exportedPerSecond counts the exports in each second since the start.
+	exportedPerSecond := map[int]int{}
+	lastServiceExportAt, firstEndpointSliceAt := time.Duration(0), time.Duration(-1)
+	for tick := 0; len(requeueAt) > 0; tick++ {
+		if tick > 1000 {
+			t.Fatalf("the backlog has not drained after %d ticks; %d objects left", tick, len(requeueAt))
+		}
+		now := fakeClock.Now()
+		for it, at := range requeueAt {
+			if now.Before(at) {
+				continue
+			}
+			delay := p.Delay(it.kind, keyOf(it))
+			if delay > 0 {
+				requeueAt[it] = now.Add(delay)
+				continue
+			}
+			delete(requeueAt, it)
+			elapsed := now.Sub(start)
+			exportedPerSecond[int(elapsed/time.Second)]++
+			switch {
+			case it.kind == KindServiceExport && elapsed > lastServiceExportAt:
+				lastServiceExportAt = elapsed
+			case it.kind == KindEndpointSlice && (firstEndpointSliceAt < 0 || elapsed < firstEndpointSliceAt):
+				firstEndpointSliceAt = elapsed
+			}
+		}
+		fakeClock.SetTime(now.Add(100 * time.Millisecond))
+	}
+
+	for second, exported := range exportedPerSecond {
+		limit := rate
+		if second == 0 {
+			limit += burst
+		}
+		if exported > limit {
+			t.Errorf("%d objects are exported in second %d, want no more than %d", exported, second, limit)
+		}
+	}
+	if firstEndpointSliceAt < lastServiceExportAt {
+		t.Errorf("the first endpoint slice is exported at %v, before the last service export at %v", firstEndpointSliceAt, lastServiceExportAt)
+	}
+	if exported, total := p.Progress(); exported != 2*objects || total != 2*objects {
+		t.Errorf("Progress() = (%d, %d), want (%d, %d)", exported, total, 2*objects, 2*objects)
+	}
+	if p.IsPacing() {
+		t.Errorf("IsPacing() after the backlog drains = true, want false")
+	}
+}
+
+func TestDetectBacklog(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme(), got %v, want no error", err)
+	}
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme(), got %v, want no error", err)
+	}
+	memberObjs := []client.Object{
+		&fleetnetv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Namespace: memberUserNS, Name: "app-0"}},
+		&fleetnetv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Namespace: memberUserNS, Name: "app-1"}},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      "app-0-x2yz",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "app-0"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		},
+		// The Service of the EndpointSlice is not exported.
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      "other-x2yz",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "other"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		},
+	}
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{ObjectMeta: metav1.ObjectMeta{Namespace: hubNamespace, Name: "work-app-0"}}
+
+	testCases := []struct {
+		name      string
+		hubObjs   []client.Object
+		threshold int
+		want      *Backlog
+	}{
+		{
+			name:      "cold start",
+			threshold: 2,
+			want: &Backlog{
+				ServiceExports: []types.NamespacedName{serviceExportKey(0), serviceExportKey(1)},
+				EndpointSlices: []types.NamespacedName{endpointSliceKey(0)},
+			},
+		},
+		{
+			name:      "too few service exports",
+			threshold: 3,
+		},
+		{
+			name:      "exported before",
+			hubObjs:   []client.Object{internalSvcExport},
+			threshold: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			memberClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(memberObjs...).Build()
+			hubClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.hubObjs...).Build()
+			got, err := DetectBacklog(context.Background(), memberClient, hubClient, hubNamespace, tc.threshold)
+			if err != nil {
+				t.Fatalf("DetectBacklog(), got %v, want no error", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("DetectBacklog() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusReporter(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	p := NewWithClock(memberClusterID, 1, 1, time.Minute, testBacklog(2, 0), fakeClock)
+	fakeClient := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	s := &StatusReporter{Pacer: p, Client: fakeClient, Namespace: "fleet-system"}
+	statusKey := types.NamespacedName{Namespace: "fleet-system", Name: StatusConfigMapName}
+
+	checkStatus := func(want map[string]string) {
+		t.Helper()
+		if err := s.Report(ctx); err != nil {
+			t.Fatalf("Report(), got %v, want no error", err)
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := fakeClient.Get(ctx, statusKey, configMap); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		if diff := cmp.Diff(want, configMap.Data); diff != "" {
+			t.Fatalf("status configMap data mismatch (-want, +got):\n%s", diff)
+		}
+	}
+
+	p.Delay(KindServiceExport, serviceExportKey(0))
+	checkStatus(map[string]string{"phase": "Pacing", "exported": "1", "total": "2"})
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	p.Delay(KindServiceExport, serviceExportKey(1))
+	checkStatus(map[string]string{"phase": "Completed", "exported": "2", "total": "2"})
+}
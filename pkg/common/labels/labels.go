@@ -0,0 +1,44 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package labels features utility functions that help read and write the labels of the objects managed by the fleet
+// networking controllers, e.g. the Service name label of EndpointSlices.
+package labels
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+)
+
+// SetFleetLabel sets a label on an object, initializing its labels if they are nil.
+func SetFleetLabel(obj metav1.Object, key, value string) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels[key] = value
+	obj.SetLabels(objLabels)
+}
+
+// RemoveFleetLabel removes a label from an object; it does nothing if the object does not have the label.
+func RemoveFleetLabel(obj metav1.Object, key string) {
+	objLabels := obj.GetLabels()
+	if _, ok := objLabels[key]; !ok {
+		return
+	}
+	delete(objLabels, key)
+	obj.SetLabels(objLabels)
+}
+
+// GetFleetLabel returns the value of a label of an object, and whether the object has the label.
+func GetFleetLabel(obj metav1.Object, key string) (string, bool) {
+	value, ok := obj.GetLabels()[key]
+	return value, ok
+}
+
+// FleetLabelSelector returns a selector which selects the objects whose label of the given key has the given value.
+func FleetLabelSelector(key, value string) k8slabels.Selector {
+	return k8slabels.SelectorFromSet(k8slabels.Set{key: value})
+}
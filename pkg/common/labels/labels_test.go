@@ -0,0 +1,157 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package labels
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	labelKey   = "networking.fleet.azure.com/derived-service"
+	labelValue = "app"
+)
+
+// TestSetFleetLabel tests the SetFleetLabel function.
+func TestSetFleetLabel(t *testing.T) {
+	testCases := []struct {
+		name       string
+		labels     map[string]string
+		wantLabels map[string]string
+	}{
+		{
+			name:       "should initialize nil labels",
+			wantLabels: map[string]string{labelKey: labelValue},
+		},
+		{
+			name:       "should add label",
+			labels:     map[string]string{"other": "value"},
+			wantLabels: map[string]string{"other": "value", labelKey: labelValue},
+		},
+		{
+			name:       "should overwrite label",
+			labels:     map[string]string{labelKey: "stale"},
+			wantLabels: map[string]string{labelKey: labelValue},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Labels: tc.labels}
+			SetFleetLabel(obj, labelKey, labelValue)
+			if diff := cmp.Diff(tc.wantLabels, obj.GetLabels()); diff != "" {
+				t.Errorf("SetFleetLabel() labels mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestRemoveFleetLabel tests the RemoveFleetLabel function.
+func TestRemoveFleetLabel(t *testing.T) {
+	testCases := []struct {
+		name       string
+		labels     map[string]string
+		wantLabels map[string]string
+	}{
+		{
+			name: "should do nothing with nil labels",
+		},
+		{
+			name:       "should do nothing if the label is absent",
+			labels:     map[string]string{"other": "value"},
+			wantLabels: map[string]string{"other": "value"},
+		},
+		{
+			name:       "should remove label",
+			labels:     map[string]string{"other": "value", labelKey: labelValue},
+			wantLabels: map[string]string{"other": "value"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Labels: tc.labels}
+			RemoveFleetLabel(obj, labelKey)
+			if diff := cmp.Diff(tc.wantLabels, obj.GetLabels()); diff != "" {
+				t.Errorf("RemoveFleetLabel() labels mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestGetFleetLabel tests the GetFleetLabel function.
+func TestGetFleetLabel(t *testing.T) {
+	testCases := []struct {
+		name      string
+		labels    map[string]string
+		wantValue string
+		wantOK    bool
+	}{
+		{
+			name: "should not find label in nil labels",
+		},
+		{
+			name:   "should not find absent label",
+			labels: map[string]string{"other": "value"},
+		},
+		{
+			name:      "should find label",
+			labels:    map[string]string{labelKey: labelValue},
+			wantValue: labelValue,
+			wantOK:    true,
+		},
+		{
+			name:   "should find label with empty value",
+			labels: map[string]string{labelKey: ""},
+			wantOK: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{Labels: tc.labels}
+			value, ok := GetFleetLabel(obj, labelKey)
+			if value != tc.wantValue || ok != tc.wantOK {
+				t.Errorf("GetFleetLabel() = (%q, %t), want (%q, %t)", value, ok, tc.wantValue, tc.wantOK)
+			}
+		})
+	}
+}
+
+// TestFleetLabelSelector tests the FleetLabelSelector function.
+func TestFleetLabelSelector(t *testing.T) {
+	testCases := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "should match label",
+			labels: map[string]string{"other": "value", labelKey: labelValue},
+			want:   true,
+		},
+		{
+			name:   "should not match other value",
+			labels: map[string]string{labelKey: "other"},
+		},
+		{
+			name:   "should not match absent label",
+			labels: map[string]string{"other": "value"},
+		},
+	}
+
+	selector := FleetLabelSelector(labelKey, labelValue)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := selector.Matches(k8slabels.Set(tc.labels)); got != tc.want {
+				t.Errorf("FleetLabelSelector().Matches() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ExportedObjectTracker tracks the objects exported by member clusters and reflects the number of exported objects
+// of each member cluster in a gauge labeled by the member cluster ID.
+//
+// Adding and removing objects are idempotent, so that the controllers can record every successful export or
+// unexport without double counting.
+type ExportedObjectTracker struct {
+	gauge *prometheus.GaugeVec
+
+	mu       sync.Mutex
+	exported map[string]map[types.NamespacedName]bool
+}
+
+// NewExportedObjectTracker returns a tracker which reflects the number of exported objects in the gauge; the gauge
+// must have a single label, the member cluster ID.
+func NewExportedObjectTracker(gauge *prometheus.GaugeVec) *ExportedObjectTracker {
+	return &ExportedObjectTracker{
+		gauge:    gauge,
+		exported: map[string]map[types.NamespacedName]bool{},
+	}
+}
+
+// Add records that the object has been exported by the member cluster.
+func (t *ExportedObjectTracker) Add(clusterID string, key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.exported[clusterID] == nil {
+		t.exported[clusterID] = map[types.NamespacedName]bool{}
+	}
+	t.exported[clusterID][key] = true
+	t.gauge.WithLabelValues(clusterID).Set(float64(len(t.exported[clusterID])))
+}
+
+// Remove records that the object is no longer exported by the member cluster.
+func (t *ExportedObjectTracker) Remove(clusterID string, key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.exported[clusterID], key)
+	t.gauge.WithLabelValues(clusterID).Set(float64(len(t.exported[clusterID])))
+}
+
+// Reset records that no object is exported by the member cluster any more.
+func (t *ExportedObjectTracker) Reset(clusterID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.exported, clusterID)
+	t.gauge.WithLabelValues(clusterID).Set(0)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestExportedObjectTracker(t *testing.T) {
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_exported_objects"}, []string{"origin_cluster_id"})
+	tracker := NewExportedObjectTracker(gauge)
+	app := types.NamespacedName{Namespace: "work", Name: "app"}
+	other := types.NamespacedName{Namespace: "work", Name: "other-app"}
+
+	steps := []struct {
+		name       string
+		do         func()
+		wantCount1 float64
+		wantCount2 float64
+	}{
+		{
+			name:       "export an object",
+			do:         func() { tracker.Add("member-1", app) },
+			wantCount1: 1,
+		},
+		{
+			name:       "update the exported object",
+			do:         func() { tracker.Add("member-1", app) },
+			wantCount1: 1,
+		},
+		{
+			name:       "export another object",
+			do:         func() { tracker.Add("member-1", other) },
+			wantCount1: 2,
+		},
+		{
+			name:       "export the object from another cluster",
+			do:         func() { tracker.Add("member-2", app) },
+			wantCount1: 2,
+			wantCount2: 1,
+		},
+		{
+			name:       "unexport an object",
+			do:         func() { tracker.Remove("member-1", app) },
+			wantCount1: 1,
+			wantCount2: 1,
+		},
+		{
+			name:       "unexport the object again",
+			do:         func() { tracker.Remove("member-1", app) },
+			wantCount1: 1,
+			wantCount2: 1,
+		},
+		{
+			name:       "unexport all the objects of a cluster",
+			do:         func() { tracker.Reset("member-1") },
+			wantCount2: 1,
+		},
+	}
+	for _, step := range steps {
+		step.do()
+		if got := testutil.ToFloat64(gauge.WithLabelValues("member-1")); got != step.wantCount1 {
+			t.Errorf("%s: exported objects of member-1 = %v, want %v", step.name, got, step.wantCount1)
+		}
+		if got := testutil.ToFloat64(gauge.WithLabelValues("member-2")); got != step.wantCount2 {
+			t.Errorf("%s: exported objects of member-2 = %v, want %v", step.name, got, step.wantCount2)
+		}
+	}
+}
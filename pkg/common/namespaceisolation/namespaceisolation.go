@@ -0,0 +1,208 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package namespaceisolation features a workqueue rate limiter which isolates the namespaces whose objects keep
+// failing to reconcile, so that their retries do not delay the reconciliation of the other namespaces.
+package namespaceisolation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+const (
+	// DefaultFailureThreshold is the default number of failures within the window above which a namespace is
+	// demoted.
+	DefaultFailureThreshold = 50
+	// DefaultWindow is the default period over which the failures of a namespace are counted.
+	DefaultWindow = time.Minute
+	// DefaultDemotedRetryInterval is the default interval between the retries of the objects of a demoted namespace.
+	DefaultDemotedRetryInterval = 5 * time.Second
+)
+
+var (
+	// namespaceReconcileFailures is a Prometheus counter metric which counts the failed reconciliations of each
+	// namespace.
+	namespaceReconcileFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "namespace_reconcile_failures_total",
+			Help:      "The number of failed reconciliations of the objects of a namespace",
+		},
+		[]string{"controller", "namespace"},
+	)
+
+	// namespaceDemoted is a Prometheus gauge metric which is 1 while a namespace is demoted, and 0 otherwise.
+	namespaceDemoted = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "namespace_demoted",
+			Help:      "Whether the retries of the objects of a namespace are slowed down, as they keep failing",
+		},
+		[]string{"controller", "namespace"},
+	)
+)
+
+func init() {
+	// Register namespaceReconcileFailures (fleet_networking_namespace_reconcile_failures_total) and
+	// namespaceDemoted (fleet_networking_namespace_demoted) metrics with the controller runtime global metrics
+	// registry.
+	ctrlmetrics.Registry.MustRegister(namespaceReconcileFailures, namespaceDemoted)
+}
+
+// Config configures when a namespace is demoted, and how much its retries are slowed down.
+type Config struct {
+	// FailureThreshold is the number of failures within the window above which a namespace is demoted.
+	FailureThreshold int
+	// Window is the period over which the failures of a namespace are counted; a demoted namespace is promoted
+	// back once none of its objects has failed for the window.
+	Window time.Duration
+	// DemotedRetryInterval is the interval between the retries of the objects of a demoted namespace; the retries
+	// of the namespace take turns, no matter how many of its objects fail.
+	DemotedRetryInterval time.Duration
+}
+
+type namespace struct {
+	failures []time.Time
+	demoted  bool
+	// nextRetry is the earliest time the next retry of a demoted namespace can happen.
+	nextRetry time.Time
+}
+
+// RateLimiter tells how long to wait before retrying a failed reconciliation. It delegates to another rate limiter,
+// usually the default one of the controller, and counts the failures of each namespace; once a namespace fails
+// more often than the threshold, its retries are spaced out, so that they no longer occupy the workers which the
+// other namespaces share.
+//
+// Only the retries are slowed down; the changes to the objects of a demoted namespace are still reconciled right
+// away. It is safe for concurrent use.
+type RateLimiter struct {
+	controllerName string
+	config         Config
+	delegate       workqueue.TypedRateLimiter[reconcile.Request]
+	clock          clock.PassiveClock
+
+	mu         sync.Mutex
+	namespaces map[string]*namespace
+}
+
+var _ workqueue.TypedRateLimiter[reconcile.Request] = &RateLimiter{}
+
+// New returns a RateLimiter which isolates the failing namespaces of the named controller.
+func New(controllerName string, config Config, delegate workqueue.TypedRateLimiter[reconcile.Request]) *RateLimiter {
+	return NewWithClock(controllerName, config, delegate, clock.RealClock{})
+}
+
+// NewWithClock returns a RateLimiter which uses the given clock; it is mostly used in tests.
+func NewWithClock(controllerName string, config Config, delegate workqueue.TypedRateLimiter[reconcile.Request], clock clock.PassiveClock) *RateLimiter {
+	return &RateLimiter{
+		controllerName: controllerName,
+		config:         config,
+		delegate:       delegate,
+		clock:          clock,
+		namespaces:     map[string]*namespace{},
+	}
+}
+
+// When records a failure of the item, and returns how long to wait before retrying it.
+func (r *RateLimiter) When(item reconcile.Request) time.Duration {
+	delay := r.delegate.When(item)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	ns, ok := r.namespaces[item.Namespace]
+	if !ok {
+		ns = &namespace{}
+		r.namespaces[item.Namespace] = ns
+	}
+	r.evaluate(item.Namespace, ns, now)
+	ns.failures = append(ns.failures, now)
+	namespaceReconcileFailures.WithLabelValues(r.controllerName, item.Namespace).Inc()
+	if !ns.demoted && len(ns.failures) > r.config.FailureThreshold {
+		klog.V(2).InfoS("Demoting namespace whose objects keep failing to reconcile",
+			"controller", r.controllerName,
+			"namespace", item.Namespace,
+			"failures", len(ns.failures),
+			"window", r.config.Window)
+		ns.demoted = true
+		namespaceDemoted.WithLabelValues(r.controllerName, item.Namespace).Set(1)
+	}
+	if !ns.demoted {
+		return delay
+	}
+
+	retryAt := ns.nextRetry
+	if retryAt.Before(now) {
+		retryAt = now
+	}
+	ns.nextRetry = retryAt.Add(r.config.DemotedRetryInterval)
+	if wait := retryAt.Sub(now); wait > delay {
+		return wait
+	}
+	return delay
+}
+
+// Forget records that the item has been reconciled successfully.
+func (r *RateLimiter) Forget(item reconcile.Request) {
+	r.delegate.Forget(item)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ns, ok := r.namespaces[item.Namespace]
+	if !ok {
+		return
+	}
+	r.evaluate(item.Namespace, ns, r.clock.Now())
+	if !ns.demoted && len(ns.failures) == 0 {
+		delete(r.namespaces, item.Namespace)
+	}
+}
+
+// NumRequeues returns how many times the item has failed.
+func (r *RateLimiter) NumRequeues(item reconcile.Request) int {
+	return r.delegate.NumRequeues(item)
+}
+
+// IsDemoted returns whether the retries of the namespace are slowed down.
+func (r *RateLimiter) IsDemoted(namespace string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ns, ok := r.namespaces[namespace]
+	if !ok {
+		return false
+	}
+	r.evaluate(namespace, ns, r.clock.Now())
+	return ns.demoted
+}
+
+// evaluate drops the failures of the namespace which have left the window, and promotes the namespace if none is
+// left; the caller must hold the lock.
+func (r *RateLimiter) evaluate(name string, ns *namespace, now time.Time) {
+	kept := ns.failures[:0]
+	for _, failedAt := range ns.failures {
+		if now.Sub(failedAt) < r.config.Window {
+			kept = append(kept, failedAt)
+		}
+	}
+	ns.failures = kept
+	if ns.demoted && len(ns.failures) == 0 {
+		klog.V(2).InfoS("Promoting namespace which has recovered", "controller", r.controllerName, "namespace", name)
+		ns.demoted = false
+		ns.nextRetry = time.Time{}
+		namespaceDemoted.WithLabelValues(r.controllerName, name).Set(0)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package namespaceisolation
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	noisyNamespace = "noisy"
+	quietNamespace = "quiet"
+)
+
+var testConfig = Config{
+	FailureThreshold:     3,
+	Window:               time.Minute,
+	DemotedRetryInterval: 5 * time.Second,
+}
+
+func request(namespace, name string) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+}
+
+// exponentialRateLimiter returns a per-item exponential rate limiter; unlike the default controller rate limiter,
+// it does not depend on the wall clock.
+func exponentialRateLimiter() workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](5*time.Millisecond, 1000*time.Second)
+}
+
+func TestRateLimiter(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	controllerName := t.Name()
+	r := NewWithClock(controllerName, testConfig, exponentialRateLimiter(), fakeClock)
+
+	// The failures up to the threshold are retried with the delay of the delegate.
+	for i := 0; i < testConfig.FailureThreshold; i++ {
+		if got := r.When(request(noisyNamespace, fmt.Sprintf("app-%d", i))); got != 5*time.Millisecond {
+			t.Fatalf("When() = %v after %d failures, want 5ms", got, i)
+		}
+	}
+	if r.IsDemoted(noisyNamespace) {
+		t.Fatalf("IsDemoted() = true at the threshold, want false")
+	}
+
+	// The namespace is demoted once the failures exceed the threshold; its retries take turns.
+	wantDelays := []time.Duration{5 * time.Millisecond, 5 * time.Second, 10 * time.Second}
+	for i, want := range wantDelays {
+		if got := r.When(request(noisyNamespace, fmt.Sprintf("web-%d", i))); got != want {
+			t.Fatalf("When() = %v for retry %d of the demoted namespace, want %v", got, i, want)
+		}
+	}
+	if !r.IsDemoted(noisyNamespace) {
+		t.Fatalf("IsDemoted() = false above the threshold, want true")
+	}
+	if got := testutil.ToFloat64(namespaceDemoted.WithLabelValues(controllerName, noisyNamespace)); got != 1 {
+		t.Errorf("namespace demoted metric = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(namespaceReconcileFailures.WithLabelValues(controllerName, noisyNamespace)); got != 6 {
+		t.Errorf("namespace reconcile failures metric = %v, want 6", got)
+	}
+
+	// Other namespaces are not affected.
+	if got := r.When(request(quietNamespace, "app")); got != 5*time.Millisecond {
+		t.Errorf("When() = %v for another namespace, want 5ms", got)
+	}
+	r.Forget(request(quietNamespace, "app"))
+	if r.IsDemoted(quietNamespace) {
+		t.Errorf("IsDemoted() = true for another namespace, want false")
+	}
+
+	// A successful reconciliation does not promote the namespace while its failures count.
+	r.Forget(request(noisyNamespace, "app-0"))
+	if !r.IsDemoted(noisyNamespace) {
+		t.Fatalf("IsDemoted() = false right after a success, want true")
+	}
+
+	// The namespace is promoted once none of its objects has failed for the window.
+	fakeClock.SetTime(fakeClock.Now().Add(testConfig.Window))
+	r.Forget(request(noisyNamespace, "app-1"))
+	if r.IsDemoted(noisyNamespace) {
+		t.Fatalf("IsDemoted() = true after the window, want false")
+	}
+	if got := testutil.ToFloat64(namespaceDemoted.WithLabelValues(controllerName, noisyNamespace)); got != 0 {
+		t.Errorf("namespace demoted metric = %v, want 0", got)
+	}
+	if got := r.When(request(noisyNamespace, "app-3")); got != 5*time.Millisecond {
+		t.Errorf("When() = %v after the namespace is promoted, want 5ms", got)
+	}
+}
+
+// simulation replays the workqueue of a controller, with a single worker, in simulated time: every object of the
+// noisy namespace fails to reconcile until the noisy namespace is fixed, while the quiet namespace keeps getting
+// new objects, which reconcile successfully.
+type simulation struct {
+	rateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+	clock       *clocktesting.FakePassiveClock
+	start       time.Time
+
+	noisyObjects  int
+	noisyAttempt  time.Duration
+	noisyFixedAt  time.Duration
+	quietInterval time.Duration
+	quietAttempt  time.Duration
+	duration      time.Duration
+}
+
+type queuedItem struct {
+	req     reconcile.Request
+	readyAt time.Time
+	addedAt time.Time
+}
+
+// run returns how long each object of the quiet namespace, added after the warm-up, has waited in the queue.
+func (s *simulation) run(warmUp time.Duration) []time.Duration {
+	now := s.start
+	var queue []queuedItem
+	for i := 0; i < s.noisyObjects; i++ {
+		queue = append(queue, queuedItem{req: request(noisyNamespace, fmt.Sprintf("svc-%d", i)), readyAt: now, addedAt: now})
+	}
+	nextQuiet, quietCount := now, 0
+	var waits []time.Duration
+	for now.Sub(s.start) < s.duration {
+		for !nextQuiet.After(now) {
+			queue = append(queue, queuedItem{req: request(quietNamespace, fmt.Sprintf("svc-%d", quietCount)), readyAt: nextQuiet, addedAt: nextQuiet})
+			quietCount++
+			nextQuiet = nextQuiet.Add(s.quietInterval)
+		}
+		// The worker picks the item which has been ready for the longest time.
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].readyAt.Before(queue[j].readyAt) })
+		if len(queue) == 0 || queue[0].readyAt.After(now) {
+			now = nextQuiet
+			if len(queue) > 0 && queue[0].readyAt.Before(now) {
+				now = queue[0].readyAt
+			}
+			continue
+		}
+		item := queue[0]
+		queue = queue[1:]
+		if item.req.Namespace == quietNamespace {
+			if item.addedAt.Sub(s.start) >= warmUp {
+				waits = append(waits, now.Sub(item.addedAt))
+			}
+			now = now.Add(s.quietAttempt)
+			s.clock.SetTime(now)
+			s.rateLimiter.Forget(item.req)
+			continue
+		}
+		now = now.Add(s.noisyAttempt)
+		s.clock.SetTime(now)
+		if now.Sub(s.start) >= s.noisyFixedAt {
+			s.rateLimiter.Forget(item.req)
+			continue
+		}
+		queue = append(queue, queuedItem{req: item.req, readyAt: now.Add(s.rateLimiter.When(item.req)), addedAt: now})
+	}
+	s.clock.SetTime(now)
+	return waits
+}
+
+func maxWait(waits []time.Duration) time.Duration {
+	var longest time.Duration
+	for _, v := range waits {
+		if v > longest {
+			longest = v
+		}
+	}
+	return longest
+}
+
+// TestRateLimiter_MisbehavingNamespace load tests the isolation of a namespace whose 200 objects keep failing, each
+// after 200ms (e.g. a webhook which rejects every update), while the quiet namespace gets a new object every second.
+func TestRateLimiter_MisbehavingNamespace(t *testing.T) {
+	newSimulation := func(isolated bool) *simulation {
+		fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+		s := &simulation{
+			rateLimiter:   exponentialRateLimiter(),
+			clock:         fakeClock,
+			start:         fakeClock.Now(),
+			noisyObjects:  200,
+			noisyAttempt:  200 * time.Millisecond,
+			noisyFixedAt:  3 * time.Minute,
+			quietInterval: time.Second,
+			quietAttempt:  10 * time.Millisecond,
+			duration:      5 * time.Minute,
+		}
+		if isolated {
+			s.rateLimiter = NewWithClock(t.Name(), Config{
+				FailureThreshold:     DefaultFailureThreshold,
+				Window:               DefaultWindow,
+				DemotedRetryInterval: DefaultDemotedRetryInterval,
+			}, s.rateLimiter, fakeClock)
+		}
+		return s
+	}
+	// The first round of the noisy objects, and the retries before the namespace is demoted, are not measured.
+	warmUp := time.Minute
+
+	baseline := maxWait(newSimulation(false).run(warmUp))
+	isolatedSimulation := newSimulation(true)
+	isolated := maxWait(isolatedSimulation.run(warmUp))
+	t.Logf("longest wait of the quiet namespace: %v without isolation, %v with isolation", baseline, isolated)
+
+	if baseline < 10*time.Second {
+		t.Fatalf("longest wait without isolation = %v, want at least 10s; the simulation does not overload the worker", baseline)
+	}
+	// A quiet object waits at most for the noisy retry in progress, and the quiet object before it.
+	if isolated > time.Second {
+		t.Errorf("longest wait with isolation = %v, want at most 1s", isolated)
+	}
+
+	// The noisy namespace is fixed after 3 minutes, and is promoted once none of its objects has failed for the
+	// window.
+	r := isolatedSimulation.rateLimiter.(*RateLimiter)
+	if r.IsDemoted(noisyNamespace) {
+		t.Errorf("IsDemoted() = true after the noisy namespace has recovered, want false")
+	}
+	if r.IsDemoted(quietNamespace) {
+		t.Errorf("IsDemoted() = true for the quiet namespace, want false")
+	}
+}
@@ -14,6 +14,10 @@ const (
 
 // Finalizers
 const (
+	// ServiceExportFinalizer is the finalizer ServiceExport controllers adds to mark that a ServiceExport can only be
+	// deleted after its corresponding Service has been unexported from the hub cluster.
+	ServiceExportFinalizer = fleetNetworkingPrefix + "svc-export-cleanup"
+
 	// InternalServiceExportFinalizer is the finalizer InternalServiceExport controllers adds to mark that a
 	// InternalServiceExport can only be deleted after both ServiceImport label and ServiceExport conflict resolution
 	// result have been updated.
@@ -26,6 +30,11 @@ const (
 	// TrafficManagerBackendFinalizer a finalizer added by the TrafficManagerBackend controller to all trafficManagerBackends,
 	// to make sure that the controller can react to backend deletions if necessary.
 	TrafficManagerBackendFinalizer = fleetNetworkingPrefix + "traffic-manager-backend-cleanup"
+
+	// MemberNamespaceFinalizer is the finalizer the member namespace controller adds to the namespaces reserved for
+	// the member clusters in the hub cluster, so that a namespace is only gone after the objects of its member
+	// cluster have been removed from the rest of the fleet.
+	MemberNamespaceFinalizer = fleetNetworkingPrefix + "member-namespace-cleanup"
 )
 
 // Labels
@@ -33,6 +42,14 @@ const (
 	// MultiClusterServiceLabelDerivedService is the label added by the MCS controller, which marks the
 	// derived Service behind a MCS.
 	MultiClusterServiceLabelDerivedService = fleetNetworkingPrefix + "derived-service"
+
+	// InternalServiceExportLabelServiceVersion is the label added by the ServiceExport controller, which marks the
+	// version of the Service exported by an InternalServiceExport.
+	InternalServiceExportLabelServiceVersion = fleetNetworkingPrefix + "service-version"
+
+	// InternalServiceExportLabelMemberClusterID is the label added by the ServiceExport controller, which marks the
+	// member cluster an InternalServiceExport is exported from.
+	InternalServiceExportLabelMemberClusterID = fleetNetworkingPrefix + "member-cluster-id"
 )
 
 // Annotations
@@ -42,12 +59,78 @@ const (
 	ServiceImportAnnotationServiceInUseBy = fleetNetworkingPrefix + "service-in-use-by"
 
 	// ExportedObjectAnnotationUniqueName is an annotation that marks the fleet-scoped unique name assigned to
-	// an exported object.
+	// an exported object; the object is exported to the hub cluster under this name, e.g. as the EndpointSliceExport
+	// of an EndpointSlice.
 	ExportedObjectAnnotationUniqueName = fleetNetworkingPrefix + "fleet-unique-name"
 
 	// ServiceExportAnnotationWeight is an annotation that marks the weight of the ServiceExport.
 	ServiceExportAnnotationWeight = fleetNetworkingPrefix + "weight"
 
+	// ServiceExportAnnotationProgressiveExportSoakTime is an annotation that marks how long, as a Go duration string
+	// (e.g. "60s"), an endpoint of the exported Service must have been ready before it is exported to the fleet.
+	ServiceExportAnnotationProgressiveExportSoakTime = fleetNetworkingPrefix + "progressive-export-soak-time"
+
+	// ServiceExportAnnotationExportTTL is an annotation that marks how long, as a Go duration string (e.g. "24h"),
+	// a Service stays exported; the ServiceExport is deleted once the TTL expires.
+	ServiceExportAnnotationExportTTL = fleetNetworkingPrefix + "export-ttl"
+
+	// ServiceExportAnnotationExportedAt is an annotation that marks when, in the RFC 3339 format, a Service with an
+	// export TTL was first exported.
+	ServiceExportAnnotationExportedAt = fleetNetworkingPrefix + "exported-at"
+
+	// EndpointSliceAnnotationLastExportTime is an annotation that marks when, in the RFC 3339 format, the
+	// EndpointSliceExport of an EndpointSlice was last written to the hub cluster.
+	EndpointSliceAnnotationLastExportTime = fleetNetworkingPrefix + "last-export-time"
+
+	// EndpointSliceExportAnnotationLastSeen is an annotation that marks when, in the RFC 3339 format, the member
+	// agent last saw the EndpointSlice of an EndpointSliceExport; the hub agent deletes the EndpointSliceExports
+	// whose EndpointSlices have not been seen within its TTL.
+	EndpointSliceExportAnnotationLastSeen = fleetNetworkingPrefix + "last-seen"
+
+	// EndpointSliceAnnotationImportedFrom is an annotation that marks the EndpointSliceImport, as "namespace/name" in
+	// the hub cluster, an EndpointSlice of the member cluster is imported from; it identifies the imported
+	// EndpointSlices whose managed-by label has been stripped.
+	EndpointSliceAnnotationImportedFrom = fleetNetworkingPrefix + "imported-from"
+
+	// ServiceExportAnnotationSuspend is an annotation that marks, when set to "true", that the export of a Service
+	// is suspended; the Service is unexported from the fleet until the annotation is removed.
+	ServiceExportAnnotationSuspend = fleetNetworkingPrefix + "suspend"
+
+	// AzureTagAnnotationPrefix is the prefix of the annotations that define the tags of the Azure resources created
+	// for an object, e.g. "networking.fleet.azure.com/azure-tag-costCenter: 1234" tags the Azure Traffic Manager
+	// profile of a TrafficManagerProfile with "costCenter: 1234".
+	AzureTagAnnotationPrefix = fleetNetworkingPrefix + "azure-tag-"
+
+	// TrafficManagerProfileAnnotationEqualizeWeights is an annotation that asks, when set to "true", the
+	// TrafficManagerProfile controller to set the weights of all the endpoints of the Azure Traffic Manager profile to
+	// 1; the controller removes the annotation once the weights are equalized.
+	TrafficManagerProfileAnnotationEqualizeWeights = fleetNetworkingPrefix + "equalize-weights"
+
+	// InternalServiceExportAnnotationPreviousSpecHash is an annotation that marks the SHA256 hash of the spec an
+	// InternalServiceExport had before the exported Service last changed; it helps operators identify which change
+	// of the exported Service caused issues in the hub cluster.
+	InternalServiceExportAnnotationPreviousSpecHash = fleetNetworkingPrefix + "previous-spec-hash"
+
+	// ServiceAnnotationOpenAPISpec is an annotation that marks, when set to "true", that the API of the Service is
+	// described by an OpenAPI spec kept in the ConfigMap named "<service-name>-openapi" in the same namespace.
+	ServiceAnnotationOpenAPISpec = fleetNetworkingPrefix + "openapi-spec"
+
+	// InternalServiceExportAnnotationOpenAPISpecConfigMap is an annotation that marks the name of the ConfigMap which
+	// keeps the OpenAPI spec of the exported Service in the member cluster, in the same namespace as the Service.
+	InternalServiceExportAnnotationOpenAPISpecConfigMap = fleetNetworkingPrefix + "openapi-spec-configmap"
+
+	// InternalServiceExportAnnotationOpenAPISpecHash is an annotation that marks the SHA256 hash of the data of the
+	// ConfigMap which keeps the OpenAPI spec of the exported Service, so that the hub cluster can tell when it changes.
+	InternalServiceExportAnnotationOpenAPISpecHash = fleetNetworkingPrefix + "openapi-spec-hash"
+
+	// ServiceImportAnnotationIncludeLocalEndpoints is an annotation the MCS controller adds, set to "true", to the
+	// ServiceImports of the MultiClusterServices which import the endpoints their member cluster exports itself.
+	ServiceImportAnnotationIncludeLocalEndpoints = fleetNetworkingPrefix + "include-local-endpoints"
+
+	// ServiceImportAnnotationOpenAPISpecCatalog is the key of the OpenAPISpecCatalog annotation, which marks where
+	// the member clusters exporting a Service keep the OpenAPI specs of its API.
+	ServiceImportAnnotationOpenAPISpecCatalog = fleetNetworkingPrefix + "openapi-spec-catalog"
+
 	// ServiceAnnotationAzureLoadBalancerInternal is an annotation that marks the Service as an internal load balancer by cloud-provider-azure.
 	ServiceAnnotationAzureLoadBalancerInternal = "service.beta.kubernetes.io/azure-load-balancer-internal"
 
@@ -62,6 +145,14 @@ const (
 	ServiceAnnotationAzureDNSLabelName = "service.beta.kubernetes.io/azure-dns-label-name"
 )
 
+// Field indexes
+const (
+	// InternalServiceExportFieldServiceNamespacedName is the field index of InternalServiceExports by the namespaced
+	// name of the Service they export, in the format NAMESPACE/NAME. The ServiceImport controller, or the
+	// TrafficManagerBackend controller when the former does not run, registers it with the hub controller manager.
+	InternalServiceExportFieldServiceNamespacedName = ".spec.serviceReference.namespacedName"
+)
+
 // Azure Resource Tags
 var (
 	// AzureTrafficManagerProfileTagKey is the key of the Azure Traffic Manager profile tag when the controller creates it.
@@ -0,0 +1,82 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package retrybudget
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// RateLimiter is the workqueue rate limiter of a controller which writes to the hub cluster: the requests whose
+// writes have failed after the budget is depleted are retried once the delay the budget asks has passed, or after the
+// regular backoff of the wrapped rate limiter, whichever is longer.
+//
+// The reconciler reports the failed writes with Failed and returns their errors as usual, so that the failures are
+// still logged and counted by the controller.
+//
+// It is safe for concurrent use.
+type RateLimiter struct {
+	workqueue.TypedRateLimiter[reconcile.Request]
+
+	budget *Budget
+
+	mu         sync.Mutex
+	retryAfter map[reconcile.Request]time.Duration
+}
+
+// NewRateLimiter returns a RateLimiter which spends the budget and wraps the given rate limiter, or the default
+// controller rate limiter if it is nil. The retries are never delayed by the budget if the budget is nil.
+func NewRateLimiter(budget *Budget, limiter workqueue.TypedRateLimiter[reconcile.Request]) *RateLimiter {
+	if limiter == nil {
+		limiter = workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]()
+	}
+	return &RateLimiter{
+		TypedRateLimiter: limiter,
+		budget:           budget,
+		retryAfter:       map[reconcile.Request]time.Duration{},
+	}
+}
+
+// Failed spends the budget for a failed write to the hub cluster made when reconciling the request and returns the
+// error for the reconciler to return; once the budget is depleted, the retry of the request is delayed by the time
+// the budget asks. Errors which are not budgeted are returned as is.
+func (l *RateLimiter) Failed(req reconcile.Request, err error) error {
+	if l.budget == nil || !IsBudgeted(err) {
+		return err
+	}
+	if retryAfter := l.budget.RetryAfter(); retryAfter > 0 {
+		klog.V(2).InfoS("Retry budget for writes to the hub cluster is depleted; delay the retry", "request", req, "retryAfter", retryAfter, "error", err)
+		l.mu.Lock()
+		l.retryAfter[req] = retryAfter
+		l.mu.Unlock()
+	}
+	return err
+}
+
+// When returns how long the request waits before it is retried.
+func (l *RateLimiter) When(req reconcile.Request) time.Duration {
+	delay := l.TypedRateLimiter.When(req)
+	l.mu.Lock()
+	retryAfter := l.retryAfter[req]
+	delete(l.retryAfter, req)
+	l.mu.Unlock()
+	if retryAfter > delay {
+		return retryAfter
+	}
+	return delay
+}
+
+// Forget forgets the failures of the request, e.g. once it is reconciled successfully.
+func (l *RateLimiter) Forget(req reconcile.Request) {
+	l.TypedRateLimiter.Forget(req)
+	l.mu.Lock()
+	delete(l.retryAfter, req)
+	l.mu.Unlock()
+}
@@ -0,0 +1,66 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package retrybudget
+
+import (
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRateLimiter(t *testing.T) {
+	backoff := 10 * time.Millisecond
+	l := NewRateLimiter(NewWithClock(1, 1, clocktesting.NewFakePassiveClock(time.Now())),
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](backoff, backoff))
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "work", Name: "app"}}
+	tooManyRequests := apierrors.NewTooManyRequests("the server has received too many requests", 1)
+
+	// The errors are always returned.
+	if err := l.Failed(req, tooManyRequests); err != tooManyRequests {
+		t.Fatalf("Failed() = %v, want %v", err, tooManyRequests)
+	}
+	// The first failure spends the last token and is retried with the regular backoff.
+	if got := l.When(req); got != backoff {
+		t.Errorf("When() = %v, want %v", got, backoff)
+	}
+	// The failures after the budget is depleted wait for the budget.
+	for _, want := range []time.Duration{time.Second, 2 * time.Second} {
+		if err := l.Failed(req, tooManyRequests); err != tooManyRequests {
+			t.Fatalf("Failed() = %v, want %v", err, tooManyRequests)
+		}
+		if got := l.When(req); got != want {
+			t.Errorf("When() = %v, want %v", got, want)
+		}
+	}
+	// The errors caused by the state of the object do not spend the budget.
+	conflict := apierrors.NewConflict(schema.GroupResource{Resource: "internalserviceexports"}, "app", nil)
+	if err := l.Failed(req, conflict); err != conflict {
+		t.Fatalf("Failed() = %v, want %v", err, conflict)
+	}
+	if got := l.When(req); got != backoff {
+		t.Errorf("When() after a conflict = %v, want %v", got, backoff)
+	}
+}
+
+func TestRateLimiter_NoBudget(t *testing.T) {
+	l := NewRateLimiter(nil, nil)
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "work", Name: "app"}}
+	tooManyRequests := apierrors.NewTooManyRequests("the server has received too many requests", 1)
+	for i := 0; i < 200; i++ {
+		if err := l.Failed(req, tooManyRequests); err != tooManyRequests {
+			t.Fatalf("Failed() = %v, want %v", err, tooManyRequests)
+		}
+	}
+	if got, max := l.When(req), time.Second; got > max {
+		t.Errorf("When() = %v, want no longer than %v", got, max)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package retrybudget features a simple in-memory token-bucket budget which the controllers of a member cluster
+// share for retrying failed writes to the hub cluster, so that they back off together when the hub API server is
+// under pressure rather than each retrying on its own.
+package retrybudget
+
+import (
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// MaxRetryAfter is the longest delay the budget asks a retry to wait.
+	MaxRetryAfter = 5 * time.Minute
+
+	// DefaultRate is the default number of tokens the budget regains per second.
+	DefaultRate = 10
+	// DefaultBurst is the default number of tokens the budget holds when it is full.
+	DefaultBurst = 100
+)
+
+// Budget is a token bucket which holds up to burst tokens and regains rate tokens per second. Every retry spends a
+// token; retries made while the bucket has tokens left proceed right away, while those made after the bucket is
+// depleted are delayed until the bucket regains their tokens, i.e. the delays grow with every failure until the
+// failures slow down.
+//
+// It is safe for concurrent use.
+type Budget struct {
+	rate  float64
+	burst float64
+	clock clock.PassiveClock
+
+	mu sync.Mutex
+	// tokens is the number of tokens in the bucket at lastUpdated; it is negative when the tokens of the delayed
+	// retries have been spent in advance.
+	tokens      float64
+	lastUpdated time.Time
+}
+
+// New returns a full Budget which holds up to burst tokens and regains rate tokens per second.
+func New(rate float64, burst int) *Budget {
+	return NewWithClock(rate, burst, clock.RealClock{})
+}
+
+// NewWithClock returns a Budget which uses the given clock; it is mostly used in tests.
+func NewWithClock(rate float64, burst int, clock clock.PassiveClock) *Budget {
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Budget{
+		rate:        rate,
+		burst:       float64(burst),
+		clock:       clock,
+		tokens:      float64(burst),
+		lastUpdated: clock.Now(),
+	}
+}
+
+// RetryAfter spends a token for retrying a failed write and returns how long the retry should wait; it returns
+// zero while the budget has tokens left, in which case the retry follows the regular backoff of the caller.
+//
+// The delay never exceeds MaxRetryAfter; retries which would have to wait longer do not spend a token.
+func (b *Budget) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	retryAfter := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if retryAfter > MaxRetryAfter {
+		return MaxRetryAfter
+	}
+	b.tokens--
+	return retryAfter
+}
+
+// SetLimits changes the rate and the burst of the budget, e.g. when the configuration is reloaded; the tokens
+// regained so far are kept, up to the new burst. Like New, it falls back to DefaultRate if rate is not positive.
+func (b *Budget) SetLimits(rate float64, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	b.rate = rate
+	b.burst = float64(burst)
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// refill adds the tokens regained since the last update, up to the burst; the caller must hold the lock.
+func (b *Budget) refill() {
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.lastUpdated).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.lastUpdated = now
+}
+
+// IsBudgeted returns whether a failed write to the hub cluster should spend the retry budget. Errors caused by the
+// state of a specific object, e.g. NotFound, AlreadyExists and Conflict, say nothing about the pressure on the hub
+// API server and are retried as usual.
+func IsBudgeted(err error) bool {
+	return err != nil && !apierrors.IsNotFound(err) && !apierrors.IsAlreadyExists(err) && !apierrors.IsConflict(err)
+}
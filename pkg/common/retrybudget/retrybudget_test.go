@@ -0,0 +1,133 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package retrybudget
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRetryAfter(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	b := NewWithClock(1, 2, fakeClock)
+
+	// Retries proceed right away while the budget has tokens left.
+	for i := 0; i < 2; i++ {
+		if got := b.RetryAfter(); got != 0 {
+			t.Fatalf("RetryAfter() = %v after %d retries, want 0", got, i)
+		}
+	}
+
+	// The delays grow as the budget depletes.
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if got := b.RetryAfter(); got != want {
+			t.Fatalf("RetryAfter() = %v after %d retries of a depleted budget, want %v", got, i, want)
+		}
+	}
+
+	// The budget refills at its rate, up to its burst.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+	for i := 0; i < 2; i++ {
+		if got := b.RetryAfter(); got != 0 {
+			t.Fatalf("RetryAfter() = %v after the budget refills, want 0", got)
+		}
+	}
+	if got := b.RetryAfter(); got != time.Second {
+		t.Fatalf("RetryAfter() = %v after the refilled budget is depleted again, want %v", got, time.Second)
+	}
+}
+
+func TestRetryAfter_Max(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	b := NewWithClock(0.01, 1, fakeClock)
+
+	if got := b.RetryAfter(); got != 0 {
+		t.Fatalf("RetryAfter() = %v, want 0", got)
+	}
+	// The fourth retry would have to wait 400 seconds without the cap.
+	for _, want := range []time.Duration{100 * time.Second, 200 * time.Second, MaxRetryAfter, MaxRetryAfter} {
+		if got := b.RetryAfter(); got != want {
+			t.Fatalf("RetryAfter() = %v, want %v", got, want)
+		}
+	}
+	// Capped retries do not spend tokens, so the budget recovers as soon as the tokens spent are regained.
+	fakeClock.SetTime(fakeClock.Now().Add(400 * time.Second))
+	if got := b.RetryAfter(); got != 0 {
+		t.Fatalf("RetryAfter() = %v after the budget refills, want 0", got)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	b := NewWithClock(1, 4, fakeClock)
+
+	// The tokens left are capped by the lower burst.
+	b.SetLimits(2, 1)
+	if got := b.RetryAfter(); got != 0 {
+		t.Fatalf("RetryAfter() = %v, want 0", got)
+	}
+	// The depleted budget refills at the new rate.
+	if got, want := b.RetryAfter(), 500*time.Millisecond; got != want {
+		t.Fatalf("RetryAfter() = %v after the burst is lowered, want %v", got, want)
+	}
+
+	// The tokens regained at the old rate are kept when the burst is raised.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Hour))
+	b.SetLimits(1, 3)
+	if got := b.RetryAfter(); got != 0 {
+		t.Fatalf("RetryAfter() = %v after the budget refills, want 0", got)
+	}
+	if got, want := b.RetryAfter(), time.Second; got != want {
+		t.Fatalf("RetryAfter() = %v after the burst is raised, want %v", got, want)
+	}
+}
+
+func TestIsBudgeted(t *testing.T) {
+	gr := schema.GroupResource{Group: "networking.fleet.azure.com", Resource: "internalserviceexports"}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "no error",
+		},
+		{
+			name: "not found",
+			err:  apierrors.NewNotFound(gr, "app"),
+		},
+		{
+			name: "already exists",
+			err:  apierrors.NewAlreadyExists(gr, "app"),
+		},
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(gr, "app", errors.New("the object has been modified")),
+		},
+		{
+			name: "too many requests",
+			err:  apierrors.NewTooManyRequests("the server has received too many requests", 1),
+			want: true,
+		},
+		{
+			name: "server timeout",
+			err:  apierrors.NewServerTimeout(gr, "update", 1),
+			want: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsBudgeted(tc.err); got != tc.want {
+				t.Errorf("IsBudgeted() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
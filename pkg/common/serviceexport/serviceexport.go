@@ -0,0 +1,22 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package serviceexport features the helpers shared by the controllers handling ServiceExports.
+package serviceexport
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// IsSvcExportCleanupNeeded returns true if the ServiceExport has been deleted and its Service has to be unexported
+// from the hub cluster before the ServiceExport is gone.
+// A ServiceExport needs cleanup when it has the cleanup finalizer, e.g. objectmeta.ServiceExportFinalizer, added; the
+// absence of this finalizer guarantees that the corresponding Service has never been exported to the fleet, thus no
+// action is needed.
+func IsSvcExportCleanupNeeded(svcExport *fleetnetv1alpha1.ServiceExport, finalizer string) bool {
+	return svcExport.DeletionTimestamp != nil && controllerutil.ContainsFinalizer(svcExport, finalizer)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+func TestIsSvcExportCleanupNeeded(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+	tests := []struct {
+		name              string
+		deletionTimestamp *metav1.Time
+		finalizers        []string
+		// cleanupFinalizer defaults to objectmeta.ServiceExportFinalizer.
+		cleanupFinalizer string
+		want             bool
+	}{
+		{
+			name:              "deleted with the cleanup finalizer",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{objectmeta.ServiceExportFinalizer},
+			want:              true,
+		},
+		{
+			name:              "deleted without the cleanup finalizer",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{"example.com/other-finalizer"},
+		},
+		{
+			name:       "not deleted with the cleanup finalizer",
+			finalizers: []string{objectmeta.ServiceExportFinalizer},
+		},
+		{
+			name:              "deleted with the cleanup finalizer and another finalizer",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{"example.com/other-finalizer", objectmeta.ServiceExportFinalizer},
+			want:              true,
+		},
+		{
+			name:              "deleted with the cleanup finalizer added twice",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{objectmeta.ServiceExportFinalizer, objectmeta.ServiceExportFinalizer},
+			want:              true,
+		},
+		{
+			name: "not deleted without finalizers",
+		},
+		{
+			name:              "deleted with a custom cleanup finalizer",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{"example.com/svc-export-cleanup"},
+			cleanupFinalizer:  "example.com/svc-export-cleanup",
+			want:              true,
+		},
+		{
+			name:              "deleted with the cleanup finalizer of another installation",
+			deletionTimestamp: &deletionTimestamp,
+			finalizers:        []string{objectmeta.ServiceExportFinalizer},
+			cleanupFinalizer:  "example.com/svc-export-cleanup",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "work",
+					Name:              "app",
+					DeletionTimestamp: tc.deletionTimestamp,
+					Finalizers:        tc.finalizers,
+				},
+			}
+			cleanupFinalizer := tc.cleanupFinalizer
+			if cleanupFinalizer == "" {
+				cleanupFinalizer = objectmeta.ServiceExportFinalizer
+			}
+			if got := IsSvcExportCleanupNeeded(svcExport, cleanupFinalizer); got != tc.want {
+				t.Errorf("IsSvcExportCleanupNeeded() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
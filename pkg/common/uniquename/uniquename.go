@@ -129,7 +129,17 @@ func ClusterScopedUniqueName(format Format, namespace, name string) (string, err
 //   - the input object name follows one of the three formats used in Kubernetes (RFC 1123 DNS subdomain,
 //     RFC 1123 DNS label, RFC 1035 DNS label).
 func FleetScopedUniqueName(format Format, clusterID, namespace, name string) (string, error) {
-	reservedSlots := 3 + uuidLength // 3 dashes + 5 character UUID string
+	return FleetScopedUniqueNameWithSuffix(format, clusterID, namespace, name, string(uuid.NewUUID()[:uuidLength]))
+}
+
+// FleetScopedUniqueNameWithSuffix is like FleetScopedUniqueName, but it ends the name with the given 5 character
+// suffix rather than a random one, so that callers can derive the same name for the same object again; the suffix
+// must consist of lower case alphanumeric characters.
+func FleetScopedUniqueNameWithSuffix(format Format, clusterID, namespace, name, suffix string) (string, error) {
+	if len(suffix) != uuidLength {
+		return "", fmt.Errorf("suffix %q is not %d characters long", suffix, uuidLength)
+	}
+	reservedSlots := 3 + uuidLength // 3 dashes + 5 character suffix
 
 	switch format {
 	case DNS1123Subdomain:
@@ -139,7 +149,7 @@ func FleetScopedUniqueName(format Format, clusterID, namespace, name string) (st
 			clusterID[:minInt(slotsPerSeg, len(clusterID))],
 			namespace[:minInt(slotsPerSeg, len(namespace))],
 			name[:minInt(slotsPerSeg, len(name))],
-			uuid.NewUUID()[:uuidLength],
+			suffix,
 		)
 
 		if errs := validation.IsDNS1123Subdomain(uniqueName); len(errs) != 0 {
@@ -160,7 +170,7 @@ func FleetScopedUniqueName(format Format, clusterID, namespace, name string) (st
 			clusterID[:minInt(slotsPerSeg, len(clusterID))],
 			namespace[:minInt(slotsPerSeg, len(namespace))],
 			name[:minInt(slotsPerSeg, len(name))],
-			uuid.NewUUID()[:uuidLength],
+			suffix,
 		)
 
 		if errs := validation.IsDNS1123Label(uniqueName); len(errs) != 0 {
@@ -181,7 +191,7 @@ func FleetScopedUniqueName(format Format, clusterID, namespace, name string) (st
 			clusterID[:minInt(slotsPerSeg, len(clusterID))],
 			namespace[:minInt(slotsPerSeg, len(namespace))],
 			name[:minInt(slotsPerSeg, len(name))],
-			uuid.NewUUID()[:uuidLength],
+			suffix,
 		)
 
 		if errs := validation.IsDNS1035Label(uniqueName); len(errs) != 0 {
@@ -257,6 +257,39 @@ func TestFleetScopedUniqueName(t *testing.T) {
 	}
 }
 
+// TestFleetScopedUniqueNameWithSuffix tests the FleetScopedUniqueNameWithSuffix function.
+func TestFleetScopedUniqueNameWithSuffix(t *testing.T) {
+	testCases := []struct {
+		name    string
+		suffix  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "should end with the given suffix",
+			suffix: "1x2yz",
+			want:   "bravelion-work-app-1x2yz",
+		},
+		{
+			name:    "should reject a suffix of another length",
+			suffix:  "1x2y",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := FleetScopedUniqueNameWithSuffix(DNS1123Subdomain, "bravelion", "work", "app", tc.suffix)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("FleetScopedUniqueNameWithSuffix(%s) = %v, want error %t", tc.suffix, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("FleetScopedUniqueNameWithSuffix(%s) = %q, want %q", tc.suffix, got, tc.want)
+			}
+		})
+	}
+}
+
 // TestRandomLowerCaseAlphabeticString tests the RandomLowerCaseAlphabeticString function.
 func TestRandomLowerCaseAlphabeticString(t *testing.T) {
 	testCases := []struct {
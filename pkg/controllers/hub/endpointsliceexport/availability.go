@@ -0,0 +1,97 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+)
+
+const (
+	conditionReasonEndpointsAvailable = "EndpointsAvailable"
+	conditionReasonNoEndpoints        = "NoEndpoints"
+)
+
+// updateEndpointsAvailableConditionOfOwnerSvc sets the EndpointsAvailable condition of the ServiceImport of the
+// Service owning an EndpointSliceExport, if the ServiceImport exists and has accepted exports.
+func (r *Reconciler) updateEndpointsAvailableConditionOfOwnerSvc(ctx context.Context, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) error {
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	svcImportKey := types.NamespacedName{
+		Namespace: endpointSliceExport.Spec.OwnerServiceReference.Namespace,
+		Name:      endpointSliceExport.Spec.OwnerServiceReference.Name,
+	}
+	if err := r.HubClient.Get(ctx, svcImportKey, svcImport); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if len(svcImport.Status.Clusters) == 0 {
+		return nil
+	}
+	return r.updateEndpointsAvailableCondition(ctx, svcImport)
+}
+
+// updateEndpointsAvailableCondition sets the EndpointsAvailable condition of a ServiceImport based on the ready
+// endpoints of all the EndpointSliceExports of the Service, across the exporting clusters.
+//
+// A Service scaled to zero keeps its EndpointSliceExports, without endpoints, so that the importing clusters converge
+// to zero endpoints; the condition is false then, telling it apart from a Service whose endpoints are distributed.
+func (r *Reconciler) updateEndpointsAvailableCondition(ctx context.Context, svcImport *fleetnetv1alpha1.ServiceImport) error {
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	fieldMatcher := client.MatchingFields{
+		endpointSliceExportOwnerSvcNamespacedNameFieldKey: fmt.Sprintf("%s/%s", svcImport.Namespace, svcImport.Name),
+	}
+	if err := r.HubClient.List(ctx, endpointSliceExportList, fieldMatcher); err != nil {
+		return err
+	}
+	readyCount, sliceCount := 0, 0
+	for _, endpointSliceExport := range endpointSliceExportList.Items {
+		if endpointSliceExport.DeletionTimestamp != nil {
+			continue
+		}
+		sliceCount++
+		for _, endpoint := range endpointSliceExport.Spec.Endpoints {
+			if endpoint.Ready == nil || *endpoint.Ready {
+				readyCount++
+			}
+		}
+	}
+
+	desiredCond := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonEndpointsAvailable,
+		ObservedGeneration: svcImport.Generation,
+		Message:            fmt.Sprintf("%d ready endpoints are exported in %d endpoint slices", readyCount, sliceCount),
+	}
+	if readyCount == 0 {
+		desiredCond.Status = metav1.ConditionFalse
+		desiredCond.Reason = conditionReasonNoEndpoints
+		desiredCond.Message = fmt.Sprintf("None of the %d exported endpoint slices has a ready endpoint; the service may be scaled to zero", sliceCount)
+	}
+	currentCond := meta.FindStatusCondition(svcImport.Status.Conditions, desiredCond.Type)
+	// The message is compared as well, as it contains the endpoint counts.
+	if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
+		return nil
+	}
+	klog.V(2).InfoS("Updating the endpoints available condition of the serviceImport",
+		"serviceImport", klog.KObj(svcImport),
+		"readyEndpoints", readyCount,
+		"endpointSlices", sliceCount)
+	meta.SetStatusCondition(&svcImport.Status.Conditions, desiredCond)
+	if err := r.HubClient.Status().Update(ctx, svcImport); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// TestReconcile_ScaleToZero tests that the EndpointSliceExport of a Service scaled to zero, and back up, is
+// distributed as is, so that no stale endpoint is left in the importing cluster, and that the ServiceImport reports
+// whether the Service has endpoints available.
+func TestReconcile_ScaleToZero(t *testing.T) {
+	ctx := context.Background()
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(freshnessTestObjects(t, time.Now())...).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameFieldKey, endpointSliceExportIndexerFunc).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceImport{}, endpointSliceImportNameFieldKey, endpointSliceImportIndexerFunc).
+		Build()
+	r := &Reconciler{HubClient: fakeHubClient}
+	ignoreLastTransitionTime := cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
+
+	// scale exports the given endpoints, distributes them, and checks the endpoints imported by member B and the
+	// endpoints available condition of the ServiceImport.
+	scale := func(endpoints []fleetnetv1alpha1.Endpoint, wantCond metav1.Condition) {
+		t.Helper()
+		endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{}
+		if err := fakeHubClient.Get(ctx, endpointSliceExportKey, endpointSliceExport); err != nil {
+			t.Fatalf("EndpointSliceExport Get() = %v, want no error", err)
+		}
+		endpointSliceExport.Spec.Endpoints = endpoints
+		if err := fakeHubClient.Update(ctx, endpointSliceExport); err != nil {
+			t.Fatalf("EndpointSliceExport Update() = %v, want no error", err)
+		}
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceExportKey}); err != nil {
+			t.Fatalf("Reconcile() = %v, want no error", err)
+		}
+
+		endpointSliceImport := &fleetnetv1alpha1.EndpointSliceImport{}
+		endpointSliceImportKey := types.NamespacedName{Namespace: hubNSForMemberB, Name: endpointSliceExportName}
+		if err := fakeHubClient.Get(ctx, endpointSliceImportKey, endpointSliceImport); err != nil {
+			t.Fatalf("EndpointSliceImport Get() = %v, want no error", err)
+		}
+		if diff := cmp.Diff(endpoints, endpointSliceImport.Spec.Endpoints); diff != "" {
+			t.Errorf("imported endpoints mismatch (-want, +got):\n%s", diff)
+		}
+		svcImport := &fleetnetv1alpha1.ServiceImport{}
+		if err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, svcImport); err != nil {
+			t.Fatalf("ServiceImport Get() = %v, want no error", err)
+		}
+		gotCond := meta.FindStatusCondition(svcImport.Status.Conditions, string(fleetnetv1alpha1.ServiceImportEndpointsAvailable))
+		if diff := cmp.Diff(&wantCond, gotCond, ignoreLastTransitionTime); diff != "" {
+			t.Errorf("endpoints available condition mismatch (-want, +got):\n%s", diff)
+		}
+	}
+
+	scale(ipv4EndpointSliceExport().Spec.Endpoints, metav1.Condition{
+		Type:    string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionReasonEndpointsAvailable,
+		Message: "2 ready endpoints are exported in 1 endpoint slices",
+	})
+	// The Service is scaled to zero; its EndpointSlice is still exported, without endpoints.
+	scale([]fleetnetv1alpha1.Endpoint{}, metav1.Condition{
+		Type:    string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonNoEndpoints,
+		Message: "None of the 1 exported endpoint slices has a ready endpoint; the service may be scaled to zero",
+	})
+	// The Service is scaled back up, to an endpoint it has not had before.
+	scale([]fleetnetv1alpha1.Endpoint{{Addresses: []string{"3.4.5.6"}}}, metav1.Condition{
+		Type:    string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionReasonEndpointsAvailable,
+		Message: "1 ready endpoints are exported in 1 endpoint slices",
+	})
+}
+
+// TestReconcile_DeletedEndpointSliceExportConditionUpdateFailure tests that the EndpointsAvailable condition of a
+// ServiceImport is updated eventually when its last EndpointSliceExport is deleted, even if the first attempt to
+// update the condition fails.
+func TestReconcile_DeletedEndpointSliceExportConditionUpdateFailure(t *testing.T) {
+	ctx := context.Background()
+	failStatusUpdate := false
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(freshnessTestObjects(t, time.Now())...).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameFieldKey, endpointSliceExportIndexerFunc).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceImport{}, endpointSliceImportNameFieldKey, endpointSliceImportIndexerFunc).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if failStatusUpdate {
+					failStatusUpdate = false
+					return fmt.Errorf("status update failure")
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	r := &Reconciler{HubClient: fakeHubClient}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceExportKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{}
+	if err := fakeHubClient.Get(ctx, endpointSliceExportKey, endpointSliceExport); err != nil {
+		t.Fatalf("EndpointSliceExport Get() = %v, want no error", err)
+	}
+	if err := fakeHubClient.Delete(ctx, endpointSliceExport); err != nil {
+		t.Fatalf("EndpointSliceExport Delete() = %v, want no error", err)
+	}
+
+	failStatusUpdate = true
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceExportKey}); err == nil {
+		t.Fatalf("Reconcile() with a failed status update = nil, want error")
+	}
+	// The cleanup finalizer is kept, so that the update of the condition is retried.
+	if err := fakeHubClient.Get(ctx, endpointSliceExportKey, endpointSliceExport); err != nil {
+		t.Fatalf("EndpointSliceExport Get() = %v, want no error", err)
+	}
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceExportKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if err := fakeHubClient.Get(ctx, endpointSliceExportKey, endpointSliceExport); !errors.IsNotFound(err) {
+		t.Errorf("EndpointSliceExport Get() = %v, want not found error", err)
+	}
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	if err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, svcImport); err != nil {
+		t.Fatalf("ServiceImport Get() = %v, want no error", err)
+	}
+	wantCond := &metav1.Condition{
+		Type:    string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonNoEndpoints,
+		Message: "None of the 0 exported endpoint slices has a ready endpoint; the service may be scaled to zero",
+	}
+	gotCond := meta.FindStatusCondition(svcImport.Status.Conditions, string(fleetnetv1alpha1.ServiceImportEndpointsAvailable))
+	if diff := cmp.Diff(wantCond, gotCond, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Errorf("endpoints available condition mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -25,6 +26,7 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/apiretry"
+	"go.goms.io/fleet-networking/pkg/common/freshness"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
@@ -58,11 +60,17 @@ var (
 // Reconciler reconciles the distribution of EndpointSlices across the fleet.
 type Reconciler struct {
 	HubClient client.Client
+	// Freshness tracks how long the endpoint changes of exported Services take to be distributed; the freshness of
+	// exported Services is not measured if it is nil.
+	Freshness *freshness.Tracker
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceimports,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceexports/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;create;update;patch;delete;list;watch
 
 // Reconcile distributes an exported EndpointSlice (in the form of EndpointSliceExports) to whichever member
@@ -94,6 +102,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// Check if the EndpointSliceExport has been marked for deletion; withdraw EndpointSliceImports across
 	// the fleet if the EndpointSlice has been distributed.
 	if endpointSliceExport.DeletionTimestamp != nil {
+		// The endpoints of the deleted EndpointSliceExport no longer count towards the availability of the Service.
+		// The condition must be updated before the cleanup finalizer is removed; otherwise the EndpointSliceExport
+		// may be gone before a failed update gets retried, leaving a stale condition behind.
+		if err := r.updateEndpointsAvailableConditionOfOwnerSvc(ctx, endpointSliceExport); err != nil {
+			klog.ErrorS(err, "Failed to update the endpoints available condition of ServiceImport",
+				"serviceImport", klog.KRef(endpointSliceExport.Spec.OwnerServiceReference.Namespace, endpointSliceExport.Spec.OwnerServiceReference.Name),
+				"endpointSliceExport", endpointSliceExportRef)
+			return ctrl.Result{}, err
+		}
 		if controllerutil.ContainsFinalizer(endpointSliceExport, endpointSliceExportCleanupFinalizer) {
 			// The presence of the EndpointSliceExport cleanup finalizer guarantees that an attempt has been made
 			// to distribute the EndpointSlice.
@@ -101,7 +118,6 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			if err := r.withdrawAllEndpointSliceImports(ctx, endpointSliceExport); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, nil
 	}
@@ -140,6 +156,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{RequeueAfter: endpointSliceExportRetryInterval}, nil
 	}
 
+	// Report whether the Service has endpoints available, whether it is imported or not.
+	if err := r.updateEndpointsAvailableCondition(ctx, svcImport); err != nil {
+		klog.ErrorS(err, "Failed to update the endpoints available condition of ServiceImport",
+			"serviceImport", svcImportRef,
+			"endpointSliceExport", endpointSliceExportRef)
+		return ctrl.Result{}, err
+	}
+
 	data, ok := svcImport.ObjectMeta.Annotations[objectmeta.ServiceImportAnnotationServiceInUseBy]
 	if !ok {
 		// No cluster has requested to import the EndpointSlice's owner service.
@@ -153,7 +177,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 		// There is no need to remove the local EndpointSlice copy in this situation (the copy might be in
 		// use by load balancing solutions on the hub cluster).
-		return ctrl.Result{}, nil
+		return r.reportFreshness(ctx, endpointSliceExport, false)
 	}
 
 	svcInUseBy := &fleetnetv1alpha1.ServiceInUseBy{}
@@ -166,6 +190,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		// data is overwritten.
 		return ctrl.Result{}, nil
 	}
+	// The EndpointSlices exported from a member cluster are not distributed back to the same member cluster, unless
+	// it has asked for its local endpoints; any EndpointSliceImport distributed to it before is withdrawn below.
+	excludeExportingCluster(svcInUseBy, endpointSliceExport)
 
 	// Distribute the EndpointSlices.
 
@@ -219,6 +246,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// len(endpointSliceImportsToCreateOrUpdate) is at most 1. However, this behavior is subject to change as fleet
 	// networking evolves, and for future compatibility reasons, the function assumes that a Service might have been
 	// imported to multiple clusters.
+	changeDistributed := false
 	for idx := range endpointSlicesImportsToCreateOrUpdate {
 		endpointSliceImport := endpointSlicesImportsToCreateOrUpdate[idx]
 		klog.V(4).InfoS("Create/update endpointSliceImport",
@@ -226,9 +254,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			"endpointSliceExport", endpointSliceExportRef)
 
 		var op controllerutil.OperationResult
+		var distributedSince metav1.Time
 		if err := apiretry.Do(func() error {
 			var createOrUpdateErr error
 			op, createOrUpdateErr = controllerutil.CreateOrUpdate(ctx, r.HubClient, endpointSliceImport, func() error {
+				distributedSince = endpointSliceImport.Spec.EndpointSliceReference.ExportedSince
 				endpointSliceImport.Spec = *endpointSliceExport.Spec.DeepCopy()
 				return nil
 			})
@@ -240,9 +270,15 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 				"op", op)
 			return ctrl.Result{}, err
 		}
+		// Only the updates which bring a change of the exported EndpointSlice to an importing cluster are measured;
+		// EndpointSliceImports are also created when a cluster starts to import the Service, which says nothing
+		// about how fast the change has been distributed.
+		if op == controllerutil.OperationResultUpdated && !distributedSince.Equal(&endpointSliceExport.Spec.EndpointSliceReference.ExportedSince) {
+			changeDistributed = true
+		}
 	}
 
-	return ctrl.Result{}, nil
+	return r.reportFreshness(ctx, endpointSliceExport, changeDistributed)
 }
 
 // SetupWithManager sets up the EndpointSliceExport controller with a controller manager.
@@ -298,7 +334,7 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) err
 	})
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&fleetnetv1alpha1.EndpointSliceExport{}).
+		For(&fleetnetv1alpha1.EndpointSliceExport{}, builder.WithPredicates(heartbeatOnlyUpdatePredicate)).
 		Watches(&fleetnetv1alpha1.ServiceImport{}, eventHandlers).
 		Complete(r)
 }
@@ -405,3 +441,14 @@ func (r *Reconciler) scanForEndpointSliceImports(
 	}
 	return endpointSliceImportsToWithdraw, endpointSliceImportsToCreateOrUpdate, nil
 }
+
+// excludeExportingCluster removes the member cluster which exports the EndpointSlice from the member clusters that
+// have requested it, unless the member cluster imports the endpoints it exports itself.
+func excludeExportingCluster(svcInUseBy *fleetnetv1alpha1.ServiceInUseBy, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) {
+	exportingClusterID := fleetnetv1alpha1.ClusterID(endpointSliceExport.Spec.EndpointSliceReference.ClusterID)
+	for ns, clusterID := range svcInUseBy.MemberClusters {
+		if clusterID == exportingClusterID && !svcInUseBy.IncludeLocalEndpoints[ns] {
+			delete(svcInUseBy.MemberClusters, ns)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package endpointsliceexport
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -17,12 +18,15 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 const (
@@ -437,3 +441,245 @@ func TestScanForEndpointSliceImports(t *testing.T) {
 		})
 	}
 }
+
+// fanOutTestEndpointSliceExport returns an EndpointSliceExport of the Service exported by a member cluster.
+func fanOutTestEndpointSliceExport(hubNS, clusterID, name string) *fleetnetv1alpha1.EndpointSliceExport {
+	endpointSliceExport := ipv4EndpointSliceExport()
+	endpointSliceExport.Namespace = hubNS
+	endpointSliceExport.Name = name
+	endpointSliceExport.Finalizers = nil
+	endpointSliceExport.Spec.EndpointSliceReference.ClusterID = clusterID
+	return endpointSliceExport
+}
+
+// TestReconcile_FanOut tests that the EndpointSlices exported by member clusters A and B are distributed to the
+// importing member clusters A and C, kept up to date, and withdrawn when an export is deleted.
+func TestReconcile_FanOut(t *testing.T) {
+	endpointSliceExportNameA := "work-app-endpointslice-a"
+	endpointSliceExportNameB := "work-app-endpointslice-b"
+	endpointSliceImportKey := func(hubNS, name string) string {
+		return types.NamespacedName{Namespace: hubNS, Name: name}.String()
+	}
+
+	testCases := []struct {
+		name string
+		// includeLocalEndpoints marks the importing member clusters which include the endpoints they export.
+		includeLocalEndpoints map[fleetnetv1alpha1.ClusterNamespace]bool
+		// wantEndpointSliceImports are the addresses of the EndpointSliceImports, keyed by their namespaced names,
+		// after each export has been reconciled.
+		wantEndpointSliceImports map[string][]string
+		// wantEndpointSliceImportsAfterUpdate are the EndpointSliceImports after the export of member cluster B drops
+		// an endpoint.
+		wantEndpointSliceImportsAfterUpdate map[string][]string
+		// wantEndpointSliceImportsAfterDelete are the EndpointSliceImports after the export of member cluster A is
+		// deleted.
+		wantEndpointSliceImportsAfterDelete map[string][]string
+	}{
+		{
+			name: "should not distribute exports back to the exporting importer by default",
+			wantEndpointSliceImports: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr, altIPAddr},
+			},
+			wantEndpointSliceImportsAfterUpdate: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr},
+			},
+			wantEndpointSliceImportsAfterDelete: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr},
+			},
+		},
+		{
+			name:                  "should distribute every export to every importer including local endpoints",
+			includeLocalEndpoints: map[fleetnetv1alpha1.ClusterNamespace]bool{hubNSForMemberA: true},
+			wantEndpointSliceImports: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr, altIPAddr},
+			},
+			wantEndpointSliceImportsAfterUpdate: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameA): {ipAddr, altIPAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr},
+			},
+			wantEndpointSliceImportsAfterDelete: map[string][]string{
+				endpointSliceImportKey(hubNSForMemberA, endpointSliceExportNameB): {ipAddr},
+				endpointSliceImportKey(hubNSForMemberC, endpointSliceExportNameB): {ipAddr},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			svcInUseBy, err := json.Marshal(&fleetnetv1alpha1.ServiceInUseBy{
+				MemberClusters: map[fleetnetv1alpha1.ClusterNamespace]fleetnetv1alpha1.ClusterID{
+					hubNSForMemberA: clusterIDForMemberA,
+					hubNSForMemberC: clusterIDForMemberC,
+				},
+				IncludeLocalEndpoints: tc.includeLocalEndpoints,
+			})
+			if err != nil {
+				t.Fatalf("failed to marshal ServiceInUseBy: %v", err)
+			}
+			svcImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   memberUserNS,
+					Name:        svcName,
+					Annotations: map[string]string{objectmeta.ServiceImportAnnotationServiceInUseBy: string(svcInUseBy)},
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: clusterIDForMemberA}, {Cluster: clusterIDForMemberB}},
+				},
+			}
+			endpointSliceExportA := fanOutTestEndpointSliceExport(hubNSForMemberA, clusterIDForMemberA, endpointSliceExportNameA)
+			endpointSliceExportB := fanOutTestEndpointSliceExport(hubNSForMemberB, clusterIDForMemberB, endpointSliceExportNameB)
+			// The EndpointSlice of member cluster A has been distributed back to member cluster A before.
+			endpointSliceImport := &fleetnetv1alpha1.EndpointSliceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: hubNSForMemberA,
+					Name:      endpointSliceExportNameA,
+				},
+				Spec: *endpointSliceExportA.Spec.DeepCopy(),
+			}
+			fakeHubClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcImport, endpointSliceExportA, endpointSliceExportB, endpointSliceImport).
+				WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}).
+				WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameFieldKey, endpointSliceExportIndexerFunc).
+				WithIndex(&fleetnetv1alpha1.EndpointSliceImport{}, endpointSliceImportNameFieldKey, endpointSliceImportIndexerFunc).
+				Build()
+			r := &Reconciler{
+				HubClient: fakeHubClient,
+			}
+
+			reconcile := func(endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) {
+				t.Helper()
+				req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: endpointSliceExport.Namespace, Name: endpointSliceExport.Name}}
+				if _, err := r.Reconcile(ctx, req); err != nil {
+					t.Fatalf("Reconcile(%+v) = %v, want no error", req, err)
+				}
+			}
+			checkEndpointSliceImports := func(want map[string][]string) {
+				t.Helper()
+				endpointSliceImportList := &fleetnetv1alpha1.EndpointSliceImportList{}
+				if err := fakeHubClient.List(ctx, endpointSliceImportList); err != nil {
+					t.Fatalf("EndpointSliceImport List() = %v, want no error", err)
+				}
+				got := map[string][]string{}
+				for _, endpointSliceImport := range endpointSliceImportList.Items {
+					key := endpointSliceImportKey(endpointSliceImport.Namespace, endpointSliceImport.Name)
+					for _, endpoint := range endpointSliceImport.Spec.Endpoints {
+						got[key] = append(got[key], endpoint.Addresses...)
+					}
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Fatalf("endpointSliceImports mismatch (-want, +got):\n%s", diff)
+				}
+			}
+
+			reconcile(endpointSliceExportA)
+			reconcile(endpointSliceExportB)
+			checkEndpointSliceImports(tc.wantEndpointSliceImports)
+
+			if err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: hubNSForMemberB, Name: endpointSliceExportNameB}, endpointSliceExportB); err != nil {
+				t.Fatalf("EndpointSliceExport Get() = %v, want no error", err)
+			}
+			endpointSliceExportB.Spec.Endpoints = endpointSliceExportB.Spec.Endpoints[:1]
+			if err := fakeHubClient.Update(ctx, endpointSliceExportB); err != nil {
+				t.Fatalf("EndpointSliceExport Update() = %v, want no error", err)
+			}
+			reconcile(endpointSliceExportB)
+			checkEndpointSliceImports(tc.wantEndpointSliceImportsAfterUpdate)
+
+			if err := fakeHubClient.Delete(ctx, endpointSliceExportA); err != nil {
+				t.Fatalf("EndpointSliceExport Delete() = %v, want no error", err)
+			}
+			reconcile(endpointSliceExportA)
+			checkEndpointSliceImports(tc.wantEndpointSliceImportsAfterDelete)
+			err = fakeHubClient.Get(ctx, types.NamespacedName{Namespace: hubNSForMemberA, Name: endpointSliceExportNameA}, &fleetnetv1alpha1.EndpointSliceExport{})
+			if !errors.IsNotFound(err) {
+				t.Errorf("EndpointSliceExport Get() = %v, want not found", err)
+			}
+		})
+	}
+}
+
+// TestReconcile_IncludeLocalEndpointsTransition tests that the EndpointSlices exported by member cluster A are
+// distributed again to member cluster A when it starts or stops including its local endpoints.
+func TestReconcile_IncludeLocalEndpointsTransition(t *testing.T) {
+	ctx := context.Background()
+	endpointSliceExport := fanOutTestEndpointSliceExport(hubNSForMemberA, clusterIDForMemberA, "work-app-endpointslice-a")
+	svcImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: clusterIDForMemberA}},
+		},
+	}
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcImport, endpointSliceExport).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameFieldKey, endpointSliceExportIndexerFunc).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceImport{}, endpointSliceImportNameFieldKey, endpointSliceImportIndexerFunc).
+		Build()
+	r := &Reconciler{
+		HubClient: fakeHubClient,
+	}
+
+	// importBy has member cluster A import the Service, including its local endpoints or not, and reconciles the
+	// export as the update of the ServiceImport would.
+	importBy := func(includeLocalEndpoints bool) {
+		t.Helper()
+		svcInUseBy := &fleetnetv1alpha1.ServiceInUseBy{
+			MemberClusters: map[fleetnetv1alpha1.ClusterNamespace]fleetnetv1alpha1.ClusterID{
+				hubNSForMemberA: clusterIDForMemberA,
+			},
+		}
+		if includeLocalEndpoints {
+			svcInUseBy.IncludeLocalEndpoints = map[fleetnetv1alpha1.ClusterNamespace]bool{hubNSForMemberA: true}
+		}
+		data, err := json.Marshal(svcInUseBy)
+		if err != nil {
+			t.Fatalf("failed to marshal ServiceInUseBy: %v", err)
+		}
+		if err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, svcImport); err != nil {
+			t.Fatalf("ServiceImport Get() = %v, want no error", err)
+		}
+		svcImport.Annotations = map[string]string{objectmeta.ServiceImportAnnotationServiceInUseBy: string(data)}
+		if err := fakeHubClient.Update(ctx, svcImport); err != nil {
+			t.Fatalf("ServiceImport Update() = %v, want no error", err)
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: endpointSliceExport.Namespace, Name: endpointSliceExport.Name}}
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile(%+v) = %v, want no error", req, err)
+		}
+	}
+	checkDistributed := func(want bool) {
+		t.Helper()
+		err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: hubNSForMemberA, Name: endpointSliceExport.Name}, &fleetnetv1alpha1.EndpointSliceImport{})
+		switch {
+		case want && err != nil:
+			t.Fatalf("EndpointSliceImport Get() = %v, want no error", err)
+		case !want && !errors.IsNotFound(err):
+			t.Fatalf("EndpointSliceImport Get() = %v, want not found", err)
+		}
+	}
+
+	importBy(false)
+	checkDistributed(false)
+
+	importBy(true)
+	checkDistributed(true)
+
+	importBy(false)
+	checkDistributed(false)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/freshness"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// reportFreshness measures the lag of an endpoint change which has just been distributed to the importing
+// clusters, if any, and reports the freshness state of the exported Service on its InternalServiceExport.
+//
+// The change is timed from when the member cluster first observed the current generation of the EndpointSlice,
+// i.e. the exportedSince timestamp of the EndpointSlice reference.
+func (r *Reconciler) reportFreshness(ctx context.Context, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport, changeDistributed bool) (ctrl.Result, error) {
+	if r.Freshness == nil {
+		return ctrl.Result{}, nil
+	}
+	key := freshness.Key{
+		ClusterID: endpointSliceExport.Spec.EndpointSliceReference.ClusterID,
+		Service: types.NamespacedName{
+			Namespace: endpointSliceExport.Spec.OwnerServiceReference.Namespace,
+			Name:      endpointSliceExport.Spec.OwnerServiceReference.Name,
+		},
+	}
+	changedAt := endpointSliceExport.Spec.EndpointSliceReference.ExportedSince
+	if changeDistributed && !changedAt.IsZero() {
+		r.Freshness.Observe(key, changedAt.Time)
+	}
+	state, ok := r.Freshness.State(key)
+	if !ok {
+		// No change of the Service has been distributed yet.
+		return ctrl.Result{}, nil
+	}
+	if err := r.updateFreshnessCondition(ctx, endpointSliceExport.Namespace, key, state); err != nil {
+		klog.ErrorS(err, "Failed to report the freshness of the exported service",
+			"service", key.Service,
+			"clusterID", key.ClusterID,
+			"endpointSliceExport", klog.KObj(endpointSliceExport))
+		return ctrl.Result{}, err
+	}
+	if state.Degraded {
+		// Evaluate the freshness again once the lags measured so far no longer count, so that the condition clears
+		// even if the Service does not change any more.
+		return ctrl.Result{RequeueAfter: r.Freshness.Window()}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// updateFreshnessCondition sets the FreshnessDegraded condition on the InternalServiceExports of the Service in the
+// namespace of the member cluster; the condition is only added to the exports which have been degraded before, or
+// are degraded now.
+//
+// The InternalServiceExports are looked up with the field index the ServiceImport controller registers, which runs
+// in the same controller manager.
+func (r *Reconciler) updateFreshnessCondition(ctx context.Context, namespace string, key freshness.Key, state freshness.State) error {
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := r.HubClient.List(ctx, internalSvcExportList,
+		client.InNamespace(namespace),
+		client.MatchingFields{objectmeta.InternalServiceExportFieldServiceNamespacedName: key.Service.String()}); err != nil {
+		return err
+	}
+	for i := range internalSvcExportList.Items {
+		internalSvcExport := &internalSvcExportList.Items[i]
+		if internalSvcExport.DeletionTimestamp != nil {
+			continue
+		}
+		currentCond := meta.FindStatusCondition(internalSvcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportFreshnessDegraded))
+		if currentCond == nil && !state.Degraded {
+			continue
+		}
+		desiredCond := condition.FreshFreshnessCondition(*internalSvcExport, r.Freshness.Threshold())
+		if state.Degraded {
+			desiredCond = condition.DegradedFreshnessCondition(*internalSvcExport, state.Lag, r.Freshness.Threshold())
+		}
+		// The message is compared as well, as it contains the measured lag.
+		if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
+			continue
+		}
+		klog.V(2).InfoS("Updating the freshness condition of the exported service",
+			"internalServiceExport", klog.KObj(internalSvcExport),
+			"degraded", state.Degraded,
+			"lag", state.Lag)
+		meta.SetStatusCondition(&internalSvcExport.Status.Conditions, desiredCond)
+		if err := r.HubClient.Status().Update(ctx, internalSvcExport); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
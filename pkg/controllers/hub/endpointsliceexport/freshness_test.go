@@ -0,0 +1,228 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/freshness"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	internalSvcExportName = "work-app"
+)
+
+var (
+	testFreshnessConfig = freshness.Config{
+		Threshold:         30 * time.Second,
+		RecoveryThreshold: 20 * time.Second,
+		Window:            5 * time.Minute,
+	}
+	internalSvcExportKey = types.NamespacedName{Namespace: hubNSForMemberA, Name: internalSvcExportName}
+)
+
+// freshnessTestObjects returns the objects of a Service exported by member A and imported by member B, whose
+// EndpointSlice has been distributed to member B before it last changed at changedAt.
+func freshnessTestObjects(t *testing.T, changedAt time.Time) []client.Object {
+	svcInUseBy, err := json.Marshal(&fleetnetv1alpha1.ServiceInUseBy{
+		MemberClusters: map[fleetnetv1alpha1.ClusterNamespace]fleetnetv1alpha1.ClusterID{
+			hubNSForMemberB: clusterIDForMemberB,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal ServiceInUseBy: %v", err)
+	}
+	svcImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   memberUserNS,
+			Name:        svcName,
+			Annotations: map[string]string{objectmeta.ServiceImportAnnotationServiceInUseBy: string(svcInUseBy)},
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: clusterIDForMemberA}},
+		},
+	}
+
+	endpointSliceExport := ipv4EndpointSliceExport()
+	endpointSliceExport.Spec.EndpointSliceReference.ExportedSince = metav1.NewTime(changedAt)
+	distributedSpec := ipv4EndpointSliceExport().Spec
+	distributedSpec.Endpoints = distributedSpec.Endpoints[:1]
+	distributedSpec.EndpointSliceReference.ExportedSince = metav1.NewTime(changedAt.Add(-time.Hour))
+	endpointSliceImport := &fleetnetv1alpha1.EndpointSliceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: hubNSForMemberB,
+			Name:      endpointSliceExportName,
+		},
+		Spec: distributedSpec,
+	}
+
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: hubNSForMemberA,
+			Name:      internalSvcExportName,
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:      hubNSForMemberA,
+				Kind:           "Service",
+				Namespace:      memberUserNS,
+				Name:           svcName,
+				Generation:     2,
+				NamespacedName: types.NamespacedName{Namespace: memberUserNS, Name: svcName}.String(),
+			},
+		},
+	}
+	return []client.Object{svcImport, endpointSliceExport, endpointSliceImport, internalSvcExport}
+}
+
+func freshnessTestReconciler(objs []client.Object, tracker *freshness.Tracker) *Reconciler {
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&fleetnetv1alpha1.InternalServiceExport{}).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameFieldKey, endpointSliceExportIndexerFunc).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceImport{}, endpointSliceImportNameFieldKey, endpointSliceImportIndexerFunc).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+		}).
+		Build()
+	return &Reconciler{
+		HubClient: fakeHubClient,
+		Freshness: tracker,
+	}
+}
+
+// TestReconcile_Freshness tests that the freshness condition is added to the InternalServiceExport when the
+// endpoint changes of the exported Service are distributed late, and clears once the lag recovers.
+func TestReconcile_Freshness(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now().Round(time.Second))
+	tracker := freshness.NewWithClock(testFreshnessConfig, fakeClock)
+	// The change of the EndpointSlice is distributed 45 seconds after it has been made.
+	r := freshnessTestReconciler(freshnessTestObjects(t, fakeClock.Now().Add(-45*time.Second)), tracker)
+	req := ctrl.Request{NamespacedName: endpointSliceExportKey}
+
+	gotFreshnessCondition := func() *metav1.Condition {
+		t.Helper()
+		internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+		if err := r.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+			t.Fatalf("InternalServiceExport Get() = %v, want no error", err)
+		}
+		return meta.FindStatusCondition(internalSvcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportFreshnessDegraded))
+	}
+	wantInternalSvcExport := fleetnetv1alpha1.InternalServiceExport{
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{Namespace: memberUserNS, Name: svcName, Generation: 2},
+		},
+	}
+	ignoreLastTransitionTime := cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
+
+	res, err := r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if want := (ctrl.Result{RequeueAfter: testFreshnessConfig.Window}); res != want {
+		t.Errorf("Reconcile() = %v, want %v", res, want)
+	}
+	wantCond := condition.DegradedFreshnessCondition(wantInternalSvcExport, 45*time.Second, testFreshnessConfig.Threshold)
+	if diff := cmp.Diff(&wantCond, gotFreshnessCondition(), ignoreLastTransitionTime); diff != "" {
+		t.Fatalf("freshness condition mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Reconciling again does not measure the change again.
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if diff := cmp.Diff(&wantCond, gotFreshnessCondition(), ignoreLastTransitionTime); diff != "" {
+		t.Fatalf("freshness condition mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The condition clears once the late change no longer counts.
+	fakeClock.SetTime(fakeClock.Now().Add(testFreshnessConfig.Window))
+	res, err = r.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if want := (ctrl.Result{}); res != want {
+		t.Errorf("Reconcile() = %v, want %v", res, want)
+	}
+	wantCond = condition.FreshFreshnessCondition(wantInternalSvcExport, testFreshnessConfig.Threshold)
+	if diff := cmp.Diff(&wantCond, gotFreshnessCondition(), ignoreLastTransitionTime); diff != "" {
+		t.Errorf("freshness condition mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_FreshnessNotDegraded tests the cases where no freshness condition is added to the
+// InternalServiceExport.
+func TestReconcile_FreshnessNotDegraded(t *testing.T) {
+	now := time.Now().Round(time.Second)
+	testCases := []struct {
+		name    string
+		objects func(t *testing.T) []client.Object
+		tracker *freshness.Tracker
+	}{
+		{
+			name: "change distributed in time",
+			objects: func(t *testing.T) []client.Object {
+				return freshnessTestObjects(t, now.Add(-5*time.Second))
+			},
+			tracker: freshness.NewWithClock(testFreshnessConfig, clocktesting.NewFakePassiveClock(now)),
+		},
+		{
+			name: "endpoint slice distributed to a new importing cluster",
+			objects: func(t *testing.T) []client.Object {
+				objs := freshnessTestObjects(t, now.Add(-time.Hour))
+				// Drop the EndpointSliceImport, as if member B has just started to import the Service.
+				return append(objs[:2], objs[3])
+			},
+			tracker: freshness.NewWithClock(testFreshnessConfig, clocktesting.NewFakePassiveClock(now)),
+		},
+		{
+			name: "freshness not measured",
+			objects: func(t *testing.T) []client.Object {
+				return freshnessTestObjects(t, now.Add(-time.Hour))
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := freshnessTestReconciler(tc.objects(t), tc.tracker)
+			res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceExportKey})
+			if err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+			if want := (ctrl.Result{}); res != want {
+				t.Errorf("Reconcile() = %v, want %v", res, want)
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+			if err := r.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+				t.Fatalf("InternalServiceExport Get() = %v, want no error", err)
+			}
+			if len(internalSvcExport.Status.Conditions) != 0 {
+				t.Errorf("InternalServiceExport conditions = %v, want none", internalSvcExport.Status.Conditions)
+			}
+		})
+	}
+}
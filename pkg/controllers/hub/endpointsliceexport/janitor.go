@@ -0,0 +1,133 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// JanitorControllerName is the name of the Janitor.
+	JanitorControllerName = "endpointsliceexport-janitor"
+)
+
+// Janitor deletes the EndpointSliceExports left over in the hub cluster, i.e. those whose EndpointSlices the member
+// agents have not seen within the TTL.
+//
+// The member agents annotate the EndpointSliceExports whose EndpointSlices still exist with the time they last saw
+// them, and delete those whose EndpointSlices are gone themselves; the Janitor cleans up after the member agents
+// which are gone or stuck. EndpointSliceExports which have never been annotated, e.g. those of member agents which
+// predate the annotation, are left alone.
+type Janitor struct {
+	HubClient client.Client
+	// TTL is the period after which an EndpointSliceExport whose EndpointSlice has not been seen is deleted; it must be
+	// well above the heartbeat interval of the member agents.
+	TTL time.Duration
+	// Clock is the clock against which the EndpointSliceExports expire; the real clock is used if it is not set.
+	Clock clock.Clock
+}
+
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch;delete
+
+// Reconcile deletes an EndpointSliceExport whose EndpointSlice has not been seen within the TTL, or requeues it for
+// when it would expire.
+func (j *Janitor) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	endpointSliceExportRef := klog.KRef(req.Namespace, req.Name)
+	endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{}
+	if err := j.HubClient.Get(ctx, req.NamespacedName, endpointSliceExport); err != nil {
+		if errors.IsNotFound(err) {
+			klog.V(4).InfoS("Ignoring NotFound endpointSliceExport", "endpointSliceExport", endpointSliceExportRef)
+			return ctrl.Result{}, nil
+		}
+		klog.ErrorS(err, "Failed to get endpointSliceExport", "endpointSliceExport", endpointSliceExportRef)
+		return ctrl.Result{}, err
+	}
+	if endpointSliceExport.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	val, ok := endpointSliceExport.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen]
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+	lastSeen, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		// The next heartbeat of the member agent overwrites the invalid annotation.
+		klog.V(2).InfoS("Ignoring endpointSliceExport with an invalid last seen time", "endpointSliceExport", endpointSliceExportRef, "lastSeen", val)
+		return ctrl.Result{}, nil
+	}
+
+	clk := j.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	if untilExpiry := lastSeen.Add(j.TTL).Sub(clk.Now()); untilExpiry > 0 {
+		return ctrl.Result{RequeueAfter: untilExpiry}, nil
+	}
+	// The EndpointSliceExport controller withdraws the EndpointSliceImports distributed from the EndpointSliceExport
+	// before its cleanup finalizer lets it go.
+	klog.V(2).InfoS("The endpoint slice of the endpointSliceExport has not been seen within the TTL; delete the endpointSliceExport",
+		"endpointSliceExport", endpointSliceExportRef,
+		"clusterID", endpointSliceExport.Spec.EndpointSliceReference.ClusterID,
+		"lastSeen", val,
+		"ttl", j.TTL)
+	if err := j.HubClient.Delete(ctx, endpointSliceExport); err != nil && !errors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to delete expired endpointSliceExport", "endpointSliceExport", endpointSliceExportRef)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the Janitor with a controller manager.
+func (j *Janitor) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(JanitorControllerName).
+		For(&fleetnetv1alpha1.EndpointSliceExport{}).
+		Complete(j)
+}
+
+// heartbeatOnlyUpdatePredicate filters out the updates of EndpointSliceExports which only refresh their last seen
+// time, so that the heartbeats of the member agents do not trigger the distribution of the EndpointSlices again.
+var heartbeatOnlyUpdatePredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldObj, okOld := e.ObjectOld.(*fleetnetv1alpha1.EndpointSliceExport)
+		newObj, okNew := e.ObjectNew.(*fleetnetv1alpha1.EndpointSliceExport)
+		if !okOld || !okNew {
+			return true
+		}
+		return !isHeartbeatOnlyUpdate(oldObj, newObj)
+	},
+}
+
+// isHeartbeatOnlyUpdate returns whether an EndpointSliceExport has changed only in its last seen time.
+func isHeartbeatOnlyUpdate(oldObj, newObj *fleetnetv1alpha1.EndpointSliceExport) bool {
+	if oldObj.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] == newObj.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] {
+		return false
+	}
+	oldCopy, newCopy := oldObj.DeepCopy(), newObj.DeepCopy()
+	for _, obj := range []*fleetnetv1alpha1.EndpointSliceExport{oldCopy, newCopy} {
+		delete(obj.Annotations, objectmeta.EndpointSliceExportAnnotationLastSeen)
+		if len(obj.Annotations) == 0 {
+			obj.Annotations = nil
+		}
+		obj.ResourceVersion = ""
+		obj.ManagedFields = nil
+	}
+	return equality.Semantic.DeepEqual(oldCopy, newCopy)
+}
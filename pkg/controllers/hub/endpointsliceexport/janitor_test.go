@@ -0,0 +1,152 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointsliceexport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// janitorTestEndpointSliceExport returns an EndpointSliceExport last seen at the given time; it is never seen if the
+// time is zero.
+func janitorTestEndpointSliceExport(name string, lastSeen time.Time) *fleetnetv1alpha1.EndpointSliceExport {
+	endpointSliceExport := ipv4EndpointSliceExport()
+	endpointSliceExport.Name = name
+	endpointSliceExport.Finalizers = nil
+	if !lastSeen.IsZero() {
+		endpointSliceExport.Annotations = map[string]string{
+			objectmeta.EndpointSliceExportAnnotationLastSeen: lastSeen.UTC().Format(time.RFC3339),
+		}
+	}
+	return endpointSliceExport
+}
+
+// TestJanitor_Reconcile tests that the Janitor deletes a leftover EndpointSliceExport once it has not been seen
+// within the TTL, and keeps a healthy one whose heartbeats keep coming.
+func TestJanitor_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	ttl := time.Hour
+	healthy := janitorTestEndpointSliceExport("healthy", now.Add(-10*time.Minute))
+	leftover := janitorTestEndpointSliceExport("leftover", now.Add(-50*time.Minute))
+	neverSeen := janitorTestEndpointSliceExport("never-seen", time.Time{})
+	invalid := janitorTestEndpointSliceExport("invalid", time.Time{})
+	invalid.Annotations = map[string]string{objectmeta.EndpointSliceExportAnnotationLastSeen: "yesterday"}
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(healthy, leftover, neverSeen, invalid).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(now)
+	janitor := &Janitor{
+		HubClient: fakeHubClient,
+		TTL:       ttl,
+		Clock:     fakeClock,
+	}
+	reconcile := func(name string, wantRequeueAfter time.Duration, wantDeleted bool) {
+		t.Helper()
+		key := types.NamespacedName{Namespace: hubNSForMemberA, Name: name}
+		res, err := janitor.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", key, err)
+		}
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Fatalf("Reconcile(%+v) requeueAfter, got %v, want %v", key, res.RequeueAfter, wantRequeueAfter)
+		}
+		err = fakeHubClient.Get(ctx, key, &fleetnetv1alpha1.EndpointSliceExport{})
+		if gotDeleted := errors.IsNotFound(err); gotDeleted != wantDeleted {
+			t.Fatalf("Get(%+v), got error %v, want deleted %t", key, err, wantDeleted)
+		}
+	}
+
+	// Neither export has expired yet; EndpointSliceExports which have never been seen are left alone.
+	reconcile("healthy", 50*time.Minute, false)
+	reconcile("leftover", 10*time.Minute, false)
+	reconcile("never-seen", 0, false)
+	reconcile("invalid", 0, false)
+
+	// The member agent keeps refreshing the healthy export, but not the leftover one.
+	fakeClock.Step(10 * time.Minute)
+	healthy.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] = fakeClock.Now().UTC().Format(time.RFC3339)
+	if err := fakeHubClient.Update(ctx, healthy); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	reconcile("healthy", ttl, false)
+	reconcile("leftover", 0, true)
+	reconcile("never-seen", 0, false)
+}
+
+// TestIsHeartbeatOnlyUpdate tests the isHeartbeatOnlyUpdate function.
+func TestIsHeartbeatOnlyUpdate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name string
+		// neverSeenBefore removes the last seen time from the EndpointSliceExport before the update.
+		neverSeenBefore bool
+		mutate          func(newObj *fleetnetv1alpha1.EndpointSliceExport)
+		want            bool
+	}{
+		{
+			name: "heartbeat",
+			mutate: func(newObj *fleetnetv1alpha1.EndpointSliceExport) {
+				newObj.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] = now.Add(time.Minute).Format(time.RFC3339)
+			},
+			want: true,
+		},
+		{
+			name:            "first heartbeat",
+			neverSeenBefore: true,
+			mutate:          func(_ *fleetnetv1alpha1.EndpointSliceExport) {},
+			want:            true,
+		},
+		{
+			name: "heartbeat with endpoint changes",
+			mutate: func(newObj *fleetnetv1alpha1.EndpointSliceExport) {
+				newObj.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] = now.Add(time.Minute).Format(time.RFC3339)
+				newObj.Spec.Endpoints = newObj.Spec.Endpoints[:1]
+			},
+		},
+		{
+			name: "endpoint changes",
+			mutate: func(newObj *fleetnetv1alpha1.EndpointSliceExport) {
+				newObj.Spec.Endpoints = newObj.Spec.Endpoints[:1]
+			},
+		},
+		{
+			name: "last seen time removed and finalizer added",
+			mutate: func(newObj *fleetnetv1alpha1.EndpointSliceExport) {
+				newObj.Annotations = nil
+				newObj.Finalizers = []string{endpointSliceExportCleanupFinalizer}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			oldObj := janitorTestEndpointSliceExport(endpointSliceExportName, now)
+			oldObj.ResourceVersion = "1"
+			newObj := oldObj.DeepCopy()
+			newObj.ResourceVersion = "2"
+			if tc.neverSeenBefore {
+				oldObj.Annotations = nil
+			}
+			tc.mutate(newObj)
+			if got := isHeartbeatOnlyUpdate(oldObj, newObj); got != tc.want {
+				t.Errorf("isHeartbeatOnlyUpdate(), got %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package fleetservicenetworkingstatus features the FleetServiceNetworkingStatus controller, which summarizes the
+// health of the networking pipeline of every exported Service on the hub cluster.
+package fleetservicenetworkingstatus
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// The field indexes below are set up by the EndpointSliceExport, InternalServiceImport and TrafficManagerBackend
+	// controllers respectively, which run in the same controller manager.
+	endpointSliceExportOwnerSvcNamespacedNameKey  = ".spec.ownerServiceReference.namespacedName"
+	internalSvcImportSvcRefNamespacedNameFieldKey = ".spec.serviceImportReference.namespacedName"
+	trafficManagerBackendBackendFieldKey          = ".spec.backend.name"
+)
+
+// Reconciler reconciles a FleetServiceNetworkingStatus object.
+type Reconciler struct {
+	Client client.Client
+	// EnableTrafficManagerFeature indicates whether TrafficManagerBackends are summarized; it must be set only when
+	// the TrafficManagerBackend controller runs in the same controller manager.
+	EnableTrafficManagerFeature bool
+	// BatchInterval is the wait time before a change of the sources is summarized; configv1alpha1.DefaultFleetServiceNetworkingStatusBatchInterval is used
+	// if it is not set.
+	BatchInterval time.Duration
+}
+
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=fleetservicenetworkingstatuses,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=fleetservicenetworkingstatuses/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceimports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=trafficmanagerbackends,verbs=get;list;watch
+
+// Reconcile rebuilds the FleetServiceNetworkingStatus of an exported Service from its sources.
+// The request is keyed by the namespace and name of the Service, which are the same as its ServiceImport.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	statusRef := klog.KRef(req.Namespace, req.Name)
+	startTime := time.Now()
+	klog.V(2).InfoS("Reconciliation starts", "fleetServiceNetworkingStatus", statusRef)
+	defer func() {
+		latency := time.Since(startTime).Milliseconds()
+		klog.V(2).InfoS("Reconciliation ends", "fleetServiceNetworkingStatus", statusRef, "latency", latency)
+	}()
+
+	src, err := r.collectSources(ctx, req.NamespacedName)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := &fleetnetv1alpha1.FleetServiceNetworkingStatus{}
+	if err := r.Client.Get(ctx, req.NamespacedName, status); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef)
+			return ctrl.Result{}, err
+		}
+		status = nil
+	}
+
+	if !src.isServiceExported() {
+		if status == nil {
+			return ctrl.Result{}, nil
+		}
+		klog.V(2).InfoS("Service is no longer exported; deleting fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef)
+		if err := r.Client.Delete(ctx, status); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if status == nil {
+		status = &fleetnetv1alpha1.FleetServiceNetworkingStatus{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: req.Namespace,
+				Name:      req.Name,
+			},
+		}
+		klog.V(2).InfoS("Creating fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef)
+		if err := r.Client.Create(ctx, status); err != nil {
+			klog.ErrorS(err, "Failed to create fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef)
+			return ctrl.Result{}, err
+		}
+	}
+
+	desired := summarize(src)
+	if equality.Semantic.DeepEqual(status.Status, desired) {
+		return ctrl.Result{}, nil
+	}
+	status.Status = desired
+	klog.V(2).InfoS("Updating fleetServiceNetworkingStatus", "fleetServiceNetworkingStatus", statusRef,
+		"exportingClusterCount", desired.ExportingClusterCount, "importingClusterCount", desired.ImportingClusterCount,
+		"conflicted", desired.Conflicted)
+	if err := r.Client.Status().Update(ctx, status); err != nil {
+		klog.ErrorS(err, "Failed to update fleetServiceNetworkingStatus status", "fleetServiceNetworkingStatus", statusRef)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// collectSources lists all the objects on the hub cluster which describe the networking pipeline of a Service.
+func (r *Reconciler) collectSources(ctx context.Context, svcKey types.NamespacedName) (*sources, error) {
+	svcRef := klog.KRef(svcKey.Namespace, svcKey.Name)
+	src := &sources{}
+
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	switch err := r.Client.Get(ctx, svcKey, svcImport); {
+	case err == nil:
+		src.serviceImport = svcImport
+	case !apierrors.IsNotFound(err):
+		klog.ErrorS(err, "Failed to get serviceImport", "serviceImport", svcRef)
+		return nil, err
+	}
+
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := r.Client.List(ctx, internalSvcExportList, client.MatchingFields{objectmeta.InternalServiceExportFieldServiceNamespacedName: svcKey.String()}); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceExports", "service", svcRef)
+		return nil, err
+	}
+	src.internalServiceExports = internalSvcExportList.Items
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := r.Client.List(ctx, endpointSliceExportList, client.MatchingFields{endpointSliceExportOwnerSvcNamespacedNameKey: svcKey.String()}); err != nil {
+		klog.ErrorS(err, "Failed to list endpointSliceExports", "service", svcRef)
+		return nil, err
+	}
+	src.endpointSliceExports = endpointSliceExportList.Items
+
+	internalSvcImportList := &fleetnetv1alpha1.InternalServiceImportList{}
+	if err := r.Client.List(ctx, internalSvcImportList, client.MatchingFields{internalSvcImportSvcRefNamespacedNameFieldKey: svcKey.String()}); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceImports", "service", svcRef)
+		return nil, err
+	}
+	src.internalServiceImports = internalSvcImportList.Items
+
+	if r.EnableTrafficManagerFeature {
+		backendList := &fleetnetv1beta1.TrafficManagerBackendList{}
+		if err := r.Client.List(ctx, backendList, client.InNamespace(svcKey.Namespace), client.MatchingFields{trafficManagerBackendBackendFieldKey: svcKey.Name}); err != nil {
+			klog.ErrorS(err, "Failed to list trafficManagerBackends", "service", svcRef)
+			return nil, err
+		}
+		src.trafficManagerBackends = backendList.Items
+	}
+	return src, nil
+}
+
+func (r *Reconciler) batchInterval() time.Duration {
+	if r.BatchInterval <= 0 {
+		return configv1alpha1.DefaultFleetServiceNetworkingStatusBatchInterval
+	}
+	return r.BatchInterval
+}
+
+// SetupWithManager sets up the FleetServiceNetworkingStatus controller with a controller manager.
+// It relies on the field indexes set up by the other hub controllers and must be set up after them.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&fleetnetv1alpha1.FleetServiceNetworkingStatus{}).
+		Watches(&fleetnetv1alpha1.ServiceImport{}, r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+			return types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}, true
+		})).
+		Watches(&fleetnetv1alpha1.InternalServiceExport{}, r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+			internalSvcExport, ok := o.(*fleetnetv1alpha1.InternalServiceExport)
+			if !ok {
+				return types.NamespacedName{}, false
+			}
+			svcRef := internalSvcExport.Spec.ServiceReference
+			return types.NamespacedName{Namespace: svcRef.Namespace, Name: svcRef.Name}, true
+		})).
+		Watches(&fleetnetv1alpha1.EndpointSliceExport{}, r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+			endpointSliceExport, ok := o.(*fleetnetv1alpha1.EndpointSliceExport)
+			if !ok {
+				return types.NamespacedName{}, false
+			}
+			svcRef := endpointSliceExport.Spec.OwnerServiceReference
+			return types.NamespacedName{Namespace: svcRef.Namespace, Name: svcRef.Name}, true
+		})).
+		Watches(&fleetnetv1alpha1.InternalServiceImport{}, r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+			internalSvcImport, ok := o.(*fleetnetv1alpha1.InternalServiceImport)
+			if !ok {
+				return types.NamespacedName{}, false
+			}
+			svcRef := internalSvcImport.Spec.ServiceImportReference
+			return types.NamespacedName{Namespace: svcRef.Namespace, Name: svcRef.Name}, true
+		}))
+	if r.EnableTrafficManagerFeature {
+		b = b.Watches(&fleetnetv1beta1.TrafficManagerBackend{}, r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+			backend, ok := o.(*fleetnetv1beta1.TrafficManagerBackend)
+			if !ok {
+				return types.NamespacedName{}, false
+			}
+			// The backend and the ServiceImport are in the same namespace.
+			return types.NamespacedName{Namespace: backend.Namespace, Name: backend.Spec.Backend.Name}, true
+		}))
+	}
+	return b.Complete(r)
+}
+
+// enqueueServiceAfterBatchInterval returns an event handler which enqueues the Service a source object belongs to
+// after the batch interval. The work queue keeps a single entry per Service while it waits, so that all the changes
+// observed in the meantime are summarized in one reconciliation.
+func (r *Reconciler) enqueueServiceAfterBatchInterval(serviceOf func(o client.Object) (types.NamespacedName, bool)) handler.EventHandler {
+	enqueue := func(o client.Object, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+		svcKey, ok := serviceOf(o)
+		if !ok || svcKey.Name == "" {
+			return
+		}
+		q.AddAfter(reconcile.Request{NamespacedName: svcKey}, r.batchInterval())
+	}
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(e.Object, q)
+		},
+		UpdateFunc: func(_ context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			// A TrafficManagerBackend may be moved to front another Service; both Services are summarized again.
+			enqueue(e.ObjectOld, q)
+			enqueue(e.ObjectNew, q)
+		},
+		DeleteFunc: func(_ context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(e.Object, q)
+		},
+		GenericFunc: func(_ context.Context, e event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueue(e.Object, q)
+		},
+	}
+}
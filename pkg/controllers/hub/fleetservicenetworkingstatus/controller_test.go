@@ -0,0 +1,235 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package fleetservicenetworkingstatus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+var (
+	testSvcKey = types.NamespacedName{Namespace: testNamespace, Name: testSvcName}
+)
+
+func newTestReconciler(t *testing.T, enableTrafficManagerFeature bool, objs ...client.Object) *Reconciler {
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&fleetnetv1alpha1.FleetServiceNetworkingStatus{}).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+		}).
+		WithIndex(&fleetnetv1alpha1.EndpointSliceExport{}, endpointSliceExportOwnerSvcNamespacedNameKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.EndpointSliceExport).Spec.OwnerServiceReference.NamespacedName}
+		}).
+		WithIndex(&fleetnetv1alpha1.InternalServiceImport{}, internalSvcImportSvcRefNamespacedNameFieldKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.InternalServiceImport).Spec.ServiceImportReference.NamespacedName}
+		}).
+		WithIndex(&fleetnetv1beta1.TrafficManagerBackend{}, trafficManagerBackendBackendFieldKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1beta1.TrafficManagerBackend).Spec.Backend.Name}
+		}).
+		Build()
+	return &Reconciler{
+		Client:                      fakeClient,
+		EnableTrafficManagerFeature: enableTrafficManagerFeature,
+	}
+}
+
+func reconcileAndGetStatus(t *testing.T, r *Reconciler) *fleetnetv1alpha1.FleetServiceNetworkingStatus {
+	ctx := context.Background()
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testSvcKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	status := &fleetnetv1alpha1.FleetServiceNetworkingStatus{}
+	if err := r.Client.Get(ctx, testSvcKey, status); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	return status
+}
+
+func TestReconcile_CreateAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler(t, true,
+		resolvedServiceImport(),
+		internalServiceExport(memberClusterID1, false),
+		endpointSliceExport(memberClusterID1, "app-1", exportedSince1),
+		trafficManagerBackend(metav1.ConditionTrue, 1, 1),
+	)
+
+	got := reconcileAndGetStatus(t, r)
+	if got == nil {
+		t.Fatalf("Get() = not found, want fleetServiceNetworkingStatus to be created")
+	}
+	want := fleetnetv1alpha1.ServiceNetworkingStatus{
+		ExportingClusters:        []string{memberClusterID1},
+		ExportingClusterCount:    1,
+		ServiceImportResolved:    true,
+		EndpointSliceExportCount: 1,
+		EndpointsLastChangedTime: ptr.To(exportedSince1),
+		TrafficManagerBackends: []fleetnetv1alpha1.TrafficManagerBackendSummary{
+			{Name: testBackendName, Profile: testProfileName, Accepted: metav1.ConditionTrue, EndpointCount: 1},
+		},
+		TrafficManagerHealthy: ptr.To(true),
+	}
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("fleetServiceNetworkingStatus status mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Reconciling again without any change of the sources does not update the object.
+	resourceVersion := got.ResourceVersion
+	if got = reconcileAndGetStatus(t, r); got.ResourceVersion != resourceVersion {
+		t.Errorf("fleetServiceNetworkingStatus resource version = %s, want %s", got.ResourceVersion, resourceVersion)
+	}
+
+	// A cluster starts to import the Service.
+	if err := r.Client.Create(ctx, internalServiceImport(memberClusterID2)); err != nil {
+		t.Fatalf("Create() = %v, want no error", err)
+	}
+	want.ImportingClusters = []string{memberClusterID2}
+	want.ImportingClusterCount = 1
+	got = reconcileAndGetStatus(t, r)
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("fleetServiceNetworkingStatus status mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_RebuildFromSources(t *testing.T) {
+	stale := &fleetnetv1alpha1.FleetServiceNetworkingStatus{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testSvcName,
+		},
+		Status: fleetnetv1alpha1.ServiceNetworkingStatus{
+			ExportingClusters:     []string{memberClusterID3},
+			ExportingClusterCount: 1,
+			Conflicted:            true,
+		},
+	}
+	r := newTestReconciler(t, false, stale, internalServiceExport(memberClusterID1, false))
+
+	got := reconcileAndGetStatus(t, r)
+	want := fleetnetv1alpha1.ServiceNetworkingStatus{
+		ExportingClusters:     []string{memberClusterID1},
+		ExportingClusterCount: 1,
+	}
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("fleetServiceNetworkingStatus status mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_TrafficManagerFeatureDisabled(t *testing.T) {
+	r := newTestReconciler(t, false, resolvedServiceImport(), trafficManagerBackend(metav1.ConditionTrue, 1, 1))
+
+	got := reconcileAndGetStatus(t, r)
+	want := fleetnetv1alpha1.ServiceNetworkingStatus{ServiceImportResolved: true}
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("fleetServiceNetworkingStatus status mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_NotExported(t *testing.T) {
+	tests := []struct {
+		name string
+		objs []client.Object
+	}{
+		{
+			name: "no sources",
+		},
+		{
+			name: "only importing clusters",
+			objs: []client.Object{internalServiceImport(memberClusterID1)},
+		},
+		{
+			name: "stale status",
+			objs: []client.Object{
+				&fleetnetv1alpha1.FleetServiceNetworkingStatus{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: testNamespace,
+						Name:      testSvcName,
+					},
+				},
+				endpointSliceExport(memberClusterID1, "app-1", exportedSince1),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReconciler(t, true, tc.objs...)
+			if got := reconcileAndGetStatus(t, r); got != nil {
+				t.Errorf("Get() = %v, want not found", got)
+			}
+		})
+	}
+}
+
+func TestEnqueueServiceAfterBatchInterval(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	q := workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.DefaultTypedControllerRateLimiter[reconcile.Request](),
+		workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{Clock: fakeClock},
+	)
+	defer q.ShutDown()
+
+	r := &Reconciler{BatchInterval: time.Minute}
+	h := r.enqueueServiceAfterBatchInterval(func(o client.Object) (types.NamespacedName, bool) {
+		sliceExport := o.(*fleetnetv1alpha1.EndpointSliceExport)
+		svcRef := sliceExport.Spec.OwnerServiceReference
+		return types.NamespacedName{Namespace: svcRef.Namespace, Name: svcRef.Name}, true
+	})
+
+	// A burst of changes of the EndpointSliceExports of the same Service.
+	ctx := context.Background()
+	sliceExport1 := endpointSliceExport(memberClusterID1, "app-1", exportedSince1)
+	sliceExport2 := endpointSliceExport(memberClusterID2, "app-2", exportedSince1)
+	h.Create(ctx, event.CreateEvent{Object: sliceExport1}, q)
+	h.Create(ctx, event.CreateEvent{Object: sliceExport2}, q)
+	h.Update(ctx, event.UpdateEvent{ObjectOld: sliceExport1, ObjectNew: sliceExport1}, q)
+	h.Delete(ctx, event.DeleteEvent{Object: sliceExport2}, q)
+	if q.Len() != 0 {
+		t.Fatalf("queue length before the batch interval = %d, want 0", q.Len())
+	}
+
+	fakeClock.Step(time.Minute)
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("queue length after the batch interval = %d, want 1", q.Len())
+	}
+	if got, _ := q.Get(); got.NamespacedName != testSvcKey {
+		t.Errorf("queued request = %v, want %v", got.NamespacedName, testSvcKey)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package fleetservicenetworkingstatus
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+// sources holds the objects on the hub cluster from which the FleetServiceNetworkingStatus of a Service is built.
+// Any of them may be absent, e.g. when no cluster imports the Service yet, or the traffic manager feature is off.
+type sources struct {
+	// serviceImport is nil if the ServiceImport of the Service does not exist.
+	serviceImport          *fleetnetv1alpha1.ServiceImport
+	internalServiceExports []fleetnetv1alpha1.InternalServiceExport
+	endpointSliceExports   []fleetnetv1alpha1.EndpointSliceExport
+	internalServiceImports []fleetnetv1alpha1.InternalServiceImport
+	trafficManagerBackends []fleetnetv1beta1.TrafficManagerBackend
+}
+
+// isServiceExported returns if the Service is exported to the fleet, i.e. its ServiceImport exists or at least one
+// cluster exports it; a FleetServiceNetworkingStatus is only kept for exported Services.
+func (s *sources) isServiceExported() bool {
+	if s.serviceImport != nil && s.serviceImport.DeletionTimestamp == nil {
+		return true
+	}
+	for i := range s.internalServiceExports {
+		if s.internalServiceExports[i].DeletionTimestamp == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// summarize joins the sources into the status of the networking pipeline of the Service.
+func summarize(s *sources) fleetnetv1alpha1.ServiceNetworkingStatus {
+	status := fleetnetv1alpha1.ServiceNetworkingStatus{}
+
+	exportingClusters := sets.New[string]()
+	conflictedClusters := sets.New[string]()
+	for i := range s.internalServiceExports {
+		internalSvcExport := &s.internalServiceExports[i]
+		// InternalServiceExports which are being deleted are being unexported.
+		if internalSvcExport.DeletionTimestamp != nil {
+			continue
+		}
+		clusterID := internalSvcExport.Spec.ServiceReference.ClusterID
+		exportingClusters.Insert(clusterID)
+		if meta.IsStatusConditionTrue(internalSvcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict)) {
+			conflictedClusters.Insert(clusterID)
+		}
+	}
+	status.ExportingClusters = sortedOrNil(exportingClusters)
+	status.ExportingClusterCount = int32(exportingClusters.Len())
+	status.ConflictedClusters = sortedOrNil(conflictedClusters)
+	status.Conflicted = conflictedClusters.Len() > 0
+
+	status.ServiceImportResolved = s.serviceImport != nil &&
+		s.serviceImport.DeletionTimestamp == nil &&
+		len(s.serviceImport.Status.Clusters) > 0
+
+	importingClusters := sets.New[string]()
+	for i := range s.internalServiceImports {
+		internalSvcImport := &s.internalServiceImports[i]
+		if internalSvcImport.DeletionTimestamp != nil {
+			continue
+		}
+		importingClusters.Insert(internalSvcImport.Spec.ServiceImportReference.ClusterID)
+	}
+	status.ImportingClusters = sortedOrNil(importingClusters)
+	status.ImportingClusterCount = int32(importingClusters.Len())
+
+	var lastChanged *metav1.Time
+	for i := range s.endpointSliceExports {
+		endpointSliceExport := &s.endpointSliceExports[i]
+		if endpointSliceExport.DeletionTimestamp != nil {
+			continue
+		}
+		status.EndpointSliceExportCount++
+		exportedSince := endpointSliceExport.Spec.EndpointSliceReference.ExportedSince
+		if exportedSince.IsZero() {
+			continue
+		}
+		if lastChanged == nil || lastChanged.Before(&exportedSince) {
+			lastChanged = exportedSince.DeepCopy()
+		}
+	}
+	status.EndpointsLastChangedTime = lastChanged
+
+	status.TrafficManagerBackends, status.TrafficManagerHealthy = summarizeTrafficManagerBackends(s.trafficManagerBackends)
+	return status
+}
+
+// summarizeTrafficManagerBackends summarizes the TrafficManagerBackends which front a Service and returns whether
+// they are healthy; the health is nil when no backend fronts the Service.
+func summarizeTrafficManagerBackends(backends []fleetnetv1beta1.TrafficManagerBackend) ([]fleetnetv1alpha1.TrafficManagerBackendSummary, *bool) {
+	var summaries []fleetnetv1alpha1.TrafficManagerBackendSummary
+	healthy := true
+	for i := range backends {
+		backend := &backends[i]
+		if backend.DeletionTimestamp != nil {
+			continue
+		}
+		accepted := metav1.ConditionUnknown
+		cond := meta.FindStatusCondition(backend.Status.Conditions, string(fleetnetv1beta1.TrafficManagerBackendConditionAccepted))
+		// A condition reported for a previous generation says nothing about the current spec.
+		if cond != nil && cond.ObservedGeneration == backend.Generation {
+			accepted = cond.Status
+		}
+		summary := fleetnetv1alpha1.TrafficManagerBackendSummary{
+			Name:          backend.Name,
+			Profile:       backend.Spec.Profile.Name,
+			Accepted:      accepted,
+			EndpointCount: int32(len(backend.Status.Endpoints)),
+		}
+		summaries = append(summaries, summary)
+		if summary.Accepted != metav1.ConditionTrue || summary.EndpointCount == 0 {
+			healthy = false
+		}
+	}
+	if len(summaries) == 0 {
+		return nil, nil
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries, &healthy
+}
+
+// sortedOrNil returns the sorted list of a set, or nil if the set is empty, so that empty lists are omitted.
+func sortedOrNil(s sets.Set[string]) []string {
+	if s.Len() == 0 {
+		return nil
+	}
+	return sets.List(s)
+}
@@ -0,0 +1,345 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package fleetservicenetworkingstatus
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+const (
+	testNamespace = "work"
+	testSvcName   = "app"
+
+	memberClusterID1 = "member-1"
+	memberClusterID2 = "member-2"
+	memberClusterID3 = "member-3"
+
+	testBackendName = "app-backend"
+	testProfileName = "app-profile"
+)
+
+var (
+	exportedSince1 = metav1.NewTime(time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC))
+	exportedSince2 = metav1.NewTime(time.Date(2024, time.May, 1, 11, 0, 0, 0, time.UTC))
+)
+
+func serviceReference(clusterID string) fleetnetv1alpha1.ExportedObjectReference {
+	return fleetnetv1alpha1.ExportedObjectReference{
+		ClusterID:      clusterID,
+		Kind:           "Service",
+		Namespace:      testNamespace,
+		Name:           testSvcName,
+		NamespacedName: fmt.Sprintf("%s/%s", testNamespace, testSvcName),
+	}
+}
+
+func resolvedServiceImport() *fleetnetv1alpha1.ServiceImport {
+	return &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testSvcName,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Type:     fleetnetv1alpha1.ClusterSetIP,
+			Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: memberClusterID1}, {Cluster: memberClusterID2}},
+		},
+	}
+}
+
+func internalServiceExport(clusterID string, conflicted bool) *fleetnetv1alpha1.InternalServiceExport {
+	conflictStatus := metav1.ConditionFalse
+	if conflicted {
+		conflictStatus = metav1.ConditionTrue
+	}
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterID,
+			Name:      fmt.Sprintf("%s-%s", testNamespace, testSvcName),
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			ServiceReference: serviceReference(clusterID),
+		},
+		Status: fleetnetv1alpha1.InternalServiceExportStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:   string(fleetnetv1alpha1.ServiceExportConflict),
+					Status: conflictStatus,
+					Reason: "Test",
+				},
+			},
+		},
+	}
+}
+
+func endpointSliceExport(clusterID, name string, exportedSince metav1.Time) *fleetnetv1alpha1.EndpointSliceExport {
+	return &fleetnetv1alpha1.EndpointSliceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterID,
+			Name:      name,
+		},
+		Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+			AddressType: "IPv4",
+			EndpointSliceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:      clusterID,
+				Kind:           "EndpointSlice",
+				Namespace:      testNamespace,
+				Name:           name,
+				NamespacedName: fmt.Sprintf("%s/%s", testNamespace, name),
+				ExportedSince:  exportedSince,
+			},
+			OwnerServiceReference: fleetnetv1alpha1.OwnerServiceReference{
+				Namespace:      testNamespace,
+				Name:           testSvcName,
+				NamespacedName: fmt.Sprintf("%s/%s", testNamespace, testSvcName),
+			},
+		},
+	}
+}
+
+func internalServiceImport(clusterID string) *fleetnetv1alpha1.InternalServiceImport {
+	return &fleetnetv1alpha1.InternalServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: clusterID,
+			Name:      fmt.Sprintf("%s-%s", testNamespace, testSvcName),
+		},
+		Spec: fleetnetv1alpha1.InternalServiceImportSpec{
+			ServiceImportReference: serviceReference(clusterID),
+		},
+	}
+}
+
+func trafficManagerBackend(accepted metav1.ConditionStatus, observedGeneration int64, endpointCount int) *fleetnetv1beta1.TrafficManagerBackend {
+	backend := &fleetnetv1beta1.TrafficManagerBackend{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  testNamespace,
+			Name:       testBackendName,
+			Generation: 1,
+		},
+		Spec: fleetnetv1beta1.TrafficManagerBackendSpec{
+			Profile: fleetnetv1beta1.TrafficManagerProfileRef{Name: testProfileName},
+			Backend: fleetnetv1beta1.TrafficManagerBackendRef{Name: testSvcName},
+		},
+		Status: fleetnetv1beta1.TrafficManagerBackendStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:               string(fleetnetv1beta1.TrafficManagerBackendConditionAccepted),
+					Status:             accepted,
+					ObservedGeneration: observedGeneration,
+					Reason:             "Test",
+				},
+			},
+		},
+	}
+	for i := 0; i < endpointCount; i++ {
+		backend.Status.Endpoints = append(backend.Status.Endpoints, fleetnetv1beta1.TrafficManagerEndpointStatus{
+			Name: fmt.Sprintf("endpoint-%d", i),
+		})
+	}
+	return backend
+}
+
+// sourceFragment is a single source of the summary together with the part of the summary it contributes to.
+type sourceFragment struct {
+	name  string
+	add   func(s *sources)
+	apply func(status *fleetnetv1alpha1.ServiceNetworkingStatus)
+}
+
+var sourceFragments = []sourceFragment{
+	{
+		name: "ServiceImport",
+		add: func(s *sources) {
+			s.serviceImport = resolvedServiceImport()
+		},
+		apply: func(status *fleetnetv1alpha1.ServiceNetworkingStatus) {
+			status.ServiceImportResolved = true
+		},
+	},
+	{
+		name: "InternalServiceExports",
+		add: func(s *sources) {
+			s.internalServiceExports = []fleetnetv1alpha1.InternalServiceExport{
+				*internalServiceExport(memberClusterID2, true),
+				*internalServiceExport(memberClusterID1, false),
+			}
+		},
+		apply: func(status *fleetnetv1alpha1.ServiceNetworkingStatus) {
+			status.ExportingClusters = []string{memberClusterID1, memberClusterID2}
+			status.ExportingClusterCount = 2
+			status.ConflictedClusters = []string{memberClusterID2}
+			status.Conflicted = true
+		},
+	},
+	{
+		name: "EndpointSliceExports",
+		add: func(s *sources) {
+			s.endpointSliceExports = []fleetnetv1alpha1.EndpointSliceExport{
+				*endpointSliceExport(memberClusterID1, "app-1", exportedSince2),
+				*endpointSliceExport(memberClusterID1, "app-2", exportedSince1),
+				*endpointSliceExport(memberClusterID2, "app-3", metav1.Time{}),
+			}
+		},
+		apply: func(status *fleetnetv1alpha1.ServiceNetworkingStatus) {
+			status.EndpointSliceExportCount = 3
+			status.EndpointsLastChangedTime = ptr.To(exportedSince2)
+		},
+	},
+	{
+		name: "InternalServiceImports",
+		add: func(s *sources) {
+			s.internalServiceImports = []fleetnetv1alpha1.InternalServiceImport{
+				*internalServiceImport(memberClusterID3),
+				*internalServiceImport(memberClusterID1),
+			}
+		},
+		apply: func(status *fleetnetv1alpha1.ServiceNetworkingStatus) {
+			status.ImportingClusters = []string{memberClusterID1, memberClusterID3}
+			status.ImportingClusterCount = 2
+		},
+	},
+	{
+		name: "TrafficManagerBackends",
+		add: func(s *sources) {
+			s.trafficManagerBackends = []fleetnetv1beta1.TrafficManagerBackend{
+				*trafficManagerBackend(metav1.ConditionTrue, 1, 2),
+			}
+		},
+		apply: func(status *fleetnetv1alpha1.ServiceNetworkingStatus) {
+			status.TrafficManagerBackends = []fleetnetv1alpha1.TrafficManagerBackendSummary{
+				{
+					Name:          testBackendName,
+					Profile:       testProfileName,
+					Accepted:      metav1.ConditionTrue,
+					EndpointCount: 2,
+				},
+			}
+			status.TrafficManagerHealthy = ptr.To(true)
+		},
+	},
+}
+
+// TestSummarize_PartialPresence summarizes every combination of present and absent sources; the summary of a
+// combination is the union of the contributions of the present sources.
+func TestSummarize_PartialPresence(t *testing.T) {
+	for mask := 0; mask < 1<<len(sourceFragments); mask++ {
+		var present []string
+		src := &sources{}
+		want := fleetnetv1alpha1.ServiceNetworkingStatus{}
+		for i, fragment := range sourceFragments {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			present = append(present, fragment.name)
+			fragment.add(src)
+			fragment.apply(&want)
+		}
+		name := "none"
+		if len(present) > 0 {
+			name = strings.Join(present, "+")
+		}
+		t.Run(name, func(t *testing.T) {
+			got := summarize(src)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("summarize() mismatch (-want, +got):\n%s", diff)
+			}
+
+			wantExported := src.serviceImport != nil || len(src.internalServiceExports) > 0
+			if gotExported := src.isServiceExported(); gotExported != wantExported {
+				t.Errorf("isServiceExported() = %v, want %v", gotExported, wantExported)
+			}
+		})
+	}
+}
+
+func TestSummarize_DeletingSources(t *testing.T) {
+	deletionTimestamp := metav1.Now()
+	svcImport := resolvedServiceImport()
+	svcImport.DeletionTimestamp = &deletionTimestamp
+	internalSvcExport := internalServiceExport(memberClusterID1, true)
+	internalSvcExport.DeletionTimestamp = &deletionTimestamp
+	sliceExport := endpointSliceExport(memberClusterID1, "app-1", exportedSince1)
+	sliceExport.DeletionTimestamp = &deletionTimestamp
+	internalSvcImport := internalServiceImport(memberClusterID1)
+	internalSvcImport.DeletionTimestamp = &deletionTimestamp
+	backend := trafficManagerBackend(metav1.ConditionTrue, 1, 1)
+	backend.DeletionTimestamp = &deletionTimestamp
+
+	src := &sources{
+		serviceImport:          svcImport,
+		internalServiceExports: []fleetnetv1alpha1.InternalServiceExport{*internalSvcExport},
+		endpointSliceExports:   []fleetnetv1alpha1.EndpointSliceExport{*sliceExport},
+		internalServiceImports: []fleetnetv1alpha1.InternalServiceImport{*internalSvcImport},
+		trafficManagerBackends: []fleetnetv1beta1.TrafficManagerBackend{*backend},
+	}
+	if diff := cmp.Diff(fleetnetv1alpha1.ServiceNetworkingStatus{}, summarize(src)); diff != "" {
+		t.Errorf("summarize() mismatch (-want, +got):\n%s", diff)
+	}
+	if src.isServiceExported() {
+		t.Errorf("isServiceExported() = true, want false")
+	}
+}
+
+func TestSummarizeTrafficManagerBackends(t *testing.T) {
+	tests := []struct {
+		name        string
+		backends    []fleetnetv1beta1.TrafficManagerBackend
+		wantAccept  []metav1.ConditionStatus
+		wantHealthy *bool
+	}{
+		{
+			name: "no backends",
+		},
+		{
+			name:        "accepted backend with endpoints",
+			backends:    []fleetnetv1beta1.TrafficManagerBackend{*trafficManagerBackend(metav1.ConditionTrue, 1, 1)},
+			wantAccept:  []metav1.ConditionStatus{metav1.ConditionTrue},
+			wantHealthy: ptr.To(true),
+		},
+		{
+			name:        "accepted backend without endpoints",
+			backends:    []fleetnetv1beta1.TrafficManagerBackend{*trafficManagerBackend(metav1.ConditionTrue, 1, 0)},
+			wantAccept:  []metav1.ConditionStatus{metav1.ConditionTrue},
+			wantHealthy: ptr.To(false),
+		},
+		{
+			name:        "rejected backend",
+			backends:    []fleetnetv1beta1.TrafficManagerBackend{*trafficManagerBackend(metav1.ConditionFalse, 1, 1)},
+			wantAccept:  []metav1.ConditionStatus{metav1.ConditionFalse},
+			wantHealthy: ptr.To(false),
+		},
+		{
+			name:        "stale condition",
+			backends:    []fleetnetv1beta1.TrafficManagerBackend{*trafficManagerBackend(metav1.ConditionTrue, 0, 1)},
+			wantAccept:  []metav1.ConditionStatus{metav1.ConditionUnknown},
+			wantHealthy: ptr.To(false),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			summaries, healthy := summarizeTrafficManagerBackends(tc.backends)
+			var gotAccept []metav1.ConditionStatus
+			for _, s := range summaries {
+				gotAccept = append(gotAccept, s.Accepted)
+			}
+			if diff := cmp.Diff(tc.wantAccept, gotAccept); diff != "" {
+				t.Errorf("summarizeTrafficManagerBackends() accepted mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantHealthy, healthy); diff != "" {
+				t.Errorf("summarizeTrafficManagerBackends() healthy mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
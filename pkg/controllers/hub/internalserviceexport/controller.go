@@ -9,6 +9,8 @@ package internalserviceexport
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -23,6 +25,7 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/exportconflict"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
@@ -111,14 +114,128 @@ func (r *Reconciler) handleDelete(ctx context.Context, internalServiceExport *fl
 		return ctrl.Result{RequeueAfter: r.RetryInternal}, nil
 	}
 
+	exports, err := r.listInternalServiceExports(ctx, internalServiceExport)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	remaining := exportsWithout(exports, internalServiceExport)
 	oldStatus := serviceImport.Status.DeepCopy()
-	removeClusterFromServiceImportStatus(serviceImport, internalServiceExport.Spec.ServiceReference.ClusterID)
+	clusterID := internalServiceExport.Spec.ServiceReference.ClusterID
+	canonicalClusterID := canonicalClusterID(serviceImport, exports)
+	listed := isListed(serviceImport, clusterID)
+	removeClusterFromServiceImportStatus(serviceImport, clusterID)
+	var conflicted []*fleetnetv1alpha1.InternalServiceExport
+	var conflictReasons map[string]string
+	if listed && len(serviceImport.Status.Clusters) > 0 {
+		// The ports only the export being deleted exposes are dropped.
+		ports := exportconflict.MergeExportedPorts(exportconflict.ListedExports(serviceImport, exports))
+		hasConflicted := exportconflict.HasUnlistedExports(serviceImport, exports, clusterID)
+		switch {
+		case hasConflicted && !exportconflict.EqualServicePorts(ports, oldStatus.Ports):
+			// Reset the status so that the ServiceImport controller resolves the spec again and recomputes the
+			// conflict conditions of all the remaining exports, as the conflicted ones may no longer be.
+			klog.V(2).InfoS("The merged ports change while other internalServiceExports are in conflict; the serviceImport spec will be resolved again",
+				"serviceImport", serviceImportKRef, "internalServiceExport", internalServiceExportKObj)
+			exportconflict.ResetResolution(serviceImport)
+		case hasConflicted && canonicalClusterID == clusterID:
+			// The export being deleted is the one the other exports are merged into; the next oldest export takes over.
+			res := exportconflict.Resolve(remaining)
+			if res.Ports == nil || !exportconflict.EqualServicePorts(ports, *res.Ports) || res.Type != oldStatus.Type {
+				klog.V(2).InfoS("The next oldest internalServiceExport exports an incompatible service; the serviceImport spec will be resolved again",
+					"serviceImport", serviceImportKRef, "internalServiceExport", internalServiceExportKObj)
+				exportconflict.ResetResolution(serviceImport)
+			} else {
+				serviceImport.Status.Ports = ports
+				canonicalClusterID = res.Canonical.Spec.ServiceReference.ClusterID
+				conflicted = res.Conflicted
+				conflictReasons = res.ConflictReasons
+			}
+		default:
+			serviceImport.Status.Ports = ports
+		}
+	}
 	if err := r.updateServiceImportStatus(ctx, serviceImport, oldStatus); err != nil {
 		return ctrl.Result{}, err
 	}
+	// The conflicted exports name the cluster whose export the other exports are merged into, which has changed.
+	for _, v := range conflicted {
+		if err := r.updateInternalServiceExportStatus(ctx, v, true, canonicalClusterID, conflictReasons[v.Spec.ServiceReference.ClusterID]); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	// The remaining exports no longer conflict with the export being deleted.
+	if err := r.updateWouldConflictConditions(ctx, remaining); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.updateOpenAPISpecCatalog(ctx, serviceImport, internalServiceExport, false); err != nil {
+		return ctrl.Result{}, err
+	}
 	return r.removeFinalizer(ctx, internalServiceExport)
 }
 
+// listInternalServiceExports lists the exports of the same service as the given export, including the export itself.
+//
+// The exports are listed with the index registered by the ServiceImport controller.
+func (r *Reconciler) listInternalServiceExports(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport) ([]fleetnetv1alpha1.InternalServiceExport, error) {
+	internalServiceExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	listOpts := client.MatchingFields{
+		objectmeta.InternalServiceExportFieldServiceNamespacedName: internalServiceExport.Spec.ServiceReference.NamespacedName,
+	}
+	if err := r.Client.List(ctx, internalServiceExportList, listOpts); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceExports of the service", "internalServiceExport", klog.KObj(internalServiceExport))
+		return nil, err
+	}
+	return internalServiceExportList.Items, nil
+}
+
+// exportsWithout returns the exports of the same service, except for the given export, e.g. the one being deleted.
+func exportsWithout(exports []fleetnetv1alpha1.InternalServiceExport, internalServiceExport *fleetnetv1alpha1.InternalServiceExport) []fleetnetv1alpha1.InternalServiceExport {
+	remaining := make([]fleetnetv1alpha1.InternalServiceExport, 0, len(exports))
+	for _, v := range exports {
+		if client.ObjectKeyFromObject(&v) != client.ObjectKeyFromObject(internalServiceExport) {
+			remaining = append(remaining, v)
+		}
+	}
+	return remaining
+}
+
+// exportsWith returns the exports of the same service with the given export in place of its cached copy, which
+// may be older than the export just handled.
+func exportsWith(exports []fleetnetv1alpha1.InternalServiceExport, internalServiceExport *fleetnetv1alpha1.InternalServiceExport) []fleetnetv1alpha1.InternalServiceExport {
+	return append(exportsWithout(exports, internalServiceExport), *internalServiceExport)
+}
+
+// isListed returns true if the ServiceImport lists the cluster.
+func isListed(serviceImport *fleetnetv1alpha1.ServiceImport, clusterID string) bool {
+	for _, c := range serviceImport.Status.Clusters {
+		if c.Cluster == clusterID {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalClusterID returns the cluster whose export the other exports of the ServiceImport are merged into, which
+// the conflicted exports name; the first cluster the ServiceImport lists is returned if none of the exports of the
+// listed clusters has been cached yet.
+func canonicalClusterID(serviceImport *fleetnetv1alpha1.ServiceImport, exports []fleetnetv1alpha1.InternalServiceExport) string {
+	if canonical := exportconflict.Canonical(serviceImport, exports); canonical != nil {
+		return canonical.Spec.ServiceReference.ClusterID
+	}
+	if len(serviceImport.Status.Clusters) > 0 {
+		return serviceImport.Status.Clusters[0].Cluster
+	}
+	return ""
+}
+
+// sortClusters sorts the clusters of the ServiceImport by name, so that the status does not change with the order
+// in which the clusters export the service.
+func sortClusters(serviceImport *fleetnetv1alpha1.ServiceImport) {
+	sort.Slice(serviceImport.Status.Clusters, func(i, j int) bool {
+		return serviceImport.Status.Clusters[i].Cluster < serviceImport.Status.Clusters[j].Cluster
+	})
+}
+
 func removeClusterFromServiceImportStatus(serviceImport *fleetnetv1alpha1.ServiceImport, clusterID string) {
 	var updatedClusters []fleetnetv1alpha1.ClusterStatus
 	for _, c := range serviceImport.Status.Clusters {
@@ -127,19 +244,27 @@ func removeClusterFromServiceImportStatus(serviceImport *fleetnetv1alpha1.Servic
 		}
 	}
 	if len(updatedClusters) == 0 {
-		serviceImport.Status = fleetnetv1alpha1.ServiceImportStatus{}
+		exportconflict.ResetResolution(serviceImport)
 	} else {
 		serviceImport.Status.Clusters = updatedClusters
+		sortClusters(serviceImport)
 	}
 }
 
 func addClusterToServiceImportStatus(serviceImport *fleetnetv1alpha1.ServiceImport, clusterID string) {
+	found := false
 	for _, c := range serviceImport.Status.Clusters {
 		if c.Cluster == clusterID {
-			return
+			found = true
+			break
 		}
 	}
-	serviceImport.Status.Clusters = append(serviceImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: clusterID})
+	if !found {
+		serviceImport.Status.Clusters = append(serviceImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: clusterID})
+	}
+	// The clusters listed by the ServiceImports written by older versions, in the order they were exported, are
+	// sorted as well.
+	sortClusters(serviceImport)
 }
 
 func (r *Reconciler) updateServiceImportStatus(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport, oldStatus *fleetnetv1alpha1.ServiceImportStatus) error {
@@ -166,13 +291,16 @@ func (r *Reconciler) removeFinalizer(ctx context.Context, internalServiceExport
 	return ctrl.Result{}, nil
 }
 
-func (r *Reconciler) updateInternalServiceExportStatus(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, conflict bool) error {
+// updateInternalServiceExportStatus sets the conflict condition of an InternalServiceExport; authoritativeClusterID is
+// the cluster whose export defines the spec of the ServiceImport, which the conflicted condition names along with the
+// reason of the conflict.
+func (r *Reconciler) updateInternalServiceExportStatus(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, conflict bool, authoritativeClusterID, reason string) error {
 	desiredCond := condition.UnconflictedServiceExportConflictCondition(*internalServiceExport)
 	if conflict {
-		desiredCond = condition.ConflictedServiceExportConflictCondition(*internalServiceExport)
+		desiredCond = condition.ConflictedServiceExportConflictCondition(*internalServiceExport, authoritativeClusterID, reason)
 	}
 	currentCond := meta.FindStatusCondition(internalServiceExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
-	if condition.EqualCondition(currentCond, &desiredCond) {
+	if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
 		return nil
 	}
 	exportKObj := klog.KObj(internalServiceExport)
@@ -187,6 +315,137 @@ func (r *Reconciler) updateInternalServiceExportStatus(ctx context.Context, inte
 	return nil
 }
 
+// updateWouldConflictConditions sets the advisory WouldConflict condition of the exports of a service to the
+// clusters on the other side of their conflicts: the exports in conflict name the clusters whose exports are merged
+// into the ServiceImport, and the merged exports name the clusters whose exports are in conflict. The condition is
+// removed from the exports which conflict with none, and the exports whose conflicts have not been resolved yet are
+// left alone.
+func (r *Reconciler) updateWouldConflictConditions(ctx context.Context, exports []fleetnetv1alpha1.InternalServiceExport) error {
+	var conflictedClusters, mergedClusters []string
+	for i := range exports {
+		if exports[i].DeletionTimestamp != nil {
+			continue
+		}
+		cond := meta.FindStatusCondition(exports[i].Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+		switch {
+		case cond == nil:
+			continue
+		case cond.Status == metav1.ConditionTrue:
+			conflictedClusters = append(conflictedClusters, exports[i].Spec.ServiceReference.ClusterID)
+		default:
+			mergedClusters = append(mergedClusters, exports[i].Spec.ServiceReference.ClusterID)
+		}
+	}
+	sort.Strings(conflictedClusters)
+	sort.Strings(mergedClusters)
+
+	for i := range exports {
+		export := &exports[i]
+		cond := meta.FindStatusCondition(export.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+		if export.DeletionTimestamp != nil || cond == nil {
+			continue
+		}
+		clusters := conflictedClusters
+		if cond.Status == metav1.ConditionTrue {
+			clusters = mergedClusters
+		}
+		if err := r.updateWouldConflictCondition(ctx, export, clusters); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateWouldConflictCondition sets the WouldConflict condition of an export to the clusters its export conflicts
+// with, or removes it if there are none.
+func (r *Reconciler) updateWouldConflictCondition(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, clusterIDs []string) error {
+	condType := string(fleetnetv1alpha1.ServiceExportWouldConflict)
+	currentCond := meta.FindStatusCondition(internalServiceExport.Status.Conditions, condType)
+	if len(clusterIDs) == 0 {
+		if currentCond == nil {
+			return nil
+		}
+		meta.RemoveStatusCondition(&internalServiceExport.Status.Conditions, condType)
+	} else {
+		desiredCond := condition.WouldConflictCondition(*internalServiceExport, clusterIDs)
+		if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
+			return nil
+		}
+		meta.SetStatusCondition(&internalServiceExport.Status.Conditions, desiredCond)
+	}
+
+	exportKObj := klog.KObj(internalServiceExport)
+	klog.V(2).InfoS("Updating the would conflict condition of internalServiceExport", "internalServiceExport", exportKObj, "clusters", clusterIDs)
+	if err := r.Status().Update(ctx, internalServiceExport); err != nil {
+		klog.ErrorS(err, "Failed to update the would conflict condition of internalServiceExport", "internalServiceExport", exportKObj, "clusters", clusterIDs)
+		return err
+	}
+	return nil
+}
+
+// updateOpenAPISpecCatalog records the OpenAPI spec the export references, if any, in the OpenAPISpecCatalog
+// annotation of the ServiceImport; the spec is removed from the catalog if the export is not listed by the
+// ServiceImport, e.g. it is in conflict or being deleted.
+//
+// The annotation is patched, so that the ServiceImport is written only when the catalog changes and the writes of the
+// other controllers to the ServiceImport are kept.
+func (r *Reconciler) updateOpenAPISpecCatalog(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, listed bool) error {
+	clusterID := fleetnetv1alpha1.ClusterID(internalServiceExport.Spec.ServiceReference.ClusterID)
+	var spec fleetnetv1alpha1.OpenAPISpecReference
+	if configMap := internalServiceExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecConfigMap]; listed && configMap != "" {
+		spec = fleetnetv1alpha1.OpenAPISpecReference{
+			ConfigMap: types.NamespacedName{Namespace: internalServiceExport.Spec.ServiceReference.Namespace, Name: configMap}.String(),
+			Hash:      internalServiceExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecHash],
+		}
+	}
+	catalog := extractOpenAPISpecCatalog(serviceImport)
+	if catalog.MemberClusters[clusterID] == spec {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(serviceImport.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if spec.ConfigMap == "" {
+		delete(catalog.MemberClusters, clusterID)
+	} else {
+		catalog.MemberClusters[clusterID] = spec
+	}
+	if len(catalog.MemberClusters) == 0 {
+		delete(serviceImport.Annotations, objectmeta.ServiceImportAnnotationOpenAPISpecCatalog)
+	} else {
+		data, err := json.Marshal(catalog)
+		if err != nil {
+			klog.ErrorS(err, "Failed to marshal OpenAPISpecCatalog data", "serviceImport", klog.KObj(serviceImport))
+			return err
+		}
+		if serviceImport.Annotations == nil {
+			serviceImport.Annotations = map[string]string{}
+		}
+		serviceImport.Annotations[objectmeta.ServiceImportAnnotationOpenAPISpecCatalog] = string(data)
+	}
+	klog.V(2).InfoS("Updating the OpenAPI spec catalog of the serviceImport", "serviceImport", klog.KObj(serviceImport), "clusterID", clusterID, "spec", spec)
+	if err := r.Client.Patch(ctx, serviceImport, patch); err != nil {
+		klog.ErrorS(err, "Failed to update the OpenAPI spec catalog of the serviceImport", "serviceImport", klog.KObj(serviceImport), "clusterID", clusterID)
+		return err
+	}
+	return nil
+}
+
+// extractOpenAPISpecCatalog extracts the OpenAPISpecCatalog from the annotations on a ServiceImport.
+func extractOpenAPISpecCatalog(serviceImport *fleetnetv1alpha1.ServiceImport) *fleetnetv1alpha1.OpenAPISpecCatalog {
+	catalog := &fleetnetv1alpha1.OpenAPISpecCatalog{}
+	if data, ok := serviceImport.Annotations[objectmeta.ServiceImportAnnotationOpenAPISpecCatalog]; ok {
+		if err := json.Unmarshal([]byte(data), catalog); err != nil {
+			// The data is rebuilt as the exports are reconciled again.
+			klog.ErrorS(err, "Failed to unmarshal OpenAPISpecCatalog data", "serviceImport", klog.KObj(serviceImport), "data", data)
+			catalog = &fleetnetv1alpha1.OpenAPISpecCatalog{}
+		}
+	}
+	if catalog.MemberClusters == nil {
+		catalog.MemberClusters = map[fleetnetv1alpha1.ClusterID]fleetnetv1alpha1.OpenAPISpecReference{}
+	}
+	return catalog
+}
+
 func (r *Reconciler) handleUpdate(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport) (ctrl.Result, error) {
 	internalServiceExportKObj := klog.KObj(internalServiceExport)
 	// get serviceImport
@@ -218,32 +477,62 @@ func (r *Reconciler) handleUpdate(ctx context.Context, internalServiceExport *fl
 		return ctrl.Result{RequeueAfter: r.RetryInternal}, nil
 	}
 
+	exports, err := r.listInternalServiceExports(ctx, internalServiceExport)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	oldStatus := serviceImport.Status.DeepCopy()
 	clusterID := internalServiceExport.Spec.ServiceReference.ClusterID
 
-	// To simplify the implementation, we compare the whole ports structure.
-	// TODO, change to compare the ports by ignoring the order and protocol and port are the map keys.
-	if !equality.Semantic.DeepEqual(serviceImport.Status.Ports, internalServiceExport.Spec.Ports) {
+	merging := exportconflict.Merge(serviceImport, exports, internalServiceExport)
+	if merging.Conflict {
 		removeClusterFromServiceImportStatus(serviceImport, clusterID)
+		if len(serviceImport.Status.Clusters) > 0 {
+			serviceImport.Status.Ports = merging.Ports
+		}
 		if err := r.updateServiceImportStatus(ctx, serviceImport, oldStatus); err != nil {
 			return ctrl.Result{}, err
 		}
-		// It's possible, eg, there is only one serviceExport and its spec has been changed.
-		// ServiceImport stores the old spec of this ServiceExport and later the serviceExport changes its spec.
 		if len(serviceImport.Status.Ports) == 0 {
 			klog.V(3).InfoS("Removed the cluster and waiting for serviceImport controller to resolve the spec", "serviceImport", serviceImportKRef, "internalServiceExport", internalServiceExportKObj)
 			// Requeue the request and waiting for the ServiceImport controller to resolve the spec.
 			return ctrl.Result{RequeueAfter: r.RetryInternal}, nil
 		}
-		return ctrl.Result{}, r.updateInternalServiceExportStatus(ctx, internalServiceExport, true)
+		if err := r.updateOpenAPISpecCatalog(ctx, serviceImport, internalServiceExport, false); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.updateInternalServiceExportStatus(ctx, internalServiceExport, true, canonicalClusterID(serviceImport, exports), merging.Reason); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.updateWouldConflictConditions(ctx, exportsWith(exports, internalServiceExport))
+	}
+	if merging.ResolveAgain {
+		// It's possible, eg, the only cluster in the ServiceImport changes its ports or type, which the conflicted
+		// exports may now be compatible with.
+		klog.V(2).InfoS("The merged ports or type change while other internalServiceExports are in conflict; the serviceImport spec will be resolved again",
+			"serviceImport", serviceImportKRef, "internalServiceExport", internalServiceExportKObj)
+		exportconflict.ResetResolution(serviceImport)
+		if err := r.updateServiceImportStatus(ctx, serviceImport, oldStatus); err != nil {
+			return ctrl.Result{}, err
+		}
+		// Requeue the request and waiting for the ServiceImport controller to resolve the spec.
+		return ctrl.Result{RequeueAfter: r.RetryInternal}, nil
 	}
 
 	addClusterToServiceImportStatus(serviceImport, clusterID)
+	serviceImport.Status.Ports = merging.Ports
+	serviceImport.Status.Type = merging.Type
 	if err := r.updateServiceImportStatus(ctx, serviceImport, oldStatus); err != nil {
 		return ctrl.Result{}, err
 	}
+	if err := r.updateOpenAPISpecCatalog(ctx, serviceImport, internalServiceExport, true); err != nil {
+		return ctrl.Result{}, err
+	}
 
-	return ctrl.Result{}, r.updateInternalServiceExportStatus(ctx, internalServiceExport, false)
+	if err := r.updateInternalServiceExportStatus(ctx, internalServiceExport, false, "", ""); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.updateWouldConflictConditions(ctx, exportsWith(exports, internalServiceExport))
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -297,10 +297,10 @@ var _ = Describe("Test InternalServiceExport Controller", func() {
 					Ports: importServicePorts,
 					Clusters: []fleetnetv1alpha1.ClusterStatus{
 						{
-							Cluster: "other-cluster",
+							Cluster: testClusterID,
 						},
 						{
-							Cluster: testClusterID,
+							Cluster: "other-cluster",
 						},
 					},
 					Type: fleetnetv1alpha1.ClusterSetIP,
@@ -352,12 +352,13 @@ var _ = Describe("Test InternalServiceExport Controller", func() {
 			}, timeout, interval).Should(BeEmpty())
 		})
 
-		It("ServiceImport has different ports spec as internalServiceExportA", func() {
+		It("ServiceImport has ports conflicting with internalServiceExportA", func() {
 			By("Updating serviceImport status")
+			// Port 8080 is named differently, so that the ports of internalServiceExportA cannot be merged.
 			serviceImport.Status = fleetnetv1alpha1.ServiceImportStatus{
 				Ports: []fleetnetv1alpha1.ServicePort{
 					{
-						Name:        "portA",
+						Name:        "portC",
 						Protocol:    corev1.ProtocolTCP,
 						Port:        8080,
 						AppProtocol: &appProtocol,
@@ -388,7 +389,8 @@ var _ = Describe("Test InternalServiceExport Controller", func() {
 			Eventually(func() string {
 				want := fleetnetv1alpha1.InternalServiceExportStatus{
 					Conditions: []metav1.Condition{
-						conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+						conflictedServiceExportConflictCondition(testNamespace, testServiceName, "other-cluster",
+							`port 8080/TCP is named "portA", but the other clusters name it "portC"`),
 					},
 				}
 				key := types.NamespacedName{Namespace: testMemberClusterA, Name: testName}
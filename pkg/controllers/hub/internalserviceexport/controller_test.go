@@ -14,6 +14,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,6 +36,9 @@ const (
 
 	conditionReasonNoConflictFound = "NoConflictFound"
 	conditionReasonConflictFound   = "ConflictFound"
+
+	// portNameConflictReason is the reason of the conflict of the exports whose port 8080 is named differently.
+	portNameConflictReason = `port 8080/TCP is named "portC", but the other clusters name it "portA"`
 )
 
 var (
@@ -85,6 +89,26 @@ func internalServiceExportForTest() *fleetnetv1alpha1.InternalServiceExport {
 	}
 }
 
+// otherInternalServiceExportForTest returns an InternalServiceExport of the test service from another cluster, whose
+// service is created at the given time.
+func otherInternalServiceExportForTest(clusterID string, ports []fleetnetv1alpha1.ServicePort, created time.Time, conflict bool) *fleetnetv1alpha1.InternalServiceExport {
+	export := internalServiceExportForTest()
+	export.Namespace = clusterID + "-ns"
+	export.Finalizers = []string{objectmeta.InternalServiceExportFinalizer}
+	export.Spec.Ports = ports
+	export.Spec.ServiceReference.ClusterID = clusterID
+	export.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(created)
+	if conflict {
+		export.Status.Conditions = []metav1.Condition{conflictedServiceExportConflictCondition(testNamespace, testServiceName, testClusterID, "")}
+	}
+	return export
+}
+
+// exportedServiceNamespacedName indexes the InternalServiceExports by service, as the ServiceImport controller does.
+func exportedServiceNamespacedName(o client.Object) []string {
+	return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+}
+
 func internalServiceExportReconciler(client client.Client) *Reconciler {
 	return &Reconciler{
 		Client:        client,
@@ -103,14 +127,20 @@ func unconflictedServiceExportConflictCondition(svcNamespace string, svcName str
 	}
 }
 
-func conflictedServiceExportConflictCondition(svcNamespace string, svcName string) metav1.Condition {
+// conflictedServiceExportConflictCondition returns the conflicted condition of an export in conflict with the export
+// of authoritativeClusterID; reason tells why the export is in conflict.
+func conflictedServiceExportConflictCondition(svcNamespace string, svcName string, authoritativeClusterID string, reason string) metav1.Condition {
+	message := fmt.Sprintf("service %s/%s is in conflict with the service exported first by cluster %s", svcNamespace, svcName, authoritativeClusterID)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
 	return metav1.Condition{
 		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
 		Status:             metav1.ConditionTrue,
 		ObservedGeneration: 0,
 		LastTransitionTime: metav1.Now(),
 		Reason:             conditionReasonConflictFound,
-		Message:            fmt.Sprintf("service %s/%s is in conflict with other exported services", svcNamespace, svcName),
+		Message:            message,
 	}
 }
 
@@ -152,10 +182,22 @@ func TestHandleDelete(t *testing.T) {
 			TargetPort: intstr.IntOrString{IntVal: 9090},
 		},
 	}
+	// otherPorts listen on the port of portA under another name; they conflict with importServicePorts.
+	otherPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:     "portC",
+			Protocol: corev1.ProtocolTCP,
+			Port:     8080,
+		},
+	}
+	now := time.Now()
 	tests := []struct {
-		name              string
-		serviceImport     *fleetnetv1alpha1.ServiceImport
-		wantServiceImport *fleetnetv1alpha1.ServiceImport
+		name               string
+		serviceImport      *fleetnetv1alpha1.ServiceImport
+		internalSvcExports []*fleetnetv1alpha1.InternalServiceExport
+		wantServiceImport  *fleetnetv1alpha1.ServiceImport
+		// wantConditions are the conflict conditions of the other exports, keyed by their namespaces.
+		wantConditions map[string]metav1.Condition
 	}{
 		{
 			name: "serviceImport has been deleted",
@@ -184,6 +226,46 @@ func TestHandleDelete(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "the conditions set by other controllers are kept when the serviceImport spec is reset",
+			serviceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: testClusterID,
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+							Status: metav1.ConditionTrue,
+							Reason: "EndpointsAvailable",
+						},
+					},
+				},
+			},
+			wantServiceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(fleetnetv1alpha1.ServiceImportEndpointsAvailable),
+							Status: metav1.ConditionTrue,
+							Reason: "EndpointsAvailable",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "there is another serviceExport with the same spec as the deleting one",
 			serviceImport: &fleetnetv1alpha1.ServiceImport{
@@ -204,6 +286,9 @@ func TestHandleDelete(t *testing.T) {
 					Type: fleetnetv1alpha1.ClusterSetIP,
 				},
 			},
+			internalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{
+				otherInternalServiceExportForTest("member-2", importServicePorts, now, false),
+			},
 			wantServiceImport: &fleetnetv1alpha1.ServiceImport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testServiceName,
@@ -220,6 +305,88 @@ func TestHandleDelete(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "the next oldest serviceExport conflicts with the ServiceImport",
+			serviceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: testClusterID,
+						},
+						{
+							Cluster: "member-3",
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
+			},
+			internalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{
+				otherInternalServiceExportForTest("member-2", otherPorts, now.Add(-time.Hour), true),
+				otherInternalServiceExportForTest("member-3", importServicePorts, now, false),
+			},
+			// The ServiceImport controller resolves the spec again.
+			wantServiceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+			},
+		},
+		{
+			name: "the next oldest serviceExport has the same spec as the deleting one",
+			serviceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: testClusterID,
+						},
+						{
+							Cluster: "member-4",
+						},
+						{
+							Cluster: "member-3",
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
+			},
+			internalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{
+				otherInternalServiceExportForTest("member-2", otherPorts, now, true),
+				otherInternalServiceExportForTest("member-3", importServicePorts, now.Add(-time.Hour), false),
+				otherInternalServiceExportForTest("member-4", importServicePorts, now.Add(-time.Minute), false),
+			},
+			wantServiceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: "member-3",
+						},
+						{
+							Cluster: "member-4",
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
+			},
+			wantConditions: map[string]metav1.Condition{
+				"member-2-ns": conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-3", portNameConflictReason),
+			},
+		},
 		{
 			name: "deleting serviceExport conflicts with the ServiceImport",
 			serviceImport: &fleetnetv1alpha1.ServiceImport{
@@ -275,16 +442,22 @@ func TestHandleDelete(t *testing.T) {
 
 			internalSvcExportObj := internalServiceExportForTest()
 			internalSvcExportObj.Finalizers = []string{objectmeta.InternalServiceExportFinalizer}
-			now := metav1.Now()
-			internalSvcExportObj.DeletionTimestamp = &now
+			// The deleting export is the oldest, i.e. it defines the spec of the ServiceImport if its cluster is listed.
+			internalSvcExportObj.Spec.ServiceReference.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Hour))
+			deletedAt := metav1.Now()
+			internalSvcExportObj.DeletionTimestamp = &deletedAt
 			objects := []client.Object{internalSvcExportObj}
 			if tc.serviceImport != nil {
 				objects = append(objects, tc.serviceImport)
 			}
+			for _, v := range tc.internalSvcExports {
+				objects = append(objects, v)
+			}
 			fakeClient := fake.NewClientBuilder().
 				WithScheme(internalServiceExportScheme(t)).
 				WithObjects(objects...).
 				WithStatusSubresource(objects...).
+				WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
 				Build()
 
 			r := internalServiceExportReconciler(fakeClient)
@@ -317,6 +490,15 @@ func TestHandleDelete(t *testing.T) {
 					t.Errorf("ServiceImport() mismatch (-want, +got):\n%s", diff)
 				}
 			}
+			for namespace, wantCond := range tc.wantConditions {
+				got := fleetnetv1alpha1.InternalServiceExport{}
+				if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: testName}, &got); err != nil {
+					t.Fatalf("InternalServiceExport Get() got error %v, want no error", err)
+				}
+				if diff := cmp.Diff([]metav1.Condition{wantCond}, got.Status.Conditions, options...); diff != "" {
+					t.Errorf("InternalServiceExport %s conditions mismatch (-want, +got):\n%s", namespace, diff)
+				}
+			}
 		})
 	}
 }
@@ -385,13 +567,38 @@ func TestHandleUpdate(t *testing.T) {
 			TargetPort: intstr.IntOrString{IntVal: 9090},
 		},
 	}
+	// conflictingPorts cannot be merged with importServicePorts, as port 8080 is named differently.
+	conflictingPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:        "portC",
+			Protocol:    corev1.ProtocolTCP,
+			Port:        8080,
+			AppProtocol: &appProtocol,
+			TargetPort:  intstr.IntOrString{IntVal: 8080},
+		},
+	}
+	// mergedServicePorts are importServicePorts with an extra port, which is compatible with them.
+	mergedServicePorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:       "portC",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       7070,
+			TargetPort: intstr.IntOrString{IntVal: 7070},
+		},
+		importServicePorts[0],
+		importServicePorts[1],
+	}
+	// otherInternalSvcExport is the export of member-2, which exports the ports of the ServiceImport.
+	otherInternalSvcExport := otherInternalServiceExportForTest("member-2", importServicePorts, time.Now().Add(-time.Hour), false)
 	tests := []struct {
-		name                  string
-		internalSvcExport     *fleetnetv1alpha1.InternalServiceExport
-		serviceImport         *fleetnetv1alpha1.ServiceImport
-		want                  ctrl.Result
-		wantInternalSvcExport *fleetnetv1alpha1.InternalServiceExport
-		wantServiceImport     *fleetnetv1alpha1.ServiceImport
+		name              string
+		internalSvcExport *fleetnetv1alpha1.InternalServiceExport
+		serviceImport     *fleetnetv1alpha1.ServiceImport
+		// otherInternalSvcExports are the exports of the other clusters.
+		otherInternalSvcExports []*fleetnetv1alpha1.InternalServiceExport
+		want                    ctrl.Result
+		wantInternalSvcExport   *fleetnetv1alpha1.InternalServiceExport
+		wantServiceImport       *fleetnetv1alpha1.ServiceImport
 	}{
 		{
 			name: "no serviceImport exists",
@@ -535,10 +742,10 @@ func TestHandleUpdate(t *testing.T) {
 					Ports: importServicePorts,
 					Clusters: []fleetnetv1alpha1.ClusterStatus{
 						{
-							Cluster: "member-2",
+							Cluster: testClusterID,
 						},
 						{
-							Cluster: testClusterID,
+							Cluster: "member-2",
 						},
 					},
 					Type: fleetnetv1alpha1.ClusterSetIP,
@@ -546,22 +753,14 @@ func TestHandleUpdate(t *testing.T) {
 			},
 		},
 		{
-			name: "serviceExport just created and has the different spec as serviceImport",
+			name: "serviceExport just created and its ports conflict with serviceImport",
 			internalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
 					Namespace: testMemberNamespace,
 				},
 				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
-					Ports: []fleetnetv1alpha1.ServicePort{
-						{
-							Name:        "portA",
-							Protocol:    corev1.ProtocolTCP,
-							Port:        8080,
-							AppProtocol: &appProtocol,
-							TargetPort:  intstr.IntOrString{IntVal: 8080},
-						},
-					},
+					Ports: conflictingPorts,
 					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
 						ClusterID:       testClusterID,
 						Kind:            "Service",
@@ -588,22 +787,15 @@ func TestHandleUpdate(t *testing.T) {
 					Type: fleetnetv1alpha1.ClusterSetIP,
 				},
 			},
-			want: ctrl.Result{},
+			otherInternalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{otherInternalSvcExport},
+			want:                    ctrl.Result{},
 			wantInternalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
 					Namespace: testMemberNamespace,
 				},
 				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
-					Ports: []fleetnetv1alpha1.ServicePort{
-						{
-							Name:        "portA",
-							Protocol:    corev1.ProtocolTCP,
-							Port:        8080,
-							AppProtocol: &appProtocol,
-							TargetPort:  intstr.IntOrString{IntVal: 8080},
-						},
-					},
+					Ports: conflictingPorts,
 					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
 						ClusterID:       testClusterID,
 						Kind:            "Service",
@@ -616,7 +808,7 @@ func TestHandleUpdate(t *testing.T) {
 				},
 				Status: fleetnetv1alpha1.InternalServiceExportStatus{
 					Conditions: []metav1.Condition{
-						conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+						conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-2", portNameConflictReason),
 					},
 				},
 			},
@@ -637,22 +829,93 @@ func TestHandleUpdate(t *testing.T) {
 			},
 		},
 		{
-			name: "update serviceExport and old serviceExport has the same spec as serviceImport",
+			name: "serviceExport just created and adds a port to serviceImport",
 			internalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
 					Namespace: testMemberNamespace,
 				},
 				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
-					Ports: []fleetnetv1alpha1.ServicePort{
+					Ports: mergedServicePorts[:1],
+					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+						ClusterID:       testClusterID,
+						Kind:            "Service",
+						Namespace:       testNamespace,
+						Name:            testServiceName,
+						ResourceVersion: "0",
+						Generation:      0,
+						UID:             "0",
+					},
+				},
+			},
+			serviceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
 						{
-							Name:        "portA",
-							Protocol:    corev1.ProtocolTCP,
-							Port:        8080,
-							AppProtocol: &appProtocol,
-							TargetPort:  intstr.IntOrString{IntVal: 8080},
+							Cluster: "member-2",
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
+			},
+			otherInternalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{otherInternalSvcExport},
+			want:                    ctrl.Result{},
+			wantInternalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testName,
+					Namespace: testMemberNamespace,
+				},
+				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					Ports: mergedServicePorts[:1],
+					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+						ClusterID:       testClusterID,
+						Kind:            "Service",
+						Namespace:       testNamespace,
+						Name:            testServiceName,
+						ResourceVersion: "0",
+						Generation:      0,
+						UID:             "0",
+					},
+				},
+				Status: fleetnetv1alpha1.InternalServiceExportStatus{
+					Conditions: []metav1.Condition{
+						unconflictedServiceExportConflictCondition(testNamespace, testServiceName),
+					},
+				},
+			},
+			wantServiceImport: &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: mergedServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: testClusterID,
+						},
+						{
+							Cluster: "member-2",
 						},
 					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
+			},
+		},
+		{
+			name: "update serviceExport to conflict with serviceImport and old serviceExport has the same spec as serviceImport",
+			internalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testName,
+					Namespace: testMemberNamespace,
+				},
+				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					Ports: conflictingPorts,
 					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
 						ClusterID:       testClusterID,
 						Kind:            "Service",
@@ -687,22 +950,15 @@ func TestHandleUpdate(t *testing.T) {
 					Type: fleetnetv1alpha1.ClusterSetIP,
 				},
 			},
-			want: ctrl.Result{},
+			otherInternalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{otherInternalSvcExport},
+			want:                    ctrl.Result{},
 			wantInternalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
 					Namespace: testMemberNamespace,
 				},
 				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
-					Ports: []fleetnetv1alpha1.ServicePort{
-						{
-							Name:        "portA",
-							Protocol:    corev1.ProtocolTCP,
-							Port:        8080,
-							AppProtocol: &appProtocol,
-							TargetPort:  intstr.IntOrString{IntVal: 8080},
-						},
-					},
+					Ports: conflictingPorts,
 					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
 						ClusterID:       testClusterID,
 						Kind:            "Service",
@@ -715,7 +971,7 @@ func TestHandleUpdate(t *testing.T) {
 				},
 				Status: fleetnetv1alpha1.InternalServiceExportStatus{
 					Conditions: []metav1.Condition{
-						conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+						conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-2", portNameConflictReason),
 					},
 				},
 			},
@@ -756,7 +1012,7 @@ func TestHandleUpdate(t *testing.T) {
 				},
 				Status: fleetnetv1alpha1.InternalServiceExportStatus{
 					Conditions: []metav1.Condition{
-						conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+						conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-2", ""),
 					},
 				},
 			},
@@ -775,7 +1031,8 @@ func TestHandleUpdate(t *testing.T) {
 					Type: fleetnetv1alpha1.ClusterSetIP,
 				},
 			},
-			want: ctrl.Result{},
+			otherInternalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{otherInternalSvcExport},
+			want:                    ctrl.Result{},
 			wantInternalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
@@ -808,10 +1065,10 @@ func TestHandleUpdate(t *testing.T) {
 					Ports: importServicePorts,
 					Clusters: []fleetnetv1alpha1.ClusterStatus{
 						{
-							Cluster: "member-2",
+							Cluster: testClusterID,
 						},
 						{
-							Cluster: testClusterID,
+							Cluster: "member-2",
 						},
 					},
 					Type: fleetnetv1alpha1.ClusterSetIP,
@@ -866,7 +1123,8 @@ func TestHandleUpdate(t *testing.T) {
 					Type: fleetnetv1alpha1.ClusterSetIP,
 				},
 			},
-			want: ctrl.Result{RequeueAfter: internalserviceexportRetryInterval},
+			// The ports of the only exporting cluster are the ports of the ServiceImport.
+			want: ctrl.Result{},
 			wantInternalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      testName,
@@ -903,7 +1161,23 @@ func TestHandleUpdate(t *testing.T) {
 					Name:      testServiceName,
 					Namespace: testNamespace,
 				},
-				Status: fleetnetv1alpha1.ServiceImportStatus{},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: []fleetnetv1alpha1.ServicePort{
+						{
+							Name:        "portA",
+							Protocol:    corev1.ProtocolTCP,
+							Port:        8080,
+							AppProtocol: &appProtocol,
+							TargetPort:  intstr.IntOrString{IntVal: 8080},
+						},
+					},
+					Clusters: []fleetnetv1alpha1.ClusterStatus{
+						{
+							Cluster: testClusterID,
+						},
+					},
+					Type: fleetnetv1alpha1.ClusterSetIP,
+				},
 			},
 		},
 	}
@@ -915,10 +1189,14 @@ func TestHandleUpdate(t *testing.T) {
 			if tc.serviceImport != nil {
 				objects = append(objects, tc.serviceImport)
 			}
+			for _, v := range tc.otherInternalSvcExports {
+				objects = append(objects, v)
+			}
 			fakeClient := fake.NewClientBuilder().
 				WithScheme(internalServiceExportScheme(t)).
 				WithObjects(objects...).
 				WithStatusSubresource(objects...).
+				WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
 				Build()
 
 			r := internalServiceExportReconciler(fakeClient)
@@ -956,3 +1234,306 @@ func TestHandleUpdate(t *testing.T) {
 		})
 	}
 }
+
+// TestHandleUpdate_ServiceImportClusters tests that the ServiceImport lists the exporting clusters sorted by name, and
+// that its status is only written when the clusters change.
+func TestHandleUpdate_ServiceImportClusters(t *testing.T) {
+	ports := internalServiceExportForTest().Spec.Ports
+	// otherPorts conflict with ports, as port 8080 is named differently.
+	otherPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:     "portC",
+			Protocol: corev1.ProtocolTCP,
+			Port:     8080,
+		},
+	}
+	now := time.Now()
+	tests := []struct {
+		name               string
+		clusters           []string
+		ports              []fleetnetv1alpha1.ServicePort
+		internalSvcExports []*fleetnetv1alpha1.InternalServiceExport
+		wantClusters       []string
+		wantStatusWritten  bool
+		// wantConflictWith is the cluster the export is in conflict with; the export is not in conflict if it is empty.
+		wantConflictWith string
+	}{
+		{
+			name:              "exporting cluster added",
+			clusters:          []string{"member-0", "member-2"},
+			ports:             ports,
+			wantClusters:      []string{"member-0", testClusterID, "member-2"},
+			wantStatusWritten: true,
+		},
+		{
+			name:     "exporting cluster removed",
+			clusters: []string{"member-0", testClusterID, "member-2"},
+			ports:    otherPorts,
+			internalSvcExports: []*fleetnetv1alpha1.InternalServiceExport{
+				otherInternalServiceExportForTest("member-0", ports, now, false),
+				otherInternalServiceExportForTest("member-2", ports, now.Add(-time.Hour), false),
+			},
+			wantClusters:      []string{"member-0", "member-2"},
+			wantStatusWritten: true,
+			// The oldest export defines the spec of the ServiceImport, no matter where its cluster is listed.
+			wantConflictWith: "member-2",
+		},
+		{
+			name:         "exporting cluster already listed",
+			clusters:     []string{testClusterID, "member-2"},
+			ports:        ports,
+			wantClusters: []string{testClusterID, "member-2"},
+		},
+		{
+			name:              "clusters listed in the order they were exported",
+			clusters:          []string{"member-2", testClusterID},
+			ports:             ports,
+			wantClusters:      []string{testClusterID, "member-2"},
+			wantStatusWritten: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			internalSvcExport := internalServiceExportForTest()
+			internalSvcExport.Finalizers = []string{objectmeta.InternalServiceExportFinalizer}
+			internalSvcExport.Spec.Ports = tc.ports
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testServiceName,
+					Namespace: testNamespace,
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports: ports,
+					Type:  fleetnetv1alpha1.ClusterSetIP,
+				},
+			}
+			for _, cluster := range tc.clusters {
+				serviceImport.Status.Clusters = append(serviceImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: cluster})
+			}
+			objects := []client.Object{internalSvcExport, serviceImport}
+			for _, v := range tc.internalSvcExports {
+				objects = append(objects, v)
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(internalServiceExportScheme(t)).
+				WithObjects(objects...).
+				WithStatusSubresource(objects...).
+				WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
+				Build()
+			serviceImportKey := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+			before := fleetnetv1alpha1.ServiceImport{}
+			if err := fakeClient.Get(ctx, serviceImportKey, &before); err != nil {
+				t.Fatalf("ServiceImport Get() got error %v, want no error", err)
+			}
+
+			r := internalServiceExportReconciler(fakeClient)
+			if _, err := r.handleUpdate(ctx, internalSvcExport); err != nil {
+				t.Fatalf("handleUpdate() got error %v, want no error", err)
+			}
+
+			got := fleetnetv1alpha1.ServiceImport{}
+			if err := fakeClient.Get(ctx, serviceImportKey, &got); err != nil {
+				t.Fatalf("ServiceImport Get() got error %v, want no error", err)
+			}
+			gotClusters := make([]string, 0, len(got.Status.Clusters))
+			for _, c := range got.Status.Clusters {
+				gotClusters = append(gotClusters, c.Cluster)
+			}
+			if diff := cmp.Diff(tc.wantClusters, gotClusters); diff != "" {
+				t.Errorf("ServiceImport clusters mismatch (-want, +got):\n%s", diff)
+			}
+			if gotWritten := got.ResourceVersion != before.ResourceVersion; gotWritten != tc.wantStatusWritten {
+				t.Errorf("ServiceImport status written = %t, want %t", gotWritten, tc.wantStatusWritten)
+			}
+
+			gotExport := fleetnetv1alpha1.InternalServiceExport{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: testMemberNamespace, Name: testName}, &gotExport); err != nil {
+				t.Fatalf("InternalServiceExport Get() got error %v, want no error", err)
+			}
+			wantCond := unconflictedServiceExportConflictCondition(testNamespace, testServiceName)
+			if tc.wantConflictWith != "" {
+				wantCond = conflictedServiceExportConflictCondition(testNamespace, testServiceName, tc.wantConflictWith, portNameConflictReason)
+			}
+			if diff := cmp.Diff([]metav1.Condition{wantCond}, gotExport.Status.Conditions, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("InternalServiceExport conditions mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestHandleUpdate_WouldConflict tests that the exports on both sides of a conflict name the clusters on the other
+// side, and that the advisory condition is removed once the conflict is resolved.
+func TestHandleUpdate_WouldConflict(t *testing.T) {
+	ctx := context.Background()
+	ports := internalServiceExportForTest().Spec.Ports
+	// otherPorts conflict with ports, as port 8080 is named differently.
+	otherPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:     "portC",
+			Protocol: corev1.ProtocolTCP,
+			Port:     8080,
+		},
+	}
+	internalSvcExport := internalServiceExportForTest()
+	internalSvcExport.Finalizers = []string{objectmeta.InternalServiceExportFinalizer}
+	internalSvcExport.Spec.Ports = otherPorts
+	serviceImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testServiceName,
+			Namespace: testNamespace,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Ports:    ports,
+			Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: "member-2"}, {Cluster: "member-3"}},
+			Type:     fleetnetv1alpha1.ClusterSetIP,
+		},
+	}
+	objects := []client.Object{internalSvcExport, serviceImport}
+	for _, cluster := range []string{"member-2", "member-3"} {
+		v := otherInternalServiceExportForTest(cluster, ports, time.Now().Add(-time.Hour), false)
+		v.Status.Conditions = []metav1.Condition{unconflictedServiceExportConflictCondition(testNamespace, testServiceName)}
+		objects = append(objects, v)
+	}
+	// member-4 has not been handled by the hub controllers yet.
+	objects = append(objects, otherInternalServiceExportForTest("member-4", otherPorts, time.Now(), false))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(internalServiceExportScheme(t)).
+		WithObjects(objects...).
+		WithStatusSubresource(objects...).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
+		Build()
+	r := internalServiceExportReconciler(fakeClient)
+	checkWouldConflict := func(clusterID, wantMessage string) {
+		t.Helper()
+		key := types.NamespacedName{Namespace: clusterID + "-ns", Name: testName}
+		export := fleetnetv1alpha1.InternalServiceExport{}
+		if err := fakeClient.Get(ctx, key, &export); err != nil {
+			t.Fatalf("InternalServiceExport Get(%v) got error %v, want no error", key, err)
+		}
+		cond := meta.FindStatusCondition(export.Status.Conditions, string(fleetnetv1alpha1.ServiceExportWouldConflict))
+		switch {
+		case wantMessage == "" && cond != nil:
+			t.Errorf("InternalServiceExport %v WouldConflict condition = %+v, want none", key, cond)
+		case wantMessage != "" && (cond == nil || cond.Status != metav1.ConditionTrue || cond.Message != wantMessage):
+			t.Errorf("InternalServiceExport %v WouldConflict condition = %+v, want true with message %q", key, cond, wantMessage)
+		}
+	}
+
+	// The export is in conflict with the exports merged into the ServiceImport, which conflict with it in turn.
+	if _, err := r.handleUpdate(ctx, internalSvcExport); err != nil {
+		t.Fatalf("handleUpdate() got error %v, want no error", err)
+	}
+	checkWouldConflict(testClusterID, "service my-ns/my-svc conflicts with the services exported by clusters member-2, member-3")
+	checkWouldConflict("member-2", "service my-ns/my-svc conflicts with the services exported by clusters member-1")
+	checkWouldConflict("member-3", "service my-ns/my-svc conflicts with the services exported by clusters member-1")
+	checkWouldConflict("member-4", "")
+
+	// The conflict is resolved once the export is compatible with the other exports.
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: testMemberNamespace, Name: testName}, internalSvcExport); err != nil {
+		t.Fatalf("InternalServiceExport Get() got error %v, want no error", err)
+	}
+	internalSvcExport.Spec.Ports = ports
+	if _, err := r.handleUpdate(ctx, internalSvcExport); err != nil {
+		t.Fatalf("handleUpdate() got error %v, want no error", err)
+	}
+	for _, cluster := range []string{testClusterID, "member-2", "member-3", "member-4"} {
+		checkWouldConflict(cluster, "")
+	}
+}
+
+func TestOpenAPISpecCatalog(t *testing.T) {
+	ports := internalServiceExportForTest().Spec.Ports
+	// otherPorts conflict with ports, as port 8080 is named differently.
+	otherPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:     "portC",
+			Protocol: corev1.ProtocolTCP,
+			Port:     8080,
+		},
+	}
+	otherCatalog := `{"MemberClusters":{"member-2":{"ConfigMap":"my-ns/my-svc-spec","Hash":"4567cdef"}}}`
+	tests := []struct {
+		name        string
+		ports       []fleetnetv1alpha1.ServicePort
+		deleting    bool
+		catalog     string
+		wantCatalog string
+	}{
+		{
+			name:        "unconflicted export added to the catalog",
+			ports:       ports,
+			catalog:     otherCatalog,
+			wantCatalog: `{"MemberClusters":{"member-1":{"ConfigMap":"my-ns/my-svc-openapi","Hash":"0123abcd"},"member-2":{"ConfigMap":"my-ns/my-svc-spec","Hash":"4567cdef"}}}`,
+		},
+		{
+			name:        "conflicted export removed from the catalog",
+			ports:       otherPorts,
+			catalog:     `{"MemberClusters":{"member-1":{"ConfigMap":"my-ns/my-svc-openapi","Hash":"0123abcd"},"member-2":{"ConfigMap":"my-ns/my-svc-spec","Hash":"4567cdef"}}}`,
+			wantCatalog: otherCatalog,
+		},
+		{
+			name:     "deleted export removed from the catalog",
+			ports:    ports,
+			deleting: true,
+			catalog:  `{"MemberClusters":{"member-1":{"ConfigMap":"my-ns/my-svc-openapi","Hash":"0123abcd"}}}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			internalSvcExport := internalServiceExportForTest()
+			internalSvcExport.Finalizers = []string{objectmeta.InternalServiceExportFinalizer}
+			internalSvcExport.Annotations = map[string]string{
+				objectmeta.InternalServiceExportAnnotationOpenAPISpecConfigMap: "my-svc-openapi",
+				objectmeta.InternalServiceExportAnnotationOpenAPISpecHash:      "0123abcd",
+			}
+			internalSvcExport.Spec.Ports = tc.ports
+			clusters := []fleetnetv1alpha1.ClusterStatus{{Cluster: "member-2"}}
+			if tc.deleting {
+				internalSvcExport.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				clusters = append(clusters, fleetnetv1alpha1.ClusterStatus{Cluster: testClusterID})
+			}
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        testServiceName,
+					Namespace:   testNamespace,
+					Annotations: map[string]string{objectmeta.ServiceImportAnnotationOpenAPISpecCatalog: tc.catalog},
+				},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Ports:    ports,
+					Clusters: clusters,
+					Type:     fleetnetv1alpha1.ClusterSetIP,
+				},
+			}
+			objects := []client.Object{
+				internalSvcExport,
+				serviceImport,
+				otherInternalServiceExportForTest("member-2", ports, time.Now().Add(-time.Hour), false),
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(internalServiceExportScheme(t)).
+				WithObjects(objects...).
+				WithStatusSubresource(objects...).
+				WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
+				Build()
+
+			r := internalServiceExportReconciler(fakeClient)
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testMemberNamespace, Name: testName}}); err != nil {
+				t.Fatalf("Reconcile() got error %v, want no error", err)
+			}
+
+			got := fleetnetv1alpha1.ServiceImport{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: testServiceName}, &got); err != nil {
+				t.Fatalf("ServiceImport Get() got error %v, want no error", err)
+			}
+			gotCatalog, ok := got.Annotations[objectmeta.ServiceImportAnnotationOpenAPISpecCatalog]
+			if tc.wantCatalog == "" && ok {
+				t.Errorf("ServiceImport OpenAPISpecCatalog annotation = %s, want none", gotCatalog)
+			}
+			if tc.wantCatalog != "" && gotCatalog != tc.wantCatalog {
+				t.Errorf("ServiceImport OpenAPISpecCatalog annotation = %s, want %s", gotCatalog, tc.wantCatalog)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ import (
 	// +kubebuilder:scaffold:imports
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 var (
@@ -115,6 +116,11 @@ var _ = BeforeSuite(func() {
 		RetryInternal: 10 * time.Millisecond,
 	}).SetupWithManager(mgr)
 	Expect(err).ToNot(HaveOccurred())
+	// The index is registered by the ServiceImport controller, which does not run in this suite.
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, func(o client.Object) []string {
+		return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+	})
+	Expect(err).ToNot(HaveOccurred())
 
 	ctx, cancel = context.WithCancel(context.TODO())
 	go func() {
@@ -123,6 +123,16 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			klog.V(2).InfoS("The member cluster has imported the Service; will sync the imported Service spec",
 				"serviceImport", svcImportRef,
 				"internalServiceImport", internalSvcImportRef)
+			// Sync whether the member cluster imports the endpoints it exports itself; the update of the ServiceImport
+			// has the EndpointSlices of the Service distributed again.
+			if setIncludeLocalEndpoints(svcInUseBy, clusterNamespace, internalSvcImport.Spec.IncludeLocalEndpoints) {
+				if err := r.annotateServiceImportWithServiceInUseByInfo(ctx, svcImport, svcInUseBy); err != nil {
+					klog.ErrorS(err, "Failed to annotate ServiceImport with ServiceInUseBy info",
+						"serviceImport", svcImportRef,
+						"serviceInUseBy", svcInUseBy)
+					return ctrl.Result{}, err
+				}
+			}
 			if err := r.fulfillInternalServiceImport(ctx, svcImport, internalSvcImport); err != nil {
 				klog.ErrorS(err, "Failed to fulfill service import by updating InternalServiceImport status",
 					"serviceImport", svcImportRef,
@@ -152,6 +162,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	// Update the ServiceInUseBy annotation, which claims the Service for the current member cluster to import.
 	svcInUseBy.MemberClusters[clusterNamespace] = clusterID
+	setIncludeLocalEndpoints(svcInUseBy, clusterNamespace, internalSvcImport.Spec.IncludeLocalEndpoints)
 	if err := r.annotateServiceImportWithServiceInUseByInfo(ctx, svcImport, svcInUseBy); err != nil {
 		klog.ErrorS(err, "Failed to annotate ServiceImport with ServiceInUseBy info",
 			"serviceImport", svcImportRef,
@@ -233,6 +244,7 @@ func (r *Reconciler) withdrawServiceImport(ctx context.Context,
 	svcInUseBy := extractServiceInUseByInfoFromServiceImport(svcImport)
 	if _, ok := svcInUseBy.MemberClusters[clusterNamespace]; ok {
 		delete(svcInUseBy.MemberClusters, clusterNamespace)
+		setIncludeLocalEndpoints(svcInUseBy, clusterNamespace, false)
 		switch {
 		case len(svcInUseBy.MemberClusters) > 0:
 			// There are still member clusters importing the Service after the withdrawal; the ServiceInUseBy
@@ -378,3 +390,23 @@ func extractServiceInUseByInfoFromServiceImport(svcImport *fleetnetv1alpha1.Serv
 	}
 	return svcInUseBy
 }
+
+// setIncludeLocalEndpoints sets whether a member cluster imports the endpoints it exports itself in the ServiceInUseBy
+// information; it returns whether the information has changed.
+func setIncludeLocalEndpoints(svcInUseBy *fleetnetv1alpha1.ServiceInUseBy, clusterNamespace fleetnetv1alpha1.ClusterNamespace, include bool) bool {
+	if svcInUseBy.IncludeLocalEndpoints[clusterNamespace] == include {
+		return false
+	}
+	if !include {
+		delete(svcInUseBy.IncludeLocalEndpoints, clusterNamespace)
+		if len(svcInUseBy.IncludeLocalEndpoints) == 0 {
+			svcInUseBy.IncludeLocalEndpoints = nil
+		}
+		return true
+	}
+	if svcInUseBy.IncludeLocalEndpoints == nil {
+		svcInUseBy.IncludeLocalEndpoints = map[fleetnetv1alpha1.ClusterNamespace]bool{}
+	}
+	svcInUseBy.IncludeLocalEndpoints[clusterNamespace] = true
+	return true
+}
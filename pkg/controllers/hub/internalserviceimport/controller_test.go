@@ -687,3 +687,59 @@ func TestClearServiceInUseByInfoFromServiceImport(t *testing.T) {
 		})
 	}
 }
+
+// TestReconcile_IncludeLocalEndpoints tests that the ServiceInUseBy annotation tracks whether member cluster A, which
+// has imported the Service, includes its local endpoints.
+func TestReconcile_IncludeLocalEndpoints(t *testing.T) {
+	ctx := context.Background()
+	internalSvcImport := &fleetnetv1alpha1.InternalServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  hubNSForMemberA,
+			Name:       internalSvcImportName,
+			Finalizers: []string{internalSvcImportCleanupFinalizer},
+		},
+		Spec: fleetnetv1alpha1.InternalServiceImportSpec{
+			ServiceImportReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID: clusterIDForMemberA,
+				Namespace: memberUserNS,
+				Name:      svcName,
+			},
+		},
+	}
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(fulfilledServiceImport(), internalSvcImport).
+		WithStatusSubresource(&fleetnetv1alpha1.InternalServiceImport{}).
+		Build()
+	reconciler := Reconciler{
+		HubClient: fakeHubClient,
+	}
+
+	reconcileWith := func(includeLocalEndpoints bool) *fleetnetv1alpha1.ServiceInUseBy {
+		t.Helper()
+		if err := fakeHubClient.Get(ctx, internalSvcImportAKey, internalSvcImport); err != nil {
+			t.Fatalf("internalServiceImport Get(%+v), got %v, want no error", internalSvcImportAKey, err)
+		}
+		internalSvcImport.Spec.IncludeLocalEndpoints = includeLocalEndpoints
+		if err := fakeHubClient.Update(ctx, internalSvcImport); err != nil {
+			t.Fatalf("internalServiceImport Update(), got %v, want no error", err)
+		}
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: internalSvcImportAKey}); err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", internalSvcImportAKey, err)
+		}
+		svcImport := &fleetnetv1alpha1.ServiceImport{}
+		if err := fakeHubClient.Get(ctx, svcImportKey, svcImport); err != nil {
+			t.Fatalf("serviceImport Get(%+v), got %v, want no error", svcImportKey, err)
+		}
+		return extractServiceInUseByInfoFromServiceImport(svcImport)
+	}
+
+	wantSvcInUseBy := fulfilledServiceInUseByAnnotation()
+	wantSvcInUseBy.IncludeLocalEndpoints = map[fleetnetv1alpha1.ClusterNamespace]bool{hubNSForMemberA: true}
+	if diff := cmp.Diff(wantSvcInUseBy, reconcileWith(true)); diff != "" {
+		t.Fatalf("serviceInUseBy after including local endpoints (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(fulfilledServiceInUseByAnnotation(), reconcileWith(false)); diff != "" {
+		t.Fatalf("serviceInUseBy after excluding local endpoints (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,271 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package mcsserviceimport features the compatibility controller which mirrors the fleet ServiceImport objects into
+// upstream mcs-api (multicluster.x-k8s.io) ServiceImport objects, so that tooling built on the upstream API group can
+// observe the services exported in the fleet.
+package mcsserviceimport
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	"go.goms.io/fleet/pkg/utils/controller"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "mcs-serviceimport-controller"
+)
+
+// ServiceImportGVK is the GroupVersionKind of the upstream mcs-api ServiceImport.
+var ServiceImportGVK = schema.GroupVersionKind{
+	Group:   "multicluster.x-k8s.io",
+	Version: "v1alpha1",
+	Kind:    "ServiceImport",
+}
+
+// mcsServiceImportSpec is the spec of the upstream ServiceImport, as defined by the mcs-api.
+type mcsServiceImportSpec struct {
+	Ports                 []mcsServicePort                   `json:"ports"`
+	IPs                   []string                           `json:"ips,omitempty"`
+	Type                  fleetnetv1alpha1.ServiceImportType `json:"type"`
+	SessionAffinity       string                             `json:"sessionAffinity,omitempty"`
+	SessionAffinityConfig *corev1.SessionAffinityConfig      `json:"sessionAffinityConfig,omitempty"`
+}
+
+// mcsServicePort is a port of the upstream ServiceImport; unlike the fleet one, it has no target port.
+type mcsServicePort struct {
+	Name        string  `json:"name,omitempty"`
+	Protocol    string  `json:"protocol,omitempty"`
+	AppProtocol *string `json:"appProtocol,omitempty"`
+	Port        int32   `json:"port"`
+}
+
+// Reconciler mirrors the fleet ServiceImport objects into the upstream mcs-api ServiceImport objects.
+//
+// The upstream objects are handled as unstructured objects so that the hub agent does not depend on the mcs-api
+// module and the scheme stays unchanged unless the compatibility mode is enabled.
+type Reconciler struct {
+	Client client.Client
+}
+
+//+kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports,verbs=get;list;watch
+
+// Reconcile creates or updates the upstream ServiceImport mirroring a fleet ServiceImport.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	serviceImportKRef := klog.KRef(req.Namespace, req.Name)
+	startTime := time.Now()
+	klog.V(2).InfoS("Reconciliation starts", "serviceImport", serviceImportKRef)
+	defer func() {
+		latency := time.Since(startTime).Milliseconds()
+		klog.V(2).InfoS("Reconciliation ends", "serviceImport", serviceImportKRef, "latency", latency)
+	}()
+
+	mcsServiceImport := newUnstructuredServiceImport()
+	getErr := r.Client.Get(ctx, req.NamespacedName, mcsServiceImport)
+	if getErr != nil && !apierrors.IsNotFound(getErr) {
+		klog.ErrorS(getErr, "Failed to get upstream serviceImport", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, controller.NewAPIServerError(true, getErr)
+	}
+
+	serviceImport := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(ctx, req.NamespacedName, serviceImport); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get serviceImport", "serviceImport", serviceImportKRef)
+			return ctrl.Result{}, controller.NewAPIServerError(true, err)
+		}
+		if getErr != nil || !isMirror(mcsServiceImport) {
+			return ctrl.Result{}, nil
+		}
+		// The upstream ServiceImport is owned by the fleet one and will be garbage collected too; it is deleted here
+		// so that the upstream object does not outlive the fleet one when the garbage collection falls behind.
+		klog.V(2).InfoS("Deleting upstream serviceImport of the deleted serviceImport", "serviceImport", serviceImportKRef)
+		if err := r.Client.Delete(ctx, mcsServiceImport); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete upstream serviceImport", "serviceImport", serviceImportKRef)
+			return ctrl.Result{}, controller.NewAPIServerError(false, err)
+		}
+		return ctrl.Result{}, nil
+	}
+	if serviceImport.DeletionTimestamp != nil {
+		klog.V(4).InfoS("ServiceImport is being deleted", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, nil
+	}
+	if serviceImport.Status.Type == "" {
+		// The upstream ServiceImport requires a type, which is set when the service spec is resolved.
+		klog.V(4).InfoS("ServiceImport has not been resolved yet", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, nil
+	}
+
+	spec, err := desiredSpec(serviceImport)
+	if err != nil {
+		klog.ErrorS(err, "Failed to build the spec of upstream serviceImport", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, controller.NewUnexpectedBehaviorError(err)
+	}
+
+	if apierrors.IsNotFound(getErr) {
+		mcsServiceImport = newUnstructuredServiceImport()
+		mcsServiceImport.SetNamespace(req.Namespace)
+		mcsServiceImport.SetName(req.Name)
+		mcsServiceImport.SetOwnerReferences([]metav1.OwnerReference{ownerReference(serviceImport)})
+		mcsServiceImport.Object["spec"] = spec
+		klog.V(2).InfoS("Creating upstream serviceImport", "serviceImport", serviceImportKRef)
+		if err := r.Client.Create(ctx, mcsServiceImport); err != nil {
+			klog.ErrorS(err, "Failed to create upstream serviceImport", "serviceImport", serviceImportKRef)
+			return ctrl.Result{}, controller.NewCreateIgnoreAlreadyExistError(err)
+		}
+		return ctrl.Result{}, r.updateClusters(ctx, mcsServiceImport, serviceImport)
+	}
+
+	if !isOwnedBy(mcsServiceImport, serviceImport) {
+		// The upstream ServiceImport is created by someone else, or mirrors a fleet ServiceImport deleted before;
+		// the latter is garbage collected and this ServiceImport is reconciled again when it is gone.
+		klog.V(2).InfoS("Upstream serviceImport is not owned by the serviceImport and skipping", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, nil
+	}
+	currentSpec, _, err := unstructured.NestedFieldNoCopy(mcsServiceImport.Object, "spec")
+	if err != nil || !equality.Semantic.DeepEqual(currentSpec, spec) {
+		mcsServiceImport.Object["spec"] = spec
+		klog.V(2).InfoS("Updating the spec of upstream serviceImport", "serviceImport", serviceImportKRef)
+		if err := r.Client.Update(ctx, mcsServiceImport); err != nil {
+			klog.ErrorS(err, "Failed to update upstream serviceImport", "serviceImport", serviceImportKRef)
+			return ctrl.Result{}, controller.NewUpdateIgnoreConflictError(err)
+		}
+	}
+	return ctrl.Result{}, r.updateClusters(ctx, mcsServiceImport, serviceImport)
+}
+
+// updateClusters sets the exporting clusters of the fleet ServiceImport in the status of the upstream ServiceImport.
+func (r *Reconciler) updateClusters(ctx context.Context, mcsServiceImport *unstructured.Unstructured, serviceImport *fleetnetv1alpha1.ServiceImport) error {
+	mcsServiceImportKObj := klog.KObj(mcsServiceImport)
+	clusters := make([]interface{}, 0, len(serviceImport.Status.Clusters))
+	for _, c := range serviceImport.Status.Clusters {
+		clusters = append(clusters, map[string]interface{}{"cluster": c.Cluster})
+	}
+	current, _, err := unstructured.NestedSlice(mcsServiceImport.Object, "status", "clusters")
+	if err == nil && equality.Semantic.DeepEqual(current, clusters) {
+		return nil
+	}
+	if _, ok := mcsServiceImport.Object["status"].(map[string]interface{}); !ok {
+		// The status may be returned as null when it has never been set.
+		delete(mcsServiceImport.Object, "status")
+	}
+	if err := unstructured.SetNestedSlice(mcsServiceImport.Object, clusters, "status", "clusters"); err != nil {
+		klog.ErrorS(err, "Failed to set the clusters", "serviceImport", mcsServiceImportKObj)
+		return controller.NewUnexpectedBehaviorError(err)
+	}
+	klog.V(2).InfoS("Updating the clusters of upstream serviceImport", "serviceImport", mcsServiceImportKObj, "clusters", serviceImport.Status.Clusters)
+	if err := r.Client.Status().Update(ctx, mcsServiceImport); err != nil {
+		klog.ErrorS(err, "Failed to update the status of upstream serviceImport", "serviceImport", mcsServiceImportKObj)
+		return controller.NewUpdateIgnoreConflictError(err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+//
+// The controller is not set up when the upstream ServiceImport CRD is not installed, so that enabling the
+// compatibility mode on a hub cluster without the mcs-api CRDs is a no-op.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	installed, err := isUpstreamCRDInstalled(mgr.GetRESTMapper())
+	if err != nil {
+		klog.ErrorS(err, "Failed to look up the upstream serviceImport CRD", "gvk", ServiceImportGVK)
+		return err
+	}
+	if !installed {
+		klog.InfoS("The upstream serviceImport CRD is not installed; skipping the mcs serviceimport controller", "gvk", ServiceImportGVK)
+		return nil
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&fleetnetv1alpha1.ServiceImport{}).
+		// The upstream ServiceImport has the same namespace and name as the fleet one.
+		Watches(newUnstructuredServiceImport(), &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
+
+// isUpstreamCRDInstalled returns true if the RESTMapper knows the upstream ServiceImport.
+func isUpstreamCRDInstalled(mapper meta.RESTMapper) (bool, error) {
+	if _, err := mapper.RESTMapping(ServiceImportGVK.GroupKind(), ServiceImportGVK.Version); err != nil {
+		if meta.IsNoMatchError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// desiredSpec returns the spec of the upstream ServiceImport mirroring the fleet ServiceImport.
+func desiredSpec(serviceImport *fleetnetv1alpha1.ServiceImport) (map[string]interface{}, error) {
+	spec := mcsServiceImportSpec{
+		Ports:                 make([]mcsServicePort, 0, len(serviceImport.Status.Ports)),
+		IPs:                   serviceImport.Status.IPs,
+		Type:                  serviceImport.Status.Type,
+		SessionAffinity:       string(serviceImport.Status.SessionAffinity),
+		SessionAffinityConfig: serviceImport.Status.SessionAffinityConfig,
+	}
+	for _, p := range serviceImport.Status.Ports {
+		spec.Ports = append(spec.Ports, mcsServicePort{
+			Name:        p.Name,
+			Protocol:    string(p.Protocol),
+			AppProtocol: p.AppProtocol,
+			Port:        p.Port,
+		})
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+}
+
+func newUnstructuredServiceImport() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ServiceImportGVK)
+	return u
+}
+
+func ownerReference(serviceImport *fleetnetv1alpha1.ServiceImport) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: fleetnetv1alpha1.GroupVersion.String(),
+		Kind:       fleetnetv1alpha1.ServiceImportKind,
+		Name:       serviceImport.Name,
+		UID:        serviceImport.UID,
+	}
+}
+
+// isOwnedBy returns true if the upstream ServiceImport mirrors the fleet ServiceImport.
+func isOwnedBy(mcsServiceImport *unstructured.Unstructured, serviceImport *fleetnetv1alpha1.ServiceImport) bool {
+	for _, ref := range mcsServiceImport.GetOwnerReferences() {
+		if ref.UID == serviceImport.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// isMirror returns true if the upstream ServiceImport mirrors a fleet ServiceImport, no matter whether the fleet
+// ServiceImport still exists.
+func isMirror(mcsServiceImport *unstructured.Unstructured) bool {
+	for _, ref := range mcsServiceImport.GetOwnerReferences() {
+		if ref.APIVersion == fleetnetv1alpha1.GroupVersion.String() && ref.Kind == fleetnetv1alpha1.ServiceImportKind {
+			return true
+		}
+	}
+	return false
+}
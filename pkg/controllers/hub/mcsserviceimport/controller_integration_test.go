@@ -0,0 +1,109 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package mcsserviceimport
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	eventuallyTimeout  = time.Second * 10
+	eventuallyInterval = time.Millisecond * 250
+)
+
+var _ = Describe("mcs serviceimport controller", func() {
+	Context("resolved service import", func() {
+		name := "resolved"
+		key := types.NamespacedName{Namespace: testNamespace, Name: name}
+
+		BeforeEach(func() {
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      name,
+				},
+			}
+			Expect(hubClient.Create(ctx, serviceImport)).Should(Succeed())
+			serviceImport.Status = fleetnetv1alpha1.ServiceImportStatus{
+				Type: fleetnetv1alpha1.ClusterSetIP,
+				Ports: []fleetnetv1alpha1.ServicePort{
+					{
+						Name:     "http",
+						Protocol: corev1.ProtocolTCP,
+						Port:     80,
+					},
+				},
+				Clusters: []fleetnetv1alpha1.ClusterStatus{
+					{Cluster: "member-1"},
+				},
+			}
+			Expect(hubClient.Status().Update(ctx, serviceImport)).Should(Succeed())
+		})
+
+		It("should mirror the service import and delete the mirror with it", func() {
+			By("confirm that the upstream service import is created")
+			Eventually(func() error {
+				got := newUnstructuredServiceImport()
+				if err := hubClient.Get(ctx, key, got); err != nil {
+					return err
+				}
+				if gotType, _, _ := unstructured.NestedString(got.Object, "spec", "type"); gotType != string(fleetnetv1alpha1.ClusterSetIP) {
+					return fmt.Errorf("type, got %q, want %q", gotType, fleetnetv1alpha1.ClusterSetIP)
+				}
+				clusters, _, err := unstructured.NestedSlice(got.Object, "status", "clusters")
+				if err != nil {
+					return err
+				}
+				if len(clusters) != 1 {
+					return fmt.Errorf("clusters, got %v, want member-1 only", clusters)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("add an exporting cluster to the service import")
+			serviceImport := &fleetnetv1alpha1.ServiceImport{}
+			Expect(hubClient.Get(ctx, key, serviceImport)).Should(Succeed())
+			serviceImport.Status.Clusters = append(serviceImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: "member-2"})
+			Expect(hubClient.Status().Update(ctx, serviceImport)).Should(Succeed())
+
+			By("confirm that the clusters of the upstream service import are updated")
+			Eventually(func() error {
+				got := newUnstructuredServiceImport()
+				if err := hubClient.Get(ctx, key, got); err != nil {
+					return err
+				}
+				clusters, _, err := unstructured.NestedSlice(got.Object, "status", "clusters")
+				if err != nil {
+					return err
+				}
+				if len(clusters) != 2 {
+					return fmt.Errorf("clusters, got %v, want member-1 and member-2", clusters)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("delete the service import")
+			Expect(hubClient.Delete(ctx, serviceImport)).Should(Succeed())
+
+			By("confirm that the upstream service import is deleted")
+			// There is no garbage collector in envtest; the controller deletes the mirror itself.
+			Eventually(func() bool {
+				return apierrors.IsNotFound(hubClient.Get(ctx, key, newUnstructuredServiceImport()))
+			}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+		})
+	})
+})
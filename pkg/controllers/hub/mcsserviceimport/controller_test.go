@@ -0,0 +1,242 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package mcsserviceimport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	testNamespace = "work"
+	testName      = "app"
+	testUID       = types.UID("fleet-uid")
+)
+
+var (
+	testKey = types.NamespacedName{Namespace: testNamespace, Name: testName}
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	scheme.AddKnownTypeWithName(ServiceImportGVK, &unstructured.Unstructured{})
+	return scheme
+}
+
+func resolvedServiceImport() *fleetnetv1alpha1.ServiceImport {
+	return &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testName,
+			UID:       testUID,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Type: fleetnetv1alpha1.ClusterSetIP,
+			Ports: []fleetnetv1alpha1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: corev1.ProtocolTCP,
+					Port:     80,
+				},
+			},
+			Clusters: []fleetnetv1alpha1.ClusterStatus{
+				{Cluster: "member-1"},
+				{Cluster: "member-2"},
+			},
+		},
+	}
+}
+
+func mcsServiceImport(ownerRefs ...metav1.OwnerReference) *unstructured.Unstructured {
+	u := newUnstructuredServiceImport()
+	u.SetNamespace(testNamespace)
+	u.SetName(testName)
+	u.SetOwnerReferences(ownerRefs)
+	u.Object["spec"] = map[string]interface{}{
+		"type":  "Headless",
+		"ports": []interface{}{},
+	}
+	return u
+}
+
+func newTestReconciler(t *testing.T, objs ...client.Object) *Reconciler {
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(objs...).
+		WithStatusSubresource(newUnstructuredServiceImport(), &fleetnetv1alpha1.ServiceImport{}).
+		Build()
+	return &Reconciler{
+		Client: fakeClient,
+	}
+}
+
+func getMCSServiceImport(t *testing.T, c client.Client) *unstructured.Unstructured {
+	got := newUnstructuredServiceImport()
+	if err := c.Get(context.Background(), testKey, got); err != nil {
+		t.Fatalf("Get() upstream serviceImport = %v, want no error", err)
+	}
+	return got
+}
+
+func TestReconcile_Mirror(t *testing.T) {
+	wantSpec := map[string]interface{}{
+		"type": "ClusterSetIP",
+		"ports": []interface{}{
+			map[string]interface{}{
+				"name":     "http",
+				"protocol": "TCP",
+				"port":     int64(80),
+			},
+		},
+	}
+	wantClusters := []interface{}{
+		map[string]interface{}{"cluster": "member-1"},
+		map[string]interface{}{"cluster": "member-2"},
+	}
+	tests := []struct {
+		name string
+		objs []client.Object
+	}{
+		{
+			name: "upstream serviceImport is created",
+			objs: []client.Object{resolvedServiceImport()},
+		},
+		{
+			name: "upstream serviceImport is updated",
+			objs: []client.Object{resolvedServiceImport(), mcsServiceImport(ownerReference(resolvedServiceImport()))},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestReconciler(t, tc.objs...)
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+			got := getMCSServiceImport(t, r.Client)
+			if !isOwnedBy(got, resolvedServiceImport()) {
+				t.Errorf("upstream serviceImport owner references = %v, want owned by the serviceImport", got.GetOwnerReferences())
+			}
+			if diff := cmp.Diff(wantSpec, got.Object["spec"]); diff != "" {
+				t.Errorf("upstream serviceImport spec mismatch (-want, +got):\n%s", diff)
+			}
+			gotClusters, _, err := unstructured.NestedSlice(got.Object, "status", "clusters")
+			if err != nil {
+				t.Fatalf("NestedSlice() = %v, want no error", err)
+			}
+			if diff := cmp.Diff(wantClusters, gotClusters); diff != "" {
+				t.Errorf("upstream serviceImport clusters mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcile_Unresolved(t *testing.T) {
+	ctx := context.Background()
+	serviceImport := resolvedServiceImport()
+	serviceImport.Status = fleetnetv1alpha1.ServiceImportStatus{}
+	r := newTestReconciler(t, serviceImport)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if err := r.Client.Get(ctx, testKey, newUnstructuredServiceImport()); !apierrors.IsNotFound(err) {
+		t.Errorf("Get() upstream serviceImport = %v, want not found error", err)
+	}
+}
+
+func TestReconcile_NotOwned(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler(t, resolvedServiceImport(), mcsServiceImport())
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	got := getMCSServiceImport(t, r.Client)
+	if gotType, _, _ := unstructured.NestedString(got.Object, "spec", "type"); gotType != "Headless" {
+		t.Errorf("upstream serviceImport type = %q, want the untouched Headless", gotType)
+	}
+}
+
+func TestReconcile_ServiceImportDeleted(t *testing.T) {
+	tests := []struct {
+		name       string
+		mcsSvcImp  *unstructured.Unstructured
+		wantExists bool
+	}{
+		{
+			name:       "mirror is deleted",
+			mcsSvcImp:  mcsServiceImport(ownerReference(resolvedServiceImport())),
+			wantExists: false,
+		},
+		{
+			name:       "upstream serviceImport created by others is kept",
+			mcsSvcImp:  mcsServiceImport(),
+			wantExists: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestReconciler(t, tc.mcsSvcImp)
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+			err := r.Client.Get(ctx, testKey, newUnstructuredServiceImport())
+			if gotExists := err == nil; gotExists != tc.wantExists {
+				t.Errorf("Get() upstream serviceImport = %v, want exists %v", err, tc.wantExists)
+			}
+		})
+	}
+}
+
+func TestIsUpstreamCRDInstalled(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed bool
+	}{
+		{
+			name:      "not installed",
+			installed: false,
+		},
+		{
+			name:      "installed",
+			installed: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mapper := meta.NewDefaultRESTMapper(nil)
+			if tc.installed {
+				mapper.Add(ServiceImportGVK, meta.RESTScopeNamespace)
+			}
+			got, err := isUpstreamCRDInstalled(mapper)
+			if err != nil {
+				t.Fatalf("isUpstreamCRDInstalled() = %v, want no error", err)
+			}
+			if got != tc.installed {
+				t.Errorf("isUpstreamCRDInstalled() = %v, want %v", got, tc.installed)
+			}
+		})
+	}
+}
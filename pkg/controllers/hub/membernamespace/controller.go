@@ -0,0 +1,316 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package membernamespace features the member namespace controller, which watches the namespaces reserved for the
+// member clusters in the hub cluster and, once such a namespace is deleted, removes what the departed member cluster
+// leaves behind in the rest of the fleet: its entries in the ServiceImport statuses and ServiceInUseBy annotations,
+// and the EndpointSliceImports derived from its endpoint slices.
+package membernamespace
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/exportconflict"
+	"go.goms.io/fleet-networking/pkg/common/hubconfig"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "membernamespace-controller"
+)
+
+var (
+	// memberNamespacePrefix is the prefix of the namespaces reserved for the member clusters in the hub cluster.
+	memberNamespacePrefix = strings.TrimSuffix(hubconfig.HubNamespaceNameFormat, "%s")
+)
+
+// Reconciler reconciles the namespaces reserved for the member clusters.
+type Reconciler struct {
+	client.Client
+	// RetryInterval is the wait time before checking again whether the exports and imports in a deleted member
+	// namespace have been cleaned up by their controllers.
+	RetryInterval time.Duration
+}
+
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceimports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceimports,verbs=get;list;watch;update;patch;delete
+
+// Reconcile adds the finalizer to a member namespace and, once the namespace is deleted, removes the objects of the
+// departed member cluster from the rest of the fleet before letting the namespace go.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	nsKRef := klog.KRef("", req.Name)
+	startTime := time.Now()
+	klog.V(2).InfoS("Reconciliation starts", "namespace", nsKRef)
+	defer func() {
+		latency := time.Since(startTime).Milliseconds()
+		klog.V(2).InfoS("Reconciliation ends", "namespace", nsKRef, "latency", latency)
+	}()
+
+	clusterID, ok := memberClusterID(req.Name)
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+	var ns corev1.Namespace
+	if err := r.Client.Get(ctx, req.NamespacedName, &ns); err != nil {
+		if errors.IsNotFound(err) {
+			klog.V(4).InfoS("Ignoring NotFound namespace", "namespace", nsKRef)
+			return ctrl.Result{}, nil
+		}
+		klog.ErrorS(err, "Failed to get namespace", "namespace", nsKRef)
+		return ctrl.Result{}, err
+	}
+
+	if ns.DeletionTimestamp == nil {
+		if controllerutil.ContainsFinalizer(&ns, objectmeta.MemberNamespaceFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		controllerutil.AddFinalizer(&ns, objectmeta.MemberNamespaceFinalizer)
+		if err := r.Client.Update(ctx, &ns); err != nil {
+			klog.ErrorS(err, "Failed to add member namespace finalizer", "namespace", nsKRef)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if !controllerutil.ContainsFinalizer(&ns, objectmeta.MemberNamespaceFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	// The member agent is gone with its namespace and cannot remove its finalizers on the EndpointSliceImports.
+	if err := r.removeEndpointSliceImportFinalizers(ctx, ns.Name); err != nil {
+		return ctrl.Result{}, err
+	}
+	// Give the hub controllers the chance to withdraw the exports and imports of the member cluster the normal way
+	// first, so that the cleanup below does not race with them.
+	pending, err := r.hasPendingExportsOrImports(ctx, ns.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pending {
+		klog.V(2).InfoS("Waiting for the exports and imports in the member namespace to be cleaned up", "namespace", nsKRef)
+		return ctrl.Result{RequeueAfter: r.RetryInterval}, nil
+	}
+
+	if err := r.cleanupServiceImports(ctx, ns.Name, clusterID); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.deleteDerivedEndpointSliceImports(ctx, clusterID); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(&ns, objectmeta.MemberNamespaceFinalizer)
+	if err := r.Client.Update(ctx, &ns); err != nil {
+		klog.ErrorS(err, "Failed to remove member namespace finalizer", "namespace", nsKRef)
+		return ctrl.Result{}, err
+	}
+	klog.V(2).InfoS("Cleaned up the member cluster of the deleted namespace", "namespace", nsKRef, "clusterID", clusterID)
+	return ctrl.Result{}, nil
+}
+
+// memberClusterID returns the ID of the member cluster the namespace is reserved for, if any.
+func memberClusterID(namespace string) (string, bool) {
+	clusterID, ok := strings.CutPrefix(namespace, memberNamespacePrefix)
+	return clusterID, ok && clusterID != ""
+}
+
+// removeEndpointSliceImportFinalizers removes the finalizers on the EndpointSliceImports in the member namespace.
+func (r *Reconciler) removeEndpointSliceImportFinalizers(ctx context.Context, namespace string) error {
+	var endpointSliceImportList fleetnetv1alpha1.EndpointSliceImportList
+	if err := r.Client.List(ctx, &endpointSliceImportList, client.InNamespace(namespace)); err != nil {
+		klog.ErrorS(err, "Failed to list endpointSliceImports", "namespace", klog.KRef("", namespace))
+		return err
+	}
+	for i := range endpointSliceImportList.Items {
+		esi := &endpointSliceImportList.Items[i]
+		if len(esi.Finalizers) == 0 {
+			continue
+		}
+		esi.SetFinalizers(nil)
+		if err := r.Client.Update(ctx, esi); client.IgnoreNotFound(err) != nil {
+			klog.ErrorS(err, "Failed to remove finalizers for endpointSliceImport", "endpointSliceImport", klog.KObj(esi))
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPendingExportsOrImports returns true if any InternalServiceExport, EndpointSliceExport or InternalServiceImport
+// is left in the member namespace.
+func (r *Reconciler) hasPendingExportsOrImports(ctx context.Context, namespace string) (bool, error) {
+	lists := []client.ObjectList{
+		&fleetnetv1alpha1.InternalServiceExportList{},
+		&fleetnetv1alpha1.EndpointSliceExportList{},
+		&fleetnetv1alpha1.InternalServiceImportList{},
+	}
+	for _, list := range lists {
+		if err := r.Client.List(ctx, list, client.InNamespace(namespace), client.Limit(1)); err != nil {
+			klog.ErrorS(err, "Failed to list objects in the member namespace", "namespace", klog.KRef("", namespace))
+			return false, err
+		}
+		if meta.LenList(list) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cleanupServiceImports removes the member cluster from the statuses of the ServiceImports, and its namespace from
+// their ServiceInUseBy annotations.
+func (r *Reconciler) cleanupServiceImports(ctx context.Context, namespace, clusterID string) error {
+	var serviceImportList fleetnetv1alpha1.ServiceImportList
+	if err := r.Client.List(ctx, &serviceImportList); err != nil {
+		klog.ErrorS(err, "Failed to list serviceImports")
+		return err
+	}
+	for i := range serviceImportList.Items {
+		serviceImport := &serviceImportList.Items[i]
+		if err := r.removeClusterFromServiceImportStatus(ctx, serviceImport, clusterID); err != nil {
+			return err
+		}
+		if err := r.removeNamespaceFromServiceInUseBy(ctx, serviceImport, namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeClusterFromServiceImportStatus removes the member cluster from the status of the ServiceImport, if the
+// ServiceImport still lists it.
+func (r *Reconciler) removeClusterFromServiceImportStatus(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport, clusterID string) error {
+	serviceImportKObj := klog.KObj(serviceImport)
+	clusters := make([]fleetnetv1alpha1.ClusterStatus, 0, len(serviceImport.Status.Clusters))
+	for _, c := range serviceImport.Status.Clusters {
+		if c.Cluster != clusterID {
+			clusters = append(clusters, c)
+		}
+	}
+	if len(clusters) == len(serviceImport.Status.Clusters) {
+		return nil
+	}
+
+	internalServiceExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	listOpts := client.MatchingFields{
+		objectmeta.InternalServiceExportFieldServiceNamespacedName: types.NamespacedName{Namespace: serviceImport.Namespace, Name: serviceImport.Name}.String(),
+	}
+	if err := r.Client.List(ctx, internalServiceExportList, listOpts); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceExports of the service", "serviceImport", serviceImportKObj)
+		return err
+	}
+	exports := internalServiceExportList.Items
+	serviceImport.Status.Clusters = clusters
+	switch {
+	case len(clusters) == 0 || exportconflict.HasUnlistedExports(serviceImport, exports, clusterID):
+		// The departed cluster may have been the one the other exports are merged into, and the conflicted exports
+		// may no longer be; the ServiceImport controller resolves the spec again from the remaining exports.
+		klog.V(2).InfoS("Resetting the serviceImport status so that the spec is resolved again", "serviceImport", serviceImportKObj, "clusterID", clusterID)
+		exportconflict.ResetResolution(serviceImport)
+	default:
+		// The ports only the departed cluster exposes are dropped.
+		serviceImport.Status.Ports = exportconflict.MergeExportedPorts(exportconflict.ListedExports(serviceImport, exports))
+	}
+	if err := r.Client.Status().Update(ctx, serviceImport); err != nil {
+		klog.ErrorS(err, "Failed to remove the member cluster from the serviceImport status", "serviceImport", serviceImportKObj, "clusterID", clusterID)
+		return err
+	}
+	return nil
+}
+
+// removeNamespaceFromServiceInUseBy removes the member namespace from the ServiceInUseBy annotation of the
+// ServiceImport, and clears the annotation if no member cluster imports the Service any more.
+func (r *Reconciler) removeNamespaceFromServiceInUseBy(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport, namespace string) error {
+	serviceImportKObj := klog.KObj(serviceImport)
+	data, ok := serviceImport.Annotations[objectmeta.ServiceImportAnnotationServiceInUseBy]
+	if !ok {
+		return nil
+	}
+	svcInUseBy := &fleetnetv1alpha1.ServiceInUseBy{}
+	if err := json.Unmarshal([]byte(data), svcInUseBy); err != nil {
+		// The InternalServiceImport controller overwrites the corrupted data.
+		klog.ErrorS(err, "Failed to unmarshal ServiceInUseBy data", "serviceImport", serviceImportKObj, "data", data)
+		return nil
+	}
+	if _, ok := svcInUseBy.MemberClusters[fleetnetv1alpha1.ClusterNamespace(namespace)]; !ok {
+		return nil
+	}
+	delete(svcInUseBy.MemberClusters, fleetnetv1alpha1.ClusterNamespace(namespace))
+	if len(svcInUseBy.MemberClusters) == 0 {
+		delete(serviceImport.Annotations, objectmeta.ServiceImportAnnotationServiceInUseBy)
+	} else {
+		updated, err := json.Marshal(svcInUseBy)
+		if err != nil {
+			klog.ErrorS(err, "Failed to marshal ServiceInUseBy data", "serviceImport", serviceImportKObj)
+			return err
+		}
+		serviceImport.Annotations[objectmeta.ServiceImportAnnotationServiceInUseBy] = string(updated)
+	}
+	if err := r.Client.Update(ctx, serviceImport); err != nil {
+		klog.ErrorS(err, "Failed to remove the member namespace from the ServiceInUseBy annotation", "serviceImport", serviceImportKObj, "namespace", klog.KRef("", namespace))
+		return err
+	}
+	return nil
+}
+
+// deleteDerivedEndpointSliceImports deletes the EndpointSliceImports derived from the endpoint slices of the member
+// cluster.
+func (r *Reconciler) deleteDerivedEndpointSliceImports(ctx context.Context, clusterID string) error {
+	var endpointSliceImportList fleetnetv1alpha1.EndpointSliceImportList
+	if err := r.Client.List(ctx, &endpointSliceImportList); err != nil {
+		klog.ErrorS(err, "Failed to list endpointSliceImports")
+		return err
+	}
+	for i := range endpointSliceImportList.Items {
+		esi := &endpointSliceImportList.Items[i]
+		if esi.Spec.EndpointSliceReference.ClusterID != clusterID || esi.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.Client.Delete(ctx, esi); client.IgnoreNotFound(err) != nil {
+			klog.ErrorS(err, "Failed to delete endpointSliceImport", "endpointSliceImport", klog.KObj(esi), "clusterID", clusterID)
+			return err
+		}
+		klog.V(2).InfoS("Deleted endpointSliceImport of the departed member cluster", "endpointSliceImport", klog.KObj(esi), "clusterID", clusterID)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+//
+// The controller relies on the index the ServiceImport controller registers on the InternalServiceExports.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	memberNamespacePredicate := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		_, ok := memberClusterID(o.GetName())
+		return ok
+	})
+	customPredicate := predicate.Funcs{
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			// The namespace is gone; there is nothing left to do.
+			return false
+		},
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(&corev1.Namespace{}, builder.WithPredicates(memberNamespacePredicate, customPredicate)).
+		Complete(r)
+}
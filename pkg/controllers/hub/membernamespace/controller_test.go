@@ -0,0 +1,347 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package membernamespace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	testNamespace = "work"
+	testSvcName   = "app"
+
+	departedClusterID  = "member-1"
+	departedNamespace  = "fleet-member-member-1"
+	remainingClusterID = "member-2"
+	remainingNamespace = "fleet-member-member-2"
+	conflictClusterID  = "member-3"
+
+	memberFinalizer = "networking.fleet.azure.com/endpointsliceimport-cleanup"
+)
+
+var (
+	testSvcKey = types.NamespacedName{Namespace: testNamespace, Name: testSvcName}
+
+	portA = fleetnetv1alpha1.ServicePort{Name: "portA", Protocol: corev1.ProtocolTCP, Port: 8080}
+	portB = fleetnetv1alpha1.ServicePort{Name: "portB", Protocol: corev1.ProtocolTCP, Port: 9090}
+	portC = fleetnetv1alpha1.ServicePort{Name: "portC", Protocol: corev1.ProtocolTCP, Port: 8080}
+)
+
+func newTestReconciler(t *testing.T, objs ...client.Object) *Reconciler {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+		}).
+		Build()
+	return &Reconciler{
+		Client:        fakeClient,
+		RetryInterval: time.Second,
+	}
+}
+
+func memberNamespace(name string, deleting bool) *corev1.Namespace {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+	if deleting {
+		ns.Finalizers = []string{objectmeta.MemberNamespaceFinalizer}
+		ns.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	}
+	return ns
+}
+
+func internalServiceExport(clusterID string, exportedSince time.Time, ports ...fleetnetv1alpha1.ServicePort) *fleetnetv1alpha1.InternalServiceExport {
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "fleet-member-" + clusterID,
+			Name:       testNamespace + "-" + testSvcName,
+			Finalizers: []string{objectmeta.InternalServiceExportFinalizer},
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: ports,
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:       clusterID,
+				Kind:            "Service",
+				Namespace:       testNamespace,
+				Name:            testSvcName,
+				NamespacedName:  testSvcKey.String(),
+				ExportedSince:   metav1.NewTime(exportedSince),
+				ResourceVersion: "0",
+			},
+		},
+	}
+}
+
+func serviceImport(svcInUseBy string, ports []fleetnetv1alpha1.ServicePort, clusters ...string) *fleetnetv1alpha1.ServiceImport {
+	svcImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testSvcName,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Ports: ports,
+		},
+	}
+	if svcInUseBy != "" {
+		svcImport.Annotations = map[string]string{objectmeta.ServiceImportAnnotationServiceInUseBy: svcInUseBy}
+	}
+	for _, c := range clusters {
+		svcImport.Status.Clusters = append(svcImport.Status.Clusters, fleetnetv1alpha1.ClusterStatus{Cluster: c})
+	}
+	return svcImport
+}
+
+func endpointSliceImport(namespace, name, clusterID string, finalizers ...string) *fleetnetv1alpha1.EndpointSliceImport {
+	return &fleetnetv1alpha1.EndpointSliceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  namespace,
+			Name:       name,
+			Finalizers: finalizers,
+		},
+		Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+			EndpointSliceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID: clusterID,
+				Kind:      "EndpointSlice",
+				Namespace: testNamespace,
+				Name:      name,
+			},
+		},
+	}
+}
+
+func reconcile(t *testing.T, r *Reconciler, name string) ctrl.Result {
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+	if err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	return res
+}
+
+func getServiceImportStatus(t *testing.T, r *Reconciler) fleetnetv1alpha1.ServiceImportStatus {
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(context.Background(), testSvcKey, svcImport); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	return svcImport.Status
+}
+
+func TestReconcile_AddFinalizer(t *testing.T) {
+	testCases := []struct {
+		name           string
+		namespace      string
+		wantFinalizers []string
+	}{
+		{
+			name:           "member namespace",
+			namespace:      departedNamespace,
+			wantFinalizers: []string{objectmeta.MemberNamespaceFinalizer},
+		},
+		{
+			name:      "other namespace",
+			namespace: testNamespace,
+		},
+		{
+			name:      "namespace named after the prefix only",
+			namespace: memberNamespacePrefix,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReconciler(t, memberNamespace(tc.namespace, false))
+			reconcile(t, r, tc.namespace)
+
+			ns := &corev1.Namespace{}
+			if err := r.Client.Get(context.Background(), types.NamespacedName{Name: tc.namespace}, ns); err != nil {
+				t.Fatalf("Get() = %v, want no error", err)
+			}
+			if diff := cmp.Diff(tc.wantFinalizers, ns.Finalizers, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("namespace finalizers mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcile_WaitForPendingExports(t *testing.T) {
+	now := time.Now()
+	r := newTestReconciler(t,
+		memberNamespace(departedNamespace, true),
+		internalServiceExport(departedClusterID, now, portA),
+		serviceImport("", []fleetnetv1alpha1.ServicePort{portA}, departedClusterID),
+	)
+
+	if res := reconcile(t, r, departedNamespace); res.RequeueAfter != r.RetryInterval {
+		t.Errorf("Reconcile() requeueAfter = %v, want %v", res.RequeueAfter, r.RetryInterval)
+	}
+	ns := &corev1.Namespace{}
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Name: departedNamespace}, ns); err != nil {
+		t.Fatalf("Get() = %v, want namespace to be kept", err)
+	}
+	want := fleetnetv1alpha1.ServiceImportStatus{
+		Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: departedClusterID}},
+		Ports:    []fleetnetv1alpha1.ServicePort{portA},
+	}
+	if diff := cmp.Diff(want, getServiceImportStatus(t, r)); diff != "" {
+		t.Errorf("serviceImport status mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_CleanupServiceImportStatus(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name    string
+		objects []client.Object
+		want    fleetnetv1alpha1.ServiceImportStatus
+	}{
+		{
+			name: "departed cluster was the conflict winner",
+			objects: []client.Object{
+				serviceImport("", []fleetnetv1alpha1.ServicePort{portA, portB}, departedClusterID, remainingClusterID),
+				internalServiceExport(remainingClusterID, now.Add(time.Second), portB),
+				internalServiceExport(conflictClusterID, now.Add(2*time.Second), portC),
+			},
+			// The ServiceImport controller resolves the spec again, which the conflicted cluster may now join.
+			want: fleetnetv1alpha1.ServiceImportStatus{},
+		},
+		{
+			name: "departed cluster was one of the unconflicted clusters",
+			objects: []client.Object{
+				serviceImport("", []fleetnetv1alpha1.ServicePort{portA, portB}, remainingClusterID, departedClusterID),
+				internalServiceExport(remainingClusterID, now, portA),
+			},
+			want: fleetnetv1alpha1.ServiceImportStatus{
+				Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: remainingClusterID}},
+				Ports:    []fleetnetv1alpha1.ServicePort{portA},
+			},
+		},
+		{
+			name: "departed cluster was the only cluster",
+			objects: []client.Object{
+				serviceImport("", []fleetnetv1alpha1.ServicePort{portA}, departedClusterID),
+			},
+			want: fleetnetv1alpha1.ServiceImportStatus{},
+		},
+		{
+			name: "departed cluster was not listed",
+			objects: []client.Object{
+				serviceImport("", []fleetnetv1alpha1.ServicePort{portA}, remainingClusterID),
+				internalServiceExport(remainingClusterID, now, portA),
+				internalServiceExport(conflictClusterID, now.Add(time.Second), portC),
+			},
+			want: fleetnetv1alpha1.ServiceImportStatus{
+				Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: remainingClusterID}},
+				Ports:    []fleetnetv1alpha1.ServicePort{portA},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := append([]client.Object{memberNamespace(departedNamespace, true)}, tc.objects...)
+			r := newTestReconciler(t, objs...)
+			if res := reconcile(t, r, departedNamespace); res.RequeueAfter != 0 {
+				t.Fatalf("Reconcile() requeueAfter = %v, want 0", res.RequeueAfter)
+			}
+
+			if diff := cmp.Diff(tc.want, getServiceImportStatus(t, r), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("serviceImport status mismatch (-want, +got):\n%s", diff)
+			}
+			ns := &corev1.Namespace{}
+			if err := r.Client.Get(context.Background(), types.NamespacedName{Name: departedNamespace}, ns); !apierrors.IsNotFound(err) {
+				t.Errorf("Get() = %v, want the namespace to be gone", err)
+			}
+		})
+	}
+}
+
+func TestReconcile_CleanupImports(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler(t,
+		memberNamespace(departedNamespace, true),
+		serviceImport(`{"MemberClusters":{"fleet-member-member-1":"member-1","fleet-member-member-2":"member-2"}}`, nil),
+		// Imported by the departed cluster; the member agent is no longer there to remove its finalizer.
+		endpointSliceImport(departedNamespace, "member-2-slice", remainingClusterID, memberFinalizer),
+		// Derived from the endpoint slice of the departed cluster.
+		endpointSliceImport(remainingNamespace, "member-1-slice", departedClusterID),
+		endpointSliceImport(remainingNamespace, "member-2-slice", remainingClusterID),
+	)
+
+	reconcile(t, r, departedNamespace)
+
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(ctx, testSvcKey, svcImport); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	wantAnnotations := map[string]string{
+		objectmeta.ServiceImportAnnotationServiceInUseBy: `{"MemberClusters":{"fleet-member-member-2":"member-2"}}`,
+	}
+	if diff := cmp.Diff(wantAnnotations, svcImport.Annotations); diff != "" {
+		t.Errorf("serviceImport annotations mismatch (-want, +got):\n%s", diff)
+	}
+
+	esiList := &fleetnetv1alpha1.EndpointSliceImportList{}
+	if err := r.Client.List(ctx, esiList); err != nil {
+		t.Fatalf("List() = %v, want no error", err)
+	}
+	var got []types.NamespacedName
+	for _, esi := range esiList.Items {
+		if len(esi.Finalizers) > 0 {
+			t.Errorf("endpointSliceImport %s finalizers = %v, want none", client.ObjectKeyFromObject(&esi), esi.Finalizers)
+		}
+		got = append(got, client.ObjectKeyFromObject(&esi))
+	}
+	want := []types.NamespacedName{
+		{Namespace: departedNamespace, Name: "member-2-slice"},
+		{Namespace: remainingNamespace, Name: "member-2-slice"},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.SortSlices(func(a, b types.NamespacedName) bool { return a.String() < b.String() })); diff != "" {
+		t.Errorf("endpointSliceImports mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_ClearServiceInUseBy(t *testing.T) {
+	r := newTestReconciler(t,
+		memberNamespace(departedNamespace, true),
+		serviceImport(`{"MemberClusters":{"fleet-member-member-1":"member-1"}}`, nil),
+	)
+
+	reconcile(t, r, departedNamespace)
+
+	svcImport := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(context.Background(), testSvcKey, svcImport); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	if _, ok := svcImport.Annotations[objectmeta.ServiceImportAnnotationServiceInUseBy]; ok {
+		t.Errorf("serviceImport annotations = %v, want the ServiceInUseBy annotation to be cleared", svcImport.Annotations)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package membernamespace
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// RemoveFinalizers removes the finalizer of the controller from all the member namespaces, so that they can be
+// deleted once the controller no longer runs, i.e. when the member namespace garbage collection is disabled or the
+// hub agent is uninstalled; the member clusters of the namespaces being deleted are not cleaned up then.
+//
+// The namespaces are listed with the given reader, so that no informer of the namespaces is started for it.
+func RemoveFinalizers(ctx context.Context, reader client.Reader, writer client.Writer) error {
+	var nsList corev1.NamespaceList
+	if err := reader.List(ctx, &nsList); err != nil {
+		klog.ErrorS(err, "Failed to list namespaces")
+		return err
+	}
+	removed := 0
+	for i := range nsList.Items {
+		ns := &nsList.Items[i]
+		if _, ok := memberClusterID(ns.Name); !ok || !controllerutil.ContainsFinalizer(ns, objectmeta.MemberNamespaceFinalizer) {
+			continue
+		}
+		patch := client.MergeFromWithOptions(ns.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		controllerutil.RemoveFinalizer(ns, objectmeta.MemberNamespaceFinalizer)
+		if err := writer.Patch(ctx, ns, patch); client.IgnoreNotFound(err) != nil {
+			klog.ErrorS(err, "Failed to remove member namespace finalizer", "namespace", klog.KObj(ns))
+			return err
+		}
+		removed++
+	}
+	klog.V(2).InfoS("Removed the member namespace finalizers", "namespaces", removed)
+	return nil
+}
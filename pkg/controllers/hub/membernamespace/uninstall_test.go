@@ -0,0 +1,47 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package membernamespace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+func TestRemoveFinalizers(t *testing.T) {
+	otherFinalizer := "example.com/other"
+	namespaces := []*corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: departedNamespace, Finalizers: []string{objectmeta.MemberNamespaceFinalizer, otherFinalizer}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: remainingNamespace, Finalizers: []string{objectmeta.MemberNamespaceFinalizer}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Finalizers: []string{otherFinalizer}}},
+	}
+	r := newTestReconciler(t, namespaces[0], namespaces[1], namespaces[2])
+
+	ctx := context.Background()
+	if err := RemoveFinalizers(ctx, r.Client, r.Client); err != nil {
+		t.Fatalf("RemoveFinalizers() = %v, want no error", err)
+	}
+	want := map[string][]string{
+		departedNamespace:  {otherFinalizer},
+		remainingNamespace: nil,
+		testNamespace:      {otherFinalizer},
+	}
+	for name, wantFinalizers := range want {
+		var ns corev1.Namespace
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, &ns); err != nil {
+			t.Fatalf("Get(%s) = %v, want no error", name, err)
+		}
+		if diff := cmp.Diff(wantFinalizers, ns.Finalizers); diff != "" {
+			t.Errorf("namespace %s finalizers mismatch (-want, +got):\n%s", name, diff)
+		}
+	}
+}
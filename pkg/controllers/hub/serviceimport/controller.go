@@ -9,29 +9,33 @@ package serviceimport
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
+	"sort"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/apiretry"
 	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/exportconflict"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 const (
-	// fields name used to filter resources
-	exportedServiceFieldNamespacedName = ".spec.serviceReference.namespacedName"
-
 	// ControllerName is the name of the Reconciler.
 	ControllerName = "serviceimport-controller"
 )
@@ -42,12 +46,6 @@ type Reconciler struct {
 	Recorder record.EventRecorder
 }
 
-// statusChange stores the internalServiceExports list whose status needs to be updated.
-type statusChange struct {
-	conflict   []*fleetnetv1alpha1.InternalServiceExport
-	noConflict []*fleetnetv1alpha1.InternalServiceExport
-}
-
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports,verbs=get;list;watch;update;patch;delete
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceimports/finalizers,verbs=update
@@ -56,6 +54,7 @@ type statusChange struct {
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile resolves the service spec when the serviceImport status is empty and updates the status of internalServiceExports.
+// It also exposes the member clusters which import the service in the status of its internalServiceExports.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	serviceImportKRef := klog.KRef(req.Namespace, req.Name)
 	startTime := time.Now()
@@ -73,55 +72,32 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		klog.ErrorS(err, "Failed to get serviceImport", "serviceImport", serviceImportKRef)
 		return ctrl.Result{}, err
 	}
-	// If the spec has already present, no need to resolve the service spec.
-	if len(serviceImport.Status.Clusters) != 0 {
-		klog.V(4).InfoS("Already resolved the service spec and skipping", "serviceImport", serviceImportKRef)
-		return ctrl.Result{}, nil
-	}
 
 	internalServiceExportList := &fleetnetv1alpha1.InternalServiceExportList{}
 	namespaceName := types.NamespacedName{Namespace: serviceImport.Namespace, Name: serviceImport.Name}
 	listOpts := client.MatchingFields{
-		exportedServiceFieldNamespacedName: namespaceName.String(),
+		objectmeta.InternalServiceExportFieldServiceNamespacedName: namespaceName.String(),
 	}
 	if err := r.Client.List(ctx, internalServiceExportList, &listOpts); err != nil {
 		klog.ErrorS(err, "Failed to list internalServiceExports used by the serviceImport", "serviceImport", serviceImportKRef)
 		return ctrl.Result{}, err
 	}
-	if len(internalServiceExportList.Items) == 0 {
-		klog.V(2).InfoS("No internalServiceExport found and deleting serviceImport", "serviceImport", serviceImportKRef)
-		return r.deleteServiceImport(ctx, &serviceImport)
-	}
-	change := statusChange{
-		conflict:   []*fleetnetv1alpha1.InternalServiceExport{},
-		noConflict: []*fleetnetv1alpha1.InternalServiceExport{},
+	if err := r.syncServiceInUseBy(ctx, &serviceImport, internalServiceExportList.Items); err != nil {
+		return ctrl.Result{}, err
 	}
 
-	var resolvedPortsSpec *[]fleetnetv1alpha1.ServicePort
-	for i := range internalServiceExportList.Items {
-		v := internalServiceExportList.Items[i]
-		if v.DeletionTimestamp != nil { // skip if the resource is in the deleting state
-			klog.V(4).InfoS("Skipping the internalServiceExport which is in the deleting state", serviceImport, serviceImportKRef, "internalServiceExport", klog.KObj(&v))
-			continue
-		}
-		// skip if the resource is just added which has not been handled by the internalServiceExport controller yet
-		if !controllerutil.ContainsFinalizer(&v, objectmeta.InternalServiceExportFinalizer) {
-			klog.V(3).InfoS("Skipping the internalServiceExport because of missing finalizer", "serviceImport", serviceImportKRef, "internalServiceExport", klog.KObj(&v))
-			continue
-		}
-
-		if resolvedPortsSpec == nil {
-			// pick the first internalServiceExport spec
-			resolvedPortsSpec = &v.Spec.Ports
-		}
-		// TODO: ideally we should ignore the order when comparing the serviceImports; port and protocol are the key.
-		if !equality.Semantic.DeepEqual(*resolvedPortsSpec, v.Spec.Ports) {
-			change.conflict = append(change.conflict, &v)
-			continue
-		}
-		change.noConflict = append(change.noConflict, &v)
+	// If the spec has already present, no need to resolve the service spec.
+	if len(serviceImport.Status.Clusters) != 0 {
+		klog.V(4).InfoS("Already resolved the service spec and skipping", "serviceImport", serviceImportKRef)
+		return ctrl.Result{}, nil
 	}
 
+	if len(internalServiceExportList.Items) == 0 {
+		klog.V(2).InfoS("No internalServiceExport found and deleting serviceImport", "serviceImport", serviceImportKRef)
+		return r.deleteServiceImport(ctx, &serviceImport)
+	}
+	resolution := exportconflict.Resolve(internalServiceExportList.Items)
+	resolvedPortsSpec := resolution.Ports
 	if resolvedPortsSpec == nil {
 		// All of internalServicesExports are in the deleting state or waiting for the internalserviceexport controller to process it.
 		// We could safely delete the serviceImport if exists.
@@ -132,10 +108,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 
 	// To reduce reconcile failure, we'll keep retry until it succeeds.
-	clusters := make([]fleetnetv1alpha1.ClusterStatus, 0, len(change.noConflict))
-	for _, v := range change.noConflict {
+	clusters := make([]fleetnetv1alpha1.ClusterStatus, 0, len(resolution.Unconflicted))
+	for _, v := range resolution.Unconflicted {
 		klog.V(3).InfoS("Marking internalServiceExport status as nonConflict", "serviceImport", serviceImportKRef, "internalServiceExport", klog.KObj(v))
-		if err := r.updateInternalServiceExportWithRetry(ctx, v, false); err != nil {
+		if err := r.updateInternalServiceExportWithRetry(ctx, v, false, "", ""); err != nil {
 			if errors.IsNotFound(err) { // ignore deleted internalServiceExport
 				continue
 			}
@@ -149,16 +125,25 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		klog.V(2).InfoS("Requeue the request to resolve the spec", "serviceImport", serviceImportKRef)
 		return ctrl.Result{Requeue: true}, nil
 	}
-	for _, v := range change.conflict {
-		klog.V(3).InfoS("Marking internalServiceExport status as Conflict", "serviceImport", serviceImportKRef, "internalServiceExport", klog.KObj(v))
-		if err := r.updateInternalServiceExportWithRetry(ctx, v, true); err != nil {
+	canonicalClusterID := resolution.Canonical.Spec.ServiceReference.ClusterID
+	for _, v := range resolution.Conflicted {
+		reason := resolution.ConflictReasons[v.Spec.ServiceReference.ClusterID]
+		klog.V(3).InfoS("Marking internalServiceExport status as Conflict", "serviceImport", serviceImportKRef, "internalServiceExport", klog.KObj(v), "canonicalClusterID", canonicalClusterID, "reason", reason)
+		if err := r.updateInternalServiceExportWithRetry(ctx, v, true, canonicalClusterID, reason); err != nil {
 			return ctrl.Result{}, client.IgnoreNotFound(err)
 		}
+		r.Recorder.Eventf(v, corev1.EventTypeWarning, "ServiceExportConflict",
+			"Service %s/%s conflicts with the service exported first by cluster %s: %s", v.Spec.ServiceReference.Namespace, v.Spec.ServiceReference.Name, canonicalClusterID, reason)
 	}
+	// The clusters are sorted by name, so that the status does not change with the order in which they export the
+	// service.
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Cluster < clusters[j].Cluster })
 	serviceImport.Status = fleetnetv1alpha1.ServiceImportStatus{
 		Ports:    *resolvedPortsSpec,
 		Clusters: clusters,
-		Type:     fleetnetv1alpha1.ClusterSetIP, // may support headless in the future
+		Type:     resolution.Type,
+		// The conditions are set by other controllers, e.g. whether the service has endpoints available.
+		Conditions: serviceImport.Status.Conditions,
 	}
 	updateFunc := func() error {
 		return r.Status().Update(ctx, &serviceImport)
@@ -172,13 +157,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	return ctrl.Result{}, nil
 }
 
-func (r *Reconciler) updateInternalServiceExportWithRetry(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, conflict bool) error {
+func (r *Reconciler) updateInternalServiceExportWithRetry(ctx context.Context, internalServiceExport *fleetnetv1alpha1.InternalServiceExport, conflict bool, canonicalClusterID, reason string) error {
 	desiredCond := condition.UnconflictedServiceExportConflictCondition(*internalServiceExport)
 	if conflict {
-		desiredCond = condition.ConflictedServiceExportConflictCondition(*internalServiceExport)
+		desiredCond = condition.ConflictedServiceExportConflictCondition(*internalServiceExport, canonicalClusterID, reason)
 	}
 	currentCond := meta.FindStatusCondition(internalServiceExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
-	if condition.EqualCondition(currentCond, &desiredCond) {
+	if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
 		return nil
 	}
 	exportKObj := klog.KObj(internalServiceExport)
@@ -194,6 +179,61 @@ func (r *Reconciler) updateInternalServiceExportWithRetry(ctx context.Context, i
 	return nil
 }
 
+// syncServiceInUseBy exposes the member clusters which import the service, as annotated on the ServiceImport by the
+// InternalServiceImport controller, in the status of the InternalServiceExports of the service, so that the member
+// clusters exporting the service can tell whether unexporting it disrupts the traffic of others.
+func (r *Reconciler) syncServiceInUseBy(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport, internalServiceExports []fleetnetv1alpha1.InternalServiceExport) error {
+	serviceImportKObj := klog.KObj(serviceImport)
+	inUseBy, err := extractServiceInUseBy(serviceImport)
+	if err != nil {
+		// This error cannot be recovered by retrying; a reconciliation will be triggered when the ServiceInUseBy
+		// annotation is updated.
+		klog.ErrorS(err, "Failed to unmarshal ServiceInUseBy data", "serviceImport", serviceImportKObj)
+		return nil
+	}
+	for i := range internalServiceExports {
+		v := &internalServiceExports[i]
+		if v.DeletionTimestamp != nil || slices.Equal(v.Status.InUseBy, inUseBy) {
+			continue
+		}
+		exportKObj := klog.KObj(v)
+		klog.V(2).InfoS("Updating the member clusters using the exported service", "serviceImport", serviceImportKObj, "internalServiceExport", exportKObj, "inUseBy", inUseBy)
+		v.Status.InUseBy = inUseBy
+		updateFunc := func() error {
+			return r.Client.Status().Update(ctx, v)
+		}
+		if err := apiretry.Do(updateFunc); err != nil {
+			if errors.IsNotFound(err) { // ignore deleted internalServiceExport
+				continue
+			}
+			klog.ErrorS(err, "Failed to update internalServiceExport status with retry", "internalServiceExport", exportKObj)
+			return err
+		}
+	}
+	return nil
+}
+
+// extractServiceInUseBy returns the sorted IDs of the member clusters which import the service, as annotated on
+// the ServiceImport.
+func extractServiceInUseBy(serviceImport *fleetnetv1alpha1.ServiceImport) ([]fleetnetv1alpha1.ClusterID, error) {
+	data, ok := serviceImport.Annotations[objectmeta.ServiceImportAnnotationServiceInUseBy]
+	if !ok {
+		return nil, nil
+	}
+	svcInUseBy := &fleetnetv1alpha1.ServiceInUseBy{}
+	if err := json.Unmarshal([]byte(data), svcInUseBy); err != nil {
+		return nil, err
+	}
+	var clusters []fleetnetv1alpha1.ClusterID
+	for _, clusterID := range svcInUseBy.MemberClusters {
+		if !slices.Contains(clusters, clusterID) {
+			clusters = append(clusters, clusterID)
+		}
+	}
+	slices.Sort(clusters)
+	return clusters, nil
+}
+
 func (r *Reconciler) deleteServiceImport(ctx context.Context, serviceImport *fleetnetv1alpha1.ServiceImport) (ctrl.Result, error) {
 	r.Recorder.Eventf(serviceImport, corev1.EventTypeNormal, "NoExportedService", "No exported service and deleting serviceImport %s", serviceImport.Name)
 
@@ -206,19 +246,46 @@ func (r *Reconciler) deleteServiceImport(ctx context.Context, serviceImport *fle
 	return ctrl.Result{}, nil
 }
 
+// exportedServiceNamespacedName extracts the namespaced name of the exported service from an internalServiceExport
+// to index the internalServiceExports by service.
+func exportedServiceNamespacedName(o client.Object) []string {
+	name := o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName
+	return []string{name}
+}
+
+// internalServiceExportCreatedPredicate returns a predicate which only lets through the creation of
+// InternalServiceExports.
+func internalServiceExportCreatedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(_ event.UpdateEvent) bool {
+			return false
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	// add index to quickly query internalServiceExport list by service
-	extractFunc := func(o client.Object) []string {
-		name := o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName
-		return []string{name}
-	}
-	if err := mgr.GetFieldIndexer().IndexField(ctx, &fleetnetv1alpha1.InternalServiceExport{}, exportedServiceFieldNamespacedName, extractFunc); err != nil {
-		klog.ErrorS(err, "Failed to create index", "field", exportedServiceFieldNamespacedName)
+	if err := mgr.GetFieldIndexer().IndexField(ctx, &fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName); err != nil {
+		klog.ErrorS(err, "Failed to create index", "field", objectmeta.InternalServiceExportFieldServiceNamespacedName)
 		return err
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&fleetnetv1alpha1.ServiceImport{}).
+		// The member clusters using the service are exposed to the InternalServiceExports created after the
+		// ServiceImport is annotated.
+		Watches(&fleetnetv1alpha1.InternalServiceExport{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				ref := o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}}}
+			}),
+			builder.WithPredicates(internalServiceExportCreatedPredicate())).
 		Complete(r)
 }
@@ -35,14 +35,20 @@ func unconflictedServiceExportConflictCondition(svcNamespace string, svcName str
 	}
 }
 
-func conflictedServiceExportConflictCondition(svcNamespace string, svcName string) metav1.Condition {
+// conflictedServiceExportConflictCondition returns the conflicted condition of an export in conflict with the export
+// of authoritativeClusterID; reason tells why the export is in conflict.
+func conflictedServiceExportConflictCondition(svcNamespace string, svcName string, authoritativeClusterID string, reason string) metav1.Condition {
+	message := fmt.Sprintf("service %s/%s is in conflict with the service exported first by cluster %s", svcNamespace, svcName, authoritativeClusterID)
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
 	return metav1.Condition{
 		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
 		Status:             metav1.ConditionTrue,
 		ObservedGeneration: 0,
 		LastTransitionTime: metav1.Now(),
 		Reason:             "ConflictFound",
-		Message:            fmt.Sprintf("service %s/%s is in conflict with other exported services", svcNamespace, svcName),
+		Message:            message,
 	}
 }
 
@@ -133,9 +139,10 @@ var _ = Describe("Test ServiceImport Controller", func() {
 					Namespace: testMemberClusterB,
 				},
 				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					// Port 8080 is named differently from internalServiceExportA, so that their ports cannot be merged.
 					Ports: []fleetnetv1alpha1.ServicePort{
 						{
-							Name:        "portA",
+							Name:        "portC",
 							Protocol:    "TCP",
 							Port:        8080,
 							AppProtocol: &appProtocol,
@@ -317,7 +324,8 @@ var _ = Describe("Test ServiceImport Controller", func() {
 					},
 				}
 				if resolvedClusterID != testClusterID {
-					want.Status.Conditions[0] = conflictedServiceExportConflictCondition(testNamespace, testServiceName)
+					want.Status.Conditions[0] = conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-cluster-b",
+						`port 8080/TCP is named "portA", but the other clusters name it "portC"`)
 				}
 				return cmp.Diff(want, got, options...)
 			}, timeout, interval).Should(BeEmpty())
@@ -336,7 +344,7 @@ var _ = Describe("Test ServiceImport Controller", func() {
 					Spec: fleetnetv1alpha1.InternalServiceExportSpec{
 						Ports: []fleetnetv1alpha1.ServicePort{
 							{
-								Name:        "portA",
+								Name:        "portC",
 								Protocol:    "TCP",
 								Port:        8080,
 								AppProtocol: &appProtocol,
@@ -358,7 +366,8 @@ var _ = Describe("Test ServiceImport Controller", func() {
 					ObjectMeta: internalServiceExportB.ObjectMeta,
 					Status: fleetnetv1alpha1.InternalServiceExportStatus{
 						Conditions: []metav1.Condition{
-							conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+							conflictedServiceExportConflictCondition(testNamespace, testServiceName, testClusterID,
+								`port 8080/TCP is named "portC", but the other clusters name it "portA"`),
 						},
 					},
 				}
@@ -405,7 +414,7 @@ var _ = Describe("Test ServiceImport Controller", func() {
 
 			internalServiceExportA.Status = fleetnetv1alpha1.InternalServiceExportStatus{
 				Conditions: []metav1.Condition{
-					conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+					conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-cluster-b", ""),
 				},
 			}
 			Expect(k8sClient.Status().Update(ctx, internalServiceExportA))
@@ -465,7 +474,7 @@ var _ = Describe("Test ServiceImport Controller", func() {
 
 			internalServiceExportA.Status = fleetnetv1alpha1.InternalServiceExportStatus{
 				Conditions: []metav1.Condition{
-					conflictedServiceExportConflictCondition(testNamespace, testServiceName),
+					conflictedServiceExportConflictCondition(testNamespace, testServiceName, "member-cluster-b", ""),
 				},
 			}
 			Expect(k8sClient.Delete(ctx, internalServiceExportA))
@@ -0,0 +1,323 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceimport
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+var testPorts = []fleetnetv1alpha1.ServicePort{
+	{
+		Name:       "http",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	},
+}
+
+func serviceImportReconciler(t *testing.T, objs ...client.Object) *Reconciler {
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceImport{}, &fleetnetv1alpha1.InternalServiceExport{}).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, exportedServiceNamespacedName).
+		Build()
+	return &Reconciler{
+		Client:   fakeClient,
+		Recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func serviceImportForTest() *fleetnetv1alpha1.ServiceImport {
+	return &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testServiceName,
+		},
+	}
+}
+
+func internalServiceExportForTest(clusterID string, ports []fleetnetv1alpha1.ServicePort) *fleetnetv1alpha1.InternalServiceExport {
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  fmt.Sprintf("fleet-member-%s", clusterID),
+			Name:       fmt.Sprintf("%s-%s", testNamespace, testServiceName),
+			Finalizers: []string{objectmeta.InternalServiceExportFinalizer},
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: ports,
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:      clusterID,
+				Kind:           "Service",
+				Namespace:      testNamespace,
+				Name:           testServiceName,
+				NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: testServiceName}.String(),
+			},
+		},
+	}
+}
+
+func TestReconcile_CompatibleExports(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	exportA := internalServiceExportForTest(testMemberClusterA, testPorts)
+	exportA.CreationTimestamp = metav1.NewTime(now)
+	// The export of member cluster B is older; the clusters are still listed by name.
+	exportB := internalServiceExportForTest(testMemberClusterB, testPorts)
+	exportB.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	r := serviceImportReconciler(t, serviceImportForTest(), exportA, exportB)
+	name := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+
+	got := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(ctx, name, got); err != nil {
+		t.Fatalf("serviceImport Get() = %v, want no error", err)
+	}
+	want := fleetnetv1alpha1.ServiceImportStatus{
+		Ports: testPorts,
+		Clusters: []fleetnetv1alpha1.ClusterStatus{
+			{Cluster: testMemberClusterA},
+			{Cluster: testMemberClusterB},
+		},
+		Type: fleetnetv1alpha1.ClusterSetIP,
+	}
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("serviceImport status mismatch (-want, +got):\n%s", diff)
+	}
+
+	for _, clusterID := range []string{testMemberClusterA, testMemberClusterB} {
+		export := internalServiceExportForTest(clusterID, testPorts)
+		if err := r.Client.Get(ctx, client.ObjectKeyFromObject(export), export); err != nil {
+			t.Fatalf("internalServiceExport Get() = %v, want no error", err)
+		}
+		cond := meta.FindStatusCondition(export.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+		if cond == nil || cond.Status != metav1.ConditionFalse {
+			t.Errorf("internalServiceExport %s conflict condition = %+v, want false", clusterID, cond)
+		}
+	}
+}
+
+func TestReconcile_OldestExportWins(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	// The port is named differently from the same port of testPorts, so that the ports cannot be merged.
+	webPorts := []fleetnetv1alpha1.ServicePort{
+		{
+			Name:       "web",
+			Protocol:   corev1.ProtocolTCP,
+			Port:       80,
+			TargetPort: intstr.FromInt(8443),
+		},
+	}
+	newer := internalServiceExportForTest(testMemberClusterA, testPorts)
+	newer.CreationTimestamp = metav1.NewTime(now)
+	older := internalServiceExportForTest(testMemberClusterB, webPorts)
+	older.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	r := serviceImportReconciler(t, serviceImportForTest(), newer, older)
+	name := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+
+	got := &fleetnetv1alpha1.ServiceImport{}
+	if err := r.Client.Get(ctx, name, got); err != nil {
+		t.Fatalf("serviceImport Get() = %v, want no error", err)
+	}
+	want := fleetnetv1alpha1.ServiceImportStatus{
+		Ports:    webPorts,
+		Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: testMemberClusterB}},
+		Type:     fleetnetv1alpha1.ClusterSetIP,
+	}
+	if diff := cmp.Diff(want, got.Status); diff != "" {
+		t.Errorf("serviceImport status mismatch (-want, +got):\n%s", diff)
+	}
+
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(newer), newer); err != nil {
+		t.Fatalf("internalServiceExport Get() = %v, want no error", err)
+	}
+	cond := meta.FindStatusCondition(newer.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		t.Errorf("internalServiceExport %s conflict condition = %+v, want true", testMemberClusterA, cond)
+	}
+	recorder := r.Recorder.(*record.FakeRecorder)
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	wantEvents := []string{
+		fmt.Sprintf("Warning ServiceExportConflict Service %s/%s conflicts with the service exported first by cluster %s: %s",
+			testNamespace, testServiceName, testMemberClusterB, `port 80/TCP is named "http", but the other clusters name it "web"`),
+		fmt.Sprintf("Normal SuccessfulUpdateStatus Resolved exported service properties and updated %s status", testServiceName),
+	}
+	if diff := cmp.Diff(wantEvents, events); diff != "" {
+		t.Errorf("events mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_ImportType(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	tests := []struct {
+		name     string
+		typeA    fleetnetv1alpha1.ServiceImportType
+		typeB    fleetnetv1alpha1.ServiceImportType
+		want     fleetnetv1alpha1.ServiceImportType
+		clusters []fleetnetv1alpha1.ClusterStatus
+		// conflicted is the cluster whose export is expected to be in conflict, if any.
+		conflicted string
+	}{
+		{
+			name:     "clusterSetIP exports",
+			typeA:    fleetnetv1alpha1.ClusterSetIP,
+			typeB:    fleetnetv1alpha1.ClusterSetIP,
+			want:     fleetnetv1alpha1.ClusterSetIP,
+			clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: testMemberClusterA}, {Cluster: testMemberClusterB}},
+		},
+		{
+			name:     "headless exports",
+			typeA:    fleetnetv1alpha1.Headless,
+			typeB:    fleetnetv1alpha1.Headless,
+			want:     fleetnetv1alpha1.Headless,
+			clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: testMemberClusterA}, {Cluster: testMemberClusterB}},
+		},
+		{
+			name:       "clusters disagreeing on the type",
+			typeA:      fleetnetv1alpha1.ClusterSetIP,
+			typeB:      fleetnetv1alpha1.Headless,
+			want:       fleetnetv1alpha1.Headless,
+			clusters:   []fleetnetv1alpha1.ClusterStatus{{Cluster: testMemberClusterB}},
+			conflicted: testMemberClusterA,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			exportA := internalServiceExportForTest(testMemberClusterA, testPorts)
+			exportA.CreationTimestamp = metav1.NewTime(now)
+			exportA.Spec.ImportType = tc.typeA
+			exportB := internalServiceExportForTest(testMemberClusterB, testPorts)
+			exportB.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+			exportB.Spec.ImportType = tc.typeB
+			r := serviceImportReconciler(t, serviceImportForTest(), exportA, exportB)
+			name := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+
+			got := &fleetnetv1alpha1.ServiceImport{}
+			if err := r.Client.Get(ctx, name, got); err != nil {
+				t.Fatalf("serviceImport Get() = %v, want no error", err)
+			}
+			want := fleetnetv1alpha1.ServiceImportStatus{
+				Ports:    testPorts,
+				Clusters: tc.clusters,
+				Type:     tc.want,
+			}
+			if diff := cmp.Diff(want, got.Status); diff != "" {
+				t.Errorf("serviceImport status mismatch (-want, +got):\n%s", diff)
+			}
+
+			for _, clusterID := range []string{testMemberClusterA, testMemberClusterB} {
+				export := internalServiceExportForTest(clusterID, testPorts)
+				if err := r.Client.Get(ctx, client.ObjectKeyFromObject(export), export); err != nil {
+					t.Fatalf("internalServiceExport Get() = %v, want no error", err)
+				}
+				wantStatus := metav1.ConditionFalse
+				if clusterID == tc.conflicted {
+					wantStatus = metav1.ConditionTrue
+				}
+				cond := meta.FindStatusCondition(export.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+				if cond == nil || cond.Status != wantStatus {
+					t.Errorf("internalServiceExport %s conflict condition = %+v, want %s", clusterID, cond, wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcile_LastExportDeleted(t *testing.T) {
+	ctx := context.Background()
+	// The internalServiceExport controller resets the status of the serviceImport when the last export is deleted.
+	r := serviceImportReconciler(t, serviceImportForTest())
+	name := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+
+	if err := r.Client.Get(ctx, name, &fleetnetv1alpha1.ServiceImport{}); !errors.IsNotFound(err) {
+		t.Errorf("serviceImport Get() = %v, want not found error", err)
+	}
+}
+
+func TestReconcile_ServiceInUseBy(t *testing.T) {
+	ctx := context.Background()
+	svcImport := serviceImportForTest()
+	svcImport.Annotations = map[string]string{
+		objectmeta.ServiceImportAnnotationServiceInUseBy: `{"MemberClusters":{"fleet-member-member-2":"member-2","fleet-member-member-1":"member-1"}}`,
+	}
+	// The service has been resolved; the member clusters using it are still exposed to its exports.
+	svcImport.Status = fleetnetv1alpha1.ServiceImportStatus{
+		Ports:    testPorts,
+		Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: testMemberClusterA}},
+		Type:     fleetnetv1alpha1.ClusterSetIP,
+	}
+	export := internalServiceExportForTest(testMemberClusterA, testPorts)
+	r := serviceImportReconciler(t, svcImport, export)
+	name := types.NamespacedName{Namespace: testNamespace, Name: testServiceName}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(export), export); err != nil {
+		t.Fatalf("internalServiceExport Get() = %v, want no error", err)
+	}
+	want := []fleetnetv1alpha1.ClusterID{"member-1", "member-2"}
+	if diff := cmp.Diff(want, export.Status.InUseBy); diff != "" {
+		t.Errorf("internalServiceExport inUseBy mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The service is no longer used once the annotation is removed.
+	if err := r.Client.Get(ctx, name, svcImport); err != nil {
+		t.Fatalf("serviceImport Get() = %v, want no error", err)
+	}
+	delete(svcImport.Annotations, objectmeta.ServiceImportAnnotationServiceInUseBy)
+	if err := r.Client.Update(ctx, svcImport); err != nil {
+		t.Fatalf("serviceImport Update() = %v, want no error", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if err := r.Client.Get(ctx, client.ObjectKeyFromObject(export), export); err != nil {
+		t.Fatalf("internalServiceExport Get() = %v, want no error", err)
+	}
+	if len(export.Status.InUseBy) != 0 {
+		t.Errorf("internalServiceExport inUseBy = %v, want empty", export.Status.InUseBy)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/externalquery"
+)
+
+const (
+	// autoWeightMetricNameQueryParameter is the query parameter which carries the metric name when querying the
+	// metrics endpoint.
+	autoWeightMetricNameQueryParameter = "metricName"
+
+	// autoWeightQueryTimeout is the timeout of a single query to the metrics endpoint.
+	autoWeightQueryTimeout = 10 * time.Second
+
+	// autoWeightResyncPeriod is the period at which the backends of profiles with auto weights are requeued so that
+	// the weights of the endpoints follow the load of the clusters; the metrics endpoints are queried again at the
+	// same period.
+	autoWeightResyncPeriod = 5 * time.Minute
+
+	// autoWeightPendingRequeueInterval is the wait time for the backends of profiles with auto weights to be requeued
+	// while the first query of their metrics endpoint is still running.
+	autoWeightPendingRequeueInterval = 5 * time.Second
+
+	// maxAutoWeightResponseBytes caps the size of the response read from the metrics endpoint.
+	maxAutoWeightResponseBytes = 1 << 20
+)
+
+// clusterCapacityList is the response of the metrics endpoint.
+type clusterCapacityList struct {
+	Items []clusterCapacity `json:"items"`
+}
+
+// clusterCapacity is the available capacity of a cluster, as a percentage.
+type clusterCapacity struct {
+	Cluster string  `json:"cluster"`
+	Value   float64 `json:"value"`
+}
+
+// metricsQueryURL returns the URL which is queried for the metric of the auto weight source.
+func metricsQueryURL(source *fleetnetv1beta1.AutoWeightSourceConfig) (string, error) {
+	endpoint, err := url.Parse(source.MetricsEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the metrics endpoint %q: %w", source.MetricsEndpoint, err)
+	}
+	query := endpoint.Query()
+	query.Set(autoWeightMetricNameQueryParameter, source.MetricName)
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// queryClusterCapacities queries the metrics endpoint of the auto weight source and returns the available capacity
+// of each cluster reported, keyed by the cluster name; the metrics endpoint must point to one of the allowed hosts.
+func (r *Reconciler) queryClusterCapacities(ctx context.Context, queryURL string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, autoWeightQueryTimeout)
+	defer cancel()
+	resp, err := externalquery.Get(ctx, r.metricsHTTPClient(), queryURL, r.MetricsAllowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the metrics endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint responded with status %q", resp.Status)
+	}
+
+	list := clusterCapacityList{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAutoWeightResponseBytes)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of the metrics endpoint: %w", err)
+	}
+	capacities := make(map[string]float64, len(list.Items))
+	for _, item := range list.Items {
+		if item.Cluster == "" || math.IsNaN(item.Value) {
+			continue
+		}
+		// The capacity is a percentage; over-committed clusters may report negative values.
+		capacities[item.Cluster] = math.Min(math.Max(item.Value, 0), 100)
+	}
+	return capacities, nil
+}
+
+// autoWeights returns the weights of the desired endpoints derived from the available capacity of their clusters,
+// keyed by the endpoint name; the metrics endpoint is queried in the background and the latest capacities reported
+// are used.
+// It returns nil when the weights cannot be derived, in which case the weights of the exported services are used.
+func (r *Reconciler) autoWeights(backend *fleetnetv1beta1.TrafficManagerBackend, profile *fleetnetv1beta1.TrafficManagerProfile, desiredEndpoints map[string]desiredEndpoint) map[string]float64 {
+	backendKObj := klog.KObj(backend)
+	queryURL, err := metricsQueryURL(profile.Spec.AutoWeightSource)
+	if err != nil {
+		klog.ErrorS(err, "Invalid metrics endpoint, falling back to the weights of the exported services", "trafficManagerBackend", backendKObj, "trafficManagerProfile", klog.KObj(profile))
+		return nil
+	}
+	capacities, ok, err := r.capacityQuerier().Get(queryURL, func(ctx context.Context) (map[string]float64, error) {
+		return r.queryClusterCapacities(ctx, queryURL)
+	})
+	switch {
+	case !ok:
+		klog.V(2).InfoS("Cluster capacities are being queried, falling back to the weights of the exported services", "trafficManagerBackend", backendKObj, "trafficManagerProfile", klog.KObj(profile))
+		return nil
+	case err != nil:
+		klog.ErrorS(err, "Failed to query the cluster capacities, falling back to the weights of the exported services", "trafficManagerBackend", backendKObj, "trafficManagerProfile", klog.KObj(profile))
+		return nil
+	}
+	weights, err := computeAutoWeights(capacities, desiredEndpoints)
+	if err != nil {
+		klog.V(2).InfoS("Cannot derive the weights from the cluster capacities, falling back to the weights of the exported services", "trafficManagerBackend", backendKObj, "trafficManagerProfile", klog.KObj(profile), "error", err)
+		return nil
+	}
+	return weights
+}
+
+// autoWeightRequeueAfter returns the wait time for the backend of the profile to be requeued, so that the weights of
+// its endpoints follow the load of the clusters.
+func (r *Reconciler) autoWeightRequeueAfter(profile *fleetnetv1beta1.TrafficManagerProfile) time.Duration {
+	if queryURL, err := metricsQueryURL(profile.Spec.AutoWeightSource); err == nil && r.capacityQuerier().Pending(queryURL) {
+		return autoWeightPendingRequeueInterval
+	}
+	return autoWeightResyncPeriod
+}
+
+// computeAutoWeights returns the weights of the desired endpoints from the available capacity of their clusters.
+// All clusters must report their capacity; otherwise the weights of the clusters cannot be compared.
+func computeAutoWeights(capacities map[string]float64, desiredEndpoints map[string]desiredEndpoint) (map[string]float64, error) {
+	weights := make(map[string]float64, len(desiredEndpoints))
+	total := float64(0)
+	for name, dp := range desiredEndpoints {
+		capacity, ok := capacities[dp.Cluster.Cluster]
+		if !ok {
+			return nil, fmt.Errorf("no capacity reported for the cluster %q", dp.Cluster.Cluster)
+		}
+		weights[name] = capacity
+		total += capacity
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("no available capacity reported for any of the %d clusters", len(desiredEndpoints))
+	}
+	return weights, nil
+}
@@ -0,0 +1,212 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerbackend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+const (
+	testMetricName = "cluster_available_cpu_percentage"
+)
+
+// newMockMetricsEndpoint starts a metrics endpoint which responds with the given status code and body to the
+// queries of the test metric.
+func newMockMetricsEndpoint(t *testing.T, statusCode int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got := req.URL.Query().Get(autoWeightMetricNameQueryParameter); got != testMetricName {
+			t.Errorf("metric name query parameter = %q, want %q", got, testMetricName)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testDesiredEndpoints(clusters ...string) map[string]desiredEndpoint {
+	res := make(map[string]desiredEndpoint, len(clusters))
+	for _, cluster := range clusters {
+		res[cluster+"-endpoint"] = desiredEndpoint{
+			Endpoint: armtrafficmanager.Endpoint{
+				Name:       ptr.To(cluster + "-endpoint"),
+				Properties: &armtrafficmanager.EndpointProperties{},
+			},
+			Cluster: fleetnetv1beta1.ClusterStatus{Cluster: cluster},
+		}
+	}
+	return res
+}
+
+// testAllowedHosts allows the mock metrics endpoints, which listen on the loopback address.
+var testAllowedHosts = []string{"127.0.0.1"}
+
+func TestQueryClusterCapacities(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		allowedHosts []string
+		want         map[string]float64
+		wantErr      bool
+	}{
+		{
+			name:       "valid response",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": 42.5}, {"cluster": "member-2", "value": 10}]}`,
+			want:       map[string]float64{"member-1": 42.5, "member-2": 10},
+		},
+		{
+			name:       "out of range values are clamped",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": -20}, {"cluster": "member-2", "value": 120}, {"value": 50}]}`,
+			want:       map[string]float64{"member-1": 0, "member-2": 100},
+		},
+		{
+			name:       "error status",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{}`,
+			wantErr:    true,
+		},
+		{
+			name:       "malformed response",
+			statusCode: http.StatusOK,
+			body:       `{"items": [`,
+			wantErr:    true,
+		},
+		{
+			name:         "host not allowed",
+			statusCode:   http.StatusOK,
+			body:         `{"items": [{"cluster": "member-1", "value": 42.5}]}`,
+			allowedHosts: []string{"metrics.example.com"},
+			wantErr:      true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newMockMetricsEndpoint(t, tc.statusCode, tc.body)
+			allowedHosts := testAllowedHosts
+			if tc.allowedHosts != nil {
+				allowedHosts = tc.allowedHosts
+			}
+			r := &Reconciler{MetricsHTTPClient: server.Client(), MetricsAllowedHosts: allowedHosts}
+			source := &fleetnetv1beta1.AutoWeightSourceConfig{MetricsEndpoint: server.URL, MetricName: testMetricName}
+			queryURL, err := metricsQueryURL(source)
+			if err != nil {
+				t.Fatalf("metricsQueryURL() = %v, want no error", err)
+			}
+			got, err := r.queryClusterCapacities(context.Background(), queryURL)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("queryClusterCapacities() got error %v, want error %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("queryClusterCapacities() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAutoWeights(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		clusters   []string
+		want       map[string]float64
+	}{
+		{
+			name:       "weights follow the available capacity",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": 75}, {"cluster": "member-2", "value": 25}, {"cluster": "member-3", "value": 50}]}`,
+			clusters:   []string{"member-1", "member-2"},
+			want:       map[string]float64{"member-1-endpoint": 75, "member-2-endpoint": 25},
+		},
+		{
+			name:       "cluster without available capacity",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": 75}, {"cluster": "member-2", "value": 0}]}`,
+			clusters:   []string{"member-1", "member-2"},
+			want:       map[string]float64{"member-1-endpoint": 75, "member-2-endpoint": 0},
+		},
+		{
+			name:       "cluster not reported",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": 75}]}`,
+			clusters:   []string{"member-1", "member-2"},
+		},
+		{
+			name:       "no available capacity in any cluster",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"cluster": "member-1", "value": 0}, {"cluster": "member-2", "value": -5}]}`,
+			clusters:   []string{"member-1", "member-2"},
+		},
+		{
+			name:       "metrics endpoint unavailable",
+			statusCode: http.StatusInternalServerError,
+			body:       `{}`,
+			clusters:   []string{"member-1"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newMockMetricsEndpoint(t, tc.statusCode, tc.body)
+			r := &Reconciler{MetricsHTTPClient: server.Client(), MetricsAllowedHosts: testAllowedHosts}
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					AutoWeightSource: &fleetnetv1beta1.AutoWeightSourceConfig{
+						MetricsEndpoint: server.URL,
+						MetricName:      testMetricName,
+					},
+				},
+			}
+			backend := &fleetnetv1beta1.TrafficManagerBackend{}
+			desiredEndpoints := testDesiredEndpoints(tc.clusters...)
+
+			// The metrics endpoint is queried in the background; the weights of the exported services are used
+			// until the first query ends.
+			if got := r.autoWeights(backend, profile, desiredEndpoints); got != nil {
+				t.Fatalf("autoWeights() = %v before the first query ends, want nil", got)
+			}
+			if got := r.autoWeightRequeueAfter(profile); got != autoWeightPendingRequeueInterval {
+				t.Errorf("autoWeightRequeueAfter() = %v before the first query ends, want %v", got, autoWeightPendingRequeueInterval)
+			}
+			waitForCapacityQuery(t, r, profile)
+			if got := r.autoWeightRequeueAfter(profile); got != autoWeightResyncPeriod {
+				t.Errorf("autoWeightRequeueAfter() = %v after the first query ends, want %v", got, autoWeightResyncPeriod)
+			}
+			got := r.autoWeights(backend, profile, desiredEndpoints)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("autoWeights() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// waitForCapacityQuery waits for the first query of the auto weight source of the profile to end.
+func waitForCapacityQuery(t *testing.T, r *Reconciler, profile *fleetnetv1beta1.TrafficManagerProfile) {
+	t.Helper()
+	queryURL, err := metricsQueryURL(profile.Spec.AutoWeightSource)
+	if err != nil {
+		t.Fatalf("metricsQueryURL() = %v, want no error", err)
+	}
+	if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return !r.capacityQuerier().Pending(queryURL), nil
+	}); err != nil {
+		t.Fatalf("the auto weight source was not queried: %v", err)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
@@ -22,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -37,15 +40,17 @@ import (
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
 	"go.goms.io/fleet-networking/pkg/common/azureerrors"
 	"go.goms.io/fleet-networking/pkg/common/defaulter"
+	"go.goms.io/fleet-networking/pkg/common/externalquery"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 	"go.goms.io/fleet-networking/pkg/controllers/hub/trafficmanagerprofile"
 )
 
 const (
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "trafficmanagerbackend-controller"
+
 	trafficManagerBackendProfileFieldKey = ".spec.profile.name"
 	trafficManagerBackendBackendFieldKey = ".spec.backend.name"
-	// fields name used to filter resources
-	exportedServiceFieldNamespacedName = ".spec.serviceReference.namespacedName"
 
 	// AzureResourceEndpointNamePrefix is the prefix format of the Azure Traffic Manager Endpoint created by the fleet controller.
 	// The naming convention of a Traffic Manager Endpoint is fleet-{TrafficManagerBackendUUID}#.
@@ -79,6 +84,20 @@ type Reconciler struct {
 	ProfilesClient    *armtrafficmanager.ProfilesClient
 	EndpointsClient   *armtrafficmanager.EndpointsClient
 	ResourceGroupName string // default resource group name to create azure traffic manager resources
+
+	// MetricsHTTPClient is used to query the auto weight sources and the SLA monitors of the profiles; a client
+	// which refuses to follow redirects or to connect to loopback and link-local addresses is used when nil.
+	MetricsHTTPClient *http.Client
+	// MetricsAllowedHosts are the hosts the auto weight sources and the SLA monitors of the profiles may point to,
+	// either host names or wildcards of the form "*.example.com"; neither is ever queried if it is empty.
+	MetricsAllowedHosts []string
+
+	initQueriersOnce     sync.Once
+	defaultMetricsClient *http.Client
+	capacities           *externalquery.Querier[map[string]float64]
+	availabilities       *externalquery.Querier[map[string]float64]
+
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=trafficmanagerbackends,verbs=get;list;watch;create;update;patch;delete
@@ -256,7 +275,7 @@ func (r *Reconciler) handleUpdate(ctx context.Context, backend *fleetnetv1beta1.
 		return ctrl.Result{}, r.updateTrafficManagerBackendStatus(ctx, backend)
 	}
 
-	desiredEndpointsMaps, invalidServicesMaps, err := r.validateExportedServiceForServiceImport(ctx, backend, serviceImport)
+	desiredEndpointsMaps, invalidServicesMaps, err := r.validateExportedServiceForServiceImport(ctx, backend, profile, serviceImport)
 	if err != nil || (desiredEndpointsMaps == nil && invalidServicesMaps == nil) {
 		// We don't need to requeue not found internalServiceExport(err == nil and desiredEndpointsMaps == nil && invalidServicesMaps == nil)
 		// as when the serviceImport is updated, the controller will be re-triggered again.
@@ -264,6 +283,8 @@ func (r *Reconciler) handleUpdate(ctx context.Context, backend *fleetnetv1beta1.
 		return ctrl.Result{}, err
 	}
 	klog.V(2).InfoS("Found the exported services behind the serviceImport", "trafficManagerBackend", backendKObj, "serviceImport", klog.KObj(serviceImport), "numberOfDesiredEndpoints", len(desiredEndpointsMaps), "numberOfInvalidServices", len(invalidServicesMaps))
+	setEndpointSubnets(profile, desiredEndpointsMaps)
+	r.setEndpointPriorities(backend, profile, atmProfile, desiredEndpointsMaps)
 
 	acceptedEndpoints, badEndpointsErr, err := r.updateTrafficManagerEndpointsAndUpdateStatusIfUnknown(ctx, backend, atmProfile, desiredEndpointsMaps)
 	if err != nil {
@@ -287,7 +308,48 @@ func (r *Reconciler) handleUpdate(ctx context.Context, backend *fleetnetv1beta1.
 		setFalseCondition(backend, acceptedEndpoints, invalidEndpointErrMessage)
 	}
 	klog.V(2).InfoS("Updated Traffic Manager endpoints for the serviceImport and updating the condition", "trafficManagerBackend", backendKObj, "status", backend.Status)
-	return ctrl.Result{}, r.updateTrafficManagerBackendStatus(ctx, backend)
+	if err := r.updateTrafficManagerBackendStatus(ctx, backend); err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case profile.Spec.SLAMonitorURL != "":
+		// The availability of the endpoints changes without any event on the hub cluster.
+		return ctrl.Result{RequeueAfter: r.slaMonitorRequeueAfter(profile)}, nil
+	case profile.Spec.AutoWeightSource != nil:
+		// The load of the clusters changes without any event on the hub cluster.
+		return ctrl.Result{RequeueAfter: r.autoWeightRequeueAfter(profile)}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// initQueriers sets up the clients and the queriers of the external systems the profiles point to.
+func (r *Reconciler) initQueriers() {
+	r.initQueriersOnce.Do(func() {
+		r.defaultMetricsClient = externalquery.NewClient(autoWeightQueryTimeout)
+		r.capacities = externalquery.NewQuerier[map[string]float64](autoWeightResyncPeriod)
+		r.availabilities = externalquery.NewQuerier[map[string]float64](slaMonitorResyncPeriod)
+	})
+}
+
+// metricsHTTPClient returns the client to query the auto weight sources and the SLA monitors of the profiles with.
+func (r *Reconciler) metricsHTTPClient() *http.Client {
+	if r.MetricsHTTPClient != nil {
+		return r.MetricsHTTPClient
+	}
+	r.initQueriers()
+	return r.defaultMetricsClient
+}
+
+// capacityQuerier returns the querier of the auto weight sources of the profiles.
+func (r *Reconciler) capacityQuerier() *externalquery.Querier[map[string]float64] {
+	r.initQueriers()
+	return r.capacities
+}
+
+// availabilityQuerier returns the querier of the SLA monitors of the profiles.
+func (r *Reconciler) availabilityQuerier() *externalquery.Querier[map[string]float64] {
+	r.initQueriers()
+	return r.availabilities
 }
 
 // validateTrafficManagerProfile returns not nil profile when the profile is valid.
@@ -440,7 +502,9 @@ type desiredEndpoint struct {
 // validateExportedServiceForServiceImport returns two maps:
 // * a map of desired endpoints for the serviceImport (key is the endpoint name).
 // * a map of invalid services which cannot be exposed as the trafficManagerEndpoints (key is the cluster name).
-func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context, backend *fleetnetv1beta1.TrafficManagerBackend, serviceImport *fleetnetv1alpha1.ServiceImport) (map[string]desiredEndpoint, map[string]error, error) {
+// The weights of the desired endpoints are derived from the available capacity of the clusters when the profile has
+// an auto weight source, and from the weights of the exported services otherwise.
+func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context, backend *fleetnetv1beta1.TrafficManagerBackend, profile *fleetnetv1beta1.TrafficManagerProfile, serviceImport *fleetnetv1alpha1.ServiceImport) (map[string]desiredEndpoint, map[string]error, error) {
 	backendKObj := klog.KObj(backend)
 	serviceImportKObj := klog.KObj(serviceImport)
 
@@ -456,7 +520,7 @@ func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context
 	internalServiceExportList := &fleetnetv1alpha1.InternalServiceExportList{}
 	namespaceName := types.NamespacedName{Namespace: serviceImport.Namespace, Name: serviceImport.Name}
 	listOpts := client.MatchingFields{
-		exportedServiceFieldNamespacedName: namespaceName.String(),
+		objectmeta.InternalServiceExportFieldServiceNamespacedName: namespaceName.String(),
 	}
 	if listErr := r.Client.List(ctx, internalServiceExportList, &listOpts); listErr != nil {
 		klog.ErrorS(listErr, "Failed to list internalServiceExports used by the serviceImport", "trafficManagerBackend", backendKObj, "serviceImport", serviceImportKObj)
@@ -473,6 +537,7 @@ func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context
 
 	desiredEndpoints := make(map[string]desiredEndpoint, len(serviceImport.Status.Clusters)) // key is the endpoint name
 	invalidServices := make(map[string]error, len(serviceImport.Status.Clusters))            // key is cluster name
+	exportWeights := make(map[string]float64, len(serviceImport.Status.Clusters))            // key is the endpoint name
 	for _, clusterStatus := range serviceImport.Status.Clusters {
 		internalServiceExport, ok := internalServiceExportMap[clusterStatus.Cluster]
 		if !ok {
@@ -489,7 +554,13 @@ func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context
 			klog.V(2).InfoS("Invalid service for TrafficManager endpoint", "trafficManagerBackend", backendKObj, "serviceImport", serviceImportKObj, "clusterID", clusterStatus.Cluster, "error", err)
 			continue
 		}
+		weight := exportedServiceWeight(internalServiceExport)
+		if weight == 0 {
+			klog.V(2).InfoS("Weight of the exported service is 0, skipping creating the endpoint", "trafficManagerBackend", backendKObj, "serviceImport", serviceImportKObj, "clusterID", clusterStatus.Cluster)
+			continue
+		}
 		endpoint := generateAzureTrafficManagerEndpoint(backend, internalServiceExport)
+		exportWeights[*endpoint.Name] = float64(weight)
 		desiredEndpoints[*endpoint.Name] = desiredEndpoint{
 			Endpoint: endpoint,
 			Cluster: fleetnetv1beta1.ClusterStatus{
@@ -497,14 +568,33 @@ func (r *Reconciler) validateExportedServiceForServiceImport(ctx context.Context
 			},
 		}
 	}
-	desiredWeight := int(math.Ceil(float64(*backend.Spec.Weight) / float64(len(desiredEndpoints))))
-	for _, dp := range desiredEndpoints {
-		dp.Endpoint.Properties.Weight = ptr.To(int64(desiredWeight))
+	if profile.Spec.AutoWeightSource != nil && len(desiredEndpoints) > 0 {
+		if weights := r.autoWeights(backend, profile, desiredEndpoints); weights != nil {
+			exportWeights = weights
+		}
+	}
+	totalWeight := float64(0)
+	for _, weight := range exportWeights {
+		totalWeight += weight
 	}
-	klog.V(2).InfoS("Finishing validating services", "trafficManagerBackend", backendKObj, "serviceImport", serviceImportKObj, "numberOfDesiredEndpoints", len(desiredEndpoints), "numberOfInvalidServices", len(invalidServices), "desiredWeight", desiredWeight)
+	for name, dp := range desiredEndpoints {
+		// The actual weight of each endpoint is the ceiling value of weight of serviceExport/(sum of all weights) * backend weight.
+		// An endpoint is kept with the minimum weight even if its cluster has no available capacity left.
+		desiredWeight := int64(math.Ceil(float64(*backend.Spec.Weight) * exportWeights[name] / totalWeight))
+		dp.Endpoint.Properties.Weight = ptr.To(max(desiredWeight, 1))
+	}
+	klog.V(2).InfoS("Finishing validating services", "trafficManagerBackend", backendKObj, "serviceImport", serviceImportKObj, "numberOfDesiredEndpoints", len(desiredEndpoints), "numberOfInvalidServices", len(invalidServices), "totalWeight", totalWeight)
 	return desiredEndpoints, invalidServices, nil
 }
 
+// exportedServiceWeight returns the weight of the exported service; it defaults to 1 when the weight is not set.
+func exportedServiceWeight(export *fleetnetv1alpha1.InternalServiceExport) int64 {
+	if export.Spec.Weight == nil {
+		return 1
+	}
+	return *export.Spec.Weight
+}
+
 // isValidTrafficManagerEndpoint returns error if the service cannot be added as a TrafficManager endpoint.
 func isValidTrafficManagerEndpoint(export *fleetnetv1alpha1.InternalServiceExport) error {
 	if export.Spec.Type != corev1.ServiceTypeLoadBalancer {
@@ -552,9 +642,21 @@ func equalAzureTrafficManagerEndpoint(current, desired armtrafficmanager.Endpoin
 	if current.Properties == nil || current.Properties.TargetResourceID == nil || current.Properties.Weight == nil || current.Properties.EndpointStatus == nil {
 		return false
 	}
+	// Azure assigns priorities to the endpoints which are not given any; they are only compared when desired.
 	return strings.EqualFold(*current.Properties.TargetResourceID, *desired.Properties.TargetResourceID) &&
 		*current.Properties.Weight == *desired.Properties.Weight &&
-		*current.Properties.EndpointStatus == *desired.Properties.EndpointStatus
+		*current.Properties.EndpointStatus == *desired.Properties.EndpointStatus &&
+		trafficmanagerprofile.EqualEndpointSubnets(current.Properties.Subnets, desired.Properties.Subnets) &&
+		(desired.Properties.Priority == nil || ptr.Equal(current.Properties.Priority, desired.Properties.Priority))
+}
+
+// setEndpointSubnets sets the subnets the subnet config of the profile maps to each desired endpoint; the endpoints
+// of a profile using another routing method get no subnets.
+func setEndpointSubnets(profile *fleetnetv1beta1.TrafficManagerProfile, desiredEndpoints map[string]desiredEndpoint) {
+	subnets := trafficmanagerprofile.EndpointSubnets(profile)
+	for name, dp := range desiredEndpoints {
+		dp.Endpoint.Properties.Subnets = subnets[strings.ToLower(name)]
+	}
 }
 
 // updateTrafficManagerEndpointsAndUpdateStatusIfUnknown updates the Azure Traffic Manager endpoints.
@@ -666,8 +768,8 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, dis
 			}
 			return []string{name.Spec.ServiceReference.NamespacedName}
 		}
-		if err := mgr.GetFieldIndexer().IndexField(ctx, &fleetnetv1alpha1.InternalServiceExport{}, exportedServiceFieldNamespacedName, internalServiceExportIndexerFunc); err != nil {
-			klog.ErrorS(err, "Failed to create index", "field", exportedServiceFieldNamespacedName)
+		if err := mgr.GetFieldIndexer().IndexField(ctx, &fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, internalServiceExportIndexerFunc); err != nil {
+			klog.ErrorS(err, "Failed to create index", "field", objectmeta.InternalServiceExportFieldServiceNamespacedName)
 			return err
 		}
 	}
@@ -6,6 +6,9 @@ Licensed under the MIT license.
 package trafficmanagerbackend
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
@@ -13,6 +16,9 @@ import (
 	"k8s.io/utils/ptr"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/azureerrors"
+	"go.goms.io/fleet-networking/test/common/trafficmanager/fakeprovider"
 )
 
 func TestIsValidTrafficManagerEndpoint(t *testing.T) {
@@ -75,6 +81,41 @@ func TestIsValidTrafficManagerEndpoint(t *testing.T) {
 	}
 }
 
+func TestExportedServiceWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		weight *int64
+		want   int64
+	}{
+		{
+			name: "weight is not set",
+			want: 1,
+		},
+		{
+			name:   "weight is 0",
+			weight: ptr.To(int64(0)),
+			want:   0,
+		},
+		{
+			name:   "weight is set",
+			weight: ptr.To(int64(100)),
+			want:   100,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			export := &fleetnetv1alpha1.InternalServiceExport{
+				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					Weight: tc.weight,
+				},
+			}
+			if got := exportedServiceWeight(export); got != tc.want {
+				t.Errorf("exportedServiceWeight() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestEqualAzureTrafficManagerEndpoint(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -170,6 +211,20 @@ func TestEqualAzureTrafficManagerEndpoint(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Properties.Subnets is different",
+			current: armtrafficmanager.Endpoint{
+				Type: ptr.To(string(armtrafficmanager.EndpointTypeAzureEndpoints)),
+				Properties: &armtrafficmanager.EndpointProperties{
+					TargetResourceID: ptr.To("resourceID"),
+					EndpointStatus:   ptr.To(armtrafficmanager.EndpointStatusEnabled),
+					Weight:           ptr.To(int64(100)),
+					Subnets: []*armtrafficmanager.EndpointPropertiesSubnetsItem{
+						{First: ptr.To("10.0.0.0"), Scope: ptr.To(int32(16))},
+					},
+				},
+			},
+		},
 	}
 	desired := armtrafficmanager.Endpoint{
 		Type: ptr.To(string(armtrafficmanager.EndpointTypeAzureEndpoints)),
@@ -187,3 +242,107 @@ func TestEqualAzureTrafficManagerEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateTrafficManagerEndpoints_SubnetRouting(t *testing.T) {
+	endpointsClient, err := fakeprovider.NewEndpointsClient("default-sub")
+	if err != nil {
+		t.Fatalf("NewEndpointsClient() failed: %v", err)
+	}
+	r := &Reconciler{
+		EndpointsClient:   endpointsClient,
+		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			RoutingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+			SubnetConfig: []fleetnetv1beta1.SubnetRoutingRule{
+				{CIDR: "10.1.0.0/16", EndpointName: strings.ToUpper(fakeprovider.ValidEndpointName)},
+			},
+		},
+	}
+	unmappedEndpointName := fmt.Sprintf("%s#%s#%s", fakeprovider.ValidBackendName, fakeprovider.ServiceImportName, "member-2")
+	endpointForTest := func(name, cluster string) desiredEndpoint {
+		return desiredEndpoint{
+			Endpoint: armtrafficmanager.Endpoint{
+				Name: ptr.To(name),
+				Type: ptr.To(string("Microsoft.Network/trafficManagerProfiles/" + armtrafficmanager.EndpointTypeAzureEndpoints)),
+				Properties: &armtrafficmanager.EndpointProperties{
+					TargetResourceID: ptr.To(fakeprovider.ValidPublicIPResourceID),
+					EndpointStatus:   ptr.To(armtrafficmanager.EndpointStatusEnabled),
+					Weight:           ptr.To(fakeprovider.Weight),
+				},
+			},
+			Cluster: fleetnetv1beta1.ClusterStatus{Cluster: cluster},
+		}
+	}
+	desiredEndpoints := map[string]desiredEndpoint{
+		fakeprovider.ValidEndpointName: endpointForTest(fakeprovider.ValidEndpointName, fakeprovider.ClusterName),
+		unmappedEndpointName:           endpointForTest(unmappedEndpointName, "member-2"),
+	}
+	setEndpointSubnets(profile, desiredEndpoints)
+
+	atmProfile := &armtrafficmanager.Profile{
+		Name:       ptr.To(fakeprovider.ValidSubnetProfileName),
+		Properties: &armtrafficmanager.ProfileProperties{},
+	}
+	backend := &fleetnetv1beta1.TrafficManagerBackend{}
+	accepted, badEndpoints, err := r.updateTrafficManagerEndpointsAndUpdateStatusIfUnknown(context.Background(), backend, atmProfile, desiredEndpoints)
+	if err != nil {
+		t.Fatalf("updateTrafficManagerEndpointsAndUpdateStatusIfUnknown() = %v, want no error", err)
+	}
+	if len(accepted) != 1 || accepted[0].Name != fakeprovider.ValidEndpointName {
+		t.Errorf("updateTrafficManagerEndpointsAndUpdateStatusIfUnknown() accepted endpoints = %+v, want only %s", accepted, fakeprovider.ValidEndpointName)
+	}
+	// The endpoint no subnet is mapped to is rejected by Azure.
+	if len(badEndpoints) != 1 || !azureerrors.IsClientError(badEndpoints[0]) {
+		t.Errorf("updateTrafficManagerEndpointsAndUpdateStatusIfUnknown() bad endpoints = %v, want one client error", badEndpoints)
+	}
+}
+
+func TestEqualAzureTrafficManagerEndpoint_Priority(t *testing.T) {
+	endpoint := func(priority *int64) armtrafficmanager.Endpoint {
+		return armtrafficmanager.Endpoint{
+			Type: ptr.To(string(armtrafficmanager.EndpointTypeAzureEndpoints)),
+			Properties: &armtrafficmanager.EndpointProperties{
+				TargetResourceID: ptr.To("resourceID"),
+				EndpointStatus:   ptr.To(armtrafficmanager.EndpointStatusEnabled),
+				Weight:           ptr.To(int64(100)),
+				Priority:         priority,
+			},
+		}
+	}
+	tests := []struct {
+		name    string
+		current *int64
+		desired *int64
+		want    bool
+	}{
+		{
+			name:    "priority assigned by Azure is ignored when not desired",
+			current: ptr.To(int64(3)),
+			want:    true,
+		},
+		{
+			name:    "same priority",
+			current: ptr.To(int64(501)),
+			desired: ptr.To(int64(501)),
+			want:    true,
+		},
+		{
+			name:    "different priority",
+			current: ptr.To(int64(1)),
+			desired: ptr.To(int64(501)),
+		},
+		{
+			name:    "priority not set",
+			desired: ptr.To(int64(1)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := equalAzureTrafficManagerEndpoint(endpoint(tt.current), endpoint(tt.desired)); got != tt.want {
+				t.Errorf("equalAzureTrafficManagerEndpoint() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/externalquery"
+	"go.goms.io/fleet-networking/pkg/controllers/hub/trafficmanagerprofile"
+)
+
+const (
+	// slaMonitorQueryTimeout is the timeout of a single query to the SLA monitor.
+	slaMonitorQueryTimeout = 10 * time.Second
+
+	// slaMonitorResyncPeriod is the period at which the backends of profiles with an SLA monitor are requeued so that
+	// the priorities of the endpoints follow their availability; the SLA monitors are queried again at the same
+	// period.
+	slaMonitorResyncPeriod = time.Minute
+
+	// slaMonitorPendingRequeueInterval is the wait time for the backends of profiles with an SLA monitor to be
+	// requeued while the first query of their SLA monitor is still running.
+	slaMonitorPendingRequeueInterval = 5 * time.Second
+
+	// maxSLAMonitorResponseBytes caps the size of the response read from the SLA monitor.
+	maxSLAMonitorResponseBytes = 1 << 20
+
+	endpointDemotedEventReason  = "EndpointDemoted"
+	endpointPromotedEventReason = "EndpointPromoted"
+)
+
+// endpointAvailabilityList is the response of the SLA monitor.
+type endpointAvailabilityList struct {
+	Items []endpointAvailability `json:"items"`
+}
+
+// endpointAvailability is the availability of an endpoint, as a ratio.
+type endpointAvailability struct {
+	Endpoint     string  `json:"endpoint"`
+	Availability float64 `json:"availability"`
+}
+
+// queryEndpointAvailabilities queries the SLA monitor and returns the availability of each endpoint reported, keyed
+// by the lower-cased endpoint name; the SLA monitor must point to one of the allowed hosts.
+func (r *Reconciler) queryEndpointAvailabilities(ctx context.Context, slaMonitorURL string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, slaMonitorQueryTimeout)
+	defer cancel()
+	resp, err := externalquery.Get(ctx, r.metricsHTTPClient(), slaMonitorURL, r.MetricsAllowedHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the SLA monitor: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SLA monitor responded with status %q", resp.Status)
+	}
+
+	list := endpointAvailabilityList{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSLAMonitorResponseBytes)).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode the response of the SLA monitor: %w", err)
+	}
+	availabilities := make(map[string]float64, len(list.Items))
+	for _, item := range list.Items {
+		if item.Endpoint == "" || math.IsNaN(item.Availability) {
+			continue
+		}
+		availabilities[strings.ToLower(item.Endpoint)] = item.Availability
+	}
+	return availabilities, nil
+}
+
+// setEndpointPriorities sets the priorities the priority config of the profile assigns to each desired endpoint; the
+// endpoints of a profile using another routing method get no priority.
+//
+// When the profile has an SLA monitor, the endpoints whose availability is below the demotion threshold are demoted
+// to a lower priority band, and the demoted endpoints whose availability has recovered get their priorities back;
+// each demotion and promotion is recorded as an event of the backend. The endpoints whose availability is not
+// reported, e.g. while the SLA monitor cannot be queried, keep their current priority band. The SLA monitor is
+// queried in the background and the latest availabilities reported are used.
+func (r *Reconciler) setEndpointPriorities(backend *fleetnetv1beta1.TrafficManagerBackend, profile *fleetnetv1beta1.TrafficManagerProfile, atmProfile *armtrafficmanager.Profile, desiredEndpoints map[string]desiredEndpoint) {
+	priorities := trafficmanagerprofile.EndpointPriorities(profile)
+	if priorities == nil {
+		return
+	}
+	var availabilities map[string]float64
+	if slaMonitorURL := profile.Spec.SLAMonitorURL; slaMonitorURL != "" {
+		result, ok, err := r.availabilityQuerier().Get(slaMonitorURL, func(ctx context.Context) (map[string]float64, error) {
+			return r.queryEndpointAvailabilities(ctx, slaMonitorURL)
+		})
+		switch {
+		case !ok:
+			klog.V(2).InfoS("Endpoint availabilities are being queried, keeping the current priority bands of the endpoints", "trafficManagerBackend", klog.KObj(backend), "trafficManagerProfile", klog.KObj(profile))
+		case err != nil:
+			klog.ErrorS(err, "Failed to query the SLA monitor, keeping the current priority bands of the endpoints", "trafficManagerBackend", klog.KObj(backend), "trafficManagerProfile", klog.KObj(profile))
+		default:
+			availabilities = result
+		}
+	}
+	currentPriorities := endpointPriorities(atmProfile)
+	threshold := trafficmanagerprofile.DemotionThreshold(profile)
+
+	for name, dp := range desiredEndpoints {
+		key := strings.ToLower(name)
+		priority, ok := priorities[key]
+		if !ok {
+			// The endpoint is rejected by Azure, which is reported in the status of the backend.
+			continue
+		}
+		wasDemoted := trafficmanagerprofile.IsDemotedEndpointPriority(currentPriorities[key])
+		// The endpoints get their priorities back once the SLA monitor is removed.
+		demoted := wasDemoted && profile.Spec.SLAMonitorURL != ""
+		availability, reported := availabilities[key]
+		if reported {
+			demoted = availability < threshold
+		}
+		if demoted {
+			priority = trafficmanagerprofile.DemotedEndpointPriority(priority)
+		}
+		dp.Endpoint.Properties.Priority = ptr.To(priority)
+
+		switch {
+		case demoted && !wasDemoted:
+			klog.V(2).InfoS("Demoting the endpoint on SLA breach", "trafficManagerBackend", klog.KObj(backend), "atmEndpoint", name, "availability", availability, "demotionThreshold", threshold, "priority", priority)
+			r.Recorder.Eventf(backend, corev1.EventTypeWarning, endpointDemotedEventReason,
+				"Demoted endpoint %s to priority %d as its availability %.4f is below the demotion threshold %.4f", name, priority, availability, threshold)
+		case !demoted && wasDemoted:
+			klog.V(2).InfoS("Promoting the endpoint back to its priority", "trafficManagerBackend", klog.KObj(backend), "atmEndpoint", name, "availability", availability, "demotionThreshold", threshold, "priority", priority)
+			if reported {
+				r.Recorder.Eventf(backend, corev1.EventTypeNormal, endpointPromotedEventReason,
+					"Restored endpoint %s to priority %d as its availability %.4f has recovered", name, priority, availability)
+			} else {
+				r.Recorder.Eventf(backend, corev1.EventTypeNormal, endpointPromotedEventReason,
+					"Restored endpoint %s to priority %d as the profile no longer has an SLA monitor", name, priority)
+			}
+		}
+	}
+}
+
+// slaMonitorRequeueAfter returns the wait time for the backend of the profile to be requeued, so that the priorities
+// of its endpoints follow their availability.
+func (r *Reconciler) slaMonitorRequeueAfter(profile *fleetnetv1beta1.TrafficManagerProfile) time.Duration {
+	if r.availabilityQuerier().Pending(profile.Spec.SLAMonitorURL) {
+		return slaMonitorPendingRequeueInterval
+	}
+	return slaMonitorResyncPeriod
+}
+
+// endpointPriorities returns the priorities of the endpoints of an Azure Traffic Manager profile, keyed by the
+// lower-cased endpoint name.
+func endpointPriorities(atmProfile *armtrafficmanager.Profile) map[string]int64 {
+	priorities := make(map[string]int64)
+	if atmProfile == nil || atmProfile.Properties == nil {
+		return priorities
+	}
+	for _, endpoint := range atmProfile.Properties.Endpoints {
+		if endpoint == nil || endpoint.Name == nil || endpoint.Properties == nil || endpoint.Properties.Priority == nil {
+			continue
+		}
+		priorities[strings.ToLower(*endpoint.Name)] = *endpoint.Properties.Priority
+	}
+	return priorities
+}
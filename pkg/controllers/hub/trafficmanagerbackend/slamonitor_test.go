@@ -0,0 +1,253 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerbackend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+// newMockSLAMonitor starts an SLA monitor which responds with the given status code and body.
+func newMockSLAMonitor(t *testing.T, statusCode int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestQueryEndpointAvailabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		body         string
+		allowedHosts []string
+		want         map[string]float64
+		wantErr      bool
+	}{
+		{
+			name:       "valid response",
+			statusCode: http.StatusOK,
+			body:       `{"items": [{"endpoint": "Member-1-Endpoint", "availability": 0.995}, {"endpoint": "member-2-endpoint", "availability": 0.9}, {"availability": 1}]}`,
+			want:       map[string]float64{"member-1-endpoint": 0.995, "member-2-endpoint": 0.9},
+		},
+		{
+			name:       "error status",
+			statusCode: http.StatusServiceUnavailable,
+			body:       `{}`,
+			wantErr:    true,
+		},
+		{
+			name:       "invalid body",
+			statusCode: http.StatusOK,
+			body:       `not json`,
+			wantErr:    true,
+		},
+		{
+			name:         "host not allowed",
+			statusCode:   http.StatusOK,
+			body:         `{"items": [{"endpoint": "member-1-endpoint", "availability": 0.995}]}`,
+			allowedHosts: []string{"sla.example.com"},
+			wantErr:      true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newMockSLAMonitor(t, tc.statusCode, tc.body)
+			allowedHosts := testAllowedHosts
+			if tc.allowedHosts != nil {
+				allowedHosts = tc.allowedHosts
+			}
+			r := &Reconciler{MetricsHTTPClient: server.Client(), MetricsAllowedHosts: allowedHosts}
+			got, err := r.queryEndpointAvailabilities(context.Background(), server.URL)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("queryEndpointAvailabilities() got error %v, want error %t", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("queryEndpointAvailabilities() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetEndpointPriorities(t *testing.T) {
+	priorityConfig := []fleetnetv1beta1.EndpointPriority{
+		{EndpointName: "member-1-endpoint", Priority: 1},
+		{EndpointName: "member-2-endpoint", Priority: 2},
+	}
+	tests := []struct {
+		name              string
+		routingMethod     fleetnetv1beta1.TrafficRoutingMethod
+		slaMonitorBody    string
+		slaMonitorStatus  int
+		noSLAMonitor      bool
+		basisPoints       *int64
+		currentPriorities map[string]int64
+		want              map[string]*int64
+		wantEvents        []string
+	}{
+		{
+			name:          "weighted routing method",
+			routingMethod: fleetnetv1beta1.TrafficRoutingMethodWeighted,
+			noSLAMonitor:  true,
+			want:          map[string]*int64{"member-1-endpoint": nil, "member-2-endpoint": nil},
+		},
+		{
+			name:          "no SLA monitor",
+			routingMethod: fleetnetv1beta1.TrafficRoutingMethodPriority,
+			noSLAMonitor:  true,
+			want:          map[string]*int64{"member-1-endpoint": ptr.To(int64(1)), "member-2-endpoint": ptr.To(int64(2))},
+		},
+		{
+			name:             "endpoint breaching its SLA is demoted",
+			routingMethod:    fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus: http.StatusOK,
+			slaMonitorBody:   `{"items": [{"endpoint": "member-1-endpoint", "availability": 0.95}, {"endpoint": "member-2-endpoint", "availability": 0.999}]}`,
+			want:             map[string]*int64{"member-1-endpoint": ptr.To(int64(501)), "member-2-endpoint": ptr.To(int64(2))},
+			wantEvents: []string{
+				"Warning EndpointDemoted Demoted endpoint member-1-endpoint to priority 501 as its availability 0.9500 is below the demotion threshold 0.9900",
+			},
+		},
+		{
+			name:             "zero demotion threshold demotes no endpoint",
+			routingMethod:    fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus: http.StatusOK,
+			slaMonitorBody:   `{"items": [{"endpoint": "member-1-endpoint", "availability": 0}, {"endpoint": "member-2-endpoint", "availability": 0.5}]}`,
+			basisPoints:      ptr.To(int64(0)),
+			want:             map[string]*int64{"member-1-endpoint": ptr.To(int64(1)), "member-2-endpoint": ptr.To(int64(2))},
+		},
+		{
+			name:              "demoted endpoint stays demoted",
+			routingMethod:     fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus:  http.StatusOK,
+			slaMonitorBody:    `{"items": [{"endpoint": "member-1-endpoint", "availability": 0.95}, {"endpoint": "member-2-endpoint", "availability": 0.999}]}`,
+			currentPriorities: map[string]int64{"member-1-endpoint": 501, "member-2-endpoint": 2},
+			want:              map[string]*int64{"member-1-endpoint": ptr.To(int64(501)), "member-2-endpoint": ptr.To(int64(2))},
+		},
+		{
+			name:              "recovered endpoint is promoted",
+			routingMethod:     fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus:  http.StatusOK,
+			slaMonitorBody:    `{"items": [{"endpoint": "member-1-endpoint", "availability": 0.99}, {"endpoint": "member-2-endpoint", "availability": 0.999}]}`,
+			currentPriorities: map[string]int64{"member-1-endpoint": 501, "member-2-endpoint": 2},
+			want:              map[string]*int64{"member-1-endpoint": ptr.To(int64(1)), "member-2-endpoint": ptr.To(int64(2))},
+			wantEvents: []string{
+				"Normal EndpointPromoted Restored endpoint member-1-endpoint to priority 1 as its availability 0.9900 has recovered",
+			},
+		},
+		{
+			name:              "endpoints not reported keep their priority bands",
+			routingMethod:     fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus:  http.StatusOK,
+			slaMonitorBody:    `{"items": []}`,
+			currentPriorities: map[string]int64{"member-1-endpoint": 501},
+			want:              map[string]*int64{"member-1-endpoint": ptr.To(int64(501)), "member-2-endpoint": ptr.To(int64(2))},
+		},
+		{
+			name:              "SLA monitor unavailable",
+			routingMethod:     fleetnetv1beta1.TrafficRoutingMethodPriority,
+			slaMonitorStatus:  http.StatusServiceUnavailable,
+			slaMonitorBody:    `{}`,
+			currentPriorities: map[string]int64{"member-1-endpoint": 501, "member-2-endpoint": 2},
+			want:              map[string]*int64{"member-1-endpoint": ptr.To(int64(501)), "member-2-endpoint": ptr.To(int64(2))},
+		},
+		{
+			name:              "SLA monitor removed",
+			routingMethod:     fleetnetv1beta1.TrafficRoutingMethodPriority,
+			noSLAMonitor:      true,
+			currentPriorities: map[string]int64{"member-1-endpoint": 501, "member-2-endpoint": 2},
+			want:              map[string]*int64{"member-1-endpoint": ptr.To(int64(1)), "member-2-endpoint": ptr.To(int64(2))},
+			wantEvents: []string{
+				"Normal EndpointPromoted Restored endpoint member-1-endpoint to priority 1 as the profile no longer has an SLA monitor",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{Recorder: recorder}
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "profile"},
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					RoutingMethod:                ptr.To(tc.routingMethod),
+					DemotionThresholdBasisPoints: tc.basisPoints,
+				},
+			}
+			if tc.routingMethod == fleetnetv1beta1.TrafficRoutingMethodPriority {
+				profile.Spec.PriorityConfig = priorityConfig
+			}
+			atmProfile := &armtrafficmanager.Profile{Properties: &armtrafficmanager.ProfileProperties{}}
+			for name, priority := range tc.currentPriorities {
+				atmProfile.Properties.Endpoints = append(atmProfile.Properties.Endpoints, &armtrafficmanager.Endpoint{
+					Name:       ptr.To(name),
+					Properties: &armtrafficmanager.EndpointProperties{Priority: ptr.To(priority)},
+				})
+			}
+			backend := &fleetnetv1beta1.TrafficManagerBackend{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-ns", Name: "backend"},
+			}
+			if !tc.noSLAMonitor {
+				server := newMockSLAMonitor(t, tc.slaMonitorStatus, tc.slaMonitorBody)
+				r.MetricsHTTPClient = server.Client()
+				r.MetricsAllowedHosts = testAllowedHosts
+				profile.Spec.SLAMonitorURL = server.URL
+
+				// The SLA monitor is queried in the background; the endpoints keep their current priority bands
+				// until the first query ends.
+				r.setEndpointPriorities(backend, profile, atmProfile, testDesiredEndpoints("member-1", "member-2"))
+				if got := r.slaMonitorRequeueAfter(profile); got != slaMonitorPendingRequeueInterval {
+					t.Errorf("slaMonitorRequeueAfter() = %v before the first query ends, want %v", got, slaMonitorPendingRequeueInterval)
+				}
+				waitForAvailabilityQuery(t, r, profile)
+				if got := r.slaMonitorRequeueAfter(profile); got != slaMonitorResyncPeriod {
+					t.Errorf("slaMonitorRequeueAfter() = %v after the first query ends, want %v", got, slaMonitorResyncPeriod)
+				}
+			}
+			desiredEndpoints := testDesiredEndpoints("member-1", "member-2")
+
+			r.setEndpointPriorities(backend, profile, atmProfile, desiredEndpoints)
+			got := make(map[string]*int64, len(desiredEndpoints))
+			for name, dp := range desiredEndpoints {
+				got[name] = dp.Endpoint.Properties.Priority
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("setEndpointPriorities() priorities mismatch (-want, +got):\n%s", diff)
+			}
+			close(recorder.Events)
+			var gotEvents []string
+			for event := range recorder.Events {
+				gotEvents = append(gotEvents, event)
+			}
+			if diff := cmp.Diff(tc.wantEvents, gotEvents); diff != "" {
+				t.Errorf("setEndpointPriorities() events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// waitForAvailabilityQuery waits for the first query of the SLA monitor of the profile to end.
+func waitForAvailabilityQuery(t *testing.T, r *Reconciler, profile *fleetnetv1beta1.TrafficManagerProfile) {
+	t.Helper()
+	if err := wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return !r.availabilityQuerier().Pending(profile.Spec.SLAMonitorURL), nil
+	}); err != nil {
+		t.Fatalf("the SLA monitor was not queried: %v", err)
+	}
+}
@@ -283,6 +283,7 @@ var _ = BeforeSuite(func() {
 		ProfilesClient:    profileClient,
 		EndpointsClient:   endpointClient,
 		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+		Recorder:          mgr.GetEventRecorderFor(ControllerName),
 	}).SetupWithManager(ctx, mgr, false)
 	Expect(err).ToNot(HaveOccurred())
 
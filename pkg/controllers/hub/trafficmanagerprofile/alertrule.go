@@ -0,0 +1,174 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"go.goms.io/fleet/pkg/utils/controller"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/azureerrors"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// AzureResourceAlertRuleNameFormat is the name format of the Azure Monitor metric alert rule created for the Azure
+	// Traffic Manager profile; it consists of the name of the Azure Traffic Manager profile.
+	AzureResourceAlertRuleNameFormat = "%s-no-online-endpoints"
+
+	// endpointStateMetricName is the metric of the Azure Traffic Manager profiles which is 1 for each endpoint whose
+	// probes succeed, and 0 otherwise; its maximum drops below 1 when none of the endpoints is online.
+	endpointStateMetricName = "ProbeAgentCurrentEndpointStateByProfileResourceId"
+	// trafficManagerProfileMetricNamespace is the namespace of the metrics of the Azure Traffic Manager profiles.
+	trafficManagerProfileMetricNamespace = "Microsoft.Network/trafficManagerProfiles"
+	// alertRuleCriterionName is the name of the only criterion of the alert rule.
+	alertRuleCriterionName = "NoOnlineEndpoints"
+	// alertRuleEvaluationFrequency and alertRuleWindowSize are in the ISO 8601 duration format.
+	alertRuleEvaluationFrequency = "PT1M"
+	alertRuleWindowSize          = "PT5M"
+)
+
+// GenerateAzureAlertRuleName generates the name of the Azure Monitor metric alert rule of the profile.
+func GenerateAzureAlertRuleName(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+	return fmt.Sprintf(AzureResourceAlertRuleNameFormat, generateAzureTrafficManagerProfileNameFunc(profile))
+}
+
+// generateAzureAlertRule returns the Azure Monitor metric alert rule which fires when none of the endpoints of the
+// Azure Traffic Manager profile is online.
+func generateAzureAlertRule(profile *fleetnetv1beta1.TrafficManagerProfile, atmProfileID string) armmonitor.MetricAlertResource {
+	config := profile.Spec.AlertRuleConfig
+	namespacedName := types.NamespacedName{Name: profile.Name, Namespace: profile.Namespace}
+	return armmonitor.MetricAlertResource{
+		Location: ptr.To("global"),
+		Properties: &armmonitor.MetricAlertProperties{
+			Description: ptr.To(fmt.Sprintf("None of the endpoints of trafficManagerProfile %s is online", namespacedName)),
+			Enabled:     ptr.To(true),
+			Severity:    ptr.To(config.SeverityLevel),
+			Scopes:      []*string{ptr.To(atmProfileID)},
+			Criteria: &armmonitor.MetricAlertSingleResourceMultipleMetricCriteria{
+				ODataType: ptr.To(armmonitor.OdatatypeMicrosoftAzureMonitorSingleResourceMultipleMetricCriteria),
+				AllOf: []*armmonitor.MetricCriteria{
+					{
+						Name:            ptr.To(alertRuleCriterionName),
+						CriterionType:   ptr.To(armmonitor.CriterionTypeStaticThresholdCriterion),
+						MetricName:      ptr.To(endpointStateMetricName),
+						MetricNamespace: ptr.To(trafficManagerProfileMetricNamespace),
+						Operator:        ptr.To(armmonitor.OperatorLessThan),
+						Threshold:       ptr.To(float64(1)),
+						TimeAggregation: ptr.To(armmonitor.AggregationTypeEnumMaximum),
+					},
+				},
+			},
+			Actions: []*armmonitor.MetricAlertAction{
+				{ActionGroupID: ptr.To(config.ActionGroupResourceID)},
+			},
+			AutoMitigate:        ptr.To(true),
+			EvaluationFrequency: ptr.To(alertRuleEvaluationFrequency),
+			WindowSize:          ptr.To(alertRuleWindowSize),
+		},
+		Tags: map[string]*string{
+			objectmeta.AzureTrafficManagerProfileTagKey: ptr.To(namespacedName.String()),
+		},
+	}
+}
+
+// equalAzureAlertRule compares only the fields of the current and desired alert rules set by the controller.
+// The desired alert rule is built by the controller and all the fields it sets should not be nil.
+func equalAzureAlertRule(current, desired armmonitor.MetricAlertResource) bool {
+	if current.Properties == nil {
+		return false
+	}
+	cur, des := current.Properties, desired.Properties
+	if !ptr.Equal(cur.Enabled, des.Enabled) || !ptr.Equal(cur.Severity, des.Severity) || !ptr.Equal(cur.AutoMitigate, des.AutoMitigate) ||
+		!ptr.Equal(cur.EvaluationFrequency, des.EvaluationFrequency) || !ptr.Equal(cur.WindowSize, des.WindowSize) {
+		return false
+	}
+	// resource IDs are case-insensitive
+	if len(cur.Scopes) != 1 || cur.Scopes[0] == nil || !strings.EqualFold(*cur.Scopes[0], *des.Scopes[0]) {
+		return false
+	}
+	if len(cur.Actions) != 1 || cur.Actions[0] == nil || cur.Actions[0].ActionGroupID == nil ||
+		!strings.EqualFold(*cur.Actions[0].ActionGroupID, *des.Actions[0].ActionGroupID) {
+		return false
+	}
+
+	curCriteria, ok := cur.Criteria.(*armmonitor.MetricAlertSingleResourceMultipleMetricCriteria)
+	if !ok || len(curCriteria.AllOf) != 1 || curCriteria.AllOf[0] == nil {
+		return false
+	}
+	curCriterion := curCriteria.AllOf[0]
+	desCriterion := des.Criteria.(*armmonitor.MetricAlertSingleResourceMultipleMetricCriteria).AllOf[0]
+	return ptr.Equal(curCriterion.MetricName, desCriterion.MetricName) &&
+		ptr.Equal(curCriterion.Operator, desCriterion.Operator) &&
+		ptr.Equal(curCriterion.Threshold, desCriterion.Threshold) &&
+		ptr.Equal(curCriterion.TimeAggregation, desCriterion.TimeAggregation) &&
+		len(curCriterion.Dimensions) == 0
+}
+
+// configureAlertRule creates or updates the Azure Monitor metric alert rule of the Azure Traffic Manager profile when
+// the profile asks for it, and deletes it otherwise.
+func (r *Reconciler) configureAlertRule(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, atmProfile *armtrafficmanager.Profile) error {
+	if r.MetricAlertsClient == nil {
+		return nil
+	}
+	if profile.Spec.AlertRuleConfig == nil {
+		return r.deleteAlertRule(ctx, profile)
+	}
+	profileKObj := klog.KObj(profile)
+	alertRuleName := GenerateAzureAlertRuleName(profile)
+	if atmProfile.ID == nil {
+		err := fmt.Errorf("got nil ID for Azure Traffic Manager profile")
+		klog.ErrorS(controller.NewUnexpectedBehaviorError(err), "Unexpected value returned by the Azure Traffic Manager", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfile.Name)
+		return err
+	}
+
+	desired := generateAzureAlertRule(profile, *atmProfile.ID)
+	getRes, err := r.MetricAlertsClient.Get(ctx, r.ResourceGroupName, alertRuleName, nil)
+	switch {
+	case err != nil && !azureerrors.IsNotFound(err):
+		klog.ErrorS(err, "Failed to get the alert rule", "trafficManagerProfile", profileKObj, "alertRuleName", alertRuleName)
+		return err
+	case err == nil && equalAzureAlertRule(getRes.MetricAlertResource, desired):
+		klog.V(2).InfoS("No alert rule update needed", "trafficManagerProfile", profileKObj, "alertRuleName", alertRuleName)
+		profile.Status.AlertRuleResourceID = ptr.Deref(getRes.ID, "")
+		return nil
+	}
+	res, err := r.MetricAlertsClient.CreateOrUpdate(ctx, r.ResourceGroupName, alertRuleName, desired, nil)
+	if err != nil {
+		klog.ErrorS(err, "Failed to create or update the alert rule", "trafficManagerProfile", profileKObj, "alertRuleName", alertRuleName)
+		return err
+	}
+	klog.V(2).InfoS("Created or updated the alert rule", "trafficManagerProfile", profileKObj, "alertRuleName", alertRuleName)
+	profile.Status.AlertRuleResourceID = ptr.Deref(res.ID, "")
+	return nil
+}
+
+// deleteAlertRule deletes the Azure Monitor metric alert rule of the Azure Traffic Manager profile, if the status of
+// the profile reports one; the caller persists the status.
+// The alert rules are only deleted when they are known to exist, so that the controller does not call Azure Monitor
+// for the profiles which never asked for an alert rule.
+func (r *Reconciler) deleteAlertRule(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile) error {
+	if r.MetricAlertsClient == nil || profile.Status.AlertRuleResourceID == "" {
+		return nil
+	}
+	alertRuleName := GenerateAzureAlertRuleName(profile)
+	if _, err := r.MetricAlertsClient.Delete(ctx, r.ResourceGroupName, alertRuleName, nil); err != nil && !azureerrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to delete the alert rule", "trafficManagerProfile", klog.KObj(profile), "alertRuleName", alertRuleName)
+		return err
+	}
+	klog.V(2).InfoS("Deleted the alert rule", "trafficManagerProfile", klog.KObj(profile), "alertRuleName", alertRuleName)
+	profile.Status.AlertRuleResourceID = ""
+	return nil
+}
@@ -0,0 +1,247 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/azureerrors"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+	"go.goms.io/fleet-networking/test/common/trafficmanager/fakeprovider"
+)
+
+const atmProfileID = "/subscriptions/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Network/trafficManagerProfiles/fleet-uid"
+
+func TestConfigureAlertRule(t *testing.T) {
+	metricAlertsClient, metricAlertServer, err := fakeprovider.NewMetricAlertsClient("sub1")
+	if err != nil {
+		t.Fatalf("NewMetricAlertsClient() = %v, want no error", err)
+	}
+	r := &Reconciler{
+		MetricAlertsClient: metricAlertsClient,
+		ResourceGroupName:  fakeprovider.DefaultResourceGroupName,
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fakeprovider.ValidProfileName,
+			Namespace: fakeprovider.ProfileNamespace,
+			UID:       "uid",
+		},
+	}
+	atmProfile := &armtrafficmanager.Profile{ID: ptr.To(atmProfileID)}
+	alertRuleName := GenerateAzureAlertRuleName(profile)
+	ctx := context.Background()
+	configure := func(wantCalls []string) {
+		t.Helper()
+		if err := r.configureAlertRule(ctx, profile, atmProfile); err != nil {
+			t.Fatalf("configureAlertRule() = %v, want no error", err)
+		}
+		if diff := cmp.Diff(wantCalls, metricAlertServer.Calls()); diff != "" {
+			t.Fatalf("Azure calls mismatch (-want, +got):\n%s", diff)
+		}
+	}
+	checkSeverity := func(want int32) {
+		t.Helper()
+		alertRule, ok := metricAlertServer.AlertRule(alertRuleName)
+		if !ok {
+			t.Fatalf("AlertRule(%s) not found, want the alert rule", alertRuleName)
+		}
+		if got := ptr.Deref(alertRule.Properties.Severity, -1); got != want {
+			t.Fatalf("alert rule severity = %d, want %d", got, want)
+		}
+		if wantID := fakeprovider.MetricAlertResourceID(alertRuleName); profile.Status.AlertRuleResourceID != wantID {
+			t.Fatalf("status.alertRuleResourceID = %q, want %q", profile.Status.AlertRuleResourceID, wantID)
+		}
+	}
+
+	// Azure Monitor is not called for the profiles which never asked for an alert rule.
+	configure(nil)
+
+	profile.Spec.AlertRuleConfig = &fleetnetv1beta1.AlertRuleConfig{
+		ActionGroupResourceID: fakeprovider.ValidActionGroupResourceID,
+		SeverityLevel:         1,
+	}
+	configure([]string{"Get", "CreateOrUpdate"})
+	checkSeverity(1)
+	configure([]string{"Get"})
+
+	profile.Spec.AlertRuleConfig.SeverityLevel = 0
+	configure([]string{"Get", "CreateOrUpdate"})
+	checkSeverity(0)
+
+	profile.Spec.AlertRuleConfig.ActionGroupResourceID = fakeprovider.BadRequestActionGroupResourceID
+	if err := r.configureAlertRule(ctx, profile, atmProfile); !azureerrors.IsClientError(err) {
+		t.Fatalf("configureAlertRule() = %v, want client error", err)
+	}
+	metricAlertServer.Calls()
+
+	profile.Spec.AlertRuleConfig = nil
+	configure([]string{"Delete"})
+	if _, ok := metricAlertServer.AlertRule(alertRuleName); ok {
+		t.Fatalf("AlertRule(%s) found, want it deleted", alertRuleName)
+	}
+	if profile.Status.AlertRuleResourceID != "" {
+		t.Fatalf("status.alertRuleResourceID = %q, want empty", profile.Status.AlertRuleResourceID)
+	}
+	configure(nil)
+}
+
+func TestEqualAzureAlertRule(t *testing.T) {
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "profile", Namespace: "ns"},
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			AlertRuleConfig: &fleetnetv1beta1.AlertRuleConfig{
+				ActionGroupResourceID: fakeprovider.ValidActionGroupResourceID,
+				SeverityLevel:         2,
+			},
+		},
+	}
+	tests := []struct {
+		name   string
+		mutate func(current *armmonitor.MetricAlertResource)
+		want   bool
+	}{
+		{
+			name:   "same alert rule",
+			mutate: func(_ *armmonitor.MetricAlertResource) {},
+			want:   true,
+		},
+		{
+			name: "resource IDs in another case and extra tags",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Scopes = []*string{ptr.To("/SUBSCRIPTIONS/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Network/trafficManagerProfiles/fleet-uid")}
+				current.Properties.Actions[0].ActionGroupID = ptr.To("/SUBSCRIPTIONS/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Insights/actionGroups/oncall")
+				current.Tags["team"] = ptr.To("networking")
+			},
+			want: true,
+		},
+		{
+			name: "nil properties",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties = nil
+			},
+		},
+		{
+			name: "severity changed",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Severity = ptr.To[int32](4)
+			},
+		},
+		{
+			name: "disabled out of band",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Enabled = ptr.To(false)
+			},
+		},
+		{
+			name: "action group added",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Actions = append(current.Properties.Actions, &armmonitor.MetricAlertAction{ActionGroupID: ptr.To("other")})
+			},
+		},
+		{
+			name: "threshold changed",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Criteria.(*armmonitor.MetricAlertSingleResourceMultipleMetricCriteria).AllOf[0].Threshold = ptr.To(float64(2))
+			},
+		},
+		{
+			name: "dimension added",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Criteria.(*armmonitor.MetricAlertSingleResourceMultipleMetricCriteria).AllOf[0].Dimensions = []*armmonitor.MetricDimension{
+					{Name: ptr.To("EndpointName"), Operator: ptr.To("Include"), Values: []*string{ptr.To("*")}},
+				}
+			},
+		},
+		{
+			name: "criteria of another type",
+			mutate: func(current *armmonitor.MetricAlertResource) {
+				current.Properties.Criteria = &armmonitor.MetricAlertMultipleResourceMultipleMetricCriteria{}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := generateAzureAlertRule(profile, atmProfileID)
+			tt.mutate(&current)
+			if got := equalAzureAlertRule(current, generateAzureAlertRule(profile, atmProfileID)); got != tt.want {
+				t.Errorf("equalAzureAlertRule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReconcile_DeleteAlertRule tests that the alert rule is deleted with the profile.
+func TestReconcile_DeleteAlertRule(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fakeprovider.ValidProfileName,
+			Namespace:         fakeprovider.ProfileNamespace,
+			Finalizers:        []string{objectmeta.TrafficManagerProfileFinalizer},
+			DeletionTimestamp: ptr.To(metav1.Now()),
+		},
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			AlertRuleConfig: &fleetnetv1beta1.AlertRuleConfig{
+				ActionGroupResourceID: fakeprovider.ValidActionGroupResourceID,
+				SeverityLevel:         1,
+			},
+		},
+	}
+	profilesClient, err := fakeprovider.NewProfileClient("sub1")
+	if err != nil {
+		t.Fatalf("NewProfileClient() = %v, want no error", err)
+	}
+	metricAlertsClient, metricAlertServer, err := fakeprovider.NewMetricAlertsClient("sub1")
+	if err != nil {
+		t.Fatalf("NewMetricAlertsClient() = %v, want no error", err)
+	}
+	r := &Reconciler{
+		ProfilesClient:     profilesClient,
+		MetricAlertsClient: metricAlertsClient,
+		ResourceGroupName:  fakeprovider.DefaultResourceGroupName,
+	}
+	ctx := context.Background()
+	if err := r.configureAlertRule(ctx, profile, &armtrafficmanager.Profile{ID: ptr.To(atmProfileID)}); err != nil {
+		t.Fatalf("configureAlertRule() = %v, want no error", err)
+	}
+	r.Client = fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(profile).
+		WithStatusSubresource(profile).
+		Build()
+
+	name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if _, ok := metricAlertServer.AlertRule(GenerateAzureAlertRuleName(profile)); ok {
+		t.Fatalf("AlertRule() found, want it deleted with the profile")
+	}
+}
@@ -10,25 +10,39 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"go.goms.io/fleet/pkg/utils/controller"
 
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
 	"go.goms.io/fleet-networking/pkg/common/azureerrors"
+	"go.goms.io/fleet-networking/pkg/common/azuretags"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/condition"
 	"go.goms.io/fleet-networking/pkg/common/defaulter"
+	"go.goms.io/fleet-networking/pkg/common/eventdedup"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
@@ -38,10 +52,35 @@ const (
 	// AzureResourceProfileNameFormat is the name format of the Azure Traffic Manager Profile created by the fleet controller.
 	AzureResourceProfileNameFormat = "fleet-%s"
 
+	// publicIPAddressResourceType is the Azure resource type of the public IP addresses.
+	publicIPAddressResourceType = "Microsoft.Network/publicIPAddresses"
+
 	// DefaultDNSTTL is in seconds. This informs the local DNS resolvers and DNS clients how long to cache DNS responses
 	// provided by this Traffic Manager profile.
 	// Defaults to 60 which is the same as the portal's default config.
 	DefaultDNSTTL = int64(60)
+
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "trafficmanagerprofile-controller"
+
+	// invalidAzureTagEventReason is the reason of the event emitted when an azure-tag annotation exceeds the Azure
+	// limits and is left out of the tags of the Azure Traffic Manager profile.
+	invalidAzureTagEventReason = "InvalidAzureTag"
+
+	// driftedEventReason is the reason of the event emitted when the Azure Traffic Manager profile has been changed
+	// out of band, e.g. in the Azure portal, and is corrected by the controller.
+	driftedEventReason = "Drifted"
+
+	// weightsEqualizedEventReason is the reason of the event emitted when the weights of the endpoints of the Azure
+	// Traffic Manager profile are equalized on request.
+	weightsEqualizedEventReason = "WeightsEqualized"
+
+	// equalizedWeight is the weight every endpoint gets when the weights are equalized.
+	equalizedWeight = int64(1)
+
+	// ddosProtectionPollInterval is the interval at which the profile is requeued while the DDoS protection of the
+	// public IP addresses behind its endpoints is being configured.
+	ddosProtectionPollInterval = 30 * time.Second
 )
 
 var (
@@ -59,9 +98,35 @@ func GenerateAzureTrafficManagerProfileName(profile *fleetnetv1beta1.TrafficMana
 // Reconciler reconciles a TrafficManagerProfile object.
 type Reconciler struct {
 	client.Client
+	Recorder record.EventRecorder
 
 	ProfilesClient    *armtrafficmanager.ProfilesClient
 	ResourceGroupName string // default resource group name to create azure traffic manager profiles
+
+	// CircuitBreaker stops the controller from calling Azure for a profile after repeated server errors.
+	// It is optional; when not set, the controller calls Azure on every reconciliation.
+	CircuitBreaker *circuitbreaker.CircuitBreaker
+
+	// PublicIPAddressesClient configures the Azure DDoS Protection on the public IP addresses behind the profile
+	// endpoints. It is optional; when not set, the DDoS protection settings of the profile are ignored.
+	PublicIPAddressesClient *armnetwork.PublicIPAddressesClient
+
+	// EndpointsClient equalizes the weights of the endpoints of the Azure Traffic Manager profile on request. It is
+	// optional; when not set, the equalize-weights annotation is ignored.
+	EndpointsClient *armtrafficmanager.EndpointsClient
+
+	// MetricAlertsClient manages the Azure Monitor metric alert rules of the Azure Traffic Manager profiles. It is
+	// optional; when not set, the alert rule settings of the profile are ignored.
+	MetricAlertsClient *armmonitor.MetricAlertsClient
+
+	// DriftDetectionInterval is the interval at which the controller compares the Azure Traffic Manager profile with
+	// the desired one and corrects the changes made out of band. It is optional; when not set, the profile is only
+	// compared when the TrafficManagerProfile changes.
+	DriftDetectionInterval time.Duration
+
+	// events records the events which report invalid azure-tag annotations only when they change.
+	events         *eventdedup.Recorder
+	initEventsOnce sync.Once
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=trafficmanagerprofiles,verbs=get;list;watch;create;update;patch;delete
@@ -85,6 +150,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	if err := r.Client.Get(ctx, name, profile); err != nil {
 		if apierrors.IsNotFound(err) {
 			klog.V(4).InfoS("Ignoring NotFound trafficManagerProfile", "trafficManagerProfile", profileKRef)
+			r.eventRecorder().Forget(name)
 			return ctrl.Result{}, nil
 		}
 		klog.ErrorS(err, "Failed to get trafficManagerProfile", "trafficManagerProfile", profileKRef)
@@ -107,7 +173,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	// TODO: replace the following with defaulter wehbook
 	defaulter.SetDefaultsTrafficManagerProfile(profile)
-	return r.handleUpdate(ctx, profile)
+	res, err := r.handleUpdate(ctx, profile)
+	if err == nil && res.IsZero() && r.EndpointsClient != nil && isProgrammed(profile) &&
+		profile.Annotations[objectmeta.TrafficManagerProfileAnnotationEqualizeWeights] == "true" {
+		if err := r.equalizeWeights(ctx, profile); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if err == nil && res.IsZero() && r.DriftDetectionInterval > 0 {
+		// Check the Azure Traffic Manager profile for drift periodically.
+		res.RequeueAfter = r.DriftDetectionInterval
+	}
+	return res, err
 }
 
 func (r *Reconciler) handleDelete(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile) (ctrl.Result, error) {
@@ -118,6 +195,9 @@ func (r *Reconciler) handleDelete(ctx context.Context, profile *fleetnetv1beta1.
 		return ctrl.Result{}, nil
 	}
 
+	if err := r.deleteAlertRule(ctx, profile); err != nil {
+		return ctrl.Result{}, err
+	}
 	atmProfileName := generateAzureTrafficManagerProfileNameFunc(profile)
 	klog.V(2).InfoS("Deleting Azure Traffic Manager profile", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
 	if _, err := r.ProfilesClient.Delete(ctx, r.ResourceGroupName, atmProfileName, nil); err != nil {
@@ -134,6 +214,10 @@ func (r *Reconciler) handleDelete(ctx context.Context, profile *fleetnetv1beta1.
 		return ctrl.Result{}, controller.NewUpdateIgnoreConflictError(err)
 	}
 	klog.V(2).InfoS("Removed trafficManagerProfile finalizer", "trafficManagerProfile", profileKObj)
+	r.eventRecorder().Forget(client.ObjectKeyFromObject(profile))
+	if r.CircuitBreaker != nil {
+		r.CircuitBreaker.Forget(circuitBreakerKey(profile))
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -141,23 +225,55 @@ func (r *Reconciler) handleUpdate(ctx context.Context, profile *fleetnetv1beta1.
 	profileKObj := klog.KObj(profile)
 	atmProfileName := generateAzureTrafficManagerProfileNameFunc(profile)
 	desiredATMProfile := generateAzureTrafficManagerProfile(profile)
+	r.warnInvalidAzureTags(profile)
+	cbKey := circuitBreakerKey(profile)
+	if r.CircuitBreaker != nil {
+		if allowed, retryAfter := r.CircuitBreaker.Allow(cbKey); !allowed {
+			klog.V(2).InfoS("Circuit breaker is open, skipping calling Azure", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName, "retryAfter", retryAfter)
+			return r.updateProfileStatusWithCircuitOpen(ctx, profile, retryAfter)
+		}
+	}
+
 	var responseError *azcore.ResponseError
 	getRes, getErr := r.ProfilesClient.Get(ctx, r.ResourceGroupName, atmProfileName, nil)
 	if getErr != nil {
 		if !azureerrors.IsNotFound(getErr) {
 			klog.ErrorS(getErr, "Failed to get the profile", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+			if r.recordAzureServerError(cbKey, getErr) {
+				return r.updateProfileStatusWithCircuitOpen(ctx, profile, r.CircuitBreaker.CoolDown())
+			}
 			return ctrl.Result{}, getErr
 		}
 		klog.V(2).InfoS("Azure Traffic Manager profile does not exist", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
 	} else {
-		if EqualAzureTrafficManagerProfile(getRes.Profile, desiredATMProfile) {
+		var removedTags bool
+		desiredATMProfile.Tags, removedTags = mergeAzureTags(getRes.Profile.Tags, desiredATMProfile.Tags, profile.Status.ManagedAzureTags)
+		if !removedTags && EqualAzureTrafficManagerProfile(getRes.Profile, desiredATMProfile) {
 			// skip creating or updating the profile
 			klog.V(2).InfoS("No profile update needed", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
-			return r.updateProfileStatus(ctx, profile, getRes.Profile, nil)
+			r.recordAzureServerError(cbKey, nil)
+			return r.updateProfileStatusWithDependentResources(ctx, profile, getRes.Profile, nil)
+		} else if isProgrammed(profile) {
+			// The current generation has been programmed, so the profile has been changed out of band.
+			klog.V(2).InfoS("Azure Traffic Manager profile has drifted from the desired state", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+			r.Recorder.Eventf(profile, corev1.EventTypeWarning, driftedEventReason, "Azure Traffic Manager profile %s has been changed out of band; reapplying the desired state", atmProfileName)
 		}
 	}
 
 	res, updateErr := r.ProfilesClient.CreateOrUpdate(ctx, r.ResourceGroupName, atmProfileName, desiredATMProfile, nil)
+	if getErr == nil && azureerrors.IsBadRequest(updateErr) && requiresRecreation(getRes.Profile, desiredATMProfile) {
+		// The traffic routing method is updated in place, so that the DNS name keeps resolving; the profile is only
+		// recreated if Azure rejects the update, e.g. as the endpoints cannot be kept with the new routing method.
+		klog.ErrorS(updateErr, "Azure rejected the update of the traffic routing method in place", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+		if recreateRes, err := r.deleteProfileForRecreation(ctx, profile, getRes.Profile, &desiredATMProfile); err != nil || recreateRes.RequeueAfter > 0 {
+			return recreateRes, err
+		}
+		res, updateErr = r.ProfilesClient.CreateOrUpdate(ctx, r.ResourceGroupName, atmProfileName, desiredATMProfile, nil)
+	}
+	if r.recordAzureServerError(cbKey, updateErr) {
+		klog.ErrorS(updateErr, "Failed to create or update a profile and opened the circuit breaker", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+		return r.updateProfileStatusWithCircuitOpen(ctx, profile, r.CircuitBreaker.CoolDown())
+	}
 	if updateErr != nil {
 		if !errors.As(updateErr, &responseError) {
 			klog.ErrorS(updateErr, "Failed to send the createOrUpdate request", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
@@ -168,7 +284,157 @@ func (r *Reconciler) handleUpdate(ctx context.Context, profile *fleetnetv1beta1.
 			"errorCode", responseError.ErrorCode, "statusCode", responseError.StatusCode)
 	}
 	klog.V(2).InfoS("Created or updated Azure Traffic Manager Profile", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
-	return r.updateProfileStatus(ctx, profile, res.Profile, updateErr)
+	return r.updateProfileStatusWithDependentResources(ctx, profile, res.Profile, updateErr)
+}
+
+// updateProfileStatusWithDependentResources configures the Azure resources which depend on the Azure Traffic Manager
+// profile, unless the profile has failed to be configured, and updates the profile status; the profile is requeued
+// while the dependent resources are being configured.
+func (r *Reconciler) updateProfileStatusWithDependentResources(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, atmProfile armtrafficmanager.Profile, updateErr error) (ctrl.Result, error) {
+	var inProgress bool
+	if updateErr == nil {
+		inProgress, updateErr = r.configureDependentResources(ctx, profile, &atmProfile)
+	}
+	res, err := r.updateProfileStatus(ctx, profile, atmProfile, updateErr)
+	if err == nil && inProgress {
+		res.RequeueAfter = ddosProtectionPollInterval
+	}
+	return res, err
+}
+
+// equalizeWeights sets the weights of all the endpoints of the Azure Traffic Manager profile to the same value, and
+// removes the equalize-weights annotation from the profile afterwards.
+//
+// Note that the TrafficManagerBackends set the weights they are configured with again the next time they are
+// reconciled; equalizing the weights helps to recover from a misconfiguration made out of band, or to spread the
+// traffic evenly until the weights of the backends and the exported services are fixed.
+func (r *Reconciler) equalizeWeights(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile) error {
+	profileKObj := klog.KObj(profile)
+	atmProfileName := generateAzureTrafficManagerProfileNameFunc(profile)
+	getRes, err := r.ProfilesClient.Get(ctx, r.ResourceGroupName, atmProfileName, nil)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get the profile to equalize the endpoint weights", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+		return err
+	}
+	var equalized int
+	if getRes.Profile.Properties != nil {
+		for _, endpoint := range getRes.Profile.Properties.Endpoints {
+			if endpoint == nil || endpoint.Name == nil || endpoint.Properties == nil || !isAzureEndpoint(endpoint) {
+				continue
+			}
+			if endpoint.Properties.Weight != nil && *endpoint.Properties.Weight == equalizedWeight {
+				continue
+			}
+			endpoint.Properties.Weight = ptr.To(equalizedWeight)
+			if _, err := r.EndpointsClient.CreateOrUpdate(ctx, r.ResourceGroupName, atmProfileName, armtrafficmanager.EndpointTypeAzureEndpoints, *endpoint.Name, *endpoint, nil); err != nil {
+				klog.ErrorS(err, "Failed to equalize the endpoint weight", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName, "atmEndpoint", *endpoint.Name)
+				return err
+			}
+			equalized++
+		}
+	}
+	klog.V(2).InfoS("Equalized the endpoint weights", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName, "numberOfUpdatedEndpoints", equalized)
+
+	// Patch the annotations only, as the spec of the profile has been defaulted in memory.
+	base := profile.DeepCopy()
+	delete(profile.Annotations, objectmeta.TrafficManagerProfileAnnotationEqualizeWeights)
+	if err := r.Client.Patch(ctx, profile, client.MergeFrom(base)); err != nil {
+		klog.ErrorS(err, "Failed to remove the equalize-weights annotation", "trafficManagerProfile", profileKObj)
+		return controller.NewUpdateIgnoreConflictError(err)
+	}
+	r.Recorder.Eventf(profile, corev1.EventTypeNormal, weightsEqualizedEventReason, "Set the weights of %d endpoints of Azure Traffic Manager profile %s to %d", equalized, atmProfileName, equalizedWeight)
+	return nil
+}
+
+// isAzureEndpoint returns true if the endpoint is an Azure endpoint, i.e. one created by the TrafficManagerBackends.
+func isAzureEndpoint(endpoint *armtrafficmanager.Endpoint) bool {
+	return endpoint.Type != nil && strings.HasSuffix(strings.ToLower(*endpoint.Type), strings.ToLower(string(armtrafficmanager.EndpointTypeAzureEndpoints)))
+}
+
+// warnInvalidAzureTags emits a warning event listing the azure-tag annotations of the profile which exceed the Azure
+// limits and are therefore left out of the tags of the Azure Traffic Manager profile; the event is only emitted when
+// the list changes.
+func (r *Reconciler) warnInvalidAzureTags(profile *fleetnetv1beta1.TrafficManagerProfile) {
+	_, invalid := azuretags.FromAnnotations(profile.Annotations)
+	if len(invalid) == 0 {
+		r.eventRecorder().Clear(profile, invalidAzureTagEventReason)
+		return
+	}
+	klog.V(2).InfoS("Ignoring invalid Azure tags", "trafficManagerProfile", klog.KObj(profile), "error", errors.Join(invalid...))
+	msgs := make([]string, 0, len(invalid))
+	for _, err := range invalid {
+		msgs = append(msgs, err.Error())
+	}
+	r.eventRecorder().Eventf(profile, corev1.EventTypeWarning, invalidAzureTagEventReason, "Ignoring Azure tags: %s", strings.Join(msgs, "; "))
+}
+
+// eventRecorder returns the recorder of the events which are only emitted when they change.
+func (r *Reconciler) eventRecorder() *eventdedup.Recorder {
+	r.initEventsOnce.Do(func() {
+		if r.events == nil {
+			r.events = eventdedup.New(r.Recorder)
+		}
+	})
+	return r.events
+}
+
+// isProgrammed returns true if the current generation of the profile has been programmed on Azure.
+// Note that the azure-tag annotations do not change the generation, so that an update of the tags on the profile is
+// also reported as a drift.
+func isProgrammed(profile *fleetnetv1beta1.TrafficManagerProfile) bool {
+	cond := meta.FindStatusCondition(profile.Status.Conditions, string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed))
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == profile.Generation
+}
+
+// requiresRecreation returns true if the traffic routing method of the current Azure Traffic Manager profile differs
+// from the desired one; if Azure rejects updating the routing method in place, the profile has to be deleted and
+// created again.
+func requiresRecreation(current, desired armtrafficmanager.Profile) bool {
+	if current.Properties == nil || current.Properties.TrafficRoutingMethod == nil {
+		return false
+	}
+	return *current.Properties.TrafficRoutingMethod != *desired.Properties.TrafficRoutingMethod
+}
+
+// deleteProfileForRecreation marks the profile as pending and deletes the current Azure Traffic Manager profile, so
+// that it can be created again with the desired traffic routing method.
+// The DNS relative name of the current profile is kept in the desired profile so that the DNS name of the profile
+// does not change after the recreation.
+func (r *Reconciler) deleteProfileForRecreation(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, current armtrafficmanager.Profile, desired *armtrafficmanager.Profile) (ctrl.Result, error) {
+	profileKObj := klog.KObj(profile)
+	atmProfileName := generateAzureTrafficManagerProfileNameFunc(profile)
+	currentMethod, desiredMethod := *current.Properties.TrafficRoutingMethod, *desired.Properties.TrafficRoutingMethod
+	klog.V(2).InfoS("Recreating Azure Traffic Manager profile as the traffic routing method changes", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName, "currentRoutingMethod", currentMethod, "desiredRoutingMethod", desiredMethod)
+
+	profile.Status.DNSName = nil // reset the DNS name
+	setEndpointHealth(&profile.Status, endpointHealth{})
+	meta.SetStatusCondition(&profile.Status.Conditions, metav1.Condition{
+		Type:               string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed),
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: profile.Generation,
+		Reason:             string(fleetnetv1beta1.TrafficManagerProfileReasonPending),
+		Message:            fmt.Sprintf("Recreating the Azure Traffic Manager profile as its traffic routing method changes from %s to %s", currentMethod, desiredMethod),
+	})
+	if err := r.Client.Status().Update(ctx, profile); err != nil {
+		klog.ErrorS(err, "Failed to update trafficManagerProfile status", "trafficManagerProfile", profileKObj)
+		return ctrl.Result{}, controller.NewUpdateIgnoreConflictError(err)
+	}
+
+	cbKey := circuitBreakerKey(profile)
+	if _, err := r.ProfilesClient.Delete(ctx, r.ResourceGroupName, atmProfileName, nil); err != nil && !azureerrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to delete Azure Traffic Manager profile for recreation", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+		if r.recordAzureServerError(cbKey, err) {
+			return r.updateProfileStatusWithCircuitOpen(ctx, profile, r.CircuitBreaker.CoolDown())
+		}
+		return ctrl.Result{}, err
+	}
+	r.recordAzureServerError(cbKey, nil)
+	klog.V(2).InfoS("Deleted Azure Traffic Manager profile for recreation", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfileName)
+
+	if current.Properties.DNSConfig != nil && current.Properties.DNSConfig.RelativeName != nil {
+		desired.Properties.DNSConfig.RelativeName = current.Properties.DNSConfig.RelativeName
+	}
+	return ctrl.Result{}, nil
 }
 
 // EqualAzureTrafficManagerProfile compares only few fields of the current and desired Azure Traffic Manager profiles
@@ -180,14 +446,13 @@ func EqualAzureTrafficManagerProfile(current, desired armtrafficmanager.Profile)
 		return false
 	}
 
-	if current.Properties.MonitorConfig.IntervalInSeconds == nil || current.Properties.MonitorConfig.Path == nil ||
+	if current.Properties.MonitorConfig.IntervalInSeconds == nil ||
 		current.Properties.MonitorConfig.Port == nil || current.Properties.MonitorConfig.Protocol == nil ||
 		current.Properties.MonitorConfig.TimeoutInSeconds == nil || current.Properties.MonitorConfig.ToleratedNumberOfFailures == nil {
 		return false
 	}
 
 	if *current.Properties.MonitorConfig.IntervalInSeconds != *desired.Properties.MonitorConfig.IntervalInSeconds ||
-		*current.Properties.MonitorConfig.Path != *desired.Properties.MonitorConfig.Path ||
 		*current.Properties.MonitorConfig.Port != *desired.Properties.MonitorConfig.Port ||
 		*current.Properties.MonitorConfig.Protocol != *desired.Properties.MonitorConfig.Protocol ||
 		*current.Properties.MonitorConfig.TimeoutInSeconds != *desired.Properties.MonitorConfig.TimeoutInSeconds ||
@@ -195,6 +460,11 @@ func EqualAzureTrafficManagerProfile(current, desired armtrafficmanager.Profile)
 		return false
 	}
 
+	// The path is not set for TCP probes, which do not use it; the path Azure reports for them is ignored.
+	if desired.Properties.MonitorConfig.Path != nil && !ptr.Equal(current.Properties.MonitorConfig.Path, desired.Properties.MonitorConfig.Path) {
+		return false
+	}
+
 	if *current.Properties.ProfileStatus != *desired.Properties.ProfileStatus || *current.Properties.TrafficRoutingMethod != *desired.Properties.TrafficRoutingMethod {
 		return false
 	}
@@ -227,8 +497,11 @@ func (r *Reconciler) updateProfileStatus(ctx context.Context, profile *fleetnetv
 			klog.ErrorS(controller.NewUnexpectedBehaviorError(err), "Unexpected value returned by the Azure Traffic Manager", "trafficManagerProfile", profileKObj, "atmProfileName", atmProfile.Name)
 			profile.Status.DNSName = nil // reset the DNS name
 		}
+		setEndpointHealth(&profile.Status, summarizeEndpointHealth(atmProfile))
+		profile.Status.ManagedAzureTags = managedAzureTags(profile)
 	} else {
 		profile.Status.DNSName = nil // reset the DNS name
+		setEndpointHealth(&profile.Status, endpointHealth{})
 	}
 
 	cond := metav1.Condition{
@@ -272,6 +545,190 @@ func (r *Reconciler) updateProfileStatus(ctx context.Context, profile *fleetnetv
 	return ctrl.Result{}, updateErr
 }
 
+// endpointHealth summarizes the monitor status of the endpoints of an Azure Traffic Manager profile.
+type endpointHealth struct {
+	online, degraded, checking, total int32
+}
+
+// summarizeEndpointHealth counts the endpoints of the Azure Traffic Manager profile by their monitor status; an
+// endpoint is healthy when its monitor status is online.
+// Endpoints which are disabled, stopped or inactive only count towards the total.
+func summarizeEndpointHealth(atmProfile armtrafficmanager.Profile) endpointHealth {
+	var health endpointHealth
+	if atmProfile.Properties == nil {
+		return health
+	}
+	for _, endpoint := range atmProfile.Properties.Endpoints {
+		if endpoint == nil {
+			continue
+		}
+		health.total++
+		if endpoint.Properties == nil {
+			continue
+		}
+		switch ptr.Deref(endpoint.Properties.EndpointMonitorStatus, "") {
+		case armtrafficmanager.EndpointMonitorStatusOnline:
+			health.online++
+		case armtrafficmanager.EndpointMonitorStatusDegraded:
+			health.degraded++
+		case armtrafficmanager.EndpointMonitorStatusCheckingEndpoint:
+			health.checking++
+		}
+	}
+	return health
+}
+
+// setEndpointHealth reports the summary of the endpoint health in the status of the profile.
+func setEndpointHealth(status *fleetnetv1beta1.TrafficManagerProfileStatus, health endpointHealth) {
+	status.HealthyEndpoints = health.online
+	status.DegradedEndpoints = health.degraded
+	status.CheckingEndpoints = health.checking
+	status.TotalEndpoints = health.total
+}
+
+// configureDependentResources configures the Azure resources which depend on the Azure Traffic Manager profile: the
+// DDoS protection of the public IP addresses behind its endpoints, and its alert rule. It returns true if the DDoS
+// protection is still being configured.
+func (r *Reconciler) configureDependentResources(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, atmProfile *armtrafficmanager.Profile) (bool, error) {
+	inProgress, err := r.configureDDoSProtection(ctx, profile, atmProfile)
+	if err != nil {
+		return false, err
+	}
+	return inProgress, r.configureAlertRule(ctx, profile, atmProfile)
+}
+
+// configureDDoSProtection enables the Azure DDoS Protection on the public IP addresses behind the endpoints of the
+// Azure Traffic Manager profile when the profile asks for it.
+// Disabling the DDoS protection on the profile does not change the public IP addresses, as they may be protected
+// for other reasons.
+//
+// The update of a public IP address is a long-running operation; the controller starts it without waiting for it to
+// complete, and returns true so that the profile is requeued and the public IP address is checked again later. A
+// public IP address which is still being updated is not updated again.
+func (r *Reconciler) configureDDoSProtection(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, atmProfile *armtrafficmanager.Profile) (bool, error) {
+	if r.PublicIPAddressesClient == nil || !ptr.Deref(profile.Spec.DDoSProtectionEnabled, false) {
+		return false, nil
+	}
+	if atmProfile.Properties == nil {
+		return false, nil
+	}
+	profileKObj := klog.KObj(profile)
+	desired := generateDDoSSettings(profile)
+	var inProgress bool
+	for _, endpoint := range atmProfile.Properties.Endpoints {
+		if endpoint == nil || endpoint.Properties == nil || endpoint.Properties.TargetResourceID == nil {
+			continue
+		}
+		pipID, err := arm.ParseResourceID(*endpoint.Properties.TargetResourceID)
+		if err != nil || !strings.EqualFold(pipID.ResourceType.String(), publicIPAddressResourceType) {
+			klog.V(2).InfoS("Skipping the endpoint which does not target a public IP address", "trafficManagerProfile", profileKObj, "atmEndpoint", endpoint.Name, "targetResourceID", *endpoint.Properties.TargetResourceID)
+			continue
+		}
+
+		getRes, err := r.PublicIPAddressesClient.Get(ctx, pipID.ResourceGroupName, pipID.Name, nil)
+		if err != nil {
+			klog.ErrorS(err, "Failed to get the public IP address", "trafficManagerProfile", profileKObj, "publicIPAddress", pipID.String())
+			return false, err
+		}
+		pip := getRes.PublicIPAddress
+		if pip.Properties == nil {
+			pip.Properties = &armnetwork.PublicIPAddressPropertiesFormat{}
+		}
+		if ptr.Deref(pip.Properties.ProvisioningState, "") == armnetwork.ProvisioningStateUpdating {
+			klog.V(2).InfoS("The public IP address is being updated", "trafficManagerProfile", profileKObj, "publicIPAddress", pipID.String())
+			inProgress = true
+			continue
+		}
+		if equalDDoSSettings(pip.Properties.DdosSettings, desired) {
+			continue
+		}
+		pip.Properties.DdosSettings = desired
+		if _, err := r.PublicIPAddressesClient.BeginCreateOrUpdate(ctx, pipID.ResourceGroupName, pipID.Name, pip, nil); err != nil {
+			klog.ErrorS(err, "Failed to configure the DDoS protection on the public IP address", "trafficManagerProfile", profileKObj, "publicIPAddress", pipID.String())
+			return false, err
+		}
+		klog.V(2).InfoS("Started configuring the DDoS protection on the public IP address", "trafficManagerProfile", profileKObj, "publicIPAddress", pipID.String())
+		inProgress = true
+	}
+	return inProgress, nil
+}
+
+func generateDDoSSettings(profile *fleetnetv1beta1.TrafficManagerProfile) *armnetwork.DdosSettings {
+	settings := &armnetwork.DdosSettings{
+		ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled),
+	}
+	if profile.Spec.DDoSPlanResourceID != nil {
+		settings.DdosProtectionPlan = &armnetwork.SubResource{ID: profile.Spec.DDoSPlanResourceID}
+	}
+	return settings
+}
+
+// equalDDoSSettings compares the current DDoS settings of a public IP address with the desired ones.
+// The desired settings are built by the controller and the ProtectionMode should not be nil.
+func equalDDoSSettings(current, desired *armnetwork.DdosSettings) bool {
+	if current == nil || current.ProtectionMode == nil || *current.ProtectionMode != *desired.ProtectionMode {
+		return false
+	}
+	if desired.DdosProtectionPlan == nil {
+		return current.DdosProtectionPlan == nil || current.DdosProtectionPlan.ID == nil
+	}
+	return current.DdosProtectionPlan != nil && current.DdosProtectionPlan.ID != nil &&
+		strings.EqualFold(*current.DdosProtectionPlan.ID, *desired.DdosProtectionPlan.ID) // resource IDs are case-insensitive
+}
+
+// recordAzureServerError records the result of an Azure call in the circuit breaker, where only server errors
+// count as failures and only successful calls close the circuit; it returns true if the circuit for the profile is
+// open after the call.
+// Other errors, e.g. invalid requests or requests which never reach Azure, say nothing about the health of Azure
+// and are not recorded; a trial call failing with them lets another trial call through instead.
+func (r *Reconciler) recordAzureServerError(key string, err error) bool {
+	if r.CircuitBreaker == nil {
+		return false
+	}
+	switch {
+	case err == nil:
+		r.CircuitBreaker.RecordSuccess(key)
+	case azureerrors.IsServerError(err):
+		return r.CircuitBreaker.RecordFailure(key)
+	default:
+		r.CircuitBreaker.ReleaseTrial(key)
+	}
+	return false
+}
+
+// updateProfileStatusWithCircuitOpen marks the profile as not programmed while the circuit breaker stops the
+// controller from calling Azure, and requeues the profile when the circuit half-opens.
+func (r *Reconciler) updateProfileStatusWithCircuitOpen(ctx context.Context, profile *fleetnetv1beta1.TrafficManagerProfile, retryAfter time.Duration) (ctrl.Result, error) {
+	profileKObj := klog.KObj(profile)
+	cond := metav1.Condition{
+		Type:               string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed),
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: profile.Generation,
+		Reason:             string(fleetnetv1beta1.TrafficManagerProfileReasonPending),
+		Message:            fmt.Sprintf("Azure Traffic Manager keeps returning server errors; stopped configuring the profile for %v before retrying", r.CircuitBreaker.CoolDown()),
+	}
+	// The message does not change while the circuit is open, so that status updates do not trigger new
+	// reconciliations.
+	if profile.Status.DNSName == nil && profile.Status.TotalEndpoints == 0 &&
+		condition.EqualCondition(meta.FindStatusCondition(profile.Status.Conditions, cond.Type), &cond) {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+	profile.Status.DNSName = nil // reset the DNS name
+	setEndpointHealth(&profile.Status, endpointHealth{})
+	meta.SetStatusCondition(&profile.Status.Conditions, cond)
+	if err := r.Client.Status().Update(ctx, profile); err != nil {
+		klog.ErrorS(err, "Failed to update trafficManagerProfile status", "trafficManagerProfile", profileKObj)
+		return ctrl.Result{}, controller.NewUpdateIgnoreConflictError(err)
+	}
+	klog.V(2).InfoS("Updated the trafficProfile status", "trafficManagerProfile", profileKObj, "status", profile.Status)
+	return ctrl.Result{RequeueAfter: retryAfter}, nil
+}
+
+// circuitBreakerKey returns the key of the profile used by the circuit breaker.
+func circuitBreakerKey(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+	return types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}.String()
+}
+
 func generateAzureTrafficManagerProfile(profile *fleetnetv1beta1.TrafficManagerProfile) armtrafficmanager.Profile {
 	mc := profile.Spec.MonitorConfig
 	namespacedName := types.NamespacedName{Name: profile.Name, Namespace: profile.Namespace}
@@ -279,7 +736,7 @@ func generateAzureTrafficManagerProfile(profile *fleetnetv1beta1.TrafficManagerP
 		Location: ptr.To("global"),
 		Properties: &armtrafficmanager.ProfileProperties{
 			DNSConfig: &armtrafficmanager.DNSConfig{
-				RelativeName: ptr.To(fmt.Sprintf(DNSRelativeNameFormat, profile.Namespace, profile.Name)),
+				RelativeName: ptr.To(dnsRelativeName(profile)),
 				TTL:          ptr.To(DefaultDNSTTL), // no default value on the server side, using 60s same as portal's default config
 			},
 			MonitorConfig: &armtrafficmanager.MonitorConfig{
@@ -290,19 +747,105 @@ func generateAzureTrafficManagerProfile(profile *fleetnetv1beta1.TrafficManagerP
 				TimeoutInSeconds:          mc.TimeoutInSeconds,
 				ToleratedNumberOfFailures: mc.ToleratedNumberOfFailures,
 			},
-			ProfileStatus: ptr.To(armtrafficmanager.ProfileStatusEnabled),
-			// By default, the routing method is set to Weighted.
-			TrafficRoutingMethod: ptr.To(armtrafficmanager.TrafficRoutingMethodWeighted),
-		},
-		Tags: map[string]*string{
-			objectmeta.AzureTrafficManagerProfileTagKey: ptr.To(namespacedName.String()),
+			ProfileStatus:        ptr.To(armtrafficmanager.ProfileStatusEnabled),
+			TrafficRoutingMethod: ptr.To(trafficRoutingMethod(profile)),
 		},
+		Tags: generateAzureTags(profile, namespacedName),
+	}
+}
+
+// trafficRoutingMethod returns the traffic routing method of the Azure Traffic Manager profile; it defaults to Weighted.
+func trafficRoutingMethod(profile *fleetnetv1beta1.TrafficManagerProfile) armtrafficmanager.TrafficRoutingMethod {
+	if profile.Spec.RoutingMethod == nil {
+		return armtrafficmanager.TrafficRoutingMethodWeighted
 	}
+	return armtrafficmanager.TrafficRoutingMethod(*profile.Spec.RoutingMethod)
+}
+
+// dnsRelativeName returns the relative DNS name of the Azure Traffic Manager profile: the one specified by the profile
+// if any, or the one derived from the namespace and name of the profile.
+func dnsRelativeName(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+	if profile.Spec.DNSConfig != nil && profile.Spec.DNSConfig.RelativeName != nil {
+		return *profile.Spec.DNSConfig.RelativeName
+	}
+	return fmt.Sprintf(DNSRelativeNameFormat, profile.Namespace, profile.Name)
+}
+
+// generateAzureTags returns the tags of the Azure Traffic Manager profile: the tags defined by the azure-tag
+// annotations of the profile, and the tag marking the profile as created by the controller, which cannot be
+// overridden.
+//
+// Note that EqualAzureTrafficManagerProfile only checks that the desired tags are present, so that tags added to the
+// Azure resource by others (e.g. Azure Policy) do not trigger updates; see mergeAzureTags for how they are kept.
+func generateAzureTags(profile *fleetnetv1beta1.TrafficManagerProfile, namespacedName types.NamespacedName) map[string]*string {
+	annotatedTags, _ := azuretags.FromAnnotations(profile.Annotations)
+	tags := make(map[string]*string, len(annotatedTags)+1)
+	for key, value := range annotatedTags {
+		tags[key] = ptr.To(value)
+	}
+	tags[objectmeta.AzureTrafficManagerProfileTagKey] = ptr.To(namespacedName.String())
+	return tags
+}
+
+// mergeAzureTags returns the tags to apply to an existing Azure Traffic Manager profile: the current tags added by
+// others (e.g. Azure Policy) are kept, so that they are not dropped when the profile is replaced, the tags which have
+// been applied from azure-tag annotations which are removed since are deleted, and the desired tags are set.
+// It also returns whether any current tag is deleted, as the profile has to be updated then.
+func mergeAzureTags(current, desired map[string]*string, managed []string) (map[string]*string, bool) {
+	merged := make(map[string]*string, len(current)+len(desired))
+	for key, value := range current {
+		merged[key] = value
+	}
+	var removed bool
+	for _, key := range managed {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if _, ok := merged[key]; ok {
+			delete(merged, key)
+			removed = true
+		}
+	}
+	for key, value := range desired {
+		merged[key] = value
+	}
+	return merged, removed
+}
+
+// managedAzureTags returns the sorted keys of the tags applied to the Azure Traffic Manager profile from the
+// azure-tag annotations of the profile.
+func managedAzureTags(profile *fleetnetv1beta1.TrafficManagerProfile) []string {
+	annotatedTags, _ := azuretags.FromAnnotations(profile.Annotations)
+	keys := make([]string, 0, len(annotatedTags))
+	for key := range annotatedTags {
+		if key != objectmeta.AzureTrafficManagerProfileTagKey {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&fleetnetv1beta1.TrafficManagerProfile{}).
-		Complete(r)
+	builder := ctrl.NewControllerManagedBy(mgr).
+		For(&fleetnetv1beta1.TrafficManagerProfile{})
+	if r.PublicIPAddressesClient != nil {
+		// The endpoints are added to the Azure Traffic Manager profile by the backends; re-configure the DDoS
+		// protection when the backends change.
+		builder = builder.Watches(&fleetnetv1beta1.TrafficManagerBackend{}, handler.EnqueueRequestsFromMapFunc(
+			func(_ context.Context, o client.Object) []reconcile.Request {
+				backend, ok := o.(*fleetnetv1beta1.TrafficManagerBackend)
+				if !ok {
+					return nil
+				}
+				return []reconcile.Request{
+					{NamespacedName: types.NamespacedName{Namespace: backend.Namespace, Name: backend.Spec.Profile.Name}},
+				}
+			}))
+	}
+	return builder.Complete(r)
 }
@@ -298,12 +298,14 @@ var _ = Describe("Test TrafficManagerProfile Controller", func() {
 		name := fakeprovider.InternalServerErrProfileName
 		var profile *fleetnetv1beta1.TrafficManagerProfile
 
-		It("AzureTrafficManager should not be configured", func() {
+		It("AzureTrafficManager should not be configured and the circuit breaker should be open", func() {
 			By("By creating a new TrafficManagerProfile")
 			profile = trafficManagerProfileForTest(name)
 			Expect(k8sClient.Create(ctx, profile)).Should(Succeed())
 
 			By("By checking profile")
+			// The controller requeues the profile on every internal server error, so the circuit breaker opens
+			// after a few failed attempts.
 			want := fleetnetv1beta1.TrafficManagerProfile{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:       name,
@@ -314,7 +316,7 @@ var _ = Describe("Test TrafficManagerProfile Controller", func() {
 				Status: fleetnetv1beta1.TrafficManagerProfileStatus{
 					Conditions: []metav1.Condition{
 						{
-							Status:             metav1.ConditionUnknown,
+							Status:             metav1.ConditionFalse,
 							Type:               string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed),
 							Reason:             string(fleetnetv1beta1.TrafficManagerProfileReasonPending),
 							ObservedGeneration: profile.Generation,
@@ -325,6 +327,28 @@ var _ = Describe("Test TrafficManagerProfile Controller", func() {
 			validator.ValidateTrafficManagerProfile(ctx, k8sClient, &want)
 		})
 
+		It("The circuit breaker should stay open after the cool-down period as the retry fails", func() {
+			want := fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       name,
+					Namespace:  testNamespace,
+					Finalizers: []string{objectmeta.TrafficManagerProfileFinalizer},
+				},
+				Spec: profile.Spec,
+				Status: fleetnetv1beta1.TrafficManagerProfileStatus{
+					Conditions: []metav1.Condition{
+						{
+							Status:             metav1.ConditionFalse,
+							Type:               string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed),
+							Reason:             string(fleetnetv1beta1.TrafficManagerProfileReasonPending),
+							ObservedGeneration: profile.Generation,
+						},
+					},
+				},
+			}
+			validator.ValidateTrafficManagerProfileConsistently(ctx, k8sClient, &want)
+		})
+
 		It("Deleting trafficManagerProfile", func() {
 			err := k8sClient.Delete(ctx, profile)
 			Expect(err).Should(Succeed(), "failed to delete trafficManagerProfile")
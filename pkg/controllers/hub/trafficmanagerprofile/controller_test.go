@@ -6,13 +6,38 @@ Licensed under the MIT license.
 package trafficmanagerprofile
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azcorefake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager/fake"
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+	"go.goms.io/fleet-networking/test/common/trafficmanager/fakeprovider"
 )
 
 func TestGenerateAzureTrafficManagerProfileName(t *testing.T) {
@@ -28,6 +53,85 @@ func TestGenerateAzureTrafficManagerProfileName(t *testing.T) {
 	}
 }
 
+func TestGenerateAzureTrafficManagerProfile_DNSRelativeName(t *testing.T) {
+	tests := []struct {
+		name      string
+		dnsConfig *fleetnetv1beta1.DNSConfig
+		want      string
+	}{
+		{
+			name: "default to the namespace and name of the profile",
+			want: "namespace-name",
+		},
+		{
+			name:      "default when dnsConfig has no relative name",
+			dnsConfig: &fleetnetv1beta1.DNSConfig{},
+			want:      "namespace-name",
+		},
+		{
+			name: "override by the relative name of the profile",
+			dnsConfig: &fleetnetv1beta1.DNSConfig{
+				RelativeName: ptr.To("contoso-app"),
+			},
+			want: "contoso-app",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "namespace",
+					Name:      "name",
+				},
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+					},
+					DNSConfig: tc.dnsConfig,
+				},
+			}
+			got := generateAzureTrafficManagerProfile(profile)
+			if got.Properties.DNSConfig.RelativeName == nil || *got.Properties.DNSConfig.RelativeName != tc.want {
+				t.Errorf("generateAzureTrafficManagerProfile() relative name = %v, want %s", ptr.Deref(got.Properties.DNSConfig.RelativeName, "<nil>"), tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateAzureTrafficManagerProfile_RoutingMethod(t *testing.T) {
+	tests := []struct {
+		name          string
+		routingMethod *fleetnetv1beta1.TrafficRoutingMethod
+		want          armtrafficmanager.TrafficRoutingMethod
+	}{
+		{
+			name: "default to the weighted routing method",
+			want: armtrafficmanager.TrafficRoutingMethodWeighted,
+		},
+		{
+			name:          "subnet routing method",
+			routingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+			want:          armtrafficmanager.TrafficRoutingMethodSubnet,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+					},
+					RoutingMethod: tc.routingMethod,
+				},
+			}
+			got := generateAzureTrafficManagerProfile(profile)
+			if got.Properties.TrafficRoutingMethod == nil || *got.Properties.TrafficRoutingMethod != tc.want {
+				t.Errorf("generateAzureTrafficManagerProfile() routing method = %v, want %s", ptr.Deref(got.Properties.TrafficRoutingMethod, "<nil>"), tc.want)
+			}
+		})
+	}
+}
+
 func buildDesiredProfile() armtrafficmanager.Profile {
 	return armtrafficmanager.Profile{
 		Location: ptr.To("global"),
@@ -278,3 +382,1107 @@ func TestEqualAzureTrafficManagerProfile(t *testing.T) {
 		})
 	}
 }
+
+// TestEqualAzureTrafficManagerProfile_TCPProbe tests that the path Azure reports for TCP probes, which do not use it,
+// is ignored.
+func TestEqualAzureTrafficManagerProfile_TCPProbe(t *testing.T) {
+	desired := buildDesiredProfile()
+	desired.Properties.MonitorConfig.Protocol = ptr.To(armtrafficmanager.MonitorProtocolTCP)
+	desired.Properties.MonitorConfig.Path = nil
+	for _, path := range []*string{nil, ptr.To("/")} {
+		current := buildDesiredProfile()
+		current.Properties.MonitorConfig.Protocol = ptr.To(armtrafficmanager.MonitorProtocolTCP)
+		current.Properties.MonitorConfig.Path = path
+		if !EqualAzureTrafficManagerProfile(current, desired) {
+			t.Errorf("EqualAzureTrafficManagerProfile() = false for the current path %v, want true", ptr.Deref(path, "<nil>"))
+		}
+	}
+}
+
+// newCountingProfileClient returns a profile client talking to the fake profile server, which counts the
+// createOrUpdate calls; once recovered is set, the server responds to the createOrUpdate calls as if the profile
+// is valid.
+func newCountingProfileClient(t *testing.T, calls *int, recovered *bool) *armtrafficmanager.ProfilesClient {
+	fakeServer := fake.ProfilesServer{
+		Get: fakeprovider.ProfileGet,
+		CreateOrUpdate: func(ctx context.Context, resourceGroupName string, profileName string, parameters armtrafficmanager.Profile, options *armtrafficmanager.ProfilesClientCreateOrUpdateOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientCreateOrUpdateResponse], azcorefake.ErrorResponder) {
+			*calls++
+			if *recovered {
+				profileName = fakeprovider.ValidProfileName
+			}
+			return fakeprovider.ProfileCreateOrUpdate(ctx, resourceGroupName, profileName, parameters, options)
+		},
+	}
+	clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewProfilesServerTransport(&fakeServer),
+			},
+		})
+	if err != nil {
+		t.Fatalf("NewClientFactory() failed: %v", err)
+	}
+	return clientFactory.NewProfilesClient()
+}
+
+func TestReconcile_CircuitBreaker(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       fakeprovider.InternalServerErrProfileName,
+			Namespace:  fakeprovider.ProfileNamespace,
+			Finalizers: []string{objectmeta.TrafficManagerProfileFinalizer},
+		},
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+				IntervalInSeconds:         ptr.To[int64](30),
+				Path:                      ptr.To("/healthz"),
+				Port:                      ptr.To[int64](8080),
+				Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+				TimeoutInSeconds:          ptr.To[int64](10),
+				ToleratedNumberOfFailures: ptr.To[int64](5),
+			},
+		},
+	}
+	fakeClient := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(profile).
+		WithStatusSubresource(profile).
+		Build()
+
+	threshold := 3
+	coolDown := time.Minute
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	var calls int
+	var recovered bool
+	r := &Reconciler{
+		Client:            fakeClient,
+		Recorder:          record.NewFakeRecorder(10),
+		ProfilesClient:    newCountingProfileClient(t, &calls, &recovered),
+		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+		CircuitBreaker:    circuitbreaker.NewWithClock(threshold, coolDown, fakeClock),
+	}
+	ctx := context.Background()
+	name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+	req := ctrl.Request{NamespacedName: name}
+
+	checkProgrammedCondition := func(wantStatus metav1.ConditionStatus, wantReason fleetnetv1beta1.TrafficManagerProfileConditionReason) {
+		t.Helper()
+		got := &fleetnetv1beta1.TrafficManagerProfile{}
+		if err := fakeClient.Get(ctx, name, got); err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		cond := meta.FindStatusCondition(got.Status.Conditions, string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed))
+		if cond == nil || cond.Status != wantStatus || cond.Reason != string(wantReason) {
+			t.Fatalf("Programmed condition = %+v, want status %s and reason %s", cond, wantStatus, wantReason)
+		}
+	}
+
+	// The controller keeps calling Azure until the threshold is reached.
+	for i := 1; i < threshold; i++ {
+		if _, err := r.Reconcile(ctx, req); err == nil {
+			t.Fatalf("Reconcile() got nil error, want internal server error")
+		}
+		checkProgrammedCondition(metav1.ConditionUnknown, fleetnetv1beta1.TrafficManagerProfileReasonPending)
+	}
+	res, err := r.Reconcile(ctx, req)
+	if err != nil || res.RequeueAfter != coolDown {
+		t.Fatalf("Reconcile() = (%+v, %v), want (RequeueAfter: %v, nil)", res, err, coolDown)
+	}
+	checkProgrammedCondition(metav1.ConditionFalse, fleetnetv1beta1.TrafficManagerProfileReasonPending)
+	if calls != threshold {
+		t.Fatalf("createOrUpdate calls = %d, want %d", calls, threshold)
+	}
+
+	// The controller stops calling Azure while the circuit is open.
+	fakeClock.SetTime(fakeClock.Now().Add(coolDown / 2))
+	res, err = r.Reconcile(ctx, req)
+	if err != nil || res.RequeueAfter != coolDown/2 {
+		t.Fatalf("Reconcile() = (%+v, %v), want (RequeueAfter: %v, nil)", res, err, coolDown/2)
+	}
+	if calls != threshold {
+		t.Fatalf("createOrUpdate calls = %d, want %d", calls, threshold)
+	}
+
+	// The circuit half-opens after the cool-down period; the failed retry opens the circuit again.
+	fakeClock.SetTime(fakeClock.Now().Add(coolDown))
+	res, err = r.Reconcile(ctx, req)
+	if err != nil || res.RequeueAfter != coolDown {
+		t.Fatalf("Reconcile() = (%+v, %v), want (RequeueAfter: %v, nil)", res, err, coolDown)
+	}
+	checkProgrammedCondition(metav1.ConditionFalse, fleetnetv1beta1.TrafficManagerProfileReasonPending)
+	if calls != threshold+1 {
+		t.Fatalf("createOrUpdate calls = %d, want %d", calls, threshold+1)
+	}
+
+	// Azure recovers and the successful retry closes the circuit.
+	recovered = true
+	fakeClock.SetTime(fakeClock.Now().Add(coolDown))
+	res, err = r.Reconcile(ctx, req)
+	if err != nil || res.RequeueAfter != 0 {
+		t.Fatalf("Reconcile() = (%+v, %v), want (%+v, nil)", res, err, ctrl.Result{})
+	}
+	checkProgrammedCondition(metav1.ConditionTrue, fleetnetv1beta1.TrafficManagerProfileReasonProgrammed)
+	if calls != threshold+2 {
+		t.Fatalf("createOrUpdate calls = %d, want %d", calls, threshold+2)
+	}
+	if got := r.CircuitBreaker.State(circuitBreakerKey(profile)); got != circuitbreaker.StateClosed {
+		t.Fatalf("circuit breaker state = %v, want %v", got, circuitbreaker.StateClosed)
+	}
+}
+
+// TestRecordAzureServerError tests that only server errors count as failures, and only successful calls close the
+// circuit.
+func TestRecordAzureServerError(t *testing.T) {
+	key := "profile-namespace/profile-name"
+	testCases := []struct {
+		name          string
+		halfOpen      bool
+		err           error
+		wantOpen      bool
+		wantState     circuitbreaker.State
+		wantAllowNext bool
+	}{
+		{
+			name:          "successful call closes the circuit",
+			halfOpen:      true,
+			wantState:     circuitbreaker.StateClosed,
+			wantAllowNext: true,
+		},
+		{
+			name:      "server error reopens the circuit",
+			halfOpen:  true,
+			err:       &azcore.ResponseError{StatusCode: http.StatusInternalServerError},
+			wantOpen:  true,
+			wantState: circuitbreaker.StateOpen,
+		},
+		{
+			name:          "client error lets another trial call through",
+			halfOpen:      true,
+			err:           &azcore.ResponseError{StatusCode: http.StatusBadRequest},
+			wantState:     circuitbreaker.StateOpen,
+			wantAllowNext: true,
+		},
+		{
+			name:          "request which never reaches Azure lets another trial call through",
+			halfOpen:      true,
+			err:           fmt.Errorf("dial tcp: connection reset by peer"),
+			wantState:     circuitbreaker.StateOpen,
+			wantAllowNext: true,
+		},
+		{
+			name:      "client error does not reset the failures of a closed circuit",
+			err:       &azcore.ResponseError{StatusCode: http.StatusNotFound},
+			wantState: circuitbreaker.StateClosed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+			r := &Reconciler{CircuitBreaker: circuitbreaker.NewWithClock(2, time.Minute, fakeClock)}
+			r.CircuitBreaker.RecordFailure(key)
+			if tc.halfOpen {
+				r.CircuitBreaker.RecordFailure(key)
+				fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+				if allowed, _ := r.CircuitBreaker.Allow(key); !allowed {
+					t.Fatalf("Allow() = false, want true for the trial call")
+				}
+			}
+
+			if got := r.recordAzureServerError(key, tc.err); got != tc.wantOpen {
+				t.Errorf("recordAzureServerError() = %v, want %v", got, tc.wantOpen)
+			}
+			if got := r.CircuitBreaker.State(key); got != tc.wantState {
+				t.Errorf("circuit state = %v, want %v", got, tc.wantState)
+			}
+			if !tc.halfOpen {
+				// One more failure opens the circuit if the failure before the client error is still counted.
+				if got := r.CircuitBreaker.RecordFailure(key); !got {
+					t.Errorf("RecordFailure() = false, want true as the earlier failure is still counted")
+				}
+				return
+			}
+			if allowed, _ := r.CircuitBreaker.Allow(key); allowed != tc.wantAllowNext {
+				t.Errorf("Allow() = %v, want %v", allowed, tc.wantAllowNext)
+			}
+		})
+	}
+}
+
+func TestConfigureDDoSProtection(t *testing.T) {
+	pipClient, pipServer, err := fakeprovider.NewPublicIPAddressesClient("sub1")
+	if err != nil {
+		t.Fatalf("NewPublicIPAddressesClient() = %v, want no error", err)
+	}
+	r := &Reconciler{PublicIPAddressesClient: pipClient}
+	atmProfile := &armtrafficmanager.Profile{
+		Properties: &armtrafficmanager.ProfileProperties{
+			Endpoints: []*armtrafficmanager.Endpoint{
+				{
+					Name: ptr.To("endpoint"),
+					Properties: &armtrafficmanager.EndpointProperties{
+						TargetResourceID: ptr.To(fakeprovider.ValidPublicIPAddressResourceID),
+					},
+				},
+				{
+					Name: ptr.To("non-public-ip-endpoint"),
+					Properties: &armtrafficmanager.EndpointProperties{
+						TargetResourceID: ptr.To(fakeprovider.ValidPublicIPResourceID),
+					},
+				},
+				{
+					Name: ptr.To("nil-properties-endpoint"),
+				},
+			},
+		},
+	}
+
+	profile := &fleetnetv1beta1.TrafficManagerProfile{}
+	if inProgress, err := r.configureDDoSProtection(context.Background(), profile, atmProfile); err != nil || inProgress {
+		t.Fatalf("configureDDoSProtection() = (%v, %v), want (false, nil)", inProgress, err)
+	}
+	if got := pipServer.DDoSSettings(fakeprovider.ValidPublicIPAddressName); got != nil {
+		t.Fatalf("DDoSSettings() = %+v, want nil when the DDoS protection is not enabled", got)
+	}
+
+	// The update of the public IP address is started without waiting for it to complete.
+	profile.Spec.DDoSProtectionEnabled = ptr.To(true)
+	profile.Spec.DDoSPlanResourceID = ptr.To(fakeprovider.DDoSPlanResourceID)
+	if inProgress, err := r.configureDDoSProtection(context.Background(), profile, atmProfile); err != nil || !inProgress {
+		t.Fatalf("configureDDoSProtection() = (%v, %v), want (true, nil)", inProgress, err)
+	}
+	want := generateDDoSSettings(profile)
+	if got := pipServer.DDoSSettings(fakeprovider.ValidPublicIPAddressName); !equalDDoSSettings(got, want) {
+		t.Fatalf("DDoSSettings() = %+v, want %+v", got, want)
+	}
+
+	// The public IP address is checked again while it is being updated.
+	pipServer.SetProvisioningState(fakeprovider.ValidPublicIPAddressName, armnetwork.ProvisioningStateUpdating)
+	if inProgress, err := r.configureDDoSProtection(context.Background(), profile, atmProfile); err != nil || !inProgress {
+		t.Fatalf("configureDDoSProtection() = (%v, %v), want (true, nil)", inProgress, err)
+	}
+
+	// The configuration is done once the update has completed.
+	pipServer.SetProvisioningState(fakeprovider.ValidPublicIPAddressName, armnetwork.ProvisioningStateSucceeded)
+	if inProgress, err := r.configureDDoSProtection(context.Background(), profile, atmProfile); err != nil || inProgress {
+		t.Fatalf("configureDDoSProtection() = (%v, %v), want (false, nil)", inProgress, err)
+	}
+
+	atmProfile.Properties.Endpoints = append(atmProfile.Properties.Endpoints, &armtrafficmanager.Endpoint{
+		Name: ptr.To("internal-server-err-endpoint"),
+		Properties: &armtrafficmanager.EndpointProperties{
+			TargetResourceID: ptr.To(fakeprovider.InternalServerErrPublicIPAddressResourceID),
+		},
+	})
+	if _, err := r.configureDDoSProtection(context.Background(), profile, atmProfile); err == nil {
+		t.Fatalf("configureDDoSProtection() = nil, want error")
+	}
+}
+
+func TestEqualDDoSSettings(t *testing.T) {
+	planID := "/subscriptions/sub1/resourceGroups/rg/providers/Microsoft.Network/ddosProtectionPlans/plan"
+	tests := []struct {
+		name    string
+		current *armnetwork.DdosSettings
+		desired *armnetwork.DdosSettings
+		want    bool
+	}{
+		{
+			name:    "nil current settings",
+			desired: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			want:    false,
+		},
+		{
+			name:    "different protection mode",
+			current: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeVirtualNetworkInherited)},
+			desired: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			want:    false,
+		},
+		{
+			name:    "same protection mode without plan",
+			current: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			desired: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			want:    true,
+		},
+		{
+			name:    "missing plan",
+			current: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			desired: &armnetwork.DdosSettings{
+				ProtectionMode:     ptr.To(armnetwork.DdosSettingsProtectionModeEnabled),
+				DdosProtectionPlan: &armnetwork.SubResource{ID: ptr.To(planID)},
+			},
+			want: false,
+		},
+		{
+			name: "same plan in different case",
+			current: &armnetwork.DdosSettings{
+				ProtectionMode:     ptr.To(armnetwork.DdosSettingsProtectionModeEnabled),
+				DdosProtectionPlan: &armnetwork.SubResource{ID: ptr.To(strings.ToUpper(planID))},
+			},
+			desired: &armnetwork.DdosSettings{
+				ProtectionMode:     ptr.To(armnetwork.DdosSettingsProtectionModeEnabled),
+				DdosProtectionPlan: &armnetwork.SubResource{ID: ptr.To(planID)},
+			},
+			want: true,
+		},
+		{
+			name: "unexpected plan",
+			current: &armnetwork.DdosSettings{
+				ProtectionMode:     ptr.To(armnetwork.DdosSettingsProtectionModeEnabled),
+				DdosProtectionPlan: &armnetwork.SubResource{ID: ptr.To(planID)},
+			},
+			desired: &armnetwork.DdosSettings{ProtectionMode: ptr.To(armnetwork.DdosSettingsProtectionModeEnabled)},
+			want:    false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := equalDDoSSettings(tc.current, tc.desired); got != tc.want {
+				t.Errorf("equalDDoSSettings() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeEndpointHealth(t *testing.T) {
+	profilesClient, err := fakeprovider.NewProfileClient("default-sub")
+	if err != nil {
+		t.Fatalf("NewProfileClient() failed: %v", err)
+	}
+	tests := []struct {
+		name        string
+		profileName string
+		want        fleetnetv1beta1.TrafficManagerProfileStatus
+	}{
+		{
+			name:        "profile without endpoints",
+			profileName: fakeprovider.ValidProfileName,
+		},
+		{
+			name:        "profile with endpoints of different monitor status",
+			profileName: fakeprovider.ValidProfileWithEndpointsName,
+			want: fleetnetv1beta1.TrafficManagerProfileStatus{
+				HealthyEndpoints:  1,
+				DegradedEndpoints: 1,
+				CheckingEndpoints: 1,
+				TotalEndpoints:    3,
+			},
+		},
+		{
+			name:        "profile with endpoints without monitor status",
+			profileName: fakeprovider.ValidProfileWithFailToDeleteEndpointName,
+			want: fleetnetv1beta1.TrafficManagerProfileStatus{
+				TotalEndpoints: 1,
+			},
+		},
+		{
+			name:        "profile with nil properties",
+			profileName: fakeprovider.ValidProfileWithNilPropertiesName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := profilesClient.Get(context.Background(), fakeprovider.DefaultResourceGroupName, tt.profileName, nil)
+			if err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			// Start from stale counts to make sure all of them are reset.
+			got := fleetnetv1beta1.TrafficManagerProfileStatus{HealthyEndpoints: 5, DegradedEndpoints: 5, CheckingEndpoints: 5, TotalEndpoints: 15}
+			setEndpointHealth(&got, summarizeEndpointHealth(res.Profile))
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("setEndpointHealth() status mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestReconcile_Delete tests that the finalizer of a deleted profile is removed only once its Azure Traffic Manager
+// profile is gone.
+func TestReconcile_Delete(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	fakeServer := fake.ProfilesServer{
+		Delete: func(ctx context.Context, resourceGroupName string, profileName string, options *armtrafficmanager.ProfilesClientDeleteOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientDeleteResponse], azcorefake.ErrorResponder) {
+			if profileName == fakeprovider.InternalServerErrProfileName {
+				var errResp azcorefake.ErrorResponder
+				errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
+				return azcorefake.Responder[armtrafficmanager.ProfilesClientDeleteResponse]{}, errResp
+			}
+			return fakeprovider.ProfileDelete(ctx, resourceGroupName, profileName, options)
+		},
+	}
+	clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewProfilesServerTransport(&fakeServer),
+			},
+		})
+	if err != nil {
+		t.Fatalf("NewClientFactory() failed: %v", err)
+	}
+
+	tests := []struct {
+		name              string
+		profileName       string
+		resourceGroupName string
+		wantErr           bool
+	}{
+		{
+			name:              "azure profile deleted",
+			profileName:       fakeprovider.ValidProfileName,
+			resourceGroupName: fakeprovider.DefaultResourceGroupName,
+		},
+		{
+			name:              "azure profile not found",
+			profileName:       "not-found-profile",
+			resourceGroupName: fakeprovider.DefaultResourceGroupName,
+		},
+		{
+			name:              "resource group not found",
+			profileName:       fakeprovider.ValidProfileName,
+			resourceGroupName: "not-found-resource-group",
+		},
+		{
+			name:              "failed to delete azure profile",
+			profileName:       fakeprovider.InternalServerErrProfileName,
+			resourceGroupName: fakeprovider.DefaultResourceGroupName,
+			wantErr:           true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              tc.profileName,
+					Namespace:         fakeprovider.ProfileNamespace,
+					Finalizers:        []string{objectmeta.TrafficManagerProfileFinalizer},
+					DeletionTimestamp: ptr.To(metav1.Now()),
+				},
+			}
+			fakeClient := fakeclient.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(profile).
+				Build()
+			r := &Reconciler{
+				Client:            fakeClient,
+				ProfilesClient:    clientFactory.NewProfilesClient(),
+				ResourceGroupName: tc.resourceGroupName,
+			}
+
+			name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: name})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("Reconcile() = %v, want error %t", err, tc.wantErr)
+			}
+			err = fakeClient.Get(context.Background(), name, profile)
+			if tc.wantErr {
+				if err != nil || !controllerutil.ContainsFinalizer(profile, objectmeta.TrafficManagerProfileFinalizer) {
+					t.Fatalf("Get() = %v with finalizers %v, want the profile with its finalizer", err, profile.Finalizers)
+				}
+				return
+			}
+			if !apierrors.IsNotFound(err) {
+				t.Fatalf("Get() = %v, want not found error", err)
+			}
+		})
+	}
+}
+
+// TestReconcile_RoutingMethodChange tests that a changed traffic routing method is updated in place, and that the
+// Azure profile is only recreated, keeping its DNS relative name, if Azure rejects the update.
+func TestReconcile_RoutingMethodChange(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	testCases := []struct {
+		name                string
+		rejectInPlaceUpdate bool
+		wantCalls           []string
+		wantReasons         []string
+	}{
+		{
+			name:        "updated in place",
+			wantCalls:   []string{"Get", "CreateOrUpdate"},
+			wantReasons: []string{string(fleetnetv1beta1.TrafficManagerProfileReasonProgrammed)},
+		},
+		{
+			name:                "recreated when the update in place is rejected",
+			rejectInPlaceUpdate: true,
+			wantCalls:           []string{"Get", "CreateOrUpdate", "Delete", "CreateOrUpdate"},
+			wantReasons: []string{
+				string(fleetnetv1beta1.TrafficManagerProfileReasonPending),
+				string(fleetnetv1beta1.TrafficManagerProfileReasonProgrammed),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       fakeprovider.ValidProfileName,
+					Namespace:  fakeprovider.ProfileNamespace,
+					Finalizers: []string{objectmeta.TrafficManagerProfileFinalizer},
+				},
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						IntervalInSeconds:         ptr.To[int64](30),
+						Path:                      ptr.To("/healthz"),
+						Port:                      ptr.To[int64](8080),
+						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+						TimeoutInSeconds:          ptr.To[int64](10),
+						ToleratedNumberOfFailures: ptr.To[int64](5),
+					},
+				},
+			}
+			// Record the Programmed conditions written to the status, so that the transition through Pending is observed.
+			var reasons []string
+			fakeClient := fakeclient.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(profile).
+				WithStatusSubresource(profile).
+				WithInterceptorFuncs(interceptor.Funcs{
+					SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+						p := obj.(*fleetnetv1beta1.TrafficManagerProfile)
+						if cond := meta.FindStatusCondition(p.Status.Conditions, string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed)); cond != nil {
+							reasons = append(reasons, cond.Reason)
+						}
+						return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+					},
+				}).
+				Build()
+
+			relativeName := "custom-relative-name"
+			var calls []string
+			var createdRelativeName *string
+			fakeServer := fake.ProfilesServer{
+				Get: func(_ context.Context, _ string, profileName string, _ *armtrafficmanager.ProfilesClientGetOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientGetResponse], azcorefake.ErrorResponder) {
+					calls = append(calls, "Get")
+					var resp azcorefake.Responder[armtrafficmanager.ProfilesClientGetResponse]
+					// The routing method of the existing profile was changed out of band.
+					resp.SetResponse(http.StatusOK, armtrafficmanager.ProfilesClientGetResponse{
+						Profile: armtrafficmanager.Profile{
+							Name:     ptr.To(profileName),
+							Location: ptr.To("global"),
+							Properties: &armtrafficmanager.ProfileProperties{
+								DNSConfig: &armtrafficmanager.DNSConfig{
+									Fqdn:         ptr.To(fmt.Sprintf(fakeprovider.ProfileDNSNameFormat, relativeName)),
+									RelativeName: ptr.To(relativeName),
+									TTL:          ptr.To(int64(60)),
+								},
+								ProfileStatus:        ptr.To(armtrafficmanager.ProfileStatusEnabled),
+								TrafficRoutingMethod: ptr.To(armtrafficmanager.TrafficRoutingMethodPriority),
+							},
+						},
+					}, nil)
+					return resp, azcorefake.ErrorResponder{}
+				},
+				Delete: func(ctx context.Context, resourceGroupName string, profileName string, options *armtrafficmanager.ProfilesClientDeleteOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientDeleteResponse], azcorefake.ErrorResponder) {
+					calls = append(calls, "Delete")
+					return fakeprovider.ProfileDelete(ctx, resourceGroupName, profileName, options)
+				},
+				CreateOrUpdate: func(ctx context.Context, resourceGroupName string, profileName string, parameters armtrafficmanager.Profile, options *armtrafficmanager.ProfilesClientCreateOrUpdateOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientCreateOrUpdateResponse], azcorefake.ErrorResponder) {
+					calls = append(calls, "CreateOrUpdate")
+					if tc.rejectInPlaceUpdate && len(calls) == 2 {
+						var errResp azcorefake.ErrorResponder
+						errResp.SetResponseError(http.StatusBadRequest, "BadRequest")
+						return azcorefake.Responder[armtrafficmanager.ProfilesClientCreateOrUpdateResponse]{}, errResp
+					}
+					createdRelativeName = parameters.Properties.DNSConfig.RelativeName
+					return fakeprovider.ProfileCreateOrUpdate(ctx, resourceGroupName, profileName, parameters, options)
+				},
+			}
+			clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+				&arm.ClientOptions{
+					ClientOptions: azcore.ClientOptions{
+						Transport: fake.NewProfilesServerTransport(&fakeServer),
+					},
+				})
+			if err != nil {
+				t.Fatalf("NewClientFactory() failed: %v", err)
+			}
+			r := &Reconciler{
+				Client:            fakeClient,
+				Recorder:          record.NewFakeRecorder(10),
+				ProfilesClient:    clientFactory.NewProfilesClient(),
+				ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+			}
+
+			ctx := context.Background()
+			name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+			if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+
+			if diff := cmp.Diff(tc.wantCalls, calls); diff != "" {
+				t.Errorf("Azure calls mismatch (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantReasons, reasons); diff != "" {
+				t.Errorf("Programmed condition reasons mismatch (-want, +got):\n%s", diff)
+			}
+			if !tc.rejectInPlaceUpdate {
+				return
+			}
+			// The profile is recreated with the DNS relative name it had, so that its DNS name does not change.
+			if createdRelativeName == nil || *createdRelativeName != relativeName {
+				t.Errorf("CreateOrUpdate() relative name = %v, want %s", ptr.Deref(createdRelativeName, "<nil>"), relativeName)
+			}
+			got := &fleetnetv1beta1.TrafficManagerProfile{}
+			if err := fakeClient.Get(ctx, name, got); err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			wantDNSName := fmt.Sprintf(fakeprovider.ProfileDNSNameFormat, relativeName)
+			if got.Status.DNSName == nil || *got.Status.DNSName != wantDNSName {
+				t.Errorf("DNSName = %v, want %s", ptr.Deref(got.Status.DNSName, "<nil>"), wantDNSName)
+			}
+		})
+	}
+}
+
+func TestReconcile_AzureTags(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       fakeprovider.ValidProfileName,
+			Namespace:  fakeprovider.ProfileNamespace,
+			Finalizers: []string{objectmeta.TrafficManagerProfileFinalizer},
+			Annotations: map[string]string{
+				objectmeta.AzureTagAnnotationPrefix + "costCenter":  "1234",
+				objectmeta.AzureTagAnnotationPrefix + "description": strings.Repeat("d", 257),
+				// The tag marking the profile as created by the controller cannot be overridden.
+				objectmeta.AzureTagAnnotationPrefix + objectmeta.AzureTrafficManagerProfileTagKey: "other",
+			},
+		},
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+				IntervalInSeconds:         ptr.To[int64](30),
+				Path:                      ptr.To("/healthz"),
+				Port:                      ptr.To[int64](8080),
+				Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+				TimeoutInSeconds:          ptr.To[int64](10),
+				ToleratedNumberOfFailures: ptr.To[int64](5),
+			},
+		},
+	}
+	fakeClient := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(profile).
+		WithStatusSubresource(profile).
+		Build()
+
+	// current is the Azure Traffic Manager profile last created or updated, with a tag added by others.
+	var current *armtrafficmanager.Profile
+	var createdTags map[string]*string
+	fakeServer := fake.ProfilesServer{
+		Get: func(_ context.Context, _ string, _ string, _ *armtrafficmanager.ProfilesClientGetOptions) (resp azcorefake.Responder[armtrafficmanager.ProfilesClientGetResponse], errResp azcorefake.ErrorResponder) {
+			if current == nil {
+				errResp.SetResponseError(http.StatusNotFound, "NotFound")
+				return resp, errResp
+			}
+			resp.SetResponse(http.StatusOK, armtrafficmanager.ProfilesClientGetResponse{Profile: *current}, nil)
+			return resp, errResp
+		},
+		CreateOrUpdate: func(ctx context.Context, resourceGroupName string, profileName string, parameters armtrafficmanager.Profile, options *armtrafficmanager.ProfilesClientCreateOrUpdateOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientCreateOrUpdateResponse], azcorefake.ErrorResponder) {
+			createdTags = parameters.Tags
+			tags := map[string]*string{"policy": ptr.To("audit")}
+			for key, value := range parameters.Tags {
+				tags[key] = value
+			}
+			current = &parameters
+			current.Tags = tags
+			return fakeprovider.ProfileCreateOrUpdate(ctx, resourceGroupName, profileName, parameters, options)
+		},
+	}
+	clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewProfilesServerTransport(&fakeServer),
+			},
+		})
+	if err != nil {
+		t.Fatalf("NewClientFactory() failed: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &Reconciler{
+		Client:            fakeClient,
+		Recorder:          recorder,
+		ProfilesClient:    clientFactory.NewProfilesClient(),
+		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+	}
+
+	ctx := context.Background()
+	name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+
+	wantTags := map[string]*string{
+		"costCenter": ptr.To("1234"),
+		objectmeta.AzureTrafficManagerProfileTagKey: ptr.To(name.String()),
+	}
+	if diff := cmp.Diff(wantTags, createdTags); diff != "" {
+		t.Errorf("CreateOrUpdate() tags mismatch (-want, +got):\n%s", diff)
+	}
+	got := &fleetnetv1beta1.TrafficManagerProfile{}
+	if err := fakeClient.Get(ctx, name, got); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"costCenter"}, got.Status.ManagedAzureTags); diff != "" {
+		t.Errorf("ManagedAzureTags mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The profile is not updated when nothing changes, and the invalid tag is not reported again.
+	createdTags = nil
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if createdTags != nil {
+		t.Errorf("CreateOrUpdate() is called with tags %v, want no call", createdTags)
+	}
+
+	// The tag is deleted once its annotation is removed, while the tag added by others is kept.
+	if err := fakeClient.Get(ctx, name, got); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	delete(got.Annotations, objectmeta.AzureTagAnnotationPrefix+"costCenter")
+	if err := fakeClient.Update(ctx, got); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	wantTags = map[string]*string{
+		"policy": ptr.To("audit"),
+		objectmeta.AzureTrafficManagerProfileTagKey: ptr.To(name.String()),
+	}
+	if diff := cmp.Diff(wantTags, createdTags); diff != "" {
+		t.Errorf("CreateOrUpdate() tags mismatch (-want, +got):\n%s", diff)
+	}
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	// The azure-tag annotations do not change the generation, so that the update of the tags is reported as a drift.
+	wantEvents := []string{
+		`Warning InvalidAzureTag Ignoring Azure tags: the value of Azure tag "description" is longer than 256 characters`,
+		"Warning Drifted Azure Traffic Manager profile valid-profile has been changed out of band; reapplying the desired state",
+	}
+	if diff := cmp.Diff(wantEvents, events); diff != "" {
+		t.Errorf("events mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_DriftDetection(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	driftDetectionInterval := 5 * time.Minute
+	tests := []struct {
+		name string
+		// monitorPath is the monitor path of the live Azure Traffic Manager profile.
+		monitorPath   string
+		programmed    bool
+		wantCalls     []string
+		wantEvents    []string
+		wantMonitored string
+	}{
+		{
+			name:        "profile in sync",
+			monitorPath: "/healthz",
+			programmed:  true,
+			wantCalls:   []string{"Get"},
+		},
+		{
+			name:          "monitor path changed out of band",
+			monitorPath:   "/changed",
+			programmed:    true,
+			wantCalls:     []string{"Get", "CreateOrUpdate"},
+			wantEvents:    []string{"Warning Drifted Azure Traffic Manager profile valid-profile has been changed out of band; reapplying the desired state"},
+			wantMonitored: "/healthz",
+		},
+		{
+			name:          "new generation not programmed yet",
+			monitorPath:   "/changed",
+			wantCalls:     []string{"Get", "CreateOrUpdate"},
+			wantMonitored: "/healthz",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       fakeprovider.ValidProfileName,
+					Namespace:  fakeprovider.ProfileNamespace,
+					Finalizers: []string{objectmeta.TrafficManagerProfileFinalizer},
+					Generation: 2,
+				},
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+						IntervalInSeconds:         ptr.To[int64](30),
+						Path:                      ptr.To("/healthz"),
+						Port:                      ptr.To[int64](8080),
+						Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+						TimeoutInSeconds:          ptr.To[int64](10),
+						ToleratedNumberOfFailures: ptr.To[int64](5),
+					},
+				},
+			}
+			observedGeneration := profile.Generation - 1
+			if tc.programmed {
+				observedGeneration = profile.Generation
+			}
+			profile.Status.Conditions = []metav1.Condition{
+				{
+					Type:               string(fleetnetv1beta1.TrafficManagerProfileConditionProgrammed),
+					Status:             metav1.ConditionTrue,
+					ObservedGeneration: observedGeneration,
+					Reason:             string(fleetnetv1beta1.TrafficManagerProfileReasonProgrammed),
+					LastTransitionTime: metav1.Now(),
+				},
+			}
+			fakeClient := fakeclient.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(profile).
+				WithStatusSubresource(profile).
+				Build()
+
+			var calls []string
+			var monitored string
+			fakeServer := fake.ProfilesServer{
+				Get: func(_ context.Context, _ string, profileName string, _ *armtrafficmanager.ProfilesClientGetOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientGetResponse], azcorefake.ErrorResponder) {
+					calls = append(calls, "Get")
+					var resp azcorefake.Responder[armtrafficmanager.ProfilesClientGetResponse]
+					resp.SetResponse(http.StatusOK, armtrafficmanager.ProfilesClientGetResponse{
+						Profile: armtrafficmanager.Profile{
+							Name:     ptr.To(profileName),
+							Location: ptr.To("global"),
+							Properties: &armtrafficmanager.ProfileProperties{
+								DNSConfig: &armtrafficmanager.DNSConfig{
+									Fqdn:         ptr.To(fmt.Sprintf(fakeprovider.ProfileDNSNameFormat, profileName)),
+									RelativeName: ptr.To(profileName),
+									TTL:          ptr.To(int64(60)),
+								},
+								MonitorConfig: &armtrafficmanager.MonitorConfig{
+									IntervalInSeconds:         ptr.To[int64](30),
+									Path:                      ptr.To(tc.monitorPath),
+									Port:                      ptr.To[int64](8080),
+									Protocol:                  ptr.To(armtrafficmanager.MonitorProtocolHTTPS),
+									TimeoutInSeconds:          ptr.To[int64](10),
+									ToleratedNumberOfFailures: ptr.To[int64](5),
+								},
+								ProfileStatus:        ptr.To(armtrafficmanager.ProfileStatusEnabled),
+								TrafficRoutingMethod: ptr.To(armtrafficmanager.TrafficRoutingMethodWeighted),
+							},
+							Tags: map[string]*string{
+								objectmeta.AzureTrafficManagerProfileTagKey: ptr.To(types.NamespacedName{Namespace: fakeprovider.ProfileNamespace, Name: profileName}.String()),
+							},
+						},
+					}, nil)
+					return resp, azcorefake.ErrorResponder{}
+				},
+				CreateOrUpdate: func(ctx context.Context, resourceGroupName string, profileName string, parameters armtrafficmanager.Profile, options *armtrafficmanager.ProfilesClientCreateOrUpdateOptions) (azcorefake.Responder[armtrafficmanager.ProfilesClientCreateOrUpdateResponse], azcorefake.ErrorResponder) {
+					calls = append(calls, "CreateOrUpdate")
+					monitored = *parameters.Properties.MonitorConfig.Path
+					return fakeprovider.ProfileCreateOrUpdate(ctx, resourceGroupName, profileName, parameters, options)
+				},
+			}
+			clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+				&arm.ClientOptions{
+					ClientOptions: azcore.ClientOptions{
+						Transport: fake.NewProfilesServerTransport(&fakeServer),
+					},
+				})
+			if err != nil {
+				t.Fatalf("NewClientFactory() failed: %v", err)
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{
+				Client:                 fakeClient,
+				Recorder:               recorder,
+				ProfilesClient:         clientFactory.NewProfilesClient(),
+				ResourceGroupName:      fakeprovider.DefaultResourceGroupName,
+				DriftDetectionInterval: driftDetectionInterval,
+			}
+
+			name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+			res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: name})
+			if err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+			if res.RequeueAfter != driftDetectionInterval {
+				t.Errorf("Reconcile() requeueAfter = %v, want %v", res.RequeueAfter, driftDetectionInterval)
+			}
+			if diff := cmp.Diff(tc.wantCalls, calls); diff != "" {
+				t.Errorf("Azure calls mismatch (-want, +got):\n%s", diff)
+			}
+			if monitored != tc.wantMonitored {
+				t.Errorf("CreateOrUpdate() monitor path = %q, want %q", monitored, tc.wantMonitored)
+			}
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcile_EqualizeWeights(t *testing.T) {
+	originalFunc := generateAzureTrafficManagerProfileNameFunc
+	generateAzureTrafficManagerProfileNameFunc = func(profile *fleetnetv1beta1.TrafficManagerProfile) string {
+		return profile.Name
+	}
+	defer func() {
+		generateAzureTrafficManagerProfileNameFunc = originalFunc
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() failed: %v", err)
+	}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fakeprovider.ValidProfileWithEndpointsName,
+			Namespace:   fakeprovider.ProfileNamespace,
+			Finalizers:  []string{objectmeta.TrafficManagerProfileFinalizer},
+			Annotations: map[string]string{objectmeta.TrafficManagerProfileAnnotationEqualizeWeights: "true"},
+		},
+		Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+			MonitorConfig: &fleetnetv1beta1.MonitorConfig{
+				IntervalInSeconds:         ptr.To[int64](10),
+				Path:                      ptr.To("/healthz"),
+				Port:                      ptr.To[int64](8080),
+				Protocol:                  ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+				TimeoutInSeconds:          ptr.To[int64](9),
+				ToleratedNumberOfFailures: ptr.To[int64](4),
+			},
+		},
+	}
+	fakeClient := fakeclient.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(profile).
+		WithStatusSubresource(profile).
+		Build()
+
+	gotWeights := map[string]int64{}
+	endpointsServer := fake.EndpointsServer{
+		CreateOrUpdate: func(_ context.Context, _ string, _ string, _ armtrafficmanager.EndpointType, endpointName string, parameters armtrafficmanager.Endpoint, _ *armtrafficmanager.EndpointsClientCreateOrUpdateOptions) (resp azcorefake.Responder[armtrafficmanager.EndpointsClientCreateOrUpdateResponse], errResp azcorefake.ErrorResponder) {
+			gotWeights[endpointName] = *parameters.Properties.Weight
+			resp.SetResponse(http.StatusOK, armtrafficmanager.EndpointsClientCreateOrUpdateResponse{Endpoint: parameters}, nil)
+			return resp, errResp
+		},
+	}
+	clientFactory, err := armtrafficmanager.NewClientFactory("default-sub", &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewEndpointsServerTransport(&endpointsServer),
+			},
+		})
+	if err != nil {
+		t.Fatalf("NewClientFactory() failed: %v", err)
+	}
+	profilesClient, err := fakeprovider.NewProfileClient("default-sub")
+	if err != nil {
+		t.Fatalf("NewProfileClient() failed: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &Reconciler{
+		Client:            fakeClient,
+		Recorder:          recorder,
+		ProfilesClient:    profilesClient,
+		EndpointsClient:   clientFactory.NewEndpointsClient(),
+		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+	}
+	ctx := context.Background()
+	name := types.NamespacedName{Namespace: profile.Namespace, Name: profile.Name}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+
+	// Only the Azure endpoints, which are created by the backends, are updated.
+	wantWeights := map[string]int64{strings.ToUpper(fakeprovider.ValidEndpointName): 1}
+	if diff := cmp.Diff(wantWeights, gotWeights); diff != "" {
+		t.Errorf("endpoint weights mismatch (-want, +got):\n%s", diff)
+	}
+	got := &fleetnetv1beta1.TrafficManagerProfile{}
+	if err := fakeClient.Get(ctx, name, got); err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if _, ok := got.Annotations[objectmeta.TrafficManagerProfileAnnotationEqualizeWeights]; ok {
+		t.Errorf("profile annotations = %v, want the equalize-weights annotation to be removed", got.Annotations)
+	}
+	if got.Generation != profile.Generation {
+		t.Errorf("profile generation = %d, want %d", got.Generation, profile.Generation)
+	}
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	wantEvent := fmt.Sprintf("Normal %s Set the weights of 1 endpoints of Azure Traffic Manager profile %s to 1", weightsEqualizedEventReason, profile.Name)
+	if diff := cmp.Diff([]string{wantEvent}, events); diff != "" {
+		t.Errorf("events mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The weights are not equalized again once the annotation is removed.
+	gotWeights = map[string]int64{}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: name}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if len(gotWeights) != 0 {
+		t.Errorf("endpoint weights = %v, want no update", gotWeights)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/defaulter"
+)
+
+// EndpointPriorities translates the priority config of a profile using the Priority routing method into the
+// priorities of its Azure Traffic Manager endpoints, keyed by the lower-cased endpoint name as the endpoint names are
+// case-insensitive. It returns nil if the profile uses another routing method.
+//
+// The priorities are set on the endpoints by the TrafficManagerBackends creating them; an endpoint of a profile using
+// the Priority routing method is rejected by Azure when it has no priority.
+func EndpointPriorities(profile *fleetnetv1beta1.TrafficManagerProfile) map[string]int64 {
+	if trafficRoutingMethod(profile) != armtrafficmanager.TrafficRoutingMethodPriority {
+		return nil
+	}
+	priorities := make(map[string]int64, len(profile.Spec.PriorityConfig))
+	for _, p := range profile.Spec.PriorityConfig {
+		priorities[strings.ToLower(p.EndpointName)] = p.Priority
+	}
+	return priorities
+}
+
+// DemotedEndpointPriority returns the priority an endpoint of the given priority is demoted to on SLA breach; the
+// demoted priorities stay unique, as Azure requires, and below all the priorities which are not demoted.
+func DemotedEndpointPriority(priority int64) int64 {
+	return priority + fleetnetv1beta1.MaxEndpointPriority
+}
+
+// IsDemotedEndpointPriority returns true if the priority of an Azure Traffic Manager endpoint is in the band the
+// endpoints are demoted to on SLA breach.
+func IsDemotedEndpointPriority(priority int64) bool {
+	return priority > fleetnetv1beta1.MaxEndpointPriority
+}
+
+// DemotionThreshold returns the availability, as a ratio, below which the endpoints of a profile are demoted on SLA
+// breach.
+func DemotionThreshold(profile *fleetnetv1beta1.TrafficManagerProfile) float64 {
+	basisPoints := int64(defaulter.DefaultDemotionThresholdBasisPoints)
+	if profile.Spec.DemotionThresholdBasisPoints != nil {
+		basisPoints = *profile.Spec.DemotionThresholdBasisPoints
+	}
+	return float64(basisPoints) / 10000
+}
@@ -0,0 +1,87 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+func TestEndpointPriorities(t *testing.T) {
+	priorityConfig := []fleetnetv1beta1.EndpointPriority{
+		{EndpointName: "Backend#Import#Member-1", Priority: 1},
+		{EndpointName: "backend#import#member-2", Priority: 2},
+	}
+	tests := []struct {
+		name           string
+		routingMethod  *fleetnetv1beta1.TrafficRoutingMethod
+		priorityConfig []fleetnetv1beta1.EndpointPriority
+		want           map[string]int64
+	}{
+		{
+			name: "routing method not set",
+		},
+		{
+			name:          "weighted routing method",
+			routingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
+		},
+		{
+			name:           "priority routing method",
+			routingMethod:  ptr.To(fleetnetv1beta1.TrafficRoutingMethodPriority),
+			priorityConfig: priorityConfig,
+			want: map[string]int64{
+				"backend#import#member-1": 1,
+				"backend#import#member-2": 2,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					RoutingMethod:  tc.routingMethod,
+					PriorityConfig: tc.priorityConfig,
+				},
+			}
+			if diff := cmp.Diff(tc.want, EndpointPriorities(profile)); diff != "" {
+				t.Errorf("EndpointPriorities() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDemotedEndpointPriority(t *testing.T) {
+	for _, priority := range []int64{1, fleetnetv1beta1.MaxEndpointPriority} {
+		demoted := DemotedEndpointPriority(priority)
+		if IsDemotedEndpointPriority(priority) || !IsDemotedEndpointPriority(demoted) {
+			t.Errorf("IsDemotedEndpointPriority(%d) = %t and IsDemotedEndpointPriority(%d) = %t, want false and true",
+				priority, IsDemotedEndpointPriority(priority), demoted, IsDemotedEndpointPriority(demoted))
+		}
+		// Azure accepts priorities from 1 to 1000.
+		if demoted > 1000 {
+			t.Errorf("DemotedEndpointPriority(%d) = %d, want at most 1000", priority, demoted)
+		}
+	}
+}
+
+func TestDemotionThreshold(t *testing.T) {
+	profile := &fleetnetv1beta1.TrafficManagerProfile{}
+	if got := DemotionThreshold(profile); got != 0.99 {
+		t.Errorf("DemotionThreshold() = %v, want 0.99", got)
+	}
+	profile.Spec.DemotionThresholdBasisPoints = ptr.To(int64(5000))
+	if got := DemotionThreshold(profile); got != 0.5 {
+		t.Errorf("DemotionThreshold() = %v, want 0.5", got)
+	}
+	profile.Spec.DemotionThresholdBasisPoints = ptr.To(int64(0))
+	if got := DemotionThreshold(profile); got != 0 {
+		t.Errorf("DemotionThreshold() = %v, want 0", got)
+	}
+}
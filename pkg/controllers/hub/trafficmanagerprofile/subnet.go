@@ -0,0 +1,64 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+// EndpointSubnets translates the subnet config of a profile using the Subnet routing method into the subnets of its
+// Azure Traffic Manager endpoints, keyed by the lower-cased endpoint name as the endpoint names are case-insensitive.
+// It returns nil if the profile uses another routing method.
+//
+// The subnets are set on the endpoints by the TrafficManagerBackends creating them; an endpoint of a profile using
+// the Subnet routing method is rejected by Azure when it has no subnets.
+func EndpointSubnets(profile *fleetnetv1beta1.TrafficManagerProfile) map[string][]*armtrafficmanager.EndpointPropertiesSubnetsItem {
+	if trafficRoutingMethod(profile) != armtrafficmanager.TrafficRoutingMethodSubnet {
+		return nil
+	}
+	subnets := make(map[string][]*armtrafficmanager.EndpointPropertiesSubnetsItem, len(profile.Spec.SubnetConfig))
+	for _, rule := range profile.Spec.SubnetConfig {
+		prefix, err := netip.ParsePrefix(rule.CIDR)
+		if err != nil {
+			// The CIDR is validated by the API server.
+			klog.ErrorS(err, "Ignoring the invalid CIDR of the subnet config", "trafficManagerProfile", klog.KObj(profile), "cidr", rule.CIDR)
+			continue
+		}
+		name := strings.ToLower(rule.EndpointName)
+		subnets[name] = append(subnets[name], &armtrafficmanager.EndpointPropertiesSubnetsItem{
+			First: ptr.To(prefix.Masked().Addr().String()),
+			Scope: ptr.To(int32(prefix.Bits())),
+		})
+	}
+	return subnets
+}
+
+// EqualEndpointSubnets returns true if the subnets of two Azure Traffic Manager endpoints map the same address ranges
+// in the same order; the last address Azure may report for a range given by its first address and scope is ignored.
+func EqualEndpointSubnets(current, desired []*armtrafficmanager.EndpointPropertiesSubnetsItem) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for i := range current {
+		if current[i] == nil || desired[i] == nil {
+			if current[i] != desired[i] {
+				return false
+			}
+			continue
+		}
+		if !ptr.Equal(current[i].First, desired[i].First) || !ptr.Equal(current[i].Scope, desired[i].Scope) {
+			return false
+		}
+	}
+	return true
+}
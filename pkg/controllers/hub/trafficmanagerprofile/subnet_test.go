@@ -0,0 +1,126 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/trafficmanager/armtrafficmanager"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+)
+
+func TestEndpointSubnets(t *testing.T) {
+	subnetConfig := []fleetnetv1beta1.SubnetRoutingRule{
+		{CIDR: "10.1.0.0/16", EndpointName: "Backend#Import#Member-1"},
+		// The CIDR is not the first address of the range.
+		{CIDR: "10.2.3.4/24", EndpointName: "backend#import#member-1"},
+		{CIDR: "2001:db8::/32", EndpointName: "backend#import#member-2"},
+	}
+	tests := []struct {
+		name          string
+		routingMethod *fleetnetv1beta1.TrafficRoutingMethod
+		subnetConfig  []fleetnetv1beta1.SubnetRoutingRule
+		want          map[string][]*armtrafficmanager.EndpointPropertiesSubnetsItem
+	}{
+		{
+			name: "routing method not set",
+		},
+		{
+			name:          "weighted routing method",
+			routingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodWeighted),
+		},
+		{
+			name:          "subnet routing method",
+			routingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+			subnetConfig:  subnetConfig,
+			want: map[string][]*armtrafficmanager.EndpointPropertiesSubnetsItem{
+				"backend#import#member-1": {
+					{First: ptr.To("10.1.0.0"), Scope: ptr.To(int32(16))},
+					{First: ptr.To("10.2.3.0"), Scope: ptr.To(int32(24))},
+				},
+				"backend#import#member-2": {
+					{First: ptr.To("2001:db8::"), Scope: ptr.To(int32(32))},
+				},
+			},
+		},
+		{
+			name:          "invalid CIDR",
+			routingMethod: ptr.To(fleetnetv1beta1.TrafficRoutingMethodSubnet),
+			subnetConfig: []fleetnetv1beta1.SubnetRoutingRule{
+				{CIDR: "10.1.0.0", EndpointName: "backend#import#member-1"},
+			},
+			want: map[string][]*armtrafficmanager.EndpointPropertiesSubnetsItem{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				Spec: fleetnetv1beta1.TrafficManagerProfileSpec{
+					RoutingMethod: tc.routingMethod,
+					SubnetConfig:  tc.subnetConfig,
+				},
+			}
+			if diff := cmp.Diff(tc.want, EndpointSubnets(profile)); diff != "" {
+				t.Errorf("EndpointSubnets() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEqualEndpointSubnets(t *testing.T) {
+	desired := []*armtrafficmanager.EndpointPropertiesSubnetsItem{
+		{First: ptr.To("10.1.0.0"), Scope: ptr.To(int32(16))},
+		{First: ptr.To("10.2.0.0"), Scope: ptr.To(int32(24))},
+	}
+	tests := []struct {
+		name    string
+		current []*armtrafficmanager.EndpointPropertiesSubnetsItem
+		desired []*armtrafficmanager.EndpointPropertiesSubnetsItem
+		want    bool
+	}{
+		{
+			name: "no subnets",
+			want: true,
+		},
+		{
+			name: "same subnets with the last addresses reported by Azure",
+			current: []*armtrafficmanager.EndpointPropertiesSubnetsItem{
+				{First: ptr.To("10.1.0.0"), Last: ptr.To("10.1.255.255"), Scope: ptr.To(int32(16))},
+				{First: ptr.To("10.2.0.0"), Last: ptr.To("10.2.0.255"), Scope: ptr.To(int32(24))},
+			},
+			desired: desired,
+			want:    true,
+		},
+		{
+			name:    "subnets added",
+			desired: desired,
+		},
+		{
+			name: "subnets removed",
+			current: []*armtrafficmanager.EndpointPropertiesSubnetsItem{
+				{First: ptr.To("10.1.0.0"), Scope: ptr.To(int32(16))},
+			},
+		},
+		{
+			name: "scope changed",
+			current: []*armtrafficmanager.EndpointPropertiesSubnetsItem{
+				{First: ptr.To("10.1.0.0"), Scope: ptr.To(int32(16))},
+				{First: ptr.To("10.2.0.0"), Scope: ptr.To(int32(16))},
+			},
+			desired: desired,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := EqualEndpointSubnets(tc.current, tc.desired); got != tc.want {
+				t.Errorf("EqualEndpointSubnets() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"flag"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -27,6 +28,7 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
 	"go.goms.io/fleet-networking/test/common/trafficmanager/fakeprovider"
 )
 
@@ -43,6 +45,11 @@ var (
 	testNamespace = fakeprovider.ProfileNamespace
 )
 
+const (
+	circuitBreakerThreshold = 3
+	circuitBreakerCoolDown  = 5 * time.Second
+)
+
 var (
 	originalGenerateAzureTrafficManagerProfileNameFunc = generateAzureTrafficManagerProfileNameFunc
 )
@@ -101,8 +108,10 @@ var _ = BeforeSuite(func() {
 
 	err = (&Reconciler{
 		Client:            mgr.GetClient(),
+		Recorder:          mgr.GetEventRecorderFor(ControllerName),
 		ProfilesClient:    profileClient,
 		ResourceGroupName: fakeprovider.DefaultResourceGroupName,
+		CircuitBreaker:    circuitbreaker.New(circuitBreakerThreshold, circuitBreakerCoolDown),
 	}).SetupWithManager(mgr)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,36 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"fmt"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+const endpointSliceResource = "endpointslices"
+
+// CheckAPIVersion returns an error if the member cluster does not serve the v1 EndpointSlices the controllers watch,
+// e.g. if it is older than Kubernetes 1.21 and only serves the end-of-life v1beta1 ones; the caches of such a member
+// cluster would never sync.
+func CheckAPIVersion(discoveryClient discovery.ServerResourcesInterface) error {
+	apiVersion := discoveryv1.SchemeGroupVersion.String()
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(apiVersion)
+	switch {
+	case errors.IsNotFound(err):
+		return fmt.Errorf("the member cluster does not serve the %s EndpointSlice API version; it is too old to export endpoint slices from", apiVersion)
+	case err != nil:
+		return fmt.Errorf("failed to discover the resources of %s: %w", apiVersion, err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == endpointSliceResource {
+			return nil
+		}
+	}
+	return fmt.Errorf("the member cluster does not serve the %s EndpointSlice API version; it is too old to export endpoint slices from", apiVersion)
+}
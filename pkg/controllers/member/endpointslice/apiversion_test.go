@@ -0,0 +1,75 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func endpointSliceResources(apiVersion string) *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: apiVersion,
+		APIResources: []metav1.APIResource{{Name: "endpointslices", Kind: "EndpointSlice", Namespaced: true}},
+	}
+}
+
+func TestCheckAPIVersion(t *testing.T) {
+	testCases := []struct {
+		name         string
+		resources    []*metav1.APIResourceList
+		discoveryErr error
+		wantErr      bool
+	}{
+		{
+			name:      "v1 and v1beta1 served",
+			resources: []*metav1.APIResourceList{endpointSliceResources("discovery.k8s.io/v1"), endpointSliceResources("discovery.k8s.io/v1beta1")},
+		},
+		{
+			name:      "only v1 served",
+			resources: []*metav1.APIResourceList{endpointSliceResources("discovery.k8s.io/v1")},
+		},
+		{
+			name:      "only end-of-life v1beta1 served",
+			resources: []*metav1.APIResourceList{endpointSliceResources("discovery.k8s.io/v1beta1")},
+			wantErr:   true,
+		},
+		{
+			name:      "group version served without endpoint slices",
+			resources: []*metav1.APIResourceList{{GroupVersion: "discovery.k8s.io/v1"}},
+			wantErr:   true,
+		},
+		{
+			name:    "no version served",
+			wantErr: true,
+		},
+		{
+			name:         "discovery failure",
+			resources:    []*metav1.APIResourceList{endpointSliceResources("discovery.k8s.io/v1")},
+			discoveryErr: errors.New("connection refused"),
+			wantErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			discoveryClient := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: tc.resources}}
+			if tc.discoveryErr != nil {
+				discoveryClient.PrependReactor("get", "resource", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tc.discoveryErr
+				})
+			}
+			if err := CheckAPIVersion(discoveryClient); (err != nil) != tc.wantErr {
+				t.Fatalf("CheckAPIVersion() = %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
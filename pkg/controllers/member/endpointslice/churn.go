@@ -0,0 +1,270 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	conditionReasonEndpointChurnAboveThreshold  = "EndpointChurnAboveThreshold"
+	conditionReasonEndpointChurnWithinThreshold = "EndpointChurnWithinThreshold"
+)
+
+// ChurnProtection configures how the exports of the Services whose endpoints change too often are coalesced, so that
+// the churn of one Service does not propagate to every member cluster importing it.
+type ChurnProtection struct {
+	// Threshold is the number of endpoint changes of a Service within the window above which the exports of its
+	// EndpointSlices are coalesced; churn protection is disabled if it is not positive.
+	Threshold int
+	// RecoveryThreshold is the number of endpoint changes of a Service within the window at or below which its
+	// EndpointSlices are exported as they change again.
+	RecoveryThreshold int
+	// Window is the period over which the endpoint changes of a Service are counted.
+	Window time.Duration
+	// CoalescingInterval is the interval at which each EndpointSlice of a Service whose exports are coalesced is
+	// exported.
+	CoalescingInterval time.Duration
+}
+
+// enabled returns whether churn protection is enabled.
+func (p ChurnProtection) enabled() bool {
+	return p.Threshold > 0 && p.Window > 0 && p.CoalescingInterval > 0
+}
+
+// churnTracker tracks the endpoint changes of the Services whose EndpointSlices are exported, and decides whether
+// their exports are coalesced.
+//
+// An endpoint change is a change of the generation of one of the EndpointSlices of a Service; the annotations the
+// controller sets on EndpointSlices do not change their generations. The tracker lives in memory only and keeps at
+// most Threshold+1 changes per Service; EndpointSlices first seen, e.g. after the controller restarts, do not count
+// as changes.
+type churnTracker struct {
+	mu         sync.Mutex
+	protection ChurnProtection
+	services   map[types.NamespacedName]*serviceChurn
+	// owners maps each tracked EndpointSlice to its Service.
+	owners map[types.NamespacedName]types.NamespacedName
+}
+
+// serviceChurn is the endpoint churn of a Service.
+type serviceChurn struct {
+	// changes are the times of the most recent endpoint changes, oldest first.
+	changes   []time.Time
+	coalesced bool
+	// endpointSlices are the EndpointSlices of the Service, keyed by name.
+	endpointSlices map[string]*endpointSliceChurn
+}
+
+// endpointSliceChurn is the generation an EndpointSlice was last seen at, and the time it was last exported.
+type endpointSliceChurn struct {
+	generation int64
+	lastExport time.Time
+}
+
+// newChurnTracker returns an empty tracker of endpoint churn.
+func newChurnTracker(protection ChurnProtection) *churnTracker {
+	return &churnTracker{
+		protection: protection,
+		services:   make(map[types.NamespacedName]*serviceChurn),
+		owners:     make(map[types.NamespacedName]types.NamespacedName),
+	}
+}
+
+// observe records the generation of an EndpointSlice of a Service, and returns whether the exports of the Service
+// are coalesced along with the number of endpoint changes of the Service within the window.
+//
+// The exports of a Service are coalesced once its changes within the window exceed the threshold, and until they
+// drop to the recovery threshold.
+func (t *churnTracker) observe(svcKey, endpointSliceKey types.NamespacedName, generation int64, now time.Time) (coalesced bool, changes int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if owner, ok := t.owners[endpointSliceKey]; ok && owner != svcKey {
+		// The EndpointSlice has been relabeled to another Service.
+		t.forgetLocked(endpointSliceKey)
+	}
+	t.owners[endpointSliceKey] = svcKey
+	svc, ok := t.services[svcKey]
+	if !ok {
+		svc = &serviceChurn{endpointSlices: make(map[string]*endpointSliceChurn)}
+		t.services[svcKey] = svc
+	}
+	switch endpointSlice, ok := svc.endpointSlices[endpointSliceKey.Name]; {
+	case !ok:
+		svc.endpointSlices[endpointSliceKey.Name] = &endpointSliceChurn{generation: generation}
+	case endpointSlice.generation != generation:
+		endpointSlice.generation = generation
+		svc.changes = append(svc.changes, now)
+	}
+
+	// Drop the changes which have left the window; only the latest Threshold+1 changes are needed to tell whether the
+	// threshold is exceeded.
+	start := 0
+	for start < len(svc.changes) && now.Sub(svc.changes[start]) >= t.protection.Window {
+		start++
+	}
+	if excess := len(svc.changes) - start - (t.protection.Threshold + 1); excess > 0 {
+		start += excess
+	}
+	svc.changes = append(svc.changes[:0], svc.changes[start:]...)
+
+	changes = len(svc.changes)
+	switch {
+	case !svc.coalesced && changes > t.protection.Threshold:
+		svc.coalesced = true
+	case svc.coalesced && changes <= t.protection.RecoveryThreshold:
+		svc.coalesced = false
+	}
+	return svc.coalesced, changes
+}
+
+// exportDelay returns the wait time until an EndpointSlice can be exported again; it is 0 unless the exports of its
+// Service are coalesced.
+func (t *churnTracker) exportDelay(endpointSliceKey types.NamespacedName, now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	svc, ok := t.services[t.owners[endpointSliceKey]]
+	if !ok || !svc.coalesced {
+		return 0
+	}
+	endpointSlice, ok := svc.endpointSlices[endpointSliceKey.Name]
+	if !ok || endpointSlice.lastExport.IsZero() {
+		return 0
+	}
+	if delay := endpointSlice.lastExport.Add(t.protection.CoalescingInterval).Sub(now); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// exported records that an EndpointSlice has been exported.
+func (t *churnTracker) exported(endpointSliceKey types.NamespacedName, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if svc, ok := t.services[t.owners[endpointSliceKey]]; ok {
+		if endpointSlice, ok := svc.endpointSlices[endpointSliceKey.Name]; ok {
+			endpointSlice.lastExport = now
+		}
+	}
+}
+
+// forget stops tracking an EndpointSlice, e.g. when it is deleted or unexported; a Service is no longer tracked once
+// none of its EndpointSlices is.
+func (t *churnTracker) forget(endpointSliceKey types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.forgetLocked(endpointSliceKey)
+}
+
+func (t *churnTracker) forgetLocked(endpointSliceKey types.NamespacedName) {
+	svcKey, ok := t.owners[endpointSliceKey]
+	if !ok {
+		return
+	}
+	delete(t.owners, endpointSliceKey)
+	svc := t.services[svcKey]
+	delete(svc.endpointSlices, endpointSliceKey.Name)
+	if len(svc.endpointSlices) == 0 {
+		delete(t.services, svcKey)
+	}
+}
+
+// endpointChurnTracker returns the tracker of endpoint churn.
+func (r *Reconciler) endpointChurnTracker() *churnTracker {
+	r.initChurnOnce.Do(func() {
+		if r.churn == nil {
+			r.churn = newChurnTracker(r.ChurnProtection)
+		}
+	})
+	return r.churn
+}
+
+// clock returns the clock of the controller.
+func (r *Reconciler) clock() clock.Clock {
+	if r.Clock == nil {
+		return clock.RealClock{}
+	}
+	return r.Clock
+}
+
+// protectAgainstChurn observes the endpoint churn of the Service of an EndpointSlice, and returns whether the exports
+// of the Service are coalesced along with the wait time until the EndpointSlice can be exported again.
+//
+// The HighEndpointChurn condition of the ServiceExport is set, and an event recorded, when the exports of the Service
+// start or stop being coalesced.
+func (r *Reconciler) protectAgainstChurn(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice) (bool, time.Duration, error) {
+	if !r.ChurnProtection.enabled() {
+		return false, 0, nil
+	}
+	now := r.clock().Now()
+	endpointSliceKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name}
+	svcKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Labels[discoveryv1.LabelServiceName]}
+	coalesced, changes := r.endpointChurnTracker().observe(svcKey, endpointSliceKey, endpointSlice.Generation, now)
+	if err := r.reportEndpointChurn(ctx, svcKey, coalesced, changes); err != nil {
+		return false, 0, err
+	}
+	return coalesced, r.endpointChurnTracker().exportDelay(endpointSliceKey, now), nil
+}
+
+// reportEndpointChurn sets the HighEndpointChurn condition of a ServiceExport when the exports of its Service start or
+// stop being coalesced; ServiceExports whose endpoints have never churned are left without the condition.
+func (r *Reconciler) reportEndpointChurn(ctx context.Context, svcKey types.NamespacedName, coalesced bool, changes int) error {
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, svcKey, svcExport); err != nil {
+		return err
+	}
+	condType := string(fleetnetv1alpha1.ServiceExportHighEndpointChurn)
+	cond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	wasCoalesced := cond != nil && cond.Status == metav1.ConditionTrue
+	if coalesced == wasCoalesced {
+		return nil
+	}
+
+	protection := r.ChurnProtection
+	desiredCond := metav1.Condition{
+		Type:               condType,
+		Status:             metav1.ConditionFalse,
+		Reason:             conditionReasonEndpointChurnWithinThreshold,
+		ObservedGeneration: svcExport.Generation,
+		Message: fmt.Sprintf("the endpoints of service %s changed %d times within %s; endpoint changes are exported as they happen",
+			svcKey, changes, protection.Window),
+	}
+	if coalesced {
+		desiredCond.Status = metav1.ConditionTrue
+		desiredCond.Reason = conditionReasonEndpointChurnAboveThreshold
+		desiredCond.Message = fmt.Sprintf("the endpoints of service %s changed %d times within %s, more than the threshold of %d; endpoint changes are exported every %s",
+			svcKey, changes, protection.Window, protection.Threshold, protection.CoalescingInterval)
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, desiredCond)
+	if err := r.MemberClient.Status().Update(ctx, svcExport); err != nil {
+		return err
+	}
+
+	klog.V(2).InfoS("The endpoint churn of the service has changed the way its endpoint slices are exported",
+		"serviceExport", klog.KObj(svcExport), "coalesced", coalesced, "changes", changes)
+	if coalesced {
+		r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "HighEndpointChurn",
+			"The endpoints of Service %s change too often; endpoint changes are exported every %s", svcExport.Name, protection.CoalescingInterval)
+	} else {
+		r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "EndpointChurnSubsided",
+			"The endpoint churn of Service %s has subsided; endpoint changes are exported as they happen", svcExport.Name)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// TestChurnTracker tests that the exports of a Service are coalesced once its endpoint changes within the window
+// exceed the threshold, and no longer once they drop to the recovery threshold.
+func TestChurnTracker(t *testing.T) {
+	svcKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	otherSvcKey := types.NamespacedName{Namespace: memberUserNS, Name: "other-app"}
+	tracker := newChurnTracker(ChurnProtection{
+		Threshold:          3,
+		RecoveryThreshold:  1,
+		Window:             time.Minute,
+		CoalescingInterval: 30 * time.Second,
+	})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	observe := func(generation int64, elapsed time.Duration, wantCoalesced bool, wantChanges int) {
+		t.Helper()
+		coalesced, changes := tracker.observe(svcKey, endpointSliceKey, generation, start.Add(elapsed))
+		if coalesced != wantCoalesced || changes != wantChanges {
+			t.Fatalf("observe(generation %d at +%s), got (%t, %d), want (%t, %d)", generation, elapsed, coalesced, changes, wantCoalesced, wantChanges)
+		}
+	}
+
+	// The EndpointSlice first seen does not count as a change, and neither does one seen at the same generation.
+	observe(1, 0, false, 0)
+	observe(1, time.Second, false, 0)
+	observe(2, time.Second, false, 1)
+	observe(3, 2*time.Second, false, 2)
+	observe(4, 3*time.Second, false, 3)
+	if got := tracker.exportDelay(endpointSliceKey, start.Add(3*time.Second)); got != 0 {
+		t.Fatalf("exportDelay() before coalescing, got %v, want 0", got)
+	}
+	tracker.exported(endpointSliceKey, start.Add(3*time.Second))
+
+	// The changes exceed the threshold; the EndpointSlice waits for the coalescing interval since its last export.
+	observe(5, 4*time.Second, true, 4)
+	if got, want := tracker.exportDelay(endpointSliceKey, start.Add(13*time.Second)), 20*time.Second; got != want {
+		t.Fatalf("exportDelay() while coalescing, got %v, want %v", got, want)
+	}
+	if got := tracker.exportDelay(endpointSliceKey, start.Add(33*time.Second)); got != 0 {
+		t.Fatalf("exportDelay() after the coalescing interval, got %v, want 0", got)
+	}
+	tracker.exported(endpointSliceKey, start.Add(33*time.Second))
+
+	// Only the latest Threshold+1 changes are kept.
+	observe(6, 5*time.Second, true, 4)
+	if got := len(tracker.services[svcKey].changes); got != 4 {
+		t.Fatalf("changes kept, got %d, want 4", got)
+	}
+
+	// The changes leave the window; the exports stay coalesced until the changes drop to the recovery threshold.
+	observe(6, 62*time.Second, true, 3)
+	observe(6, 63*time.Second, true, 2)
+	observe(6, 64*time.Second, false, 1)
+	if got := tracker.exportDelay(endpointSliceKey, start.Add(65*time.Second)); got != 0 {
+		t.Fatalf("exportDelay() after recovery, got %v, want 0", got)
+	}
+
+	// An EndpointSlice relabeled to another Service is no longer tracked for the Service it left.
+	if coalesced, changes := tracker.observe(otherSvcKey, endpointSliceKey, 7, start.Add(66*time.Second)); coalesced || changes != 0 {
+		t.Fatalf("observe() for another service, got (%t, %d), want (false, 0)", coalesced, changes)
+	}
+	if _, ok := tracker.services[svcKey]; ok {
+		t.Fatalf("services, got %v tracked, want it forgotten", svcKey)
+	}
+
+	tracker.forget(endpointSliceKey)
+	if len(tracker.services) != 0 || len(tracker.owners) != 0 {
+		t.Fatalf("tracker after forget(), got %d services and %d endpoint slices, want none", len(tracker.services), len(tracker.owners))
+	}
+}
+
+// TestReconcile_ChurnProtection tests that the exports of a Service whose endpoints churn are coalesced, with the
+// HighEndpointChurn condition and event raised on its ServiceExport, and that the Service returns to exporting every
+// change once its churn subsides.
+func TestReconcile_ChurnProtection(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "10.0.0.1")
+	svcExport.Annotations = nil
+	endpointSlice.Generation = 1
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Clock:           fakeClock,
+		Recorder:        recorder,
+		ChurnProtection: ChurnProtection{
+			Threshold:          2,
+			RecoveryThreshold:  0,
+			Window:             time.Minute,
+			CoalescingInterval: 30 * time.Second,
+		},
+	}
+	addEndpoint := func(addr string) {
+		t.Helper()
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("Get(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{addr}})
+		endpointSlice.Generation++
+		if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+			t.Fatalf("Update(), got %v, want no error", err)
+		}
+	}
+	checkChurnCondition := func(wantStatus metav1.ConditionStatus, wantEvent string) {
+		t.Helper()
+		got := &fleetnetv1alpha1.ServiceExport{}
+		if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, got); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		cond := meta.FindStatusCondition(got.Status.Conditions, string(fleetnetv1alpha1.ServiceExportHighEndpointChurn))
+		switch {
+		case wantStatus == "" && cond != nil:
+			t.Fatalf("HighEndpointChurn condition, got %+v, want none", cond)
+		case wantStatus != "" && (cond == nil || cond.Status != wantStatus):
+			t.Fatalf("HighEndpointChurn condition, got %+v, want status %s", cond, wantStatus)
+		}
+		select {
+		case event := <-recorder.Events:
+			if event != wantEvent {
+				t.Fatalf("event, got %q, want %q", event, wantEvent)
+			}
+		default:
+			if wantEvent != "" {
+				t.Fatalf("event, got none, want %q", wantEvent)
+			}
+		}
+	}
+
+	// Endpoint changes up to the threshold are exported as they happen.
+	addrs, requeueAfter := reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"10.0.0.1"}, addrs); diff != "" || requeueAfter != 0 {
+		t.Fatalf("exported addresses (-want, +got):\n%s\nrequeueAfter, got %v, want 0", diff, requeueAfter)
+	}
+	// Drain the event of the unique name assigned to the EndpointSlice.
+	<-recorder.Events
+	for i := 2; i <= 3; i++ {
+		addEndpoint(fmt.Sprintf("10.0.0.%d", i))
+		addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+		if len(addrs) != i || requeueAfter != 0 {
+			t.Fatalf("exported addresses, got %v, want %d addresses\nrequeueAfter, got %v, want 0", addrs, i, requeueAfter)
+		}
+	}
+	checkChurnCondition("", "")
+
+	// The change above the threshold is held back until the coalescing interval passes since the last export.
+	addEndpoint("10.0.0.4")
+	fakeClock.Step(10 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 3 || requeueAfter != 20*time.Second {
+		t.Fatalf("exported addresses, got %v, want 3 addresses\nrequeueAfter, got %v, want %v", addrs, requeueAfter, 20*time.Second)
+	}
+	checkChurnCondition(metav1.ConditionTrue, "Warning HighEndpointChurn The endpoints of Service app change too often; endpoint changes are exported every 30s")
+
+	// The changes held back are exported at the next interval; the EndpointSlice is requeued while the exports of the
+	// Service are coalesced.
+	fakeClock.Step(20 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 4 || requeueAfter != 30*time.Second {
+		t.Fatalf("exported addresses, got %v, want 4 addresses\nrequeueAfter, got %v, want %v", addrs, requeueAfter, 30*time.Second)
+	}
+	checkChurnCondition(metav1.ConditionTrue, "")
+
+	// The exports stay coalesced while the change observed last is still in the window.
+	fakeClock.Step(30 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 4 || requeueAfter != 30*time.Second {
+		t.Fatalf("exported addresses, got %v, want 4 addresses\nrequeueAfter, got %v, want %v", addrs, requeueAfter, 30*time.Second)
+	}
+	checkChurnCondition(metav1.ConditionTrue, "")
+
+	// The Service returns to exporting every change once all the changes leave the window.
+	fakeClock.Step(10 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 4 || requeueAfter != 0 {
+		t.Fatalf("exported addresses, got %v, want 4 addresses\nrequeueAfter, got %v, want 0", addrs, requeueAfter)
+	}
+	checkChurnCondition(metav1.ConditionFalse, "Normal EndpointChurnSubsided The endpoint churn of Service app has subsided; endpoint changes are exported as they happen")
+
+	addEndpoint("10.0.0.5")
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 5 || requeueAfter != 0 {
+		t.Fatalf("exported addresses, got %v, want 5 addresses\nrequeueAfter, got %v, want 0", addrs, requeueAfter)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// exportAnnotations are the annotations the controller adds to EndpointSlices when exporting them.
+var exportAnnotations = []string{
+	objectmeta.ExportedObjectAnnotationUniqueName,
+	metrics.MetricsAnnotationLastSeenGeneration,
+	metrics.MetricsAnnotationLastSeenTimestamp,
+}
+
+// Cleanup unexports all the EndpointSlices of the member cluster when the member cluster leaves the fleet; it
+// deletes the EndpointSliceExports created by the member cluster from the hub namespace, and removes the unique name
+// and the other export annotations from the EndpointSlices in the member cluster.
+//
+// EndpointSliceExports of other clusters are left untouched. Every step is idempotent, so an interrupted cleanup
+// can be resumed by calling Cleanup again.
+func (r *Reconciler) Cleanup(ctx context.Context) error {
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := r.HubClient.List(ctx, endpointSliceExportList, client.InNamespace(r.HubNamespace)); err != nil {
+		klog.ErrorS(err, "Failed to list endpoint slice exports", "hubNamespace", r.HubNamespace)
+		return err
+	}
+	deleted := 0
+	for i := range endpointSliceExportList.Items {
+		endpointSliceExport := &endpointSliceExportList.Items[i]
+		if endpointSliceExport.Spec.EndpointSliceReference.ClusterID != r.MemberClusterID {
+			continue
+		}
+		if err := r.HubClient.Delete(ctx, endpointSliceExport); err != nil && !errors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete endpoint slice export", "endpointSliceExport", klog.KObj(endpointSliceExport))
+			return err
+		}
+		deleted++
+	}
+
+	// The annotations are removed only after the EndpointSliceExports are deleted; the unique name annotation is the
+	// only link between an EndpointSlice and its EndpointSliceExport.
+	endpointSliceList := &discoveryv1.EndpointSliceList{}
+	if err := r.MemberClient.List(ctx, endpointSliceList); err != nil {
+		klog.ErrorS(err, "Failed to list endpoint slices")
+		return err
+	}
+	stripped := 0
+	for i := range endpointSliceList.Items {
+		endpointSlice := &endpointSliceList.Items[i]
+		needsUpdate := false
+		for _, key := range exportAnnotations {
+			if _, ok := endpointSlice.Annotations[key]; ok {
+				delete(endpointSlice.Annotations, key)
+				needsUpdate = true
+			}
+		}
+		if !needsUpdate {
+			continue
+		}
+		if err := r.MemberClient.Update(ctx, endpointSlice); err != nil && !errors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to strip the export annotations from endpoint slice", "endpointSlice", klog.KObj(endpointSlice))
+			return err
+		}
+		r.readyEndpointTracker().forget(types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name})
+		stripped++
+	}
+	exportedEndpointSliceTracker.Reset(r.MemberClusterID)
+	klog.V(2).InfoS("Cleanup of exported endpoint slices has been completed", "hubNamespace", r.HubNamespace,
+		"deletedEndpointSliceExports", deleted, "strippedEndpointSlices", stripped)
+	return nil
+}
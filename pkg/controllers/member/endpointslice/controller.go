@@ -9,27 +9,53 @@ package endpointslice
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"math/rand"
 	"strconv"
+	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/drain"
+	"go.goms.io/fleet-networking/pkg/common/eventdedup"
+	"go.goms.io/fleet-networking/pkg/common/hubschema"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+	fleetlabels "go.goms.io/fleet-networking/pkg/common/labels"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
-	"go.goms.io/fleet-networking/pkg/common/uniquename"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
+	"go.goms.io/fleet-networking/pkg/names"
+)
+
+const (
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "endpointslice-controller"
+
+	// maxUniqueNameConflictRetries is the number of times the assignment of a unique name to an EndpointSlice is
+	// retried right away when the EndpointSlice has changed since it was read.
+	maxUniqueNameConflictRetries = 3
 )
 
 // skipOrUnexportEndpointSliceOp describes the op the controller should take on an EndpointSlice, specifically
@@ -50,13 +76,117 @@ type Reconciler struct {
 	// The ID of the member cluster.
 	MemberClusterID string
 	MemberClient    client.Client
+	// MemberAPIReader reads the EndpointSlices of the member cluster bypassing the cache, e.g. the API reader of the
+	// manager, when the assignment of a unique name conflicts, as the cache may not have observed the latest version
+	// yet. MemberClient is used if it is not set.
+	MemberAPIReader client.Reader
 	HubClient       client.Client
 	// The namespace reserved for the current member cluster in the hub cluster.
 	HubNamespace string
+	// Recorder records the events of the EndpointSlices which are assigned unique names to export with, or whose
+	// endpoints are all left out by the endpoint selectors of their ServiceExports, and of the ServiceExports whose
+	// endpoint selectors cannot be honored.
+	Recorder record.EventRecorder
+
+	// CircuitBreaker stops the controller from reconciling EndpointSlices after repeated failures to write to the
+	// hub namespace, e.g. when the resource quota of the namespace is exhausted; circuits are keyed by the hub
+	// namespace. The circuit breaker is disabled if it is not set.
+	CircuitBreaker *circuitbreaker.CircuitBreaker
+
+	// RetryBudget is the budget shared by the controllers of the member cluster for retrying failed writes to the
+	// hub cluster; once it is depleted, the failed writes are retried after the delay it asks rather than with the
+	// regular backoff of the workqueue alone. Failed writes are retried with the regular backoff if it is not set.
+	RetryBudget *retrybudget.Budget
+
+	// RateLimiter is the rate limiter of the workqueue of the controller; the default one of the controller is used
+	// if it is not set.
+	RateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+
+	// MaxConcurrentReconciles is the maximum number of EndpointSlices the controller reconciles at the same time; an
+	// EndpointSlice is never reconciled by two workers at once. It defaults to 1 if it is not positive.
+	MaxConcurrentReconciles int
+
+	// DrainTimeout is the period the in-flight writes to the hub cluster are given to complete when the controller
+	// manager shuts down; the writes are cancelled right away if it is not set.
+	DrainTimeout time.Duration
+
+	// ExportNotReadyAddresses exports the endpoints of EndpointSlices regardless of their readiness, matching the
+	// publishNotReadyAddresses semantics of Services; only ready endpoints are exported if it is false.
+	ExportNotReadyAddresses bool
+
+	// EnableEndpointSelector honors the endpoint selectors of ServiceExports, for which the controller caches the
+	// metadata of all the Pods of the member cluster. If it is false, the Pods are not watched, and the ServiceExports
+	// which set an endpoint selector export no endpoint, as exporting all of them would defeat the selector.
+	EnableEndpointSelector bool
+
+	// InitialSyncPacer paces the first export of the EndpointSlices which exist when a cold starting member cluster
+	// joins the fleet; exports are not paced if it is not set.
+	InitialSyncPacer *initialsync.Pacer
+
+	// ChurnProtection coalesces the exports of the Services whose endpoints change too often; churn protection is
+	// disabled if its threshold is not set.
+	ChurnProtection ChurnProtection
+
+	// HubSchemaChecker learns the schemas of the hub CRDs; the EndpointSlices whose exports the hub cluster rejects for
+	// their fields are requeued for when the hub CRDs are checked again.
+	HubSchemaChecker *hubschema.Checker
+
+	// RequeueJitter is the upper bound of the random delay added when the controller requeues an EndpointSlice for a
+	// later time, i.e. while the circuit for the hub namespace is open, the exports are paused or its fields are
+	// rejected by the hub CRDs, so that the EndpointSlices skipped at the same time are not retried at the same time
+	// either. Failed writes to the hub cluster are returned as errors and retried with the backoff of the workqueue
+	// instead. Requeues are not jittered if it is not positive.
+	RequeueJitter time.Duration
+
+	// ResourcePressureMonitor pauses the exports of EndpointSlices while the member cluster is under resource
+	// pressure; the EndpointSlices are requeued for the next check, while unexports, and exports which only remove or
+	// update the endpoints exported last, go on. The exports are never paused if it is not set.
+	ResourcePressureMonitor *ResourcePressureMonitor
+
+	// Clock is the clock against which endpoints soak for progressive export and endpoint churn is measured, and by
+	// which EndpointSlices are annotated with the time of their last export; the real clock is used if it is not set.
+	Clock clock.Clock
+
+	// inFlightWrites tracks the writes to the hub cluster, so that they are drained on shutdown.
+	inFlightWrites *drain.Tracker
+
+	// hubWriteRetries is the rate limiter of the workqueue, which delays the retries of the failed writes to the hub
+	// cluster as per the retry budget.
+	hubWriteRetries         *retrybudget.RateLimiter
+	initHubWriteRetriesOnce sync.Once
+
+	// readyEndpoints tracks when the endpoints of exported EndpointSlices became ready, for progressive export.
+	readyEndpoints         *readyEndpointTracker
+	initReadyEndpointsOnce sync.Once
+
+	// lastExportedEndpoints keeps the hashes of the endpoints last exported for each EndpointSlice, so that updates
+	// to EndpointSliceExports only carry the endpoints that have changed.
+	lastExportedEndpoints         *exportedEndpointCache
+	initLastExportedEndpointsOnce sync.Once
+
+	// annotatedVersions keeps the resource versions EndpointSlices are left at by the last export time annotation,
+	// so that the annotation does not make their EndpointSliceExports look stale.
+	annotatedVersions         *annotatedVersionTracker
+	initAnnotatedVersionsOnce sync.Once
+
+	// churn tracks the endpoint changes of the exported Services, so that the exports of the Services whose endpoints
+	// churn are coalesced.
+	churn         *churnTracker
+	initChurnOnce sync.Once
+
+	// skipLogs rate limits the logs of the EndpointSlices which are skipped for reconciliation on every resync.
+	skipLogs         *skipLogLimiter
+	initSkipLogsOnce sync.Once
+
+	// events records the events of the endpoint selectors only when they change.
+	events         *eventdedup.Recorder
+	initEventsOnce sync.Once
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceexports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 
 // Reconcile exports an EndpointSlice.
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -68,6 +198,23 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		klog.V(2).InfoS("Reconciliation ends", "endpointSlice", endpointSliceRef, "latency", latency)
 	}()
 
+	// Skip the reconciliation while the circuit for the hub namespace is open.
+	if r.CircuitBreaker != nil {
+		allowed, isTrial, retryAfter := r.CircuitBreaker.AllowTrial(r.HubNamespace)
+		if !allowed {
+			klog.V(2).InfoS("Circuit breaker for the hub namespace is open; skip reconciling",
+				"endpointSlice", endpointSliceRef,
+				"hubNamespace", r.HubNamespace,
+				"retryAfter", retryAfter)
+			return ctrl.Result{RequeueAfter: r.withJitter(retryAfter)}, nil
+		}
+		if isTrial {
+			// If the trial reconciliation does not write to the hub namespace, the circuit is still half-open
+			// when it ends; let the next one try instead.
+			defer r.CircuitBreaker.ReleaseTrial(r.HubNamespace)
+		}
+	}
+
 	// Retrieve the EndpointSlice object.
 	var endpointSlice discoveryv1.EndpointSlice
 	if err := r.MemberClient.Get(ctx, req.NamespacedName, &endpointSlice); err != nil {
@@ -76,9 +223,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		// been exported to the fleet, no action is required on this controller's end; on the other hand, if the
 		// EndpointSlice has been exported before, this may result in an EndpointSlice being left over on the
 		// hub cluster, and it is up to another controller, EndpointSliceExport controller, to pick up the leftover
-		// and clean it out.
+		// and clean it out; should the member agent be gone as well, the hub agent expires the leftover once the
+		// EndpointSliceExport controller stops refreshing its last seen time.
 		if errors.IsNotFound(err) {
 			klog.V(4).InfoS("Ignoring NotFound endpointSlice", "endpointSlice", endpointSliceRef)
+			r.readyEndpointTracker().forget(req.NamespacedName)
+			r.lastExportedEndpointCache().forget(req.NamespacedName)
+			r.annotatedVersionTracker().forget(req.NamespacedName)
+			r.endpointChurnTracker().forget(req.NamespacedName)
+			r.skipLogLimiter().forget(req.NamespacedName)
+			r.eventRecorder().Forget(req.NamespacedName)
+			exportedEndpointSliceTracker.Remove(r.MemberClusterID, req.NamespacedName)
+			r.InitialSyncPacer.Forget(initialsync.KindEndpointSlice, req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		klog.ErrorS(err, "Failed to get endpoint slice", "endpointSlice", endpointSliceRef)
@@ -86,7 +242,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 
 	// Check if the EndpointSlice should be skipped for reconciliation or unexported.
-	skipOrUnexportOp, err := r.shouldSkipOrUnexportEndpointSlice(ctx, &endpointSlice)
+	skipOrUnexportOp, svcExport, err := r.shouldSkipOrUnexportEndpointSlice(ctx, &endpointSlice)
 	if err != nil {
 		// An unexpected error occurs.
 		klog.ErrorS(err,
@@ -97,18 +253,39 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	switch skipOrUnexportOp {
 	case shouldSkipEndpointSliceOp:
-		// Skip reconciling the EndpointSlice.
-		klog.V(4).InfoS("Endpoint slice should be skipped for reconciliation", "endpointSlice", endpointSliceRef)
+		// Skip reconciling the EndpointSlice; an unchanged EndpointSlice is skipped again on every resync, and its
+		// repeated skips are summarized instead.
+		if log, suppressed := r.skipLogLimiter().allow(req.NamespacedName, endpointSlice.ResourceVersion, r.clock().Now()); log {
+			klog.V(4).InfoS("Endpoint slice should be skipped for reconciliation",
+				"endpointSlice", endpointSliceRef,
+				"suppressedSkips", suppressed)
+		}
 		return ctrl.Result{}, nil
 	case shouldUnexportEndpointSliceOp:
 		// Unexport the EndpointSlice.
 		klog.V(4).InfoS("Endpoint slice should be unexported", "endpointSlice", endpointSliceRef)
+		r.skipLogLimiter().forget(req.NamespacedName)
+		r.readyEndpointTracker().forget(req.NamespacedName)
+		r.lastExportedEndpointCache().forget(req.NamespacedName)
+		r.annotatedVersionTracker().forget(req.NamespacedName)
+		r.endpointChurnTracker().forget(req.NamespacedName)
+		r.eventRecorder().Forget(req.NamespacedName)
 		if err := r.unexportEndpointSlice(ctx, &endpointSlice); err != nil {
 			klog.ErrorS(err, "Failed to unexport the endpoint slice", "endpointSlice", endpointSliceRef)
-			return ctrl.Result{}, err
+			return ctrl.Result{}, r.hubWriteRetryLimiter().Failed(req, err)
 		}
 		return ctrl.Result{}, nil
 	}
+	// The EndpointSlice is no longer skipped.
+	r.skipLogLimiter().forget(req.NamespacedName)
+
+	// Pause the export while the member cluster is under resource pressure, unless it can only remove or update the
+	// endpoints exported last, so that the endpoints which are gone stop receiving traffic from the fleet.
+	if r.ResourcePressureMonitor.IsPaused() && !r.lastExportedEndpointCache().covers(req.NamespacedName, &endpointSlice) {
+		klog.V(2).InfoS("The member cluster is under resource pressure; the export of the endpoint slice is paused",
+			"endpointSlice", endpointSliceRef)
+		return ctrl.Result{RequeueAfter: r.withJitter(r.ResourcePressureMonitor.CheckInterval())}, nil
+	}
 
 	// Retrieve the unique name assigned; if none has been assigned, or the one assigned is not valid, possibly due
 	// to user tampering with the annotation, assign a new unique name.
@@ -119,10 +296,28 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		var err error
 		// Unique name annotation must be added before an EndpointSlice is exported.
 		fleetUniqueName, err = r.assignUniqueNameAsAnnotation(ctx, &endpointSlice)
+		if errors.IsNotFound(err) {
+			// The EndpointSlice has been deleted since it was retrieved; this is common for short-lived
+			// EndpointSlices and no further action is needed.
+			klog.V(2).InfoS("Endpoint slice is gone before a unique name can be assigned; skip exporting", "endpointSlice", endpointSliceRef)
+			return ctrl.Result{}, nil
+		}
 		if err != nil {
 			klog.ErrorS(err, "Failed to assign unique name as an annotation", "endpointSlice", endpointSliceRef)
 			return ctrl.Result{}, err
 		}
+
+		// Short-lived EndpointSlices might be deleted right after the unique name is assigned; stop here if so,
+		// as the deletion will trigger another reconciliation, which unexports the EndpointSlice.
+		isDeleted, err := r.isEndpointSliceDeleted(ctx, &endpointSlice)
+		if err != nil {
+			klog.ErrorS(err, "Failed to check whether the endpoint slice has been deleted", "endpointSlice", endpointSliceRef)
+			return ctrl.Result{}, err
+		}
+		if isDeleted {
+			klog.V(2).InfoS("Endpoint slice has been deleted or is being deleted after a unique name is assigned; skip exporting", "endpointSlice", endpointSliceRef)
+			return ctrl.Result{}, nil
+		}
 	}
 
 	// Retrieve the last seen generation and the last seen timestamp; these two values are used for metric collection.
@@ -130,13 +325,51 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	//
 	// Note that the two values are not tamperproof.
 	exportedSince, err := r.collectAndVerifyLastSeenGenerationAndTimestamp(ctx, &endpointSlice, startTime)
-	if err != nil {
+	switch {
+	case errors.IsNotFound(err):
+		klog.V(2).InfoS("Endpoint slice is gone before it can be exported; skip exporting", "endpointSlice", endpointSliceRef)
+		return ctrl.Result{}, nil
+	case err != nil:
 		klog.Warning("Failed to annotate last seen generation and timestamp", "endpointSlice", endpointSliceRef)
 	}
 
+	// Check again right before exporting so that no EndpointSliceExport is created for an EndpointSlice that is
+	// already gone.
+	isDeleted, err := r.isEndpointSliceDeleted(ctx, &endpointSlice)
+	if err != nil {
+		klog.ErrorS(err, "Failed to check whether the endpoint slice has been deleted", "endpointSlice", endpointSliceRef)
+		return ctrl.Result{}, err
+	}
+	if isDeleted {
+		klog.V(2).InfoS("Endpoint slice has been deleted or is being deleted; skip exporting", "endpointSlice", endpointSliceRef)
+		return ctrl.Result{}, nil
+	}
+
+	// Wait for the turn of the EndpointSlice if the initial export of the member cluster is paced; unexports are
+	// never delayed.
+	if delay := r.InitialSyncPacer.Delay(initialsync.KindEndpointSlice, req.NamespacedName); delay > 0 {
+		klog.V(2).InfoS("The initial export of the member cluster is paced; wait for the turn of the endpoint slice", "endpointSlice", endpointSliceRef, "delay", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	// Hold the EndpointSlice back while the endpoints of its Service churn and their exports are coalesced.
+	coalesced, delay, err := r.protectAgainstChurn(ctx, &endpointSlice)
+	if err != nil {
+		klog.ErrorS(err, "Failed to observe the endpoint churn of the service", "endpointSlice", endpointSliceRef)
+		return ctrl.Result{}, err
+	}
+	if delay > 0 {
+		klog.V(2).InfoS("The exports of the service are coalesced for its endpoint churn; wait for the next export of the endpoint slice", "endpointSlice", endpointSliceRef, "delay", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
 	// Create an EndpointSliceExport in the hub cluster if the EndpointSlice has never been exported; otherwise
 	// update the corresponding EndpointSliceExport.
-	extractedEndpoints := extractEndpointsFromEndpointSlice(&endpointSlice)
+	extractedEndpoints, requeueAfter, err := r.progressivelyExportedEndpoints(ctx, &endpointSlice, svcExport)
+	if err != nil {
+		klog.ErrorS(err, "Failed to select the endpoints to export progressively", "endpointSlice", endpointSliceRef)
+		return ctrl.Result{}, err
+	}
 	endpointSliceExport := fleetnetv1alpha1.EndpointSliceExport{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: r.HubNamespace,
@@ -146,7 +379,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	klog.V(2).InfoS("Endpoint slice will be exported",
 		"endpointSlice", endpointSliceRef,
 		"endpointSliceExport", klog.KObj(&endpointSliceExport))
-	createOrUpdateOp, err := controllerutil.CreateOrUpdate(ctx, r.HubClient, &endpointSliceExport, func() error {
+	createOrUpdateOp, err := r.createOrPatchEndpointSliceExport(ctx, &endpointSlice, extractedEndpoints, &endpointSliceExport, func() error {
 		// Set up an EndpointSliceReference and only when an EndpointSliceExport is first created; this is because
 		// most fields in EndpointSliceReference should be immutable after creation.
 		if endpointSliceExport.CreationTimestamp.IsZero() {
@@ -165,7 +398,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			)
 		}
 
-		endpointSliceExport.Spec.AddressType = discoveryv1.AddressTypeIPv4
+		endpointSliceExport.Spec.AddressType = endpointSlice.AddressType
 		endpointSliceExport.Spec.Endpoints = extractedEndpoints
 		endpointSliceExport.Spec.Ports = endpointSlice.Ports
 		endpointSliceExport.Spec.OwnerServiceReference = fleetnetv1alpha1.OwnerServiceReference{
@@ -176,7 +409,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 
 		endpointSliceExport.Spec.EndpointSliceReference.UpdateFromMetaObject(endpointSlice.ObjectMeta, metav1.NewTime(exportedSince))
-		return nil
+		// Never write a malformed EndpointSliceReference to the hub cluster.
+		return fleetnetv1alpha1.ValidateExportedObjectReference(&endpointSliceExport.Spec.EndpointSliceReference,
+			field.NewPath("spec", "endpointSliceReference"))
 	})
 	switch {
 	case errors.IsAlreadyExists(err):
@@ -188,28 +423,71 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
+	case len(hubschema.RejectedFields(err)) > 0:
+		// The hub cluster rejects fields of the EndpointSliceExport; retrying will not help until the fields the hub
+		// CRDs accept are learnt again at the next check of the hub schema.
+		rejectedFields := hubschema.RejectedFields(err)
+		klog.ErrorS(err, "The hub cluster rejected fields of the endpointslice export",
+			"endpointSlice", endpointSliceRef,
+			"endpointSliceExport", klog.KObj(&endpointSliceExport),
+			"fields", rejectedFields)
+		if err := r.reportHubFieldRejection(ctx, &endpointSlice, rejectedFields); err != nil {
+			klog.ErrorS(err, "Failed to report the fields rejected by the hub cluster on the service export", "endpointSlice", endpointSliceRef)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.withJitter(r.HubSchemaChecker.CheckInterval())}, nil
 	case err != nil:
 		klog.ErrorS(err,
 			"Failed to create/update endpointslice export",
 			"endpointSlice", endpointSliceRef,
 			"endpointSliceExport", klog.KObj(&endpointSliceExport),
 			"op", createOrUpdateOp)
+		return ctrl.Result{}, r.hubWriteRetryLimiter().Failed(req, err)
+	}
+	if err := r.reportHubFieldRejection(ctx, &endpointSlice, nil); err != nil {
+		klog.ErrorS(err, "Failed to clear the fields rejected by the hub cluster from the service export", "endpointSlice", endpointSliceRef)
 		return ctrl.Result{}, err
 	}
+	exportedEndpointSliceTracker.Add(r.MemberClusterID, req.NamespacedName)
+	if r.ChurnProtection.enabled() {
+		r.endpointChurnTracker().exported(req.NamespacedName, r.clock().Now())
+	}
 
-	return ctrl.Result{}, nil
+	// Annotate the EndpointSlice with the time of the export only when the EndpointSliceExport has been written;
+	// the annotation would otherwise change on every reconciliation and trigger yet another one.
+	if createOrUpdateOp != controllerutil.OperationResultNone {
+		if err := r.annotateLastExportTime(ctx, &endpointSlice); err != nil && !errors.IsNotFound(err) {
+			// The annotation is informational; failing to set it does not fail the export.
+			klog.ErrorS(err, "Failed to annotate the endpoint slice with the last export time", "endpointSlice", endpointSliceRef)
+		}
+	}
+
+	// Requeue the EndpointSlice of a Service whose exports are coalesced at the next interval, so that the changes
+	// held back in the meantime are exported and the Service returns to exporting every change once its churn
+	// subsides.
+	if coalesced && (requeueAfter == 0 || requeueAfter > r.ChurnProtection.CoalescingInterval) {
+		requeueAfter = r.ChurnProtection.CoalescingInterval
+	}
+	// Requeue the EndpointSlice when the next endpoint held back for progressive export has soaked.
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the EndpointSlice controller with a controller manager.
 func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	if r.DrainTimeout > 0 {
+		// The tracker runs with the manager, which waits for it to drain the in-flight writes before it exits.
+		r.inFlightWrites = drain.New(ControllerName, r.DrainTimeout)
+		if err := mgr.Add(r.inFlightWrites); err != nil {
+			return err
+		}
+	}
+
 	// Enqueue EndpointSlices for processing when a ServiceExport changes.
 	eventHandlers := handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
 		endpointSliceList := &discoveryv1.EndpointSliceList{}
 		listOpts := client.ListOptions{
-			LabelSelector: labels.SelectorFromSet(labels.Set{
-				discoveryv1.LabelServiceName: o.GetName(),
-			}),
-			Namespace: o.GetNamespace(),
+			LabelSelector: fleetlabels.FleetLabelSelector(discoveryv1.LabelServiceName, o.GetName()),
+			Namespace:     o.GetNamespace(),
 		}
 		if err := r.MemberClient.List(ctx, endpointSliceList, &listOpts); err != nil {
 			klog.ErrorS(err,
@@ -227,10 +505,18 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) err
 		return reqs
 	})
 
-	// EndpointSlice controller watches over EndpointSlice and ServiceExport objects.
-	return ctrl.NewControllerManagedBy(mgr).
+	// EndpointSlice controller watches over EndpointSlice and ServiceExport objects; with the endpoint selectors
+	// enabled, it watches the labels of Pods as well, which decide the endpoints exported for Services exported with
+	// an endpoint selector, and only the metadata of Pods is cached.
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&discoveryv1.EndpointSlice{}).
-		Watches(&fleetnetv1alpha1.ServiceExport{}, eventHandlers).
+		Watches(&fleetnetv1alpha1.ServiceExport{}, eventHandlers)
+	if r.EnableEndpointSelector {
+		b = b.WatchesMetadata(&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(r.endpointSlicesForPod),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}))
+	}
+	return b.WithOptions(controller.Options{RateLimiter: r.hubWriteRetryLimiter(), MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
@@ -250,16 +536,19 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) err
 // * not exportable; or
 // * not owned by a successfully exported Service
 // should never be reconciled with this controller.
+//
+// The ServiceExport of the owner Service is returned as well when the EndpointSlice should be further processed, so
+// that it is not read again.
 func (r *Reconciler) shouldSkipOrUnexportEndpointSlice(ctx context.Context,
-	endpointSlice *discoveryv1.EndpointSlice) (skipOrUnexportEndpointSliceOp, error) {
+	endpointSlice *discoveryv1.EndpointSlice) (skipOrUnexportEndpointSliceOp, *fleetnetv1alpha1.ServiceExport, error) {
 	// Skip the reconciliation if the EndpointSlice is not permanently exportable.
 	if isEndpointSlicePermanentlyUnexportable(endpointSlice) {
-		return shouldSkipEndpointSliceOp, nil
+		return shouldSkipEndpointSliceOp, nil, nil
 	}
 
 	// If the Service name label is absent, the EndpointSlice is not in use by a Service and thus cannot
 	// be exported.
-	svcName, hasSvcNameLabel := endpointSlice.Labels[discoveryv1.LabelServiceName]
+	svcName, hasSvcNameLabel := fleetlabels.GetFleetLabel(endpointSlice, discoveryv1.LabelServiceName)
 	// It is guaranteed that if there is no unique name assigned to an EndpointSlice as an annotation, no attempt has
 	// been made to export an EndpointSlice.
 	_, hasUniqueNameAnnotation := endpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]
@@ -268,11 +557,11 @@ func (r *Reconciler) shouldSkipOrUnexportEndpointSlice(ctx context.Context,
 		if !hasUniqueNameAnnotation {
 			// The Service is not in use by a Service and does not have a unique name annotation (i.e. it has not been
 			// exported before); it should be skipped for further processing.
-			return shouldSkipEndpointSliceOp, nil
+			return shouldSkipEndpointSliceOp, nil, nil
 		}
 		// The Service is not in use by a Service but has a unique name annotation (i.e. it might have been exported);
 		// this could happen on an orphaned exported EndpointSlice, which should be unexported.
-		return shouldUnexportEndpointSliceOp, nil
+		return shouldUnexportEndpointSliceOp, nil, nil
 	}
 
 	// Retrieve the Service Export.
@@ -282,14 +571,14 @@ func (r *Reconciler) shouldSkipOrUnexportEndpointSlice(ctx context.Context,
 	case errors.IsNotFound(err) && hasUniqueNameAnnotation:
 		// The Service using the EndpointSlice is not exported but the EndpointSlice has a unique name annotation
 		// present (i.e. it might have been exported); the EndpointSlice should be unexported.
-		return shouldUnexportEndpointSliceOp, nil
+		return shouldUnexportEndpointSliceOp, nil, nil
 	case errors.IsNotFound(err) && !hasUniqueNameAnnotation:
 		// The Service using the EndpointSlice is not exported and the EndpointSlice has no unique name annotation
 		// present (i.e. it has not been exported before); the EndpointSlice should be skipped for further processing.
-		return shouldSkipEndpointSliceOp, nil
+		return shouldSkipEndpointSliceOp, nil, nil
 	case err != nil:
 		// An unexpected error has occurred.
-		return continueReconcileOp, err
+		return continueReconcileOp, nil, err
 	}
 
 	// Check if the ServiceExport is valid with no conflicts.
@@ -298,12 +587,12 @@ func (r *Reconciler) shouldSkipOrUnexportEndpointSlice(ctx context.Context,
 			// The Service using the EndpointSlice is not valid for export or has conflicts with other exported
 			// Services, but the EndpointSlice has a unique name annotation present (i.e. it might have been
 			// exported before); the EndpointSlice should be unexported.
-			return shouldUnexportEndpointSliceOp, nil
+			return shouldUnexportEndpointSliceOp, nil, nil
 		}
 		// The Service using the EndpointSlice is not valid for export or has conflicts with other exported
 		// Services, and the EndpointSlice has no unique name annoation present (i.e. it has not been
 		// exported before); the EndpointSlice should be skipped for further processing.
-		return shouldSkipEndpointSliceOp, nil
+		return shouldSkipEndpointSliceOp, nil, nil
 	}
 
 	if endpointSlice.DeletionTimestamp != nil {
@@ -311,17 +600,17 @@ func (r *Reconciler) shouldSkipOrUnexportEndpointSlice(ctx context.Context,
 			// The Service using the EndpointSlice is exported with no conflicts, and the EndpointSlice has a unique
 			// name annotation (i.e. it might have been exported), but it has been deleted; as a result,
 			// the EndpointSlice should be unexported.
-			return shouldUnexportEndpointSliceOp, nil
+			return shouldUnexportEndpointSliceOp, nil, nil
 		}
 		// The Service using the EndpointSlice is exported with no conflicts, but the EndpointSlice does not have a
 		// unique name annotation (i.e. it has not been exported), and it has been deleted; as a result,
 		// the EndpointSlice should be skipped.
-		return shouldSkipEndpointSliceOp, nil
+		return shouldSkipEndpointSliceOp, nil, nil
 	}
 
 	// The Service using the EndpointSlice is exported with no conflicts, and the EndpointSlice is not marked
 	// for deletion; the EndpointSlice should be further processed.
-	return continueReconcileOp, nil
+	return continueReconcileOp, svcExport, nil
 }
 
 // unexportEndpointSlice unexports an EndpointSlice by deleting its corresponding EndpointSliceExport.
@@ -330,6 +619,7 @@ func (r *Reconciler) unexportEndpointSlice(ctx context.Context, endpointSlice *d
 	if err := r.deleteEndpointSliceExportIfLinked(ctx, endpointSlice); err != nil {
 		return err
 	}
+	exportedEndpointSliceTracker.Remove(r.MemberClusterID, types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name})
 
 	// Remove the last seen annotations; this must happen after the EndpointSliceExport has been deleted.
 	delete(endpointSlice.Annotations, metrics.MetricsAnnotationLastSeenGeneration)
@@ -380,33 +670,112 @@ func (r *Reconciler) deleteEndpointSliceExportIfLinked(ctx context.Context, endp
 		return nil
 	}
 
-	if err := r.HubClient.Delete(ctx, &endpointSliceExport); err != nil && !errors.IsNotFound(err) {
+	err = r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+		return r.HubClient.Delete(ctx, &endpointSliceExport)
+	})
+	r.recordHubWriteResult(err)
+	if err != nil && !errors.IsNotFound(err) {
 		// An unexpected error has occurred.
 		return err
 	}
 	return nil
 }
 
+// recordHubWriteResult records the result of a write to the hub namespace in the circuit breaker. Errors caused
+// by the state of a specific object, e.g. NotFound, AlreadyExists and Conflict, say nothing about the namespace
+// and count as successful writes; writes cancelled with the context, e.g. on shutdown, are not recorded at all.
+func (r *Reconciler) recordHubWriteResult(err error) {
+	if r.CircuitBreaker == nil || goerrors.Is(err, context.Canceled) {
+		return
+	}
+	// The hub cluster is healthy if it rejects the fields of an export; opening the circuit would hold back the
+	// exports it accepts.
+	if err == nil || errors.IsNotFound(err) || errors.IsAlreadyExists(err) || errors.IsConflict(err) || len(hubschema.RejectedFields(err)) > 0 {
+		r.CircuitBreaker.RecordSuccess(r.HubNamespace)
+		return
+	}
+	if r.CircuitBreaker.RecordFailure(r.HubNamespace) {
+		klog.ErrorS(err, "CRITICAL: writes to the hub namespace keep failing; circuit breaker opened and endpoint slices will not be exported until it closes",
+			"hubNamespace", r.HubNamespace,
+			"coolDown", r.CircuitBreaker.CoolDown())
+	}
+}
+
+// withJitter adds a random delay of up to the requeue jitter to a requeue delay.
+func (r *Reconciler) withJitter(delay time.Duration) time.Duration {
+	if r.RequeueJitter <= 0 {
+		return delay
+	}
+	return delay + time.Duration(rand.Int63n(int64(r.RequeueJitter)))
+}
+
+// isEndpointSliceDeleted returns whether an EndpointSlice has been deleted or is being deleted.
+func (r *Reconciler) isEndpointSliceDeleted(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice) (bool, error) {
+	if endpointSlice.DeletionTimestamp != nil {
+		return true, nil
+	}
+	latest := &discoveryv1.EndpointSlice{}
+	err := r.MemberClient.Get(ctx, types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name}, latest)
+	switch {
+	case errors.IsNotFound(err):
+		return true, nil
+	case err != nil:
+		return false, err
+	}
+	// A different UID means that the EndpointSlice has been deleted and re-created with the same name.
+	return latest.DeletionTimestamp != nil || latest.UID != endpointSlice.UID, nil
+}
+
+// memberAPIReader returns the reader of the EndpointSlices of the member cluster which bypasses the cache.
+func (r *Reconciler) memberAPIReader() client.Reader {
+	if r.MemberAPIReader == nil {
+		return r.MemberClient
+	}
+	return r.MemberAPIReader
+}
+
 // assignUniqueNameAsAnnotation assigns a new unique name as an annotation.
+//
+// The update carries the resource version the EndpointSlice was read at, so it fails with a conflict if the
+// EndpointSlice has changed since, e.g. if another reconciliation has assigned a unique name which the cache has yet
+// to observe. On a conflict, the EndpointSlice is read again from the API server and the update retried right away,
+// up to maxUniqueNameConflictRetries times, rather than requeued; no new name is assigned if the EndpointSlice read
+// again already has a valid one.
 func (r *Reconciler) assignUniqueNameAsAnnotation(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice) (string, error) {
-	fleetUniqueName, err := uniquename.FleetScopedUniqueName(uniquename.DNS1123Subdomain,
-		r.MemberClusterID,
-		endpointSlice.Namespace,
-		endpointSlice.Name)
-	if err != nil {
-		// Fall back to use a random lower case alphabetic string as the unique name. Normally this branch should
-		// never run.
-		klog.ErrorS(err, "Failed to generate a unique name; fall back to random lower case alphabetic strings",
-			"endpointSlice", klog.KObj(endpointSlice))
-		fleetUniqueName = uniquename.RandomLowerCaseAlphabeticString(25)
+	endpointSliceKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name}
+	for retries := 0; ; retries++ {
+		fleetUniqueName, err := r.updateUniqueNameAnnotation(ctx, endpointSlice)
+		if !errors.IsConflict(err) || retries >= maxUniqueNameConflictRetries {
+			return fleetUniqueName, err
+		}
+		klog.V(2).InfoS("The endpoint slice has changed since it was read; retrying to assign a unique name",
+			"endpointSlice", klog.KObj(endpointSlice), "retries", retries+1)
+		if err := r.memberAPIReader().Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			return "", err
+		}
+		if assigned, ok := endpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]; ok && isUniqueNameValid(assigned) {
+			return assigned, nil
+		}
 	}
+}
+
+// updateUniqueNameAnnotation updates an EndpointSlice with a new unique name as an annotation.
+func (r *Reconciler) updateUniqueNameAnnotation(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice) (string, error) {
+	fleetUniqueName := names.FormatFleetUniqueName(r.MemberClusterID, endpointSlice)
 
 	// Initialize the annotations field if no annotations are present.
 	if endpointSlice.Annotations == nil {
 		endpointSlice.Annotations = map[string]string{}
 	}
 	endpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName] = fleetUniqueName
-	return fleetUniqueName, r.MemberClient.Update(ctx, endpointSlice)
+	if err := r.MemberClient.Update(ctx, endpointSlice); err != nil {
+		return fleetUniqueName, err
+	}
+	// The event lets operators trace an EndpointSlice to its EndpointSliceExport in the hub cluster, which is named
+	// after the unique name.
+	r.Recorder.Eventf(endpointSlice, corev1.EventTypeNormal, "UniqueNameAssigned",
+		"Endpoint slice %s is exported as EndpointSliceExport %s/%s", endpointSlice.Name, r.HubNamespace, fleetUniqueName)
+	return fleetUniqueName, nil
 }
 
 // collectAndVerifyLastSeenGenerationAndTime collects and verifies the last seen generation and timestamp annotations
@@ -439,3 +808,221 @@ func (r *Reconciler) annotateLastSeenGenerationAndTimestamp(ctx context.Context,
 	endpointSlice.Annotations[metrics.MetricsAnnotationLastSeenTimestamp] = startTime.Format(metrics.MetricsLastSeenTimestampFormat)
 	return r.MemberClient.Update(ctx, endpointSlice)
 }
+
+// createOrPatchEndpointSliceExport creates an EndpointSliceExport in the hub cluster if it does not exist, or
+// updates it otherwise, after mutating it with the given function, in the same fashion as
+// controllerutil.CreateOrUpdate.
+//
+// Existing EndpointSliceExports are updated with a JSON patch carrying only the endpoints that have been added,
+// changed or removed, so that a small change to a large EndpointSlice does not send all of its endpoints to the hub
+// cluster again; the whole object is updated if the patch would not be smaller.
+//
+// No write is made at all if the EndpointSliceExport already references the current resource version of the
+// EndpointSlice and carries the endpoints to export; the endpoints are compared as well, since progressive export and
+// endpoint selectors may change them while the EndpointSlice stays the same.
+func (r *Reconciler) createOrPatchEndpointSliceExport(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice, endpoints []fleetnetv1alpha1.Endpoint,
+	endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport, mutate controllerutil.MutateFn) (controllerutil.OperationResult, error) {
+	endpointSliceKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name}
+	if err := r.HubClient.Get(ctx, client.ObjectKeyFromObject(endpointSliceExport), endpointSliceExport); err != nil {
+		if !errors.IsNotFound(err) {
+			return controllerutil.OperationResultNone, err
+		}
+		if err := mutate(); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		err := r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+			return r.HubClient.Create(ctx, endpointSliceExport)
+		})
+		r.recordHubWriteResult(err)
+		if err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		r.lastExportedEndpointCache().store(endpointSliceKey, endpointSliceExport)
+		return controllerutil.OperationResultCreated, nil
+	}
+
+	if isEndpointSliceExportUpToDate(endpointSliceExport, r.annotatedVersionTracker().exported(endpointSliceKey, endpointSlice), endpoints) {
+		endpointSliceExportsSkippedNoChange.WithLabelValues(r.MemberClusterID).Inc()
+		return controllerutil.OperationResultNone, nil
+	}
+	exported := endpointSliceExport.DeepCopy()
+	if err := mutate(); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	patch, fullUpdate, err := endpointSliceExportPatch(exported, endpointSliceExport, r.lastExportedEndpointCache().lookup(endpointSliceKey, exported))
+	switch {
+	case err != nil:
+		return controllerutil.OperationResultNone, err
+	case fullUpdate:
+	case patch == nil:
+		return controllerutil.OperationResultNone, nil
+	default:
+		err := r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+			return r.HubClient.Patch(ctx, endpointSliceExport, client.RawPatch(types.JSONPatchType, patch))
+		})
+		if err == nil {
+			r.recordHubWriteResult(err)
+			r.lastExportedEndpointCache().store(endpointSliceKey, endpointSliceExport)
+			return controllerutil.OperationResultUpdated, nil
+		}
+		if !errors.IsInvalid(err) {
+			r.recordHubWriteResult(err)
+			return controllerutil.OperationResultNone, err
+		}
+		// The test of the resource version fails if the EndpointSliceExport has changed since it was read; fall back
+		// to a full update, which fails with a conflict if the copy read is stale.
+		klog.V(2).InfoS("Failed to patch the endpoint slice export; fall back to a full update",
+			"endpointSliceExport", klog.KObj(endpointSliceExport),
+			"err", err)
+	}
+	err = r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+		return r.HubClient.Update(ctx, endpointSliceExport)
+	})
+	r.recordHubWriteResult(err)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	r.lastExportedEndpointCache().store(endpointSliceKey, endpointSliceExport)
+	return controllerutil.OperationResultUpdated, nil
+}
+
+// lastExportedEndpointCache returns the cache of the endpoints last exported for each EndpointSlice.
+func (r *Reconciler) lastExportedEndpointCache() *exportedEndpointCache {
+	r.initLastExportedEndpointsOnce.Do(func() {
+		if r.lastExportedEndpoints == nil {
+			r.lastExportedEndpoints = newExportedEndpointCache()
+		}
+	})
+	return r.lastExportedEndpoints
+}
+
+// annotatedVersionTracker returns the tracker of the resource versions left by the last export time annotation.
+func (r *Reconciler) annotatedVersionTracker() *annotatedVersionTracker {
+	r.initAnnotatedVersionsOnce.Do(func() {
+		if r.annotatedVersions == nil {
+			r.annotatedVersions = newAnnotatedVersionTracker()
+		}
+	})
+	return r.annotatedVersions
+}
+
+// skipLogLimiter returns the limiter of the logs of EndpointSlices skipped for reconciliation.
+func (r *Reconciler) skipLogLimiter() *skipLogLimiter {
+	r.initSkipLogsOnce.Do(func() {
+		if r.skipLogs == nil {
+			r.skipLogs = newSkipLogLimiter(skipLogInterval)
+		}
+	})
+	return r.skipLogs
+}
+
+// readyEndpointTracker returns the tracker of ready endpoints for progressive export.
+func (r *Reconciler) readyEndpointTracker() *readyEndpointTracker {
+	r.initReadyEndpointsOnce.Do(func() {
+		if r.readyEndpoints == nil {
+			r.readyEndpoints = newReadyEndpointTracker(defaultMaxTrackedReadyEndpoints)
+		}
+	})
+	return r.readyEndpoints
+}
+
+// progressivelyExportedEndpoints returns the endpoints of an EndpointSlice to export and, if the owner
+// ServiceExport enables progressive export, the wait time until the next endpoint held back finishes soaking.
+//
+// If the owner ServiceExport sets an endpoint selector, only the endpoints whose Pods match it are exported.
+func (r *Reconciler) progressivelyExportedEndpoints(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice, svcExport *fleetnetv1alpha1.ServiceExport) ([]fleetnetv1alpha1.Endpoint, time.Duration, error) {
+	endpointSlice, err := r.selectEndpoints(ctx, endpointSlice, svcExport)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	endpoints := extractEndpointsFromEndpointSlice(endpointSlice, r.ExportNotReadyAddresses)
+	clk := r.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+	// Ready endpoints are always tracked so that enabling progressive export later does not release the endpoints
+	// which become ready in the meantime without soaking.
+	readySince := r.readyEndpointTracker().observe(types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name}, endpoints, now)
+
+	soakTime, err := progressiveExportSoakTime(svcExport)
+	if err != nil {
+		klog.V(2).InfoS("Progressive export is disabled for an invalid soak time", "serviceExport", klog.KObj(svcExport), "error", err)
+		return endpoints, 0, nil
+	}
+	if soakTime == 0 {
+		return endpoints, 0, nil
+	}
+	soaked, requeueAfter := filterSoakedEndpoints(endpoints, readySince, soakTime, now)
+	if len(soaked) < len(endpoints) {
+		klog.V(2).InfoS("Holding back endpoints which have not soaked for progressive export",
+			"endpointSlice", klog.KObj(endpointSlice), "soakTime", soakTime,
+			"heldBack", len(endpoints)-len(soaked), "requeueAfter", requeueAfter)
+	}
+	return soaked, requeueAfter, nil
+}
+
+// selectEndpoints returns a copy of an EndpointSlice with only the endpoints selected by the endpoint selector of its
+// owner ServiceExport; the EndpointSlice itself is returned if the ServiceExport sets no selector.
+//
+// No endpoint is selected if the selector is invalid, or if the endpoint selectors are disabled, as exporting all the
+// endpoints would defeat its purpose; a warning event is recorded on the ServiceExport then, and on the EndpointSlice
+// if the selector leaves none of its endpoints. The events are recorded only when they change.
+func (r *Reconciler) selectEndpoints(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice, svcExport *fleetnetv1alpha1.ServiceExport) (*discoveryv1.EndpointSlice, error) {
+	if svcExport.Spec.IncludeOnlyEndpointsWithLabels == nil {
+		r.eventRecorder().Clear(svcExport, endpointSelectorDisabledReason)
+		r.eventRecorder().Clear(svcExport, invalidEndpointSelectorReason)
+		r.eventRecorder().Clear(endpointSlice, endpointsFilteredToZeroReason)
+		return endpointSlice, nil
+	}
+	selected := endpointSlice.DeepCopy()
+	if !r.EnableEndpointSelector {
+		klog.V(2).InfoS("No endpoint is exported for an endpoint selector while the endpoint selectors are disabled", "serviceExport", klog.KObj(svcExport))
+		r.eventRecorder().Eventf(svcExport, corev1.EventTypeWarning, endpointSelectorDisabledReason, "Service %s has an endpoint selector, which is disabled in the member cluster, and no endpoint is exported", svcExport.Name)
+		selected.Endpoints = nil
+		return selected, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(svcExport.Spec.IncludeOnlyEndpointsWithLabels)
+	if err != nil {
+		klog.V(2).InfoS("No endpoint is exported for an invalid endpoint selector", "serviceExport", klog.KObj(svcExport), "error", err)
+		r.eventRecorder().Eventf(svcExport, corev1.EventTypeWarning, invalidEndpointSelectorReason, "Service %s has an invalid endpoint selector and no endpoint is exported: %v", svcExport.Name, err)
+		selected.Endpoints = nil
+		return selected, nil
+	}
+	r.eventRecorder().Clear(svcExport, invalidEndpointSelectorReason)
+	if selected.Endpoints, err = r.includedEndpoints(ctx, endpointSlice, selector); err != nil {
+		return nil, err
+	}
+	if len(selected.Endpoints) == 0 && len(endpointSlice.Endpoints) > 0 {
+		klog.V(2).InfoS("The endpoint selector leaves no endpoint of the endpoint slice to export",
+			"endpointSlice", klog.KObj(endpointSlice),
+			"serviceExport", klog.KObj(svcExport),
+			"selector", selector)
+		r.eventRecorder().Eventf(endpointSlice, corev1.EventTypeWarning, endpointsFilteredToZeroReason, "None of the %d endpoints of endpoint slice %s matches the endpoint selector %q of Service %s", len(endpointSlice.Endpoints), endpointSlice.Name, selector, svcExport.Name)
+	} else {
+		r.eventRecorder().Clear(endpointSlice, endpointsFilteredToZeroReason)
+	}
+	return selected, nil
+}
+
+// eventRecorder returns the recorder of the events which are recorded only when their messages change.
+func (r *Reconciler) eventRecorder() *eventdedup.Recorder {
+	r.initEventsOnce.Do(func() {
+		if r.events == nil {
+			r.events = eventdedup.New(r.Recorder)
+		}
+	})
+	return r.events
+}
+
+// hubWriteRetryLimiter returns the rate limiter of the workqueue, which wraps the configured one and delays the retries
+// of the failed writes to the hub cluster as per the retry budget.
+func (r *Reconciler) hubWriteRetryLimiter() *retrybudget.RateLimiter {
+	r.initHubWriteRetriesOnce.Do(func() {
+		if r.hubWriteRetries == nil {
+			r.hubWriteRetries = retrybudget.NewRateLimiter(r.RetryBudget, r.RateLimiter)
+		}
+	})
+	return r.hubWriteRetries
+}
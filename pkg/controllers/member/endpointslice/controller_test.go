@@ -11,18 +11,30 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/circuitbreaker"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 	"go.goms.io/fleet-networking/pkg/common/uniquename"
@@ -34,6 +46,7 @@ const (
 	hubNSForMember                 = "bravelion"
 	svcName                        = "app"
 	endpointSliceName              = "app-endpointslice"
+	endpointSliceUID               = "app-endpointslice-uid"
 	endpointSliceUniqueName        = "bravelion-work-app-endpointslice"
 	endpointSliceGeneration        = 1
 	customDeletionBlockerFinalizer = "custom-deletion-finalizer"
@@ -132,6 +145,17 @@ func TestIsEndpointSlicePermanentlyUnexportable(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "should be exportable (FQDN endpointslice)",
+			endpointSlice: &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+				AddressType: discoveryv1.AddressTypeFQDN,
+			},
+			want: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -152,9 +176,10 @@ func TestExtractEndpointsFromEndpointSlice(t *testing.T) {
 	notReadyAddress := "3.4.5.6"
 
 	testCases := []struct {
-		name              string
-		endpointSlice     *discoveryv1.EndpointSlice
-		expectedEndpoints []fleetnetv1alpha1.Endpoint
+		name                    string
+		endpointSlice           *discoveryv1.EndpointSlice
+		exportNotReadyAddresses bool
+		expectedEndpoints       []fleetnetv1alpha1.Endpoint
 	}{
 		{
 			name: "should extract ready endpoints only",
@@ -191,13 +216,153 @@ func TestExtractEndpointsFromEndpointSlice(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "should extract not ready endpoints, flagged as not ready, when exporting not ready addresses",
+			endpointSlice: &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+				Endpoints: []discoveryv1.Endpoint{
+					{
+						Addresses: []string{readyAddress},
+						Conditions: discoveryv1.EndpointConditions{
+							Ready: &isReady,
+						},
+					},
+					{
+						Addresses:  []string{unknownStateAddress},
+						Conditions: discoveryv1.EndpointConditions{},
+					},
+					{
+						Addresses: []string{notReadyAddress},
+						Conditions: discoveryv1.EndpointConditions{
+							Ready:       &isNotReady,
+							Terminating: &isReady,
+						},
+					},
+				},
+			},
+			exportNotReadyAddresses: true,
+			expectedEndpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{readyAddress},
+				},
+				{
+					Addresses: []string{unknownStateAddress},
+				},
+				{
+					Addresses: []string{notReadyAddress},
+					Ready:     ptr.To(false),
+				},
+			},
+		},
+		{
+			name: "should extract zones and hints",
+			endpointSlice: &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+				Endpoints: []discoveryv1.Endpoint{
+					{
+						Addresses: []string{readyAddress},
+						Zone:      ptr.To("eastus-1"),
+						Hints: &discoveryv1.EndpointHints{
+							ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+						},
+					},
+					{
+						Addresses: []string{unknownStateAddress},
+						Zone:      ptr.To("eastus-2"),
+					},
+				},
+			},
+			expectedEndpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{readyAddress},
+					Zone:      ptr.To("eastus-1"),
+					Hints: &discoveryv1.EndpointHints{
+						ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+					},
+				},
+				{
+					Addresses: []string{unknownStateAddress},
+					Zone:      ptr.To("eastus-2"),
+				},
+			},
+		},
+		{
+			name: "should extract per-pod endpoints (headless service)",
+			endpointSlice: &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+					Labels: map[string]string{
+						discoveryv1.LabelServiceName: svcName,
+						corev1.IsHeadlessService:     "",
+					},
+				},
+				Endpoints: []discoveryv1.Endpoint{
+					{
+						Addresses: []string{readyAddress},
+						Hostname:  ptr.To("app-0"),
+						Conditions: discoveryv1.EndpointConditions{
+							Ready: &isReady,
+						},
+					},
+					{
+						Addresses: []string{unknownStateAddress},
+						Hostname:  ptr.To("app-1"),
+					},
+				},
+			},
+			expectedEndpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{readyAddress},
+				},
+				{
+					Addresses: []string{unknownStateAddress},
+				},
+			},
+		},
+		{
+			name: "should extract FQDNs as-is",
+			endpointSlice: &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+				AddressType: discoveryv1.AddressTypeFQDN,
+				Endpoints: []discoveryv1.Endpoint{
+					{
+						Addresses: []string{"db.example.com"},
+						Conditions: discoveryv1.EndpointConditions{
+							Ready: &isReady,
+						},
+					},
+					{
+						Addresses: []string{"db-replica.example.com"},
+						Conditions: discoveryv1.EndpointConditions{
+							Ready: &isNotReady,
+						},
+					},
+				},
+			},
+			expectedEndpoints: []fleetnetv1alpha1.Endpoint{
+				{
+					Addresses: []string{"db.example.com"},
+					IsFQDN:    true,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			extractedEndpoints := extractEndpointsFromEndpointSlice(tc.endpointSlice)
+			extractedEndpoints := extractEndpointsFromEndpointSlice(tc.endpointSlice, tc.exportNotReadyAddresses)
 			if !cmp.Equal(extractedEndpoints, tc.expectedEndpoints) {
-				t.Fatalf("extractEndpointsFromEndpointSlice(%+v) = %+v, want %+v", tc.endpointSlice, extractedEndpoints, tc.expectedEndpoints)
+				t.Fatalf("extractEndpointsFromEndpointSlice(%+v, %t) = %+v, want %+v", tc.endpointSlice, tc.exportNotReadyAddresses, extractedEndpoints, tc.expectedEndpoints)
 			}
 		})
 	}
@@ -272,6 +437,7 @@ func TestUnexportLinkedEndpointSlice(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
 			if err := reconciler.unexportEndpointSlice(ctx, tc.endpointSlice); err != nil {
@@ -352,6 +518,7 @@ func TestUnexportUnlinkedEndpointSlice(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
 			if err := reconciler.unexportEndpointSlice(ctx, tc.endpointSlice); err != nil {
@@ -405,6 +572,7 @@ func TestAssignUniqueNameAsAnnotation(t *testing.T) {
 				MemberClient:    fakeMemberClient,
 				HubClient:       fakeHubClient,
 				HubNamespace:    hubNSForMember,
+				Recorder:        record.NewFakeRecorder(10),
 			}
 
 			uniqueName, err := reconciler.assignUniqueNameAsAnnotation(ctx, tc.endpointSlice)
@@ -426,6 +594,194 @@ func TestAssignUniqueNameAsAnnotation(t *testing.T) {
 	}
 }
 
+// TestAssignUniqueNameAsAnnotation_StaleEndpointSlice tests that no unique name is assigned over the one assigned to
+// an EndpointSlice since it was read; the one assigned is returned instead.
+func TestAssignUniqueNameAsAnnotation_StaleEndpointSlice(t *testing.T) {
+	ctx := context.Background()
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	stale := &discoveryv1.EndpointSlice{}
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, stale); err != nil {
+		t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+	}
+	assigned := stale.DeepCopy()
+	uniqueName, err := reconciler.assignUniqueNameAsAnnotation(ctx, assigned)
+	if err != nil {
+		t.Fatalf("assignUniqueNameAsAnnotation(), got %v, want no error", err)
+	}
+	got, err := reconciler.assignUniqueNameAsAnnotation(ctx, stale)
+	if err != nil {
+		t.Fatalf("assignUniqueNameAsAnnotation() with a stale endpoint slice, got %v, want no error", err)
+	}
+	if got != uniqueName {
+		t.Errorf("assignUniqueNameAsAnnotation() with a stale endpoint slice = %s, want %s", got, uniqueName)
+	}
+
+	updated := &discoveryv1.EndpointSlice{}
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, updated); err != nil {
+		t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+	}
+	if got := updated.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]; got != uniqueName {
+		t.Errorf("unique name annotation, got %s, want %s", got, uniqueName)
+	}
+}
+
+// TestAssignUniqueNameAsAnnotation_ConflictRetries tests that the assignment of a unique name is retried right away
+// on conflicts, up to maxUniqueNameConflictRetries times, with the EndpointSlice read again from the API server.
+func TestAssignUniqueNameAsAnnotation_ConflictRetries(t *testing.T) {
+	testCases := []struct {
+		name        string
+		conflicts   int
+		wantUpdates int
+		wantErr     bool
+	}{
+		{
+			name:        "no conflict",
+			wantUpdates: 1,
+		},
+		{
+			name:        "conflicts resolved by retries",
+			conflicts:   maxUniqueNameConflictRetries,
+			wantUpdates: maxUniqueNameConflictRetries + 1,
+		},
+		{
+			name:        "conflicts exhausting the retries",
+			conflicts:   maxUniqueNameConflictRetries + 1,
+			wantUpdates: maxUniqueNameConflictRetries + 1,
+			wantErr:     true,
+		},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpointSlice := &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+			}
+			updates := 0
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(endpointSlice).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+						updates++
+						if updates <= tc.conflicts {
+							return errors.NewConflict(discoveryv1.Resource("endpointslices"), obj.GetName(), fmt.Errorf("the object has been modified"))
+						}
+						return c.Update(ctx, obj, opts...)
+					},
+				}).
+				Build()
+			apiReads := 0
+			apiReader := interceptor.NewClient(fakeMemberClient, interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					apiReads++
+					return c.Get(ctx, key, obj, opts...)
+				},
+			})
+			reconciler := &Reconciler{
+				MemberClusterID: memberClusterID,
+				MemberClient:    fakeMemberClient,
+				MemberAPIReader: apiReader,
+				HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+				HubNamespace:    hubNSForMember,
+				Recorder:        record.NewFakeRecorder(10),
+			}
+
+			read := &discoveryv1.EndpointSlice{}
+			if err := fakeMemberClient.Get(ctx, endpointSliceKey, read); err != nil {
+				t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+			}
+			uniqueName, err := reconciler.assignUniqueNameAsAnnotation(ctx, read)
+			if updates != tc.wantUpdates {
+				t.Errorf("assignUniqueNameAsAnnotation() made %d updates, want %d", updates, tc.wantUpdates)
+			}
+			if wantReads := min(tc.conflicts, maxUniqueNameConflictRetries); apiReads != wantReads {
+				t.Errorf("assignUniqueNameAsAnnotation() read the endpoint slice from the API server %d times, want %d", apiReads, wantReads)
+			}
+			if tc.wantErr {
+				if !errors.IsConflict(err) {
+					t.Fatalf("assignUniqueNameAsAnnotation(), got %v, want a conflict", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("assignUniqueNameAsAnnotation(), got %v, want no error", err)
+			}
+
+			updated := &discoveryv1.EndpointSlice{}
+			if err := fakeMemberClient.Get(ctx, endpointSliceKey, updated); err != nil {
+				t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+			}
+			if got := updated.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]; got != uniqueName {
+				t.Errorf("unique name annotation, got %s, want %s", got, uniqueName)
+			}
+		})
+	}
+}
+
+// TestReconcile_UniqueNameAssignedEvent tests that the unique name an EndpointSlice is exported under is written to
+// its annotation, and reported in an event, on its first export only.
+func TestReconcile_UniqueNameAssignedEvent(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        recorder,
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+			t.Fatalf("Reconcile() #%d, got %v, want no error", i, err)
+		}
+	}
+
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+	}
+	uniqueName := endpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]
+	endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{}
+	if err := fakeHubClient.Get(ctx, types.NamespacedName{Namespace: hubNSForMember, Name: uniqueName}, endpointSliceExport); err != nil {
+		t.Fatalf("endpointSliceExport Get() of the unique name annotated %q, got %v, want no error", uniqueName, err)
+	}
+	wantEvent := fmt.Sprintf("Normal UniqueNameAssigned Endpoint slice %s is exported as EndpointSliceExport %s/%s", endpointSliceName, hubNSForMember, uniqueName)
+	if got := <-recorder.Events; got != wantEvent {
+		t.Errorf("event, got %q, want %q", got, wantEvent)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("got %d more events, want none", len(recorder.Events))
+	}
+}
+
 // TestShouldSkipOrUnexportEndpointSlice_NoServiceExport tests the *Reconciler.shouldSkipOrUnexportEndpointSlice method.
 func TestShouldSkipOrUnexportEndpointSlice_NoServiceExport(t *testing.T) {
 	testCases := []struct {
@@ -514,9 +870,10 @@ func TestShouldSkipOrUnexportEndpointSlice_NoServiceExport(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
-			op, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
+			op, _, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
 			if err != nil {
 				t.Fatalf("shouldSkipOrUnexportEndpointSlice(%+v), got %v, want no error", tc.endpointSlice, err)
 			}
@@ -728,9 +1085,10 @@ func TestShouldSkipOrUnexportEndpointSlice_InvalidOrConflictedServiceExport(t *t
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
-			op, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
+			op, _, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
 			if err != nil {
 				t.Fatalf("shouldSkipOrUnexportEndpointSlice(%+v), got %v, want no error", tc.endpointSlice, err)
 			}
@@ -852,9 +1210,10 @@ func TestShouldSkipOrUnexportEndpointSlice_ExportedService(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
-			op, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
+			op, _, err := reconciler.shouldSkipOrUnexportEndpointSlice(ctx, tc.endpointSlice)
 			if err != nil {
 				t.Fatalf("shouldSkipOrUnexportEndpointSlice(%+v), got %v, want no error", tc.endpointSlice, err)
 			}
@@ -1094,6 +1453,7 @@ func TestAnnotateLastSeenGenerationAndTimestamp(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
 			if err := reconciler.annotateLastSeenGenerationAndTimestamp(ctx, tc.endpointSlice, tc.startTime); err != nil {
@@ -1256,6 +1616,7 @@ func TestCollectAndVerifyLastSeenGenerationAndTimestamp(t *testing.T) {
 				MemberClient: fakeMemberClient,
 				HubClient:    fakeHubClient,
 				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
 			}
 
 			exportedSince, err := reconciler.collectAndVerifyLastSeenGenerationAndTimestamp(ctx, tc.endpointSlice, tc.startTime)
@@ -1275,3 +1636,1516 @@ func TestCollectAndVerifyLastSeenGenerationAndTimestamp(t *testing.T) {
 		})
 	}
 }
+
+// TestReconcile_ShortLivedEndpointSlices tests that the controller handles EndpointSlices which are deleted while
+// they are being exported without leaving EndpointSliceExports behind.
+func TestReconcile_ShortLivedEndpointSlices(t *testing.T) {
+	// deletionPoint describes at which point of the reconciliation an EndpointSlice gets deleted.
+	type deletionPoint int
+	const (
+		deletedBeforeReconcile deletionPoint = iota
+		deletedBeforeUniqueNameAssigned
+		deletedAfterUniqueNameAssigned
+		deletedAfterLastSeenAnnotated
+		deletionPointCount
+	)
+	const endpointSliceCount = 500
+
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+
+	ctx := context.Background()
+	var deletionPoints map[string]deletionPoint
+	var updateCounts map[string]int
+	var unexpectedErrs []error
+	deleteEndpointSlice := func(ctx context.Context, c client.WithWatch, obj client.Object) {
+		endpointSlice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+			},
+		}
+		if err := c.Delete(ctx, endpointSlice); err != nil && !errors.IsNotFound(err) {
+			unexpectedErrs = append(unexpectedErrs, err)
+		}
+	}
+	fakeMemberClient := interceptor.NewClient(
+		fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(svcExport).
+			WithStatusSubresource(svcExport).
+			Build(),
+		interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updateCounts[obj.GetName()]++
+				point := deletionPoints[obj.GetName()]
+				// The first update assigns the unique name; the second one annotates the last seen generation and
+				// timestamp.
+				if updateCounts[obj.GetName()] == 1 && point == deletedBeforeUniqueNameAssigned {
+					deleteEndpointSlice(ctx, c, obj)
+				}
+				err := c.Update(ctx, obj, opts...)
+				if err != nil && !errors.IsNotFound(err) {
+					unexpectedErrs = append(unexpectedErrs, err)
+				}
+				if (updateCounts[obj.GetName()] == 1 && point == deletedAfterUniqueNameAssigned) ||
+					(updateCounts[obj.GetName()] == 2 && point == deletedAfterLastSeenAnnotated) {
+					deleteEndpointSlice(ctx, c, obj)
+				}
+				return err
+			},
+		},
+	)
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		// Each EndpointSlice is assigned a unique name at most once.
+		Recorder: record.NewFakeRecorder(endpointSliceCount),
+	}
+
+	deletionPoints = make(map[string]deletionPoint, endpointSliceCount)
+	updateCounts = make(map[string]int, endpointSliceCount)
+	for i := 0; i < endpointSliceCount; i++ {
+		name := fmt.Sprintf("%s-%d", endpointSliceName, i)
+		point := deletionPoint(i % int(deletionPointCount))
+		// Half of the EndpointSlices are blocked from deletion by a finalizer so that the controller also sees
+		// EndpointSlices that are being deleted.
+		hasFinalizer := (i/int(deletionPointCount))%2 == 1
+		if hasFinalizer && point == deletedBeforeUniqueNameAssigned {
+			// The fake client rejects updates to an EndpointSlice with a stale resource version, which is
+			// what a deletion before the update leads to; let the EndpointSlice go right away instead.
+			hasFinalizer = false
+		}
+		deletionPoints[name] = point
+
+		endpointSlice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      name,
+				Labels: map[string]string{
+					discoveryv1.LabelServiceName: svcName,
+				},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses: []string{"1.2.3.4"},
+				},
+			},
+		}
+		if hasFinalizer {
+			endpointSlice.Finalizers = []string{customDeletionBlockerFinalizer}
+		}
+		if err := fakeMemberClient.Create(ctx, endpointSlice); err != nil {
+			t.Fatalf("Create(%s), got %v, want no error", name, err)
+		}
+		if point == deletedBeforeReconcile {
+			deleteEndpointSlice(ctx, fakeMemberClient, endpointSlice)
+		}
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: memberUserNS, Name: name}}
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Errorf("Reconcile(%s) at deletion point %d, got %v, want no error", name, point, err)
+		}
+
+		if hasFinalizer {
+			// The deletion triggers another reconciliation before the finalizer is removed.
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Errorf("Reconcile(%s) of the deleted endpoint slice, got %v, want no error", name, err)
+			}
+			latest := &discoveryv1.EndpointSlice{}
+			if err := fakeMemberClient.Get(ctx, req.NamespacedName, latest); err != nil {
+				t.Fatalf("Get(%s), got %v, want no error", name, err)
+			}
+			latest.Finalizers = nil
+			if err := fakeMemberClient.Update(ctx, latest); err != nil {
+				t.Fatalf("Update(%s), got %v, want no error", name, err)
+			}
+		}
+
+		// The EndpointSlice is gone for good.
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Errorf("Reconcile(%s) of the removed endpoint slice, got %v, want no error", name, err)
+		}
+	}
+
+	if len(unexpectedErrs) != 0 {
+		t.Errorf("member client errors, got %v, want none other than NotFound", unexpectedErrs)
+	}
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 0 {
+		t.Errorf("endpointSliceExports, got %d, want none", len(endpointSliceExportList.Items))
+	}
+}
+
+// TestReconcile_HeadlessServiceEndpointSlice tests that the controller exports an EndpointSlice of a headless
+// Service with the addresses of all the backing pods.
+func TestReconcile_HeadlessServiceEndpointSlice(t *testing.T) {
+	isReady := true
+	isNotReady := false
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	ports := []discoveryv1.EndpointPort{
+		{
+			Name:     ptr.To("redis"),
+			Protocol: ptr.To(corev1.ProtocolTCP),
+			Port:     ptr.To(int32(6379)),
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			UID:       endpointSliceUID,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: svcName,
+				corev1.IsHeadlessService:     "",
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses:  []string{"10.0.0.1"},
+				Hostname:   ptr.To("app-0"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &isReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: "app-0"},
+			},
+			{
+				Addresses:  []string{"10.0.0.2"},
+				Hostname:   ptr.To("app-1"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &isReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: "app-1"},
+			},
+			{
+				Addresses:  []string{"10.0.0.3"},
+				Hostname:   ptr.To("app-2"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &isReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: "app-2"},
+			},
+			{
+				Addresses:  []string{"10.0.0.4"},
+				Hostname:   ptr.To("app-3"),
+				Conditions: discoveryv1.EndpointConditions{Ready: &isNotReady},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: "app-3"},
+			},
+		},
+		Ports: ports,
+	}
+
+	ctx := context.Background()
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 1 {
+		t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+	}
+	want := fleetnetv1alpha1.EndpointSliceExportSpec{
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []fleetnetv1alpha1.Endpoint{
+			{
+				Addresses: []string{"10.0.0.1"},
+			},
+			{
+				Addresses: []string{"10.0.0.2"},
+			},
+			{
+				Addresses: []string{"10.0.0.3"},
+			},
+		},
+		Ports: ports,
+		OwnerServiceReference: fleetnetv1alpha1.OwnerServiceReference{
+			Namespace:      memberUserNS,
+			Name:           svcName,
+			NamespacedName: fmt.Sprintf("%s/%s", memberUserNS, svcName),
+		},
+	}
+	if diff := cmp.Diff(want, endpointSliceExportList.Items[0].Spec,
+		cmpopts.IgnoreFields(fleetnetv1alpha1.EndpointSliceExportSpec{}, "EndpointSliceReference")); diff != "" {
+		t.Errorf("endpointSliceExport spec (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_EndpointTopology tests that the zones and hints of the endpoints in an EndpointSlice are exported
+// to the hub cluster.
+func TestReconcile_EndpointTopology(t *testing.T) {
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			UID:       endpointSliceUID,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: svcName,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses: []string{"10.0.0.1"},
+				Zone:      ptr.To("eastus-1"),
+				Hints: &discoveryv1.EndpointHints{
+					ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+				},
+			},
+			{
+				Addresses: []string{"10.0.0.2"},
+				Zone:      ptr.To("eastus-2"),
+				Hints: &discoveryv1.EndpointHints{
+					ForZones: []discoveryv1.ForZone{{Name: "eastus-2"}, {Name: "eastus-3"}},
+				},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 1 {
+		t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+	}
+	want := []fleetnetv1alpha1.Endpoint{
+		{
+			Addresses: []string{"10.0.0.1"},
+			Zone:      ptr.To("eastus-1"),
+			Hints: &discoveryv1.EndpointHints{
+				ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+			},
+		},
+		{
+			Addresses: []string{"10.0.0.2"},
+			Zone:      ptr.To("eastus-2"),
+			Hints: &discoveryv1.EndpointHints{
+				ForZones: []discoveryv1.ForZone{{Name: "eastus-2"}, {Name: "eastus-3"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, endpointSliceExportList.Items[0].Spec.Endpoints); diff != "" {
+		t.Errorf("endpointSliceExport endpoints (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_FQDNEndpointSlice tests that an EndpointSlice of the FQDN address type is exported to the hub
+// cluster with its FQDNs flagged.
+func TestReconcile_FQDNEndpointSlice(t *testing.T) {
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			UID:       endpointSliceUID,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: svcName,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeFQDN,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses: []string{"db.example.com"},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 1 {
+		t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+	}
+	got := endpointSliceExportList.Items[0].Spec
+	if got.AddressType != discoveryv1.AddressTypeFQDN {
+		t.Errorf("endpointSliceExport address type, got %s, want %s", got.AddressType, discoveryv1.AddressTypeFQDN)
+	}
+	want := []fleetnetv1alpha1.Endpoint{
+		{
+			Addresses: []string{"db.example.com"},
+			IsFQDN:    true,
+		},
+	}
+	if diff := cmp.Diff(want, got.Endpoints); diff != "" {
+		t.Errorf("endpointSliceExport endpoints (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_HubNamespaceCircuitBreaker tests that the controller stops reconciling EndpointSlices after
+// repeated failures to write to the hub namespace, and resumes once a trial write succeeds.
+func TestReconcile_HubNamespaceCircuitBreaker(t *testing.T) {
+	const threshold = 10
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	objs := []client.Object{svcExport}
+	for i := 0; i <= threshold; i++ {
+		objs = append(objs, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      fmt.Sprintf("%s-%d", endpointSliceName, i),
+				UID:       types.UID(fmt.Sprintf("%s-%d", endpointSliceUID, i)),
+				Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{fmt.Sprintf("10.0.0.%d", i+1)}}},
+		})
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(svcExport).
+		Build()
+
+	quotaExceeded := true
+	createCalls := 0
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				createCalls++
+				if quotaExceeded {
+					return errors.NewForbidden(fleetnetv1alpha1.GroupVersion.WithResource("endpointsliceexports").GroupResource(),
+						obj.GetName(), fmt.Errorf("exceeded quota: hub-quota"))
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		CircuitBreaker:  circuitbreaker.NewWithClock(threshold, 5*time.Minute, fakeClock),
+		Recorder:        record.NewFakeRecorder(threshold + 1),
+	}
+	reconcileEndpointSlice := func(i int) (ctrl.Result, error) {
+		key := types.NamespacedName{Namespace: memberUserNS, Name: fmt.Sprintf("%s-%d", endpointSliceName, i)}
+		return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	// The circuit opens after the threshold is reached.
+	for i := 0; i < threshold; i++ {
+		if _, err := reconcileEndpointSlice(i); err == nil {
+			t.Fatalf("Reconcile() #%d = nil, want an error", i)
+		}
+	}
+	if got := reconciler.CircuitBreaker.State(hubNSForMember); got != circuitbreaker.StateOpen {
+		t.Fatalf("circuit state = %v, want %v", got, circuitbreaker.StateOpen)
+	}
+
+	// Reconciliations are skipped while the circuit is open.
+	res, err := reconcileEndpointSlice(threshold)
+	if err != nil || res.RequeueAfter != 5*time.Minute {
+		t.Fatalf("Reconcile() = %+v, %v, want requeue after %v and no error", res, err, 5*time.Minute)
+	}
+	if createCalls != threshold {
+		t.Fatalf("hub create calls = %d, want %d", createCalls, threshold)
+	}
+
+	// A successful trial write after the cool-down period closes the circuit.
+	quotaExceeded = false
+	fakeClock.SetTime(fakeClock.Now().Add(5 * time.Minute))
+	if _, err := reconcileEndpointSlice(threshold); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if got := reconciler.CircuitBreaker.State(hubNSForMember); got != circuitbreaker.StateClosed {
+		t.Fatalf("circuit state = %v, want %v", got, circuitbreaker.StateClosed)
+	}
+	for i := 0; i < threshold; i++ {
+		if _, err := reconcileEndpointSlice(i); err != nil {
+			t.Fatalf("Reconcile() #%d = %v, want no error", i, err)
+		}
+	}
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != threshold+1 {
+		t.Errorf("endpointSliceExports, got %d, want %d", len(endpointSliceExportList.Items), threshold+1)
+	}
+}
+
+// TestReconcile_HubNamespaceCircuitBreakerTrialWithoutWrite tests that a trial reconciliation which does not write
+// to the hub namespace lets the next reconciliation try.
+func TestReconcile_HubNamespaceCircuitBreakerTrialWithoutWrite(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		CircuitBreaker:  circuitbreaker.NewWithClock(1, time.Minute, fakeClock),
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	reconciler.CircuitBreaker.RecordFailure(hubNSForMember)
+	fakeClock.SetTime(fakeClock.Now().Add(time.Minute))
+
+	// The EndpointSlice does not exist; the trial reconciliation ends without writing to the hub namespace.
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if allowed, _ := reconciler.CircuitBreaker.Allow(hubNSForMember); !allowed {
+		t.Errorf("Allow() = false after a trial reconciliation without writes, want true")
+	}
+}
+
+// TestReconcile_HubNamespaceCircuitBreakerReadFailure tests that failures to read from the hub namespace do not
+// open the circuit, which only tracks writes.
+func TestReconcile_HubNamespaceCircuitBreakerReadFailure(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}}},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, endpointSlice).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return errors.NewServiceUnavailable("hub cluster is unavailable")
+			},
+		}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		CircuitBreaker:  circuitbreaker.NewWithClock(1, time.Minute, clocktesting.NewFakePassiveClock(time.Now())),
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err == nil {
+		t.Fatalf("Reconcile() = nil, want an error")
+	}
+	if got := reconciler.CircuitBreaker.State(hubNSForMember); got != circuitbreaker.StateClosed {
+		t.Errorf("circuit state = %v, want %v", got, circuitbreaker.StateClosed)
+	}
+}
+
+// TestReconcile_RequeueJitter tests that the EndpointSlices skipped while the circuit for the hub namespace is open
+// are requeued for when the circuit half-opens, with the jitter spreading their retries.
+func TestReconcile_RequeueJitter(t *testing.T) {
+	const count = 100
+	const coolDown = time.Minute
+	const jitter = 5 * time.Second
+	ctx := context.Background()
+	var objs []client.Object
+	for i := 0; i < count; i++ {
+		objs = append(objs, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      fmt.Sprintf("%s-%d", endpointSliceName, i),
+				Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		})
+	}
+	// The circuit for the hub namespace is open, e.g. as the resource quota of the namespace is exhausted.
+	cb := circuitbreaker.NewWithClock(1, coolDown, clocktesting.NewFakePassiveClock(time.Now()))
+	cb.RecordFailure(hubNSForMember)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build(),
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(count),
+		CircuitBreaker:  cb,
+		RequeueJitter:   jitter,
+	}
+
+	requeueAfters := map[time.Duration]bool{}
+	for i := 0; i < count; i++ {
+		key := types.NamespacedName{Namespace: memberUserNS, Name: fmt.Sprintf("%s-%d", endpointSliceName, i)}
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile() #%d = %v, want no error", i, err)
+		}
+		if res.RequeueAfter < coolDown || res.RequeueAfter >= coolDown+jitter {
+			t.Fatalf("Reconcile() #%d requeueAfter = %v, want in [%v, %v)", i, res.RequeueAfter, coolDown, coolDown+jitter)
+		}
+		requeueAfters[res.RequeueAfter] = true
+	}
+	if len(requeueAfters) < 2 {
+		t.Errorf("Reconcile() requeued all the endpoint slices after %v, want them spread by the jitter", requeueAfters)
+	}
+}
+
+// TestReconcile_HubWriteFailureNotJittered tests that the failed writes to the hub cluster are returned as errors, so
+// that they are retried with the backoff of the workqueue, rather than hidden behind jittered requeues.
+func TestReconcile_HubWriteFailureNotJittered(t *testing.T) {
+	const count = 100
+	const jitter = 5 * time.Second
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	objs := []client.Object{svcExport}
+	for i := 0; i < count; i++ {
+		objs = append(objs, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      fmt.Sprintf("%s-%d", endpointSliceName, i),
+				UID:       types.UID(fmt.Sprintf("uid-%d", i)),
+				Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{fmt.Sprintf("10.0.%d.%d", i/250, i%250+1)}}},
+		})
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(svcExport).
+		Build()
+	// The hub API server is under pressure, e.g. right after the hub namespace is created.
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.CreateOption) error {
+				return errors.NewTooManyRequests("the server has received too many requests", 1)
+			},
+		}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(count),
+		RequeueJitter:   jitter,
+	}
+
+	requeueAfters := make([]time.Duration, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := types.NamespacedName{Namespace: memberUserNS, Name: fmt.Sprintf("%s-%d", endpointSliceName, i)}
+			res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			requeueAfters[i], errs[i] = res.RequeueAfter, err
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < count; i++ {
+		if !errors.IsTooManyRequests(errs[i]) {
+			t.Fatalf("Reconcile() #%d = %v, want a TooManyRequests error", i, errs[i])
+		}
+		if requeueAfters[i] != 0 {
+			t.Fatalf("Reconcile() #%d requeueAfter = %v, want 0", i, requeueAfters[i])
+		}
+	}
+}
+
+// TestRecordHubWriteResult tests the recordHubWriteResult method.
+func TestRecordHubWriteResult(t *testing.T) {
+	testCases := []struct {
+		name      string
+		err       error
+		wantState circuitbreaker.State
+	}{
+		{
+			name:      "successful write",
+			wantState: circuitbreaker.StateClosed,
+		},
+		{
+			name:      "object conflict",
+			err:       errors.NewConflict(fleetnetv1alpha1.GroupVersion.WithResource("endpointsliceexports").GroupResource(), "app", fmt.Errorf("stale")),
+			wantState: circuitbreaker.StateClosed,
+		},
+		{
+			name:      "cancelled write",
+			err:       fmt.Errorf("failed to create: %w", context.Canceled),
+			wantState: circuitbreaker.StateClosed,
+		},
+		{
+			name:      "quota exceeded",
+			err:       errors.NewForbidden(fleetnetv1alpha1.GroupVersion.WithResource("endpointsliceexports").GroupResource(), "app", fmt.Errorf("exceeded quota: hub-quota")),
+			wantState: circuitbreaker.StateOpen,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reconciler := &Reconciler{
+				HubNamespace:   hubNSForMember,
+				CircuitBreaker: circuitbreaker.NewWithClock(1, time.Minute, clocktesting.NewFakePassiveClock(time.Now())),
+			}
+			reconciler.recordHubWriteResult(tc.err)
+			if got := reconciler.CircuitBreaker.State(hubNSForMember); got != tc.wantState {
+				t.Errorf("recordHubWriteResult() circuit state = %v, want %v", got, tc.wantState)
+			}
+		})
+	}
+}
+
+// progressiveExportTestObjects returns a ServiceExport with progressive export enabled and an EndpointSlice with
+// the given ready addresses.
+func progressiveExportTestObjects(soakTime string, addrs ...string) (*fleetnetv1alpha1.ServiceExport, *discoveryv1.EndpointSlice) {
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Annotations: map[string]string{
+				objectmeta.ServiceExportAnnotationProgressiveExportSoakTime: soakTime,
+			},
+		},
+		Status: fleetnetv1alpha1.ServiceExportStatus{
+			Conditions: []metav1.Condition{
+				serviceExportValidCondition(memberUserNS, svcName),
+				serviceExportNoConflictCondition(memberUserNS, svcName),
+			},
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			UID:       endpointSliceUID,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: svcName,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	for _, addr := range addrs {
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{addr}})
+	}
+	return svcExport, endpointSlice
+}
+
+// reconcileAndGetExportedAddresses reconciles the test EndpointSlice and returns the addresses exported.
+func reconcileAndGetExportedAddresses(t *testing.T, reconciler *Reconciler) ([]string, time.Duration) {
+	ctx := context.Background()
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey})
+	if err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := reconciler.HubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 1 {
+		t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+	}
+	addrs := []string{}
+	for _, endpoint := range endpointSliceExportList.Items[0].Spec.Endpoints {
+		addrs = append(addrs, endpoint.Addresses...)
+	}
+	return addrs, res.RequeueAfter
+}
+
+// TestReconcile_ProgressiveExport tests that endpoints which become ready are exported only after they soak.
+func TestReconcile_ProgressiveExport(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("60s", "1.2.3.4")
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Clock:           fakeClock,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	// Endpoints ready when the EndpointSlice is first seen are exported right away.
+	addrs, requeueAfter := reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4"}, addrs); diff != "" || requeueAfter != 0 {
+		t.Fatalf("exported addresses (-want, +got):\n%s\nrequeueAfter, got %v, want 0", diff, requeueAfter)
+	}
+
+	// A new endpoint becomes ready during a rollout.
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("Get(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"2.3.4.5"}})
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4"}, addrs); diff != "" || requeueAfter != time.Minute {
+		t.Fatalf("exported addresses (-want, +got):\n%s\nrequeueAfter, got %v, want %v", diff, requeueAfter, time.Minute)
+	}
+
+	// The new endpoint is still soaking halfway through the soak time.
+	fakeClock.Step(30 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4"}, addrs); diff != "" || requeueAfter != 30*time.Second {
+		t.Fatalf("exported addresses (-want, +got):\n%s\nrequeueAfter, got %v, want %v", diff, requeueAfter, 30*time.Second)
+	}
+
+	// The new endpoint is exported once it matures.
+	fakeClock.Step(30 * time.Second)
+	addrs, requeueAfter = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4", "2.3.4.5"}, addrs); diff != "" || requeueAfter != 0 {
+		t.Fatalf("exported addresses (-want, +got):\n%s\nrequeueAfter, got %v, want 0", diff, requeueAfter)
+	}
+}
+
+// TestReconcile_ProgressiveExportAfterRestart tests that a restarted controller, which has lost track of when
+// endpoints became ready, does not hold back any of the endpoints exported before.
+func TestReconcile_ProgressiveExportAfterRestart(t *testing.T) {
+	svcExport, endpointSlice := progressiveExportTestObjects("60s", "1.2.3.4", "2.3.4.5")
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	newReconciler := func() *Reconciler {
+		return &Reconciler{
+			MemberClusterID: memberClusterID,
+			MemberClient:    fakeMemberClient,
+			HubClient:       fakeHubClient,
+			HubNamespace:    hubNSForMember,
+			Clock:           fakeClock,
+			Recorder:        record.NewFakeRecorder(10),
+		}
+	}
+
+	addrs, _ := reconcileAndGetExportedAddresses(t, newReconciler())
+	if diff := cmp.Diff([]string{"1.2.3.4", "2.3.4.5"}, addrs); diff != "" {
+		t.Fatalf("exported addresses before restart (-want, +got):\n%s", diff)
+	}
+
+	// The restarted controller starts with an empty tracker.
+	addrs, requeueAfter := reconcileAndGetExportedAddresses(t, newReconciler())
+	if diff := cmp.Diff([]string{"1.2.3.4", "2.3.4.5"}, addrs); diff != "" || requeueAfter != 0 {
+		t.Fatalf("exported addresses after restart (-want, +got):\n%s\nrequeueAfter, got %v, want 0", diff, requeueAfter)
+	}
+}
+
+// TestReconcile_ScaleToZero tests that the EndpointSlice of a Service scaled to zero is still exported, with no
+// endpoints and its ports, rather than unexported, and that its endpoints are exported again once it scales back up.
+func TestReconcile_ScaleToZero(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4", "2.3.4.5")
+	endpointSlice.Ports = []discoveryv1.EndpointPort{{Name: ptr.To("http"), Port: ptr.To(int32(80))}}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	// scale updates the endpoints of the EndpointSlice, and checks the endpoints exported.
+	scale := func(wantAddrs ...string) {
+		t.Helper()
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		endpointSlice.Endpoints = nil
+		for _, addr := range wantAddrs {
+			endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{addr}})
+		}
+		if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+			t.Fatalf("Update(), got %v, want no error", err)
+		}
+		addrs, _ := reconcileAndGetExportedAddresses(t, reconciler)
+		if diff := cmp.Diff(append([]string{}, wantAddrs...), addrs); diff != "" {
+			t.Fatalf("exported addresses (-want, +got):\n%s", diff)
+		}
+		endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+		if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+			t.Fatalf("List(), got %v, want no error", err)
+		}
+		spec := endpointSliceExportList.Items[0].Spec
+		if spec.Endpoints == nil {
+			t.Errorf("exported endpoints, got nil, want an empty list")
+		}
+		if diff := cmp.Diff(endpointSlice.Ports, spec.Ports); diff != "" {
+			t.Errorf("exported ports (-want, +got):\n%s", diff)
+		}
+	}
+
+	scale("1.2.3.4", "2.3.4.5")
+	scale()
+	scale("3.4.5.6")
+}
+
+func TestReadyEndpointTracker(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Minute)
+	key1 := types.NamespacedName{Namespace: memberUserNS, Name: "slice-1"}
+	key2 := types.NamespacedName{Namespace: memberUserNS, Name: "slice-2"}
+	endpoints := func(addrs ...string) []fleetnetv1alpha1.Endpoint {
+		res := []fleetnetv1alpha1.Endpoint{}
+		for _, addr := range addrs {
+			res = append(res, fleetnetv1alpha1.Endpoint{Addresses: []string{addr}})
+		}
+		return res
+	}
+	tracker := newReadyEndpointTracker(3)
+
+	if diff := cmp.Diff(map[string]time.Time{"1.1.1.1": {}}, tracker.observe(key1, endpoints("1.1.1.1"), now)); diff != "" {
+		t.Errorf("observe() first seen slice (-want, +got):\n%s", diff)
+	}
+	want := map[string]time.Time{"1.1.1.1": {}, "2.2.2.2": later}
+	if diff := cmp.Diff(want, tracker.observe(key1, endpoints("1.1.1.1", "2.2.2.2"), later)); diff != "" {
+		t.Errorf("observe() new endpoint (-want, +got):\n%s", diff)
+	}
+
+	// The tracker is full; the second slice is not tracked and its endpoints are considered soaked.
+	want = map[string]time.Time{"3.3.3.3": {}, "4.4.4.4": {}}
+	if diff := cmp.Diff(want, tracker.observe(key2, endpoints("3.3.3.3", "4.4.4.4"), later)); diff != "" {
+		t.Errorf("observe() over capacity (-want, +got):\n%s", diff)
+	}
+	if tracker.size != 2 {
+		t.Errorf("tracker size, got %d, want 2", tracker.size)
+	}
+
+	// Forgetting the first slice frees up space for the second one.
+	tracker.forget(key1)
+	tracker.observe(key2, endpoints("3.3.3.3", "4.4.4.4"), later)
+	if tracker.size != 2 {
+		t.Errorf("tracker size, got %d, want 2", tracker.size)
+	}
+
+	// Endpoints are keyed by their full address set; an endpoint which shares its first address with another one, or
+	// gains an address, soaks on its own, while reordering the addresses of an endpoint does not reset it.
+	evenLater := later.Add(time.Minute)
+	dualStack := []fleetnetv1alpha1.Endpoint{
+		{Addresses: []string{"3.3.3.3", "5.5.5.5"}},
+		{Addresses: []string{"4.4.4.4"}},
+	}
+	want = map[string]time.Time{"3.3.3.3,5.5.5.5": evenLater, "4.4.4.4": {}}
+	if diff := cmp.Diff(want, tracker.observe(key2, dualStack, evenLater)); diff != "" {
+		t.Errorf("observe() endpoint with a new address (-want, +got):\n%s", diff)
+	}
+	dualStack[0].Addresses = []string{"5.5.5.5", "3.3.3.3"}
+	if diff := cmp.Diff(want, tracker.observe(key2, dualStack, evenLater.Add(time.Minute))); diff != "" {
+		t.Errorf("observe() endpoint with reordered addresses (-want, +got):\n%s", diff)
+	}
+}
+
+// TestCleanup tests that the cleanup on leave unexports all the EndpointSlices of the member cluster only, and can
+// be resumed after being interrupted.
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	otherClusterID := "other-cluster"
+	endpointSliceExport := func(namespace, name, clusterID string) *fleetnetv1alpha1.EndpointSliceExport {
+		return &fleetnetv1alpha1.EndpointSliceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+				EndpointSliceReference: fleetnetv1alpha1.ExportedObjectReference{
+					ClusterID: clusterID,
+					Kind:      "EndpointSlice",
+					Namespace: memberUserNS,
+					Name:      name,
+				},
+			},
+		}
+	}
+	endpointSlice := func(name string, exported bool) *discoveryv1.EndpointSlice {
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      name,
+				Labels: map[string]string{
+					discoveryv1.LabelServiceName: svcName,
+				},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		}
+		if exported {
+			slice.Annotations = map[string]string{
+				objectmeta.ExportedObjectAnnotationUniqueName: memberClusterID + "-" + memberUserNS + "-" + name,
+				metrics.MetricsAnnotationLastSeenGeneration:   "1",
+				metrics.MetricsAnnotationLastSeenTimestamp:    time.Now().Format(metrics.MetricsLastSeenTimestampFormat),
+			}
+		}
+		return slice
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice("app-1", true), endpointSlice("app-2", true), endpointSlice("app-3", false)).
+		Build()
+
+	// The first attempt is interrupted when deleting the second EndpointSliceExport.
+	hubDeletes := 0
+	fakeHubClient := interceptor.NewClient(
+		fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(
+				endpointSliceExport(hubNSForMember, "bravelion-work-app-1", memberClusterID),
+				endpointSliceExport(hubNSForMember, "bravelion-work-app-2", memberClusterID),
+				// An object in the same namespace but created by another cluster.
+				endpointSliceExport(hubNSForMember, "other-cluster-work-app-1", otherClusterID),
+				endpointSliceExport("other-cluster-ns", "other-cluster-work-app-1", otherClusterID),
+			).
+			Build(),
+		interceptor.Funcs{
+			Delete: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				hubDeletes++
+				if hubDeletes == 2 {
+					return errors.NewServiceUnavailable("hub is unavailable")
+				}
+				return client.Delete(ctx, obj, opts...)
+			},
+		},
+	)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	if err := reconciler.Cleanup(ctx); err == nil {
+		t.Fatalf("Cleanup(), got no error, want an error")
+	}
+	// The unique names are kept until all the EndpointSliceExports are deleted.
+	gotEndpointSlice := &discoveryv1.EndpointSlice{}
+	if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: "app-2"}, gotEndpointSlice); err != nil {
+		t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+	}
+	if _, ok := gotEndpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName]; !ok {
+		t.Fatalf("endpointSlice annotations, got %v, want the unique name annotation", gotEndpointSlice.Annotations)
+	}
+
+	// The cleanup is resumed.
+	if err := reconciler.Cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup(), got %v, want no error", err)
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("endpointSliceExport List(), got %v, want no error", err)
+	}
+	gotKeys := []string{}
+	for _, endpointSliceExport := range endpointSliceExportList.Items {
+		gotKeys = append(gotKeys, endpointSliceExport.Namespace+"/"+endpointSliceExport.Name)
+	}
+	wantKeys := []string{"bravelion/other-cluster-work-app-1", "other-cluster-ns/other-cluster-work-app-1"}
+	if diff := cmp.Diff(wantKeys, gotKeys, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("endpointSliceExports (-want, +got):\n%s", diff)
+	}
+
+	endpointSliceList := &discoveryv1.EndpointSliceList{}
+	if err := fakeMemberClient.List(ctx, endpointSliceList); err != nil {
+		t.Fatalf("endpointSlice List(), got %v, want no error", err)
+	}
+	for _, endpointSlice := range endpointSliceList.Items {
+		if len(endpointSlice.Annotations) != 0 {
+			t.Errorf("endpointSlice %s annotations, got %v, want none", endpointSlice.Name, endpointSlice.Annotations)
+		}
+	}
+}
+
+// TestReconcile_ExportedEndpointSlicesMetric tests that the exported EndpointSlices gauge tracks the exports and
+// unexports of EndpointSlices.
+func TestReconcile_ExportedEndpointSlicesMetric(t *testing.T) {
+	ctx := context.Background()
+	clusterID := "metrics-member"
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: clusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	checkExportedEndpointSlices := func(want float64) {
+		t.Helper()
+		if got := testutil.ToFloat64(exportedEndpointSlices.WithLabelValues(clusterID)); got != want {
+			t.Fatalf("exported endpoint slices, got %v, want %v", got, want)
+		}
+	}
+
+	// The EndpointSlice is exported; reconciling it again does not count it twice.
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		checkExportedEndpointSlices(1)
+	}
+
+	// The Service is no longer exported, which unexports the EndpointSlice.
+	if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, svcExport); err != nil {
+		t.Fatalf("Get(), got %v, want no error", err)
+	}
+	svcExport.Status.Conditions = []metav1.Condition{serviceExportInvalidNotFoundCondition(memberUserNS, svcName)}
+	if err := fakeMemberClient.Status().Update(ctx, svcExport); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	checkExportedEndpointSlices(0)
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := reconciler.HubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 0 {
+		t.Fatalf("endpointSliceExports, got %d, want 0", len(endpointSliceExportList.Items))
+	}
+}
+
+// TestReconcile_SkipUnchangedEndpointSlice tests that an EndpointSlice that has not changed since it was last
+// exported is not written to the hub cluster again.
+func TestReconcile_SkipUnchangedEndpointSlice(t *testing.T) {
+	ctx := context.Background()
+	clusterID := "skip-unchanged-member"
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	hubWrites := 0
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				hubWrites++
+				return c.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				hubWrites++
+				return c.Update(ctx, obj, opts...)
+			},
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				hubWrites++
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: clusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	reconcile := func(wantHubWrites int, wantSkipped float64) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		if hubWrites != wantHubWrites {
+			t.Fatalf("hub writes, got %d, want %d", hubWrites, wantHubWrites)
+		}
+		if got := testutil.ToFloat64(endpointSliceExportsSkippedNoChange.WithLabelValues(clusterID)); got != wantSkipped {
+			t.Fatalf("skipped endpoint slice exports, got %v, want %v", got, wantSkipped)
+		}
+	}
+
+	// The first reconciliation creates the EndpointSliceExport; the second one finds it up to date.
+	reconcile(1, 0)
+	reconcile(1, 1)
+
+	// A new endpoint is exported.
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("Get(), got %v, want no error", err)
+	}
+	endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"5.6.7.8"}})
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	reconcile(2, 1)
+	reconcile(2, 2)
+}
+
+// TestReconcile_LastExportTimeAnnotation tests that an EndpointSlice is annotated with a merge patch each time it is
+// exported, and that the annotation does not trigger another export.
+func TestReconcile_LastExportTimeAnnotation(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	memberPatches := 0
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if _, ok := obj.(*discoveryv1.EndpointSlice); ok {
+					memberPatches++
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	hubWrites := 0
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				hubWrites++
+				return c.Create(ctx, obj, opts...)
+			},
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				hubWrites++
+				return c.Update(ctx, obj, opts...)
+			},
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				hubWrites++
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Clock:           fakeClock,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	reconcile := func(wantHubWrites, wantMemberPatches int, wantLastExportTime string) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		if hubWrites != wantHubWrites {
+			t.Fatalf("hub writes, got %d, want %d", hubWrites, wantHubWrites)
+		}
+		if memberPatches != wantMemberPatches {
+			t.Fatalf("endpoint slice patches, got %d, want %d", memberPatches, wantMemberPatches)
+		}
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("Get(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		if got := endpointSlice.Annotations[objectmeta.EndpointSliceAnnotationLastExportTime]; got != wantLastExportTime {
+			t.Fatalf("last export time annotation, got %q, want %q", got, wantLastExportTime)
+		}
+	}
+
+	// The first export annotates the EndpointSlice.
+	reconcile(1, 1, "2024-05-01T10:00:00Z")
+
+	// The annotation changes the resource version of the EndpointSlice; the EndpointSliceExport is still up to date,
+	// so neither the export nor the annotation is written again.
+	fakeClock.Step(time.Minute)
+	reconcile(1, 1, "2024-05-01T10:00:00Z")
+
+	// A new endpoint is exported and the annotation refreshed.
+	endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"5.6.7.8"}})
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	reconcile(2, 2, "2024-05-01T10:01:00Z")
+	reconcile(2, 2, "2024-05-01T10:01:00Z")
+}
+
+// TestReconcile_LastExportTimeAnnotationFailure tests that failing to annotate an EndpointSlice does not fail its
+// export.
+func TestReconcile_LastExportTimeAnnotationFailure(t *testing.T) {
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(_ context.Context, _ client.WithWatch, _ client.Object, _ client.Patch, _ ...client.PatchOption) error {
+				return errors.NewInternalError(fmt.Errorf("patch failed"))
+			},
+		}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	addrs, _ := reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4"}, addrs); diff != "" {
+		t.Fatalf("exported addresses (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_InitialSyncPacing tests that the EndpointSlices in the backlog of a cold starting member cluster
+// are exported after the ServiceExports.
+func TestReconcile_InitialSyncPacing(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	pacer := initialsync.New(memberClusterID, 10, 10, &initialsync.Backlog{
+		ServiceExports: []types.NamespacedName{svcExportKey},
+		EndpointSlices: []types.NamespacedName{endpointSliceKey},
+	})
+	reconciler := &Reconciler{
+		MemberClusterID:  memberClusterID,
+		MemberClient:     fakeMemberClient,
+		HubClient:        fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:     hubNSForMember,
+		Recorder:         record.NewFakeRecorder(10),
+		InitialSyncPacer: pacer,
+	}
+
+	res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey})
+	if err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	if res.RequeueAfter <= 0 {
+		t.Fatalf("Reconcile(%+v) before the service export is exported, got %+v, want a requeue", endpointSliceKey, res)
+	}
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := reconciler.HubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 0 {
+		t.Fatalf("endpointSliceExports before the service export is exported, got %d, want 0", len(endpointSliceExportList.Items))
+	}
+
+	// The ServiceExport controller exports the ServiceExport.
+	if got := pacer.Delay(initialsync.KindServiceExport, svcExportKey); got != 0 {
+		t.Fatalf("Delay(%v), got %v, want 0", svcExportKey, got)
+	}
+	if addrs, _ := reconcileAndGetExportedAddresses(t, reconciler); !cmp.Equal(addrs, []string{"1.2.3.4"}) {
+		t.Fatalf("exported addresses, got %v, want [1.2.3.4]", addrs)
+	}
+	if pacer.IsPacing() {
+		t.Fatalf("IsPacing() after the backlog drains, got true, want false")
+	}
+}
+
+// TestReconcile_Concurrent tests that EndpointSlices reconciled at the same time, as with more than one worker, are
+// each exported under their own unique name.
+func TestReconcile_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	const count = 20
+	svcExport, template := progressiveExportTestObjects("0s", "1.2.3.4")
+	svcExport.Annotations = nil
+	objs := []client.Object{svcExport}
+	keys := make([]types.NamespacedName, 0, count)
+	for i := 0; i < count; i++ {
+		endpointSlice := template.DeepCopy()
+		endpointSlice.Name = fmt.Sprintf("%s-%d", endpointSliceName, i)
+		endpointSlice.UID = types.UID(fmt.Sprintf("uid-%d", i))
+		objs = append(objs, endpointSlice)
+		keys = append(keys, types.NamespacedName{Namespace: memberUserNS, Name: endpointSlice.Name})
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(svcExport).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(count),
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, count)
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", keys[i], err)
+		}
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := reconciler.HubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != count {
+		t.Fatalf("endpointSliceExports, got %d, want %d", len(endpointSliceExportList.Items), count)
+	}
+	exportedAs := map[string]string{}
+	for _, endpointSliceExport := range endpointSliceExportList.Items {
+		exportedAs[endpointSliceExport.Spec.EndpointSliceReference.Name] = endpointSliceExport.Name
+	}
+	for _, key := range keys {
+		endpointSlice := &discoveryv1.EndpointSlice{}
+		if err := fakeMemberClient.Get(ctx, key, endpointSlice); err != nil {
+			t.Fatalf("endpointSlice Get(%+v), got %v, want no error", key, err)
+		}
+		if got, want := endpointSlice.Annotations[objectmeta.ExportedObjectAnnotationUniqueName], exportedAs[key.Name]; got != want {
+			t.Errorf("unique name annotation of %+v, got %s, want %s", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// jsonPatchOp is an operation of a JSON patch (RFC 6902).
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// newJSONPatchOp returns a JSON patch operation carrying a value; a nil value is encoded as null rather than
+// omitted.
+func newJSONPatchOp(op, path string, value interface{}) jsonPatchOp {
+	// Marshalling the spec fields of an EndpointSliceExport never fails.
+	data, _ := json.Marshal(value)
+	return jsonPatchOp{Op: op, Path: path, Value: data}
+}
+
+// exportedEndpointCache keeps the hashes of the endpoints last exported for each EndpointSlice, keyed by
+// EndpointSlice and then by endpoint address, so that the controller can compute the delta of the endpoints without
+// hashing the exported endpoints again on every reconciliation.
+//
+// The cache lives in memory only; the hashes are recomputed from the EndpointSliceExport read from the hub cluster
+// whenever the cached entry does not match its resource version.
+type exportedEndpointCache struct {
+	mu                    sync.Mutex
+	lastExportedEndpoints map[types.NamespacedName]exportedEndpoints
+}
+
+// exportedEndpoints is the hashes of the endpoints of an EndpointSliceExport at a resource version.
+type exportedEndpoints struct {
+	resourceVersion string
+	hashes          map[string]uint64
+}
+
+// newExportedEndpointCache returns an empty cache of the last exported endpoints.
+func newExportedEndpointCache() *exportedEndpointCache {
+	return &exportedEndpointCache{
+		lastExportedEndpoints: make(map[types.NamespacedName]exportedEndpoints),
+	}
+}
+
+// lookup returns the hashes of the endpoints of an EndpointSliceExport, keyed by endpoint address.
+func (c *exportedEndpointCache) lookup(endpointSliceKey types.NamespacedName, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if last, ok := c.lastExportedEndpoints[endpointSliceKey]; ok && last.resourceVersion == endpointSliceExport.ResourceVersion {
+		return last.hashes
+	}
+	return hashEndpoints(endpointSliceExport.Spec.Endpoints)
+}
+
+// store records the endpoints of an EndpointSliceExport which has just been written to the hub cluster.
+func (c *exportedEndpointCache) store(endpointSliceKey types.NamespacedName, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) {
+	hashes := hashEndpoints(endpointSliceExport.Spec.Endpoints)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastExportedEndpoints[endpointSliceKey] = exportedEndpoints{
+		resourceVersion: endpointSliceExport.ResourceVersion,
+		hashes:          hashes,
+	}
+}
+
+// covers returns whether the EndpointSlice has been exported and all of its endpoints were exported then, so that
+// exporting it again can only remove the endpoints exported or update them.
+func (c *exportedEndpointCache) covers(endpointSliceKey types.NamespacedName, endpointSlice *discoveryv1.EndpointSlice) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last, ok := c.lastExportedEndpoints[endpointSliceKey]
+	if !ok {
+		return false
+	}
+	for i := range endpointSlice.Endpoints {
+		addresses := endpointSlice.Endpoints[i].Addresses
+		if len(addresses) == 0 {
+			continue
+		}
+		if _, ok := last.hashes[addresses[0]]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// forget removes the endpoints of an EndpointSlice which is no longer exported.
+func (c *exportedEndpointCache) forget(endpointSliceKey types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.lastExportedEndpoints, endpointSliceKey)
+}
+
+// hashEndpoints returns the hashes of endpoints, keyed by endpoint address.
+func hashEndpoints(endpoints []fleetnetv1alpha1.Endpoint) map[string]uint64 {
+	hashes := make(map[string]uint64, len(endpoints))
+	for i := range endpoints {
+		hashes[endpointKey(&endpoints[i])] = hashEndpoint(&endpoints[i])
+	}
+	return hashes
+}
+
+// endpointKey returns the key of an endpoint; consumers of an EndpointSlice only use the first address of an
+// endpoint.
+func endpointKey(endpoint *fleetnetv1alpha1.Endpoint) string {
+	if len(endpoint.Addresses) == 0 {
+		return ""
+	}
+	return endpoint.Addresses[0]
+}
+
+// hashEndpoint returns the FNV-1a hash of the JSON encoding of an endpoint.
+func hashEndpoint(endpoint *fleetnetv1alpha1.Endpoint) uint64 {
+	// Marshalling an endpoint never fails.
+	data, _ := json.Marshal(endpoint)
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+// endpointDelta is the difference between the exported endpoints of an EndpointSliceExport and the endpoints to
+// export.
+type endpointDelta struct {
+	// added is the endpoints to append.
+	added []fleetnetv1alpha1.Endpoint
+	// changed is the endpoints to replace, keyed by their indices in the exported endpoints.
+	changed map[int]fleetnetv1alpha1.Endpoint
+	// removed is the indices of the exported endpoints to remove.
+	removed []int
+}
+
+// size returns the number of endpoints the delta touches.
+func (d *endpointDelta) size() int {
+	return len(d.added) + len(d.changed) + len(d.removed)
+}
+
+// diffEndpoints computes the delta between the exported endpoints and the endpoints to export; the order of the
+// endpoints does not matter. It returns false if the delta cannot be computed, i.e. if either list has endpoints
+// sharing the same key.
+func diffEndpoints(exported, desired []fleetnetv1alpha1.Endpoint, exportedHashes map[string]uint64) (*endpointDelta, bool) {
+	exportedIndices := make(map[string]int, len(exported))
+	for i := range exported {
+		key := endpointKey(&exported[i])
+		if _, dup := exportedIndices[key]; dup {
+			return nil, false
+		}
+		exportedIndices[key] = i
+	}
+
+	delta := &endpointDelta{changed: map[int]fleetnetv1alpha1.Endpoint{}}
+	desiredKeys := make(map[string]bool, len(desired))
+	for i := range desired {
+		key := endpointKey(&desired[i])
+		if desiredKeys[key] {
+			return nil, false
+		}
+		desiredKeys[key] = true
+
+		idx, found := exportedIndices[key]
+		if !found {
+			delta.added = append(delta.added, desired[i])
+			continue
+		}
+		hash, hashed := exportedHashes[key]
+		if !hashed {
+			hash = hashEndpoint(&exported[idx])
+		}
+		if hash != hashEndpoint(&desired[i]) {
+			delta.changed[idx] = desired[i]
+		}
+	}
+	for key, idx := range exportedIndices {
+		if !desiredKeys[key] {
+			delta.removed = append(delta.removed, idx)
+		}
+	}
+	return delta, true
+}
+
+// unpatchedSpec returns a copy of the spec with the fields carried by the patch of endpointSliceExportPatch zeroed,
+// i.e. the fields which the patch would leave untouched. A difference in any of them, e.g. in a field added to the
+// spec later on, requires a full update so that the field is never left stale in the hub cluster.
+func unpatchedSpec(spec *fleetnetv1alpha1.EndpointSliceExportSpec) *fleetnetv1alpha1.EndpointSliceExportSpec {
+	unpatched := spec.DeepCopy()
+	unpatched.AddressType = ""
+	unpatched.Endpoints = nil
+	unpatched.Ports = nil
+	unpatched.EndpointSliceReference = fleetnetv1alpha1.ExportedObjectReference{}
+	unpatched.OwnerServiceReference = fleetnetv1alpha1.OwnerServiceReference{}
+	return unpatched
+}
+
+// endpointSliceExportPatch returns a JSON patch which updates the spec of an exported EndpointSliceExport to the
+// desired one, touching only the endpoints that are added, changed or removed.
+//
+// Endpoints is an atomic list, so a server-side apply patch would always carry the whole list; the JSON patch
+// addresses the endpoints by index instead, and starts with a test of the resource version so that the indices are
+// never applied to a list other than the one they were computed against.
+//
+// It returns a nil patch if the spec has not changed, and fullUpdate = true if the delta cannot be computed or is
+// not smaller than the endpoints to export, in which case the caller should update the whole object.
+func endpointSliceExportPatch(exported, desired *fleetnetv1alpha1.EndpointSliceExport, exportedHashes map[string]uint64) (patch []byte, fullUpdate bool, err error) {
+	if exported.ResourceVersion == "" || !equality.Semantic.DeepEqual(unpatchedSpec(&exported.Spec), unpatchedSpec(&desired.Spec)) {
+		return nil, true, nil
+	}
+	delta, ok := diffEndpoints(exported.Spec.Endpoints, desired.Spec.Endpoints, exportedHashes)
+	if !ok || (delta.size() > 0 && delta.size() >= len(desired.Spec.Endpoints)) {
+		return nil, true, nil
+	}
+
+	ops := []jsonPatchOp{}
+	if exported.Spec.AddressType != desired.Spec.AddressType {
+		ops = append(ops, newJSONPatchOp("replace", "/spec/addressType", desired.Spec.AddressType))
+	}
+	if !equality.Semantic.DeepEqual(exported.Spec.Ports, desired.Spec.Ports) {
+		ops = append(ops, newJSONPatchOp("replace", "/spec/ports", desired.Spec.Ports))
+	}
+	if !equality.Semantic.DeepEqual(exported.Spec.EndpointSliceReference, desired.Spec.EndpointSliceReference) {
+		ops = append(ops, newJSONPatchOp("replace", "/spec/endpointSliceReference", desired.Spec.EndpointSliceReference))
+	}
+	if !equality.Semantic.DeepEqual(exported.Spec.OwnerServiceReference, desired.Spec.OwnerServiceReference) {
+		ops = append(ops, newJSONPatchOp("replace", "/spec/ownerServiceReference", desired.Spec.OwnerServiceReference))
+	}
+
+	// Replace the changed endpoints before removing any so that their indices stay valid; then remove endpoints
+	// from the end of the list backwards for the same reason.
+	changedIndices := make([]int, 0, len(delta.changed))
+	for idx := range delta.changed {
+		changedIndices = append(changedIndices, idx)
+	}
+	sort.Ints(changedIndices)
+	for _, idx := range changedIndices {
+		ops = append(ops, newJSONPatchOp("replace", fmt.Sprintf("/spec/endpoints/%d", idx), delta.changed[idx]))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(delta.removed)))
+	for _, idx := range delta.removed {
+		ops = append(ops, jsonPatchOp{Op: "remove", Path: fmt.Sprintf("/spec/endpoints/%d", idx)})
+	}
+	for _, endpoint := range delta.added {
+		ops = append(ops, newJSONPatchOp("add", "/spec/endpoints/-", endpoint))
+	}
+	if len(ops) == 0 {
+		return nil, false, nil
+	}
+
+	ops = append([]jsonPatchOp{newJSONPatchOp("test", "/metadata/resourceVersion", exported.ResourceVersion)}, ops...)
+	patch, err = json.Marshal(ops)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal the patch of the endpoint slice export: %w", err)
+	}
+	return patch, false, nil
+}
@@ -0,0 +1,320 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+func deltaTestEndpoints(addrs ...string) []fleetnetv1alpha1.Endpoint {
+	endpoints := []fleetnetv1alpha1.Endpoint{}
+	for _, addr := range addrs {
+		endpoints = append(endpoints, fleetnetv1alpha1.Endpoint{Addresses: []string{addr}})
+	}
+	return endpoints
+}
+
+// deltaTestAddrs returns n distinct IPv4 addresses.
+func deltaTestAddrs(n int) []string {
+	addrs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		addrs = append(addrs, fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	return addrs
+}
+
+func TestDiffEndpoints(t *testing.T) {
+	zonedEndpoint := fleetnetv1alpha1.Endpoint{Addresses: []string{"1.2.3.5"}, Zone: ptr.To("zone-1")}
+	tests := []struct {
+		name     string
+		exported []fleetnetv1alpha1.Endpoint
+		desired  []fleetnetv1alpha1.Endpoint
+		want     *endpointDelta
+		wantOK   bool
+	}{
+		{
+			name:     "no change",
+			exported: deltaTestEndpoints("1.2.3.4", "1.2.3.5"),
+			desired:  deltaTestEndpoints("1.2.3.4", "1.2.3.5"),
+			want:     &endpointDelta{changed: map[int]fleetnetv1alpha1.Endpoint{}},
+			wantOK:   true,
+		},
+		{
+			name:     "reordered endpoints",
+			exported: deltaTestEndpoints("1.2.3.4", "1.2.3.5"),
+			desired:  deltaTestEndpoints("1.2.3.5", "1.2.3.4"),
+			want:     &endpointDelta{changed: map[int]fleetnetv1alpha1.Endpoint{}},
+			wantOK:   true,
+		},
+		{
+			name:     "added, changed and removed endpoints",
+			exported: deltaTestEndpoints("1.2.3.4", "1.2.3.5", "1.2.3.6"),
+			desired:  append(deltaTestEndpoints("1.2.3.4", "1.2.3.7"), zonedEndpoint),
+			want: &endpointDelta{
+				added:   deltaTestEndpoints("1.2.3.7"),
+				changed: map[int]fleetnetv1alpha1.Endpoint{1: zonedEndpoint},
+				removed: []int{2},
+			},
+			wantOK: true,
+		},
+		{
+			name:     "duplicate exported endpoints",
+			exported: deltaTestEndpoints("1.2.3.4", "1.2.3.4"),
+			desired:  deltaTestEndpoints("1.2.3.4"),
+		},
+		{
+			name:     "duplicate desired endpoints",
+			exported: deltaTestEndpoints("1.2.3.4"),
+			desired:  deltaTestEndpoints("1.2.3.4", "1.2.3.4"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := diffEndpoints(tc.exported, tc.desired, hashEndpoints(tc.exported))
+			if ok != tc.wantOK {
+				t.Fatalf("diffEndpoints() ok, got %t, want %t", ok, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(endpointDelta{})); diff != "" {
+				t.Errorf("diffEndpoints() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEndpointSliceExportPatch(t *testing.T) {
+	exported := &fleetnetv1alpha1.EndpointSliceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       hubNSForMember,
+			Name:            endpointSliceUniqueName,
+			ResourceVersion: "1",
+		},
+		Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   deltaTestEndpoints("1.2.3.4", "1.2.3.5", "1.2.3.6"),
+		},
+	}
+
+	t.Run("no change", func(t *testing.T) {
+		desired := exported.DeepCopy()
+		desired.Spec.Endpoints = deltaTestEndpoints("1.2.3.6", "1.2.3.5", "1.2.3.4")
+		patch, fullUpdate, err := endpointSliceExportPatch(exported, desired, hashEndpoints(exported.Spec.Endpoints))
+		if err != nil || fullUpdate || patch != nil {
+			t.Errorf("endpointSliceExportPatch() = %s, %t, %v, want nil patch, false, no error", patch, fullUpdate, err)
+		}
+	})
+
+	t.Run("most endpoints changed", func(t *testing.T) {
+		desired := exported.DeepCopy()
+		desired.Spec.Endpoints = deltaTestEndpoints("1.2.3.4", "1.2.3.7", "1.2.3.8")
+		patch, fullUpdate, err := endpointSliceExportPatch(exported, desired, hashEndpoints(exported.Spec.Endpoints))
+		if err != nil || !fullUpdate || patch != nil {
+			t.Errorf("endpointSliceExportPatch() = %s, %t, %v, want nil patch, true, no error", patch, fullUpdate, err)
+		}
+	})
+
+	t.Run("delta", func(t *testing.T) {
+		desired := exported.DeepCopy()
+		desired.Spec.Endpoints = deltaTestEndpoints("1.2.3.4", "1.2.3.6")
+		desired.Spec.Ports = []discoveryv1.EndpointPort{{Name: ptr.To("http"), Port: ptr.To[int32](80)}}
+		patch, fullUpdate, err := endpointSliceExportPatch(exported, desired, hashEndpoints(exported.Spec.Endpoints))
+		if err != nil || fullUpdate {
+			t.Fatalf("endpointSliceExportPatch() = %s, %t, %v, want a patch, false, no error", patch, fullUpdate, err)
+		}
+		want := `[{"op":"test","path":"/metadata/resourceVersion","value":"1"},` +
+			`{"op":"replace","path":"/spec/ports","value":[{"name":"http","port":80}]},` +
+			`{"op":"remove","path":"/spec/endpoints/1"}]`
+		if diff := cmp.Diff(want, string(patch)); diff != "" {
+			t.Errorf("endpointSliceExportPatch() mismatch (-want, +got):\n%s", diff)
+		}
+	})
+}
+
+// TestReconcile_DeltaExport tests that a small change to a large EndpointSlice is exported with a patch carrying
+// only the delta, and reports the bandwidth saved compared to sending the whole EndpointSliceExport.
+func TestReconcile_DeltaExport(t *testing.T) {
+	ctx := context.Background()
+	const endpointCount = 500
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", deltaTestAddrs(endpointCount)...)
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		Build()
+
+	var patches [][]byte
+	updates := 0
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, client client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if patch.Type() != types.JSONPatchType {
+					t.Errorf("Patch() type, got %s, want %s", patch.Type(), types.JSONPatchType)
+				}
+				data, err := patch.Data(obj)
+				if err != nil {
+					return err
+				}
+				patches = append(patches, data)
+				return client.Patch(ctx, obj, patch, opts...)
+			},
+			Update: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				updates++
+				return client.Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+
+	// Change the zone of one endpoint, remove another and add a new one.
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("Get(), got %v, want no error", err)
+	}
+	endpointSlice.Endpoints[10].Zone = ptr.To("zone-1")
+	endpointSlice.Endpoints = append(endpointSlice.Endpoints[:20], endpointSlice.Endpoints[21:]...)
+	endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"10.1.0.0"}})
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	if len(patches) != 1 || updates != 0 {
+		t.Fatalf("hub writes, got %d patches and %d updates, want 1 patch and no updates", len(patches), updates)
+	}
+
+	endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+	if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+		t.Fatalf("List(), got %v, want no error", err)
+	}
+	if len(endpointSliceExportList.Items) != 1 {
+		t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+	}
+	endpointSliceExport := &endpointSliceExportList.Items[0]
+	wantEndpoints := extractEndpointsFromEndpointSlice(endpointSlice, false)
+	sortEndpoints := func(endpoints []fleetnetv1alpha1.Endpoint) {
+		sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Addresses[0] < endpoints[j].Addresses[0] })
+	}
+	sortEndpoints(wantEndpoints)
+	gotEndpoints := endpointSliceExport.Spec.Endpoints
+	sortEndpoints(gotEndpoints)
+	if diff := cmp.Diff(wantEndpoints, gotEndpoints); diff != "" {
+		t.Errorf("exported endpoints mismatch (-want, +got):\n%s", diff)
+	}
+
+	full, err := json.Marshal(endpointSliceExport)
+	if err != nil {
+		t.Fatalf("Marshal(), got %v, want no error", err)
+	}
+	reduction := 1 - float64(len(patches[0]))/float64(len(full))
+	t.Logf("patch of %d bytes instead of %d bytes for the whole endpoint slice export of %d endpoints; bandwidth reduced by %.1f%%",
+		len(patches[0]), len(full), len(wantEndpoints), reduction*100)
+	if reduction < 0.9 {
+		t.Errorf("bandwidth reduction, got %.1f%%, want at least 90%%", reduction*100)
+	}
+
+	// Reconciling again without any change does not write to the hub cluster.
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+	if len(patches) != 1 || updates != 0 {
+		t.Errorf("hub writes, got %d patches and %d updates, want 1 patch and no updates", len(patches), updates)
+	}
+}
+
+// TestReconcile_RemovedFieldsCleared tests that the optional fields no longer populated for an endpoint are cleared
+// from the hub cluster, whether the EndpointSliceExport is patched or fully updated, and after the agent restarts.
+func TestReconcile_RemovedFieldsCleared(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", deltaTestAddrs(10)...)
+	svcExport.Annotations = nil
+	for i := range endpointSlice.Endpoints {
+		endpointSlice.Endpoints[i].Zone = ptr.To("zone-1")
+		endpointSlice.Endpoints[i].Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: "zone-1"}}}
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	newReconciler := func() *Reconciler {
+		return &Reconciler{
+			MemberClusterID: memberClusterID,
+			MemberClient:    fakeMemberClient,
+			HubClient:       fakeHubClient,
+			HubNamespace:    hubNSForMember,
+			Recorder:        record.NewFakeRecorder(10),
+		}
+	}
+	reconciler := newReconciler()
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+	}
+
+	exportedEndpoints := func() map[string]fleetnetv1alpha1.Endpoint {
+		endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+		if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+			t.Fatalf("List(), got %v, want no error", err)
+		}
+		if len(endpointSliceExportList.Items) != 1 {
+			t.Fatalf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+		}
+		endpoints := map[string]fleetnetv1alpha1.Endpoint{}
+		for _, endpoint := range endpointSliceExportList.Items[0].Spec.Endpoints {
+			endpoints[endpoint.Addresses[0]] = endpoint
+		}
+		return endpoints
+	}
+	removeTopology := func(reconciler *Reconciler, idx int) {
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		endpointSlice.Endpoints[idx].Zone = nil
+		endpointSlice.Endpoints[idx].Hints = nil
+		if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+			t.Fatalf("Update(), got %v, want no error", err)
+		}
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		addr := endpointSlice.Endpoints[idx].Addresses[0]
+		want := fleetnetv1alpha1.Endpoint{Addresses: []string{addr}}
+		if diff := cmp.Diff(want, exportedEndpoints()[addr]); diff != "" {
+			t.Errorf("exported endpoint %s mismatch (-want, +got):\n%s", addr, diff)
+		}
+	}
+
+	// The topology of one endpoint is removed; the endpoint is replaced by a patch.
+	removeTopology(reconciler, 0)
+	// The agent restarts and has not cached the endpoints exported so far.
+	removeTopology(newReconciler(), 1)
+}
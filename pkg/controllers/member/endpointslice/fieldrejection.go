@@ -0,0 +1,58 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+)
+
+const endpointSliceExportKind = "EndpointSliceExport"
+
+// reportHubFieldRejection sets the HubFieldRejected condition of the ServiceExport of an EndpointSlice when the hub
+// cluster rejects the fields of its EndpointSliceExport, and removes the condition set for the EndpointSliceExports
+// once the hub cluster accepts them, i.e. when no field is rejected.
+func (r *Reconciler) reportHubFieldRejection(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice, rejectedFields []string) error {
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	svcExportKey := types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Labels[discoveryv1.LabelServiceName]}
+	if err := r.MemberClient.Get(ctx, svcExportKey, svcExport); err != nil {
+		// The EndpointSlice is unexported when its ServiceExport is gone.
+		return client.IgnoreNotFound(err)
+	}
+	condType := string(fleetnetv1alpha1.ServiceExportHubFieldRejected)
+	cond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	if len(rejectedFields) == 0 {
+		if cond == nil || cond.Reason != condition.HubFieldRejectedReason(endpointSliceExportKind) {
+			return nil
+		}
+		meta.RemoveStatusCondition(&svcExport.Status.Conditions, condType)
+		klog.V(2).InfoS("The hub cluster accepts the endpoint slice exports of the service again", "serviceExport", klog.KObj(svcExport))
+		return r.MemberClient.Status().Update(ctx, svcExport)
+	}
+
+	desiredCond := condition.HubFieldRejectedCondition(svcExport, endpointSliceExportKind, rejectedFields)
+	// The message is compared as well, as it names the rejected fields.
+	if condition.EqualCondition(cond, &desiredCond) && cond.Message == desiredCond.Message {
+		return nil
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, desiredCond)
+	if err := r.MemberClient.Status().Update(ctx, svcExport); err != nil {
+		return err
+	}
+	r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "HubFieldRejected", "The hub cluster rejects the fields %s of the endpoint slice exports of Service %s",
+		strings.Join(rejectedFields, ", "), svcExport.Name)
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// TestReportHubFieldRejection tests that the HubFieldRejected condition of the ServiceExport names the fields the hub
+// cluster rejects, and is removed once the hub cluster accepts the EndpointSliceExports again.
+func TestReportHubFieldRejection(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "10.0.0.1")
+	svcExport.Generation = 2
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		Recorder:        recorder,
+	}
+	report := func(rejectedFields ...string) {
+		t.Helper()
+		if err := reconciler.reportHubFieldRejection(ctx, endpointSlice, rejectedFields); err != nil {
+			t.Fatalf("reportHubFieldRejection() = %v, want no error", err)
+		}
+	}
+	checkCondition := func(wantReason, wantMessage, wantEvent string) {
+		t.Helper()
+		got := &fleetnetv1alpha1.ServiceExport{}
+		if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, got); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		cond := meta.FindStatusCondition(got.Status.Conditions, string(fleetnetv1alpha1.ServiceExportHubFieldRejected))
+		switch {
+		case wantReason == "" && cond != nil:
+			t.Fatalf("HubFieldRejected condition, got %+v, want none", cond)
+		case wantReason != "" && (cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != wantReason ||
+			cond.Message != wantMessage || cond.ObservedGeneration != 2):
+			t.Fatalf("HubFieldRejected condition, got %+v, want reason %s and message %q", cond, wantReason, wantMessage)
+		}
+		select {
+		case event := <-recorder.Events:
+			if event != wantEvent {
+				t.Fatalf("event, got %q, want %q", event, wantEvent)
+			}
+		default:
+			if wantEvent != "" {
+				t.Fatalf("event, got none, want %q", wantEvent)
+			}
+		}
+	}
+
+	// Nothing is reported while the hub cluster accepts the EndpointSliceExports.
+	report()
+	checkCondition("", "", "")
+
+	report("spec.endpoints[0].hints")
+	checkCondition("EndpointSliceExportRejected",
+		"the hub cluster rejects the EndpointSliceExport of service work/app, as it does not accept the fields spec.endpoints[0].hints",
+		"Warning HubFieldRejected The hub cluster rejects the fields spec.endpoints[0].hints of the endpoint slice exports of Service app")
+
+	// The same rejection is reported once.
+	report("spec.endpoints[0].hints")
+	checkCondition("EndpointSliceExportRejected",
+		"the hub cluster rejects the EndpointSliceExport of service work/app, as it does not accept the fields spec.endpoints[0].hints", "")
+
+	report()
+	checkCondition("", "", "")
+
+	// The rejections of the InternalServiceExport are left to the ServiceExport controller.
+	got := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, got); err != nil {
+		t.Fatalf("Get(), got %v, want no error", err)
+	}
+	meta.SetStatusCondition(&got.Status.Conditions, metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportHubFieldRejected),
+		Status:             metav1.ConditionTrue,
+		Reason:             "InternalServiceExportRejected",
+		ObservedGeneration: 2,
+	})
+	if err := fakeMemberClient.Status().Update(ctx, got); err != nil {
+		t.Fatalf("Status().Update(), got %v, want no error", err)
+	}
+	report()
+	checkCondition("InternalServiceExportRejected", "", "")
+
+	// The EndpointSlices of the Services no longer exported are not reported.
+	endpointSlice.Labels[discoveryv1.LabelServiceName] = "gone"
+	report("spec.endpoints[0].hints")
+}
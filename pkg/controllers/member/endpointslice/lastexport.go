@@ -0,0 +1,97 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// annotatedVersionTracker keeps, for each EndpointSlice, the resource version the EndpointSlice was exported at and
+// the one it was left at by the last export time annotation.
+//
+// An EndpointSliceExport references the resource version of the EndpointSlice it was exported from; annotating the
+// EndpointSlice after the export changes the resource version, which would otherwise make the EndpointSliceExport
+// look stale and have it exported, and annotated, again endlessly. The tracker lives in memory only; after a restart
+// each annotated EndpointSlice is exported once more.
+type annotatedVersionTracker struct {
+	mu       sync.Mutex
+	versions map[types.NamespacedName]annotatedVersion
+}
+
+// annotatedVersion is the resource version an EndpointSlice was exported at, and the one the annotation left it at.
+type annotatedVersion struct {
+	exported  string
+	annotated string
+}
+
+// newAnnotatedVersionTracker returns an empty tracker of annotated resource versions.
+func newAnnotatedVersionTracker() *annotatedVersionTracker {
+	return &annotatedVersionTracker{
+		versions: make(map[types.NamespacedName]annotatedVersion),
+	}
+}
+
+// exported returns the EndpointSlice as of the resource version it was exported at, if it has not changed since
+// other than by the last export time annotation; the EndpointSlice itself is returned otherwise.
+func (t *annotatedVersionTracker) exported(endpointSliceKey types.NamespacedName, endpointSlice *discoveryv1.EndpointSlice) *discoveryv1.EndpointSlice {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.versions[endpointSliceKey]
+	if !ok || v.annotated != endpointSlice.ResourceVersion {
+		return endpointSlice
+	}
+	// A shallow copy suffices; only the resource version differs.
+	exported := *endpointSlice
+	exported.ResourceVersion = v.exported
+	return &exported
+}
+
+// record notes that an EndpointSlice exported at a resource version has been annotated into another one.
+func (t *annotatedVersionTracker) record(endpointSliceKey types.NamespacedName, exported, annotated string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.versions[endpointSliceKey] = annotatedVersion{exported: exported, annotated: annotated}
+}
+
+// forget removes the resource versions of an EndpointSlice which is no longer exported.
+func (t *annotatedVersionTracker) forget(endpointSliceKey types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.versions, endpointSliceKey)
+}
+
+// annotateLastExportTime annotates an EndpointSlice which has just been exported with the time of the export.
+//
+// The annotation is set with a merge patch rather than an update of the whole EndpointSlice, which may carry
+// many endpoints. The patch is guarded by the resource version the EndpointSlice was exported at, so that an
+// EndpointSlice which has changed since is left for the next reconciliation to export and annotate.
+func (r *Reconciler) annotateLastExportTime(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice) error {
+	clk := r.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	exportedVersion := endpointSlice.ResourceVersion
+	patch := client.MergeFromWithOptions(endpointSlice.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	if endpointSlice.Annotations == nil {
+		endpointSlice.Annotations = map[string]string{}
+	}
+	endpointSlice.Annotations[objectmeta.EndpointSliceAnnotationLastExportTime] = clk.Now().UTC().Format(time.RFC3339)
+	if err := r.MemberClient.Patch(ctx, endpointSlice, patch); err != nil {
+		return err
+	}
+	r.annotatedVersionTracker().record(types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name},
+		exportedVersion, endpointSlice.ResourceVersion)
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+var (
+	// exportedEndpointSlices is a Prometheus gauge metric which tracks the number of EndpointSlices currently
+	// exported by the member cluster.
+	exportedEndpointSlices = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "exported_endpointslices",
+			Help:      "The number of endpoint slices exported by the member cluster",
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the EndpointSlices.
+			"origin_cluster_id",
+		},
+	)
+
+	// endpointSliceExportsSkippedNoChange is a Prometheus counter metric which counts the reconciliations that skip
+	// updating an EndpointSliceExport as the EndpointSlice has not changed since it was last exported.
+	endpointSliceExportsSkippedNoChange = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.MetricsNamespace,
+			Name:      "endpointslice_export_skipped_no_change_total",
+			Help:      "The number of endpoint slice export updates skipped as the endpoint slice has not changed",
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the EndpointSlices.
+			"origin_cluster_id",
+		},
+	)
+
+	// exportedEndpointSliceTracker tracks the exported EndpointSlices behind the exportedEndpointSlices metric.
+	exportedEndpointSliceTracker = metrics.NewExportedObjectTracker(exportedEndpointSlices)
+)
+
+func init() {
+	// Register exportedEndpointSlices (fleet_networking_exported_endpointslices) and
+	// endpointSliceExportsSkippedNoChange (fleet_endpointslice_export_skipped_no_change_total) metrics with the
+	// controller runtime global metrics registry.
+	ctrlmetrics.Registry.MustRegister(exportedEndpointSlices, endpointSliceExportsSkippedNoChange)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// maxProgressiveExportSoakTime is the longest soak time allowed for progressive export.
+	maxProgressiveExportSoakTime = time.Hour
+
+	// defaultMaxTrackedReadyEndpoints caps the number of endpoints whose ready timestamps are kept in memory.
+	defaultMaxTrackedReadyEndpoints = 100000
+)
+
+// progressiveExportSoakTime returns the soak time configured on a ServiceExport for progressive export; it returns
+// 0 if progressive export is not enabled.
+func progressiveExportSoakTime(svcExport *fleetnetv1alpha1.ServiceExport) (time.Duration, error) {
+	val, found := svcExport.Annotations[objectmeta.ServiceExportAnnotationProgressiveExportSoakTime]
+	if !found {
+		return 0, nil
+	}
+	soakTime, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("the progressive export soak time annotation %q is not a valid duration: %w", val, err)
+	}
+	if soakTime < 0 || soakTime > maxProgressiveExportSoakTime {
+		return 0, fmt.Errorf("the progressive export soak time annotation %q is out of the range [0, %s]", val, maxProgressiveExportSoakTime)
+	}
+	return soakTime, nil
+}
+
+// readyEndpointTracker tracks when the ready endpoints of exported EndpointSlices were first seen ready, keyed by
+// EndpointSlice and then by the address set of the endpoint.
+//
+// The tracker lives in memory only. Endpoints of an EndpointSlice the tracker has never seen, e.g. after the
+// controller restarts, are considered to have been ready for long, so that a restart never withdraws endpoints from
+// the fleet; only endpoints which become ready later in a tracked EndpointSlice soak.
+type readyEndpointTracker struct {
+	mu           sync.Mutex
+	maxEndpoints int
+	size         int
+	readySince   map[types.NamespacedName]map[string]time.Time
+}
+
+// newReadyEndpointTracker returns a tracker which keeps the ready timestamps of at most maxEndpoints endpoints.
+func newReadyEndpointTracker(maxEndpoints int) *readyEndpointTracker {
+	return &readyEndpointTracker{
+		maxEndpoints: maxEndpoints,
+		readySince:   make(map[types.NamespacedName]map[string]time.Time),
+	}
+}
+
+// observe records the ready endpoints of an EndpointSlice and returns when each of them was first seen ready, keyed
+// by readyEndpointKey; the zero time is returned for endpoints considered to have been ready for long.
+func (t *readyEndpointTracker) observe(endpointSliceKey types.NamespacedName, endpoints []fleetnetv1alpha1.Endpoint, now time.Time) map[string]time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen, isTracked := t.readySince[endpointSliceKey]
+	readySince := make(map[string]time.Time, len(endpoints))
+	for _, endpoint := range endpoints {
+		addr := readyEndpointKey(&endpoint)
+		switch since, ok := seen[addr]; {
+		case ok:
+			readySince[addr] = since
+		case isTracked:
+			readySince[addr] = now
+		default:
+			readySince[addr] = time.Time{}
+		}
+	}
+
+	// Endpoints which are no longer ready are dropped; they soak again once they become ready again.
+	t.size -= len(seen)
+	if t.size+len(readySince) > t.maxEndpoints {
+		// Stop tracking the EndpointSlice when the tracker is full; it is considered unseen the next time.
+		delete(t.readySince, endpointSliceKey)
+		for addr := range readySince {
+			readySince[addr] = time.Time{}
+		}
+		return readySince
+	}
+	t.readySince[endpointSliceKey] = readySince
+	t.size += len(readySince)
+
+	res := make(map[string]time.Time, len(readySince))
+	for addr, since := range readySince {
+		res[addr] = since
+	}
+	return res
+}
+
+// forget stops tracking an EndpointSlice, e.g. when it is deleted or unexported.
+func (t *readyEndpointTracker) forget(endpointSliceKey types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.size -= len(t.readySince[endpointSliceKey])
+	delete(t.readySince, endpointSliceKey)
+}
+
+// filterSoakedEndpoints returns the endpoints which have been ready for at least the soak time, and the wait time
+// until the next filtered endpoint matures; the wait time is 0 if no endpoint is filtered.
+func filterSoakedEndpoints(endpoints []fleetnetv1alpha1.Endpoint, readySince map[string]time.Time, soakTime time.Duration, now time.Time) ([]fleetnetv1alpha1.Endpoint, time.Duration) {
+	soaked := make([]fleetnetv1alpha1.Endpoint, 0, len(endpoints))
+	var nextMaturity time.Duration
+	for _, endpoint := range endpoints {
+		untilMature := readySince[readyEndpointKey(&endpoint)].Add(soakTime).Sub(now)
+		if untilMature <= 0 {
+			soaked = append(soaked, endpoint)
+			continue
+		}
+		if nextMaturity == 0 || untilMature < nextMaturity {
+			nextMaturity = untilMature
+		}
+	}
+	return soaked, nextMaturity
+}
+
+// readyEndpointKey returns the key under which the ready timestamp of an endpoint is tracked: its sorted addresses,
+// so that an endpoint whose addresses are reordered keeps its timestamp, while an endpoint which gains or loses an
+// address soaks again.
+func readyEndpointKey(endpoint *fleetnetv1alpha1.Endpoint) string {
+	addrs := slices.Clone(endpoint.Addresses)
+	slices.Sort(addrs)
+	return strings.Join(addrs, ",")
+}
@@ -0,0 +1,329 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+const (
+	// ExportPausedDueToResourcePressureCondition is the type of the agent condition which reports whether the exports
+	// are paused while the member cluster is under resource pressure.
+	ExportPausedDueToResourcePressureCondition = "ExportPausedDueToResourcePressure"
+
+	conditionReasonResourcePressure   = "ResourcePressure"
+	conditionReasonNoResourcePressure = "NoResourcePressure"
+
+	// maxPodCIDRHostBits is the size, in bits, of the largest pod CIDR which may hold fewer addresses than the Pods a
+	// node allows; the pod CIDRs larger than it, e.g. the IPv6 ones, cannot be exhausted by the Pods of a node.
+	maxPodCIDRHostBits = 16
+)
+
+// podsHoldingAddresses selects the Pods which hold an address of the node they run on, i.e. those which are not on the
+// host network and have not terminated.
+var podsHoldingAddresses = fields.AndSelectors(
+	fields.OneTermEqualSelector("spec.hostNetwork", "false"),
+	fields.OneTermNotEqualSelector("status.phase", string(corev1.PodSucceeded)),
+	fields.OneTermNotEqualSelector("status.phase", string(corev1.PodFailed)),
+)
+
+var (
+	// exportPausedDueToResourcePressure reports whether the exports of the member cluster are paused while it is
+	// under resource pressure.
+	exportPausedDueToResourcePressure = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "endpointslice_export_paused_resource_pressure",
+			Help:      "Whether the endpoint slice exports are paused while the member cluster is under resource pressure (1) or not (0)",
+		},
+	)
+)
+
+func init() {
+	// Register exportPausedDueToResourcePressure (fleet_networking_endpointslice_export_paused_resource_pressure)
+	// metric with the controller runtime global metrics registry.
+	ctrlmetrics.Registry.MustRegister(exportPausedDueToResourcePressure)
+}
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=nodes,verbs=get;list
+
+// ResourcePressureMonitor periodically checks whether the member cluster is under resource pressure, so that the
+// EndpointSlice controller pauses its exports, which would only add to the pressure, until the pressure drops;
+// unexports, and exports which only remove endpoints, go on while the exports are paused.
+//
+// The member cluster is under resource pressure if any node runs more of the Pods it can hold than the threshold,
+// or if its nodes use more of their allocatable CPU, as reported by the Metrics Server, than the threshold. The Pods
+// a node can hold are its allocatable Pods, capped by the addresses of its pod CIDR, if any. A nil
+// ResourcePressureMonitor never pauses the exports. Checks which fail keep the previous results.
+type ResourcePressureMonitor struct {
+	// MemberClient reads the nodes and Pods of the member cluster, e.g. the cache returned by
+	// NewResourcePressureCache, which watches only the Pods holding an address and keeps only the fields checked.
+	MemberClient client.Reader
+	// MetricsClient reads the node metrics of the Metrics Server; it must read from the API server directly, as the
+	// metrics cannot be watched. The CPU utilization is not checked if it is not set, or while the metrics are not
+	// served.
+	MetricsClient client.Reader
+	// PodCIDRUtilizationThreshold is the percentage of the Pods a node can hold in use above which the exports are
+	// paused; the Pods of the nodes are not checked if it is not positive.
+	PodCIDRUtilizationThreshold int
+	// CPUUtilizationThreshold is the percentage of the allocatable CPU of the nodes in use above which the exports
+	// are paused; the CPU utilization is not checked if it is not positive.
+	CPUUtilizationThreshold int
+	// Interval is the interval between two checks; configv1alpha1.DefaultResourcePressureCheckInterval is used if it is not set.
+	Interval time.Duration
+
+	mu sync.RWMutex
+	// pressures describe the resource pressures found by the last check; the exports are paused if there is any.
+	pressures []string
+}
+
+// IsPaused returns whether the exports are paused for resource pressure.
+func (m *ResourcePressureMonitor) IsPaused() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.pressures) > 0
+}
+
+// CheckInterval returns the interval between two checks.
+func (m *ResourcePressureMonitor) CheckInterval() time.Duration {
+	if m == nil || m.Interval <= 0 {
+		return configv1alpha1.DefaultResourcePressureCheckInterval
+	}
+	return m.Interval
+}
+
+// AgentCondition returns the ExportPausedDueToResourcePressure condition the member agent reports in its status.
+func (m *ResourcePressureMonitor) AgentCondition() metav1.Condition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.pressures) == 0 {
+		return metav1.Condition{
+			Type:    ExportPausedDueToResourcePressureCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReasonNoResourcePressure,
+			Message: "the member cluster is not under resource pressure",
+		}
+	}
+	return metav1.Condition{
+		Type:   ExportPausedDueToResourcePressureCondition,
+		Status: metav1.ConditionTrue,
+		Reason: conditionReasonResourcePressure,
+		Message: fmt.Sprintf("the endpoint slice exports are paused until the member cluster is no longer under resource pressure: %s",
+			strings.Join(m.pressures, "; ")),
+	}
+}
+
+// Check reads the utilization of the resources of the member cluster and updates whether the exports are paused.
+func (m *ResourcePressureMonitor) Check(ctx context.Context) error {
+	nodeList := &corev1.NodeList{}
+	if err := m.MemberClient.List(ctx, nodeList); err != nil {
+		klog.ErrorS(err, "Failed to list the nodes; keeping the previous results of the resource pressure check")
+		return err
+	}
+
+	var pressures []string
+	if m.PodCIDRUtilizationThreshold > 0 {
+		pressure, err := m.checkPodCapacity(ctx, nodeList.Items)
+		if err != nil {
+			klog.ErrorS(err, "Failed to check the pod capacity of the nodes; keeping the previous results of the resource pressure check")
+			return err
+		}
+		if pressure != "" {
+			pressures = append(pressures, pressure)
+		}
+	}
+	if m.CPUUtilizationThreshold > 0 && m.MetricsClient != nil {
+		pressure, err := m.checkCPU(ctx, nodeList.Items)
+		if err != nil {
+			klog.ErrorS(err, "Failed to check the CPU utilization; keeping the previous results of the resource pressure check")
+			return err
+		}
+		if pressure != "" {
+			pressures = append(pressures, pressure)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wasPaused, isPaused := len(m.pressures) > 0, len(pressures) > 0
+	switch {
+	case !wasPaused && isPaused:
+		klog.InfoS("The member cluster is under resource pressure; the endpoint slice exports are paused", "pressures", pressures)
+	case wasPaused && !isPaused:
+		klog.InfoS("The member cluster is no longer under resource pressure; the endpoint slice exports are resumed")
+	}
+	paused := float64(0)
+	if isPaused {
+		paused = 1
+	}
+	exportPausedDueToResourcePressure.Set(paused)
+	m.pressures = pressures
+	return nil
+}
+
+// checkPodCapacity returns the pressure on the node which runs the most of the Pods it can hold, relative to its
+// capacity, if its utilization is above the threshold.
+func (m *ResourcePressureMonitor) checkPodCapacity(ctx context.Context, nodes []corev1.Node) (string, error) {
+	podList := &corev1.PodList{}
+	if err := m.MemberClient.List(ctx, podList); err != nil {
+		return "", err
+	}
+	podsByNode := map[string]int{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		// The Pods on the host network and those which have terminated hold no address of the node.
+		if pod.Spec.NodeName == "" || pod.Spec.HostNetwork || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podsByNode[pod.Spec.NodeName]++
+	}
+
+	var mostUtilizedNode string
+	var maxUtilization float64
+	for i := range nodes {
+		node := &nodes[i]
+		capacity := podCapacity(node)
+		if capacity <= 0 {
+			continue
+		}
+		if utilization := 100 * float64(podsByNode[node.Name]) / float64(capacity); utilization > maxUtilization {
+			mostUtilizedNode, maxUtilization = node.Name, utilization
+		}
+	}
+	if maxUtilization <= float64(m.PodCIDRUtilizationThreshold) {
+		return "", nil
+	}
+	return fmt.Sprintf("node %s runs %.0f%% of the pods it can hold, above the threshold of %d%%",
+		mostUtilizedNode, maxUtilization, m.PodCIDRUtilizationThreshold), nil
+}
+
+// podCapacity returns the number of Pods holding an address a node can run, i.e. its allocatable Pods, capped by the
+// addresses of its pod CIDR, if any; it returns 0 if the capacity of the node is not known.
+func podCapacity(node *corev1.Node) int64 {
+	capacity := node.Status.Allocatable.Pods().Value()
+	podCIDR, err := netip.ParsePrefix(node.Spec.PodCIDR)
+	if err != nil {
+		// The node has no pod CIDR, e.g. with Azure CNI, where Pods are assigned the addresses of the subnet.
+		return capacity
+	}
+	hostBits := podCIDR.Addr().BitLen() - podCIDR.Bits()
+	if hostBits > maxPodCIDRHostBits {
+		return capacity
+	}
+	// The network address and the one of the gateway are not assigned to Pods.
+	addresses := max(int64(1<<hostBits)-2, 0)
+	if capacity <= 0 || addresses < capacity {
+		return addresses
+	}
+	return capacity
+}
+
+// checkCPU returns the pressure on the CPU of the nodes, if their utilization is above the threshold.
+func (m *ResourcePressureMonitor) checkCPU(ctx context.Context, nodes []corev1.Node) (string, error) {
+	nodeMetricsList := &metricsv1beta1.NodeMetricsList{}
+	if err := m.MetricsClient.List(ctx, nodeMetricsList); err != nil {
+		if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+			// The Metrics Server is not installed or not available.
+			klog.V(2).InfoS("The node metrics are not served; the CPU utilization is not checked", "error", err)
+			return "", nil
+		}
+		return "", err
+	}
+	allocatable := make(map[string]int64, len(nodes))
+	for i := range nodes {
+		allocatable[nodes[i].Name] = nodes[i].Status.Allocatable.Cpu().MilliValue()
+	}
+	var usedMilliCPU, allocatableMilliCPU int64
+	for i := range nodeMetricsList.Items {
+		nodeMetrics := &nodeMetricsList.Items[i]
+		nodeAllocatable, ok := allocatable[nodeMetrics.Name]
+		if !ok {
+			// The node has been removed since it was measured.
+			continue
+		}
+		usedMilliCPU += nodeMetrics.Usage.Cpu().MilliValue()
+		allocatableMilliCPU += nodeAllocatable
+	}
+	if allocatableMilliCPU == 0 {
+		return "", nil
+	}
+	utilization := 100 * float64(usedMilliCPU) / float64(allocatableMilliCPU)
+	if utilization <= float64(m.CPUUtilizationThreshold) {
+		return "", nil
+	}
+	return fmt.Sprintf("the CPU of the nodes is %.0f%% utilized, above the threshold of %d%%", utilization, m.CPUUtilizationThreshold), nil
+}
+
+// Start checks the resource pressure periodically until the context is cancelled; it implements the
+// manager.Runnable interface.
+func (m *ResourcePressureMonitor) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		// Errors have been logged and the previous results are kept.
+		_ = m.Check(ctx)
+	}, m.CheckInterval())
+	return nil
+}
+
+// NewResourcePressureCache returns the cache of the nodes and Pods of the member cluster the ResourcePressureMonitor
+// reads, which watches only the Pods holding an address and keeps only the fields checked, rather than caching all
+// the Pods and nodes in full with the cache of the manager; the cache must be added to the manager.
+func NewResourcePressureCache(mgr manager.Manager) (cache.Cache, error) {
+	return cache.New(mgr.GetConfig(), cache.Options{
+		HTTPClient: mgr.GetHTTPClient(),
+		Scheme:     mgr.GetScheme(),
+		Mapper:     mgr.GetRESTMapper(),
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Pod{}: {Field: podsHoldingAddresses},
+		},
+		DefaultTransform: trimForResourcePressure,
+	})
+}
+
+// trimForResourcePressure keeps only the fields of the nodes and Pods the ResourcePressureMonitor checks.
+func trimForResourcePressure(obj interface{}) (interface{}, error) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: o.Namespace, Name: o.Name, UID: o.UID, ResourceVersion: o.ResourceVersion},
+			Spec:       corev1.PodSpec{NodeName: o.Spec.NodeName, HostNetwork: o.Spec.HostNetwork},
+			Status:     corev1.PodStatus{Phase: o.Status.Phase},
+		}, nil
+	case *corev1.Node:
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: o.Name, UID: o.UID, ResourceVersion: o.ResourceVersion},
+			Spec:       corev1.NodeSpec{PodCIDR: o.Spec.PodCIDR},
+			Status:     corev1.NodeStatus{Allocatable: o.Status.Allocatable},
+		}, nil
+	}
+	return obj, nil
+}
@@ -0,0 +1,346 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// resourcePressureTestThreshold is the utilization threshold of the resource pressure tests, in percent.
+const resourcePressureTestThreshold = 90
+
+// resourcePressureTestNode returns a node with a pod CIDR and allocatable CPU.
+func resourcePressureTestNode(name, podCIDR, allocatableCPU string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{PodCIDR: podCIDR},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(allocatableCPU)},
+		},
+	}
+}
+
+// resourcePressureTestNodeWithMaxPods sets the allocatable Pods of a node.
+func resourcePressureTestNodeWithMaxPods(node *corev1.Node, maxPods string) *corev1.Node {
+	node.Status.Allocatable[corev1.ResourcePods] = resource.MustParse(maxPods)
+	return node
+}
+
+// resourcePressureTestPods returns the given number of Pods running on a node.
+func resourcePressureTestPods(nodeName string, count int, mutate func(pod *corev1.Pod)) []client.Object {
+	pods := make([]client.Object, 0, count)
+	for i := 0; i < count; i++ {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: memberUserNS, Name: fmt.Sprintf("%s-pod-%d", nodeName, i)},
+			Spec:       corev1.PodSpec{NodeName: nodeName},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if mutate != nil {
+			mutate(pod)
+		}
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// resourcePressureTestNodeMetrics returns the metrics of a node using the given CPU.
+func resourcePressureTestNodeMetrics(name, usedCPU string) *metricsv1beta1.NodeMetrics {
+	return &metricsv1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Usage:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(usedCPU)},
+	}
+}
+
+func resourcePressureTestScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := scheme.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme(), got %v, want no error", err)
+	}
+	if err := metricsv1beta1.AddToScheme(s); err != nil {
+		t.Fatalf("AddToScheme(), got %v, want no error", err)
+	}
+	return s
+}
+
+// TestResourcePressureMonitor_Check tests that the exports are paused while the pod CIDR of a node or the CPU of the
+// nodes is utilized above the thresholds.
+func TestResourcePressureMonitor_Check(t *testing.T) {
+	testCases := []struct {
+		name               string
+		objects            []client.Object
+		metricsUnavailable bool
+		wantCondition      metav1.Condition
+	}{
+		{
+			name: "no resource pressure",
+			objects: append(resourcePressureTestPods("node-1", 100, nil),
+				resourcePressureTestNode("node-1", "10.244.0.0/24", "4"),
+				resourcePressureTestNodeMetrics("node-1", "2")),
+			wantCondition: metav1.Condition{
+				Type:    ExportPausedDueToResourcePressureCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonNoResourcePressure,
+				Message: "the member cluster is not under resource pressure",
+			},
+		},
+		{
+			name: "pod CIDR utilized above the threshold",
+			objects: append(resourcePressureTestPods("node-2", 60, nil),
+				resourcePressureTestNode("node-1", "10.244.0.0/24", "4"),
+				resourcePressureTestNode("node-2", "10.244.1.0/26", "4")),
+			wantCondition: metav1.Condition{
+				Type:   ExportPausedDueToResourcePressureCondition,
+				Status: metav1.ConditionTrue,
+				Reason: conditionReasonResourcePressure,
+				Message: "the endpoint slice exports are paused until the member cluster is no longer under resource pressure: " +
+					"node node-2 runs 97% of the pods it can hold, above the threshold of 90%",
+			},
+		},
+		{
+			name: "allocatable pods utilized above the threshold",
+			objects: append(resourcePressureTestPods("node-1", 105, nil),
+				resourcePressureTestNodeWithMaxPods(resourcePressureTestNode("node-1", "10.244.0.0/24", "4"), "110")),
+			metricsUnavailable: true,
+			wantCondition: metav1.Condition{
+				Type:   ExportPausedDueToResourcePressureCondition,
+				Status: metav1.ConditionTrue,
+				Reason: conditionReasonResourcePressure,
+				Message: "the endpoint slice exports are paused until the member cluster is no longer under resource pressure: " +
+					"node node-1 runs 95% of the pods it can hold, above the threshold of 90%",
+			},
+		},
+		{
+			name: "allocatable pods of a node without pod CIDR",
+			objects: append(resourcePressureTestPods("node-1", 20, nil),
+				resourcePressureTestNodeWithMaxPods(resourcePressureTestNode("node-1", "", "4"), "30")),
+			metricsUnavailable: true,
+			wantCondition: metav1.Condition{
+				Type:    ExportPausedDueToResourcePressureCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonNoResourcePressure,
+				Message: "the member cluster is not under resource pressure",
+			},
+		},
+		{
+			name: "host network and terminated pods hold no pod address",
+			objects: append(append(resourcePressureTestPods("node-1", 30, nil),
+				resourcePressureTestPods("node-1", 30, func(pod *corev1.Pod) {
+					pod.Name = "host-network-" + pod.Name
+					pod.Spec.HostNetwork = true
+				})...),
+				append(resourcePressureTestPods("node-1", 30, func(pod *corev1.Pod) {
+					pod.Name = "completed-" + pod.Name
+					pod.Status.Phase = corev1.PodSucceeded
+				}), resourcePressureTestNode("node-1", "10.244.1.0/26", "4"))...),
+			metricsUnavailable: true,
+			wantCondition: metav1.Condition{
+				Type:    ExportPausedDueToResourcePressureCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonNoResourcePressure,
+				Message: "the member cluster is not under resource pressure",
+			},
+		},
+		{
+			name: "nodes without pod CIDRs",
+			objects: append(resourcePressureTestPods("node-1", 300, nil),
+				resourcePressureTestNode("node-1", "", "4"),
+				resourcePressureTestNode("node-2", "fd00:10:244::/64", "4")),
+			wantCondition: metav1.Condition{
+				Type:    ExportPausedDueToResourcePressureCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonNoResourcePressure,
+				Message: "the member cluster is not under resource pressure",
+			},
+		},
+		{
+			name: "CPU utilized above the threshold",
+			objects: []client.Object{
+				resourcePressureTestNode("node-1", "10.244.0.0/24", "4"),
+				resourcePressureTestNode("node-2", "10.244.1.0/24", "4"),
+				resourcePressureTestNodeMetrics("node-1", "3900m"),
+				resourcePressureTestNodeMetrics("node-2", "3700m"),
+				// The node has been removed since it was measured.
+				resourcePressureTestNodeMetrics("node-3", "100m"),
+			},
+			wantCondition: metav1.Condition{
+				Type:   ExportPausedDueToResourcePressureCondition,
+				Status: metav1.ConditionTrue,
+				Reason: conditionReasonResourcePressure,
+				Message: "the endpoint slice exports are paused until the member cluster is no longer under resource pressure: " +
+					"the CPU of the nodes is 95% utilized, above the threshold of 90%",
+			},
+		},
+		{
+			name: "node metrics not served",
+			objects: []client.Object{
+				resourcePressureTestNode("node-1", "10.244.0.0/24", "4"),
+				resourcePressureTestNodeMetrics("node-1", "4"),
+			},
+			metricsUnavailable: true,
+			wantCondition: metav1.Condition{
+				Type:    ExportPausedDueToResourcePressureCondition,
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonNoResourcePressure,
+				Message: "the member cluster is not under resource pressure",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(resourcePressureTestScheme(t)).WithObjects(tc.objects...)
+			if tc.metricsUnavailable {
+				builder = builder.WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						if _, ok := list.(*metricsv1beta1.NodeMetricsList); ok {
+							return &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "metrics.k8s.io", Kind: "NodeMetrics"}}
+						}
+						return c.List(ctx, list, opts...)
+					},
+				})
+			}
+			fakeMemberClient := builder.Build()
+			monitor := &ResourcePressureMonitor{
+				MemberClient:                fakeMemberClient,
+				MetricsClient:               fakeMemberClient,
+				PodCIDRUtilizationThreshold: resourcePressureTestThreshold,
+				CPUUtilizationThreshold:     resourcePressureTestThreshold,
+			}
+			if err := monitor.Check(context.Background()); err != nil {
+				t.Fatalf("Check(), got %v, want no error", err)
+			}
+			if got := monitor.AgentCondition(); got != tc.wantCondition {
+				t.Errorf("AgentCondition(), got %+v, want %+v", got, tc.wantCondition)
+			}
+			if got, want := monitor.IsPaused(), tc.wantCondition.Status == metav1.ConditionTrue; got != want {
+				t.Errorf("IsPaused(), got %t, want %t", got, want)
+			}
+		})
+	}
+}
+
+// TestResourcePressureMonitor_CheckFailure tests that a failed check keeps the exports paused.
+func TestResourcePressureMonitor_CheckFailure(t *testing.T) {
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(resourcePressureTestScheme(t)).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+				return fmt.Errorf("the cache is not synced")
+			},
+		}).
+		Build()
+	monitor := &ResourcePressureMonitor{
+		MemberClient:                fakeMemberClient,
+		PodCIDRUtilizationThreshold: resourcePressureTestThreshold,
+		pressures:                   []string{"node node-1 runs 97% of the pods it can hold, above the threshold of 90%"},
+	}
+	if err := monitor.Check(context.Background()); err == nil {
+		t.Fatalf("Check(), got no error, want error")
+	}
+	if !monitor.IsPaused() {
+		t.Errorf("IsPaused(), got false, want true")
+	}
+}
+
+// TestReconcile_ResourcePressure tests that the EndpointSlices are not exported while the member cluster is under
+// resource pressure, unless the export only removes endpoints, but are still unexported.
+func TestReconcile_ResourcePressure(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := progressiveExportTestObjects("0s", "1.2.3.4", "1.2.3.5")
+	svcExport.Annotations = nil
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	underPressure := []string{"the CPU of the nodes is 95% utilized, above the threshold of 90%"}
+	monitor := &ResourcePressureMonitor{pressures: underPressure}
+	reconciler := &Reconciler{
+		MemberClusterID:         memberClusterID,
+		MemberClient:            fakeMemberClient,
+		HubClient:               fakeHubClient,
+		HubNamespace:            hubNSForMember,
+		Recorder:                record.NewFakeRecorder(10),
+		ResourcePressureMonitor: monitor,
+	}
+	reconcile := func(wantRequeueAfter string, wantAddresses ...string) {
+		t.Helper()
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey})
+		if err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+		}
+		if got := res.RequeueAfter.String(); got != wantRequeueAfter {
+			t.Fatalf("Reconcile(%+v) requeue after, got %s, want %s", endpointSliceKey, got, wantRequeueAfter)
+		}
+		endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+		if err := fakeHubClient.List(ctx, endpointSliceExportList); err != nil {
+			t.Fatalf("List(), got %v, want no error", err)
+		}
+		var gotAddresses []string
+		for _, export := range endpointSliceExportList.Items {
+			for _, endpoint := range export.Spec.Endpoints {
+				gotAddresses = append(gotAddresses, endpoint.Addresses...)
+			}
+		}
+		if diff := cmp.Diff(wantAddresses, gotAddresses); diff != "" {
+			t.Fatalf("exported addresses mismatch (-want, +got):\n%s", diff)
+		}
+	}
+	updateEndpoints := func(addrs ...string) {
+		t.Helper()
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("Get(), got %v, want no error", err)
+		}
+		endpointSlice.Endpoints = nil
+		for _, addr := range addrs {
+			endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{addr}})
+		}
+		if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+			t.Fatalf("Update(), got %v, want no error", err)
+		}
+	}
+	pausedRequeueAfter := monitor.CheckInterval().String()
+
+	// The export is paused until the next check of the resource pressure.
+	reconcile(pausedRequeueAfter)
+
+	monitor.pressures = nil
+	reconcile("0s", "1.2.3.4", "1.2.3.5")
+
+	// The removal of an endpoint is exported under resource pressure, but not the addition of one.
+	monitor.pressures = underPressure
+	updateEndpoints("1.2.3.4")
+	reconcile("0s", "1.2.3.4")
+	updateEndpoints("1.2.3.4", "1.2.3.6")
+	reconcile(pausedRequeueAfter, "1.2.3.4")
+
+	// The EndpointSlice of a Service no longer exported is unexported under resource pressure.
+	if err := fakeMemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("Delete(), got %v, want no error", err)
+	}
+	reconcile("0s")
+}
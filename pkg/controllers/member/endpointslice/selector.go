@@ -0,0 +1,109 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	// podKind is the kind of the objects endpoints reference when they are backed by Pods.
+	podKind = "Pod"
+
+	// endpointSelectorDisabledReason is the reason of the events of the ServiceExports whose endpoint selectors are
+	// not honored, as the endpoint selectors are disabled.
+	endpointSelectorDisabledReason = "EndpointSelectorDisabled"
+	// invalidEndpointSelectorReason is the reason of the events of the ServiceExports whose endpoint selectors are
+	// invalid.
+	invalidEndpointSelectorReason = "InvalidEndpointSelector"
+	// endpointsFilteredToZeroReason is the reason of the events of the EndpointSlices whose endpoints are all left
+	// out by the endpoint selectors of their ServiceExports.
+	endpointsFilteredToZeroReason = "EndpointsFilteredToZero"
+)
+
+// includedEndpoints returns the endpoints of an EndpointSlice whose Pods match the selector; endpoints which do not
+// reference a Pod, or whose Pod is gone, never match.
+//
+// The Pods which match the selector are listed once, as metadata only, from the cache of the Pod metadata the
+// controller watches, so that the memory used is bounded by the metadata of the Pods rather than their full specs.
+func (r *Reconciler) includedEndpoints(ctx context.Context, endpointSlice *discoveryv1.EndpointSlice, selector labels.Selector) ([]discoveryv1.Endpoint, error) {
+	podList := &metav1.PartialObjectMetadataList{}
+	podList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(podKind + "List"))
+	if err := r.MemberClient.List(ctx, podList,
+		client.InNamespace(endpointSlice.Namespace),
+		client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(podList.Items))
+	for i := range podList.Items {
+		matched[podList.Items[i].Name] = true
+	}
+
+	included := make([]discoveryv1.Endpoint, 0, len(endpointSlice.Endpoints))
+	for _, endpoint := range endpointSlice.Endpoints {
+		ref := endpoint.TargetRef
+		if ref == nil || ref.Kind != podKind || (ref.Namespace != "" && ref.Namespace != endpointSlice.Namespace) {
+			continue
+		}
+		if matched[ref.Name] {
+			included = append(included, endpoint)
+		}
+	}
+	return included, nil
+}
+
+// endpointSlicesForPod returns the requests of the EndpointSlices which reference a Pod, if the Services they belong
+// to are exported with an endpoint selector; the endpoints of these EndpointSlices may need to be exported or
+// withdrawn as the labels of the Pod change.
+func (r *Reconciler) endpointSlicesForPod(ctx context.Context, pod client.Object) []reconcile.Request {
+	svcExportList := &fleetnetv1alpha1.ServiceExportList{}
+	if err := r.MemberClient.List(ctx, svcExportList, client.InNamespace(pod.GetNamespace())); err != nil {
+		klog.ErrorS(err, "Failed to list service exports in the namespace of a pod", "pod", klog.KObj(pod))
+		return []reconcile.Request{}
+	}
+	reqs := []reconcile.Request{}
+	for _, svcExport := range svcExportList.Items {
+		if svcExport.Spec.IncludeOnlyEndpointsWithLabels == nil {
+			continue
+		}
+		endpointSliceList := &discoveryv1.EndpointSliceList{}
+		if err := r.MemberClient.List(ctx, endpointSliceList,
+			client.InNamespace(svcExport.Namespace),
+			client.MatchingLabels{discoveryv1.LabelServiceName: svcExport.Name}); err != nil {
+			klog.ErrorS(err, "Failed to list endpoint slices in use by a service", "serviceExport", klog.KObj(&svcExport))
+			continue
+		}
+		for _, endpointSlice := range endpointSliceList.Items {
+			if referencesPod(&endpointSlice, pod.GetName()) {
+				reqs = append(reqs, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: endpointSlice.Namespace, Name: endpointSlice.Name},
+				})
+			}
+		}
+	}
+	return reqs
+}
+
+// referencesPod returns if any endpoint of an EndpointSlice references the Pod of the given name.
+func referencesPod(endpointSlice *discoveryv1.EndpointSlice, podName string) bool {
+	for _, endpoint := range endpointSlice.Endpoints {
+		if ref := endpoint.TargetRef; ref != nil && ref.Kind == podKind && ref.Name == podName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	legacyPodName = "app-legacy"
+	newPodName    = "app-new"
+)
+
+func pod(name, track string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      name,
+			Labels:    map[string]string{"app": svcName, "track": track},
+		},
+	}
+}
+
+// endpointSelectorTestObjects returns a ServiceExport with the given endpoint selector, and an EndpointSlice with the
+// endpoints of a legacy Pod, a new Pod, and an endpoint which does not reference a Pod.
+func endpointSelectorTestObjects(selector *metav1.LabelSelector) (*fleetnetv1alpha1.ServiceExport, *discoveryv1.EndpointSlice) {
+	svcExport, endpointSlice := progressiveExportTestObjects("0s")
+	svcExport.Spec.IncludeOnlyEndpointsWithLabels = selector
+	endpointSlice.Endpoints = []discoveryv1.Endpoint{
+		{
+			Addresses: []string{"1.2.3.4"},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: legacyPodName},
+		},
+		{
+			Addresses: []string{"2.3.4.5"},
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: memberUserNS, Name: newPodName},
+		},
+		{
+			Addresses: []string{"3.4.5.6"},
+		},
+	}
+	return svcExport, endpointSlice
+}
+
+// TestReconcile_EndpointSelector tests that only the endpoints whose Pods match the endpoint selector of the
+// ServiceExport are exported, as the labels of the Pods and the selector change.
+func TestReconcile_EndpointSelector(t *testing.T) {
+	ctx := context.Background()
+	svcExport, endpointSlice := endpointSelectorTestObjects(&metav1.LabelSelector{MatchLabels: map[string]string{"track": "new"}})
+	legacyPod := pod(legacyPodName, "legacy")
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice, svcExport, legacyPod, pod(newPodName, "new")).
+		WithStatusSubresource(svcExport).
+		Build()
+	// Endpoints which change are patched at the end of the EndpointSliceExport.
+	sortAddrs := cmpopts.SortSlices(func(a, b string) bool { return a < b })
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        recorder,
+
+		EnableEndpointSelector: true,
+	}
+
+	addrs, _ := reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"2.3.4.5"}, addrs, sortAddrs); diff != "" {
+		t.Fatalf("exported addresses (-want, +got):\n%s", diff)
+	}
+	// Skip the event of the unique name assigned on the first export.
+	<-recorder.Events
+
+	// The legacy Pod is relabeled as it is migrated.
+	legacyPod.Labels["track"] = "new"
+	if err := fakeMemberClient.Update(ctx, legacyPod); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	addrs, _ = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4", "2.3.4.5"}, addrs, sortAddrs); diff != "" {
+		t.Fatalf("exported addresses after relabeling (-want, +got):\n%s", diff)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("got %d events, want none", len(recorder.Events))
+	}
+
+	// The selector is changed to one which no Pod matches.
+	svcExport.Spec.IncludeOnlyEndpointsWithLabels = &metav1.LabelSelector{MatchLabels: map[string]string{"track": "canary"}}
+	if err := fakeMemberClient.Update(ctx, svcExport); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	addrs, _ = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{}, addrs, sortAddrs); diff != "" {
+		t.Fatalf("exported addresses after changing the selector (-want, +got):\n%s", diff)
+	}
+	wantEvent := `Warning EndpointsFilteredToZero None of the 3 endpoints of endpoint slice app-endpointslice matches the endpoint selector "track=canary" of Service app`
+	if got := <-recorder.Events; got != wantEvent {
+		t.Errorf("event, got %q, want %q", got, wantEvent)
+	}
+	// The event is not recorded again while nothing changes.
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if len(recorder.Events) != 0 {
+		t.Fatalf("got %d events after reconciling again, want none", len(recorder.Events))
+	}
+
+	// All the endpoints are exported once the selector is removed.
+	svcExport.Spec.IncludeOnlyEndpointsWithLabels = nil
+	if err := fakeMemberClient.Update(ctx, svcExport); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	addrs, _ = reconcileAndGetExportedAddresses(t, reconciler)
+	if diff := cmp.Diff([]string{"1.2.3.4", "2.3.4.5", "3.4.5.6"}, addrs, sortAddrs); diff != "" {
+		t.Fatalf("exported addresses after removing the selector (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_EndpointSelectorDisabled tests that no endpoint is exported for a ServiceExport with an endpoint
+// selector while the endpoint selectors are disabled.
+func TestReconcile_EndpointSelectorDisabled(t *testing.T) {
+	svcExport, endpointSlice := endpointSelectorTestObjects(&metav1.LabelSelector{MatchLabels: map[string]string{"track": "new"}})
+	recorder := record.NewFakeRecorder(10)
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient: fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(endpointSlice, svcExport, pod(legacyPodName, "legacy"), pod(newPodName, "new")).
+			WithStatusSubresource(svcExport).
+			Build(),
+		HubClient:    fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace: hubNSForMember,
+		Recorder:     recorder,
+	}
+
+	addrs, _ := reconcileAndGetExportedAddresses(t, reconciler)
+	if len(addrs) != 0 {
+		t.Fatalf("exported addresses, got %v, want none", addrs)
+	}
+	// Skip the event of the unique name assigned on the first export.
+	<-recorder.Events
+	wantEvent := "Warning EndpointSelectorDisabled Service app has an endpoint selector, which is disabled in the member cluster, and no endpoint is exported"
+	if got := <-recorder.Events; got != wantEvent {
+		t.Errorf("event, got %q, want %q", got, wantEvent)
+	}
+}
+
+// TestEndpointSlicesForPod tests that a change to the labels of a Pod enqueues the EndpointSlices which reference it,
+// only if their Service is exported with an endpoint selector.
+func TestEndpointSlicesForPod(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"track": "new"}}
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		podName  string
+		want     []reconcile.Request
+	}{
+		{
+			name:     "referenced pod",
+			selector: selector,
+			podName:  legacyPodName,
+			want:     []reconcile.Request{{NamespacedName: endpointSliceKey}},
+		},
+		{
+			name:     "pod not referenced",
+			selector: selector,
+			podName:  "app-other",
+			want:     []reconcile.Request{},
+		},
+		{
+			name:    "no endpoint selector",
+			podName: legacyPodName,
+			want:    []reconcile.Request{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport, endpointSlice := endpointSelectorTestObjects(tc.selector)
+			reconciler := &Reconciler{
+				MemberClient: fake.NewClientBuilder().
+					WithScheme(scheme.Scheme).
+					WithObjects(endpointSlice, svcExport).
+					Build(),
+			}
+			got := reconciler.endpointSlicesForPod(context.Background(), pod(tc.podName, "new"))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("endpointSlicesForPod() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// skipLogInterval is the minimum period between two logs of the same EndpointSlice being skipped for
+	// reconciliation while it does not change.
+	skipLogInterval = 10 * time.Minute
+)
+
+// skipLogLimiter rate limits the logs of EndpointSlices which are skipped for reconciliation, e.g. those of the
+// Services which are not exported, as they are skipped again on every resync.
+//
+// The first skip of an EndpointSlice, and the first one after the EndpointSlice changes, is always logged; the
+// repeated skips of an unchanged EndpointSlice are suppressed and summarized by the next log, at most once per
+// interval. The limiter lives in memory only; after a restart each skipped EndpointSlice is logged once more.
+type skipLogLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	skips    map[types.NamespacedName]*endpointSliceSkips
+}
+
+// endpointSliceSkips is the log state of an EndpointSlice skipped for reconciliation.
+type endpointSliceSkips struct {
+	// resourceVersion is the resource version the EndpointSlice was last skipped at.
+	resourceVersion string
+	// lastLogged is when the skip of the EndpointSlice was last logged.
+	lastLogged time.Time
+	// suppressed is the number of skips which have not been logged since.
+	suppressed int
+}
+
+// newSkipLogLimiter returns a limiter which logs the skips of each unchanged EndpointSlice at most once per interval.
+func newSkipLogLimiter(interval time.Duration) *skipLogLimiter {
+	return &skipLogLimiter{
+		interval: interval,
+		skips:    make(map[types.NamespacedName]*endpointSliceSkips),
+	}
+}
+
+// allow notes that an EndpointSlice at a resource version has been skipped at the given time, and returns whether
+// the skip should be logged and, if so, the number of skips suppressed since the last log.
+func (l *skipLogLimiter) allow(endpointSliceKey types.NamespacedName, resourceVersion string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.skips[endpointSliceKey]
+	if !ok || s.resourceVersion != resourceVersion {
+		l.skips[endpointSliceKey] = &endpointSliceSkips{resourceVersion: resourceVersion, lastLogged: now}
+		return true, 0
+	}
+	if now.Sub(s.lastLogged) < l.interval {
+		s.suppressed++
+		return false, 0
+	}
+	suppressed := s.suppressed
+	s.lastLogged = now
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// forget removes the log state of an EndpointSlice which is no longer skipped.
+func (l *skipLogLimiter) forget(endpointSliceKey types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.skips, endpointSliceKey)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package endpointslice
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	"k8s.io/klog/v2/textlogger"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// captureLogs captures the logs of the test, up to the given verbosity.
+func captureLogs(t *testing.T, verbosity int) *bytes.Buffer {
+	flags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(flags)
+	originalVerbosity := flags.Lookup("v").Value.String()
+	if err := flags.Set("v", strconv.Itoa(verbosity)); err != nil {
+		t.Fatalf("Set(v), got %v, want no error", err)
+	}
+	buf := &bytes.Buffer{}
+	klog.SetLogger(textlogger.NewLogger(textlogger.NewConfig(textlogger.Verbosity(verbosity), textlogger.Output(buf))))
+	t.Cleanup(func() {
+		klog.ClearLogger()
+		_ = flags.Set("v", originalVerbosity)
+	})
+	return buf
+}
+
+// TestReconcile_SkipLogRateLimited tests that the repeated skips of an unchanged EndpointSlice are logged at most once
+// per interval, with the number of skips suppressed in between.
+func TestReconcile_SkipLogRateLimited(t *testing.T) {
+	ctx := context.Background()
+	// The Service of the EndpointSlice is not exported.
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSlice).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Clock:           fakeClock,
+	}
+	logs := captureLogs(t, 4)
+	reconcile := func(times int, wantLogs ...string) {
+		t.Helper()
+		logs.Reset()
+		for i := 0; i < times; i++ {
+			if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceKey}); err != nil {
+				t.Fatalf("Reconcile(%+v), got %v, want no error", endpointSliceKey, err)
+			}
+		}
+		var gotLogs []string
+		for _, line := range strings.Split(logs.String(), "\n") {
+			if strings.Contains(line, "Endpoint slice should be skipped for reconciliation") {
+				gotLogs = append(gotLogs, line)
+			}
+		}
+		if len(gotLogs) != len(wantLogs) {
+			t.Fatalf("skip logs, got %q, want %d logs", gotLogs, len(wantLogs))
+		}
+		for i, want := range wantLogs {
+			if !strings.Contains(gotLogs[i], want) {
+				t.Fatalf("skip log %d, got %q, want it to contain %q", i, gotLogs[i], want)
+			}
+		}
+	}
+
+	// The first skip is logged and the repeated ones are suppressed.
+	reconcile(5, "suppressedSkips=0")
+	fakeClock.Step(time.Minute)
+	reconcile(5)
+
+	// The skips suppressed are summarized once the interval has passed.
+	fakeClock.Step(skipLogInterval)
+	reconcile(3, "suppressedSkips=9")
+	reconcile(3)
+
+	// The skip of a changed EndpointSlice is logged right away.
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("Get(), got %v, want no error", err)
+	}
+	endpointSlice.Endpoints = []discoveryv1.Endpoint{{Addresses: []string{"1.2.3.4"}}}
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("Update(), got %v, want no error", err)
+	}
+	reconcile(2, "suppressedSkips=0")
+}
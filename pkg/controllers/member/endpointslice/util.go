@@ -7,17 +7,19 @@ package endpointslice
 
 import (
 	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 )
 
 // isEndpointSlicePermanentlyUnexportable returns if an EndpointSlice is permanently unexportable.
 func isEndpointSlicePermanentlyUnexportable(endpointSlice *discoveryv1.EndpointSlice) bool {
-	// At this moment only IPv4 endpointslices can be exported; note that AddressType is an immutable field.
-	return endpointSlice.AddressType != discoveryv1.AddressTypeIPv4
+	// At this moment only IPv4 and FQDN endpointslices can be exported; note that AddressType is an immutable field.
+	return endpointSlice.AddressType != discoveryv1.AddressTypeIPv4 && endpointSlice.AddressType != discoveryv1.AddressTypeFQDN
 }
 
 // isServiceExportValidWithNoConflict returns if a ServiceExport
@@ -46,19 +48,46 @@ func isEndpointSliceExportLinkedWithEndpointSlice(endpointSliceExport *fleetnetv
 	return (endpointSliceExport.Spec.EndpointSliceReference.UID == endpointSlice.UID)
 }
 
-// extractEndpointsFromEndpointSlice extracts endpoints from an EndpointSlice.
-func extractEndpointsFromEndpointSlice(endpointSlice *discoveryv1.EndpointSlice) []fleetnetv1alpha1.Endpoint {
+// isEndpointSliceExportUpToDate returns if an EndpointSliceExport has been exported from the current resource version
+// of an EndpointSlice with the given endpoints.
+func isEndpointSliceExportUpToDate(endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport,
+	endpointSlice *discoveryv1.EndpointSlice, endpoints []fleetnetv1alpha1.Endpoint) bool {
+	return isEndpointSliceExportLinkedWithEndpointSlice(endpointSliceExport, endpointSlice) &&
+		endpointSliceExport.Spec.EndpointSliceReference.ResourceVersion == endpointSlice.ResourceVersion &&
+		equality.Semantic.DeepEqual(endpointSliceExport.Spec.Endpoints, endpoints)
+}
+
+// extractEndpointsFromEndpointSlice extracts endpoints from an EndpointSlice; endpoints which are not ready are
+// extracted as well if exportNotReadyAddresses is true, the same way the publishNotReadyAddresses field of a Service
+// publishes the addresses of Pods regardless of their readiness.
+//
+// The endpoints returned are never nil; an EndpointSlice without endpoints, e.g. of a Service scaled to zero, is
+// exported with an empty list, so that the importing clusters converge to zero endpoints.
+func extractEndpointsFromEndpointSlice(endpointSlice *discoveryv1.EndpointSlice, exportNotReadyAddresses bool) []fleetnetv1alpha1.Endpoint {
 	extractedEndpoints := []fleetnetv1alpha1.Endpoint{}
+	// FQDNs are exported as-is; they are flagged on each endpoint so that importing clusters can tell them apart
+	// from IP addresses.
+	isFQDN := endpointSlice.AddressType == discoveryv1.AddressTypeFQDN
 	for _, endpoint := range endpointSlice.Endpoints {
 		// Only ready endpoints can be exported; EndpointSlice API dictates that consumers should interpret
 		// unknown ready state, represented by a nil value, as true ready state.
 		// TO-DO (chenyu1): In newer API versions the EndpointConditions API (V1) introduces a serving state, which
 		// allows a backend to serve traffic even if it is already terminating (EndpointSliceTerminationCondition
 		// feature gate).
-		if endpoint.Conditions.Ready == nil || *(endpoint.Conditions.Ready) {
-			extractedEndpoints = append(extractedEndpoints, fleetnetv1alpha1.Endpoint{
+		isReady := endpoint.Conditions.Ready == nil || *(endpoint.Conditions.Ready)
+		if exportNotReadyAddresses || isReady {
+			// Zone and hints are carried over for topology aware routing across clusters.
+			extractedEndpoint := fleetnetv1alpha1.Endpoint{
 				Addresses: endpoint.Addresses,
-			})
+				Zone:      endpoint.Zone,
+				Hints:     endpoint.Hints,
+				IsFQDN:    isFQDN,
+			}
+			// Not-ready endpoints are flagged so that importing clusters can tell whether traffic can flow.
+			if !isReady {
+				extractedEndpoint.Ready = ptr.To(false)
+			}
+			extractedEndpoints = append(extractedEndpoints, extractedEndpoint)
 		}
 	}
 	return extractedEndpoints
@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -29,9 +30,18 @@ const (
 type Reconciler struct {
 	MemberClient client.Client
 	HubClient    client.Client
+
+	// HeartbeatInterval is the interval at which the EndpointSliceExports whose EndpointSlices still exist are
+	// annotated with the time their EndpointSlices were last seen, so that the hub agent does not expire them; it must
+	// be well below the TTL of the EndpointSliceExports set on the hub agent. No heartbeat is written if it is not
+	// set, and the EndpointSliceExports are re-scanned every 5 minutes.
+	HeartbeatInterval time.Duration
+
+	// Clock is the clock by which the heartbeats are written; the real clock is used if it is not set.
+	Clock clock.Clock
 }
 
-//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceexports,verbs=get;list;watch;patch;delete
 //+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 
 // Reconcile verifies if an EndpointSliceExport in the hub cluster matches with a exported EndpointSlice from
@@ -93,9 +103,37 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return r.deleteEndpointSliceExport(ctx, endpointSliceExport)
 	}
 
-	// Periodically re-scan EndpointSliceExports; this help addresses corner cases where an EndpointSlice
-	// is deleted without the EndpointSlice controller getting a chance to withdraw it from the hub cluster.
-	return ctrl.Result{RequeueAfter: endpointSliceExportRetryInterval}, nil
+	if r.HeartbeatInterval <= 0 {
+		// Periodically re-scan EndpointSliceExports; this help addresses corner cases where an EndpointSlice
+		// is deleted without the EndpointSlice controller getting a chance to withdraw it from the hub cluster.
+		return ctrl.Result{RequeueAfter: endpointSliceExportRetryInterval}, nil
+	}
+
+	// Let the hub agent know that the EndpointSlice still exists; the re-scans double as heartbeats.
+	if err := r.heartbeat(ctx, endpointSliceExport); err != nil {
+		klog.ErrorS(err, "Failed to annotate the endpointSliceExport with the last seen time", "endpointSliceExport", endpointSliceExportRef)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.HeartbeatInterval}, nil
+}
+
+// heartbeat annotates an EndpointSliceExport with the current time as the time its EndpointSlice was last seen,
+// unless the annotation has been refreshed within the heartbeat interval.
+func (r *Reconciler) heartbeat(ctx context.Context, endpointSliceExport *fleetnetv1alpha1.EndpointSliceExport) error {
+	clk := r.Clock
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
+	now := clk.Now()
+	if lastSeen, err := time.Parse(time.RFC3339, endpointSliceExport.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen]); err == nil && now.Sub(lastSeen) < r.HeartbeatInterval {
+		return nil
+	}
+	patch := client.MergeFrom(endpointSliceExport.DeepCopy())
+	if endpointSliceExport.Annotations == nil {
+		endpointSliceExport.Annotations = map[string]string{}
+	}
+	endpointSliceExport.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen] = now.UTC().Format(time.RFC3339)
+	return client.IgnoreNotFound(r.HubClient.Patch(ctx, endpointSliceExport, patch))
 }
 
 // SetupWithManager builds a controller with Reconciler and sets it up with a controller manager.
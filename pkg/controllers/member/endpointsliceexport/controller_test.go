@@ -10,12 +10,15 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
@@ -163,3 +166,82 @@ func TestDeleteEndpointSliceExport(t *testing.T) {
 		})
 	}
 }
+
+// TestReconcile_Heartbeat tests that the EndpointSliceExports whose EndpointSlices still exist are annotated with the
+// time their EndpointSlices were last seen once every heartbeat interval, and that leftovers are deleted.
+func TestReconcile_Heartbeat(t *testing.T) {
+	ctx := context.Background()
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      endpointSliceName,
+			Annotations: map[string]string{
+				objectmeta.ExportedObjectAnnotationUniqueName: endpointSliceExportName,
+			},
+		},
+	}
+	newEndpointSliceExport := func(name, endpointSliceName string) *fleetnetv1alpha1.EndpointSliceExport {
+		return &fleetnetv1alpha1.EndpointSliceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: hubNSForMember,
+				Name:      name,
+			},
+			Spec: fleetnetv1alpha1.EndpointSliceExportSpec{
+				EndpointSliceReference: fleetnetv1alpha1.ExportedObjectReference{
+					Namespace: memberUserNS,
+					Name:      endpointSliceName,
+				},
+			},
+		}
+	}
+	leftoverKey := types.NamespacedName{Namespace: hubNSForMember, Name: "leftover-endpointsliceexport"}
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(newEndpointSliceExport(endpointSliceExportName, endpointSliceName), newEndpointSliceExport(leftoverKey.Name, "deleted-endpointslice")).
+		Build()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	reconciler := &Reconciler{
+		MemberClient:      fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(endpointSlice).Build(),
+		HubClient:         fakeHubClient,
+		HeartbeatInterval: 5 * time.Minute,
+		Clock:             fakeClock,
+	}
+	reconcile := func(key types.NamespacedName, wantRequeueAfter time.Duration) {
+		t.Helper()
+		res, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		if err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", key, err)
+		}
+		if res.RequeueAfter != wantRequeueAfter {
+			t.Fatalf("Reconcile(%+v) requeueAfter, got %v, want %v", key, res.RequeueAfter, wantRequeueAfter)
+		}
+	}
+	checkLastSeen := func(want string) {
+		t.Helper()
+		endpointSliceExport := &fleetnetv1alpha1.EndpointSliceExport{}
+		if err := fakeHubClient.Get(ctx, endpointSliceExportKey, endpointSliceExport); err != nil {
+			t.Fatalf("Get(%+v), got %v, want no error", endpointSliceExportKey, err)
+		}
+		if got := endpointSliceExport.Annotations[objectmeta.EndpointSliceExportAnnotationLastSeen]; got != want {
+			t.Fatalf("last seen annotation, got %q, want %q", got, want)
+		}
+	}
+
+	reconcile(endpointSliceExportKey, 5*time.Minute)
+	checkLastSeen("2024-05-01T10:00:00Z")
+
+	// The annotation is not refreshed within the heartbeat interval.
+	fakeClock.Step(time.Minute)
+	reconcile(endpointSliceExportKey, 5*time.Minute)
+	checkLastSeen("2024-05-01T10:00:00Z")
+
+	fakeClock.Step(4 * time.Minute)
+	reconcile(endpointSliceExportKey, 5*time.Minute)
+	checkLastSeen("2024-05-01T10:05:00Z")
+
+	// The EndpointSliceExport whose EndpointSlice is gone is deleted rather than annotated.
+	reconcile(leftoverKey, 0)
+	if err := fakeHubClient.Get(ctx, leftoverKey, &fleetnetv1alpha1.EndpointSliceExport{}); !errors.IsNotFound(err) {
+		t.Fatalf("Get(%+v), got %v, want not found error", leftoverKey, err)
+	}
+}
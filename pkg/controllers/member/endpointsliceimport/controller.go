@@ -16,6 +16,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation"
@@ -23,9 +24,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+	fleetlabels "go.goms.io/fleet-networking/pkg/common/labels"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
@@ -38,6 +44,9 @@ const (
 	mcsServiceImportRefFieldKey = ".spec.serviceImport.name"
 
 	endpointSliceImportRetryInterval = time.Second * 2
+
+	conditionReasonEndpointsReady   = "EndpointsReady"
+	conditionReasonNoReadyEndpoints = "NoReadyEndpoints"
 )
 
 var (
@@ -82,11 +91,14 @@ type Reconciler struct {
 	HubClient       client.Client
 	// The namespace reserved for fleet resources in the member cluster.
 	FleetSystemNamespace string
+	// The namespace reserved for the member cluster in the hub cluster, where its EndpointSliceImports are kept.
+	HubNamespace string
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=endpointsliceimports,verbs=get;list;watch;update;patch
-//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;create;update;patch;delete
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=multiclusterservices,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=multiclusterservices/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list
 
 // Reconcile imports an EndpointSlice from hub cluster.
@@ -102,11 +114,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// Retrieve the EndpointSliceImport.
 	endpointSliceImport := &fleetnetv1alpha1.EndpointSliceImport{}
 	if err := r.HubClient.Get(ctx, req.NamespacedName, endpointSliceImport); err != nil {
-		// Skip the reconciliation if the EndpointSliceImport does not exist; this should only happen when an
-		// EndpointSliceImport is deleted before the controller gets a chance to reconcile it, which
-		// requires no action to take on this controller's end.
+		// The EndpointSliceImport does not exist; this happens when an EndpointSliceImport is deleted before the
+		// controller gets a chance to reconcile it, or when an imported EndpointSlice is left behind by an
+		// EndpointSliceImport which is gone, e.g. one whose cleanup finalizer has been removed by hand. The
+		// orphaned EndpointSlice, if any, is deleted.
 		if errors.IsNotFound(err) {
-			klog.V(4).InfoS("Ignoring NotFound endpointSliceImport", "endpointSliceImport", endpointSliceImportRef)
+			klog.V(4).InfoS("EndpointSliceImport is not found; delete the orphaned EndpointSlice if any", "endpointSliceImport", endpointSliceImportRef)
+			if err := r.deleteOrphanedEndpointSlice(ctx, req.Name); err != nil {
+				klog.ErrorS(err, "Failed to delete orphaned EndpointSlice",
+					"endpointSliceImport", endpointSliceImportRef,
+					"endpointSlice", klog.KRef(r.FleetSystemNamespace, req.Name))
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{}, nil
 		}
 		klog.ErrorS(err, "Failed to get endpoint slice import", "endpointSliceImport", endpointSliceImportRef)
@@ -121,6 +140,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		klog.V(2).InfoS("EndpointSliceImport is deleted; unimport EndpointSlice",
 			"endpointSliceImport", endpointSliceImportRef,
 			"endpointSlice", endpointSliceRef)
+		// The endpoints ready condition must be updated while the cleanup finalizer is still present; otherwise
+		// the EndpointSliceImport may be gone before a failed update gets retried, leaving a stale condition behind.
+		if err := r.updateEndpointsReadyCondition(ctx, endpointSliceImport); err != nil {
+			klog.ErrorS(err, "Failed to update the endpoints ready condition of MCS",
+				"serviceImport", klog.KRef(endpointSliceImport.Spec.OwnerServiceReference.Namespace, endpointSliceImport.Spec.OwnerServiceReference.Name),
+				"endpointSliceImport", endpointSliceImportRef)
+			return ctrl.Result{}, err
+		}
 		if err := r.unimportEndpointSlice(ctx, endpointSliceImport); err != nil {
 			klog.ErrorS(err, "Failed to unimport EndpointSlice",
 				"endpointSliceImport", endpointSliceImportRef,
@@ -194,13 +221,37 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{RequeueAfter: endpointSliceImportRetryInterval}, nil
 	}
 
+	// FQDN endpoints cannot be load balanced by IP addresses; FQDN EndpointSlices are only imported onto derived
+	// Services which are ExternalName or headless.
+	if endpointSliceImport.Spec.AddressType == discoveryv1.AddressTypeFQDN {
+		acceptsFQDN, err := r.derivedServiceAcceptsFQDNEndpoints(ctx, derivedSvcName)
+		if err != nil {
+			klog.ErrorS(err, "Failed to check if derived Service accepts FQDN endpoints",
+				"derivedServiceName", derivedSvcName,
+				"endpointSliceImport", endpointSliceImportRef)
+			return ctrl.Result{}, err
+		}
+		if !acceptsFQDN {
+			klog.V(2).InfoS("Derived Service is neither ExternalName nor headless; FQDN EndpointSlice will not be imported",
+				"derivedServiceName", derivedSvcName,
+				"endpointSliceImport", endpointSliceImportRef)
+			// The EndpointSlice might have been imported before the derived Service changed.
+			if err := r.unimportEndpointSlice(ctx, endpointSliceImport); err != nil {
+				klog.ErrorS(err, "Failed to unimport EndpointSlice",
+					"endpointSliceImport", endpointSliceImportRef,
+					"endpointSlice", endpointSliceRef)
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, nil
+		}
+	}
+
 	// Special note:
 	// There exists a corner case where an MCS that imports a specific Service have multiple derived Services created;
 	// this is usually the result of direct label manipulation on the user's end. Ideally, this controller should watch
 	// for changes on MCS resources and (re)associate imported EndpointSlices to the latest dervied Service in use;
-	// however, unfortunately, with the current implementation of controller-runtime package, it is not possible
-	// for the controller to watch for resources on two different directions (member cluster and hub cluster),
-	// and as a result, an imported EndpointSlice could be bound to a derived Service that is no longer in use.
+	// however, the controller only watches the imported EndpointSlices in the member cluster, and as a result, an
+	// imported EndpointSlice could be bound to a derived Service that is no longer in use.
 	// Periodic resyncs can help address this issue, but it may take a quite long while before the situation is
 	// corrected, should this corner case happens.
 
@@ -212,7 +263,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
-	// Associate the EndpointSlice with the Service.
+	// Associate the EndpointSlice with the Service; this also reverts any manual edit of the imported EndpointSlice.
 	klog.V(2).InfoS("Import the EndpointSlice", "endpointSlice", endpointSliceRef)
 	endpointSlice := &discoveryv1.EndpointSlice{
 		ObjectMeta: metav1.ObjectMeta{
@@ -237,6 +288,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	if err := r.updateEndpointsReadyCondition(ctx, endpointSliceImport); err != nil {
+		klog.ErrorS(err, "Failed to update the endpoints ready condition of MCS",
+			"serviceImport", klog.KRef(ownerSvcNS, ownerSvcName),
+			"endpointSliceImport", endpointSliceImportRef)
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -262,9 +320,47 @@ func (r *Reconciler) SetupWithManager(ctx context.Context, memberCtrlMgr, hubCtr
 	return ctrl.NewControllerManagedBy(hubCtrlMgr).
 		// The EndpointSliceImport controller watches over EndpointSliceImport objects.
 		For(&fleetnetv1alpha1.EndpointSliceImport{}).
+		// The EndpointSliceImport controller also watches over the imported EndpointSlices in the member cluster,
+		// so that manual edits are reverted and orphaned EndpointSlices are deleted.
+		WatchesRawSource(source.Kind(memberCtrlMgr.GetCache(), &discoveryv1.EndpointSlice{},
+			handler.TypedEnqueueRequestsFromMapFunc(r.endpointSliceImportForEndpointSlice))).
 		Complete(r)
 }
 
+// endpointSliceImportForEndpointSlice returns the EndpointSliceImport an imported EndpointSlice is imported from.
+func (r *Reconciler) endpointSliceImportForEndpointSlice(_ context.Context, endpointSlice *discoveryv1.EndpointSlice) []reconcile.Request {
+	if endpointSlice.Namespace != r.FleetSystemNamespace || !isImportedEndpointSlice(endpointSlice) {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: r.HubNamespace, Name: endpointSlice.Name}},
+	}
+}
+
+// isImportedEndpointSlice returns if an EndpointSlice has been imported by this controller; the EndpointSlice is
+// identified by either its managed-by label or its imported-from annotation, as users may strip either of them.
+func isImportedEndpointSlice(endpointSlice *discoveryv1.EndpointSlice) bool {
+	if endpointSlice.Labels[discoveryv1.LabelManagedBy] == controllerID {
+		return true
+	}
+	_, ok := endpointSlice.Annotations[objectmeta.EndpointSliceAnnotationImportedFrom]
+	return ok
+}
+
+// deleteOrphanedEndpointSlice deletes the imported EndpointSlice of an EndpointSliceImport which is gone.
+func (r *Reconciler) deleteOrphanedEndpointSlice(ctx context.Context, name string) error {
+	endpointSlice := &discoveryv1.EndpointSlice{}
+	if err := r.MemberClient.Get(ctx, types.NamespacedName{Namespace: r.FleetSystemNamespace, Name: name}, endpointSlice); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	// EndpointSlices of the same name which have not been imported by this controller are left alone.
+	if !isImportedEndpointSlice(endpointSlice) || endpointSlice.DeletionTimestamp != nil {
+		return nil
+	}
+	klog.V(2).InfoS("Delete orphaned EndpointSlice", "endpointSlice", klog.KObj(endpointSlice))
+	return client.IgnoreNotFound(r.MemberClient.Delete(ctx, endpointSlice, client.Preconditions{UID: &endpointSlice.UID}))
+}
+
 // unimportEndpointSlice unimports an EndpointSlice.
 func (r *Reconciler) unimportEndpointSlice(ctx context.Context, endpointSliceImport *fleetnetv1alpha1.EndpointSliceImport) error {
 	// Skip the unimporting if the cleanup finalizer is not present on the EndpointSliceImport; the absence of this
@@ -320,6 +416,17 @@ func (r *Reconciler) isDerivedServiceValid(ctx context.Context, derivedSvcName s
 	return derivedSvc.DeletionTimestamp == nil, nil
 }
 
+// derivedServiceAcceptsFQDNEndpoints returns if a derived Service can serve FQDN endpoints, i.e. if it is an
+// ExternalName or headless Service.
+func (r *Reconciler) derivedServiceAcceptsFQDNEndpoints(ctx context.Context, derivedSvcName string) (bool, error) {
+	derivedSvc := &corev1.Service{}
+	derivedSvcKey := types.NamespacedName{Namespace: r.FleetSystemNamespace, Name: derivedSvcName}
+	if err := r.MemberClient.Get(ctx, derivedSvcKey, derivedSvc); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return derivedSvc.Spec.Type == corev1.ServiceTypeExternalName || derivedSvc.Spec.ClusterIP == corev1.ClusterIPNone, nil
+}
+
 // scanForDerivedServiceName scans a list of MCSes and returns the first found derived Service label in the list.
 func scanForDerivedServiceName(multiClusterSvcList *fleetnetv1alpha1.MultiClusterServiceList) string {
 	var derivedSvcName string
@@ -328,7 +435,7 @@ func scanForDerivedServiceName(multiClusterSvcList *fleetnetv1alpha1.MultiCluste
 			continue
 		}
 
-		svcName, ok := multiClusterSvc.Labels[objectmeta.MultiClusterServiceLabelDerivedService]
+		svcName, ok := fleetlabels.GetFleetLabel(&multiClusterSvc, objectmeta.MultiClusterServiceLabelDerivedService)
 		if ok {
 			derivedSvcName = svcName
 			break
@@ -344,17 +451,113 @@ func formatEndpointSliceFromImport(endpointSlice *discoveryv1.EndpointSlice, der
 		discoveryv1.LabelServiceName: derivedSvcName,
 		discoveryv1.LabelManagedBy:   controllerID,
 	}
+	metav1.SetMetaDataAnnotation(&endpointSlice.ObjectMeta, objectmeta.EndpointSliceAnnotationImportedFrom, types.NamespacedName{
+		Namespace: endpointSliceImport.Namespace,
+		Name:      endpointSliceImport.Name,
+	}.String())
 	endpointSlice.Ports = endpointSliceImport.Spec.Ports
 
 	endpoints := []discoveryv1.Endpoint{}
 	for _, importedEndpoint := range endpointSliceImport.Spec.Endpoints {
 		endpoints = append(endpoints, discoveryv1.Endpoint{
 			Addresses: importedEndpoint.Addresses,
+			// Endpoints exported while they are not ready are imported as neither ready nor serving; an unset
+			// condition is interpreted as ready and serving.
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:   importedEndpoint.Ready,
+				Serving: importedEndpoint.Ready,
+			},
+			Zone:  importedEndpoint.Zone,
+			Hints: importedEndpoint.Hints,
 		})
 	}
 	endpointSlice.Endpoints = endpoints
 }
 
+// updateEndpointsReadyCondition sets the EndpointsReady condition of the MCSes which import the Service owning an
+// EndpointSliceImport, based on the endpoints of all the EndpointSliceImports of the Service, i.e. the endpoints
+// exported by all the clusters exporting the Service; the condition is true if at least one of them is ready.
+func (r *Reconciler) updateEndpointsReadyCondition(ctx context.Context, endpointSliceImport *fleetnetv1alpha1.EndpointSliceImport) error {
+	ownerSvcRef := endpointSliceImport.Spec.OwnerServiceReference
+	multiClusterSvcList := &fleetnetv1alpha1.MultiClusterServiceList{}
+	if err := r.MemberClient.List(ctx,
+		multiClusterSvcList,
+		client.InNamespace(ownerSvcRef.Namespace),
+		client.MatchingFields{mcsServiceImportRefFieldKey: ownerSvcRef.Name}); err != nil {
+		return err
+	}
+	if len(multiClusterSvcList.Items) == 0 {
+		return nil
+	}
+
+	endpointSliceImportList := &fleetnetv1alpha1.EndpointSliceImportList{}
+	if err := r.HubClient.List(ctx, endpointSliceImportList, client.InNamespace(endpointSliceImport.Namespace)); err != nil {
+		return err
+	}
+	readyCount, totalCount := countEndpoints(endpointSliceImportList, ownerSvcRef.NamespacedName)
+
+	for i := range multiClusterSvcList.Items {
+		multiClusterSvc := &multiClusterSvcList.Items[i]
+		if multiClusterSvc.DeletionTimestamp != nil {
+			continue
+		}
+		desiredCond := endpointsReadyCondition(multiClusterSvc, readyCount, totalCount)
+		currentCond := meta.FindStatusCondition(multiClusterSvc.Status.Conditions, desiredCond.Type)
+		// The message carries the endpoint counts, which may change while the condition holds.
+		if condition.EqualCondition(currentCond, &desiredCond) && currentCond.Message == desiredCond.Message {
+			continue
+		}
+		meta.SetStatusCondition(&multiClusterSvc.Status.Conditions, desiredCond)
+		klog.V(2).InfoS("Updating the endpoints ready condition of MCS",
+			"multiClusterService", klog.KObj(multiClusterSvc),
+			"readyEndpoints", readyCount,
+			"totalEndpoints", totalCount)
+		if err := r.MemberClient.Status().Update(ctx, multiClusterSvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countEndpoints returns the number of ready endpoints, and the total number of endpoints, of the EndpointSliceImports
+// which are owned by the given Service, have been imported and have not been deleted.
+//
+// EndpointSliceImports which have never been imported, i.e. without the cleanup finalizer, are not counted, as their
+// deletion does not go through this controller, and could not update the count.
+func countEndpoints(endpointSliceImportList *fleetnetv1alpha1.EndpointSliceImportList, ownerSvcNamespacedName string) (readyCount, totalCount int) {
+	for i := range endpointSliceImportList.Items {
+		endpointSliceImport := &endpointSliceImportList.Items[i]
+		if endpointSliceImport.DeletionTimestamp != nil ||
+			!controllerutil.ContainsFinalizer(endpointSliceImport, endpointSliceImportCleanupFinalizer) ||
+			endpointSliceImport.Spec.OwnerServiceReference.NamespacedName != ownerSvcNamespacedName {
+			continue
+		}
+		for _, endpoint := range endpointSliceImport.Spec.Endpoints {
+			totalCount++
+			if endpoint.Ready == nil || *endpoint.Ready {
+				readyCount++
+			}
+		}
+	}
+	return readyCount, totalCount
+}
+
+// endpointsReadyCondition returns the EndpointsReady condition of an MCS given its endpoint counts.
+func endpointsReadyCondition(multiClusterSvc *fleetnetv1alpha1.MultiClusterService, readyCount, totalCount int) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonEndpointsReady,
+		ObservedGeneration: multiClusterSvc.Generation,
+		Message:            fmt.Sprintf("%d of %d endpoints are ready", readyCount, totalCount),
+	}
+	if readyCount == 0 {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = conditionReasonNoReadyEndpoints
+	}
+	return cond
+}
+
 // Observe data points for metrics.
 func (r *Reconciler) observeMetrics(ctx context.Context, endpointSliceImport *fleetnetv1alpha1.EndpointSliceImport, startTime time.Time) error {
 	// Check if a metric data point has been observed for the current generation of the object; this helps guard
@@ -15,13 +15,20 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
@@ -138,6 +145,9 @@ func importedIPv4EndpointSlice() *discoveryv1.EndpointSlice {
 				discoveryv1.LabelServiceName: derivedSvcName,
 				discoveryv1.LabelManagedBy:   controllerID,
 			},
+			Annotations: map[string]string{
+				objectmeta.EndpointSliceAnnotationImportedFrom: endpointSliceImportKey.String(),
+			},
 		},
 		AddressType: discoveryv1.AddressTypeIPv4,
 		Endpoints: []discoveryv1.Endpoint{
@@ -247,6 +257,40 @@ func TestFormatEndpointSliceFromImport(t *testing.T) {
 			endpointSliceImport: ipv4EndpointSliceImport(),
 			want:                importedIPv4EndpointSlice(),
 		},
+		{
+			name: "should format endpointslice with zones and hints using an endpointslice import",
+			endpointSliceImport: func() *fleetnetv1alpha1.EndpointSliceImport {
+				endpointSliceImport := ipv4EndpointSliceImport()
+				endpointSliceImport.Spec.Endpoints[0].Zone = ptr.To("eastus-1")
+				endpointSliceImport.Spec.Endpoints[0].Hints = &discoveryv1.EndpointHints{
+					ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+				}
+				return endpointSliceImport
+			}(),
+			want: func() *discoveryv1.EndpointSlice {
+				endpointSlice := importedIPv4EndpointSlice()
+				endpointSlice.Endpoints[0].Zone = ptr.To("eastus-1")
+				endpointSlice.Endpoints[0].Hints = &discoveryv1.EndpointHints{
+					ForZones: []discoveryv1.ForZone{{Name: "eastus-1"}},
+				}
+				return endpointSlice
+			}(),
+		},
+		{
+			name: "should format endpointslice with endpoints not ready using an endpointslice import",
+			endpointSliceImport: func() *fleetnetv1alpha1.EndpointSliceImport {
+				endpointSliceImport := ipv4EndpointSliceImport()
+				endpointSliceImport.Spec.Endpoints[0].Ready = ptr.To(false)
+				endpointSliceImport.Spec.Endpoints[1].Ready = ptr.To(true)
+				return endpointSliceImport
+			}(),
+			want: func() *discoveryv1.EndpointSlice {
+				endpointSlice := importedIPv4EndpointSlice()
+				endpointSlice.Endpoints[0].Conditions = discoveryv1.EndpointConditions{Ready: ptr.To(false), Serving: ptr.To(false)}
+				endpointSlice.Endpoints[1].Conditions = discoveryv1.EndpointConditions{Ready: ptr.To(true), Serving: ptr.To(true)}
+				return endpointSlice
+			}(),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -339,6 +383,96 @@ func TestIsDerivedServiceValid(t *testing.T) {
 	}
 }
 
+// TestDerivedServiceAcceptsFQDNEndpoints tests the derivedServiceAcceptsFQDNEndpoints function.
+func TestDerivedServiceAcceptsFQDNEndpoints(t *testing.T) {
+	testCases := []struct {
+		name       string
+		derivedSvc *corev1.Service
+		want       bool
+	}{
+		{
+			name: "load balancer svc",
+			derivedSvc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: fleetSystemNS,
+					Name:      derivedSvcName,
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeLoadBalancer,
+					ClusterIP: "10.0.0.1",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "cluster IP svc",
+			derivedSvc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: fleetSystemNS,
+					Name:      derivedSvcName,
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeClusterIP,
+					ClusterIP: "10.0.0.1",
+				},
+			},
+			want: false,
+		},
+		{
+			name: "headless svc",
+			derivedSvc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: fleetSystemNS,
+					Name:      derivedSvcName,
+				},
+				Spec: corev1.ServiceSpec{
+					Type:      corev1.ServiceTypeClusterIP,
+					ClusterIP: corev1.ClusterIPNone,
+				},
+			},
+			want: true,
+		},
+		{
+			name: "external name svc",
+			derivedSvc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: fleetSystemNS,
+					Name:      derivedSvcName,
+				},
+				Spec: corev1.ServiceSpec{
+					Type:         corev1.ServiceTypeExternalName,
+					ExternalName: "example.com",
+				},
+			},
+			want: true,
+		},
+		{
+			name: "svc not found",
+			want: false,
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeMemberClientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tc.derivedSvc != nil {
+				fakeMemberClientBuilder = fakeMemberClientBuilder.WithObjects(tc.derivedSvc)
+			}
+			reconciler := Reconciler{
+				MemberClient:         fakeMemberClientBuilder.Build(),
+				HubClient:            fake.NewClientBuilder().Build(),
+				FleetSystemNamespace: fleetSystemNS,
+			}
+
+			if got, err := reconciler.derivedServiceAcceptsFQDNEndpoints(ctx, derivedSvcName); got != tc.want || err != nil {
+				t.Fatalf("derivedServiceAcceptsFQDNEndpoints(%s) = %t, %v, want %t, no error", derivedSvcName, got, err, tc.want)
+			}
+		})
+	}
+}
+
 // TestUnimportEndpointSlice_WithFinalizer tests the *Reconciler.unimportEndpointSlice method.
 func TestUnimportEndpointSlice_WithFinalizer(t *testing.T) {
 	testCases := []struct {
@@ -720,3 +854,453 @@ func TestObserveMetrics(t *testing.T) {
 		})
 	}
 }
+
+// TestUpdateEndpointsReadyCondition tests the *Reconciler.updateEndpointsReadyCondition method.
+func TestUpdateEndpointsReadyCondition(t *testing.T) {
+	ownerSvcNamespacedName := fmt.Sprintf("%s/%s", memberUserNS, svcName)
+	endpointSliceImport := ipv4EndpointSliceImport()
+	endpointSliceImport.Finalizers = []string{endpointSliceImportCleanupFinalizer}
+	endpointSliceImport.Spec.OwnerServiceReference.NamespacedName = ownerSvcNamespacedName
+	notReadyEndpointSliceImport := ipv4EndpointSliceImport()
+	notReadyEndpointSliceImport.Name = "bravelion-work-appendpoint-slice-2b3cd"
+	notReadyEndpointSliceImport.Finalizers = []string{endpointSliceImportCleanupFinalizer}
+	notReadyEndpointSliceImport.Spec.OwnerServiceReference.NamespacedName = ownerSvcNamespacedName
+	notImportedEndpointSliceImport := ipv4EndpointSliceImport()
+	notImportedEndpointSliceImport.Name = "bravelion-work-appendpoint-slice-4d5ef"
+	notImportedEndpointSliceImport.Spec.OwnerServiceReference.NamespacedName = ownerSvcNamespacedName
+	notReadyEndpointSliceImport.Spec.Endpoints = []fleetnetv1alpha1.Endpoint{
+		{
+			Addresses: []string{"3.4.5.6"},
+			Ready:     ptr.To(false),
+		},
+	}
+	otherSvcEndpointSliceImport := ipv4EndpointSliceImport()
+	otherSvcEndpointSliceImport.Name = "bravelion-work-webendpoint-slice-3c4de"
+	otherSvcEndpointSliceImport.Finalizers = []string{endpointSliceImportCleanupFinalizer}
+	otherSvcEndpointSliceImport.Spec.OwnerServiceReference = fleetnetv1alpha1.OwnerServiceReference{
+		Namespace:      memberUserNS,
+		Name:           "web",
+		NamespacedName: fmt.Sprintf("%s/%s", memberUserNS, "web"),
+	}
+
+	testCases := []struct {
+		name                 string
+		endpointSliceImports []*fleetnetv1alpha1.EndpointSliceImport
+		wantCondition        metav1.Condition
+	}{
+		{
+			name:                 "some endpoints are ready",
+			endpointSliceImports: []*fleetnetv1alpha1.EndpointSliceImport{endpointSliceImport, notReadyEndpointSliceImport, otherSvcEndpointSliceImport},
+			wantCondition: metav1.Condition{
+				Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+				Status:             metav1.ConditionTrue,
+				Reason:             conditionReasonEndpointsReady,
+				ObservedGeneration: 1,
+				Message:            "2 of 3 endpoints are ready",
+			},
+		},
+		{
+			name:                 "no endpoint is ready",
+			endpointSliceImports: []*fleetnetv1alpha1.EndpointSliceImport{notReadyEndpointSliceImport, otherSvcEndpointSliceImport},
+			wantCondition: metav1.Condition{
+				Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+				Status:             metav1.ConditionFalse,
+				Reason:             conditionReasonNoReadyEndpoints,
+				ObservedGeneration: 1,
+				Message:            "0 of 1 endpoints are ready",
+			},
+		},
+		{
+			name:                 "endpointSliceImport not imported yet",
+			endpointSliceImports: []*fleetnetv1alpha1.EndpointSliceImport{notReadyEndpointSliceImport, notImportedEndpointSliceImport},
+			wantCondition: metav1.Condition{
+				Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+				Status:             metav1.ConditionFalse,
+				Reason:             conditionReasonNoReadyEndpoints,
+				ObservedGeneration: 1,
+				Message:            "0 of 1 endpoints are ready",
+			},
+		},
+		{
+			name:                 "the last endpointSliceImport is deleted",
+			endpointSliceImports: []*fleetnetv1alpha1.EndpointSliceImport{otherSvcEndpointSliceImport},
+			wantCondition: metav1.Condition{
+				Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+				Status:             metav1.ConditionFalse,
+				Reason:             conditionReasonNoReadyEndpoints,
+				ObservedGeneration: 1,
+				Message:            "0 of 0 endpoints are ready",
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			multiClusterSvc := &fleetnetv1alpha1.MultiClusterService{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  memberUserNS,
+					Name:       svcName,
+					Generation: 1,
+				},
+				Spec: fleetnetv1alpha1.MultiClusterServiceSpec{
+					ServiceImport: fleetnetv1alpha1.ServiceImportRef{Name: svcName},
+				},
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(multiClusterSvc).
+				WithStatusSubresource(multiClusterSvc).
+				WithIndex(&fleetnetv1alpha1.MultiClusterService{}, mcsServiceImportRefFieldKey, func(o client.Object) []string {
+					return []string{o.(*fleetnetv1alpha1.MultiClusterService).Spec.ServiceImport.Name}
+				}).
+				Build()
+			fakeHubClientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			for _, obj := range tc.endpointSliceImports {
+				fakeHubClientBuilder = fakeHubClientBuilder.WithObjects(obj.DeepCopy())
+			}
+			reconciler := Reconciler{
+				MemberClient:         fakeMemberClient,
+				HubClient:            fakeHubClientBuilder.Build(),
+				FleetSystemNamespace: fleetSystemNS,
+			}
+
+			if err := reconciler.updateEndpointsReadyCondition(ctx, endpointSliceImport); err != nil {
+				t.Fatalf("updateEndpointsReadyCondition(), got %v, want no error", err)
+			}
+
+			updatedMultiClusterSvc := &fleetnetv1alpha1.MultiClusterService{}
+			if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, updatedMultiClusterSvc); err != nil {
+				t.Fatalf("multiClusterService Get(), got %v, want no error", err)
+			}
+			if diff := cmp.Diff([]metav1.Condition{tc.wantCondition}, updatedMultiClusterSvc.Status.Conditions,
+				cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("multiClusterService conditions (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestReconcile_DeletedEndpointSliceImportConditionUpdateFailure tests that the EndpointsReady condition of an MCS is
+// updated eventually when the EndpointSliceImport of its last endpoints is deleted, even if the first attempt to
+// update the condition fails.
+func TestReconcile_DeletedEndpointSliceImportConditionUpdateFailure(t *testing.T) {
+	ctx := context.Background()
+	multiClusterSvc := &fleetnetv1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  memberUserNS,
+			Name:       svcName,
+			Generation: 1,
+		},
+		Spec: fleetnetv1alpha1.MultiClusterServiceSpec{
+			ServiceImport: fleetnetv1alpha1.ServiceImportRef{Name: svcName},
+		},
+		Status: fleetnetv1alpha1.MultiClusterServiceStatus{
+			Conditions: []metav1.Condition{
+				{
+					Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+					Status:             metav1.ConditionTrue,
+					Reason:             conditionReasonEndpointsReady,
+					ObservedGeneration: 1,
+					Message:            "2 of 2 endpoints are ready",
+				},
+			},
+		},
+	}
+	statusUpdateFailures := 1
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(multiClusterSvc, importedIPv4EndpointSlice()).
+		WithStatusSubresource(multiClusterSvc).
+		WithIndex(&fleetnetv1alpha1.MultiClusterService{}, mcsServiceImportRefFieldKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.MultiClusterService).Spec.ServiceImport.Name}
+		}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if statusUpdateFailures > 0 {
+					statusUpdateFailures--
+					return fmt.Errorf("status update failure")
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	endpointSliceImport := ipv4EndpointSliceImport()
+	endpointSliceImport.Finalizers = []string{endpointSliceImportCleanupFinalizer}
+	endpointSliceImport.DeletionTimestamp = ptr.To(metav1.Now())
+	endpointSliceImport.Spec.OwnerServiceReference.NamespacedName = fmt.Sprintf("%s/%s", memberUserNS, svcName)
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSliceImport).
+		Build()
+	reconciler := Reconciler{
+		MemberClusterID:      memberClusterID,
+		MemberClient:         fakeMemberClient,
+		HubClient:            fakeHubClient,
+		FleetSystemNamespace: fleetSystemNS,
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceImportKey}); err == nil {
+		t.Fatalf("Reconcile() with a failed status update, got no error, want error")
+	}
+	// The cleanup finalizer is kept, so that the update of the condition is retried.
+	if err := fakeHubClient.Get(ctx, endpointSliceImportKey, endpointSliceImport); err != nil {
+		t.Fatalf("endpointSliceImport Get(), got %v, want no error", err)
+	}
+
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceImportKey}); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := fakeHubClient.Get(ctx, endpointSliceImportKey, endpointSliceImport); !errors.IsNotFound(err) {
+		t.Errorf("endpointSliceImport Get(), got %v, want not found error", err)
+	}
+	updatedMultiClusterSvc := &fleetnetv1alpha1.MultiClusterService{}
+	if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: svcName}, updatedMultiClusterSvc); err != nil {
+		t.Fatalf("multiClusterService Get(), got %v, want no error", err)
+	}
+	wantConditions := []metav1.Condition{
+		{
+			Type:               string(fleetnetv1alpha1.MultiClusterServiceEndpointsReady),
+			Status:             metav1.ConditionFalse,
+			Reason:             conditionReasonNoReadyEndpoints,
+			ObservedGeneration: 1,
+			Message:            "0 of 0 endpoints are ready",
+		},
+	}
+	if diff := cmp.Diff(wantConditions, updatedMultiClusterSvc.Status.Conditions,
+		cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Errorf("multiClusterService conditions (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_ScaleToZero tests that an imported EndpointSlice converges to zero endpoints, keeping its ports, when
+// the exported Service is scaled to zero, with no stale endpoint left behind, and that it gets the endpoints again
+// once the Service scales back up.
+func TestReconcile_ScaleToZero(t *testing.T) {
+	ctx := context.Background()
+	multiClusterSvc := &fleetnetv1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Labels:    map[string]string{objectmeta.MultiClusterServiceLabelDerivedService: derivedSvcName},
+		},
+		Spec: fleetnetv1alpha1.MultiClusterServiceSpec{
+			ServiceImport: fleetnetv1alpha1.ServiceImportRef{Name: svcName},
+		},
+	}
+	derivedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fleetSystemNS,
+			Name:      derivedSvcName,
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(multiClusterSvc, derivedSvc, importedIPv4EndpointSlice()).
+		WithStatusSubresource(multiClusterSvc).
+		WithIndex(&fleetnetv1alpha1.MultiClusterService{}, mcsServiceImportRefFieldKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.MultiClusterService).Spec.ServiceImport.Name}
+		}).
+		Build()
+	endpointSliceImport := ipv4EndpointSliceImport()
+	endpointSliceImport.Spec.OwnerServiceReference.NamespacedName = fmt.Sprintf("%s/%s", memberUserNS, svcName)
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(endpointSliceImport).
+		Build()
+	reconciler := Reconciler{
+		MemberClusterID:      memberClusterID,
+		MemberClient:         fakeMemberClient,
+		HubClient:            fakeHubClient,
+		FleetSystemNamespace: fleetSystemNS,
+	}
+
+	// scale imports the given endpoints, and checks the endpoints of the imported EndpointSlice.
+	scale := func(endpoints []fleetnetv1alpha1.Endpoint, wantEndpoints []discoveryv1.Endpoint) {
+		t.Helper()
+		if err := fakeHubClient.Get(ctx, endpointSliceImportKey, endpointSliceImport); err != nil {
+			t.Fatalf("endpointSliceImport Get(), got %v, want no error", err)
+		}
+		endpointSliceImport.Spec.Endpoints = endpoints
+		endpointSliceImport.Spec.EndpointSliceReference.Generation++
+		if err := fakeHubClient.Update(ctx, endpointSliceImport); err != nil {
+			t.Fatalf("endpointSliceImport Update(), got %v, want no error", err)
+		}
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceImportKey}); err != nil {
+			t.Fatalf("Reconcile(), got %v, want no error", err)
+		}
+
+		endpointSlice := &discoveryv1.EndpointSlice{}
+		if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: fleetSystemNS, Name: endpointSliceImportName}, endpointSlice); err != nil {
+			t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+		}
+		if diff := cmp.Diff(wantEndpoints, endpointSlice.Endpoints); diff != "" {
+			t.Errorf("imported endpoints (-want, +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(importedIPv4EndpointSlice().Ports, endpointSlice.Ports); diff != "" {
+			t.Errorf("imported ports (-want, +got):\n%s", diff)
+		}
+	}
+
+	scale([]fleetnetv1alpha1.Endpoint{}, []discoveryv1.Endpoint{})
+	scale([]fleetnetv1alpha1.Endpoint{{Addresses: []string{"3.4.5.6"}}}, []discoveryv1.Endpoint{{Addresses: []string{"3.4.5.6"}}})
+}
+
+// importTestReconciler returns a reconciler, and its fake member and hub clients, which can import an
+// EndpointSliceImport into the derived Service of its MCS.
+func importTestReconciler(memberObjs, hubObjs []client.Object) (*Reconciler, client.Client, client.Client) {
+	multiClusterSvc := &fleetnetv1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Labels:    map[string]string{objectmeta.MultiClusterServiceLabelDerivedService: derivedSvcName},
+		},
+		Spec: fleetnetv1alpha1.MultiClusterServiceSpec{
+			ServiceImport: fleetnetv1alpha1.ServiceImportRef{Name: svcName},
+		},
+	}
+	derivedSvc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: fleetSystemNS,
+			Name:      derivedSvcName,
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(append(memberObjs, multiClusterSvc, derivedSvc)...).
+		WithStatusSubresource(multiClusterSvc).
+		WithIndex(&fleetnetv1alpha1.MultiClusterService{}, mcsServiceImportRefFieldKey, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.MultiClusterService).Spec.ServiceImport.Name}
+		}).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(hubObjs...).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID:      memberClusterID,
+		MemberClient:         fakeMemberClient,
+		HubClient:            fakeHubClient,
+		FleetSystemNamespace: fleetSystemNS,
+		HubNamespace:         hubNSForMember,
+	}
+	return reconciler, fakeMemberClient, fakeHubClient
+}
+
+// TestReconcile_CreateAndUpdate tests that an EndpointSliceImport is imported as an EndpointSlice of the derived
+// Service, which follows the changes of the EndpointSliceImport, and whose manual edits are reverted.
+func TestReconcile_CreateAndUpdate(t *testing.T) {
+	ctx := context.Background()
+	endpointSliceImport := ipv4EndpointSliceImport()
+	endpointSliceImport.Spec.OwnerServiceReference.NamespacedName = fmt.Sprintf("%s/%s", memberUserNS, svcName)
+	reconciler, fakeMemberClient, fakeHubClient := importTestReconciler(nil, []client.Object{endpointSliceImport})
+	endpointSliceKey := types.NamespacedName{Namespace: fleetSystemNS, Name: endpointSliceImportName}
+
+	// reconcileAndCheck reconciles the EndpointSliceImport, and checks the imported EndpointSlice.
+	reconcileAndCheck := func(want *discoveryv1.EndpointSlice) {
+		t.Helper()
+		if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceImportKey}); err != nil {
+			t.Fatalf("Reconcile(), got %v, want no error", err)
+		}
+		endpointSlice := &discoveryv1.EndpointSlice{}
+		if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+			t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+		}
+		if diff := cmp.Diff(want, endpointSlice, cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion")); diff != "" {
+			t.Errorf("imported endpointSlice (-want, +got):\n%s", diff)
+		}
+	}
+
+	// The EndpointSlice is created.
+	reconcileAndCheck(importedIPv4EndpointSlice())
+
+	// The EndpointSlice follows the changes of the EndpointSliceImport.
+	if err := fakeHubClient.Get(ctx, endpointSliceImportKey, endpointSliceImport); err != nil {
+		t.Fatalf("endpointSliceImport Get(), got %v, want no error", err)
+	}
+	endpointSliceImport.Spec.Endpoints[0].Ready = ptr.To(false)
+	endpointSliceImport.Spec.Endpoints = append(endpointSliceImport.Spec.Endpoints, fleetnetv1alpha1.Endpoint{Addresses: []string{"3.4.5.6"}})
+	endpointSliceImport.Spec.Ports = endpointSliceImport.Spec.Ports[:1]
+	endpointSliceImport.Spec.EndpointSliceReference.Generation++
+	if err := fakeHubClient.Update(ctx, endpointSliceImport); err != nil {
+		t.Fatalf("endpointSliceImport Update(), got %v, want no error", err)
+	}
+	wantEndpointSlice := importedIPv4EndpointSlice()
+	wantEndpointSlice.Endpoints[0].Conditions = discoveryv1.EndpointConditions{Ready: ptr.To(false), Serving: ptr.To(false)}
+	wantEndpointSlice.Endpoints = append(wantEndpointSlice.Endpoints, discoveryv1.Endpoint{Addresses: []string{"3.4.5.6"}})
+	wantEndpointSlice.Ports = wantEndpointSlice.Ports[:1]
+	reconcileAndCheck(wantEndpointSlice)
+
+	// The manual edits of the EndpointSlice are reverted.
+	endpointSlice := &discoveryv1.EndpointSlice{}
+	if err := fakeMemberClient.Get(ctx, endpointSliceKey, endpointSlice); err != nil {
+		t.Fatalf("endpointSlice Get(), got %v, want no error", err)
+	}
+	endpointSlice.Labels = map[string]string{discoveryv1.LabelServiceName: "other"}
+	endpointSlice.Endpoints[0].Addresses = []string{"9.9.9.9"}
+	endpointSlice.Endpoints[0].Conditions = discoveryv1.EndpointConditions{}
+	if err := fakeMemberClient.Update(ctx, endpointSlice); err != nil {
+		t.Fatalf("endpointSlice Update(), got %v, want no error", err)
+	}
+	reconcileAndCheck(wantEndpointSlice)
+}
+
+// TestReconcile_OrphanedEndpointSlice tests that an imported EndpointSlice whose EndpointSliceImport is gone is
+// deleted, even if its managed-by label has been stripped, while other EndpointSlices of the same name are kept.
+func TestReconcile_OrphanedEndpointSlice(t *testing.T) {
+	testCases := []struct {
+		name          string
+		endpointSlice *discoveryv1.EndpointSlice
+		wantDeleted   bool
+	}{
+		{
+			name:          "imported endpointslice",
+			endpointSlice: importedIPv4EndpointSlice(),
+			wantDeleted:   true,
+		},
+		{
+			name: "imported endpointslice with its managed-by label stripped",
+			endpointSlice: func() *discoveryv1.EndpointSlice {
+				endpointSlice := importedIPv4EndpointSlice()
+				delete(endpointSlice.Labels, discoveryv1.LabelManagedBy)
+				return endpointSlice
+			}(),
+			wantDeleted: true,
+		},
+		{
+			name: "endpointslice not imported",
+			endpointSlice: func() *discoveryv1.EndpointSlice {
+				endpointSlice := importedIPv4EndpointSlice()
+				endpointSlice.Labels[discoveryv1.LabelManagedBy] = "endpointslice-controller.k8s.io"
+				endpointSlice.Annotations = nil
+				return endpointSlice
+			}(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			reconciler, fakeMemberClient, _ := importTestReconciler([]client.Object{tc.endpointSlice}, nil)
+
+			// The EndpointSlice maps to its EndpointSliceImport only if it has been imported.
+			requests := reconciler.endpointSliceImportForEndpointSlice(ctx, tc.endpointSlice)
+			var wantRequests []reconcile.Request
+			if tc.wantDeleted {
+				wantRequests = []reconcile.Request{{NamespacedName: endpointSliceImportKey}}
+			}
+			if diff := cmp.Diff(wantRequests, requests); diff != "" {
+				t.Errorf("endpointSliceImportForEndpointSlice() (-want, +got):\n%s", diff)
+			}
+
+			if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: endpointSliceImportKey}); err != nil {
+				t.Fatalf("Reconcile(), got %v, want no error", err)
+			}
+			endpointSlice := &discoveryv1.EndpointSlice{}
+			err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: fleetSystemNS, Name: endpointSliceImportName}, endpointSlice)
+			if gotDeleted := errors.IsNotFound(err); gotDeleted != tc.wantDeleted {
+				t.Fatalf("endpointSlice Get(), got %v, want deleted %t", err, tc.wantDeleted)
+			}
+		})
+	}
+}
@@ -120,6 +120,7 @@ var _ = BeforeSuite(func() {
 		MemberClient:         memberClient,
 		HubClient:            hubClient,
 		FleetSystemNamespace: fleetSystemNS,
+		HubNamespace:         hubNSForMember,
 	}).SetupWithManager(ctx, memberCtrlMgr, hubCtrlMgr)
 	Expect(err).NotTo(HaveOccurred())
 
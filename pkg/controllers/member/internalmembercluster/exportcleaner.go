@@ -0,0 +1,16 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package internalmembercluster features the types shared by the InternalMemberCluster controllers of the v1alpha1
+// and v1beta1 cluster APIs.
+package internalmembercluster
+
+import "context"
+
+// ExportCleaner unexports everything a member cluster has exported to the fleet; the cleanup must be idempotent, as
+// it is retried until it succeeds.
+type ExportCleaner interface {
+	Cleanup(ctx context.Context) error
+}
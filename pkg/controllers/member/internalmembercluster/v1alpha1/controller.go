@@ -27,6 +27,7 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/apiretry"
+	imc "go.goms.io/fleet-networking/pkg/controllers/member/internalmembercluster"
 )
 
 const (
@@ -42,6 +43,20 @@ type Reconciler struct {
 	MemberClient client.Client
 	HubClient    client.Client
 	AgentType    fleetv1alpha1.AgentType
+
+	// ExportCleaners unexport everything the member cluster has exported to the fleet when the member cluster
+	// leaves the fleet; they are only used by the ServiceExportImport agent.
+	ExportCleaners []imc.ExportCleaner
+
+	// AgentConditionReporters report the conditions of the agent, in addition to the AgentJoined condition, while the
+	// member cluster is in the fleet.
+	AgentConditionReporters []AgentConditionReporter
+}
+
+// AgentConditionReporter reports a condition of the agent, e.g. whether its exports are paused; the condition is
+// reported in the agent status with each heartbeat.
+type AgentConditionReporter interface {
+	AgentCondition() metav1.Condition
 }
 
 //+kubebuilder:rbac:groups=fleet.azure.com,resources=internalmemberclusters,verbs=get;list;watch
@@ -85,6 +100,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			},
 			LastReceivedHeartbeat: metav1.NewTime(time.Now()),
 		}
+		for _, reporter := range r.AgentConditionReporters {
+			cond := reporter.AgentCondition()
+			cond.ObservedGeneration = imc.GetGeneration()
+			agentStatus.Conditions = append(agentStatus.Conditions, cond)
+		}
 		if err := r.updateAgentStatus(ctx, &imc, agentStatus); err != nil {
 			return ctrl.Result{}, err
 		}
@@ -103,6 +123,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			if err := r.cleanupServiceExportRelatedResources(ctx); err != nil {
 				return ctrl.Result{}, err
 			}
+			// Sweep the exported objects left behind, e.g. when the controllers fail to unexport them in time.
+			for _, cleaner := range r.ExportCleaners {
+				if err := cleaner.Cleanup(ctx); err != nil {
+					klog.ErrorS(err, "Failed to clean up the exported objects", "internalMemberCluster", imcKRef)
+					return ctrl.Result{}, err
+				}
+			}
 		}
 		agentStatus := fleetv1alpha1.AgentStatus{
 			Type: r.AgentType,
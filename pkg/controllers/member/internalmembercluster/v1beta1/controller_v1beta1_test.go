@@ -16,11 +16,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	clusterv1beta1 "go.goms.io/fleet/apis/cluster/v1beta1"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/controllers/member/internalmembercluster"
 )
 
 const (
@@ -33,6 +35,13 @@ var (
 	ignoreAgentStatusLastReceivedHeartbeatField = cmpopts.IgnoreFields(clusterv1beta1.AgentStatus{}, "LastReceivedHeartbeat")
 )
 
+// agentConditionFunc reports the agent condition it returns.
+type agentConditionFunc func() metav1.Condition
+
+func (f agentConditionFunc) AgentCondition() metav1.Condition {
+	return f()
+}
+
 // TestUpdateAgentStatus tests the updateAgentStatus method.
 func TestUpdateAgentStatus(t *testing.T) {
 	agentType := clusterv1beta1.AgentType("DummyAgent")
@@ -40,6 +49,7 @@ func TestUpdateAgentStatus(t *testing.T) {
 	testCases := []struct {
 		name                  string
 		internalMemberCluster *clusterv1beta1.InternalMemberCluster
+		reporters             []AgentConditionReporter
 		wantAgentStatus       []clusterv1beta1.AgentStatus
 	}{
 		{
@@ -108,6 +118,47 @@ func TestUpdateAgentStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "member cluster is active, conditions of the agent reported",
+			internalMemberCluster: &clusterv1beta1.InternalMemberCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       memberClusterName,
+					Namespace:  memberClusterNamespace,
+					Generation: 3,
+				},
+				Spec: clusterv1beta1.InternalMemberClusterSpec{
+					State: clusterv1beta1.ClusterStateJoin,
+				},
+			},
+			reporters: []AgentConditionReporter{
+				agentConditionFunc(func() metav1.Condition {
+					return metav1.Condition{
+						Type:   "ExportPausedDueToResourcePressure",
+						Status: metav1.ConditionTrue,
+						Reason: "ResourcePressure",
+					}
+				}),
+			},
+			wantAgentStatus: []clusterv1beta1.AgentStatus{
+				{
+					Type: agentType,
+					Conditions: []metav1.Condition{
+						{
+							Type:               string(clusterv1beta1.AgentJoined),
+							Status:             metav1.ConditionTrue,
+							Reason:             conditionReasonJoined,
+							ObservedGeneration: 3,
+						},
+						{
+							Type:               "ExportPausedDueToResourcePressure",
+							Status:             metav1.ConditionTrue,
+							Reason:             "ResourcePressure",
+							ObservedGeneration: 3,
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "member cluster has left the fleet, status of the agent type reported before",
 			internalMemberCluster: &clusterv1beta1.InternalMemberCluster{
@@ -161,9 +212,10 @@ func TestUpdateAgentStatus(t *testing.T) {
 				Build()
 			fakeMemberClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
 			reconciler := &Reconciler{
-				MemberClient: fakeMemberClient,
-				HubClient:    fakeHubClient,
-				AgentType:    agentType,
+				MemberClient:            fakeMemberClient,
+				HubClient:               fakeHubClient,
+				AgentType:               agentType,
+				AgentConditionReporters: tc.reporters,
 			}
 
 			ctx := context.Background()
@@ -284,3 +336,59 @@ func TestCleanupServiceExportRelatedResources(t *testing.T) {
 		}
 	}
 }
+
+// fakeExportCleaner counts the cleanups; the first failures cleanups fail.
+type fakeExportCleaner struct {
+	calls    int
+	failures int
+}
+
+func (c *fakeExportCleaner) Cleanup(_ context.Context) error {
+	c.calls++
+	if c.calls <= c.failures {
+		return errors.NewServiceUnavailable("hub is unavailable")
+	}
+	return nil
+}
+
+// TestReconcile_LeaveRunsExportCleaners tests that the export cleaners run when the member cluster leaves the
+// fleet, and are retried until they succeed.
+func TestReconcile_LeaveRunsExportCleaners(t *testing.T) {
+	imc := &clusterv1beta1.InternalMemberCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      memberClusterName,
+			Namespace: memberClusterNamespace,
+		},
+		Spec: clusterv1beta1.InternalMemberClusterSpec{
+			State: clusterv1beta1.ClusterStateLeave,
+		},
+	}
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(imc).
+		WithStatusSubresource(imc).
+		Build()
+	svcExportCleaner := &fakeExportCleaner{failures: 1}
+	endpointSliceCleaner := &fakeExportCleaner{}
+	reconciler := &Reconciler{
+		MemberClient:   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubClient:      fakeHubClient,
+		AgentType:      clusterv1beta1.ServiceExportImportAgent,
+		ExportCleaners: []internalmembercluster.ExportCleaner{svcExportCleaner, endpointSliceCleaner},
+	}
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: memberClusterNamespace, Name: memberClusterName}}
+
+	if _, err := reconciler.Reconcile(ctx, req); err == nil {
+		t.Fatalf("Reconcile(), got no error, want an error")
+	}
+	if endpointSliceCleaner.calls != 0 {
+		t.Fatalf("endpointSlice cleanups, got %d, want 0", endpointSliceCleaner.calls)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if svcExportCleaner.calls != 2 || endpointSliceCleaner.calls != 1 {
+		t.Errorf("cleanups, got (%d, %d), want (2, 1)", svcExportCleaner.calls, endpointSliceCleaner.calls)
+	}
+}
@@ -4,7 +4,7 @@ Licensed under the MIT license.
 */
 
 // package internalserviceexport features the InternalServiceExport controller for reporting back conflict resolution
-// status from the fleet to a member cluster.
+// and freshness status from the fleet to a member cluster.
 package internalserviceexport
 
 import (
@@ -137,6 +137,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// Report back the freshness of the export.
+	if err := r.reportBackFreshnessCondition(ctx, &svcExport, &internalSvcExport); err != nil {
+		klog.ErrorS(err, "Failed to report back freshness condition", "serviceExport", svcExportRef)
+		return ctrl.Result{}, err
+	}
+
+	// Report back the clusters the export conflicts with, if any.
+	if err := r.reportBackWouldConflictCondition(ctx, &svcExport, &internalSvcExport); err != nil {
+		klog.ErrorS(err, "Failed to report back would conflict condition", "serviceExport", svcExportRef)
+		return ctrl.Result{}, err
+	}
+
 	// Observe a data point for the svcExportDuration metric.
 	// Note that an observation happens only when there is a conflict resolution result to report back.
 	if reported {
@@ -191,6 +203,61 @@ func (r *Reconciler) reportBackConflictCondition(ctx context.Context,
 	return true, r.MemberClient.Status().Update(ctx, svcExport)
 }
 
+// reportBackFreshnessCondition mirrors the FreshnessDegraded condition added to the InternalServiceExport object in
+// the hub cluster to the ServiceExport object in the member cluster, so that the owners of the Service can tell when
+// its endpoint changes are slow to become visible across the fleet.
+func (r *Reconciler) reportBackFreshnessCondition(ctx context.Context,
+	svcExport *fleetnetv1alpha1.ServiceExport,
+	internalSvcExport *fleetnetv1alpha1.InternalServiceExport) error {
+	condType := string(fleetnetv1alpha1.ServiceExportFreshnessDegraded)
+	internalSvcExportFreshnessCond := meta.FindStatusCondition(internalSvcExport.Status.Conditions, condType)
+	svcExportFreshnessCond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	if reflect.DeepEqual(internalSvcExportFreshnessCond, svcExportFreshnessCond) {
+		return nil
+	}
+
+	if internalSvcExportFreshnessCond == nil {
+		// The freshness is no longer measured, e.g. the Service has been exported again.
+		meta.RemoveStatusCondition(&svcExport.Status.Conditions, condType)
+		return r.MemberClient.Status().Update(ctx, svcExport)
+	}
+	wasDegraded := svcExportFreshnessCond != nil && svcExportFreshnessCond.Status == metav1.ConditionTrue
+	switch {
+	case internalSvcExportFreshnessCond.Status == metav1.ConditionTrue && !wasDegraded:
+		r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "ServiceExportFreshnessDegraded", "Endpoint changes of Service %s are slow to become visible across the fleet", svcExport.Name)
+	case internalSvcExportFreshnessCond.Status == metav1.ConditionFalse && wasDegraded:
+		r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "ServiceExportFreshnessRecovered", "Endpoint changes of Service %s become visible across the fleet in time again", svcExport.Name)
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *internalSvcExportFreshnessCond)
+	return r.MemberClient.Status().Update(ctx, svcExport)
+}
+
+// reportBackWouldConflictCondition mirrors the advisory WouldConflict condition added to the InternalServiceExport
+// object in the hub cluster to the ServiceExport object in the member cluster, so that the owners of the Service can
+// tell which clusters export the same service in a way that conflicts with theirs, even if their export wins.
+func (r *Reconciler) reportBackWouldConflictCondition(ctx context.Context,
+	svcExport *fleetnetv1alpha1.ServiceExport,
+	internalSvcExport *fleetnetv1alpha1.InternalServiceExport) error {
+	condType := string(fleetnetv1alpha1.ServiceExportWouldConflict)
+	internalSvcExportWouldConflictCond := meta.FindStatusCondition(internalSvcExport.Status.Conditions, condType)
+	svcExportWouldConflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	if reflect.DeepEqual(internalSvcExportWouldConflictCond, svcExportWouldConflictCond) {
+		return nil
+	}
+
+	if internalSvcExportWouldConflictCond == nil {
+		// The conflicts have been resolved.
+		meta.RemoveStatusCondition(&svcExport.Status.Conditions, condType)
+		r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "ServiceExportConflictsResolved", "Service %s no longer conflicts with the services exported by other clusters", svcExport.Name)
+		return r.MemberClient.Status().Update(ctx, svcExport)
+	}
+	if svcExportWouldConflictCond == nil || svcExportWouldConflictCond.Message != internalSvcExportWouldConflictCond.Message {
+		r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "ServiceExportWouldConflict", "Service %s conflicts with the services exported by other clusters", svcExport.Name)
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *internalSvcExportWouldConflictCond)
+	return r.MemberClient.Status().Update(ctx, svcExport)
+}
+
 // Observe data points for metrics.
 func (r *Reconciler) observeMetrics(ctx context.Context,
 	internalSvcExport *fleetnetv1alpha1.InternalServiceExport,
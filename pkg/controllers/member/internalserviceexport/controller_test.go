@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
@@ -216,6 +217,247 @@ func TestReportBackConflictCondition(t *testing.T) {
 	}
 }
 
+// freshnessDegradedCondition returns a ServiceExportFreshnessDegraded condition with the given status.
+func freshnessDegradedCondition(status metav1.ConditionStatus) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportFreshnessDegraded),
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(time.Now().Round(time.Second)),
+		Reason:             "LagWithinThreshold",
+		Message:            fmt.Sprintf("endpoint changes of service %s/%s become visible across the fleet within the objective of 30s", memberUserNS, svcName),
+	}
+	if status == metav1.ConditionTrue {
+		cond.Reason = "LagAboveThreshold"
+		cond.Message = fmt.Sprintf("endpoint changes of service %s/%s took up to 45s to become visible across the fleet, longer than the objective of 30s", memberUserNS, svcName)
+	}
+	return cond
+}
+
+// TestReportBackFreshnessCondition tests the *Reconciler.reportBackFreshnessCondition method.
+func TestReportBackFreshnessCondition(t *testing.T) {
+	testCases := []struct {
+		name          string
+		svcExportCond *metav1.Condition
+		hubCond       *metav1.Condition
+		wantConds     []metav1.Condition
+		wantEvents    []string
+	}{
+		{
+			name:      "freshness not measured",
+			wantConds: []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+		},
+		{
+			name:    "freshness degraded",
+			hubCond: ptr.To(freshnessDegradedCondition(metav1.ConditionTrue)),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				freshnessDegradedCondition(metav1.ConditionTrue),
+			},
+			wantEvents: []string{"Warning ServiceExportFreshnessDegraded Endpoint changes of Service app are slow to become visible across the fleet"},
+		},
+		{
+			name:          "freshness recovered",
+			svcExportCond: ptr.To(freshnessDegradedCondition(metav1.ConditionTrue)),
+			hubCond:       ptr.To(freshnessDegradedCondition(metav1.ConditionFalse)),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				freshnessDegradedCondition(metav1.ConditionFalse),
+			},
+			wantEvents: []string{"Normal ServiceExportFreshnessRecovered Endpoint changes of Service app become visible across the fleet in time again"},
+		},
+		{
+			name:          "no update",
+			svcExportCond: ptr.To(freshnessDegradedCondition(metav1.ConditionFalse)),
+			hubCond:       ptr.To(freshnessDegradedCondition(metav1.ConditionFalse)),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				freshnessDegradedCondition(metav1.ConditionFalse),
+			},
+		},
+		{
+			name:          "freshness no longer measured",
+			svcExportCond: ptr.To(freshnessDegradedCondition(metav1.ConditionTrue)),
+			wantConds:     []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      svcName,
+				},
+				Status: fleetnetv1alpha1.ServiceExportStatus{
+					Conditions: []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+				},
+			}
+			if tc.svcExportCond != nil {
+				svcExport.Status.Conditions = append(svcExport.Status.Conditions, *tc.svcExportCond)
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: hubNSForMember,
+					Name:      internalSvcExportName,
+				},
+			}
+			if tc.hubCond != nil {
+				internalSvcExport.Status.Conditions = []metav1.Condition{*tc.hubCond}
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcExport).
+				WithStatusSubresource(svcExport).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := Reconciler{
+				MemberClient: fakeMemberClient,
+				HubClient:    fake.NewClientBuilder().Build(),
+				Recorder:     recorder,
+			}
+
+			if err := reconciler.reportBackFreshnessCondition(ctx, svcExport, internalSvcExport); err != nil {
+				t.Fatalf("reportBackFreshnessCondition() = %v, want no error", err)
+			}
+
+			updatedSvcExport := &fleetnetv1alpha1.ServiceExport{}
+			if err := fakeMemberClient.Get(ctx, svcExportKey, updatedSvcExport); err != nil {
+				t.Fatalf("failed to get updated svc export: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantConds, updatedSvcExport.Status.Conditions, ignoredCondFields); diff != "" {
+				t.Errorf("conditions mismatch (-want, +got):\n%s", diff)
+			}
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func wouldConflictCondition(clusters string) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportWouldConflict),
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now().Round(time.Second)),
+		Reason:             "ConflictingExportsFound",
+		Message:            fmt.Sprintf("service %s/%s conflicts with the services exported by clusters %s", memberUserNS, svcName, clusters),
+	}
+}
+
+// TestReportBackWouldConflictCondition tests the *Reconciler.reportBackWouldConflictCondition method.
+func TestReportBackWouldConflictCondition(t *testing.T) {
+	testCases := []struct {
+		name          string
+		svcExportCond *metav1.Condition
+		hubCond       *metav1.Condition
+		wantConds     []metav1.Condition
+		wantEvents    []string
+	}{
+		{
+			name:      "no conflicts",
+			wantConds: []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+		},
+		{
+			name:    "conflicts found",
+			hubCond: ptr.To(wouldConflictCondition("member-2")),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				wouldConflictCondition("member-2"),
+			},
+			wantEvents: []string{"Warning ServiceExportWouldConflict Service app conflicts with the services exported by other clusters"},
+		},
+		{
+			name:          "conflicting clusters changed",
+			svcExportCond: ptr.To(wouldConflictCondition("member-2")),
+			hubCond:       ptr.To(wouldConflictCondition("member-2, member-3")),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				wouldConflictCondition("member-2, member-3"),
+			},
+			wantEvents: []string{"Warning ServiceExportWouldConflict Service app conflicts with the services exported by other clusters"},
+		},
+		{
+			name:          "no update",
+			svcExportCond: ptr.To(wouldConflictCondition("member-2")),
+			hubCond:       ptr.To(wouldConflictCondition("member-2")),
+			wantConds: []metav1.Condition{
+				unconflictedServiceExportConflictCondition(memberUserNS, svcName),
+				wouldConflictCondition("member-2"),
+			},
+		},
+		{
+			name:          "conflicts resolved",
+			svcExportCond: ptr.To(wouldConflictCondition("member-2")),
+			wantConds:     []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+			wantEvents:    []string{"Normal ServiceExportConflictsResolved Service app no longer conflicts with the services exported by other clusters"},
+		},
+	}
+
+	ctx := context.Background()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      svcName,
+				},
+				Status: fleetnetv1alpha1.ServiceExportStatus{
+					Conditions: []metav1.Condition{unconflictedServiceExportConflictCondition(memberUserNS, svcName)},
+				},
+			}
+			if tc.svcExportCond != nil {
+				svcExport.Status.Conditions = append(svcExport.Status.Conditions, *tc.svcExportCond)
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: hubNSForMember,
+					Name:      internalSvcExportName,
+				},
+			}
+			if tc.hubCond != nil {
+				internalSvcExport.Status.Conditions = []metav1.Condition{*tc.hubCond}
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcExport).
+				WithStatusSubresource(svcExport).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := Reconciler{
+				MemberClient: fakeMemberClient,
+				HubClient:    fake.NewClientBuilder().Build(),
+				Recorder:     recorder,
+			}
+
+			if err := reconciler.reportBackWouldConflictCondition(ctx, svcExport, internalSvcExport); err != nil {
+				t.Fatalf("reportBackWouldConflictCondition() = %v, want no error", err)
+			}
+
+			updatedSvcExport := &fleetnetv1alpha1.ServiceExport{}
+			if err := fakeMemberClient.Get(ctx, svcExportKey, updatedSvcExport); err != nil {
+				t.Fatalf("failed to get updated svc export: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantConds, updatedSvcExport.Status.Conditions, ignoredCondFields); diff != "" {
+				t.Errorf("conditions mismatch (-want, +got):\n%s", diff)
+			}
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 // TestObserveMetrics tests the Reconciler.observeMetrics function.
 func TestObserveMetrics(t *testing.T) {
 	metricMetadata := `
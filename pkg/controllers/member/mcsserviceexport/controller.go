@@ -0,0 +1,282 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package mcsserviceexport features the compatibility controller which translates the upstream mcs-api
+// (multicluster.x-k8s.io) ServiceExport objects into fleet ServiceExport objects, so that users who already run the
+// mcs-api CRDs can migrate to fleet networking gradually.
+package mcsserviceexport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	"go.goms.io/fleet/pkg/utils/controller"
+
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// Mode is the migration mode of the compatibility controller.
+type Mode = configv1alpha1.MCSAPICompatibilityMode
+
+const (
+	// ModeDualWrite honors the upstream ServiceExports.
+	ModeDualWrite = configv1alpha1.MCSAPICompatibilityModeDualWrite
+	// ModeCutover stops honoring the upstream ServiceExports.
+	ModeCutover = configv1alpha1.MCSAPICompatibilityModeCutover
+)
+
+const (
+	// ControllerName is the name of the Reconciler.
+	ControllerName = "mcs-serviceexport-controller"
+
+	// fleetServiceExportExistsReason is the reason of the Conflict condition set on an upstream ServiceExport when
+	// a fleet ServiceExport not created for it already exports the same service.
+	fleetServiceExportExistsReason = "FleetServiceExportExists"
+)
+
+// ServiceExportGVK is the GroupVersionKind of the upstream mcs-api ServiceExport.
+var ServiceExportGVK = schema.GroupVersionKind{
+	Group:   "multicluster.x-k8s.io",
+	Version: "v1alpha1",
+	Kind:    "ServiceExport",
+}
+
+// Reconciler reconciles the upstream mcs-api ServiceExport objects.
+//
+// The upstream objects are handled as unstructured objects so that the member agent does not depend on the mcs-api
+// module and the scheme stays unchanged unless the compatibility mode is enabled.
+type Reconciler struct {
+	MemberClient client.Client
+	Recorder     record.EventRecorder
+	Mode         Mode
+}
+
+//+kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports,verbs=get;list;watch
+//+kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceexports,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile translates an upstream ServiceExport into a fleet ServiceExport.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	svcExportRef := klog.KRef(req.Namespace, req.Name)
+	startTime := time.Now()
+	klog.V(2).InfoS("Reconciliation starts", "serviceExport", svcExportRef, "mode", r.Mode)
+	defer func() {
+		latency := time.Since(startTime).Milliseconds()
+		klog.V(2).InfoS("Reconciliation ends", "serviceExport", svcExportRef, "latency", latency)
+	}()
+
+	mcsSvcExport := newUnstructuredServiceExport()
+	if err := r.MemberClient.Get(ctx, req.NamespacedName, mcsSvcExport); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The fleet ServiceExport created for the upstream one is owned by it and will be garbage collected.
+			klog.V(4).InfoS("Upstream service export is not found", "serviceExport", svcExportRef)
+			return ctrl.Result{}, nil
+		}
+		klog.ErrorS(err, "Failed to get upstream service export", "serviceExport", svcExportRef)
+		return ctrl.Result{}, controller.NewAPIServerError(true, err)
+	}
+	if mcsSvcExport.GetDeletionTimestamp() != nil {
+		klog.V(4).InfoS("Upstream service export is being deleted", "serviceExport", svcExportRef)
+		return ctrl.Result{}, nil
+	}
+
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	getErr := r.MemberClient.Get(ctx, req.NamespacedName, svcExport)
+	if getErr != nil && !apierrors.IsNotFound(getErr) {
+		klog.ErrorS(getErr, "Failed to get fleet service export", "serviceExport", svcExportRef)
+		return ctrl.Result{}, controller.NewAPIServerError(true, getErr)
+	}
+
+	if r.Mode == ModeCutover {
+		if getErr != nil || !isOwnedBy(svcExport, mcsSvcExport) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, r.detachServiceExport(ctx, svcExport, mcsSvcExport)
+	}
+
+	if apierrors.IsNotFound(getErr) {
+		svcExport = &fleetnetv1alpha1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       req.Namespace,
+				Name:            req.Name,
+				OwnerReferences: []metav1.OwnerReference{ownerReference(mcsSvcExport)},
+			},
+		}
+		klog.V(2).InfoS("Creating fleet service export for the upstream service export", "serviceExport", svcExportRef)
+		if err := r.MemberClient.Create(ctx, svcExport); err != nil {
+			klog.ErrorS(err, "Failed to create fleet service export", "serviceExport", svcExportRef)
+			return ctrl.Result{}, controller.NewCreateIgnoreAlreadyExistError(err)
+		}
+		r.Recorder.Eventf(mcsSvcExport, corev1.EventTypeNormal, "FleetServiceExportCreated", "Created fleet service export %s for the upstream service export", req.Name)
+		// The fleet ServiceExport controller will set the conditions, which triggers another reconciliation; until
+		// then, clear the conditions left from a fleet ServiceExport which previously took precedence.
+		return ctrl.Result{}, r.updateServiceExportConditions(ctx, mcsSvcExport, nil)
+	}
+
+	var conditions []metav1.Condition
+	if isOwnedBy(svcExport, mcsSvcExport) {
+		conditions = svcExport.Status.Conditions
+	} else {
+		// The fleet ServiceExport always wins when both objects exist for the same service.
+		conditions = []metav1.Condition{
+			{
+				Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+				Status:             metav1.ConditionTrue,
+				Reason:             fleetServiceExportExistsReason,
+				Message:            fmt.Sprintf("Service %s is exported by a fleet ServiceExport of the same name, which takes precedence over this object", req.Name),
+				LastTransitionTime: metav1.Now(),
+			},
+		}
+	}
+	return ctrl.Result{}, r.updateServiceExportConditions(ctx, mcsSvcExport, conditions)
+}
+
+// detachServiceExport removes the owner reference of the upstream ServiceExport from the fleet ServiceExport.
+func (r *Reconciler) detachServiceExport(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, mcsSvcExport *unstructured.Unstructured) error {
+	svcExportKObj := klog.KObj(svcExport)
+	ownerRefs := make([]metav1.OwnerReference, 0, len(svcExport.OwnerReferences))
+	for _, ref := range svcExport.OwnerReferences {
+		if ref.UID != mcsSvcExport.GetUID() {
+			ownerRefs = append(ownerRefs, ref)
+		}
+	}
+	svcExport.OwnerReferences = ownerRefs
+	klog.V(2).InfoS("Detaching fleet service export from the upstream service export", "serviceExport", svcExportKObj)
+	if err := r.MemberClient.Update(ctx, svcExport); err != nil {
+		klog.ErrorS(err, "Failed to detach fleet service export", "serviceExport", svcExportKObj)
+		return controller.NewUpdateIgnoreConflictError(err)
+	}
+	r.Recorder.Eventf(mcsSvcExport, corev1.EventTypeNormal, "FleetServiceExportDetached", "Fleet service export %s is no longer managed by the upstream service export", svcExport.Name)
+	return nil
+}
+
+// updateServiceExportConditions sets the conditions on the upstream ServiceExport; conditions which are not
+// desired any more, e.g. a Conflict condition once the conflict is resolved, are removed.
+func (r *Reconciler) updateServiceExportConditions(ctx context.Context, mcsSvcExport *unstructured.Unstructured, desired []metav1.Condition) error {
+	mcsSvcExportKObj := klog.KObj(mcsSvcExport)
+	current, err := extractConditions(mcsSvcExport)
+	if err != nil {
+		// The conditions are overwritten below.
+		klog.ErrorS(err, "Failed to extract the conditions of upstream service export", "serviceExport", mcsSvcExportKObj)
+	}
+	updated := make([]metav1.Condition, 0, len(desired))
+	for i := range desired {
+		cond := desired[i]
+		// The observed generation of the fleet object is meaningless on the upstream object.
+		cond.ObservedGeneration = 0
+		if c := meta.FindStatusCondition(current, cond.Type); c != nil && c.Status == cond.Status {
+			cond.LastTransitionTime = c.LastTransitionTime
+		}
+		updated = append(updated, cond)
+	}
+	if err == nil && conditionsEqual(current, updated) {
+		return nil
+	}
+
+	conditions := make([]interface{}, 0, len(updated))
+	for i := range updated {
+		cond, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&updated[i])
+		if err != nil {
+			klog.ErrorS(err, "Failed to convert the condition", "serviceExport", mcsSvcExportKObj, "condition", updated[i])
+			return controller.NewUnexpectedBehaviorError(err)
+		}
+		conditions = append(conditions, cond)
+	}
+	if err := unstructured.SetNestedSlice(mcsSvcExport.Object, conditions, "status", "conditions"); err != nil {
+		klog.ErrorS(err, "Failed to set the conditions", "serviceExport", mcsSvcExportKObj)
+		return controller.NewUnexpectedBehaviorError(err)
+	}
+	klog.V(2).InfoS("Updating the conditions of upstream service export", "serviceExport", mcsSvcExportKObj, "conditions", updated)
+	if err := r.MemberClient.Status().Update(ctx, mcsSvcExport); err != nil {
+		klog.ErrorS(err, "Failed to update the status of upstream service export", "serviceExport", mcsSvcExportKObj)
+		return controller.NewUpdateIgnoreConflictError(err)
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(ControllerName).
+		For(newUnstructuredServiceExport()).
+		// The fleet ServiceExport has the same namespace and name as the upstream one.
+		Watches(&fleetnetv1alpha1.ServiceExport{}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
+
+func newUnstructuredServiceExport() *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(ServiceExportGVK)
+	return u
+}
+
+func ownerReference(mcsSvcExport *unstructured.Unstructured) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: ServiceExportGVK.GroupVersion().String(),
+		Kind:       ServiceExportGVK.Kind,
+		Name:       mcsSvcExport.GetName(),
+		UID:        mcsSvcExport.GetUID(),
+	}
+}
+
+// isOwnedBy returns true if the fleet ServiceExport is created for the upstream ServiceExport.
+func isOwnedBy(svcExport *fleetnetv1alpha1.ServiceExport, mcsSvcExport *unstructured.Unstructured) bool {
+	for _, ref := range svcExport.OwnerReferences {
+		if ref.UID == mcsSvcExport.GetUID() {
+			return true
+		}
+	}
+	return false
+}
+
+func extractConditions(mcsSvcExport *unstructured.Unstructured) ([]metav1.Condition, error) {
+	raw, found, err := unstructured.NestedSlice(mcsSvcExport.Object, "status", "conditions")
+	if err != nil || !found {
+		return nil, err
+	}
+	conditions := make([]metav1.Condition, 0, len(raw))
+	for _, r := range raw {
+		m, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected condition type %T", r)
+		}
+		var cond metav1.Condition
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &cond); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// conditionsEqual compares the conditions by ignoring the LastTransitionTime.
+func conditionsEqual(current, desired []metav1.Condition) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for _, d := range desired {
+		c := meta.FindStatusCondition(current, d.Type)
+		if c == nil || c.Status != d.Status || c.Reason != d.Reason || c.Message != d.Message {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package mcsserviceexport
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	eventuallyTimeout  = time.Second * 10
+	eventuallyInterval = time.Millisecond * 250
+)
+
+var _ = Describe("mcs serviceexport controller", func() {
+	Context("upstream service export only", func() {
+		name := "upstream-only"
+		key := types.NamespacedName{Namespace: testNamespace, Name: name}
+
+		BeforeEach(func() {
+			mcsSvcExport := newUnstructuredServiceExport()
+			mcsSvcExport.SetNamespace(testNamespace)
+			mcsSvcExport.SetName(name)
+			Expect(memberClient.Create(ctx, mcsSvcExport)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			mcsSvcExport := newUnstructuredServiceExport()
+			mcsSvcExport.SetNamespace(testNamespace)
+			mcsSvcExport.SetName(name)
+			Expect(memberClient.Delete(ctx, mcsSvcExport)).Should(Succeed())
+			// There is no garbage collector in envtest.
+			svcExport := &fleetnetv1alpha1.ServiceExport{}
+			Expect(memberClient.Get(ctx, key, svcExport)).Should(Succeed())
+			Expect(memberClient.Delete(ctx, svcExport)).Should(Succeed())
+		})
+
+		It("should create the fleet service export and mirror its conditions", func() {
+			By("confirm that the fleet service export is created")
+			svcExport := &fleetnetv1alpha1.ServiceExport{}
+			Eventually(func() error {
+				return memberClient.Get(ctx, key, svcExport)
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			mcsSvcExport := newUnstructuredServiceExport()
+			Expect(memberClient.Get(ctx, key, mcsSvcExport)).Should(Succeed())
+			Expect(isOwnedBy(svcExport, mcsSvcExport)).Should(BeTrue())
+
+			By("set the conditions of the fleet service export")
+			meta.SetStatusCondition(&svcExport.Status.Conditions, metav1.Condition{
+				Type:               string(fleetnetv1alpha1.ServiceExportValid),
+				Status:             metav1.ConditionTrue,
+				Reason:             "ServiceIsValid",
+				Message:            "service is valid",
+				ObservedGeneration: svcExport.Generation,
+			})
+			Expect(memberClient.Status().Update(ctx, svcExport)).Should(Succeed())
+
+			By("confirm that the conditions are written back to the upstream service export")
+			Eventually(func() error {
+				got := newUnstructuredServiceExport()
+				if err := memberClient.Get(ctx, key, got); err != nil {
+					return err
+				}
+				conditions, err := extractConditions(got)
+				if err != nil {
+					return err
+				}
+				cond := meta.FindStatusCondition(conditions, string(fleetnetv1alpha1.ServiceExportValid))
+				if cond == nil || cond.Status != metav1.ConditionTrue {
+					return fmt.Errorf("valid condition, got %v, want true", cond)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+		})
+	})
+
+	Context("both fleet and upstream service exports", func() {
+		name := "both"
+		key := types.NamespacedName{Namespace: testNamespace, Name: name}
+
+		BeforeEach(func() {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      name,
+				},
+			}
+			Expect(memberClient.Create(ctx, svcExport)).Should(Succeed())
+
+			mcsSvcExport := newUnstructuredServiceExport()
+			mcsSvcExport.SetNamespace(testNamespace)
+			mcsSvcExport.SetName(name)
+			Expect(memberClient.Create(ctx, mcsSvcExport)).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			mcsSvcExport := newUnstructuredServiceExport()
+			mcsSvcExport.SetNamespace(testNamespace)
+			mcsSvcExport.SetName(name)
+			Expect(memberClient.Delete(ctx, mcsSvcExport)).Should(Succeed())
+			svcExport := &fleetnetv1alpha1.ServiceExport{}
+			Expect(memberClient.Get(ctx, key, svcExport)).Should(Succeed())
+			Expect(memberClient.Delete(ctx, svcExport)).Should(Succeed())
+			Eventually(func() bool {
+				return apierrors.IsNotFound(memberClient.Get(ctx, key, &fleetnetv1alpha1.ServiceExport{}))
+			}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+		})
+
+		It("should keep the fleet service export and report the conflict on the upstream one", func() {
+			Eventually(func() error {
+				got := newUnstructuredServiceExport()
+				if err := memberClient.Get(ctx, key, got); err != nil {
+					return err
+				}
+				conditions, err := extractConditions(got)
+				if err != nil {
+					return err
+				}
+				cond := meta.FindStatusCondition(conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+				if cond == nil || cond.Status != metav1.ConditionTrue || cond.Reason != fleetServiceExportExistsReason {
+					return fmt.Errorf("conflict condition, got %v, want true with reason %s", cond, fleetServiceExportExistsReason)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			svcExport := &fleetnetv1alpha1.ServiceExport{}
+			Expect(memberClient.Get(ctx, key, svcExport)).Should(Succeed())
+			Expect(svcExport.OwnerReferences).Should(BeEmpty())
+		})
+	})
+})
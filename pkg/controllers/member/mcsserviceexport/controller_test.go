@@ -0,0 +1,283 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package mcsserviceexport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	testNamespace = "work"
+	testName      = "app"
+	testUID       = types.UID("mcs-uid")
+)
+
+var (
+	testKey = types.NamespacedName{Namespace: testNamespace, Name: testName}
+
+	ignoreConditionLTTField = cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	scheme.AddKnownTypeWithName(ServiceExportGVK, &unstructured.Unstructured{})
+	return scheme
+}
+
+func mcsServiceExport() *unstructured.Unstructured {
+	u := newUnstructuredServiceExport()
+	u.SetNamespace(testNamespace)
+	u.SetName(testName)
+	u.SetUID(testUID)
+	return u
+}
+
+func newTestReconciler(t *testing.T, mode Mode, objs ...client.Object) *Reconciler {
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(testScheme(t)).
+		WithObjects(objs...).
+		WithStatusSubresource(newUnstructuredServiceExport(), &fleetnetv1alpha1.ServiceExport{}).
+		Build()
+	return &Reconciler{
+		MemberClient: fakeClient,
+		Recorder:     record.NewFakeRecorder(10),
+		Mode:         mode,
+	}
+}
+
+func getConditions(t *testing.T, c client.Client) []metav1.Condition {
+	got := newUnstructuredServiceExport()
+	if err := c.Get(context.Background(), testKey, got); err != nil {
+		t.Fatalf("Get() = %v, want no error", err)
+	}
+	conditions, err := extractConditions(got)
+	if err != nil {
+		t.Fatalf("extractConditions() = %v, want no error", err)
+	}
+	return conditions
+}
+
+func TestReconcile_DualWrite(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler(t, ModeDualWrite, mcsServiceExport())
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, testKey, svcExport); err != nil {
+		t.Fatalf("Get() fleet service export = %v, want no error", err)
+	}
+	if !isOwnedBy(svcExport, mcsServiceExport()) {
+		t.Fatalf("fleet service export owner references = %v, want owned by the upstream service export", svcExport.OwnerReferences)
+	}
+
+	// The conditions set by the fleet ServiceExport controller are written back to the upstream object.
+	validCond := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportValid),
+		Status:             metav1.ConditionTrue,
+		Reason:             "ServiceIsValid",
+		Message:            "service is valid",
+		ObservedGeneration: 1,
+		LastTransitionTime: metav1.Now(),
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, validCond)
+	if err := r.MemberClient.Status().Update(ctx, svcExport); err != nil {
+		t.Fatalf("Update() fleet service export status = %v, want no error", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	validCond.ObservedGeneration = 0
+	want := []metav1.Condition{validCond}
+	if diff := cmp.Diff(want, getConditions(t, r.MemberClient), ignoreConditionLTTField); diff != "" {
+		t.Errorf("upstream service export conditions mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_Conflict(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testName,
+		},
+	}
+	r := newTestReconciler(t, ModeDualWrite, mcsServiceExport(), svcExport)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	got := getConditions(t, r.MemberClient)
+	if len(got) != 1 || got[0].Type != string(fleetnetv1alpha1.ServiceExportConflict) ||
+		got[0].Status != metav1.ConditionTrue || got[0].Reason != fleetServiceExportExistsReason {
+		t.Errorf("upstream service export conditions = %v, want a true Conflict condition with reason %s", got, fleetServiceExportExistsReason)
+	}
+
+	// The fleet ServiceExport is left untouched.
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, testKey, gotSvcExport); err != nil {
+		t.Fatalf("Get() fleet service export = %v, want no error", err)
+	}
+	if len(gotSvcExport.OwnerReferences) != 0 {
+		t.Errorf("fleet service export owner references = %v, want none", gotSvcExport.OwnerReferences)
+	}
+}
+
+// TestReconcile_ConflictResolved tests that the Conflict condition is removed from the upstream ServiceExport once
+// the fleet ServiceExport which took precedence is deleted.
+func TestReconcile_ConflictResolved(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testName,
+		},
+	}
+	r := newTestReconciler(t, ModeDualWrite, mcsServiceExport(), svcExport)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if got := getConditions(t, r.MemberClient); len(got) != 1 {
+		t.Fatalf("upstream service export conditions = %v, want a Conflict condition", got)
+	}
+
+	if err := r.MemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("Delete() fleet service export = %v, want no error", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if got := getConditions(t, r.MemberClient); len(got) != 0 {
+		t.Errorf("upstream service export conditions = %v, want none", got)
+	}
+
+	// Only the conditions of the fleet ServiceExport created for the upstream one are mirrored.
+	created := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, testKey, created); err != nil {
+		t.Fatalf("Get() fleet service export = %v, want no error", err)
+	}
+	validCond := metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportValid),
+		Status:             metav1.ConditionTrue,
+		Reason:             "ServiceIsValid",
+		Message:            "service is valid",
+		LastTransitionTime: metav1.Now(),
+	}
+	meta.SetStatusCondition(&created.Status.Conditions, validCond)
+	if err := r.MemberClient.Status().Update(ctx, created); err != nil {
+		t.Fatalf("Update() fleet service export status = %v, want no error", err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if diff := cmp.Diff([]metav1.Condition{validCond}, getConditions(t, r.MemberClient), ignoreConditionLTTField); diff != "" {
+		t.Errorf("upstream service export conditions mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestReconcile_Cutover(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       testNamespace,
+			Name:            testName,
+			OwnerReferences: []metav1.OwnerReference{ownerReference(mcsServiceExport())},
+		},
+	}
+	r := newTestReconciler(t, ModeCutover, mcsServiceExport(), svcExport)
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	got := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, testKey, got); err != nil {
+		t.Fatalf("Get() fleet service export = %v, want no error", err)
+	}
+	if len(got.OwnerReferences) != 0 {
+		t.Errorf("fleet service export owner references = %v, want none", got.OwnerReferences)
+	}
+	if conditions := getConditions(t, r.MemberClient); len(conditions) != 0 {
+		t.Errorf("upstream service export conditions = %v, want none", conditions)
+	}
+}
+
+func TestReconcile_CutoverDoesNotCreate(t *testing.T) {
+	ctx := context.Background()
+	r := newTestReconciler(t, ModeCutover, mcsServiceExport())
+
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: testKey}); err != nil {
+		t.Fatalf("Reconcile() = %v, want no error", err)
+	}
+	if err := r.MemberClient.Get(ctx, testKey, &fleetnetv1alpha1.ServiceExport{}); err == nil {
+		t.Errorf("Get() fleet service export = nil, want not found error")
+	}
+}
+
+func TestConditionsEqual(t *testing.T) {
+	cond := metav1.Condition{
+		Type:    string(fleetnetv1alpha1.ServiceExportValid),
+		Status:  metav1.ConditionTrue,
+		Reason:  "ServiceIsValid",
+		Message: "service is valid",
+	}
+	changed := cond
+	changed.Status = metav1.ConditionFalse
+	tests := []struct {
+		name    string
+		current []metav1.Condition
+		desired []metav1.Condition
+		want    bool
+	}{
+		{
+			name: "both empty",
+			want: true,
+		},
+		{
+			name:    "same conditions with different transition time",
+			current: []metav1.Condition{cond},
+			desired: []metav1.Condition{{Type: cond.Type, Status: cond.Status, Reason: cond.Reason, Message: cond.Message, LastTransitionTime: metav1.Now()}},
+			want:    true,
+		},
+		{
+			name:    "different status",
+			current: []metav1.Condition{cond},
+			desired: []metav1.Condition{changed},
+			want:    false,
+		},
+		{
+			name:    "missing condition",
+			desired: []metav1.Condition{cond},
+			want:    false,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := conditionsEqual(tc.current, tc.desired); got != tc.want {
+				t.Errorf("conditionsEqual() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package mcsserviceexport
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+var (
+	memberTestEnv *envtest.Environment
+	memberClient  client.Client
+	ctx           context.Context
+	cancel        context.CancelFunc
+)
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "MCS ServiceExport Controller Suite")
+}
+
+var _ = BeforeSuite(func() {
+	logger := zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true))
+	klog.SetLogger(logger)
+	log.SetLogger(logger)
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	By("bootstrap the test environment")
+	// Install both the fleet networking CRDs and the upstream mcs-api ServiceExport CRD.
+	memberTestEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "..", "..", "config", "crd", "bases"),
+			filepath.Join("testdata"),
+		},
+		ErrorIfCRDPathMissing: true,
+	}
+	memberCfg, err := memberTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(memberCfg).NotTo(BeNil())
+
+	Expect(fleetnetv1alpha1.AddToScheme(scheme.Scheme)).Should(Succeed())
+
+	memberClient, err = client.New(memberCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(memberClient).NotTo(BeNil())
+
+	memberNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNamespace,
+		},
+	}
+	Expect(memberClient.Create(ctx, &memberNS)).Should(Succeed())
+
+	ctrlMgr, err := ctrl.NewManager(memberCfg, ctrl.Options{
+		Scheme: scheme.Scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&Reconciler{
+		MemberClient: memberClient,
+		Recorder:     ctrlMgr.GetEventRecorderFor(ControllerName),
+		Mode:         ModeDualWrite,
+	}).SetupWithManager(ctrlMgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		defer GinkgoRecover()
+		err := ctrlMgr.Start(ctx)
+		Expect(err).ToNot(HaveOccurred(), "failed to start manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	defer klog.Flush()
+	cancel()
+
+	By("tearing down the test environment")
+	Expect(memberTestEnv.Stop()).Should(Succeed())
+})
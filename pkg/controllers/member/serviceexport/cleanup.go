@@ -0,0 +1,83 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+// exportAnnotations are the annotations the controller adds to ServiceExports when exporting Services.
+var exportAnnotations = []string{
+	metrics.MetricsAnnotationLastSeenResourceVersion,
+	metrics.MetricsAnnotationLastSeenTimestamp,
+	objectmeta.ServiceExportAnnotationExportedAt,
+}
+
+// Cleanup unexports all the Services of the member cluster when the member cluster leaves the fleet; it deletes the
+// InternalServiceExports created by the member cluster from the hub namespace, and removes the cleanup finalizer and
+// the export annotations from the ServiceExports in the member cluster.
+//
+// InternalServiceExports of other clusters are left untouched. Every step is idempotent, so an interrupted cleanup
+// can be resumed by calling Cleanup again.
+func (r *Reconciler) Cleanup(ctx context.Context) error {
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := r.HubClient.List(ctx, internalSvcExportList, client.InNamespace(r.HubNamespace)); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceExports", "hubNamespace", r.HubNamespace)
+		return err
+	}
+	deleted := 0
+	for i := range internalSvcExportList.Items {
+		internalSvcExport := &internalSvcExportList.Items[i]
+		if internalSvcExport.Spec.ServiceReference.ClusterID != r.MemberClusterID {
+			continue
+		}
+		if err := r.HubClient.Delete(ctx, internalSvcExport); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete internalServiceExport", "internalServiceExport", klog.KObj(internalSvcExport))
+			return err
+		}
+		deleted++
+	}
+
+	// The finalizer and annotations are removed only after the InternalServiceExports are deleted, so that an
+	// interrupted cleanup never leaves exported Services behind without a trace in the member cluster.
+	svcExportList := &fleetnetv1alpha1.ServiceExportList{}
+	if err := r.MemberClient.List(ctx, svcExportList); err != nil {
+		klog.ErrorS(err, "Failed to list service exports")
+		return err
+	}
+	stripped := 0
+	for i := range svcExportList.Items {
+		svcExport := &svcExportList.Items[i]
+		needsUpdate := controllerutil.RemoveFinalizer(svcExport, r.cleanupFinalizer())
+		for _, key := range exportAnnotations {
+			if _, ok := svcExport.Annotations[key]; ok {
+				delete(svcExport.Annotations, key)
+				needsUpdate = true
+			}
+		}
+		if !needsUpdate {
+			continue
+		}
+		if err := r.MemberClient.Update(ctx, svcExport); err != nil && !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to strip the export finalizer and annotations from service export", "serviceExport", klog.KObj(svcExport))
+			return err
+		}
+		stripped++
+	}
+	svcExportMetrics.recordAllUnexported(r.MemberClusterID)
+	klog.V(2).InfoS("Cleanup of exported services has been completed", "hubNamespace", r.HubNamespace,
+		"deletedInternalServiceExports", deleted, "strippedServiceExports", stripped)
+	return nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	// cleanupTestNS is the namespace for the cleanup tests; it is not watched by the running controller.
+	cleanupTestNS = "cleanup"
+)
+
+var (
+	cleanupSvcExportKey         = types.NamespacedName{Namespace: cleanupTestNS, Name: svcName}
+	cleanupInternalSvcExportKey = types.NamespacedName{Namespace: hubNSForMember, Name: cleanupTestNS + "-" + svcName}
+
+	internalSvcExportGR = schema.GroupResource{Group: fleetnetv1alpha1.GroupVersion.Group, Resource: "internalserviceexports"}
+	svcExportGR         = schema.GroupResource{Group: fleetnetv1alpha1.GroupVersion.Group, Resource: "serviceexports"}
+)
+
+// newCleanupReconciler returns a reconciler whose member and hub clients are wrapped with the given interceptors.
+func newCleanupReconciler(memberFuncs, hubFuncs interceptor.Funcs) *Reconciler {
+	return &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    interceptor.NewClient(memberClient, memberFuncs),
+		HubClient:       interceptor.NewClient(hubClient, hubFuncs),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+}
+
+func createCleanupServiceExport() *fleetnetv1alpha1.ServiceExport {
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  cleanupTestNS,
+			Name:       svcName,
+			Finalizers: []string{svcExportCleanupFinalizer},
+		},
+	}
+	Expect(memberClient.Create(ctx, svcExport)).Should(Succeed())
+	return svcExport
+}
+
+func createCleanupInternalServiceExport() {
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cleanupInternalSvcExportKey.Namespace,
+			Name:      cleanupInternalSvcExportKey.Name,
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: []fleetnetv1alpha1.ServicePort{
+				{
+					Protocol: "TCP",
+					Port:     svcPort,
+				},
+			},
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:       memberClusterID,
+				Kind:            "Service",
+				Namespace:       cleanupTestNS,
+				Name:            svcName,
+				ResourceVersion: "0",
+				Generation:      0,
+				UID:             "0",
+				ExportedSince:   metav1.Now(),
+			},
+		},
+	}
+	Expect(hubClient.Create(ctx, internalSvcExport)).Should(Succeed())
+}
+
+func getCleanupServiceExport() *fleetnetv1alpha1.ServiceExport {
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	Expect(memberClient.Get(ctx, cleanupSvcExportKey, svcExport)).Should(Succeed())
+	return svcExport
+}
+
+func expectCleanupInternalServiceExportAbsent() {
+	err := hubClient.Get(ctx, cleanupInternalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{})
+	Expect(apierrors.IsNotFound(err)).Should(BeTrue(), "internalServiceExport Get(), got %v, want not found", err)
+}
+
+var _ = Describe("serviceexport controller cleanup", func() {
+	AfterEach(func() {
+		svcExport := &fleetnetv1alpha1.ServiceExport{}
+		if err := memberClient.Get(ctx, cleanupSvcExportKey, svcExport); err == nil {
+			controllerutil.RemoveFinalizer(svcExport, svcExportCleanupFinalizer)
+			Expect(memberClient.Update(ctx, svcExport)).Should(Succeed())
+			Expect(client.IgnoreNotFound(memberClient.Delete(ctx, svcExport))).Should(Succeed())
+		}
+		internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cleanupInternalSvcExportKey.Namespace,
+				Name:      cleanupInternalSvcExportKey.Name,
+			},
+		}
+		Expect(client.IgnoreNotFound(hubClient.Delete(ctx, internalSvcExport))).Should(Succeed())
+		Eventually(func() bool {
+			return apierrors.IsNotFound(memberClient.Get(ctx, cleanupSvcExportKey, &fleetnetv1alpha1.ServiceExport{}))
+		}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+	})
+
+	It("should unexport the service and remove the finalizer", func() {
+		svcExport := createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(err).Should(Succeed())
+
+		expectCleanupInternalServiceExportAbsent()
+		Expect(getCleanupServiceExport().Finalizers).ShouldNot(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should remove the finalizer when the service has never been exported", func() {
+		// The controller may crash right after it adds the finalizer but before it exports the service.
+		svcExport := createCleanupServiceExport()
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(err).Should(Succeed())
+
+		expectCleanupInternalServiceExportAbsent()
+		Expect(getCleanupServiceExport().Finalizers).ShouldNot(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should remove the finalizer when the hub API returns NotFound during delete", func() {
+		svcExport := createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{
+			Delete: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.DeleteOption) error {
+				// The internalServiceExport has been deleted by someone else.
+				return apierrors.NewNotFound(internalSvcExportGR, obj.GetName())
+			},
+		})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(err).Should(Succeed())
+
+		Expect(getCleanupServiceExport().Finalizers).ShouldNot(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should keep the finalizer when the hub API fails to delete", func() {
+		svcExport := createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{
+			Delete: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.DeleteOption) error {
+				return apierrors.NewInternalError(context.DeadlineExceeded)
+			},
+		})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(apierrors.IsInternalError(err)).Should(BeTrue(), "unexportService(), got %v, want internal error", err)
+
+		Expect(hubClient.Get(ctx, cleanupInternalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{})).Should(Succeed())
+		Expect(getCleanupServiceExport().Finalizers).Should(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should return the conflict when the member API fails to remove the finalizer and recover on retry", func() {
+		svcExport := createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+
+		r := newCleanupReconciler(interceptor.Funcs{
+			Update: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.UpdateOption) error {
+				return apierrors.NewConflict(svcExportGR, obj.GetName(), context.Canceled)
+			},
+		}, interceptor.Funcs{})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(apierrors.IsConflict(err)).Should(BeTrue(), "unexportService(), got %v, want conflict error", err)
+
+		// The service has been unexported while the finalizer is still present.
+		expectCleanupInternalServiceExportAbsent()
+		Expect(getCleanupServiceExport().Finalizers).Should(ContainElement(svcExportCleanupFinalizer))
+
+		By("retry with a healthy member API")
+		r = newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{})
+		_, err = r.unexportService(ctx, getCleanupServiceExport())
+		Expect(err).Should(Succeed())
+		Expect(getCleanupServiceExport().Finalizers).ShouldNot(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should return the conflict when the service export is stale", func() {
+		svcExport := createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+
+		By("update the service export so that the copy in hand is stale")
+		latest := getCleanupServiceExport()
+		latest.Labels = map[string]string{"updated": "true"}
+		Expect(memberClient.Update(ctx, latest)).Should(Succeed())
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{})
+		_, err := r.unexportService(ctx, svcExport)
+		Expect(apierrors.IsConflict(err)).Should(BeTrue(), "unexportService(), got %v, want conflict error", err)
+
+		expectCleanupInternalServiceExportAbsent()
+		Expect(getCleanupServiceExport().Finalizers).Should(ContainElement(svcExportCleanupFinalizer))
+	})
+
+	It("should unexport the service when the deleted service export is reconciled", func() {
+		createCleanupServiceExport()
+		createCleanupInternalServiceExport()
+		Expect(memberClient.Delete(ctx, getCleanupServiceExport())).Should(Succeed())
+
+		r := newCleanupReconciler(interceptor.Funcs{}, interceptor.Funcs{})
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: cleanupSvcExportKey})
+		Expect(err).Should(Succeed())
+
+		expectCleanupInternalServiceExportAbsent()
+		Eventually(func() bool {
+			return apierrors.IsNotFound(memberClient.Get(ctx, cleanupSvcExportKey, &fleetnetv1alpha1.ServiceExport{}))
+		}, eventuallyTimeout, eventuallyInterval).Should(BeTrue())
+	})
+})
@@ -11,40 +11,65 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 	"sigs.k8s.io/cloud-provider-azure/pkg/azclient/publicipaddressclient"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"go.goms.io/fleet/pkg/utils/controller"
 
+	configv1alpha1 "go.goms.io/fleet-networking/api/config/v1alpha1"
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/drain"
+	"go.goms.io/fleet-networking/pkg/common/eventdedup"
+	"go.goms.io/fleet-networking/pkg/common/hubschema"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+	fleetlabels "go.goms.io/fleet-networking/pkg/common/labels"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
+	"go.goms.io/fleet-networking/pkg/common/serviceexport"
 )
 
 const (
 	svcExportValidCondReason                 = "ServiceIsValid"
 	svcExportInvalidNotFoundCondReason       = "ServiceNotFound"
 	svcExportInvalidIneligibleCondReason     = "ServiceIneligible"
+	svcExportInvalidNameCondReason           = "InternalServiceExportNameInvalid"
 	svcExportPendingConflictResolutionReason = "ServicePendingConflictResolution"
+	svcExportHubSchemaOutdatedCondReason     = "HubSchemaOutdated"
+	svcExportSuspendedCondReason             = "ServiceExportSuspended"
+	svcExportNameClashCondReason             = "InternalServiceExportNameClash"
 
-	// svcExportCleanupFinalizer is the finalizer ServiceExport controllers adds to mark that
+	internalServiceExportKind = "InternalServiceExport"
+
+	// svcExportCleanupFinalizer is the default finalizer ServiceExport controllers adds to mark that
 	// a ServiceExport can only be deleted after its corresponding Service has been unexported from the hub cluster.
-	svcExportCleanupFinalizer = "networking.fleet.azure.com/svc-export-cleanup"
+	svcExportCleanupFinalizer = objectmeta.ServiceExportFinalizer
 
 	// ControllerName is the name of the Reconciler.
 	ControllerName = "serviceexport-controller"
@@ -63,6 +88,82 @@ type Reconciler struct {
 	AzurePublicIPAddressClient publicipaddressclient.Interface
 
 	EnableTrafficManagerFeature bool
+
+	// HubSchemaChecker reports whether the hub CRDs support the enabled features; the Traffic Manager feature is
+	// disabled at runtime while the hub CRDs do not support it. All features are considered available if it is not
+	// set.
+	HubSchemaChecker *hubschema.Checker
+
+	// ServiceNotFoundRequeueAfter is the interval to requeue a ServiceExport whose Service is not found, so that
+	// the export does not solely depend on the Service create event; it defaults to configv1alpha1.DefaultServiceNotFoundRequeueAfter.
+	ServiceNotFoundRequeueAfter time.Duration
+
+	// Clock is the clock against which the time a ServiceExport spends waiting for its Service is measured; the real
+	// clock is used if it is not set.
+	Clock clock.Clock
+
+	// CleanupFinalizer is the finalizer the controller adds to the ServiceExports it exports, so that their Services
+	// are unexported before they are gone; it defaults to objectmeta.ServiceExportFinalizer. Installations of fleet
+	// networking running side by side against the same hub cluster should each use their own finalizer, so that they
+	// do not clean up the exports of each other.
+	CleanupFinalizer string
+
+	// RetryBudget is the budget shared by the controllers of the member cluster for retrying failed writes to the
+	// hub cluster; once it is depleted, the failed writes are retried after the delay it asks rather than with the
+	// regular backoff of the workqueue alone. Failed writes are retried with the regular backoff if it is not set.
+	RetryBudget *retrybudget.Budget
+
+	// RateLimiter is the rate limiter of the workqueue of the controller; the default one of the controller is used
+	// if it is not set.
+	RateLimiter workqueue.TypedRateLimiter[reconcile.Request]
+
+	// MaxConcurrentReconciles is the maximum number of ServiceExports the controller reconciles at the same time; a
+	// ServiceExport is never reconciled by two workers at once. It defaults to 1 if it is not positive.
+	MaxConcurrentReconciles int
+
+	// DrainTimeout is the period the in-flight writes to the hub cluster are given to complete when the controller
+	// manager shuts down; the writes are cancelled right away if it is not set.
+	DrainTimeout time.Duration
+
+	// UnexportGracePeriod is the period, counting from the deletion of a ServiceExport, the unexport of its Service is
+	// delayed while member clusters still import the Service; the Service is unexported right away, with a
+	// warning event, if it is not set.
+	UnexportGracePeriod time.Duration
+
+	// OpenAPISpecReader reads in full the ConfigMaps which keep the OpenAPI specs of the exported Services, e.g. the
+	// API reader of the manager, so that the ConfigMaps are not cached in full; they are read only when they change.
+	// MemberClient is used if it is not set.
+	OpenAPISpecReader client.Reader
+
+	// InitialSyncPacer paces the first export of the ServiceExports which exist when a cold starting member cluster
+	// joins the fleet; exports are not paced if it is not set.
+	InitialSyncPacer *initialsync.Pacer
+
+	// inFlightWrites tracks the writes to the hub cluster, so that they are drained on shutdown.
+	inFlightWrites *drain.Tracker
+
+	// hubWriteRetries is the rate limiter of the workqueue, which delays the retries of the failed writes to the hub
+	// cluster as per the retry budget.
+	hubWriteRetries         *retrybudget.RateLimiter
+	initHubWriteRetriesOnce sync.Once
+
+	// ownWrites tracks the resource versions of the ServiceExports the controller has just written, so that its own
+	// writes do not trigger reconciliations again.
+	ownWrites         *ownWriteTracker
+	initOwnWritesOnce sync.Once
+
+	// revalidations tracks the ServiceExports whose Services are not found, so that the recoveries through the
+	// periodic revalidation are told apart from the ones triggered by the Service create events.
+	revalidations         *revalidationTracker
+	initRevalidationsOnce sync.Once
+
+	// openAPISpecHashes tracks the hashes of the OpenAPI specs of the exported Services.
+	openAPISpecHashes         *openAPISpecHashTracker
+	initOpenAPISpecHashesOnce sync.Once
+
+	// events records the events which report the state of a ServiceExport only when the state changes.
+	events         *eventdedup.Recorder
+	initEventsOnce sync.Once
 }
 
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceexports,verbs=get;list;watch;create;update;patch;delete
@@ -70,10 +171,12 @@ type Reconciler struct {
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=serviceexports/finalizers,verbs=update
 //+kubebuilder:rbac:groups=networking.fleet.azure.com,resources=internalserviceexports,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile exports a Service.
-func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	svcRef := klog.KRef(req.Namespace, req.Name)
 	startTime := time.Now()
 	klog.V(2).InfoS("Reconciliation starts", "service", svcRef)
@@ -91,6 +194,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			// corresponding Service is exported to the fleet (and a cleanup finalizer is added). Either case requires
 			// no action on this controller's end.
 			klog.V(4).InfoS("Service export is not found", "service", svcRef)
+			r.ownWriteTracker().forget(req.NamespacedName)
+			r.revalidationTracker().forget(req.NamespacedName)
+			r.eventRecorder().Forget(req.NamespacedName)
+			r.InitialSyncPacer.Forget(initialsync.KindServiceExport, req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// An error has occurred when getting the ServiceExport.
@@ -99,20 +206,92 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 
 	// Check if the ServiceExport has been deleted and needs cleanup (unexporting Service).
-	// A ServiceExport needs cleanup when it has the ServiceExport cleanup finalizer added; the absence of this
-	// finalizer guarantees that the corresponding Service has never been exported to the fleet, thus no action
-	// is needed.
 	if svcExport.DeletionTimestamp != nil {
-		if controllerutil.ContainsFinalizer(&svcExport, svcExportCleanupFinalizer) {
-			klog.V(4).InfoS("Service export is deleted; unexport the service", "service", svcRef)
-			res, err := r.unexportService(ctx, &svcExport)
-			if err != nil {
-				klog.ErrorS(err, "Failed to unexport the service", "service", svcRef)
+		r.InitialSyncPacer.Forget(initialsync.KindServiceExport, req.NamespacedName)
+		r.revalidationTracker().forget(req.NamespacedName)
+	}
+	if serviceexport.IsSvcExportCleanupNeeded(&svcExport, r.cleanupFinalizer()) {
+		klog.V(4).InfoS("Service export is deleted; unexport the service", "service", svcRef)
+		res, err := r.unexportService(ctx, &svcExport)
+		if err != nil {
+			klog.ErrorS(err, "Failed to unexport the service", "service", svcRef)
+		}
+		return res, err
+	}
+	if svcExport.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	// Delete the ServiceExport once its export TTL expires; the deletion unexports the Service via the cleanup
+	// finalizer.
+	exportTTL, ttlErr := extractExportTTLFromServiceExport(&svcExport)
+	if ttlErr != nil {
+		klog.V(2).InfoS("Invalid export TTL annotation on the service export; the TTL is ignored", "service", svcRef, "error", ttlErr)
+		r.eventRecorder().Eventf(&svcExport, corev1.EventTypeWarning, "InvalidExportTTLAnnotation", "Service %s has an invalid export TTL annotation, which is ignored: %v", svcExport.Name, ttlErr)
+	} else {
+		r.eventRecorder().Clear(&svcExport, "InvalidExportTTLAnnotation")
+	}
+	// Forget when the Service was first exported once the TTL annotation is removed, so that a TTL added again later
+	// counts from the time it is observed.
+	if exportTTL == 0 && ttlErr == nil && clearExportedAt(&svcExport) {
+		klog.V(4).InfoS("Remove the export start time from the service export without an export TTL", "service", svcRef)
+		if err := r.updateServiceExport(ctx, &svcExport); err != nil {
+			klog.ErrorS(err, "Failed to remove the export start time from the service export", "service", svcRef)
+			return ctrl.Result{}, err
+		}
+	}
+	var ttlRemaining time.Duration
+	// Requeue the ServiceExport when its export TTL expires, whichever path the reconciliation takes, unless it is
+	// requeued earlier anyway; the TTL stops once the Service is unexported, which clears the export start time.
+	defer func() {
+		if _, ok := extractExportedAtFromServiceExport(&svcExport); !ok {
+			ttlRemaining = 0
+		}
+		result = requeueBeforeTTLExpires(result, err, ttlRemaining)
+	}()
+	exportedAt, hasExportedAt := extractExportedAtFromServiceExport(&svcExport)
+	if exportTTL > 0 && hasExportedAt {
+		ttlRemaining = exportedAt.Add(exportTTL).Sub(startTime)
+		if ttlRemaining <= 0 {
+			ttlRemaining = 0
+			klog.V(2).InfoS("The export TTL of the service export has expired; delete the service export", "service", svcRef, "exportTTL", exportTTL, "exportedAt", exportedAt)
+			r.eventRecorder().Eventf(&svcExport, corev1.EventTypeNormal, "TTLExpired", "Service %s has been exported for longer than its export TTL %v and is unexported", svcExport.Name, exportTTL)
+			if err := r.MemberClient.Delete(ctx, &svcExport); err != nil && !apierrors.IsNotFound(err) {
+				klog.ErrorS(err, "Failed to delete the expired service export", "service", svcRef)
+				return ctrl.Result{}, err
 			}
-			return res, err
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Unexport the Service while the export is suspended; the ServiceExport and its cleanup finalizer are kept so that
+	// the export resumes once the suspend annotation is removed.
+	suspended, suspendErr := isServiceExportSuspended(&svcExport)
+	if suspendErr != nil {
+		klog.V(2).InfoS("Invalid suspend annotation on the service export; the annotation is ignored", "service", svcRef, "error", suspendErr)
+		r.eventRecorder().Eventf(&svcExport, corev1.EventTypeWarning, "InvalidSuspendAnnotation", "Service %s has an invalid suspend annotation, which is ignored: %v", svcExport.Name, suspendErr)
+	} else {
+		r.eventRecorder().Clear(&svcExport, "InvalidSuspendAnnotation")
+	}
+	if suspended {
+		klog.V(2).InfoS("The export of the service is suspended; unexport the service", "service", svcRef)
+		if err := r.suspendServiceExport(ctx, &svcExport); err != nil {
+			klog.ErrorS(err, "Failed to suspend the service export", "service", svcRef)
+			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
 	}
+	if err := r.removeSuspendedCondition(ctx, &svcExport); err != nil {
+		klog.ErrorS(err, "Failed to resume the service export", "service", svcRef)
+		return ctrl.Result{}, err
+	}
+
+	// Wait for the turn of the ServiceExport if the initial export of the member cluster is paced; the unexports
+	// above are never delayed.
+	if delay := r.InitialSyncPacer.Delay(initialsync.KindServiceExport, req.NamespacedName); delay > 0 {
+		klog.V(2).InfoS("The initial export of the member cluster is paced; wait for the turn of the service export", "service", svcRef, "delay", delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
 
 	// Check if the Service to export exists.
 	svc := corev1.Service{
@@ -121,7 +300,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			Name:      req.Name,
 		},
 	}
-	err := r.MemberClient.Get(ctx, req.NamespacedName, &svc)
+	err = r.MemberClient.Get(ctx, req.NamespacedName, &svc)
 	switch {
 	// The Service to export does not exist or has been deleted.
 	case apierrors.IsNotFound(err) || svc.DeletionTimestamp != nil:
@@ -129,7 +308,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 		// Unexport the Service if the ServiceExport has the cleanup finalizer added.
 		klog.V(4).InfoS("Service is deleted; unexport the service", "service", svcRef)
-		if controllerutil.ContainsFinalizer(&svcExport, svcExportCleanupFinalizer) {
+		if controllerutil.ContainsFinalizer(&svcExport, r.cleanupFinalizer()) {
 			if _, err = r.unexportService(ctx, &svcExport); err != nil {
 				klog.ErrorS(err, "Failed to unexport the service", "service", svcRef)
 				return ctrl.Result{}, err
@@ -137,23 +316,36 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 		// Mark the ServiceExport as invalid.
 		klog.V(4).InfoS("Mark service export as invalid (service not found)", "service", svcRef)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultInvalid)
 		if err := r.markServiceExportAsInvalidNotFound(ctx, &svcExport); err != nil {
 			klog.ErrorS(err, "Failed to mark service export as invalid (service not found)", "service", svcRef)
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, nil
+		// Requeue the ServiceExport in case the Service create event is missed, e.g. the Service is created
+		// while the controller is restarting; the requeue stops once the Service is found.
+		r.revalidationTracker().serviceNotFound(req.NamespacedName, r.clock().Now())
+		return ctrl.Result{RequeueAfter: r.serviceNotFoundRequeueAfter()}, nil
 	// An unexpected error occurs when retrieving the Service.
 	case err != nil:
 		klog.ErrorS(err, "Failed to get the service", "service", svcRef)
 		return ctrl.Result{}, err
 	}
 
+	// Report the ServiceExports which only recover from a missing Service through the periodic revalidation, i.e.
+	// whose Service create event has been missed.
+	if notFoundSince, revalidated := r.revalidationTracker().serviceFound(req.NamespacedName); revalidated {
+		missingFor := r.clock().Since(notFoundSince).Round(time.Second)
+		klog.V(2).InfoS("The service is found by the periodic revalidation", "service", svcRef, "missingFor", missingFor)
+		svcExportMetrics.recordRevalidated(r.MemberClusterID)
+		r.Recorder.Eventf(&svcExport, corev1.EventTypeNormal, "ServiceFoundOnRevalidation", "Service %s is found by the periodic revalidation after it was missing for %v", svc.Name, missingFor)
+	}
+
 	// Check if the Service is eligible for export.
 	if !isServiceEligibleForExport(&svc) {
 		r.Recorder.Eventf(&svcExport, corev1.EventTypeWarning, "ServiceNotEligible", "Service %s is not eligible for exporting and please check service spec", svc.Name)
 
 		// Unexport ineligible Service if the ServiceExport has the cleanup finalizer added.
-		if controllerutil.ContainsFinalizer(&svcExport, svcExportCleanupFinalizer) {
+		if controllerutil.ContainsFinalizer(&svcExport, r.cleanupFinalizer()) {
 			klog.V(4).InfoS("Service is ineligible; unexport the service", "service", svcRef)
 			if _, err = r.unexportService(ctx, &svcExport); err != nil {
 				klog.ErrorS(err, "Failed to unexport the service", "service", svcRef)
@@ -162,6 +354,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 		// Mark the ServiceExport as invalid.
 		klog.V(4).InfoS("Mark service export as invalid (service ineligible)", "service", svcRef)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultInvalid)
 		err := r.markServiceExportAsInvalidSvcIneligible(ctx, &svcExport, &svc)
 		if err != nil {
 			klog.ErrorS(err, "Failed to mark service export as invalid (service ineligible)", "service", svcRef)
@@ -169,8 +362,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// Find the name of the InternalServiceExport; the Service cannot be exported if no valid name can be assigned.
+	internalSvcExportName, err := formatInternalServiceExportName(&svcExport)
+	if err != nil {
+		klog.V(2).InfoS("Failed to format internalServiceExport name", "service", svcRef, "error", err)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultInvalid)
+		return ctrl.Result{}, r.markServiceExportAsInvalidName(ctx, &svcExport, &svc, err)
+	}
+	internalSvcExportName, legacyInternalSvcExport, err := r.resolveLegacyInternalServiceExportName(ctx, &svcExport, internalSvcExportName)
+	if err != nil {
+		klog.ErrorS(err, "Failed to look up internalServiceExport with the legacy name", "service", svcRef)
+		return ctrl.Result{}, err
+	}
+
 	// Add the cleanup finalizer to the ServiceExport; this must happen before the Service is actually exported.
-	if !controllerutil.ContainsFinalizer(&svcExport, svcExportCleanupFinalizer) {
+	if !controllerutil.ContainsFinalizer(&svcExport, r.cleanupFinalizer()) {
 		klog.V(4).InfoS("Add cleanup finalizer to service export", "service", svcRef)
 		if err := r.addServiceExportCleanupFinalizer(ctx, &svcExport); err != nil {
 			klog.ErrorS(err, "Failed to add cleanup finalizer to svc export", "service", svcRef)
@@ -178,6 +384,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		}
 	}
 
+	// Record when the Service is first exported so that the export TTL, if any, can be enforced; a TTL added to an
+	// exported Service counts from the time it is first observed.
+	if exportTTL > 0 && !hasExportedAt {
+		klog.V(4).InfoS("Annotate service export with the export start time", "service", svcRef, "exportTTL", exportTTL)
+		if err := r.annotateExportedAt(ctx, &svcExport, startTime); err != nil {
+			klog.ErrorS(err, "Failed to annotate service export with the export start time", "service", svcRef)
+			return ctrl.Result{}, err
+		}
+		ttlRemaining = exportTTL
+	}
+
 	// Mark the ServiceExport as valid.
 	klog.V(4).InfoS("Mark service export as valid", "service", svcRef)
 	if err := r.markServiceExportAsValid(ctx, &svcExport, &svc); err != nil {
@@ -185,6 +402,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// Disable the Traffic Manager feature for the export if the hub CRDs do not support it yet.
+	enableTrafficManagerFeature := r.EnableTrafficManagerFeature
+	var missingHubFields []string
+	if enableTrafficManagerFeature {
+		missingHubFields = r.HubSchemaChecker.MissingFields(hubschema.FeatureTrafficManager)
+		enableTrafficManagerFeature = len(missingHubFields) == 0
+	}
+	if err := r.setFeatureUnavailableOnHubCondition(ctx, &svcExport, &svc, missingHubFields); err != nil {
+		klog.ErrorS(err, "Failed to update the feature unavailable on hub condition of service export", "service", svcRef)
+		return ctrl.Result{}, err
+	}
+
 	// Retrieve the last seen resource version and the last seen timestamp; these two values are used for metric collection.
 	// If the two values are not present or not valid, annotate ServiceExport with new values.
 	//
@@ -197,17 +426,44 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	// Export the Service or update the exported Service.
 
 	// Create or update the InternalServiceExport object.
-	internalSvcExport := fleetnetv1alpha1.InternalServiceExport{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: r.HubNamespace,
-			Name:      formatInternalServiceExportName(&svcExport),
-		},
-	}
+	var internalSvcExport fleetnetv1alpha1.InternalServiceExport
 	svcExportPorts := extractServicePorts(&svc)
+	var svcExportWeight int64
+	if enableTrafficManagerFeature {
+		// An invalid weight should not block exporting the service; fall back to the default weight instead.
+		svcExportWeight, err = extractWeightFromServiceExport(&svcExport)
+		if err != nil {
+			klog.V(2).InfoS("Invalid weight annotation on the service export and using the default weight", "service", svcRef, "error", err, "weight", svcExportWeight)
+			r.Recorder.Eventf(&svcExport, corev1.EventTypeWarning, "InvalidWeightAnnotation", "Service %s has an invalid weight annotation, defaulting to %d: %v", svc.Name, svcExportWeight, err)
+		}
+	}
+	openAPISpecConfigMap, openAPISpecHash, err := r.lookupOpenAPISpec(ctx, &svc)
+	switch {
+	case apierrors.IsNotFound(err):
+		// The ConfigMaps are watched; the reference is added once the ConfigMap is created.
+		klog.V(2).InfoS("The OpenAPI spec configMap of the service is not found", "service", svcRef, "configMap", svc.Name+openAPISpecConfigMapSuffix)
+		r.eventRecorder().Eventf(&svcExport, corev1.EventTypeWarning, openAPISpecNotFoundReason, "Service %s is annotated to have an OpenAPI spec, but ConfigMap %s%s is not found", svc.Name, svc.Name, openAPISpecConfigMapSuffix)
+	case err != nil:
+		klog.ErrorS(err, "Failed to get the OpenAPI spec configMap of the service", "service", svcRef)
+		return ctrl.Result{}, err
+	default:
+		r.eventRecorder().Clear(&svcExport, openAPISpecNotFoundReason)
+	}
 	klog.V(2).InfoS("Export the service or update the exported service",
 		"service", svcExport,
 		"internalServiceExport", klog.KObj(&internalSvcExport))
-	createOrUpdateOp, err := controllerutil.CreateOrUpdate(ctx, r.HubClient, &internalSvcExport, func() error {
+	mutate := func() error {
+		// Never overwrite an InternalServiceExport created by another member cluster; this happens only when two
+		// member clusters are misconfigured to share the same hub namespace.
+		if clusterID := internalSvcExport.Spec.ServiceReference.ClusterID; clusterID != "" && clusterID != r.MemberClusterID {
+			return fmt.Errorf("%w: internalServiceExport %s/%s is exported by member cluster %s", errExportNameClash,
+				internalSvcExport.Namespace, internalSvcExport.Name, internalSvcExport.Spec.ServiceReference.ClusterID)
+		}
+
+		var previousSpec *fleetnetv1alpha1.InternalServiceExportSpec
+		if !internalSvcExport.CreationTimestamp.IsZero() {
+			previousSpec = internalSvcExport.Spec.DeepCopy()
+		}
 		if internalSvcExport.CreationTimestamp.IsZero() {
 			// Set the ServiceReference only when the InternalServiceExport is created; most of the fields in
 			// an ExportedObjectReference should be immutable.
@@ -232,21 +488,101 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			)
 		}
 
-		internalSvcExport.Spec.Ports = svcExportPorts
+		// Label the export with the member cluster it is exported from, so that the exports of a member cluster can
+		// be listed in the hub cluster, and with the version of the Service, if any, so that the versions of a service
+		// can be told apart.
+		fleetlabels.SetFleetLabel(&internalSvcExport, objectmeta.InternalServiceExportLabelMemberClusterID, r.MemberClusterID)
+		if svcExport.Spec.Version != "" {
+			fleetlabels.SetFleetLabel(&internalSvcExport, objectmeta.InternalServiceExportLabelServiceVersion, svcExport.Spec.Version)
+		}
+
+		// Rebuild the spec from scratch rather than updating it field by field, so that the optional fields which are
+		// no longer populated, e.g. after the Traffic Manager feature is disabled or the Service stops being an
+		// external load balancer, are removed from the hub cluster instead of keeping their stale values.
+		internalSvcExport.Spec = fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports:                 svcExportPorts,
+			ServiceReference:      internalSvcExport.Spec.ServiceReference,
+			SessionAffinity:       svc.Spec.SessionAffinity,
+			SessionAffinityConfig: svc.Spec.SessionAffinityConfig.DeepCopy(),
+			ImportType:            serviceImportTypeOf(&svc),
+		}
 		internalSvcExport.Spec.ServiceReference.UpdateFromMetaObject(svc.ObjectMeta, metav1.NewTime(exportedSince))
 
-		if r.EnableTrafficManagerFeature {
+		if enableTrafficManagerFeature {
 			klog.V(2).InfoS("Collecting Traffic Manager related information", "service", svcRef)
 			if err := r.setAzureRelatedInformation(ctx, &svc, &internalSvcExport); err != nil {
 				klog.ErrorS(err, "Failed to populate the Azure information for the Traffic Manager feature", "service", svcRef)
 				return err
 			}
+			internalSvcExport.Spec.Weight = &svcExportWeight
+		}
+
+		// Reference the OpenAPI spec of the Service, if any, with the hash of its data, so that the hub cluster can
+		// catalog it and tell when it changes.
+		if openAPISpecConfigMap != "" {
+			if internalSvcExport.Annotations == nil {
+				internalSvcExport.Annotations = map[string]string{}
+			}
+			internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecConfigMap] = openAPISpecConfigMap
+			internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecHash] = openAPISpecHash
+		} else {
+			delete(internalSvcExport.Annotations, objectmeta.InternalServiceExportAnnotationOpenAPISpecConfigMap)
+			delete(internalSvcExport.Annotations, objectmeta.InternalServiceExportAnnotationOpenAPISpecHash)
+		}
+
+		// Keep track of the spec before the change so that operators can identify the change that causes issues.
+		if previousSpec != nil && isExportedServiceSpecChanged(previousSpec, &internalSvcExport.Spec) {
+			previousSpecHash, err := hashInternalServiceExportSpec(previousSpec)
+			if err != nil {
+				klog.ErrorS(err, "Failed to hash the previous spec of internalServiceExport", "service", svcRef, "internalServiceExport", klog.KObj(&internalSvcExport))
+				return err
+			}
+			if internalSvcExport.Annotations == nil {
+				internalSvcExport.Annotations = map[string]string{}
+			}
+			internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationPreviousSpecHash] = previousSpecHash
 		}
 		return nil
+	}
+	var createOrUpdateOp controllerutil.OperationResult
+	// Another replica of the agent, or the agent before it restarts, may have created the InternalServiceExport
+	// after it is read from the (possibly stale) cache; fetch the object again and update it instead.
+	err = r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+		return retry.OnError(retry.DefaultBackoff, isCreateRaceError, func() error {
+			var err error
+			if legacyInternalSvcExport != nil {
+				// The export with the legacy name has just been read; update it without reading it again.
+				internalSvcExport, legacyInternalSvcExport = *legacyInternalSvcExport, nil
+				createOrUpdateOp, err = updateFetchedObject(ctx, r.HubClient, &internalSvcExport, mutate)
+				return err
+			}
+			internalSvcExport = fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: r.HubNamespace,
+					Name:      internalSvcExportName,
+				},
+			}
+			createOrUpdateOp, err = controllerutil.CreateOrUpdate(ctx, r.HubClient, &internalSvcExport, mutate)
+			if isCreateRaceError(err) {
+				klog.V(2).InfoS("InternalServiceExport has been created concurrently; adopting it",
+					"internalServiceExport", klog.KObj(&internalSvcExport), "service", svcRef)
+			}
+			return err
+		})
 	})
 	statusErr := &apierrors.StatusError{}
 	ok := errors.As(err, &statusErr)
+	rejectedFields := hubschema.RejectedFields(err)
 	switch {
+	case errors.Is(err, errExportNameClash):
+		// The name of the InternalServiceExport is taken by another member cluster; retrying will not help until
+		// the clash is resolved.
+		klog.V(2).InfoS("The internalServiceExport name clashes with the export of another member cluster",
+			"internalServiceExport", klog.KObj(&internalSvcExport),
+			"service", svcRef,
+			"error", err)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultInvalid)
+		return ctrl.Result{}, r.markServiceExportAsNameClash(ctx, &svcExport, err)
 	case apierrors.IsAlreadyExists(err) && ok && statusErr.Status().Details.Kind == "Service":
 		// An export with the same key but different UID already exists; unexport the Service first, and
 		// requeue a new attempt to export the Service.
@@ -265,16 +601,65 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		// will trigger another reconciliation loop automatically; for better clarity here the controller requests
 		// the new reconciliation attempt explicitly.
 		return ctrl.Result{Requeue: true}, nil
+	case isInvalidNameError(err):
+		// The hub cluster rejects the name of the InternalServiceExport; retrying will not help until the
+		// ServiceExport is changed.
+		klog.V(2).InfoS("The hub cluster rejected the internalServiceExport name",
+			"internalServiceExport", klog.KObj(&internalSvcExport),
+			"service", svcRef,
+			"error", err)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultInvalid)
+		return ctrl.Result{}, r.markServiceExportAsInvalidName(ctx, &svcExport, &svc, err)
+	case len(rejectedFields) > 0:
+		// The hub cluster rejects fields of the InternalServiceExport; retrying will not help until the fields the
+		// hub CRDs accept are learnt again at the next check of the hub schema.
+		klog.ErrorS(err, "The hub cluster rejected fields of the internalServiceExport",
+			"internalServiceExport", klog.KObj(&internalSvcExport),
+			"service", svcRef,
+			"fields", rejectedFields)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultFailed)
+		if err := r.markServiceExportAsHubFieldRejected(ctx, &svcExport, rejectedFields); err != nil {
+			klog.ErrorS(err, "Failed to mark service export as rejected by the hub cluster", "service", svcRef)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.HubSchemaChecker.CheckInterval()}, nil
 	case err != nil:
 		klog.ErrorS(err, "Failed to create/update InternalServiceExport",
 			"internalServiceExport", klog.KObj(&internalSvcExport),
 			"service", svcRef,
 			"op", createOrUpdateOp)
+		svcExportMetrics.recordResult(r.MemberClusterID, svcExportResultFailed)
+		return ctrl.Result{}, r.hubWriteRetryLimiter().Failed(req, err)
+	}
+	if err := r.resetNameClashCondition(ctx, &svcExport, &svc); err != nil {
+		klog.ErrorS(err, "Failed to reset the name clash condition of service export", "service", svcRef)
+		return ctrl.Result{}, err
+	}
+	if err := r.removeHubFieldRejectedCondition(ctx, &svcExport); err != nil {
+		klog.ErrorS(err, "Failed to remove the hub field rejected condition of service export", "service", svcRef)
 		return ctrl.Result{}, err
 	}
+	svcExportMetrics.recordExported(r.MemberClusterID, &svcExport, createOrUpdateOp == controllerutil.OperationResultCreated, time.Now())
+	// Check again for the features unavailable on the hub cluster.
+	if len(missingHubFields) > 0 {
+		return ctrl.Result{RequeueAfter: r.HubSchemaChecker.CheckInterval()}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// requeueBeforeTTLExpires returns the result of a reconciliation, requeued no later than when the export TTL of the
+// ServiceExport expires; ttlRemaining is 0 if the ServiceExport has no export TTL. Failed reconciliations are
+// requeued by the workqueue anyway.
+func requeueBeforeTTLExpires(result ctrl.Result, err error, ttlRemaining time.Duration) ctrl.Result {
+	if err != nil || ttlRemaining <= 0 || result.Requeue {
+		return result
+	}
+	if result.RequeueAfter == 0 || ttlRemaining < result.RequeueAfter {
+		result.RequeueAfter = ttlRemaining
+	}
+	return result
+}
+
 func (r *Reconciler) setAzureRelatedInformation(ctx context.Context, service *corev1.Service, export *fleetnetv1alpha1.InternalServiceExport) error {
 	export.Spec.Type = service.Spec.Type
 	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
@@ -363,38 +748,71 @@ func (r *Reconciler) lookupPublicIPResourceIDByLoadBalancerIP(ctx context.Contex
 	return nil, nil
 }
 
+func (r *Reconciler) serviceNotFoundRequeueAfter() time.Duration {
+	if r.ServiceNotFoundRequeueAfter <= 0 {
+		return configv1alpha1.DefaultServiceNotFoundRequeueAfter
+	}
+	return r.ServiceNotFoundRequeueAfter
+}
+
 // SetupWithManager builds a controller with Reconciler and sets it up with a controller manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.DrainTimeout > 0 {
+		// The tracker runs with the manager, which waits for it to drain the in-flight writes before it exits.
+		r.inFlightWrites = drain.New(ControllerName, r.DrainTimeout)
+		if err := mgr.Add(r.inFlightWrites); err != nil {
+			return err
+		}
+	}
 	return ctrl.NewControllerManagedBy(mgr).
-		// The ServiceExport controller watches over ServiceExport objects.
-		For(&fleetnetv1alpha1.ServiceExport{}).
-		// The ServiceExport controller watches over Service objects.
-		Watches(&corev1.Service{}, &handler.EnqueueRequestForObject{}).
+		// The ServiceExport controller watches over ServiceExport objects; the updates it makes itself to
+		// ServiceExports, i.e. finalizers, annotations and conditions, are filtered out, as they would otherwise
+		// requeue the ServiceExport just reconciled once per write.
+		For(&fleetnetv1alpha1.ServiceExport{}, builder.WithPredicates(r.ownWriteTracker().predicate())).
+		// The ServiceExport controller watches over Service objects, which it reads from the informer cache; the
+		// member manager strips the managed fields of cached objects.
+		//
+		// The create events of Services are observed as well, so that the ServiceExports recovering through the
+		// periodic revalidation instead can be reported.
+		Watches(&corev1.Service{}, &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(r.revalidationTracker().servicePredicate())).
+		// The ServiceExport controller watches over the ports of EndpointSlices as well, so that an export which
+		// has fallen out of date with the ports actually served is brought up to date.
+		Watches(&discoveryv1.EndpointSlice{},
+			handler.EnqueueRequestsFromMapFunc(r.enqueueServiceExportForEndpointSlice),
+			builder.WithPredicates(endpointSlicePortsChangedPredicate())).
+		// The ServiceExport controller watches over the metadata of ConfigMaps as well, so that an export picks up
+		// the OpenAPI spec of its Service once the ConfigMap keeping it is created or changes; the ConfigMaps are not
+		// cached in full, as the specs may be large.
+		WatchesMetadata(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(enqueueServiceExportForOpenAPISpec)).
+		WithOptions(ctrlcontroller.Options{RateLimiter: r.hubWriteRetryLimiter(), MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
 
 // unexportService unexports a Service, specifically, it deletes the corresponding InternalServiceExport from the
 // hub cluster and removes the cleanup finalizer.
 func (r *Reconciler) unexportService(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) (ctrl.Result, error) {
-	// Get the unique name assigned when the Service is exported. it is guaranteed that Services are
-	// always exported using the name format `ORIGINAL_NAMESPACE-ORIGINAL_NAME`; for example, a Service
-	// from namespace `default`` with the name `store`` will be exported with the name `default-store`.
-	internalSvcExportName := formatInternalServiceExportName(svcExport)
-	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: r.HubNamespace,
-			Name:      internalSvcExportName,
-		},
+	internalSvcExports, err := r.getInternalServiceExports(ctx, svcExport)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Warn that the endpoints of the Service are withdrawn from the member clusters still importing it; the unexport
+	// of a deleted ServiceExport is delayed for the grace period, if any, so that the importers can move off first.
+	if inUseBy := serviceInUseBy(internalSvcExports); len(inUseBy) > 0 {
+		if remaining := r.unexportGraceRemaining(svcExport, time.Now()); remaining > 0 {
+			klog.V(2).InfoS("The service is still in use; delay the unexport", "service", klog.KObj(svcExport), "inUseBy", inUseBy, "remaining", remaining)
+			r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "ServiceInUse",
+				"Service %s is still imported by member clusters %v; it will be unexported in %v", svcExport.Name, inUseBy, remaining.Round(time.Second))
+			return ctrl.Result{RequeueAfter: remaining}, nil
+		}
+		klog.V(2).InfoS("The service is unexported while still in use", "service", klog.KObj(svcExport), "inUseBy", inUseBy)
+		r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "ServiceInUse",
+			"Service %s is unexported while still imported by member clusters %v", svcExport.Name, inUseBy)
 	}
 
 	// Unexport the Service.
-	if err := r.HubClient.Delete(ctx, internalSvcExport); err != nil && !apierrors.IsNotFound(err) {
-		// It is guaranteed that a finalizer is always added to a ServiceExport before the corresponding Service is
-		// actually exported; in some rare occasions, e.g. the controller crashes right after it adds the finalizer
-		// to the ServiceExport but before the it gets a chance to actually export the Service to the
-		// hub cluster, it could happen that a ServiceExport has a finalizer present yet the corresponding Service
-		// has not been exported to the hub cluster. It is an expected behavior and no action is needed on this
-		// controller's end.
+	if err := r.deleteGivenInternalServiceExports(ctx, internalSvcExports); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -402,13 +820,298 @@ func (r *Reconciler) unexportService(ctx context.Context, svcExport *fleetnetv1a
 	if err := r.removeServiceExportCleanupFinalizer(ctx, svcExport); err != nil {
 		return ctrl.Result{}, err
 	}
+	svcExportMetrics.recordUnexported(r.MemberClusterID, svcExport)
 	return ctrl.Result{}, nil
 }
 
-// removeServiceExportCleanupFinalizer removes the cleanup finalizer from a ServiceExport.
+// deleteInternalServiceExports deletes the InternalServiceExports which may have been created for a Service from
+// the hub cluster.
+func (r *Reconciler) deleteInternalServiceExports(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	internalSvcExports, err := r.getInternalServiceExports(ctx, svcExport)
+	if err != nil {
+		return err
+	}
+	return r.deleteGivenInternalServiceExports(ctx, internalSvcExports)
+}
+
+// getInternalServiceExports returns the InternalServiceExports which have been created for a Service in the hub
+// cluster by the member cluster.
+func (r *Reconciler) getInternalServiceExports(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) ([]*fleetnetv1alpha1.InternalServiceExport, error) {
+	// Get the unique names that may have been assigned when the Service is exported. Services are exported using
+	// the name format `ORIGINAL_NAMESPACE-ORIGINAL_NAME`, e.g. a Service from namespace `default` with the name
+	// `store` will be exported with the name `default-store`; names that are too long are shortened with a hash
+	// suffix, though Services exported before the change may still use the legacy name.
+	internalSvcExportNames := []string{formatLegacyInternalServiceExportName(svcExport)}
+	if name, err := formatInternalServiceExportName(svcExport); err == nil && name != internalSvcExportNames[0] {
+		internalSvcExportNames = append(internalSvcExportNames, name)
+	}
+
+	var internalSvcExports []*fleetnetv1alpha1.InternalServiceExport
+	for _, internalSvcExportName := range internalSvcExportNames {
+		internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+		internalSvcExportKey := types.NamespacedName{Namespace: r.HubNamespace, Name: internalSvcExportName}
+		if err := r.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		// Leave the InternalServiceExport alone if its name is taken by another member cluster.
+		if clusterID := internalSvcExport.Spec.ServiceReference.ClusterID; clusterID != r.MemberClusterID {
+			klog.V(2).InfoS("Skipping the internalServiceExport exported by another member cluster",
+				"internalServiceExport", klog.KObj(internalSvcExport), "clusterID", clusterID)
+			continue
+		}
+		internalSvcExports = append(internalSvcExports, internalSvcExport)
+	}
+	return internalSvcExports, nil
+}
+
+// deleteGivenInternalServiceExports deletes the given InternalServiceExports from the hub cluster.
+func (r *Reconciler) deleteGivenInternalServiceExports(ctx context.Context, internalSvcExports []*fleetnetv1alpha1.InternalServiceExport) error {
+	for _, internalSvcExport := range internalSvcExports {
+		err := r.inFlightWrites.Track(ctx, func(ctx context.Context) error {
+			return r.HubClient.Delete(ctx, internalSvcExport, client.Preconditions{UID: &internalSvcExport.UID})
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			// It is guaranteed that a finalizer is always added to a ServiceExport before the corresponding Service is
+			// actually exported; in some rare occasions, e.g. the controller crashes right after it adds the finalizer
+			// to the ServiceExport but before the it gets a chance to actually export the Service to the
+			// hub cluster, it could happen that a ServiceExport has a finalizer present yet the corresponding Service
+			// has not been exported to the hub cluster. It is an expected behavior and no action is needed on this
+			// controller's end.
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceInUseBy returns the IDs of the member clusters which import a Service, as the hub cluster reports in the
+// status of its InternalServiceExports.
+func serviceInUseBy(internalSvcExports []*fleetnetv1alpha1.InternalServiceExport) []fleetnetv1alpha1.ClusterID {
+	var inUseBy []fleetnetv1alpha1.ClusterID
+	for _, internalSvcExport := range internalSvcExports {
+		for _, clusterID := range internalSvcExport.Status.InUseBy {
+			if !slices.Contains(inUseBy, clusterID) {
+				inUseBy = append(inUseBy, clusterID)
+			}
+		}
+	}
+	return inUseBy
+}
+
+// unexportGraceRemaining returns how long the unexport of a deleted ServiceExport whose Service is still in use is
+// delayed; ServiceExports which are not deleted, e.g. whose Services are gone, are unexported right away.
+func (r *Reconciler) unexportGraceRemaining(svcExport *fleetnetv1alpha1.ServiceExport, now time.Time) time.Duration {
+	if r.UnexportGracePeriod <= 0 || svcExport.DeletionTimestamp == nil {
+		return 0
+	}
+	return svcExport.DeletionTimestamp.Add(r.UnexportGracePeriod).Sub(now)
+}
+
+// suspendServiceExport unexports the Service of a suspended ServiceExport and marks the ServiceExport as
+// suspended. The conflict condition is removed so that the EndpointSlices of the Service are unexported as well;
+// it is added back, pending conflict resolution, once the export resumes.
+func (r *Reconciler) suspendServiceExport(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	if err := r.deleteInternalServiceExports(ctx, svcExport); err != nil {
+		return err
+	}
+	svcExportMetrics.recordUnexported(r.MemberClusterID, svcExport)
+	if clearExportedAt(svcExport) {
+		if err := r.updateServiceExport(ctx, svcExport); err != nil {
+			return err
+		}
+	}
+
+	suspendedCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportSuspended))
+	expectedSuspendedCond := &metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportSuspended),
+		Status:             metav1.ConditionTrue,
+		Reason:             svcExportSuspendedCondReason,
+		ObservedGeneration: svcExport.Generation,
+		Message:            fmt.Sprintf("the export of service %s/%s is suspended", svcExport.Namespace, svcExport.Name),
+	}
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	wouldConflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportWouldConflict))
+	if condition.EqualCondition(suspendedCond, expectedSuspendedCond) && conflictCond == nil && wouldConflictCond == nil {
+		// A stable state has been reached; no further action is needed.
+		return nil
+	}
+
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedSuspendedCond)
+	meta.RemoveStatusCondition(&svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	meta.RemoveStatusCondition(&svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportWouldConflict))
+	r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "ServiceExportSuspended", "The export of Service %s is suspended", svcExport.Name)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// removeSuspendedCondition removes the suspended condition from a ServiceExport whose export is no longer
+// suspended.
+func (r *Reconciler) removeSuspendedCondition(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	if meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportSuspended)) == nil {
+		return nil
+	}
+	meta.RemoveStatusCondition(&svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportSuspended))
+	r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "ServiceExportResumed", "The export of Service %s is resumed", svcExport.Name)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// resolveLegacyInternalServiceExportName returns the legacy name of the InternalServiceExport of a Service, along
+// with the InternalServiceExport read, if the Service has been exported with it, so that an existing export is
+// updated rather than duplicated; otherwise, it returns the given name and no InternalServiceExport.
+func (r *Reconciler) resolveLegacyInternalServiceExportName(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, name string) (string, *fleetnetv1alpha1.InternalServiceExport, error) {
+	legacyName := formatLegacyInternalServiceExportName(svcExport)
+	if legacyName == name {
+		return name, nil, nil
+	}
+	legacyInternalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	switch err := r.HubClient.Get(ctx, types.NamespacedName{Namespace: r.HubNamespace, Name: legacyName}, legacyInternalSvcExport); {
+	case apierrors.IsNotFound(err):
+		return name, nil, nil
+	case err != nil:
+		return "", nil, err
+	}
+	klog.V(4).InfoS("Service has been exported with the legacy internalServiceExport name",
+		"service", klog.KObj(svcExport),
+		"internalServiceExport", klog.KObj(legacyInternalSvcExport))
+	return legacyName, legacyInternalSvcExport, nil
+}
+
+// updateFetchedObject mutates an object which has just been read and updates it if the mutation changes it, in the
+// same fashion as controllerutil.CreateOrUpdate does once it has read the object.
+func updateFetchedObject(ctx context.Context, c client.Client, obj client.Object, mutate controllerutil.MutateFn) (controllerutil.OperationResult, error) {
+	existing := obj.DeepCopyObject()
+	if err := mutate(); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if equality.Semantic.DeepEqual(existing, obj) {
+		return controllerutil.OperationResultNone, nil
+	}
+	if err := c.Update(ctx, obj); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	return controllerutil.OperationResultUpdated, nil
+}
+
+// errExportNameClash is returned when the InternalServiceExport of a Service exists but has been created by
+// another member cluster.
+var errExportNameClash = errors.New("the internalServiceExport name is taken by another member cluster")
+
+// isCreateRaceError returns if an error is returned by the API server because the InternalServiceExport to create
+// already exists, as opposed to the AlreadyExists error returned when the export references another Service.
+func isCreateRaceError(err error) bool {
+	if !apierrors.IsAlreadyExists(err) {
+		return false
+	}
+	statusErr := &apierrors.StatusError{}
+	return !errors.As(err, &statusErr) || statusErr.Status().Details == nil || statusErr.Status().Details.Kind != "Service"
+}
+
+// isInvalidNameError returns if an error is returned by the API server because an object name is invalid.
+func isInvalidNameError(err error) bool {
+	if !apierrors.IsInvalid(err) {
+		return false
+	}
+	statusErr := &apierrors.StatusError{}
+	if !errors.As(err, &statusErr) || statusErr.Status().Details == nil {
+		return false
+	}
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Field == "metadata.name" {
+			return true
+		}
+	}
+	return false
+}
+
+// updateServiceExport updates a ServiceExport and records the write as the controller's own.
+func (r *Reconciler) updateServiceExport(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	if err := r.MemberClient.Update(ctx, svcExport); err != nil {
+		return err
+	}
+	r.ownWriteTracker().record(svcExport)
+	return nil
+}
+
+// updateServiceExportStatus updates the status of a ServiceExport and records the write as the controller's own.
+func (r *Reconciler) updateServiceExportStatus(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	if err := r.MemberClient.Status().Update(ctx, svcExport); err != nil {
+		return err
+	}
+	r.ownWriteTracker().record(svcExport)
+	return nil
+}
+
+// ownWriteTracker returns the tracker of the controller's own writes to ServiceExports.
+func (r *Reconciler) ownWriteTracker() *ownWriteTracker {
+	r.initOwnWritesOnce.Do(func() {
+		if r.ownWrites == nil {
+			r.ownWrites = newOwnWriteTracker()
+		}
+	})
+	return r.ownWrites
+}
+
+// revalidationTracker returns the tracker of the ServiceExports whose Services are not found.
+func (r *Reconciler) revalidationTracker() *revalidationTracker {
+	r.initRevalidationsOnce.Do(func() {
+		if r.revalidations == nil {
+			r.revalidations = newRevalidationTracker()
+		}
+	})
+	return r.revalidations
+}
+
+// openAPISpecHashTracker returns the tracker of the hashes of the OpenAPI specs of the exported Services.
+func (r *Reconciler) openAPISpecHashTracker() *openAPISpecHashTracker {
+	r.initOpenAPISpecHashesOnce.Do(func() {
+		if r.openAPISpecHashes == nil {
+			r.openAPISpecHashes = newOpenAPISpecHashTracker()
+		}
+	})
+	return r.openAPISpecHashes
+}
+
+// openAPISpecReader returns the reader of the ConfigMaps which keep the OpenAPI specs of the exported Services.
+func (r *Reconciler) openAPISpecReader() client.Reader {
+	if r.OpenAPISpecReader == nil {
+		return r.MemberClient
+	}
+	return r.OpenAPISpecReader
+}
+
+// eventRecorder returns the recorder of the events which are recorded only when their messages change.
+func (r *Reconciler) eventRecorder() *eventdedup.Recorder {
+	r.initEventsOnce.Do(func() {
+		if r.events == nil {
+			r.events = eventdedup.New(r.Recorder)
+		}
+	})
+	return r.events
+}
+
+// clock returns the clock of the controller.
+func (r *Reconciler) clock() clock.Clock {
+	if r.Clock == nil {
+		return clock.RealClock{}
+	}
+	return r.Clock
+}
+
+// cleanupFinalizer returns the finalizer the controller adds to the ServiceExports it exports.
+func (r *Reconciler) cleanupFinalizer() string {
+	if r.CleanupFinalizer == "" {
+		return svcExportCleanupFinalizer
+	}
+	return r.CleanupFinalizer
+}
+
+// removeServiceExportCleanupFinalizer removes the cleanup finalizer from a ServiceExport, along with the time its
+// Service was first exported, as the Service is no longer exported.
 func (r *Reconciler) removeServiceExportCleanupFinalizer(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
-	controllerutil.RemoveFinalizer(svcExport, svcExportCleanupFinalizer)
-	return r.MemberClient.Update(ctx, svcExport)
+	controllerutil.RemoveFinalizer(svcExport, r.cleanupFinalizer())
+	clearExportedAt(svcExport)
+	return r.updateServiceExport(ctx, svcExport)
 }
 
 // markServiceExportAsInvalidNotFound marks a ServiceExport as invalid.
@@ -427,7 +1130,7 @@ func (r *Reconciler) markServiceExportAsInvalidNotFound(ctx context.Context, svc
 	}
 
 	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedValidCond)
-	return r.MemberClient.Status().Update(ctx, svcExport)
+	return r.updateServiceExportStatus(ctx, svcExport)
 }
 
 // markServiceExportAsInvalidSvcIneligible marks a ServiceExport as invalid.
@@ -446,13 +1149,99 @@ func (r *Reconciler) markServiceExportAsInvalidSvcIneligible(ctx context.Context
 	}
 
 	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedValidCond)
-	return r.MemberClient.Status().Update(ctx, svcExport)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// markServiceExportAsInvalidName marks a ServiceExport as invalid as no valid InternalServiceExport name can
+// be assigned to the Service.
+func (r *Reconciler) markServiceExportAsInvalidName(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, svc *corev1.Service, nameErr error) error {
+	validCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportValid))
+	expectedValidCond := &metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportValid),
+		Status:             metav1.ConditionFalse,
+		Reason:             svcExportInvalidNameCondReason,
+		ObservedGeneration: svc.Generation,
+		Message:            fmt.Sprintf("service %s/%s cannot be exported with a valid name: %v", svcExport.Namespace, svcExport.Name, nameErr),
+	}
+	if condition.EqualCondition(validCond, expectedValidCond) {
+		// A stable state has been reached; no further action is needed.
+		return nil
+	}
+
+	r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "InvalidInternalServiceExportName", "Service %s cannot be exported with a valid name: %v", svcExport.Name, nameErr)
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedValidCond)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// markServiceExportAsNameClash marks a ServiceExport as in conflict as the name of its InternalServiceExport is
+// taken by another member cluster.
+func (r *Reconciler) markServiceExportAsNameClash(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, clashErr error) error {
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	expectedConflictCond := &metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+		Status:             metav1.ConditionTrue,
+		Reason:             svcExportNameClashCondReason,
+		ObservedGeneration: svcExport.Generation,
+		Message:            fmt.Sprintf("service %s/%s cannot be exported: %v", svcExport.Namespace, svcExport.Name, clashErr),
+	}
+	if condition.EqualCondition(conflictCond, expectedConflictCond) {
+		// A stable state has been reached; no further action is needed.
+		return nil
+	}
+
+	r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "InternalServiceExportNameClash", "Service %s cannot be exported: %v", svcExport.Name, clashErr)
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedConflictCond)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// resetNameClashCondition marks a ServiceExport whose name clash has been resolved as pending conflict resolution
+// again, now that its Service has been exported.
+func (r *Reconciler) resetNameClashCondition(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, svc *corev1.Service) error {
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	if conflictCond == nil || conflictCond.Reason != svcExportNameClashCondReason {
+		return nil
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, metav1.Condition{
+		Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+		Status:             metav1.ConditionUnknown,
+		ObservedGeneration: svc.Generation,
+		Reason:             svcExportPendingConflictResolutionReason,
+		Message:            fmt.Sprintf("service %s/%s is pending export conflict resolution", svcExport.Namespace, svcExport.Name),
+	})
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// markServiceExportAsHubFieldRejected adds the hub field rejected condition to a ServiceExport whose
+// InternalServiceExport the hub cluster rejects for the fields its CRDs do not accept.
+func (r *Reconciler) markServiceExportAsHubFieldRejected(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, fields []string) error {
+	cond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportHubFieldRejected))
+	expectedCond := condition.HubFieldRejectedCondition(svcExport, internalServiceExportKind, fields)
+	// The message is compared as well, as it names the rejected fields.
+	if condition.EqualCondition(cond, &expectedCond) && cond.Message == expectedCond.Message {
+		return nil
+	}
+	r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "HubFieldRejected", "The hub cluster rejects the fields %s of the export of Service %s",
+		strings.Join(fields, ", "), svcExport.Name)
+	meta.SetStatusCondition(&svcExport.Status.Conditions, expectedCond)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// removeHubFieldRejectedCondition removes the hub field rejected condition added for the InternalServiceExport from a
+// ServiceExport, now that the hub cluster accepts the InternalServiceExport.
+func (r *Reconciler) removeHubFieldRejectedCondition(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
+	condType := string(fleetnetv1alpha1.ServiceExportHubFieldRejected)
+	cond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	if cond == nil || cond.Reason != condition.HubFieldRejectedReason(internalServiceExportKind) {
+		return nil
+	}
+	meta.RemoveStatusCondition(&svcExport.Status.Conditions, condType)
+	return r.updateServiceExportStatus(ctx, svcExport)
 }
 
 // addServiceExportCleanupFinalizer adds the cleanup finalizer to a ServiceExport.
 func (r *Reconciler) addServiceExportCleanupFinalizer(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport) error {
-	controllerutil.AddFinalizer(svcExport, svcExportCleanupFinalizer)
-	return r.MemberClient.Update(ctx, svcExport)
+	controllerutil.AddFinalizer(svcExport, r.cleanupFinalizer())
+	return r.updateServiceExport(ctx, svcExport)
 }
 
 // markServiceExportAsValid marks a ServiceExport as valid; if no conflict condition has been added, the
@@ -483,7 +1272,38 @@ func (r *Reconciler) markServiceExportAsValid(ctx context.Context, svcExport *fl
 	})
 	r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "ValidServiceExport", "Service %s is valid for export", svcExport.Name)
 	r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "PendingExportConflictResolution", "Service %s is pending export conflict resolution", svcExport.Name)
-	return r.MemberClient.Status().Update(ctx, svcExport)
+	return r.updateServiceExportStatus(ctx, svcExport)
+}
+
+// setFeatureUnavailableOnHubCondition adds the feature unavailable on hub condition to a ServiceExport if some
+// fields the enabled features depend on are missing from the hub CRDs, and removes it otherwise.
+func (r *Reconciler) setFeatureUnavailableOnHubCondition(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, svc *corev1.Service, missingFields []string) error {
+	condType := string(fleetnetv1alpha1.ServiceExportFeatureUnavailableOnHub)
+	cond := meta.FindStatusCondition(svcExport.Status.Conditions, condType)
+	if len(missingFields) == 0 {
+		if cond == nil {
+			return nil
+		}
+		meta.RemoveStatusCondition(&svcExport.Status.Conditions, condType)
+		r.Recorder.Eventf(svcExport, corev1.EventTypeNormal, "FeatureAvailableOnHub", "The hub cluster supports all the enabled features for Service %s", svcExport.Name)
+		return r.updateServiceExportStatus(ctx, svcExport)
+	}
+
+	expectedCond := &metav1.Condition{
+		Type:               condType,
+		Status:             metav1.ConditionTrue,
+		Reason:             svcExportHubSchemaOutdatedCondReason,
+		ObservedGeneration: svc.Generation,
+		Message: fmt.Sprintf("the %s feature is disabled for service %s/%s, as the hub cluster does not support the fields %s",
+			hubschema.FeatureTrafficManager, svcExport.Namespace, svcExport.Name, strings.Join(missingFields, ", ")),
+	}
+	// The message is compared as well, as it lists the missing fields.
+	if condition.EqualCondition(cond, expectedCond) && cond.Message == expectedCond.Message {
+		return nil
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, *expectedCond)
+	r.Recorder.Eventf(svcExport, corev1.EventTypeWarning, "FeatureUnavailableOnHub", "The %s feature is disabled for Service %s until the hub cluster is upgraded", hubschema.FeatureTrafficManager, svcExport.Name)
+	return r.updateServiceExportStatus(ctx, svcExport)
 }
 
 // collectAndVerifyLastSeenResourceVersionAndTime collects and verifies the last seen resource version and timestamp annotations
@@ -517,5 +1337,35 @@ func (r *Reconciler) annotateLastSeenResourceVersionAndTimestamp(ctx context.Con
 
 	svcExport.Annotations[metrics.MetricsAnnotationLastSeenResourceVersion] = svc.ResourceVersion
 	svcExport.Annotations[metrics.MetricsAnnotationLastSeenTimestamp] = startTime.Format(metrics.MetricsLastSeenTimestampFormat)
-	return r.MemberClient.Update(ctx, svcExport)
+	return r.updateServiceExport(ctx, svcExport)
+}
+
+// annotateExportedAt annotates a ServiceExport with the time its Service is first exported.
+func (r *Reconciler) annotateExportedAt(ctx context.Context, svcExport *fleetnetv1alpha1.ServiceExport, exportedAt time.Time) error {
+	if svcExport.Annotations == nil {
+		svcExport.Annotations = map[string]string{}
+	}
+	svcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt] = exportedAt.UTC().Format(time.RFC3339)
+	return r.updateServiceExport(ctx, svcExport)
+}
+
+// clearExportedAt removes the time its Service was first exported from a ServiceExport, so that the export TTL, if
+// any, counts from the next export; it returns whether the ServiceExport is changed.
+func clearExportedAt(svcExport *fleetnetv1alpha1.ServiceExport) bool {
+	if _, ok := svcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt]; !ok {
+		return false
+	}
+	delete(svcExport.Annotations, objectmeta.ServiceExportAnnotationExportedAt)
+	return true
+}
+
+// hubWriteRetryLimiter returns the rate limiter of the workqueue, which wraps the configured one and delays the retries
+// of the failed writes to the hub cluster as per the retry budget.
+func (r *Reconciler) hubWriteRetryLimiter() *retrybudget.RateLimiter {
+	r.initHubWriteRetriesOnce.Do(func() {
+		if r.hubWriteRetries == nil {
+			r.hubWriteRetries = retrybudget.NewRateLimiter(r.RetryBudget, r.RateLimiter)
+		}
+	})
+	return r.hubWriteRetries
 }
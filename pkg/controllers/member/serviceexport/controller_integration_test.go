@@ -24,6 +24,7 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 const (
@@ -38,6 +39,10 @@ const (
 	consistentlyDuration = time.Millisecond * 1000
 	consistentlyInterval = time.Millisecond * 50
 
+	// maxReconcilesPerEvent is the most reconciliations a ServiceExport may go through for one lifecycle event
+	// before it settles.
+	maxReconcilesPerEvent = 2
+
 	testIngressIP          = "1.2.3.4"
 	testPublicIPResourceID = "/subscriptions/sub1/resourceGroups/valid-rg/providers/Microsoft.Network/publicIPAddresses/pip"
 )
@@ -74,6 +79,12 @@ func headlessService() *corev1.Service {
 			Selector: map[string]string{
 				"app": "redis",
 			},
+			Ports: []corev1.ServicePort{
+				{
+					Port:       svcPort,
+					TargetPort: intstr.FromInt(targetPort),
+				},
+			},
 		},
 	}
 }
@@ -301,7 +312,8 @@ func serviceIsExportedToHubActual(serviceType corev1.ServiceType, isPublicAzureL
 				svc.ObjectMeta,
 				metav1.NewTime(lastSeenTimestamp),
 			),
-			Type: serviceType,
+			Type:   serviceType,
+			Weight: ptr.To(int64(1)),
 		}
 		if isPublicAzureLoadBalancer {
 			expectedInternalSvcExportSpec.IsDNSLabelConfigured = true
@@ -425,6 +437,13 @@ var _ = Describe("serviceexport controller", func() {
 			Eventually(serviceIsExportedFromMemberActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
 			Eventually(serviceIsExportedToHubActual(svc.Spec.Type, false), eventuallyTimeout, eventuallyInterval).Should(Succeed())
 
+			By("record the spec of the exported service")
+			previousInternalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+			Expect(hubClient.Get(ctx, internalSvcExportKey, previousInternalSvcExport)).Should(Succeed())
+			Expect(previousInternalSvcExport.Annotations).ShouldNot(HaveKey(objectmeta.InternalServiceExportAnnotationPreviousSpecHash))
+			previousSpecHash, err := hashInternalServiceExportSpec(&previousInternalSvcExport.Spec)
+			Expect(err).Should(Succeed())
+
 			By("update the service")
 			Expect(memberClient.Get(ctx, svcOrSvcExportKey, svc)).Should(Succeed())
 			svc.Spec.Ports = []corev1.ServicePort{
@@ -472,11 +491,15 @@ var _ = Describe("serviceexport controller", func() {
 						svc.ObjectMeta,
 						metav1.Now(),
 					),
-					Type: svc.Spec.Type,
+					Type:   svc.Spec.Type,
+					Weight: ptr.To(int64(1)),
 				}
 				if diff := cmp.Diff(internalSvcExport.Spec, expectedInternalSvcExportSpec, ignoredRefFields); diff != "" {
 					return fmt.Errorf("internalServiceExport spec (-got, +want): %s", diff)
 				}
+				if got := internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationPreviousSpecHash]; got != previousSpecHash {
+					return fmt.Errorf("internalServiceExport previous spec hash, got %s, want %s", got, previousSpecHash)
+				}
 				return nil
 			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
 		})
@@ -546,7 +569,7 @@ var _ = Describe("serviceexport controller", func() {
 		})
 	})
 
-	Context("export ineligible service (headless)", func() {
+	Context("export headless service", func() {
 		var svcExport = &fleetnetv1alpha1.ServiceExport{}
 		var svc = &corev1.Service{}
 
@@ -562,14 +585,17 @@ var _ = Describe("serviceexport controller", func() {
 			Expect(memberClient.Delete(ctx, svcExport)).Should(Succeed())
 			Expect(memberClient.Delete(ctx, svc)).Should(Succeed())
 
+			// Confirm that the Service has been unexported; this helps make the tests less flaky.
+			Eventually(serviceIsNotExportedActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
 			// Confirm that Service + ServiceExport have been deleted; this helps make the test less flaky.
 			Eventually(serviceExportIsAbsentActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
 			Eventually(serviceIsAbsentActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
 		})
 
-		It("should mark the service export as invalid (ineligible) + should not export headless service", func() {
-			Eventually(serviceIsInvalidForExportIneligibleActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
-			Consistently(serviceIsNotExportedActual, consistentlyDuration, consistentlyInterval).Should(Succeed())
+		It("should mark the service export as valid + should export headless service", func() {
+			Eventually(serviceIsExportedFromMemberActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Eventually(serviceIsExportedToHubActual(svc.Spec.Type, false), eventuallyTimeout, eventuallyInterval).Should(Succeed())
 		})
 	})
 
@@ -795,3 +821,72 @@ var _ = Describe("serviceexport controller", func() {
 		})
 	})
 })
+
+var _ = Describe("serviceexport controller reconciliations", func() {
+	Context("lifecycle of an exported service", func() {
+		var svc = &corev1.Service{}
+
+		// reconcilesSettleActual runs with Consistently assertion to make sure that the ServiceExport settles in
+		// a bounded number of reconciliations.
+		reconcilesSettleActual := func() error {
+			if got := svcExportReconciles.count(svcOrSvcExportKey); got > maxReconcilesPerEvent {
+				return fmt.Errorf("reconciliations, got %d, want at most %d", got, maxReconcilesPerEvent)
+			}
+			return nil
+		}
+
+		BeforeEach(func() {
+			svc = clusterIPService()
+			Expect(memberClient.Create(ctx, svc)).Should(Succeed())
+			// The Service create event triggers a reconciliation of the ServiceExport, which is not created yet.
+			Eventually(func() int {
+				return svcExportReconciles.count(svcOrSvcExportKey)
+			}, eventuallyTimeout, eventuallyInterval).Should(BeNumerically(">=", 1))
+		})
+
+		AfterEach(func() {
+			Expect(memberClient.Delete(ctx, svc)).Should(Succeed())
+			Eventually(serviceIsAbsentActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+		})
+
+		It("should settle in a bounded number of reconciliations per lifecycle event", func() {
+			By("export the service")
+			svcExportReconciles.reset(svcOrSvcExportKey)
+			Expect(memberClient.Create(ctx, notYetFulfilledServiceExport())).Should(Succeed())
+			Eventually(serviceIsExportedFromMemberActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Eventually(serviceIsExportedToHubActual(svc.Spec.Type, false), eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Consistently(reconcilesSettleActual, consistentlyDuration, consistentlyInterval).Should(Succeed())
+
+			By("update the service")
+			svcExportReconciles.reset(svcOrSvcExportKey)
+			Expect(memberClient.Get(ctx, svcOrSvcExportKey, svc)).Should(Succeed())
+			svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+				Name:       "port2",
+				Port:       81,
+				TargetPort: intstr.FromInt(8081),
+			})
+			svc.Spec.Ports[0].Name = svcPortName
+			Expect(memberClient.Update(ctx, svc)).Should(Succeed())
+			Eventually(func() error {
+				internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+				if err := hubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+					return fmt.Errorf("internalServiceExport Get(%+v), got %w, want no error", internalSvcExportKey, err)
+				}
+				if len(internalSvcExport.Spec.Ports) != 2 {
+					return fmt.Errorf("internalServiceExport ports, got %d, want 2", len(internalSvcExport.Spec.Ports))
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Consistently(reconcilesSettleActual, consistentlyDuration, consistentlyInterval).Should(Succeed())
+
+			By("unexport the service")
+			svcExportReconciles.reset(svcOrSvcExportKey)
+			svcExport := &fleetnetv1alpha1.ServiceExport{}
+			Expect(memberClient.Get(ctx, svcOrSvcExportKey, svcExport)).Should(Succeed())
+			Expect(memberClient.Delete(ctx, svcExport)).Should(Succeed())
+			Eventually(serviceIsNotExportedActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Eventually(serviceExportIsAbsentActual, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Consistently(reconcilesSettleActual, consistentlyDuration, consistentlyInterval).Should(Succeed())
+		})
+	})
+})
@@ -7,30 +7,47 @@ package serviceexport
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/condition"
+	"go.goms.io/fleet-networking/pkg/common/hubschema"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
 	"go.goms.io/fleet-networking/pkg/common/metrics"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+	"go.goms.io/fleet-networking/pkg/common/retrybudget"
 )
 
 const (
@@ -152,7 +169,7 @@ func TestIsServiceEligibleForExport(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "should not export headless Service",
+			name: "should export headless Service",
 			svc: &corev1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace: memberUserNS,
@@ -168,69 +185,2337 @@ func TestIsServiceEligibleForExport(t *testing.T) {
 					},
 				},
 			},
-			want: false,
+			want: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isServiceEligibleForExport(tc.svc); got != tc.want {
+				t.Errorf("isServiceEligibleForExport(%+v) = %t, want %t", tc.svc, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestFormatInternalServiceExportName tests the formatInternalServiceExportName function.
+func TestFormatInternalServiceExportName(t *testing.T) {
+	testCases := []struct {
+		name      string
+		namespace string
+		svcName   string
+		want      string
+	}{
+		{
+			name:      "should return formatted name",
+			namespace: memberUserNS,
+			svcName:   svcName,
+			want:      "work-app",
+		},
+		{
+			name:      "should return legacy name at the length limit",
+			namespace: strings.Repeat("a", 31),
+			svcName:   strings.Repeat("b", 31),
+			want:      strings.Repeat("a", 31) + "-" + strings.Repeat("b", 31),
+		},
+		{
+			name:      "should return hashed name right above the length limit",
+			namespace: strings.Repeat("a", 31),
+			svcName:   strings.Repeat("b", 32),
+			want:      strings.Repeat("a", 31) + "-" + strings.Repeat("b", 20) + "-" + hashSuffix(strings.Repeat("a", 31), strings.Repeat("b", 32)),
+		},
+		{
+			name:      "should return hashed name for the longest namespace and name",
+			namespace: strings.Repeat("a", 63),
+			svcName:   strings.Repeat("b", 63),
+			want:      strings.Repeat("a", 52) + "-" + hashSuffix(strings.Repeat("a", 63), strings.Repeat("b", 63)),
+		},
+		{
+			name:      "should trim dashes before the hash suffix",
+			namespace: strings.Repeat("a", 51),
+			svcName:   strings.Repeat("b", 63),
+			want:      strings.Repeat("a", 51) + "-" + hashSuffix(strings.Repeat("a", 51), strings.Repeat("b", 63)),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: tc.namespace,
+					Name:      tc.svcName,
+				},
+			}
+			got, err := formatInternalServiceExportName(svcExport)
+			if err != nil {
+				t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+			}
+			if got != tc.want {
+				t.Errorf("formatInternalServiceExportName() = %s, want %s", got, tc.want)
+			}
+			if len(got) > maxInternalServiceExportNameLength {
+				t.Errorf("formatInternalServiceExportName() = %s with length %d, want no longer than %d", got, len(got), maxInternalServiceExportNameLength)
+			}
+		})
+	}
+}
+
+// TestFormatInternalServiceExportName_Version tests that the versions of a Service are exported with different
+// names.
+func TestFormatInternalServiceExportName_Version(t *testing.T) {
+	testCases := []struct {
+		name      string
+		namespace string
+		svcName   string
+		version   string
+		want      string
+	}{
+		{
+			name:      "should append the version",
+			namespace: memberUserNS,
+			svcName:   svcName,
+			version:   "v2",
+			want:      "work-app.v2",
+		},
+		{
+			name:      "should return hashed name including the version above the length limit",
+			namespace: strings.Repeat("a", 31),
+			svcName:   strings.Repeat("b", 31),
+			version:   "v2",
+			want: strings.Repeat("a", 31) + "-" + strings.Repeat("b", 20) + "-" +
+				fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Repeat("a", 31)+"/"+strings.Repeat("b", 31)+"/v2")))[:internalServiceExportNameHashLength],
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: tc.namespace,
+					Name:      tc.svcName,
+				},
+				Spec: fleetnetv1alpha1.ServiceExportSpec{
+					Version: tc.version,
+				},
+			}
+			got, err := formatInternalServiceExportName(svcExport)
+			if err != nil {
+				t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+			}
+			if got != tc.want {
+				t.Errorf("formatInternalServiceExportName() = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestFormatInternalServiceExportName_VersionCollision tests that a version of a Service is not exported with the
+// name of another Service whose name ends with the version.
+func TestFormatInternalServiceExportName_VersionCollision(t *testing.T) {
+	versioned := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: memberUserNS, Name: svcName},
+		Spec:       fleetnetv1alpha1.ServiceExportSpec{Version: "v2"},
+	}
+	unversioned := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{Namespace: memberUserNS, Name: svcName + "-v2"},
+	}
+	versionedName, err := formatInternalServiceExportName(versioned)
+	if err != nil {
+		t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+	}
+	unversionedName, err := formatInternalServiceExportName(unversioned)
+	if err != nil {
+		t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+	}
+	if versionedName == unversionedName {
+		t.Errorf("formatInternalServiceExportName() = %s for both services, want different names", versionedName)
+	}
+	if legacyName := formatLegacyInternalServiceExportName(versioned); legacyName == unversionedName {
+		t.Errorf("formatLegacyInternalServiceExportName() = %s, want a name different from the one of Service %s", legacyName, unversioned.Name)
+	}
+}
+
+// TestFormatInternalServiceExportName_Unique tests that Services whose names share the same prefix are
+// exported with different names.
+func TestFormatInternalServiceExportName_Unique(t *testing.T) {
+	namespace := strings.Repeat("a", 63)
+	svcExport1 := &fleetnetv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: strings.Repeat("b", 63)}}
+	svcExport2 := &fleetnetv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: strings.Repeat("b", 62) + "c"}}
+	name1, err := formatInternalServiceExportName(svcExport1)
+	if err != nil {
+		t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+	}
+	name2, err := formatInternalServiceExportName(svcExport2)
+	if err != nil {
+		t.Fatalf("formatInternalServiceExportName() = %v, want no error", err)
+	}
+	if name1 == name2 {
+		t.Errorf("formatInternalServiceExportName() = %s for both services, want different names", name1)
+	}
+}
+
+// hashSuffix returns the hash suffix of the shortened InternalServiceExport name of a Service.
+func hashSuffix(namespace, name string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(namespace+"/"+name)))[:internalServiceExportNameHashLength]
+}
+
+// TestExtractServicePorts tests the extractServicePorts function.
+func TestExtractServicePorts(t *testing.T) {
+	testCases := []struct {
+		name string
+		svc  *corev1.Service
+		want []fleetnetv1alpha1.ServicePort
+	}{
+		{
+			name: "should extract ports",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{
+						{
+							Name:       "web",
+							Protocol:   corev1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						},
+					},
+				},
+			},
+			want: []fleetnetv1alpha1.ServicePort{
+				{
+					Name:       "web",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExportPorts := extractServicePorts(tc.svc)
+			if !cmp.Equal(svcExportPorts, tc.want) {
+				t.Fatalf("extractServicePorts(%+v) = %v, want %v", tc.svc, svcExportPorts, tc.want)
+			}
+		})
+	}
+}
+
+// TestIsExportedServiceSpecChanged tests the isExportedServiceSpecChanged function.
+func TestIsExportedServiceSpecChanged(t *testing.T) {
+	previous := &fleetnetv1alpha1.InternalServiceExportSpec{
+		Ports: []fleetnetv1alpha1.ServicePort{
+			{
+				Name:       "web",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			},
+		},
+		ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+			ClusterID:       memberClusterID,
+			Kind:            "Service",
+			Namespace:       memberUserNS,
+			Name:            svcName,
+			ResourceVersion: "1",
+			Generation:      1,
+		},
+		Type: corev1.ServiceTypeClusterIP,
+	}
+	testCases := []struct {
+		name    string
+		current func() *fleetnetv1alpha1.InternalServiceExportSpec
+		want    bool
+	}{
+		{
+			name: "unchanged",
+			current: func() *fleetnetv1alpha1.InternalServiceExportSpec {
+				return previous.DeepCopy()
+			},
+		},
+		{
+			name: "only service reference changed",
+			current: func() *fleetnetv1alpha1.InternalServiceExportSpec {
+				spec := previous.DeepCopy()
+				spec.ServiceReference.ResourceVersion = "2"
+				spec.ServiceReference.Generation = 2
+				return spec
+			},
+		},
+		{
+			name: "ports changed",
+			current: func() *fleetnetv1alpha1.InternalServiceExportSpec {
+				spec := previous.DeepCopy()
+				spec.Ports[0].Port = 81
+				return spec
+			},
+			want: true,
+		},
+		{
+			name: "type changed",
+			current: func() *fleetnetv1alpha1.InternalServiceExportSpec {
+				spec := previous.DeepCopy()
+				spec.Type = corev1.ServiceTypeLoadBalancer
+				return spec
+			},
+			want: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isExportedServiceSpecChanged(previous, tc.current()); got != tc.want {
+				t.Fatalf("isExportedServiceSpecChanged() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestHashInternalServiceExportSpec tests the hashInternalServiceExportSpec function.
+func TestHashInternalServiceExportSpec(t *testing.T) {
+	spec := &fleetnetv1alpha1.InternalServiceExportSpec{
+		Ports: []fleetnetv1alpha1.ServicePort{
+			{
+				Name:       "web",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			},
+		},
+		Type: corev1.ServiceTypeClusterIP,
+	}
+	hash, err := hashInternalServiceExportSpec(spec)
+	if err != nil {
+		t.Fatalf("hashInternalServiceExportSpec() = %v, want no error", err)
+	}
+	if len(hash) != 64 {
+		t.Fatalf("hashInternalServiceExportSpec() = %s, want a SHA256 hex digest", hash)
+	}
+	sameHash, err := hashInternalServiceExportSpec(spec.DeepCopy())
+	if err != nil || sameHash != hash {
+		t.Fatalf("hashInternalServiceExportSpec() = (%s, %v), want (%s, nil)", sameHash, err, hash)
+	}
+
+	changedSpec := spec.DeepCopy()
+	changedSpec.Ports[0].Port = 81
+	changedHash, err := hashInternalServiceExportSpec(changedSpec)
+	if err != nil || changedHash == hash {
+		t.Fatalf("hashInternalServiceExportSpec() = (%s, %v), want a different hash from %s", changedHash, err, hash)
+	}
+}
+
+// TestExtractWeightFromServiceExport tests the extractWeightFromServiceExport function.
+func TestExtractWeightFromServiceExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        int64
+		wantErr     bool
+	}{
+		{
+			name: "no weight annotation",
+			want: 1,
+		},
+		{
+			name:        "valid weight",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "100"},
+			want:        100,
+		},
+		{
+			name:        "zero weight",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "0"},
+			want:        0,
+		},
+		{
+			name:        "max weight",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "1000"},
+			want:        1000,
+		},
+		{
+			name:        "weight out of range",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "1001"},
+			want:        1,
+			wantErr:     true,
+		},
+		{
+			name:        "negative weight",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "-1"},
+			want:        1,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid weight",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationWeight: "abc"},
+			want:        1,
+			wantErr:     true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   memberUserNS,
+					Name:        svcName,
+					Annotations: tc.annotations,
+				},
+			}
+			got, err := extractWeightFromServiceExport(svcExport)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("extractWeightFromServiceExport() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("extractWeightFromServiceExport() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestExtractExportTTLFromServiceExport tests the extractExportTTLFromServiceExport function.
+func TestExtractExportTTLFromServiceExport(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        time.Duration
+		wantErr     bool
+	}{
+		{
+			name: "no export TTL annotation",
+		},
+		{
+			name:        "valid export TTL",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationExportTTL: "24h"},
+			want:        24 * time.Hour,
+		},
+		{
+			name:        "zero export TTL",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationExportTTL: "0s"},
+			wantErr:     true,
+		},
+		{
+			name:        "negative export TTL",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationExportTTL: "-1h"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid export TTL",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationExportTTL: "1 day"},
+			wantErr:     true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   memberUserNS,
+					Name:        svcName,
+					Annotations: tc.annotations,
+				},
+			}
+			got, err := extractExportTTLFromServiceExport(svcExport)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("extractExportTTLFromServiceExport() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("extractExportTTLFromServiceExport() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestIsServiceExportSuspended tests the isServiceExportSuspended function.
+func TestIsServiceExportSuspended(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+		wantErr     bool
+	}{
+		{
+			name: "no suspend annotation",
+		},
+		{
+			name:        "suspended",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationSuspend: "true"},
+			want:        true,
+		},
+		{
+			name:        "not suspended",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationSuspend: "false"},
+		},
+		{
+			name:        "invalid suspend annotation",
+			annotations: map[string]string{objectmeta.ServiceExportAnnotationSuspend: "yes"},
+			wantErr:     true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   memberUserNS,
+					Name:        svcName,
+					Annotations: tc.annotations,
+				},
+			}
+			got, err := isServiceExportSuspended(svcExport)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("isServiceExportSuspended() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("isServiceExportSuspended() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestReconcile_ExportTTL tests that a ServiceExport is deleted, and its Service unexported, once its export TTL
+// expires.
+func TestReconcile_ExportTTL(t *testing.T) {
+	ctx := context.Background()
+	exportTTL := 24 * time.Hour
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Annotations: map[string]string{
+				objectmeta.ServiceExportAnnotationExportTTL: exportTTL.String(),
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        recorder,
+	}
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+	req := ctrl.Request{NamespacedName: svcExportKey}
+
+	// The Service is exported, and the ServiceExport is requeued when the TTL expires.
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if res.RequeueAfter <= exportTTL-time.Minute || res.RequeueAfter > exportTTL {
+		t.Fatalf("Reconcile(), got %+v, want requeue after about %v", res, exportTTL)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	if _, ok := extractExportedAtFromServiceExport(gotSvcExport); !ok {
+		t.Fatalf("svc export annotations, got %v, want a valid %s annotation", gotSvcExport.Annotations, objectmeta.ServiceExportAnnotationExportedAt)
+	}
+
+	// The TTL stops, and the export start time is forgotten, once the Service becomes ineligible for export.
+	gotSvc := &corev1.Service{}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	gotSvc.Spec.Type = corev1.ServiceTypeExternalName
+	if err := fakeMemberClient.Update(ctx, gotSvc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	if res, err := reconciler.Reconcile(ctx, req); err != nil || !res.IsZero() {
+		t.Fatalf("Reconcile() for an ineligible service, got (%+v, %v), want (empty result, no error)", res, err)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	if _, ok := gotSvcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt]; ok {
+		t.Fatalf("svc export annotations, got %v, want no %s annotation", gotSvcExport.Annotations, objectmeta.ServiceExportAnnotationExportedAt)
+	}
+
+	// The TTL counts again from the time the Service is exported again.
+	gotSvc.Spec.Type = corev1.ServiceTypeClusterIP
+	if err := fakeMemberClient.Update(ctx, gotSvc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	res, err = reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if res.RequeueAfter <= exportTTL-time.Minute || res.RequeueAfter > exportTTL {
+		t.Fatalf("Reconcile() for a service exported again, got %+v, want requeue after about %v", res, exportTTL)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+
+	// The TTL expires.
+	gotSvcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt] = time.Now().Add(-exportTTL - time.Hour).UTC().Format(time.RFC3339)
+	if err := fakeMemberClient.Update(ctx, gotSvcExport); err != nil {
+		t.Fatalf("svc export Update(), got %v, want no error", err)
+	}
+	if res, err := reconciler.Reconcile(ctx, req); err != nil || !res.IsZero() {
+		t.Fatalf("Reconcile(), got (%+v, %v), want (empty result, no error)", res, err)
+	}
+	var events []string
+	for len(recorder.Events) > 0 {
+		events = append(events, <-recorder.Events)
+	}
+	if len(events) == 0 || !strings.HasPrefix(events[len(events)-1], corev1.EventTypeNormal+" TTLExpired") {
+		t.Errorf("events, got %v, want the last one to be a %s TTLExpired event", events, corev1.EventTypeNormal)
+	}
+
+	// The deletion of the ServiceExport unexports the Service.
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); !apierrors.IsNotFound(err) {
+		t.Errorf("svc export Get(%+v), got %v, want not found error", svcExportKey, err)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); !apierrors.IsNotFound(err) {
+		t.Errorf("internal svc export Get(%+v), got %v, want not found error", internalSvcExportKey, err)
+	}
+}
+
+// TestReconcile_ExportTTLReadded tests that an export TTL removed from a ServiceExport, and added again later, counts
+// from the time it is added again.
+func TestReconcile_ExportTTLReadded(t *testing.T) {
+	ctx := context.Background()
+	exportTTL := 24 * time.Hour
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Annotations: map[string]string{
+				objectmeta.ServiceExportAnnotationExportTTL: exportTTL.String(),
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	req := ctrl.Request{NamespacedName: svcExportKey}
+
+	// The Service is exported with a TTL, which has long expired by the time the TTL annotation is removed.
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	gotSvcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt] = time.Now().Add(-exportTTL - time.Hour).UTC().Format(time.RFC3339)
+	delete(gotSvcExport.Annotations, objectmeta.ServiceExportAnnotationExportTTL)
+	if err := fakeMemberClient.Update(ctx, gotSvcExport); err != nil {
+		t.Fatalf("svc export Update(), got %v, want no error", err)
+	}
+	if res, err := reconciler.Reconcile(ctx, req); err != nil || !res.IsZero() {
+		t.Fatalf("Reconcile() without a TTL, got (%+v, %v), want (empty result, no error)", res, err)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	if _, ok := gotSvcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt]; ok {
+		t.Fatalf("svc export annotations, got %v, want no %s annotation", gotSvcExport.Annotations, objectmeta.ServiceExportAnnotationExportedAt)
+	}
+
+	// The TTL added again counts from now rather than from the first export.
+	gotSvcExport.Annotations[objectmeta.ServiceExportAnnotationExportTTL] = exportTTL.String()
+	if err := fakeMemberClient.Update(ctx, gotSvcExport); err != nil {
+		t.Fatalf("svc export Update(), got %v, want no error", err)
+	}
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if res.RequeueAfter <= exportTTL-time.Minute || res.RequeueAfter > exportTTL {
+		t.Fatalf("Reconcile() with the TTL added again, got %+v, want requeue after about %v", res, exportTTL)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	if gotSvcExport.DeletionTimestamp != nil {
+		t.Errorf("svc export deletion timestamp, got %v, want nil", gotSvcExport.DeletionTimestamp)
+	}
+	if exportedAt, ok := extractExportedAtFromServiceExport(gotSvcExport); !ok || time.Since(exportedAt) > time.Minute {
+		t.Errorf("svc export annotations, got %v, want a %s annotation of about now", gotSvcExport.Annotations, objectmeta.ServiceExportAnnotationExportedAt)
+	}
+}
+
+// TestReconcile_InvalidExportTTL tests that the warning event of an invalid export TTL is recorded only when the
+// annotation changes.
+func TestReconcile_InvalidExportTTL(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Annotations: map[string]string{
+				objectmeta.ServiceExportAnnotationExportTTL: "1 day",
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        recorder,
+	}
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	invalidTTLEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "InvalidExportTTLAnnotation") {
+				count++
+			}
+		}
+		return count
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile(), got %v, want no error", err)
+		}
+	}
+	if got := invalidTTLEvents(); got != 1 {
+		t.Fatalf("InvalidExportTTLAnnotation events of an unchanged annotation, got %d, want 1", got)
+	}
+
+	// A different invalid value is reported again.
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svcExport.Annotations[objectmeta.ServiceExportAnnotationExportTTL] = "-1h"
+	if err := fakeMemberClient.Update(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if got := invalidTTLEvents(); got != 1 {
+		t.Errorf("InvalidExportTTLAnnotation events of a changed annotation, got %d, want 1", got)
+	}
+}
+
+// TestReconcile_InvalidSuspendAnnotation tests that the warning event of an invalid suspend annotation is recorded
+// only once while the annotation stays invalid, and again once it becomes invalid after being fixed.
+func TestReconcile_InvalidSuspendAnnotation(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			Annotations: map[string]string{
+				objectmeta.ServiceExportAnnotationSuspend: "yes",
+			},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        recorder,
+	}
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	invalidSuspendEvents := func() int {
+		count := 0
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "InvalidSuspendAnnotation") {
+				count++
+			}
+		}
+		return count
+	}
+	setSuspendAnnotation := func(val string) {
+		if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+			t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+		}
+		svcExport.Annotations[objectmeta.ServiceExportAnnotationSuspend] = val
+		if err := fakeMemberClient.Update(ctx, svcExport); err != nil {
+			t.Fatalf("svc export Update(), got %v, want no error", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile(), got %v, want no error", err)
+		}
+	}
+	if got := invalidSuspendEvents(); got != 1 {
+		t.Fatalf("InvalidSuspendAnnotation events of an unchanged annotation, got %d, want 1", got)
+	}
+
+	// A valid value clears the event, so the same invalid value set again is reported again.
+	setSuspendAnnotation("false")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if got := invalidSuspendEvents(); got != 0 {
+		t.Fatalf("InvalidSuspendAnnotation events of a valid annotation, got %d, want 0", got)
+	}
+	setSuspendAnnotation("yes")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if got := invalidSuspendEvents(); got != 1 {
+		t.Errorf("InvalidSuspendAnnotation events of an annotation invalid again, got %d, want 1", got)
+	}
+}
+
+// TestRequeueBeforeTTLExpires tests the requeueBeforeTTLExpires function.
+func TestRequeueBeforeTTLExpires(t *testing.T) {
+	testCases := []struct {
+		name         string
+		result       ctrl.Result
+		err          error
+		ttlRemaining time.Duration
+		want         ctrl.Result
+	}{
+		{
+			name:   "no export TTL",
+			result: ctrl.Result{RequeueAfter: time.Minute},
+			want:   ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:         "no requeue",
+			ttlRemaining: time.Hour,
+			want:         ctrl.Result{RequeueAfter: time.Hour},
+		},
+		{
+			name:         "earlier requeue",
+			result:       ctrl.Result{RequeueAfter: time.Minute},
+			ttlRemaining: time.Hour,
+			want:         ctrl.Result{RequeueAfter: time.Minute},
+		},
+		{
+			name:         "later requeue",
+			result:       ctrl.Result{RequeueAfter: 2 * time.Hour},
+			ttlRemaining: time.Hour,
+			want:         ctrl.Result{RequeueAfter: time.Hour},
+		},
+		{
+			name:         "immediate requeue",
+			result:       ctrl.Result{Requeue: true},
+			ttlRemaining: time.Hour,
+			want:         ctrl.Result{Requeue: true},
+		},
+		{
+			name:         "error",
+			err:          errors.New("failed"),
+			ttlRemaining: time.Hour,
+			want:         ctrl.Result{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := requeueBeforeTTLExpires(tc.result, tc.err, tc.ttlRemaining); got != tc.want {
+				t.Errorf("requeueBeforeTTLExpires() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// suspendTestReconciler returns a reconciler, with fake clients, which has exported a Service without conflict.
+func suspendTestReconciler(t *testing.T) *Reconciler {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: svcOrSvcExportKey}); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+
+	// The hub cluster resolves the export without conflict, while the export of another cluster conflicts with it.
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	meta.SetStatusCondition(&svcExport.Status.Conditions, metav1.Condition{
+		Type:   string(fleetnetv1alpha1.ServiceExportConflict),
+		Status: metav1.ConditionFalse,
+		Reason: "NoConflictFound",
+	})
+	meta.SetStatusCondition(&svcExport.Status.Conditions, metav1.Condition{
+		Type:   string(fleetnetv1alpha1.ServiceExportWouldConflict),
+		Status: metav1.ConditionTrue,
+		Reason: "ConflictingExportsFound",
+	})
+	if err := fakeMemberClient.Status().Update(ctx, svcExport); err != nil {
+		t.Fatalf("svc export status Update(), got %v, want no error", err)
+	}
+	return reconciler
+}
+
+// setSuspendAnnotation sets or, if suspend is empty, removes the suspend annotation of the test ServiceExport.
+func setSuspendAnnotation(t *testing.T, reconciler *Reconciler, suspend string) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if suspend == "" {
+		delete(svcExport.Annotations, objectmeta.ServiceExportAnnotationSuspend)
+	} else {
+		if svcExport.Annotations == nil {
+			svcExport.Annotations = map[string]string{}
+		}
+		svcExport.Annotations[objectmeta.ServiceExportAnnotationSuspend] = suspend
+	}
+	if err := reconciler.MemberClient.Update(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Update(), got %v, want no error", err)
+	}
+}
+
+// TestReconcile_SuspendAndResume tests that suspending a ServiceExport unexports its Service while keeping the
+// ServiceExport, and that removing the suspend annotation exports the Service again.
+func TestReconcile_SuspendAndResume(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	// Suspend the export.
+	setSuspendAnnotation(t, reconciler, "true")
+	for i := 0; i < 2; i++ {
+		if res, err := reconciler.Reconcile(ctx, req); err != nil || !res.IsZero() {
+			t.Fatalf("Reconcile(), got (%+v, %v), want (empty result, no error)", res, err)
+		}
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); !apierrors.IsNotFound(err) {
+		t.Errorf("internal svc export Get(%+v), got %v, want not found error", internalSvcExportKey, err)
+	}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if !controllerutil.ContainsFinalizer(svcExport, svcExportCleanupFinalizer) {
+		t.Errorf("svc export finalizers, got %v, want %s", svcExport.Finalizers, svcExportCleanupFinalizer)
+	}
+	if !meta.IsStatusConditionTrue(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportSuspended)) {
+		t.Errorf("svc export conditions, got %+v, want a true %s condition", svcExport.Status.Conditions, fleetnetv1alpha1.ServiceExportSuspended)
+	}
+	// The EndpointSlices of the Service are unexported as the export is no longer free of conflict.
+	if cond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict)); cond != nil {
+		t.Errorf("svc export conflict condition, got %+v, want none", cond)
+	}
+	if cond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportWouldConflict)); cond != nil {
+		t.Errorf("svc export would conflict condition, got %+v, want none", cond)
+	}
+
+	// Resume the export.
+	setSuspendAnnotation(t, reconciler, "")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); err != nil {
+		t.Errorf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if cond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportSuspended)); cond != nil {
+		t.Errorf("svc export suspended condition, got %+v, want none", cond)
+	}
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	if conflictCond == nil || conflictCond.Status != metav1.ConditionUnknown || conflictCond.Reason != svcExportPendingConflictResolutionReason {
+		t.Errorf("svc export conflict condition, got %+v, want it to be pending conflict resolution", conflictCond)
+	}
+}
+
+// TestReconcile_DeleteSuspendedServiceExport tests that a suspended ServiceExport can be deleted.
+func TestReconcile_DeleteSuspendedServiceExport(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+
+	setSuspendAnnotation(t, reconciler, "true")
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if err := reconciler.MemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); !apierrors.IsNotFound(err) {
+		t.Errorf("svc export Get(%+v), got %v, want not found error", svcOrSvcExportKey, err)
+	}
+}
+
+// TestReconcile_CustomCleanupFinalizer tests that the controller exports and unexports a Service with its own
+// cleanup finalizer, leaving the finalizer of another installation untouched.
+func TestReconcile_CustomCleanupFinalizer(t *testing.T) {
+	ctx := context.Background()
+	customFinalizer := "example.com/svc-export-cleanup"
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+			// The finalizer of another installation of fleet networking.
+			Finalizers: []string{svcExportCleanupFinalizer},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := &Reconciler{
+		MemberClusterID:  memberClusterID,
+		MemberClient:     fakeMemberClient,
+		HubClient:        fakeHubClient,
+		HubNamespace:     hubNSForMember,
+		Recorder:         record.NewFakeRecorder(10),
+		CleanupFinalizer: customFinalizer,
+	}
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	wantFinalizers := []string{svcExportCleanupFinalizer, customFinalizer}
+	if diff := cmp.Diff(wantFinalizers, svcExport.Finalizers); diff != "" {
+		t.Errorf("svc export finalizers mismatch (-want, +got):\n%s", diff)
+	}
+
+	if err := fakeMemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); !apierrors.IsNotFound(err) {
+		t.Errorf("internal svc export Get(%+v), got %v, want not found error", internalSvcExportKey, err)
+	}
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	wantFinalizers = []string{svcExportCleanupFinalizer}
+	if diff := cmp.Diff(wantFinalizers, svcExport.Finalizers); diff != "" {
+		t.Errorf("svc export finalizers mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+// TestReconcile_RemovedFieldsCleared tests that the fields of an InternalServiceExport which are no longer populated,
+// e.g. after the Traffic Manager feature is disabled, are cleared from the hub cluster on the next reconciliation.
+func TestReconcile_RemovedFieldsCleared(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	// The Service is exported with the Traffic Manager information.
+	reconciler.EnableTrafficManagerFeature = true
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.Weight == nil || internalSvcExport.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Fatalf("internal svc export spec, got weight %v and type %q, want the Traffic Manager information", internalSvcExport.Spec.Weight, internalSvcExport.Spec.Type)
+	}
+	// Fields populated by an older agent for an external load balancer.
+	internalSvcExport.Spec.PublicIPResourceID = ptr.To("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/publicIPAddresses/pip")
+	internalSvcExport.Spec.IsDNSLabelConfigured = true
+	if err := reconciler.HubClient.Update(ctx, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Update(), got %v, want no error", err)
+	}
+
+	// The feature is disabled; the Traffic Manager information is cleared from the hub cluster.
+	reconciler.EnableTrafficManagerFeature = false
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	want := fleetnetv1alpha1.InternalServiceExportSpec{
+		Ports: []fleetnetv1alpha1.ServicePort{
+			{
+				Protocol:   corev1.ProtocolTCP,
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			},
+		},
+		ImportType: fleetnetv1alpha1.ClusterSetIP,
+	}
+	if diff := cmp.Diff(want, internalSvcExport.Spec, cmpopts.IgnoreFields(fleetnetv1alpha1.InternalServiceExportSpec{}, "ServiceReference")); diff != "" {
+		t.Errorf("internal svc export spec mismatch (-want, +got):\n%s", diff)
+	}
+	if internalSvcExport.Spec.ServiceReference.ClusterID != memberClusterID {
+		t.Errorf("internal svc export cluster ID, got %s, want %s", internalSvcExport.Spec.ServiceReference.ClusterID, memberClusterID)
+	}
+}
+
+// TestReconcile_SessionAffinity tests that the session affinity of an exported Service is propagated to its
+// InternalServiceExport.
+func TestReconcile_SessionAffinity(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	svc := &corev1.Service{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+	svc.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+		ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: ptr.To(int32(3600))},
+	}
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.SessionAffinity != corev1.ServiceAffinityClientIP {
+		t.Errorf("internal svc export session affinity, got %q, want %q", internalSvcExport.Spec.SessionAffinity, corev1.ServiceAffinityClientIP)
+	}
+	if diff := cmp.Diff(svc.Spec.SessionAffinityConfig, internalSvcExport.Spec.SessionAffinityConfig); diff != "" {
+		t.Errorf("internal svc export session affinity config mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The session affinity is removed from the export once the Service no longer has it.
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	svc.Spec.SessionAffinityConfig = nil
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.SessionAffinity != corev1.ServiceAffinityNone || internalSvcExport.Spec.SessionAffinityConfig != nil {
+		t.Errorf("internal svc export session affinity, got %q with config %+v, want %q without config", internalSvcExport.Spec.SessionAffinity, internalSvcExport.Spec.SessionAffinityConfig, corev1.ServiceAffinityNone)
+	}
+}
+
+// TestReconcile_ServiceImportType tests that the type of the ServiceImport an exported Service is imported as is
+// derived from the cluster IP of the Service.
+func TestReconcile_ServiceImportType(t *testing.T) {
+	testCases := []struct {
+		name      string
+		clusterIP string
+		want      fleetnetv1alpha1.ServiceImportType
+	}{
+		{
+			name:      "service with a cluster IP",
+			clusterIP: "10.0.0.10",
+			want:      fleetnetv1alpha1.ClusterSetIP,
+		},
+		{
+			name:      "headless service",
+			clusterIP: corev1.ClusterIPNone,
+			want:      fleetnetv1alpha1.Headless,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			reconciler := suspendTestReconciler(t)
+			req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+			internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+			svc := &corev1.Service{}
+			if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+				t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+			}
+			svc.Spec.ClusterIP = tc.clusterIP
+			if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+				t.Fatalf("svc Update(), got %v, want no error", err)
+			}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile(), got %v, want no error", err)
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+			if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+				t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+			}
+			if got := internalSvcExport.Spec.ImportType; got != tc.want {
+				t.Errorf("internal svc export import type, got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestReconcile_OpenAPISpec tests that the export references the ConfigMap keeping the OpenAPI spec of a Service
+// annotated to have one, with the hash of its data, once the ConfigMap exists.
+func TestReconcile_OpenAPISpec(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+	getOpenAPISpecAnnotations := func() (string, string, bool) {
+		internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+		if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+			t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+		}
+		configMap, ok := internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecConfigMap]
+		return configMap, internalSvcExport.Annotations[objectmeta.InternalServiceExportAnnotationOpenAPISpecHash], ok
+	}
+	countNotFoundEvents := func() int {
+		count := 0
+		for recorder := reconciler.Recorder.(*record.FakeRecorder); len(recorder.Events) > 0; {
+			if strings.Contains(<-recorder.Events, openAPISpecNotFoundReason) {
+				count++
+			}
+		}
+		return count
+	}
+
+	svc := &corev1.Service{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Annotations = map[string]string{objectmeta.ServiceAnnotationOpenAPISpec: "true"}
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	// The missing ConfigMap is reported once, however many times the Service is reconciled.
+	for i := 0; i < 2; i++ {
+		if _, err := reconciler.Reconcile(ctx, req); err != nil {
+			t.Fatalf("Reconcile(), got %v, want no error", err)
+		}
+	}
+	if configMap, _, ok := getOpenAPISpecAnnotations(); ok {
+		t.Errorf("internal svc export OpenAPI spec configMap, got %q, want none before the configMap is created", configMap)
+	}
+	if got := countNotFoundEvents(); got != 1 {
+		t.Errorf("OpenAPISpecNotFound events, got %d, want 1", got)
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName + openAPISpecConfigMapSuffix,
+		},
+		Data: map[string]string{"openapi.yaml": "openapi: 3.0.0"},
+	}
+	if err := reconciler.MemberClient.Create(ctx, configMap); err != nil {
+		t.Fatalf("configMap Create(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	gotConfigMap, hash, _ := getOpenAPISpecAnnotations()
+	if gotConfigMap != configMap.Name {
+		t.Errorf("internal svc export OpenAPI spec configMap, got %q, want %q", gotConfigMap, configMap.Name)
+	}
+	if hash == "" {
+		t.Errorf("internal svc export OpenAPI spec hash, got none, want one")
+	}
+
+	// The hash changes with the spec.
+	configMap.Data["openapi.yaml"] = "openapi: 3.1.0"
+	if err := reconciler.MemberClient.Update(ctx, configMap); err != nil {
+		t.Fatalf("configMap Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if _, newHash, _ := getOpenAPISpecAnnotations(); newHash == "" || newHash == hash {
+		t.Errorf("internal svc export OpenAPI spec hash after the spec changes, got %q, want a hash other than %q", newHash, hash)
+	}
+
+	// The reference is removed once the Service is no longer annotated.
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Annotations = nil
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if got, hash, ok := getOpenAPISpecAnnotations(); ok || hash != "" {
+		t.Errorf("internal svc export OpenAPI spec annotations, got %q and hash %q, want none", got, hash)
+	}
+}
+
+// TestReconcile_AdoptConcurrentlyCreatedInternalServiceExport tests that the controller updates an
+// InternalServiceExport created after it has been read as missing, e.g. by another replica of the agent, rather than
+// failing the reconciliation.
+func TestReconcile_AdoptConcurrentlyCreatedInternalServiceExport(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	// Change the Service so that the export has to be updated.
+	svc := &corev1.Service{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Spec.Ports[0].Port = 81
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+
+	// The first read of the InternalServiceExport comes from a stale cache which has not seen it yet.
+	staleReads := 1
+	reconciler.HubClient = interceptor.NewClient(reconciler.HubClient.(client.WithWatch), interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*fleetnetv1alpha1.InternalServiceExport); ok && key == internalSvcExportKey && staleReads > 0 {
+				staleReads--
+				return apierrors.NewNotFound(fleetnetv1alpha1.GroupVersion.WithResource("internalserviceexports").GroupResource(), key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	})
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if len(internalSvcExport.Spec.Ports) != 1 || internalSvcExport.Spec.Ports[0].Port != 81 {
+		t.Errorf("internal svc export ports, got %+v, want the updated port 81", internalSvcExport.Spec.Ports)
+	}
+}
+
+// TestReconcile_InitialSyncPacing tests that the ServiceExports in the backlog of a cold starting member cluster wait
+// for their turns to be exported, while their unexports are never delayed.
+func TestReconcile_InitialSyncPacing(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Protocol: corev1.ProtocolTCP,
+					Port:     80,
+				},
+			},
+		},
+	}
+	otherSvcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: "other-app"}
+	pacer := initialsync.New(memberClusterID, 1, 1, &initialsync.Backlog{
+		ServiceExports: []types.NamespacedName{otherSvcExportKey, svcOrSvcExportKey},
+	})
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient: fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(svcExport, svc).
+			WithStatusSubresource(svcExport).
+			Build(),
+		HubClient:        fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:     hubNSForMember,
+		Recorder:         record.NewFakeRecorder(10),
+		InitialSyncPacer: pacer,
+	}
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+
+	// The other ServiceExport spends the only token.
+	if got := pacer.Delay(initialsync.KindServiceExport, otherSvcExportKey); got != 0 {
+		t.Fatalf("Delay(%v), got %v, want 0", otherSvcExportKey, got)
+	}
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if res.RequeueAfter <= 0 {
+		t.Fatalf("Reconcile() while the initial export is paced, got %+v, want a requeue", res)
+	}
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := reconciler.HubClient.List(ctx, internalSvcExportList); err != nil {
+		t.Fatalf("internal svc export List(), got %v, want no error", err)
+	}
+	if len(internalSvcExportList.Items) != 0 {
+		t.Fatalf("internal svc exports while the initial export is paced, got %d, want 0", len(internalSvcExportList.Items))
+	}
+
+	// The deleted ServiceExport leaves the backlog, which drains.
+	if err := reconciler.MemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if pacer.IsPacing() {
+		t.Errorf("IsPacing() after the backlog drains, got true, want false")
+	}
+}
+
+// TestReconcile_RetryBudget tests that failed writes to the hub cluster are returned as errors, and retried with
+// growing delays once the retry budget is depleted.
+func TestReconcile_RetryBudget(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+
+	// Change the Service so that the export has to be updated.
+	svc := &corev1.Service{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Spec.Ports[0].Port = 81
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+
+	// The hub API server is under pressure.
+	reconciler.HubClient = interceptor.NewClient(reconciler.HubClient.(client.WithWatch), interceptor.Funcs{
+		Update: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.UpdateOption) error {
+			return apierrors.NewTooManyRequests("the server has received too many requests", 1)
+		},
+	})
+	reconciler.RetryBudget = retrybudget.NewWithClock(1, 1, clocktesting.NewFakePassiveClock(time.Now()))
+
+	// The first failure is retried with the regular backoff; the delays grow once the budget is depleted.
+	for _, wantAtLeast := range []time.Duration{0, time.Second, 2 * time.Second} {
+		res, err := reconciler.Reconcile(ctx, req)
+		if !apierrors.IsTooManyRequests(err) {
+			t.Fatalf("Reconcile(), got %v, want a TooManyRequests error", err)
+		}
+		if !res.IsZero() {
+			t.Errorf("Reconcile() result, got %+v, want zero", res)
+		}
+		if got := reconciler.hubWriteRetryLimiter().When(req); got < wantAtLeast || got > wantAtLeast+time.Second {
+			t.Errorf("When(), got %v, want at least %v", got, wantAtLeast)
+		}
+	}
+}
+
+// TestReconcile_InternalServiceExportNameClash tests that the controller does not overwrite an InternalServiceExport
+// exported by another member cluster, but marks the ServiceExport as in conflict until the clash is resolved.
+func TestReconcile_InternalServiceExportNameClash(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	// Another member cluster, misconfigured to share the hub namespace, exports a Service with the same name.
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	internalSvcExport.Spec.ServiceReference.ClusterID = "other-member"
+	internalSvcExport.Spec.ServiceReference.UID = "other-uid"
+	if err := reconciler.HubClient.Update(ctx, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Update(), got %v, want no error", err)
+	}
+	wantSpec := internalSvcExport.Spec.DeepCopy()
+
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if diff := cmp.Diff(wantSpec, &internalSvcExport.Spec); diff != "" {
+		t.Errorf("internal svc export spec mismatch (-want, +got):\n%s", diff)
+	}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	if conflictCond == nil || conflictCond.Status != metav1.ConditionTrue || conflictCond.Reason != svcExportNameClashCondReason {
+		t.Errorf("svc export conflict condition, got %+v, want a true condition with reason %s", conflictCond, svcExportNameClashCondReason)
+	}
+
+	// Deleting the ServiceExport leaves the export of the other member cluster alone.
+	if err := reconciler.MemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); !apierrors.IsNotFound(err) {
+		t.Errorf("svc export Get(%+v), got %v, want not found error", svcOrSvcExportKey, err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Errorf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+}
+
+// TestReconcile_InternalServiceExportNameClashResolved tests that a ServiceExport is exported again and pending
+// conflict resolution once the InternalServiceExport of another member cluster is gone.
+func TestReconcile_InternalServiceExportNameClashResolved(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	internalSvcExport.Spec.ServiceReference.ClusterID = "other-member"
+	if err := reconciler.HubClient.Update(ctx, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Update(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+
+	// The other member cluster unexports its Service.
+	if err := reconciler.HubClient.Delete(ctx, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.ServiceReference.ClusterID != memberClusterID {
+		t.Errorf("internal svc export cluster ID, got %s, want %s", internalSvcExport.Spec.ServiceReference.ClusterID, memberClusterID)
+	}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	conflictCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportConflict))
+	if conflictCond == nil || conflictCond.Status != metav1.ConditionUnknown || conflictCond.Reason != svcExportPendingConflictResolutionReason {
+		t.Errorf("svc export conflict condition, got %+v, want it to be pending conflict resolution", conflictCond)
+	}
+}
+
+// TestReconcile_HubFieldRejected tests that a ServiceExport whose InternalServiceExport has fields rejected by the hub
+// cluster names the fields in its conditions, and that the condition is removed once the hub cluster accepts the
+// export again.
+func TestReconcile_HubFieldRejected(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	hubClient := reconciler.HubClient
+
+	// Change the Service so that the export has to be updated.
+	svc := &corev1.Service{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svc); err != nil {
+		t.Fatalf("svc Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	svc.Spec.Ports[0].Port = 81
+	if err := reconciler.MemberClient.Update(ctx, svc); err != nil {
+		t.Fatalf("svc Update(), got %v, want no error", err)
+	}
+
+	// The hub cluster validates the fields strictly and does not support the weight yet.
+	reconciler.HubClient = interceptor.NewClient(hubClient.(client.WithWatch), interceptor.Funcs{
+		Update: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.UpdateOption) error {
+			return apierrors.NewBadRequest(`InternalServiceExport in version "v1alpha1" cannot be handled as a InternalServiceExport: ` +
+				`strict decoding error: unknown field "spec.weight"`)
 		},
+	})
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
 	}
+	if want := hubschema.DefaultCheckInterval; res.RequeueAfter != want {
+		t.Errorf("Reconcile() requeueAfter, got %v, want %v", res.RequeueAfter, want)
+	}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	wantCond := condition.HubFieldRejectedCondition(svcExport, internalServiceExportKind, []string{"spec.weight"})
+	rejectedCond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportHubFieldRejected))
+	if diff := cmp.Diff(&wantCond, rejectedCond, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+		t.Errorf("svc export hub field rejected condition mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The hub cluster accepts the export once the fields are learnt again.
+	reconciler.HubClient = hubClient
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := reconciler.MemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if cond := meta.FindStatusCondition(svcExport.Status.Conditions, string(fleetnetv1alpha1.ServiceExportHubFieldRejected)); cond != nil {
+		t.Errorf("svc export hub field rejected condition, got %+v, want none", cond)
+	}
+}
+
+// TestReconcile_VersionedServiceExport tests that a versioned Service is exported with the version in the name
+// and labels of its InternalServiceExport, next to the label of the member cluster, and unexported once its
+// ServiceExport is deleted.
+func TestReconcile_VersionedServiceExport(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: fleetnetv1alpha1.ServiceExportSpec{
+			Version: "v2",
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	req := ctrl.Request{NamespacedName: svcOrSvcExportKey}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s.v2", memberUserNS, svcName)}
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	wantLabels := map[string]string{
+		objectmeta.InternalServiceExportLabelMemberClusterID: memberClusterID,
+		objectmeta.InternalServiceExportLabelServiceVersion:  "v2",
+	}
+	if diff := cmp.Diff(wantLabels, internalSvcExport.Labels); diff != "" {
+		t.Errorf("internal svc export labels (-want, +got):\n%s", diff)
+	}
+
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, svcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if err := fakeMemberClient.Delete(ctx, svcExport); err != nil {
+		t.Fatalf("svc export Delete(), got %v, want no error", err)
+	}
+	if _, err := reconciler.Reconcile(ctx, req); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, internalSvcExport); !apierrors.IsNotFound(err) {
+		t.Errorf("internal svc export Get(%+v), got %v, want not found error", internalSvcExportKey, err)
+	}
+}
+
+// TestReconcile_ServiceCreatedAfterServiceExport tests that a ServiceExport created before its Service is requeued
+// and exported once the Service appears.
+// TestReconcile_FeatureUnavailableOnHub tests that the Traffic Manager feature is disabled while the hub CRDs do not
+// support it, and enabled again once they do.
+func TestReconcile_FeatureUnavailableOnHub(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+	crdScheme := runtime.NewScheme()
+	if err := apiextensionsv1.AddToScheme(crdScheme); err != nil {
+		t.Fatalf("AddToScheme(), got %v, want no error", err)
+	}
+	fakeHubCRDClient := fake.NewClientBuilder().WithScheme(crdScheme).Build()
+	weightRequirement := hubschema.FieldRequirement{
+		CRDName: "internalserviceexports.networking.fleet.azure.com",
+		Version: "v1alpha1",
+		Path:    []string{"spec", "weight"},
+	}
+	checker := &hubschema.Checker{
+		HubClient:    fakeHubCRDClient,
+		Features:     []hubschema.Feature{hubschema.FeatureTrafficManager},
+		Requirements: map[hubschema.Feature][]hubschema.FieldRequirement{hubschema.FeatureTrafficManager: {weightRequirement}},
+		Interval:     time.Minute,
+	}
+	if err := checker.Check(ctx); err != nil {
+		t.Fatalf("Check(), got %v, want no error", err)
+	}
+
+	reconciler := Reconciler{
+		MemberClusterID:             memberClusterID,
+		MemberClient:                fakeMemberClient,
+		HubClient:                   fakeHubClient,
+		HubNamespace:                hubNSForMember,
+		Recorder:                    record.NewFakeRecorder(10),
+		EnableTrafficManagerFeature: true,
+		HubSchemaChecker:            checker,
+	}
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+	req := ctrl.Request{NamespacedName: svcExportKey}
+
+	// The hub CRD does not support the feature; the Service is exported without the Traffic Manager information.
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("Reconcile(), got %+v, want requeue after %v", res, time.Minute)
+	}
+	internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.Weight != nil || internalSvcExport.Spec.Type != "" {
+		t.Errorf("internal svc export spec, got weight %v and type %q, want no Traffic Manager information", internalSvcExport.Spec.Weight, internalSvcExport.Spec.Type)
+	}
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	wantCond := metav1.Condition{
+		Type:   string(fleetnetv1alpha1.ServiceExportFeatureUnavailableOnHub),
+		Status: metav1.ConditionTrue,
+		Reason: svcExportHubSchemaOutdatedCondReason,
+		Message: fmt.Sprintf("the %s feature is disabled for service %s/%s, as the hub cluster does not support the fields %s",
+			hubschema.FeatureTrafficManager, memberUserNS, svcName, weightRequirement),
+	}
+	gotCond := meta.FindStatusCondition(gotSvcExport.Status.Conditions, wantCond.Type)
+	if diff := cmp.Diff(&wantCond, gotCond, ignoredCondFields); diff != "" {
+		t.Errorf("feature unavailable on hub condition mismatch (-want, +got):\n%s", diff)
+	}
+
+	// The hub CRD is upgraded; the feature is enabled again.
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: weightRequirement.CRDName},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:   "v1alpha1",
+					Served: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Type: "object",
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{
+								"spec": {
+									Type: "object",
+									Properties: map[string]apiextensionsv1.JSONSchemaProps{
+										"weight": {Type: "integer"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := fakeHubCRDClient.Create(ctx, crd); err != nil {
+		t.Fatalf("CRD Create(), got %v, want no error", err)
+	}
+	if err := checker.Check(ctx); err != nil {
+		t.Fatalf("Check(), got %v, want no error", err)
+	}
+	if res, err := reconciler.Reconcile(ctx, req); err != nil || !res.IsZero() {
+		t.Fatalf("Reconcile(), got (%+v, %v), want (empty result, no error)", res, err)
+	}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+	if internalSvcExport.Spec.Weight == nil || internalSvcExport.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("internal svc export spec, got weight %v and type %q, want the Traffic Manager information", internalSvcExport.Spec.Weight, internalSvcExport.Spec.Type)
+	}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	if gotCond := meta.FindStatusCondition(gotSvcExport.Status.Conditions, wantCond.Type); gotCond != nil {
+		t.Errorf("feature unavailable on hub condition, got %+v, want nil", gotCond)
+	}
+}
+
+func TestReconcile_ServiceCreatedAfterServiceExport(t *testing.T) {
+	ctx := context.Background()
+	requeueAfter := 10 * time.Second
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport).
+		WithStatusSubresource(svcExport).
+		Build()
+	fakeHubClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	reconciler := Reconciler{
+		MemberClusterID:             memberClusterID,
+		MemberClient:                fakeMemberClient,
+		HubClient:                   fakeHubClient,
+		HubNamespace:                hubNSForMember,
+		Recorder:                    record.NewFakeRecorder(10),
+		ServiceNotFoundRequeueAfter: requeueAfter,
+	}
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+	req := ctrl.Request{NamespacedName: svcExportKey}
+
+	// The ServiceExport is requeued while the Service does not exist.
+	for i := 0; i < 2; i++ {
+		res, err := reconciler.Reconcile(ctx, req)
+		if err != nil {
+			t.Fatalf("Reconcile() #%d, got %v, want no error", i, err)
+		}
+		if want := (ctrl.Result{RequeueAfter: requeueAfter}); res != want {
+			t.Fatalf("Reconcile() #%d, got %+v, want %+v", i, res, want)
+		}
+		gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+		if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+			t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+		}
+		wantConds := []metav1.Condition{serviceExportInvalidNotFoundCondition(memberUserNS, svcName)}
+		if diff := cmp.Diff(gotSvcExport.Status.Conditions, wantConds, ignoredCondFields); diff != "" {
+			t.Fatalf("svc export conditions (-got, +want): %s", diff)
+		}
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Protocol:   corev1.ProtocolTCP,
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+			},
+		},
+	}
+	if err := fakeMemberClient.Create(ctx, svc); err != nil {
+		t.Fatalf("svc Create(%+v), got %v, want no error", svc, err)
+	}
+
+	// The invalid condition is cleared and the Service is exported once it appears.
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	if !res.IsZero() {
+		t.Fatalf("Reconcile(), got %+v, want empty result", res)
+	}
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcExportKey, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", svcExportKey, err)
+	}
+	wantConds := []metav1.Condition{
+		serviceExportValidCondition(memberUserNS, svcName),
+		serviceExportPendingConflictResolutionCondition(memberUserNS, svcName),
+	}
+	if diff := cmp.Diff(gotSvcExport.Status.Conditions, wantConds, ignoredCondFields); diff != "" {
+		t.Fatalf("svc export conditions (-got, +want): %s", diff)
+	}
+	internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", memberUserNS, svcName)}
+	if err := fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{}); err != nil {
+		t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+	}
+}
+
+// TestReconcile_ServiceFoundOnRevalidation tests that a ServiceExport whose Service create event is missed recovers
+// through the periodic revalidation, which is reported, and that the revalidation stops once the export is valid.
+func TestReconcile_ServiceFoundOnRevalidation(t *testing.T) {
+	testCases := []struct {
+		name             string
+		observeSvcCreate bool
+		wantRevalidated  float64
+		wantEvent        string
+	}{
+		{
+			name:            "service create event missed",
+			wantRevalidated: 1,
+			wantEvent:       "Normal ServiceFoundOnRevalidation Service app is found by the periodic revalidation after it was missing for 4m0s",
+		},
+		{
+			name:             "service create event observed",
+			observeSvcCreate: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			clusterID := fmt.Sprintf("revalidation-%t", tc.observeSvcCreate)
+			requeueAfter := 2 * time.Minute
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      svcName,
+				},
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcExport).
+				WithStatusSubresource(svcExport).
+				Build()
+			recorder := record.NewFakeRecorder(20)
+			fakeClock := clocktesting.NewFakeClock(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+			reconciler := Reconciler{
+				MemberClusterID:             clusterID,
+				MemberClient:                fakeMemberClient,
+				HubClient:                   fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+				HubNamespace:                hubNSForMember,
+				Recorder:                    recorder,
+				ServiceNotFoundRequeueAfter: requeueAfter,
+				Clock:                       fakeClock,
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: memberUserNS, Name: svcName}}
+			reconcile := func(want ctrl.Result) {
+				t.Helper()
+				res, err := reconciler.Reconcile(ctx, req)
+				if err != nil {
+					t.Fatalf("Reconcile(), got %v, want no error", err)
+				}
+				if res != want {
+					t.Fatalf("Reconcile(), got %+v, want %+v", res, want)
+				}
+			}
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			if got := isServiceEligibleForExport(tc.svc); got != tc.want {
-				t.Errorf("isServiceEligibleForExport(%+v) = %t, want %t", tc.svc, got, tc.want)
+			// The ServiceExport is revalidated periodically while the Service does not exist.
+			reconcile(ctrl.Result{RequeueAfter: requeueAfter})
+			fakeClock.Step(requeueAfter)
+			reconcile(ctrl.Result{RequeueAfter: requeueAfter})
+
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      svcName,
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8080)}},
+				},
+			}
+			if err := fakeMemberClient.Create(ctx, svc); err != nil {
+				t.Fatalf("svc Create(), got %v, want no error", err)
+			}
+			if tc.observeSvcCreate {
+				reconciler.revalidationTracker().servicePredicate().Create(event.CreateEvent{Object: svc})
+			}
+
+			// The ServiceExport recovers once the Service is found, and is not requeued any more.
+			fakeClock.Step(requeueAfter)
+			reconcile(ctrl.Result{})
+			reconcile(ctrl.Result{})
+
+			if got := testutil.ToFloat64(svcExportsRevalidated.WithLabelValues(clusterID)); got != tc.wantRevalidated {
+				t.Errorf("service exports recovered by revalidation, got %v, want %v", got, tc.wantRevalidated)
+			}
+			var gotEvent string
+			for len(recorder.Events) > 0 {
+				if e := <-recorder.Events; strings.Contains(e, "ServiceFoundOnRevalidation") {
+					gotEvent = e
+				}
+			}
+			if gotEvent != tc.wantEvent {
+				t.Errorf("revalidation event, got %q, want %q", gotEvent, tc.wantEvent)
 			}
 		})
 	}
 }
 
-// TestFormatInternalServiceExportName tests the formatInternalServiceExportName function.
-func TestFormatInternalServiceExportName(t *testing.T) {
+// TestReconcile_LongServiceName tests exporting a Service whose legacy InternalServiceExport name is too long.
+func TestReconcile_LongServiceName(t *testing.T) {
+	longNS := strings.Repeat("a", 63)
+	longSvcName := strings.Repeat("b", 63)
+	legacyName := fmt.Sprintf("%s-%s", longNS, longSvcName)
+	hashedName := strings.Repeat("a", 52) + "-" + hashSuffix(longNS, longSvcName)
+
 	testCases := []struct {
-		name      string
-		svcExport *fleetnetv1alpha1.ServiceExport
-		want      string
+		name              string
+		internalSvcExport *fleetnetv1alpha1.InternalServiceExport
+		wantName          string
+		wantAbsentName    string
 	}{
 		{
-			name: "should return formatted name",
-			svcExport: &fleetnetv1alpha1.ServiceExport{
+			name:           "should export with the hashed name",
+			wantName:       hashedName,
+			wantAbsentName: legacyName,
+		},
+		{
+			name: "should keep exporting with the legacy name",
+			internalSvcExport: &fleetnetv1alpha1.InternalServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace: memberUserNS,
-					Name:      svcName,
+					Namespace: hubNSForMember,
+					Name:      legacyName,
+				},
+				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+						ClusterID: memberClusterID,
+						Namespace: longNS,
+						Name:      longSvcName,
+						UID:       "svc-uid",
+					},
 				},
 			},
-			want: "work-app",
+			wantName:       legacyName,
+			wantAbsentName: hashedName,
 		},
 	}
 
 	for _, tc := range testCases {
-		if got := formatInternalServiceExportName(tc.svcExport); got != tc.want {
-			t.Fatalf("formatInternalServiceExportName(%+v) = %s, want %s", tc.svcExport, got, tc.want)
-		}
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: longNS,
+					Name:      longSvcName,
+				},
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: longNS,
+					Name:      longSvcName,
+					UID:       "svc-uid",
+				},
+				Spec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}},
+				},
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcExport, svc).
+				WithStatusSubresource(svcExport).
+				Build()
+			fakeHubClientBuilder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tc.internalSvcExport != nil {
+				fakeHubClientBuilder = fakeHubClientBuilder.WithObjects(tc.internalSvcExport)
+			}
+			fakeHubClient := fakeHubClientBuilder.Build()
+			hubGets := map[string]int{}
+			reconciler := Reconciler{
+				MemberClusterID: memberClusterID,
+				MemberClient:    fakeMemberClient,
+				HubClient: interceptor.NewClient(fakeHubClient, interceptor.Funcs{
+					Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+						hubGets[key.Name]++
+						return c.Get(ctx, key, obj, opts...)
+					},
+				}),
+				HubNamespace: hubNSForMember,
+				Recorder:     record.NewFakeRecorder(10),
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: longNS, Name: longSvcName}}
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				t.Fatalf("Reconcile() = %v, want no error", err)
+			}
+			if hubGets[tc.wantName] != 1 {
+				t.Errorf("hub Get(%s) calls = %d, want 1", tc.wantName, hubGets[tc.wantName])
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+			wantKey := types.NamespacedName{Namespace: hubNSForMember, Name: tc.wantName}
+			if err := fakeHubClient.Get(ctx, wantKey, internalSvcExport); err != nil {
+				t.Fatalf("internal svc export Get(%+v), got %v, want no error", wantKey, err)
+			}
+			if len(internalSvcExport.Spec.Ports) != 1 {
+				t.Errorf("internal svc export ports = %+v, want the ports of the service", internalSvcExport.Spec.Ports)
+			}
+			absentKey := types.NamespacedName{Namespace: hubNSForMember, Name: tc.wantAbsentName}
+			if err := fakeHubClient.Get(ctx, absentKey, &fleetnetv1alpha1.InternalServiceExport{}); !apierrors.IsNotFound(err) {
+				t.Errorf("internal svc export Get(%+v), got %v, want not found error", absentKey, err)
+			}
+
+			// Both names are cleaned up when the Service is unexported.
+			gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+			if err := fakeMemberClient.Get(ctx, req.NamespacedName, gotSvcExport); err != nil {
+				t.Fatalf("svc export Get(%+v), got %v, want no error", req.NamespacedName, err)
+			}
+			if _, err := reconciler.unexportService(ctx, gotSvcExport); err != nil {
+				t.Fatalf("unexportService() = %v, want no error", err)
+			}
+			if err := fakeHubClient.Get(ctx, wantKey, &fleetnetv1alpha1.InternalServiceExport{}); !apierrors.IsNotFound(err) {
+				t.Errorf("internal svc export Get(%+v), got %v, want not found error", wantKey, err)
+			}
+		})
 	}
 }
 
-// TestExtractServicePorts tests the extractServicePorts function.
-func TestExtractServicePorts(t *testing.T) {
-	testCases := []struct {
-		name string
-		svc  *corev1.Service
-		want []fleetnetv1alpha1.ServicePort
-	}{
-		{
-			name: "should extract ports",
-			svc: &corev1.Service{
-				Spec: corev1.ServiceSpec{
-					Ports: []corev1.ServicePort{
-						{
-							Name:       "web",
-							Protocol:   corev1.ProtocolTCP,
-							Port:       80,
-							TargetPort: intstr.FromInt(8080),
-						},
-					},
-				},
+// TestReconcile_InvalidInternalServiceExportName tests that the ServiceExport is marked as invalid when the hub
+// cluster rejects the name of the InternalServiceExport.
+func TestReconcile_InvalidInternalServiceExportName(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Protocol: corev1.ProtocolTCP, Port: 80}},
+		},
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	nameErr := apierrors.NewInvalid(
+		fleetnetv1alpha1.GroupVersion.WithKind("InternalServiceExport").GroupKind(),
+		fmt.Sprintf("%s-%s", memberUserNS, svcName),
+		field.ErrorList{field.Invalid(field.NewPath("metadata", "name"), svcName, "name is rejected")},
+	)
+	fakeHubClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.CreateOption) error {
+				return nameErr
 			},
-			want: []fleetnetv1alpha1.ServicePort{
+		}).
+		Build()
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: memberUserNS, Name: svcName}}
+	res, err := reconciler.Reconcile(ctx, req)
+	if err != nil || !res.IsZero() {
+		t.Fatalf("Reconcile() = %+v, %v, want empty result and no error", res, err)
+	}
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, req.NamespacedName, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(%+v), got %v, want no error", req.NamespacedName, err)
+	}
+	wantConds := []metav1.Condition{
+		{
+			Type:    string(fleetnetv1alpha1.ServiceExportValid),
+			Status:  metav1.ConditionFalse,
+			Reason:  svcExportInvalidNameCondReason,
+			Message: fmt.Sprintf("service %s/%s cannot be exported with a valid name: %v", memberUserNS, svcName, nameErr),
+		},
+		serviceExportPendingConflictResolutionCondition(memberUserNS, svcName),
+	}
+	if diff := cmp.Diff(gotSvcExport.Status.Conditions, wantConds, ignoredCondFields); diff != "" {
+		t.Fatalf("svc export conditions (-got, +want): %s", diff)
+	}
+}
+
+// TestMarkServiceExportAsInvalidNotFound tests the *Reconciler.markServiceExportAsInvalidNotFound method.
+// TestReconcile_OwnWritesFiltered tests that the updates the controller makes to a ServiceExport do not trigger
+// another reconciliation, while updates made by others do.
+func TestReconcile_OwnWritesFiltered(t *testing.T) {
+	ctx := context.Background()
+	svcExport := &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: memberUserNS,
+			Name:      svcName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
 				{
-					Name:       "web",
 					Protocol:   corev1.ProtocolTCP,
 					Port:       80,
 					TargetPort: intstr.FromInt(8080),
@@ -238,18 +2523,67 @@ func TestExtractServicePorts(t *testing.T) {
 			},
 		},
 	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport, svc).
+		WithStatusSubresource(svcExport).
+		Build()
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        record.NewFakeRecorder(10),
+	}
+	pred := reconciler.ownWriteTracker().predicate()
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			svcExportPorts := extractServicePorts(tc.svc)
-			if !cmp.Equal(svcExportPorts, tc.want) {
-				t.Fatalf("extractServicePorts(%+v) = %v, want %v", tc.svc, svcExportPorts, tc.want)
-			}
-		})
+	created := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, created); err != nil {
+		t.Fatalf("serviceExport Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: svcOrSvcExportKey}); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	reconciled := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, reconciled); err != nil {
+		t.Fatalf("serviceExport Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if pred.Update(event.UpdateEvent{ObjectOld: created, ObjectNew: reconciled}) {
+		t.Errorf("Update() for the controller's own writes, got true, want false")
+	}
+	if !pred.Update(event.UpdateEvent{ObjectOld: reconciled, ObjectNew: reconciled}) {
+		t.Errorf("Update() for a resync, got false, want true")
+	}
+
+	updated := reconciled.DeepCopy()
+	updated.Annotations[objectmeta.ServiceExportAnnotationWeight] = "10"
+	if err := fakeMemberClient.Update(ctx, updated); err != nil {
+		t.Fatalf("serviceExport Update(), got %v, want no error", err)
+	}
+	if !pred.Update(event.UpdateEvent{ObjectOld: reconciled, ObjectNew: updated}) {
+		t.Errorf("Update() for a user change, got false, want true")
+	}
+
+	// Reconciling again settles without writing the ServiceExport.
+	if _, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: svcOrSvcExportKey}); err != nil {
+		t.Fatalf("Reconcile(), got %v, want no error", err)
+	}
+	settled := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, svcOrSvcExportKey, settled); err != nil {
+		t.Fatalf("serviceExport Get(%+v), got %v, want no error", svcOrSvcExportKey, err)
+	}
+	if settled.ResourceVersion != updated.ResourceVersion {
+		t.Errorf("serviceExport resource version, got %s, want %s", settled.ResourceVersion, updated.ResourceVersion)
+	}
+
+	if !pred.Delete(event.DeleteEvent{Object: settled}) {
+		t.Errorf("Delete(), got false, want true")
+	}
+	if _, found := reconciler.ownWriteTracker().resourceVersions[svcOrSvcExportKey]; found {
+		t.Errorf("own writes contain %v, want it to be forgotten", svcOrSvcExportKey)
 	}
 }
 
-// TestMarkServiceExportAsInvalidNotFound tests the *Reconciler.markServiceExportAsInvalidNotFound method.
 func TestMarkServiceExportAsInvalidNotFound(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -756,6 +3090,137 @@ func TestUnexportService(t *testing.T) {
 	}
 }
 
+// TestUnexportService_InUse tests the *Reconciler.unexportService method with Services which are still imported by
+// member clusters.
+func TestUnexportService_InUse(t *testing.T) {
+	internalSvcExportName := fmt.Sprintf("%s-%s", memberUserNS, svcName)
+	now := time.Now()
+
+	testCases := []struct {
+		name                string
+		inUseBy             []fleetnetv1alpha1.ClusterID
+		deletionTimestamp   *metav1.Time
+		unexportGracePeriod time.Duration
+		wantRequeue         bool
+		wantUnexported      bool
+		wantEvents          []string
+	}{
+		{
+			name:              "should unexport svc not in use",
+			deletionTimestamp: &metav1.Time{Time: now},
+			wantUnexported:    true,
+		},
+		{
+			name:              "should unexport svc in use with a warning",
+			inUseBy:           []fleetnetv1alpha1.ClusterID{"member-1", "member-2"},
+			deletionTimestamp: &metav1.Time{Time: now},
+			wantUnexported:    true,
+			wantEvents: []string{
+				fmt.Sprintf("Warning ServiceInUse Service %s is unexported while still imported by member clusters [member-1 member-2]", svcName),
+			},
+		},
+		{
+			name:                "should delay the unexport of svc in use for the grace period",
+			inUseBy:             []fleetnetv1alpha1.ClusterID{"member-1"},
+			deletionTimestamp:   &metav1.Time{Time: now},
+			unexportGracePeriod: time.Hour,
+			wantRequeue:         true,
+			wantEvents: []string{
+				fmt.Sprintf("Warning ServiceInUse Service %s is still imported by member clusters [member-1]; it will be unexported in 1h0m0s", svcName),
+			},
+		},
+		{
+			name:                "should unexport svc in use once the grace period expires",
+			inUseBy:             []fleetnetv1alpha1.ClusterID{"member-1"},
+			deletionTimestamp:   &metav1.Time{Time: now.Add(-2 * time.Hour)},
+			unexportGracePeriod: time.Hour,
+			wantUnexported:      true,
+			wantEvents: []string{
+				fmt.Sprintf("Warning ServiceInUse Service %s is unexported while still imported by member clusters [member-1]", svcName),
+			},
+		},
+		{
+			name:                "should unexport svc in use right away if the svc export is not deleted",
+			inUseBy:             []fleetnetv1alpha1.ClusterID{"member-1"},
+			unexportGracePeriod: time.Hour,
+			wantUnexported:      true,
+			wantEvents: []string{
+				fmt.Sprintf("Warning ServiceInUse Service %s is unexported while still imported by member clusters [member-1]", svcName),
+			},
+		},
+	}
+
+	ctx := context.Background()
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         memberUserNS,
+					Name:              svcName,
+					Finalizers:        []string{svcExportCleanupFinalizer},
+					DeletionTimestamp: tc.deletionTimestamp,
+				},
+			}
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: hubNSForMember,
+					Name:      internalSvcExportName,
+				},
+				Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+					ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+						ClusterID: memberClusterID,
+					},
+				},
+				Status: fleetnetv1alpha1.InternalServiceExportStatus{
+					InUseBy: tc.inUseBy,
+				},
+			}
+			fakeMemberClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(svcExport).
+				Build()
+			fakeHubClient := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(internalSvcExport).
+				WithStatusSubresource(internalSvcExport).
+				Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := Reconciler{
+				MemberClusterID:     memberClusterID,
+				MemberClient:        fakeMemberClient,
+				HubClient:           fakeHubClient,
+				HubNamespace:        hubNSForMember,
+				Recorder:            recorder,
+				UnexportGracePeriod: tc.unexportGracePeriod,
+			}
+
+			res, err := reconciler.unexportService(ctx, svcExport)
+			if err != nil {
+				t.Fatalf("unexportService() = %v, want no error", err)
+			}
+			if gotRequeue := res.RequeueAfter > 0; gotRequeue != tc.wantRequeue {
+				t.Errorf("unexportService() = %+v, want requeue %t", res, tc.wantRequeue)
+			}
+
+			internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: internalSvcExportName}
+			err = fakeHubClient.Get(ctx, internalSvcExportKey, &fleetnetv1alpha1.InternalServiceExport{})
+			if gotUnexported := apierrors.IsNotFound(err); gotUnexported != tc.wantUnexported {
+				t.Errorf("internalSvcExport Get(%+v) = %v, want unexported %t", internalSvcExportKey, err, tc.wantUnexported)
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if diff := cmp.Diff(tc.wantEvents, events); diff != "" {
+				t.Errorf("events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 // TestCollectAndVerifyLastSeenResourceVersionAndTimestamp tests the
 // *Reconciler.collectAndVerifyLastSeenResourceVersionAndTimestamp method.
 func TestCollectAndVerifyLastSeenResourceVersionAndTimestamp(t *testing.T) {
@@ -1479,3 +3944,193 @@ func (c *fakePublicIPAddressClient) List(_ context.Context, rg string) ([]*armne
 	}
 	return nil, errors.New("invalid resource group")
 }
+
+// TestCleanup tests that the cleanup on leave unexports all the Services of the member cluster only, and can be
+// resumed after being interrupted.
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	otherClusterID := "other-cluster"
+	internalServiceExport := func(namespace, name, clusterID string) *fleetnetv1alpha1.InternalServiceExport {
+		return &fleetnetv1alpha1.InternalServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      name,
+			},
+			Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+				ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+					ClusterID: clusterID,
+					Kind:      "Service",
+					Namespace: memberUserNS,
+					Name:      name,
+				},
+			},
+		}
+	}
+	svcExport := func(name string) *fleetnetv1alpha1.ServiceExport {
+		return &fleetnetv1alpha1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  memberUserNS,
+				Name:       name,
+				Finalizers: []string{svcExportCleanupFinalizer},
+				Annotations: map[string]string{
+					metrics.MetricsAnnotationLastSeenResourceVersion: svcResourceVersion,
+					metrics.MetricsAnnotationLastSeenTimestamp:       time.Now().Format(metrics.MetricsLastSeenTimestampFormat),
+					objectmeta.ServiceExportAnnotationWeight:         "10",
+				},
+			},
+		}
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(svcExport("app-1"), svcExport("app-2")).
+		Build()
+
+	// The first attempt is interrupted when deleting the second InternalServiceExport.
+	hubDeletes := 0
+	fakeHubClient := interceptor.NewClient(
+		fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(
+				internalServiceExport(hubNSForMember, "work-app-1", memberClusterID),
+				internalServiceExport(hubNSForMember, "work-app-2", memberClusterID),
+				// An object in the same namespace but created by another cluster.
+				internalServiceExport(hubNSForMember, "work-app-3", otherClusterID),
+				internalServiceExport("other-cluster-ns", "work-app-1", otherClusterID),
+			).
+			Build(),
+		interceptor.Funcs{
+			Delete: func(ctx context.Context, client client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				hubDeletes++
+				if hubDeletes == 2 {
+					return apierrors.NewServiceUnavailable("hub is unavailable")
+				}
+				return client.Delete(ctx, obj, opts...)
+			},
+		},
+	)
+	reconciler := Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fakeHubClient,
+		HubNamespace:    hubNSForMember,
+	}
+
+	if err := reconciler.Cleanup(ctx); err == nil {
+		t.Fatalf("Cleanup(), got no error, want an error")
+	}
+	// The finalizers are kept until all the InternalServiceExports are deleted.
+	gotSvcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := fakeMemberClient.Get(ctx, types.NamespacedName{Namespace: memberUserNS, Name: "app-1"}, gotSvcExport); err != nil {
+		t.Fatalf("svc export Get(), got %v, want no error", err)
+	}
+	if len(gotSvcExport.Finalizers) == 0 {
+		t.Fatalf("svc export finalizers, got none, want %s", svcExportCleanupFinalizer)
+	}
+
+	// The cleanup is resumed.
+	if err := reconciler.Cleanup(ctx); err != nil {
+		t.Fatalf("Cleanup(), got %v, want no error", err)
+	}
+
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := fakeHubClient.List(ctx, internalSvcExportList); err != nil {
+		t.Fatalf("internal svc export List(), got %v, want no error", err)
+	}
+	gotKeys := []string{}
+	for _, internalSvcExport := range internalSvcExportList.Items {
+		gotKeys = append(gotKeys, internalSvcExport.Namespace+"/"+internalSvcExport.Name)
+	}
+	wantKeys := []string{"bravelion/work-app-3", "other-cluster-ns/work-app-1"}
+	if diff := cmp.Diff(wantKeys, gotKeys, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("internal svc exports (-want, +got):\n%s", diff)
+	}
+
+	svcExportList := &fleetnetv1alpha1.ServiceExportList{}
+	if err := fakeMemberClient.List(ctx, svcExportList); err != nil {
+		t.Fatalf("svc export List(), got %v, want no error", err)
+	}
+	for _, svcExport := range svcExportList.Items {
+		if len(svcExport.Finalizers) != 0 {
+			t.Errorf("svc export %s finalizers, got %v, want none", svcExport.Name, svcExport.Finalizers)
+		}
+		wantAnnotations := map[string]string{objectmeta.ServiceExportAnnotationWeight: "10"}
+		if diff := cmp.Diff(wantAnnotations, svcExport.Annotations); diff != "" {
+			t.Errorf("svc export %s annotations (-want, +got):\n%s", svcExport.Name, diff)
+		}
+	}
+}
+
+// TestReconcile_Concurrent tests that ServiceExports reconciled at the same time, as with more than one worker, are
+// each exported.
+func TestReconcile_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	const count = 20
+	var objs []client.Object
+	keys := make([]types.NamespacedName, 0, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("%s-%d", svcName, i)
+		objs = append(objs,
+			&fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      name,
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberUserNS,
+					Name:      name,
+				},
+				Spec: corev1.ServiceSpec{
+					Type: corev1.ServiceTypeClusterIP,
+					Ports: []corev1.ServicePort{
+						{
+							Protocol:   corev1.ProtocolTCP,
+							Port:       80,
+							TargetPort: intstr.FromInt(8080),
+						},
+					},
+				},
+			})
+		keys = append(keys, types.NamespacedName{Namespace: memberUserNS, Name: name})
+	}
+	fakeMemberClient := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&fleetnetv1alpha1.ServiceExport{}).
+		Build()
+	reconciler := &Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    fakeMemberClient,
+		HubClient:       fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		HubNamespace:    hubNSForMember,
+		Recorder:        &record.FakeRecorder{},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, count)
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Reconcile(%+v), got %v, want no error", keys[i], err)
+		}
+	}
+
+	for _, key := range keys {
+		internalSvcExportKey := types.NamespacedName{Namespace: hubNSForMember, Name: fmt.Sprintf("%s-%s", key.Namespace, key.Name)}
+		internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+		if err := reconciler.HubClient.Get(ctx, internalSvcExportKey, internalSvcExport); err != nil {
+			t.Fatalf("internal svc export Get(%+v), got %v, want no error", internalSvcExportKey, err)
+		}
+		if got := internalSvcExport.Spec.ServiceReference.Name; got != key.Name {
+			t.Errorf("internal svc export %+v exports service %s, want %s", internalSvcExportKey, got, key.Name)
+		}
+	}
+}
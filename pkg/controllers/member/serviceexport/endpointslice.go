@@ -0,0 +1,67 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+// endpointSlicePortsChangedPredicate returns a predicate which only lets through the creation of EndpointSlices and
+// the updates which change their ports; changes in the endpoints alone are handled by the EndpointSlice controller.
+func endpointSlicePortsChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldEndpointSlice, oldOK := e.ObjectOld.(*discoveryv1.EndpointSlice)
+			newEndpointSlice, newOK := e.ObjectNew.(*discoveryv1.EndpointSlice)
+			if !oldOK || !newOK {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(oldEndpointSlice.Ports, newEndpointSlice.Ports)
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// enqueueServiceExportForEndpointSlice returns the ServiceExport of the Service an EndpointSlice belongs to, as
+// found by the service name label of the EndpointSlice.
+//
+// The EndpointSlices of a Service are updated as soon as the ports of the Service change; the reconciliation of the
+// ServiceExport compares the export with the Service and brings an export which is out of date, e.g. because the
+// change of the Service has not been exported yet, up to date. The map func itself only reads the informer cache of
+// the member cluster, so that the churn of EndpointSlices does not turn into requests to the hub cluster.
+func (r *Reconciler) enqueueServiceExportForEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	svcName, ok := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if !ok {
+		return nil
+	}
+	svcExportKey := types.NamespacedName{Namespace: obj.GetNamespace(), Name: svcName}
+	svcExport := &fleetnetv1alpha1.ServiceExport{}
+	if err := r.MemberClient.Get(ctx, svcExportKey, svcExport); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The Service is not exported.
+			return nil
+		}
+		klog.ErrorS(err, "Failed to get the service export of the endpoint slice", "endpointSlice", klog.KObj(obj), "serviceExport", svcExportKey)
+	}
+	return []reconcile.Request{{NamespacedName: svcExportKey}}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestEndpointSlicePortsChangedPredicate tests that only the updates of EndpointSlices which change their ports are
+// let through.
+func TestEndpointSlicePortsChangedPredicate(t *testing.T) {
+	endpointSlice := &discoveryv1.EndpointSlice{
+		Ports: []discoveryv1.EndpointPort{{Port: ptr.To(int32(8080))}},
+	}
+	newEndpoints := endpointSlice.DeepCopy()
+	newEndpoints.Endpoints = []discoveryv1.Endpoint{{Addresses: []string{"1.2.3.4"}}}
+	newPorts := endpointSlice.DeepCopy()
+	newPorts.Ports[0].Port = ptr.To(int32(8081))
+
+	p := endpointSlicePortsChangedPredicate()
+	if p.Update(event.UpdateEvent{ObjectOld: endpointSlice, ObjectNew: newEndpoints}) {
+		t.Errorf("Update() with changed endpoints, got true, want false")
+	}
+	if !p.Update(event.UpdateEvent{ObjectOld: endpointSlice, ObjectNew: newPorts}) {
+		t.Errorf("Update() with changed ports, got false, want true")
+	}
+	if !p.Create(event.CreateEvent{Object: endpointSlice}) {
+		t.Errorf("Create(), got false, want true")
+	}
+	if p.Delete(event.DeleteEvent{Object: endpointSlice}) {
+		t.Errorf("Delete(), got true, want false")
+	}
+}
+
+// TestEnqueueServiceExportForEndpointSlice tests that the ServiceExport of an EndpointSlice is enqueued only when the
+// Service of the EndpointSlice is exported, without reading the hub cluster.
+func TestEnqueueServiceExportForEndpointSlice(t *testing.T) {
+	ctx := context.Background()
+	reconciler := suspendTestReconciler(t)
+	// The map func must not read the hub cluster.
+	reconciler.HubClient = nil
+	endpointSliceWithPort := func(svc string, port int32) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      svc + "-abcde",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: svc},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports: []discoveryv1.EndpointPort{
+				{Name: ptr.To(""), Protocol: ptr.To(corev1.ProtocolTCP), Port: ptr.To(port)},
+			},
+		}
+	}
+	testCases := []struct {
+		name          string
+		endpointSlice *discoveryv1.EndpointSlice
+		want          []reconcile.Request
+	}{
+		{
+			name:          "service exported",
+			endpointSlice: endpointSliceWithPort(svcName, 9090),
+			want:          []reconcile.Request{{NamespacedName: svcOrSvcExportKey}},
+		},
+		{
+			name:          "service not exported",
+			endpointSlice: endpointSliceWithPort("other-app", 9090),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := reconciler.enqueueServiceExportForEndpointSlice(ctx, tc.endpointSlice)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("enqueueServiceExportForEndpointSlice() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
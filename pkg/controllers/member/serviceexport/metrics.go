@@ -0,0 +1,175 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/metrics"
+)
+
+// The results of an attempt to export a Service.
+const (
+	svcExportResultExported = "exported"
+	svcExportResultInvalid  = "invalid"
+	svcExportResultFailed   = "failed"
+)
+
+var (
+	// svcExportsTotal is a Prometheus counter metric which counts the attempts of the controller to export Services,
+	// by their results.
+	svcExportsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "service_exports_total",
+			Help:      "The number of attempts to export a service, by result",
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the Service.
+			"origin_cluster_id",
+			// The result of the attempt: exported, invalid or failed.
+			"result",
+		},
+	)
+
+	// exportedServices is a Prometheus gauge metric which tracks the number of Services currently exported by the
+	// member cluster.
+	exportedServices = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "exported_services",
+			Help:      "The number of services exported by the member cluster",
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the Services.
+			"origin_cluster_id",
+		},
+	)
+
+	// svcExportProgrammingLatency is a Prometheus histogram metric which measures the time it takes from the
+	// creation of a ServiceExport to the first successful write of its InternalServiceExport to the hub cluster.
+	// At most one data point is observed for each ServiceExport.
+	svcExportProgrammingLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "service_export_programming_latency_milliseconds",
+			Help:      "The duration from the creation of a service export to the first successful write to the hub cluster",
+			Buckets:   metrics.ExportDurationMillisecondsBuckets,
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the Service.
+			"origin_cluster_id",
+		},
+	)
+
+	// svcExportsRevalidated is a Prometheus counter metric which counts the ServiceExports that recover from a
+	// missing Service only through the periodic revalidation, i.e. whose Service create event has been missed.
+	svcExportsRevalidated = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.MetricsNamespace,
+			Subsystem: metrics.MetricsSubsystem,
+			Name:      "service_exports_recovered_by_revalidation_total",
+			Help:      "The number of service exports whose service is found by the periodic revalidation rather than its create event",
+		},
+		[]string{
+			// The ID of the origin cluster, which exports the Service.
+			"origin_cluster_id",
+		},
+	)
+
+	// svcExportMetrics tracks the state behind the metrics shared by all the reconcilers of the process.
+	svcExportMetrics = newExportMetricsTracker()
+)
+
+func init() {
+	// Register the metrics with the controller runtime global metrics registry.
+	ctrlmetrics.Registry.MustRegister(svcExportsTotal, exportedServices, svcExportProgrammingLatency, svcExportsRevalidated)
+}
+
+// exportMetricsTracker keeps track of the exported Services and of the ServiceExports whose programming latency
+// has been observed, so that the gauge stays accurate and each ServiceExport is observed only once.
+type exportMetricsTracker struct {
+	// exported tracks the exported Services.
+	exported *metrics.ExportedObjectTracker
+
+	mu sync.Mutex
+	// observed is the set of ServiceExports whose programming latency has been observed.
+	observed map[types.UID]bool
+}
+
+func newExportMetricsTracker() *exportMetricsTracker {
+	return &exportMetricsTracker{
+		exported: metrics.NewExportedObjectTracker(exportedServices),
+		observed: map[types.UID]bool{},
+	}
+}
+
+// recordExported records that the Service of the ServiceExport has been exported to the hub cluster at now; the
+// programming latency is observed if the write created the InternalServiceExport and no data point has been
+// observed for the ServiceExport yet. It returns true if a data point is observed.
+//
+// Only the creation of an InternalServiceExport counts as the first write so that the existing exports are not
+// observed again after the controller restarts.
+func (t *exportMetricsTracker) recordExported(clusterID string, svcExport *fleetnetv1alpha1.ServiceExport, created bool, now time.Time) bool {
+	svcExportsTotal.WithLabelValues(clusterID, svcExportResultExported).Inc()
+	t.exported.Add(clusterID, types.NamespacedName{Namespace: svcExport.Namespace, Name: svcExport.Name})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !created || t.observed[svcExport.UID] || svcExport.CreationTimestamp.IsZero() {
+		return false
+	}
+	t.observed[svcExport.UID] = true
+	timeSpent := now.Sub(svcExport.CreationTimestamp.Time).Milliseconds()
+	// The creation timestamp is assigned by the API server, whose clock may drift from the local one; to avoid
+	// negative outliers, assign a constant of exactly 1 second when the calculated duration does not make sense.
+	if timeSpent <= 0 {
+		timeSpent = time.Second.Milliseconds()
+	}
+	// Cap the data point to avoid large outliers skewing the stats.
+	if timeSpent > int64(metrics.ExportDurationRightBound) {
+		timeSpent = int64(metrics.ExportDurationRightBound)
+	}
+	svcExportProgrammingLatency.WithLabelValues(clusterID).Observe(float64(timeSpent))
+	klog.V(2).InfoS("serviceExportProgrammingLatencyMilliseconds", "value", timeSpent, "originClusterID", clusterID)
+	return true
+}
+
+// recordResult counts an attempt to export a Service which does not result in an export.
+func (t *exportMetricsTracker) recordResult(clusterID, result string) {
+	svcExportsTotal.WithLabelValues(clusterID, result).Inc()
+}
+
+// recordRevalidated counts a ServiceExport whose Service is found by the periodic revalidation.
+func (t *exportMetricsTracker) recordRevalidated(clusterID string) {
+	svcExportsRevalidated.WithLabelValues(clusterID).Inc()
+}
+
+// recordUnexported records that the Service of the ServiceExport is no longer exported; the ServiceExport is
+// forgotten if it is deleted.
+func (t *exportMetricsTracker) recordUnexported(clusterID string, svcExport *fleetnetv1alpha1.ServiceExport) {
+	t.exported.Remove(clusterID, types.NamespacedName{Namespace: svcExport.Namespace, Name: svcExport.Name})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if svcExport.DeletionTimestamp != nil {
+		delete(t.observed, svcExport.UID)
+	}
+}
+
+// recordAllUnexported records that no Service is exported by the member cluster any more.
+func (t *exportMetricsTracker) recordAllUnexported(clusterID string) {
+	t.exported.Reset(clusterID)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+func metricsTestServiceExport(name string, uid types.UID, createdAt time.Time) *fleetnetv1alpha1.ServiceExport {
+	return &fleetnetv1alpha1.ServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "work",
+			Name:              name,
+			UID:               uid,
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+	}
+}
+
+// TestRecordExported_SingleObservation tests that the programming latency is observed only once per ServiceExport.
+func TestRecordExported_SingleObservation(t *testing.T) {
+	clusterID := "metrics-single-observation"
+	tracker := newExportMetricsTracker()
+	now := time.Now()
+	svcExport := metricsTestServiceExport("app", "uid-1", now.Add(-5*time.Second))
+
+	steps := []struct {
+		name        string
+		svcExport   *fleetnetv1alpha1.ServiceExport
+		created     bool
+		wantObserve bool
+	}{
+		{
+			name:      "update of an existing export, e.g. after the controller restarts",
+			svcExport: svcExport,
+		},
+		{
+			name:        "first write creating the export",
+			svcExport:   svcExport,
+			created:     true,
+			wantObserve: true,
+		},
+		{
+			name:      "later update",
+			svcExport: svcExport,
+		},
+		{
+			name:      "export created again after the service is unexported",
+			svcExport: svcExport,
+			created:   true,
+		},
+		{
+			name:        "another service export",
+			svcExport:   metricsTestServiceExport("other-app", "uid-2", now.Add(-time.Second)),
+			created:     true,
+			wantObserve: true,
+		},
+		{
+			name:        "service export re-created with the same name",
+			svcExport:   metricsTestServiceExport("app", "uid-3", now.Add(-time.Second)),
+			created:     true,
+			wantObserve: true,
+		},
+	}
+	for _, step := range steps {
+		if got := tracker.recordExported(clusterID, step.svcExport, step.created, now); got != step.wantObserve {
+			t.Errorf("%s: recordExported() = %t, want %t", step.name, got, step.wantObserve)
+		}
+	}
+
+	if got := testutil.ToFloat64(svcExportsTotal.WithLabelValues(clusterID, svcExportResultExported)); got != float64(len(steps)) {
+		t.Errorf("service exports total = %v, want %d", got, len(steps))
+	}
+	if got := testutil.ToFloat64(exportedServices.WithLabelValues(clusterID)); got != 2 {
+		t.Errorf("exported services = %v, want 2", got)
+	}
+}
+
+func TestRecordUnexported(t *testing.T) {
+	clusterID := "metrics-unexported"
+	tracker := newExportMetricsTracker()
+	now := time.Now()
+	svcExport := metricsTestServiceExport("app", "uid-1", now)
+	other := metricsTestServiceExport("other-app", "uid-2", now)
+	tracker.recordExported(clusterID, svcExport, true, now)
+	tracker.recordExported(clusterID, other, true, now)
+
+	// The Service is unexported as it becomes ineligible; it is not observed again once exported again.
+	tracker.recordUnexported(clusterID, svcExport)
+	if got := testutil.ToFloat64(exportedServices.WithLabelValues(clusterID)); got != 1 {
+		t.Errorf("exported services = %v, want 1", got)
+	}
+	if tracker.recordExported(clusterID, svcExport, true, now) {
+		t.Errorf("recordExported() = true, want false")
+	}
+
+	// The ServiceExport is deleted and forgotten.
+	deleted := svcExport.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: now}
+	tracker.recordUnexported(clusterID, deleted)
+	if _, ok := tracker.observed[svcExport.UID]; ok {
+		t.Errorf("observed service exports contain %s, want it to be forgotten", svcExport.UID)
+	}
+
+	tracker.recordAllUnexported(clusterID)
+	if got := testutil.ToFloat64(exportedServices.WithLabelValues(clusterID)); got != 0 {
+		t.Errorf("exported services = %v, want 0", got)
+	}
+}
+
+func TestRecordResult(t *testing.T) {
+	clusterID := "metrics-result"
+	tracker := newExportMetricsTracker()
+	tracker.recordResult(clusterID, svcExportResultInvalid)
+	tracker.recordResult(clusterID, svcExportResultInvalid)
+	tracker.recordResult(clusterID, svcExportResultFailed)
+	if got := testutil.ToFloat64(svcExportsTotal.WithLabelValues(clusterID, svcExportResultInvalid)); got != 2 {
+		t.Errorf("invalid service exports total = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(svcExportsTotal.WithLabelValues(clusterID, svcExportResultFailed)); got != 1 {
+		t.Errorf("failed service exports total = %v, want 1", got)
+	}
+}
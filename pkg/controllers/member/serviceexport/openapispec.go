@@ -0,0 +1,133 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// openAPISpecConfigMapSuffix is the suffix of the name of the ConfigMap which keeps the OpenAPI spec of a Service
+	// annotated with objectmeta.ServiceAnnotationOpenAPISpec.
+	openAPISpecConfigMapSuffix = "-openapi"
+
+	openAPISpecNotFoundReason = "OpenAPISpecNotFound"
+)
+
+// openAPISpecHashTracker keeps the hashes of the data of the ConfigMaps which keep the OpenAPI specs of the exported
+// Services, so that a ConfigMap is read in full only when it changes.
+//
+// The ConfigMaps are watched and cached by their metadata only, as the specs may be large and most of the ConfigMaps
+// of a member cluster keep no spec at all.
+type openAPISpecHashTracker struct {
+	mu sync.Mutex
+	// hashes is, for each ConfigMap, the hash of its data.
+	hashes map[types.NamespacedName]openAPISpecHash
+}
+
+// openAPISpecHash is the hash of the data of a ConfigMap, computed at a resource version of the ConfigMap.
+type openAPISpecHash struct {
+	resourceVersion string
+	hash            string
+}
+
+// newOpenAPISpecHashTracker returns an empty tracker of OpenAPI spec hashes.
+func newOpenAPISpecHashTracker() *openAPISpecHashTracker {
+	return &openAPISpecHashTracker{
+		hashes: make(map[types.NamespacedName]openAPISpecHash),
+	}
+}
+
+// get returns the hash of the data of the ConfigMap, if it is computed at the given resource version.
+func (t *openAPISpecHashTracker) get(key types.NamespacedName, resourceVersion string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.hashes[key]
+	if !ok || v.resourceVersion != resourceVersion {
+		return "", false
+	}
+	return v.hash, true
+}
+
+// set records the hash of the data of the ConfigMap at the given resource version.
+func (t *openAPISpecHashTracker) set(key types.NamespacedName, resourceVersion, hash string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hashes[key] = openAPISpecHash{resourceVersion: resourceVersion, hash: hash}
+}
+
+// forget removes a ConfigMap which no longer keeps the OpenAPI spec of an exported Service.
+func (t *openAPISpecHashTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.hashes, key)
+}
+
+// lookupOpenAPISpec returns the name of the ConfigMap which keeps the OpenAPI spec of the Service, and the hash of its
+// data, if the Service is annotated to have one.
+//
+// The ConfigMap is looked up in the metadata cache and read in full, with the OpenAPISpecReader, only when it has
+// changed since its hash was last computed.
+func (r *Reconciler) lookupOpenAPISpec(ctx context.Context, svc *corev1.Service) (string, string, error) {
+	key := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name + openAPISpecConfigMapSuffix}
+	if svc.Annotations[objectmeta.ServiceAnnotationOpenAPISpec] != "true" {
+		r.openAPISpecHashTracker().forget(key)
+		return "", "", nil
+	}
+	configMapMeta := &metav1.PartialObjectMetadata{}
+	configMapMeta.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	if err := r.MemberClient.Get(ctx, key, configMapMeta); err != nil {
+		r.openAPISpecHashTracker().forget(key)
+		return "", "", err
+	}
+	if hash, ok := r.openAPISpecHashTracker().get(key, configMapMeta.ResourceVersion); ok {
+		return key.Name, hash, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	if err := r.openAPISpecReader().Get(ctx, key, configMap); err != nil {
+		r.openAPISpecHashTracker().forget(key)
+		return "", "", err
+	}
+	hash, err := hashOpenAPISpec(configMap)
+	if err != nil {
+		return "", "", err
+	}
+	r.openAPISpecHashTracker().set(key, configMap.ResourceVersion, hash)
+	return key.Name, hash, nil
+}
+
+// hashOpenAPISpec returns the SHA256 hash of the data of the ConfigMap which keeps an OpenAPI spec.
+func hashOpenAPISpec(configMap *corev1.ConfigMap) (string, error) {
+	data, err := json.Marshal([]interface{}{configMap.Data, configMap.BinaryData})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal the data of configMap %s: %w", client.ObjectKeyFromObject(configMap), err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// enqueueServiceExportForOpenAPISpec enqueues the ServiceExport of the Service whose OpenAPI spec the ConfigMap may
+// keep, so that the export picks up the ConfigMap once it is created and its hash once it changes.
+func enqueueServiceExportForOpenAPISpec(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := strings.CutSuffix(obj.GetName(), openAPISpecConfigMapSuffix)
+	if !ok || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
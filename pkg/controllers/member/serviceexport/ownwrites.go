@@ -0,0 +1,75 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// ownWriteTracker records the resource versions of ServiceExports right after the controller writes them, so that
+// the update events caused by the controller's own writes do not trigger another reconciliation; the controller
+// has already acted on the state it writes.
+//
+// Only the last write of each ServiceExport is recorded: every write yields a new resource version, so an update
+// event whose new object carries the recorded version can only stem from that write, while any later change made
+// by someone else carries a different version and still triggers a reconciliation.
+type ownWriteTracker struct {
+	mu               sync.Mutex
+	resourceVersions map[types.NamespacedName]string
+}
+
+// newOwnWriteTracker returns an empty tracker of own writes.
+func newOwnWriteTracker() *ownWriteTracker {
+	return &ownWriteTracker{
+		resourceVersions: make(map[types.NamespacedName]string),
+	}
+}
+
+// record records the resource version of an object the controller has just written.
+func (t *ownWriteTracker) record(obj client.Object) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resourceVersions[client.ObjectKeyFromObject(obj)] = obj.GetResourceVersion()
+}
+
+// forget removes the record of an object which no longer exists.
+func (t *ownWriteTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.resourceVersions, key)
+}
+
+// isOwnWrite returns whether the object is at the resource version the controller last wrote.
+func (t *ownWriteTracker) isOwnWrite(obj client.Object) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rv, found := t.resourceVersions[client.ObjectKeyFromObject(obj)]
+	return found && rv == obj.GetResourceVersion()
+}
+
+// predicate returns a predicate which filters out the update events caused by the controller's own writes.
+//
+// Resync events, whose old and new objects share the same resource version, are let through so that periodic
+// reconciliations keep happening.
+func (t *ownWriteTracker) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() {
+				return true
+			}
+			return !t.isOwnWrite(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			t.forget(client.ObjectKeyFromObject(e.Object))
+			return true
+		},
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// revalidationTracker keeps track of the ServiceExports whose Services are not found, so that the controller can
+// tell whether a ServiceExport recovers through the periodic revalidation rather than the Service create event.
+//
+// A ServiceExport created before its Service, e.g. by GitOps tools applying manifests in alphabetical order, is
+// marked invalid and requeued periodically until the Service appears; the Service create event usually brings the
+// ServiceExport back first, but an event missed by the controller leaves the recovery to the periodic requeue.
+type revalidationTracker struct {
+	mu sync.Mutex
+	// notFoundSince is, for each ServiceExport whose Service is not found and for which no Service event has been
+	// observed since, the time the Service was first found missing.
+	notFoundSince map[types.NamespacedName]time.Time
+}
+
+// newRevalidationTracker returns an empty tracker of revalidations.
+func newRevalidationTracker() *revalidationTracker {
+	return &revalidationTracker{
+		notFoundSince: make(map[types.NamespacedName]time.Time),
+	}
+}
+
+// serviceNotFound records that the Service of a ServiceExport is not found at now; the earliest time is kept.
+func (t *revalidationTracker) serviceNotFound(key types.NamespacedName, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.notFoundSince[key]; !ok {
+		t.notFoundSince[key] = now
+	}
+}
+
+// serviceFound records that the Service of a ServiceExport is found; it returns true, with the time the Service
+// was first found missing, if no event of the Service has been observed in the meantime, i.e. the ServiceExport
+// recovers through the periodic revalidation.
+func (t *revalidationTracker) serviceFound(key types.NamespacedName) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since, ok := t.notFoundSince[key]
+	delete(t.notFoundSince, key)
+	return since, ok
+}
+
+// forget removes a ServiceExport which no longer waits for its Service, e.g. as it is deleted.
+func (t *revalidationTracker) forget(key types.NamespacedName) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.notFoundSince, key)
+}
+
+// servicePredicate returns a predicate which observes the create events of Services; all the events are let
+// through.
+func (t *revalidationTracker) servicePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			t.forget(client.ObjectKeyFromObject(e.Object))
+			return true
+		},
+	}
+}
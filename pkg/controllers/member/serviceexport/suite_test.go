@@ -8,6 +8,7 @@ package serviceexport
 import (
 	"context"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
@@ -15,11 +16,14 @@ import (
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -31,12 +35,45 @@ import (
 var (
 	memberTestEnv *envtest.Environment
 	hubTestEnv    *envtest.Environment
-	memberClient  client.Client
-	hubClient     client.Client
+	memberClient  client.WithWatch
+	hubClient     client.WithWatch
 	ctx           context.Context
 	cancel        context.CancelFunc
+
+	// svcExportReconciles counts the reconciliations of each ServiceExport by the running controller.
+	svcExportReconciles = &reconcileCounter{counts: map[types.NamespacedName]int{}}
 )
 
+// reconcileCounter counts reconciliations by ServiceExport; each reconciliation starts with reading the
+// ServiceExport, which the counter intercepts.
+type reconcileCounter struct {
+	mu     sync.Mutex
+	counts map[types.NamespacedName]int
+}
+
+func (c *reconcileCounter) interceptGet(ctx context.Context, client client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if _, ok := obj.(*fleetnetv1alpha1.ServiceExport); ok {
+		c.mu.Lock()
+		c.counts[key]++
+		c.mu.Unlock()
+	}
+	return client.Get(ctx, key, obj, opts...)
+}
+
+// reset resets the count of a ServiceExport.
+func (c *reconcileCounter) reset(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.counts, key)
+}
+
+// count returns the count of a ServiceExport.
+func (c *reconcileCounter) count(key types.NamespacedName) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[key]
+}
+
 // setUpResources help set up resources in the test environment.
 func setUpResources() {
 	// Add the namespaces.
@@ -47,6 +84,13 @@ func setUpResources() {
 	}
 	Expect(memberClient.Create(ctx, &memberNS)).Should(Succeed())
 
+	cleanupNS := corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: cleanupTestNS,
+		},
+	}
+	Expect(memberClient.Create(ctx, &cleanupNS)).Should(Succeed())
+
 	hubNS := corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: hubNSForMember,
@@ -91,10 +135,10 @@ var _ = BeforeSuite(func() {
 	Expect(fleetnetv1alpha1.AddToScheme(scheme.Scheme)).Should(Succeed())
 
 	// Set up clients for member and hub clusters.
-	memberClient, err = client.New(memberCfg, client.Options{Scheme: scheme.Scheme})
+	memberClient, err = client.NewWithWatch(memberCfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(memberClient).NotTo(BeNil())
-	hubClient, err = client.New(hubCfg, client.Options{Scheme: scheme.Scheme})
+	hubClient, err = client.NewWithWatch(hubCfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(hubClient).NotTo(BeNil())
 
@@ -107,6 +151,13 @@ var _ = BeforeSuite(func() {
 		Metrics: metricsserver.Options{
 			BindAddress: "0",
 		},
+		// Only watch the user namespace so that the cleanup tests, which call the reconciler directly with
+		// faulty clients in another namespace, do not race with the running controller.
+		Cache: cache.Options{
+			DefaultNamespaces: map[string]cache.Config{
+				memberUserNS: {},
+			},
+		},
 	})
 	Expect(err).NotTo(HaveOccurred())
 
@@ -129,7 +180,7 @@ var _ = BeforeSuite(func() {
 
 	err = (&Reconciler{
 		MemberClusterID:             memberClusterID,
-		MemberClient:                memberClient,
+		MemberClient:                interceptor.NewClient(memberClient, interceptor.Funcs{Get: svcExportReconciles.interceptGet}),
 		HubClient:                   hubClient,
 		HubNamespace:                hubNSForMember,
 		Recorder:                    ctrlMgr.GetEventRecorderFor(ControllerName),
@@ -6,25 +6,88 @@ Licensed under the MIT license.
 package serviceexport
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
-// formatInternalServiceExportName returns the unique name assigned to an exported Service.
-func formatInternalServiceExportName(svcExport *fleetnetv1alpha1.ServiceExport) string {
+const (
+	// defaultServiceExportWeight is the weight of a ServiceExport when the weight annotation is not set or invalid.
+	defaultServiceExportWeight = int64(1)
+	// maxServiceExportWeight is the largest weight accepted by the Azure Traffic Manager endpoints.
+	maxServiceExportWeight = int64(1000)
+
+	// maxInternalServiceExportNameLength is the maximum length of the name of an InternalServiceExport, which is
+	// the same as the one of the exported Service itself.
+	maxInternalServiceExportNameLength = validation.DNS1123LabelMaxLength
+	// internalServiceExportNameHashLength is the length of the hash suffix of a shortened InternalServiceExport name.
+	internalServiceExportNameHashLength = 10
+)
+
+// formatLegacyInternalServiceExportName returns the name assigned to an exported Service before names were
+// shortened, in the format NAMESPACE-NAME; InternalServiceExports created with such names are still in use.
+//
+// A versioned Service is exported as NAMESPACE-NAME.VERSION, so that multiple versions of a service can be exported
+// side by side. A dot can appear in neither a namespace, the name of a Service nor a version, so the name of a
+// version never collides with the name of another Service, e.g. version v2 of Service app is not exported with the
+// name of Service app-v2.
+func formatLegacyInternalServiceExportName(svcExport *fleetnetv1alpha1.ServiceExport) string {
+	if svcExport.Spec.Version != "" {
+		return fmt.Sprintf("%s-%s.%s", svcExport.Namespace, svcExport.Name, svcExport.Spec.Version)
+	}
 	return fmt.Sprintf("%s-%s", svcExport.Namespace, svcExport.Name)
 }
 
-// isServiceEligibleForExport returns if a Service is eligible for export; at this stage, headless Services
-// and Services of the ExternalName type cannot be exported.
+// formatInternalServiceExportName returns the unique name assigned to an exported Service.
+//
+// The name is in the format NAMESPACE-NAME if it fits in maxInternalServiceExportNameLength characters; otherwise,
+// it is truncated and suffixed with a hash of the namespace and name of the Service, e.g. a Service with a
+// 63 character long namespace and name is exported as NAMESPACE-NA...-1a2b3c4d5e.
+func formatInternalServiceExportName(svcExport *fleetnetv1alpha1.ServiceExport) (string, error) {
+	name := formatLegacyInternalServiceExportName(svcExport)
+	if len(name) > maxInternalServiceExportNameLength {
+		// A slash can appear in neither a namespace, a name nor a version, so different Services always have
+		// different hashes.
+		key := svcExport.Namespace + "/" + svcExport.Name
+		if svcExport.Spec.Version != "" {
+			key += "/" + svcExport.Spec.Version
+		}
+		hash := fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+		prefix := strings.TrimRight(name[:maxInternalServiceExportNameLength-internalServiceExportNameHashLength-1], "-.")
+		name = fmt.Sprintf("%s-%s", prefix, hash[:internalServiceExportNameHashLength])
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+		return "", fmt.Errorf("internalServiceExport name %q is invalid: %s", name, strings.Join(errs, "; "))
+	}
+	return name, nil
+}
+
+// isServiceEligibleForExport returns if a Service is eligible for export; at this stage, Services of the
+// ExternalName type cannot be exported.
+//
+// Headless Services are eligible for export; they have no cluster IP, but their endpoints, exported via
+// EndpointSlices, are still used for DNS-based discovery.
 func isServiceEligibleForExport(svc *corev1.Service) bool {
-	if svc.Spec.Type == corev1.ServiceTypeExternalName || svc.Spec.ClusterIP == "None" {
-		return false
+	return svc.Spec.Type != corev1.ServiceTypeExternalName
+}
+
+// serviceImportTypeOf returns the type of the ServiceImport a Service is imported as; Services without a cluster IP
+// are imported as Headless.
+func serviceImportTypeOf(svc *corev1.Service) fleetnetv1alpha1.ServiceImportType {
+	if svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		return fleetnetv1alpha1.Headless
 	}
-	return true
+	return fleetnetv1alpha1.ClusterSetIP
 }
 
 // extractServicePorts extracts ports in use from Service.
@@ -42,3 +105,84 @@ func extractServicePorts(svc *corev1.Service) []fleetnetv1alpha1.ServicePort {
 
 	return svcExportPorts
 }
+
+// isExportedServiceSpecChanged returns if the exported Service spec in an InternalServiceExport has changed; changes
+// in the ServiceReference alone, e.g. a new resource version of the Service, are not considered.
+func isExportedServiceSpecChanged(previous, current *fleetnetv1alpha1.InternalServiceExportSpec) bool {
+	previousSpec := previous.DeepCopy()
+	currentSpec := current.DeepCopy()
+	previousSpec.ServiceReference = fleetnetv1alpha1.ExportedObjectReference{}
+	currentSpec.ServiceReference = fleetnetv1alpha1.ExportedObjectReference{}
+	return !equality.Semantic.DeepEqual(previousSpec, currentSpec)
+}
+
+// hashInternalServiceExportSpec returns the SHA256 hash of an InternalServiceExport spec.
+func hashInternalServiceExportSpec(spec *fleetnetv1alpha1.InternalServiceExportSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal internalServiceExport spec: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// extractWeightFromServiceExport returns the weight specified in the weight annotation of the ServiceExport.
+// It returns the default weight together with an error if the annotation value is not an integer in the range
+// [0, 1000].
+func extractWeightFromServiceExport(svcExport *fleetnetv1alpha1.ServiceExport) (int64, error) {
+	val, found := svcExport.Annotations[objectmeta.ServiceExportAnnotationWeight]
+	if !found {
+		return defaultServiceExportWeight, nil
+	}
+	weight, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return defaultServiceExportWeight, fmt.Errorf("the weight annotation %q is not a valid integer: %w", val, err)
+	}
+	if weight < 0 || weight > maxServiceExportWeight {
+		return defaultServiceExportWeight, fmt.Errorf("the weight annotation %q is out of the range [0, %d]", val, maxServiceExportWeight)
+	}
+	return weight, nil
+}
+
+// extractExportTTLFromServiceExport returns the export TTL of a ServiceExport; it returns 0 if no TTL is set.
+func extractExportTTLFromServiceExport(svcExport *fleetnetv1alpha1.ServiceExport) (time.Duration, error) {
+	val, found := svcExport.Annotations[objectmeta.ServiceExportAnnotationExportTTL]
+	if !found {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("the export TTL annotation %q is not a valid duration: %w", val, err)
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("the export TTL annotation %q is not positive", val)
+	}
+	return ttl, nil
+}
+
+// isServiceExportSuspended returns whether the export of a ServiceExport is suspended; it returns false together
+// with an error if the suspend annotation is not a valid boolean.
+func isServiceExportSuspended(svcExport *fleetnetv1alpha1.ServiceExport) (bool, error) {
+	val, found := svcExport.Annotations[objectmeta.ServiceExportAnnotationSuspend]
+	if !found {
+		return false, nil
+	}
+	suspended, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("the suspend annotation %q is not a valid boolean: %w", val, err)
+	}
+	return suspended, nil
+}
+
+// extractExportedAtFromServiceExport returns when a Service with an export TTL was first exported; it returns
+// false if the time has not been recorded or is not valid.
+func extractExportedAtFromServiceExport(svcExport *fleetnetv1alpha1.ServiceExport) (time.Time, bool) {
+	val, found := svcExport.Annotations[objectmeta.ServiceExportAnnotationExportedAt]
+	if !found {
+		return time.Time{}, false
+	}
+	exportedAt, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return exportedAt, true
+}
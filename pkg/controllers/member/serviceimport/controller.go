@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 const (
@@ -115,6 +116,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		// TO-DO: InternalServiceImport object is not an exported object and the ServiceImportReference (an
 		// exportedObject field) will be removed; information updated here is not used.
 		internalServiceImport.Spec.ServiceImportReference.UpdateFromMetaObject(serviceImport.ObjectMeta, serviceImport.CreationTimestamp)
+		internalServiceImport.Spec.IncludeLocalEndpoints = serviceImport.Annotations[objectmeta.ServiceImportAnnotationIncludeLocalEndpoints] == "true"
 		return nil
 	}); err != nil {
 		klog.ErrorS(err, "Failed to create or update InternalServiceImport from ServiceImport", "InternalServiceImport", internalServiceImportRef, "ServiceImport", serviceImportRef, "op", op)
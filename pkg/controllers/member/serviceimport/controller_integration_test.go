@@ -18,6 +18,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
 var (
@@ -113,7 +114,20 @@ var _ = Describe("Create or update a service import", func() {
 				return cmp.Equal(expectedServiceImportRef, updatedInternalServiceImport.Spec.ServiceImportReference)
 			}, duration, interval).Should(BeTrue())
 
-			// 3. Check internal service import is deleted after service import is deleted.
+			// 3. Check internal service import includes the local endpoints after service import asks for them.
+			By("By annotating service import to include the local endpoints")
+			Expect(memberClient.Get(ctx, serviceImportLookupKey, serviceImport)).Should(Succeed())
+			metav1.SetMetaDataAnnotation(&serviceImport.ObjectMeta, objectmeta.ServiceImportAnnotationIncludeLocalEndpoints, "true")
+			Expect(memberClient.Update(ctx, serviceImport)).Should(Succeed())
+			By("By checking internal service import includes the local endpoints")
+			Eventually(func() bool {
+				if err := hubClient.Get(ctx, internalServiceImportLookupKey, updatedInternalServiceImport); err != nil {
+					return false
+				}
+				return updatedInternalServiceImport.Spec.IncludeLocalEndpoints
+			}, duration, interval).Should(BeTrue())
+
+			// 4. Check internal service import is deleted after service import is deleted.
 			By("By deleting the service import")
 			Expect(memberClient.Delete(ctx, serviceImport)).Should(Succeed())
 			By("By checking the existence of  service import")
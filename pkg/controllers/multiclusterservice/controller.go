@@ -30,6 +30,7 @@ import (
 
 	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	"go.goms.io/fleet-networking/pkg/common/condition"
+	fleetlabels "go.goms.io/fleet-networking/pkg/common/labels"
 	"go.goms.io/fleet-networking/pkg/common/objectmeta"
 )
 
@@ -44,6 +45,12 @@ const (
 
 	conditionReasonUnknownServiceImport = "UnknownServiceImport"
 	conditionReasonFoundServiceImport   = "FoundServiceImport"
+	// conditionReasonHeadlessServiceImport is the reason the mcs is invalid when the service import is headless, which
+	// a load balancer cannot be attached to.
+	conditionReasonHeadlessServiceImport = "HeadlessServiceImport"
+
+	conditionReasonMinReadyClustersMet    = "MinReadyClustersMet"
+	conditionReasonMinReadyClustersNotMet = "MinReadyClustersNotMet"
 
 	mcsRetryInterval = time.Second * 5
 
@@ -243,6 +250,13 @@ func (r *Reconciler) handleUpdate(ctx context.Context, mcs *fleetnetv1alpha1.Mul
 		// it will do nothing.
 		return ctrl.Result{}, r.handleInvalidServiceImport(ctx, mcs, serviceImport)
 	}
+	if serviceImport.Status.Type == fleetnetv1alpha1.Headless {
+		// A headless service has no cluster IP to load balance to; the derived service is not created, or deleted if
+		// the service has been exported with a cluster IP before.
+		klog.V(2).InfoS("Refusing to attach a load balancer to the headless service import", "multiClusterService", mcsKObj, "serviceImport", klog.KObj(serviceImport))
+		r.Recorder.Eventf(mcs, corev1.EventTypeWarning, "HeadlessServiceImport", "Service %s is headless and cannot be exposed by a load balancer", serviceImport.Name)
+		return ctrl.Result{}, r.handleInvalidServiceImport(ctx, mcs, serviceImport)
+	}
 	r.Recorder.Eventf(mcs, corev1.EventTypeNormal, "FoundValidService", "Found valid service %s and importing", serviceImport.Name)
 
 	serviceName := r.derivedServiceFromLabel(mcs)
@@ -291,6 +305,13 @@ func isServiceImportOwnedByOthers(mcs *fleetnetv1alpha1.MultiClusterService, ser
 }
 
 func (r *Reconciler) ensureServiceImport(serviceImport *fleetnetv1alpha1.ServiceImport, mcs *fleetnetv1alpha1.MultiClusterService) error {
+	// The ServiceImport tells the import request made to the hub cluster whether the endpoints exported by this member
+	// cluster are imported as well.
+	if mcs.Spec.IncludeLocalEndpoints {
+		metav1.SetMetaDataAnnotation(&serviceImport.ObjectMeta, objectmeta.ServiceImportAnnotationIncludeLocalEndpoints, "true")
+	} else {
+		delete(serviceImport.Annotations, objectmeta.ServiceImportAnnotationIncludeLocalEndpoints)
+	}
 	return controllerutil.SetControllerReference(mcs, serviceImport, r.Scheme)
 }
 
@@ -314,7 +335,7 @@ func (r *Reconciler) handleInvalidServiceImport(ctx context.Context, mcs *fleetn
 		return err
 	}
 	// update mcs label
-	delete(mcs.GetLabels(), objectmeta.MultiClusterServiceLabelDerivedService)
+	fleetlabels.RemoveFleetLabel(mcs, objectmeta.MultiClusterServiceLabelDerivedService)
 	if err := r.Client.Update(ctx, mcs); err != nil {
 		klog.ErrorS(err, "Failed to update the derived service label of mcs", "multiClusterService", mcsKObj)
 		return err
@@ -330,10 +351,7 @@ func (r *Reconciler) updateMultiClusterLabel(ctx context.Context, mcs *fleetnetv
 		klog.V(4).InfoS("No need to update the mcs label", "multiClusterService", mcsKObj)
 		return nil
 	}
-	if labels == nil { // in case labels map is nil and causes the panic
-		mcs.Labels = map[string]string{}
-	}
-	mcs.Labels[key] = value
+	fleetlabels.SetFleetLabel(mcs, key, value)
 	if err := r.Client.Update(ctx, mcs); err != nil {
 		klog.ErrorS(err, "Failed to add label to mcs", "multiClusterService", mcsKObj, "key", key, "value", value)
 		return err
@@ -388,7 +406,8 @@ func (r *Reconciler) updateMultiClusterServiceStatus(ctx context.Context, mcs *f
 		ObservedGeneration: mcs.GetGeneration(),
 		Message:            "found valid service import",
 	}
-	if len(serviceImport.Status.Clusters) == 0 {
+	switch {
+	case len(serviceImport.Status.Clusters) == 0:
 		desiredCond = &metav1.Condition{
 			Type:               string(fleetnetv1alpha1.MultiClusterServiceValid),
 			Status:             metav1.ConditionUnknown,
@@ -396,16 +415,35 @@ func (r *Reconciler) updateMultiClusterServiceStatus(ctx context.Context, mcs *f
 			ObservedGeneration: mcs.GetGeneration(),
 			Message:            "importing service; if the condition remains for a while, please verify that service has been exported or service has been exported by other multiClusterService",
 		}
+	case serviceImport.Status.Type == fleetnetv1alpha1.Headless:
+		desiredCond = &metav1.Condition{
+			Type:               string(fleetnetv1alpha1.MultiClusterServiceValid),
+			Status:             metav1.ConditionFalse,
+			Reason:             conditionReasonHeadlessServiceImport,
+			ObservedGeneration: mcs.GetGeneration(),
+			Message:            "service import is headless; a load balancer cannot be attached to a headless service",
+		}
 	}
 
+	currentReadyCond := meta.FindStatusCondition(mcs.Status.Conditions, string(fleetnetv1alpha1.MultiClusterServiceReady))
+	desiredReadyCond := readyCondition(mcs, serviceImport)
+
 	mcsKObj := klog.KObj(mcs)
 	if equality.Semantic.DeepEqual(mcs.Status.LoadBalancer, service.Status.LoadBalancer) &&
-		condition.EqualCondition(currentCond, desiredCond) {
+		condition.EqualCondition(currentCond, desiredCond) &&
+		condition.EqualCondition(currentReadyCond, desiredReadyCond) &&
+		// The message tells how many member clusters export the service.
+		(desiredReadyCond == nil || currentReadyCond.Message == desiredReadyCond.Message) {
 		klog.V(4).InfoS("Status is in the desired state and skipping updating status", "multiClusterService", mcsKObj)
 		return nil
 	}
 	mcs.Status.LoadBalancer = service.Status.LoadBalancer
 	meta.SetStatusCondition(&mcs.Status.Conditions, *desiredCond)
+	if desiredReadyCond != nil {
+		meta.SetStatusCondition(&mcs.Status.Conditions, *desiredReadyCond)
+	} else {
+		meta.RemoveStatusCondition(&mcs.Status.Conditions, string(fleetnetv1alpha1.MultiClusterServiceReady))
+	}
 
 	klog.V(2).InfoS("Updating mcs status", "multiClusterService", mcsKObj)
 	if err := r.Status().Update(ctx, mcs); err != nil {
@@ -415,6 +453,34 @@ func (r *Reconciler) updateMultiClusterServiceStatus(ctx context.Context, mcs *f
 	return nil
 }
 
+// readyCondition returns the Ready condition of the mcs as per its readiness policy, or nil if it has none.
+//
+// The clusters of the service import are the member clusters whose exports of the Service are valid and do not
+// conflict, as resolved by the hub cluster across the namespaces of the member clusters.
+func readyCondition(mcs *fleetnetv1alpha1.MultiClusterService, serviceImport *fleetnetv1alpha1.ServiceImport) *metav1.Condition {
+	policy := mcs.Spec.ReadinessPolicy
+	if policy == nil {
+		return nil
+	}
+	readyClusters := len(serviceImport.Status.Clusters)
+	if readyClusters < int(policy.MinReadyClusters) {
+		return &metav1.Condition{
+			Type:               string(fleetnetv1alpha1.MultiClusterServiceReady),
+			Status:             metav1.ConditionFalse,
+			Reason:             conditionReasonMinReadyClustersNotMet,
+			ObservedGeneration: mcs.GetGeneration(),
+			Message:            fmt.Sprintf("%d of the required %d member clusters export the service without conflicts", readyClusters, policy.MinReadyClusters),
+		}
+	}
+	return &metav1.Condition{
+		Type:               string(fleetnetv1alpha1.MultiClusterServiceReady),
+		Status:             metav1.ConditionTrue,
+		Reason:             conditionReasonMinReadyClustersMet,
+		ObservedGeneration: mcs.GetGeneration(),
+		Message:            fmt.Sprintf("%d member clusters export the service without conflicts, at least %d required", readyClusters, policy.MinReadyClusters),
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -8,12 +8,14 @@ package multiclusterservice
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -1055,6 +1057,200 @@ func TestHandleUpdate(t *testing.T) {
 	}
 }
 
+func TestHandleUpdate_ServiceImportType(t *testing.T) {
+	importServicePorts := []fleetnetv1alpha1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}}
+	tests := []struct {
+		name              string
+		importType        fleetnetv1alpha1.ServiceImportType
+		wantDerived       bool
+		wantValidStatus   metav1.ConditionStatus
+		wantValidReason   string
+		wantWarningEvents []string
+	}{
+		{
+			name:            "clusterSetIP service import",
+			importType:      fleetnetv1alpha1.ClusterSetIP,
+			wantDerived:     true,
+			wantValidStatus: metav1.ConditionTrue,
+			wantValidReason: conditionReasonFoundServiceImport,
+		},
+		{
+			name:            "headless service import",
+			importType:      fleetnetv1alpha1.Headless,
+			wantValidStatus: metav1.ConditionFalse,
+			wantValidReason: conditionReasonHeadlessServiceImport,
+			wantWarningEvents: []string{
+				fmt.Sprintf("Warning HeadlessServiceImport Service %s is headless and cannot be exposed by a load balancer", testServiceName),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mcsObj := multiClusterServiceForTest()
+			// The service import has been exported with a cluster IP before, and the derived service created.
+			mcsObj.Labels = map[string]string{
+				multiClusterServiceLabelServiceImport:             testServiceName,
+				objectmeta.MultiClusterServiceLabelDerivedService: derivedServiceName,
+			}
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testServiceName},
+				Status: fleetnetv1alpha1.ServiceImportStatus{
+					Type:     tc.importType,
+					Ports:    importServicePorts,
+					Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: "member1"}},
+				},
+			}
+			derivedService := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: systemNamespace, Name: derivedServiceName},
+				Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			}
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(multiClusterServiceScheme(t)).
+				WithObjects(mcsObj, serviceImport, derivedService).
+				WithStatusSubresource(mcsObj, serviceImport).
+				Build()
+
+			r := multiClusterServiceReconciler(fakeClient)
+			if _, err := r.handleUpdate(ctx, mcsObj); err != nil {
+				t.Fatalf("handleUpdate() got error %v, want no error", err)
+			}
+
+			err := fakeClient.Get(ctx, types.NamespacedName{Namespace: systemNamespace, Name: derivedServiceName}, &corev1.Service{})
+			switch {
+			case tc.wantDerived && err != nil:
+				t.Errorf("derived Service Get() got error %v, want no error", err)
+			case !tc.wantDerived && !errors.IsNotFound(err):
+				t.Errorf("derived Service Get() got error %v, want not found error", err)
+			}
+
+			mcs := fleetnetv1alpha1.MultiClusterService{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: testName}, &mcs); err != nil {
+				t.Fatalf("MultiClusterService Get() got error %v, want no error", err)
+			}
+			cond := meta.FindStatusCondition(mcs.Status.Conditions, string(fleetnetv1alpha1.MultiClusterServiceValid))
+			if cond == nil || cond.Status != tc.wantValidStatus || cond.Reason != tc.wantValidReason {
+				t.Errorf("MultiClusterService Valid condition = %+v, want status %s and reason %s", cond, tc.wantValidStatus, tc.wantValidReason)
+			}
+			if _, ok := mcs.Labels[objectmeta.MultiClusterServiceLabelDerivedService]; ok != tc.wantDerived {
+				t.Errorf("MultiClusterService has derived service label = %t, want %t", ok, tc.wantDerived)
+			}
+
+			recorder := r.Recorder.(*record.FakeRecorder)
+			close(recorder.Events)
+			var warnings []string
+			for event := range recorder.Events {
+				if strings.HasPrefix(event, corev1.EventTypeWarning) {
+					warnings = append(warnings, event)
+				}
+			}
+			if diff := cmp.Diff(tc.wantWarningEvents, warnings); diff != "" {
+				t.Errorf("warning events mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateMultiClusterServiceStatus_ReadinessPolicy(t *testing.T) {
+	readyCondition := metav1.Condition{
+		Type:    string(fleetnetv1alpha1.MultiClusterServiceReady),
+		Status:  metav1.ConditionTrue,
+		Reason:  conditionReasonMinReadyClustersMet,
+		Message: "2 member clusters export the service without conflicts, at least 2 required",
+	}
+	notReadyCondition := metav1.Condition{
+		Type:    string(fleetnetv1alpha1.MultiClusterServiceReady),
+		Status:  metav1.ConditionFalse,
+		Reason:  conditionReasonMinReadyClustersNotMet,
+		Message: "1 of the required 2 member clusters export the service without conflicts",
+	}
+	clusters := func(names ...string) []fleetnetv1alpha1.ClusterStatus {
+		var statuses []fleetnetv1alpha1.ClusterStatus
+		for _, name := range names {
+			statuses = append(statuses, fleetnetv1alpha1.ClusterStatus{Cluster: name})
+		}
+		return statuses
+	}
+
+	tests := []struct {
+		name           string
+		policy         *fleetnetv1alpha1.ReadinessPolicy
+		conditions     []metav1.Condition
+		clusters       []fleetnetv1alpha1.ClusterStatus
+		wantConditions []metav1.Condition
+	}{
+		{
+			name:     "no readiness policy",
+			clusters: clusters("member-1"),
+		},
+		{
+			name:           "not enough clusters",
+			policy:         &fleetnetv1alpha1.ReadinessPolicy{MinReadyClusters: 2},
+			clusters:       clusters("member-1"),
+			wantConditions: []metav1.Condition{notReadyCondition},
+		},
+		{
+			name:           "enough clusters",
+			policy:         &fleetnetv1alpha1.ReadinessPolicy{MinReadyClusters: 2},
+			conditions:     []metav1.Condition{notReadyCondition},
+			clusters:       clusters("member-1", "member-2"),
+			wantConditions: []metav1.Condition{readyCondition},
+		},
+		{
+			name:       "no clusters export the service anymore",
+			policy:     &fleetnetv1alpha1.ReadinessPolicy{MinReadyClusters: 2},
+			conditions: []metav1.Condition{readyCondition},
+			wantConditions: []metav1.Condition{{
+				Type:    string(fleetnetv1alpha1.MultiClusterServiceReady),
+				Status:  metav1.ConditionFalse,
+				Reason:  conditionReasonMinReadyClustersNotMet,
+				Message: "0 of the required 2 member clusters export the service without conflicts",
+			}},
+		},
+		{
+			name:       "readiness policy removed",
+			conditions: []metav1.Condition{readyCondition},
+			clusters:   clusters("member-1", "member-2"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			mcsObj := multiClusterServiceForTest()
+			mcsObj.Spec.ReadinessPolicy = tc.policy
+			mcsObj.Status.Conditions = tc.conditions
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(multiClusterServiceScheme(t)).
+				WithObjects(mcsObj).
+				WithStatusSubresource(mcsObj).
+				Build()
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: testServiceName},
+				Status:     fleetnetv1alpha1.ServiceImportStatus{Clusters: tc.clusters},
+			}
+
+			r := multiClusterServiceReconciler(fakeClient)
+			if err := r.updateMultiClusterServiceStatus(ctx, mcsObj, serviceImport, &corev1.Service{}); err != nil {
+				t.Fatalf("updateMultiClusterServiceStatus() got error %v, want no error", err)
+			}
+
+			mcs := fleetnetv1alpha1.MultiClusterService{}
+			if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: testNamespace, Name: testName}, &mcs); err != nil {
+				t.Fatalf("MultiClusterService Get() got error %v, want no error", err)
+			}
+			var gotConditions []metav1.Condition
+			for _, cond := range mcs.Status.Conditions {
+				if cond.Type == string(fleetnetv1alpha1.MultiClusterServiceReady) {
+					gotConditions = append(gotConditions, cond)
+				}
+			}
+			if diff := cmp.Diff(tc.wantConditions, gotConditions, cmpopts.IgnoreFields(metav1.Condition{}, "LastTransitionTime")); diff != "" {
+				t.Errorf("MultiClusterService Ready condition mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestConfigureInternalLoadBalancer(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1111,3 +1307,50 @@ func TestConfigureInternalLoadBalancer(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureServiceImport_IncludeLocalEndpoints(t *testing.T) {
+	tests := []struct {
+		name                  string
+		includeLocalEndpoints bool
+		annotations           map[string]string
+		want                  map[string]string
+	}{
+		{
+			name: "local endpoints are excluded by default",
+		},
+		{
+			name:                  "local endpoints are included",
+			includeLocalEndpoints: true,
+			want: map[string]string{
+				objectmeta.ServiceImportAnnotationIncludeLocalEndpoints: "true",
+			},
+		},
+		{
+			name: "local endpoints are no longer included",
+			annotations: map[string]string{
+				objectmeta.ServiceImportAnnotationIncludeLocalEndpoints: "true",
+			},
+			want: map[string]string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mcs := multiClusterServiceForTest()
+			mcs.Spec.IncludeLocalEndpoints = tc.includeLocalEndpoints
+			serviceImport := &fleetnetv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   testNamespace,
+					Name:        testServiceName,
+					Annotations: tc.annotations,
+				},
+			}
+			r := &Reconciler{Scheme: multiClusterServiceScheme(t)}
+			if err := r.ensureServiceImport(serviceImport, mcs); err != nil {
+				t.Fatalf("ensureServiceImport() = %v, want no error", err)
+			}
+			if got := serviceImport.GetAnnotations(); !cmp.Equal(got, tc.want) {
+				t.Errorf("ensureServiceImport() got serviceImport annotations %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package names features utility functions that derive the names of the objects which fleet networking controllers
+// create on behalf of user objects, e.g. the names of the EndpointSliceExports created in the hub cluster.
+package names
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/klog/v2"
+
+	"go.goms.io/fleet-networking/pkg/common/uniquename"
+)
+
+const (
+	// fallbackFleetUniqueNameLength is the length of the name used when a unique name cannot be formatted.
+	fallbackFleetUniqueNameLength = 25
+	// fleetUniqueNameSuffixLength is the length of the hash suffix of a unique name.
+	fleetUniqueNameSuffixLength = 5
+)
+
+// FormatFleetUniqueName returns a fleet-wide unique name for exporting an EndpointSlice from a member cluster; the
+// name is used as the name of the EndpointSliceExport in the hub cluster and is always a valid RFC 1123 DNS subdomain.
+//
+// The name ends with a suffix derived from a hash of the member cluster ID and the namespace, name and UID of the
+// EndpointSlice, so the same EndpointSlice always gets the same name, e.g. when the assignment of the name is retried,
+// while an EndpointSlice re-created with the same name gets another one. The name assigned to an exported
+// EndpointSlice is recorded in its objectmeta.ExportedObjectAnnotationUniqueName annotation, which is where tools
+// should look up the hub-side name of an existing export.
+func FormatFleetUniqueName(memberClusterID string, endpointSlice *discoveryv1.EndpointSlice) string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{memberClusterID, endpointSlice.Namespace, endpointSlice.Name, string(endpointSlice.UID)}, "/")))
+	fleetUniqueName, err := uniquename.FleetScopedUniqueNameWithSuffix(uniquename.DNS1123Subdomain,
+		memberClusterID,
+		endpointSlice.Namespace,
+		endpointSlice.Name,
+		hex.EncodeToString(hash[:])[:fleetUniqueNameSuffixLength])
+	if err != nil {
+		// Fall back to use a lower case alphabetic string derived from the hash as the unique name. Normally this
+		// branch should never run.
+		klog.ErrorS(err, "Failed to generate a unique name; fall back to lower case alphabetic strings",
+			"endpointSlice", klog.KObj(endpointSlice))
+		return lowerCaseAlphabeticString(hash[:fallbackFleetUniqueNameLength])
+	}
+	return fleetUniqueName
+}
+
+// lowerCaseAlphabeticString maps each byte to a lower case alphabetic character; such a string of proper length is
+// always a valid Kubernetes object name.
+func lowerCaseAlphabeticString(b []byte) string {
+	res := make([]byte, len(b))
+	for i := range b {
+		res[i] = 'a' + b[i]%26
+	}
+	return string(res)
+}
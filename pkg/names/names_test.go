@@ -0,0 +1,97 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package names
+
+import (
+	"strings"
+	"testing"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func endpointSlice(namespace, name string) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+}
+
+// TestFormatFleetUniqueName tests the FormatFleetUniqueName function.
+func TestFormatFleetUniqueName(t *testing.T) {
+	tests := []struct {
+		name            string
+		memberClusterID string
+		endpointSlice   *discoveryv1.EndpointSlice
+		wantPrefix      string
+	}{
+		{
+			name:            "short names",
+			memberClusterID: "bravelion",
+			endpointSlice:   endpointSlice("work", "app-x2yz"),
+			wantPrefix:      "bravelion-work-app-x2yz-",
+		},
+		{
+			name:            "dotted names",
+			memberClusterID: "cluster.bravelion",
+			endpointSlice:   endpointSlice("work", "app.v1-x2yz"),
+			wantPrefix:      "cluster.bravelion-work-app.v1-x2yz-",
+		},
+		{
+			name:            "invalid names fall back to a hashed name",
+			memberClusterID: "Bravelion",
+			endpointSlice:   endpointSlice("work", "app"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FormatFleetUniqueName(tc.memberClusterID, tc.endpointSlice)
+			if errs := validation.IsDNS1123Subdomain(got); len(errs) != 0 {
+				t.Fatalf("FormatFleetUniqueName() = %q, not a valid RFC 1123 DNS subdomain: %v", got, errs)
+			}
+			if !strings.HasPrefix(got, tc.wantPrefix) {
+				t.Errorf("FormatFleetUniqueName() = %q, want prefix %q", got, tc.wantPrefix)
+			}
+		})
+	}
+
+	// The same EndpointSlice always gets the same name, while an EndpointSlice re-created with the same name gets
+	// another one.
+	first := FormatFleetUniqueName("bravelion", endpointSlice("work", "app"))
+	if second := FormatFleetUniqueName("bravelion", endpointSlice("work", "app")); first != second {
+		t.Errorf("FormatFleetUniqueName() returned %q and %q for the same endpoint slice, want the same name", first, second)
+	}
+	recreated := endpointSlice("work", "app")
+	recreated.UID = "recreated-uid"
+	if got := FormatFleetUniqueName("bravelion", recreated); got == first {
+		t.Errorf("FormatFleetUniqueName() returned %q for a re-created endpoint slice, want another name", got)
+	}
+	invalid := FormatFleetUniqueName("Bravelion", endpointSlice("work", "app"))
+	if got := FormatFleetUniqueName("Bravelion", endpointSlice("work", "app")); got != invalid {
+		t.Errorf("FormatFleetUniqueName() fell back to %q and %q for the same endpoint slice, want the same name", invalid, got)
+	}
+}
+
+// FuzzFormatFleetUniqueName verifies that FormatFleetUniqueName always returns a valid object name, whatever its
+// input is. EndpointSliceExport names are RFC 1123 DNS subdomains, the format the controller has always used, so
+// the name is checked against that rather than the stricter DNS label format.
+func FuzzFormatFleetUniqueName(f *testing.F) {
+	f.Add("bravelion", "work", "app-x2yz")
+	f.Add("cluster.bravelion", "work", "app.v1-x2yz")
+	f.Add(strings.Repeat("c", 253), strings.Repeat("n", 63), strings.Repeat("e", 253))
+	f.Add("", "", "")
+	f.Add("Bravelion", "-work", "app_")
+	f.Fuzz(func(t *testing.T, memberClusterID, namespace, name string) {
+		got := FormatFleetUniqueName(memberClusterID, endpointSlice(namespace, name))
+		if errs := validation.IsDNS1123Subdomain(got); len(errs) != 0 {
+			t.Errorf("FormatFleetUniqueName(%q, %q/%q) = %q, not a valid RFC 1123 DNS subdomain: %v",
+				memberClusterID, namespace, name, got, errs)
+		}
+	})
+}
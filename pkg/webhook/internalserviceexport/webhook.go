@@ -0,0 +1,117 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package internalserviceexport features the validating webhook for InternalServiceExports.
+package internalserviceexport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/validate-networking-fleet-azure-com-v1alpha1-internalserviceexport,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.fleet.azure.com,resources=internalserviceexports,verbs=create;update,versions=v1alpha1,name=vinternalserviceexport.networking.fleet.azure.com,admissionReviewVersions=v1
+
+// validator validates InternalServiceExports on admission.
+type validator struct{}
+
+var _ admission.CustomValidator = &validator{}
+
+// SetupWebhookWithManager registers the validating webhook for InternalServiceExports with the manager.
+func SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&fleetnetv1alpha1.InternalServiceExport{}).
+		WithValidator(&validator{}).
+		Complete()
+}
+
+// ValidateCreate validates an InternalServiceExport on creation.
+func (v *validator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	internalSvcExport, ok := obj.(*fleetnetv1alpha1.InternalServiceExport)
+	if !ok {
+		return nil, fmt.Errorf("expected an InternalServiceExport, got %T", obj)
+	}
+	return nil, validateInternalServiceExport(internalSvcExport)
+}
+
+// ValidateUpdate validates an InternalServiceExport on update.
+func (v *validator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldInternalSvcExport, ok := oldObj.(*fleetnetv1alpha1.InternalServiceExport)
+	if !ok {
+		return nil, fmt.Errorf("expected an InternalServiceExport, got %T", oldObj)
+	}
+	internalSvcExport, ok := newObj.(*fleetnetv1alpha1.InternalServiceExport)
+	if !ok {
+		return nil, fmt.Errorf("expected an InternalServiceExport, got %T", newObj)
+	}
+	// Objects admitted before the webhook was enabled must still be updatable, e.g. to remove their finalizers.
+	if equality.Semantic.DeepEqual(oldInternalSvcExport.Spec.Ports, internalSvcExport.Spec.Ports) {
+		return nil, nil
+	}
+	return nil, validateInternalServiceExport(internalSvcExport)
+}
+
+// ValidateDelete allows the deletion of any InternalServiceExport.
+func (v *validator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateInternalServiceExport returns an Invalid error if the InternalServiceExport is invalid.
+func validateInternalServiceExport(internalSvcExport *fleetnetv1alpha1.InternalServiceExport) error {
+	allErrs := validateServicePorts(internalSvcExport.Spec.Ports, field.NewPath("spec", "ports"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+	klog.V(2).InfoS("Rejecting invalid internalServiceExport", "internalServiceExport", klog.KObj(internalSvcExport), "errors", allErrs)
+	return apierrors.NewInvalid(
+		fleetnetv1alpha1.GroupVersion.WithKind("InternalServiceExport").GroupKind(),
+		internalSvcExport.Name,
+		allErrs,
+	)
+}
+
+// portProtocol identifies a port on which a Service listens.
+type portProtocol struct {
+	port     int32
+	protocol corev1.Protocol
+}
+
+// validateServicePorts validates that the ports have unique names and do not listen on the same port with the same
+// protocol; ServiceImports cannot be merged from such ports.
+func validateServicePorts(ports []fleetnetv1alpha1.ServicePort, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]bool, len(ports))
+	portProtocols := make(map[portProtocol]bool, len(ports))
+	for i, port := range ports {
+		idxPath := fldPath.Index(i)
+		if names[port.Name] {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), port.Name))
+		}
+		names[port.Name] = true
+
+		protocol := port.Protocol
+		// The protocol defaults to TCP.
+		if protocol == "" {
+			protocol = corev1.ProtocolTCP
+		}
+		key := portProtocol{port: port.Port, protocol: protocol}
+		if portProtocols[key] {
+			allErrs = append(allErrs, field.Duplicate(idxPath, strconv.Itoa(int(port.Port))+"/"+string(protocol)))
+		}
+		portProtocols[key] = true
+	}
+	return allErrs
+}
@@ -0,0 +1,64 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package internalserviceexport
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+var _ = Describe("Test InternalServiceExport Webhook", func() {
+	newInternalServiceExport := func(name string, ports []fleetnetv1alpha1.ServicePort) *fleetnetv1alpha1.InternalServiceExport {
+		return &fleetnetv1alpha1.InternalServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      name,
+			},
+			Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+				Ports: ports,
+				ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+					ClusterID:       testNamespace,
+					Kind:            "Service",
+					Namespace:       "work",
+					Name:            name,
+					ResourceVersion: "0",
+					Generation:      0,
+					UID:             "0",
+					NamespacedName:  "work/" + name,
+					ExportedSince:   metav1.NewTime(time.Now().Round(time.Second)),
+				},
+			},
+		}
+	}
+
+	Context("When creating internalServiceExport", func() {
+		It("Should admit the internalServiceExport with unique ports", func() {
+			internalSvcExport := newInternalServiceExport("valid-app", []fleetnetv1alpha1.ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns-udp", Protocol: corev1.ProtocolUDP, Port: 53},
+			})
+			Expect(k8sClient.Create(ctx, internalSvcExport)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, internalSvcExport)).Should(Succeed())
+		})
+
+		It("Should reject the internalServiceExport with duplicate port names", func() {
+			internalSvcExport := newInternalServiceExport("duplicate-app", []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080},
+			})
+			err := k8sClient.Create(ctx, internalSvcExport)
+			Expect(apierrors.IsInvalid(err)).Should(BeTrue(), "Create() got %v, want an Invalid error", err)
+			Expect(err.Error()).Should(ContainSubstring("spec.ports[1].name"))
+		})
+	})
+})
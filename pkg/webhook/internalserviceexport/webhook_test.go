@@ -0,0 +1,145 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package internalserviceexport
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+func internalServiceExport(ports ...fleetnetv1alpha1.ServicePort) *fleetnetv1alpha1.InternalServiceExport {
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "member-1",
+			Name:      "work-app",
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: ports,
+		},
+	}
+}
+
+func TestValidateServicePorts(t *testing.T) {
+	fldPath := field.NewPath("spec", "ports")
+	tests := []struct {
+		name  string
+		ports []fleetnetv1alpha1.ServicePort
+		want  field.ErrorList
+	}{
+		{
+			name: "no ports",
+		},
+		{
+			name: "single unnamed port",
+			ports: []fleetnetv1alpha1.ServicePort{
+				{Port: 80},
+			},
+		},
+		{
+			name: "unique ports",
+			ports: []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53},
+				{Name: "dns-udp", Protocol: corev1.ProtocolUDP, Port: 53},
+			},
+		},
+		{
+			name: "duplicate names",
+			ports: []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "https", Protocol: corev1.ProtocolTCP, Port: 443},
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080},
+			},
+			want: field.ErrorList{
+				field.Duplicate(fldPath.Index(2).Child("name"), "http"),
+			},
+		},
+		{
+			name: "conflicting port and protocol",
+			ports: []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "http-alt", Port: 80},
+			},
+			want: field.ErrorList{
+				field.Duplicate(fldPath.Index(1), "80/TCP"),
+			},
+		},
+		{
+			name: "duplicate name and conflicting port and protocol",
+			ports: []fleetnetv1alpha1.ServicePort{
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+			},
+			want: field.ErrorList{
+				field.Duplicate(fldPath.Index(1).Child("name"), "http"),
+				field.Duplicate(fldPath.Index(1), "80/TCP"),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validateServicePorts(tc.ports, fldPath)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("validateServicePorts() mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	v := &validator{}
+	valid := internalServiceExport(fleetnetv1alpha1.ServicePort{Name: "http", Port: 80})
+	if _, err := v.ValidateCreate(context.Background(), valid); err != nil {
+		t.Errorf("ValidateCreate() = %v, want no error", err)
+	}
+
+	invalid := internalServiceExport(
+		fleetnetv1alpha1.ServicePort{Name: "http", Port: 80},
+		fleetnetv1alpha1.ServicePort{Name: "http", Port: 8080},
+	)
+	if _, err := v.ValidateCreate(context.Background(), invalid); !apierrors.IsInvalid(err) {
+		t.Errorf("ValidateCreate() = %v, want an Invalid error", err)
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	v := &validator{}
+	invalidPorts := []fleetnetv1alpha1.ServicePort{
+		{Name: "http", Port: 80},
+		{Name: "http", Port: 8080},
+	}
+	oldInvalid := internalServiceExport(invalidPorts...)
+
+	// Updating an object admitted before the webhook was enabled without changing its ports is allowed.
+	newInvalid := oldInvalid.DeepCopy()
+	newInvalid.Finalizers = []string{"networking.fleet.azure.com/internal-svc-export-cleanup"}
+	if _, err := v.ValidateUpdate(context.Background(), oldInvalid, newInvalid); err != nil {
+		t.Errorf("ValidateUpdate() = %v, want no error", err)
+	}
+
+	// Fixing the ports is allowed.
+	fixed := internalServiceExport(fleetnetv1alpha1.ServicePort{Name: "http", Port: 80})
+	if _, err := v.ValidateUpdate(context.Background(), oldInvalid, fixed); err != nil {
+		t.Errorf("ValidateUpdate() = %v, want no error", err)
+	}
+
+	// Introducing a conflict is rejected.
+	conflicted := internalServiceExport(
+		fleetnetv1alpha1.ServicePort{Name: "http", Port: 80},
+		fleetnetv1alpha1.ServicePort{Name: "http-alt", Protocol: corev1.ProtocolTCP, Port: 80},
+	)
+	if _, err := v.ValidateUpdate(context.Background(), fixed, conflicted); !apierrors.IsInvalid(err) {
+		t.Errorf("ValidateUpdate() = %v, want an Invalid error", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package serviceexport features the validating webhook for ServiceExports.
+package serviceexport
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/validate-networking-fleet-azure-com-v1alpha1-serviceexport,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.fleet.azure.com,resources=serviceexports,verbs=create;update,versions=v1alpha1,name=vserviceexport.networking.fleet.azure.com,admissionReviewVersions=v1
+
+// validator validates ServiceExports on admission.
+type validator struct {
+	// serviceReader reads the Services the ServiceExports export.
+	serviceReader client.Reader
+}
+
+var _ admission.CustomValidator = &validator{}
+
+// SetupWebhookWithManager registers the validating webhook for ServiceExports with the manager.
+//
+// Services are read from the API server directly, as the manager of the member cluster only caches their metadata.
+func SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&fleetnetv1alpha1.ServiceExport{}).
+		WithValidator(&validator{serviceReader: mgr.GetAPIReader()}).
+		Complete()
+}
+
+// ValidateCreate rejects a ServiceExport with an invalid spec, or which exports an ExternalName Service.
+//
+// A ServiceExport whose Service does not exist yet, or cannot be read, is admitted; the ServiceExport controller
+// still marks the ServiceExports of ineligible Services as invalid when it reconciles them.
+func (v *validator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	svcExport, ok := obj.(*fleetnetv1alpha1.ServiceExport)
+	if !ok {
+		return nil, fmt.Errorf("expected a ServiceExport, got %T", obj)
+	}
+	if err := validateSpec(svcExport); err != nil {
+		return nil, err
+	}
+	svc := &corev1.Service{}
+	svcKey := types.NamespacedName{Namespace: svcExport.Namespace, Name: svcExport.Name}
+	if err := v.serviceReader.Get(ctx, svcKey, svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get the service to validate the serviceExport; admitting it", "serviceExport", klog.KObj(svcExport))
+		}
+		return nil, nil
+	}
+	if svc.Spec.Type != corev1.ServiceTypeExternalName {
+		return nil, nil
+	}
+	klog.V(2).InfoS("Rejecting serviceExport of an ExternalName service", "serviceExport", klog.KObj(svcExport))
+	return nil, apierrors.NewInvalid(
+		fleetnetv1alpha1.GroupVersion.WithKind("ServiceExport").GroupKind(),
+		svcExport.Name,
+		field.ErrorList{
+			field.Forbidden(field.NewPath("metadata", "name"),
+				fmt.Sprintf("service %s is of the ExternalName type, which has no endpoints and cannot be exported", svcKey)),
+		},
+	)
+}
+
+// ValidateUpdate rejects an update of a ServiceExport to an invalid spec; the Service it exports cannot change.
+func (v *validator) ValidateUpdate(_ context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	svcExport, ok := newObj.(*fleetnetv1alpha1.ServiceExport)
+	if !ok {
+		return nil, fmt.Errorf("expected a ServiceExport, got %T", newObj)
+	}
+	return nil, validateSpec(svcExport)
+}
+
+// ValidateDelete allows the deletion of any ServiceExport.
+func (v *validator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSpec returns an invalid error if the endpoint selector of a ServiceExport is not a valid label selector.
+func validateSpec(svcExport *fleetnetv1alpha1.ServiceExport) error {
+	errs := metav1validation.ValidateLabelSelector(svcExport.Spec.IncludeOnlyEndpointsWithLabels,
+		metav1validation.LabelSelectorValidationOptions{},
+		field.NewPath("spec", "includeOnlyEndpointsWithLabels"))
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(fleetnetv1alpha1.GroupVersion.WithKind("ServiceExport").GroupKind(), svcExport.Name, errs)
+}
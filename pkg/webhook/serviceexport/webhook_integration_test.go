@@ -0,0 +1,68 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+var _ = Describe("Test ServiceExport Webhook", func() {
+	newServiceExport := func(name string) *fleetnetv1alpha1.ServiceExport {
+		return &fleetnetv1alpha1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      name,
+			},
+		}
+	}
+	newService := func(name string, spec corev1.ServiceSpec) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: testNamespace,
+				Name:      name,
+			},
+			Spec: spec,
+		}
+	}
+
+	Context("When creating serviceExport", func() {
+		It("Should admit the serviceExport of a ClusterIP service", func() {
+			svc := newService("cluster-ip-app", corev1.ServiceSpec{
+				Type:  corev1.ServiceTypeClusterIP,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			})
+			Expect(k8sClient.Create(ctx, svc)).Should(Succeed())
+			svcExport := newServiceExport(svc.Name)
+			Expect(k8sClient.Create(ctx, svcExport)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, svcExport)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, svc)).Should(Succeed())
+		})
+
+		It("Should admit the serviceExport of a service which does not exist yet", func() {
+			svcExport := newServiceExport("missing-app")
+			Expect(k8sClient.Create(ctx, svcExport)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, svcExport)).Should(Succeed())
+		})
+
+		It("Should reject the serviceExport of an ExternalName service", func() {
+			svc := newService("external-app", corev1.ServiceSpec{
+				Type:         corev1.ServiceTypeExternalName,
+				ExternalName: "app.example.com",
+			})
+			Expect(k8sClient.Create(ctx, svc)).Should(Succeed())
+			err := k8sClient.Create(ctx, newServiceExport(svc.Name))
+			Expect(apierrors.IsInvalid(err)).Should(BeTrue(), "Create() got %v, want an Invalid error", err)
+			Expect(err.Error()).Should(ContainSubstring("ExternalName"))
+			Expect(k8sClient.Delete(ctx, svc)).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,164 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	testName = "app"
+)
+
+func service(svcType corev1.ServiceType) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testName,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: svcType,
+		},
+	}
+}
+
+func serviceWithPorts(ports ...corev1.ServicePort) *corev1.Service {
+	svc := service(corev1.ServiceTypeClusterIP)
+	svc.Spec.Ports = ports
+	return svc
+}
+
+func headlessService() *corev1.Service {
+	svc := serviceWithPorts(corev1.ServicePort{Name: "http", Port: 80})
+	svc.Spec.ClusterIP = corev1.ClusterIPNone
+	return svc
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		svc     *corev1.Service
+		wantErr bool
+	}{
+		{
+			name: "ClusterIP service",
+			svc:  service(corev1.ServiceTypeClusterIP),
+		},
+		{
+			name: "LoadBalancer service",
+			svc:  service(corev1.ServiceTypeLoadBalancer),
+		},
+		{
+			name:    "ExternalName service",
+			svc:     service(corev1.ServiceTypeExternalName),
+			wantErr: true,
+		},
+		{
+			name: "service with multiple ports",
+			svc: serviceWithPorts(
+				corev1.ServicePort{Name: "http", Port: 80},
+				corev1.ServicePort{Name: "https", Port: 443},
+			),
+		},
+		{
+			name: "headless service",
+			svc:  headlessService(),
+		},
+		{
+			name: "service not found",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var objs []client.Object
+			if tc.svc != nil {
+				objs = append(objs, tc.svc)
+			}
+			v := &validator{serviceReader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()}
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      testName,
+				},
+			}
+			_, err := v.ValidateCreate(context.Background(), svcExport)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ValidateCreate(), got error %v, want error %t", err, tc.wantErr)
+			}
+			if tc.wantErr && !apierrors.IsInvalid(err) {
+				t.Errorf("ValidateCreate(), got error %v, want an Invalid error", err)
+			}
+		})
+	}
+}
+
+func TestValidate_EndpointSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector *metav1.LabelSelector
+		wantErr  bool
+	}{
+		{
+			name: "no selector",
+		},
+		{
+			name:     "valid selector",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/version": "v2"}},
+		},
+		{
+			name: "invalid operator",
+			selector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "Matches", Values: []string{"web"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "invalid label value",
+			selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "not a label value"}},
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := &validator{serviceReader: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(service(corev1.ServiceTypeClusterIP)).Build()}
+			svcExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: testNamespace,
+					Name:      testName,
+				},
+				Spec: fleetnetv1alpha1.ServiceExportSpec{IncludeOnlyEndpointsWithLabels: tc.selector},
+			}
+			for op, validate := range map[string]func() error{
+				"ValidateCreate()": func() error {
+					_, err := v.ValidateCreate(context.Background(), svcExport)
+					return err
+				},
+				"ValidateUpdate()": func() error {
+					_, err := v.ValidateUpdate(context.Background(), svcExport.DeepCopy(), svcExport)
+					return err
+				},
+			} {
+				err := validate()
+				if gotErr := err != nil; gotErr != tc.wantErr {
+					t.Fatalf("%s, got error %v, want error %t", op, err, tc.wantErr)
+				}
+				if tc.wantErr && !apierrors.IsInvalid(err) {
+					t.Errorf("%s, got error %v, want an Invalid error", op, err)
+				}
+			}
+		})
+	}
+}
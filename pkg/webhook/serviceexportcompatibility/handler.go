@@ -0,0 +1,219 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package serviceexportcompatibility features the what-if endpoint which predicts whether a proposed change of the
+// ports of an exported service would conflict with the exports of the same service from other clusters.
+//
+// The endpoint reads the exports of all the member clusters, so it serves only the callers which are allowed to read
+// them: the bearer token of a request is authenticated with a TokenReview, and the caller is authorized with
+// SubjectAccessReviews.
+package serviceexportcompatibility
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/exportconflict"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	// Path is the path on which the endpoint is served by the webhook server.
+	Path = "/check-serviceexport-compatibility"
+
+	// maxRequestBytes caps the size of the request body.
+	maxRequestBytes = 1 << 20
+)
+
+// Request is the proposed change of the ports of a service exported by a cluster.
+type Request struct {
+	// Namespace is the namespace of the exported service.
+	Namespace string `json:"namespace"`
+	// Name is the name of the exported service.
+	Name string `json:"name"`
+	// ClusterID is the ID of the cluster exporting the service.
+	ClusterID string `json:"clusterId"`
+	// Ports is the proposed ports of the exported service.
+	Ports []fleetnetv1alpha1.ServicePort `json:"ports"`
+}
+
+// Response is the predicted conflict outcome of the proposed change.
+type Response = exportconflict.Prediction
+
+// Handler evaluates the proposed changes against the current exports on the hub cluster without persisting
+// anything.
+type Handler struct {
+	// Client reads the exports, and reviews the credentials of the callers.
+	Client client.Client
+}
+
+// SetupWithManager registers the endpoint on the webhook server of the manager.
+func SetupWithManager(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(Path, &Handler{Client: mgr.GetClient()})
+}
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %s is not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := req.Context()
+	user, err := h.authenticate(ctx, req)
+	if err != nil {
+		reply(w, err)
+		return
+	}
+	proposal := Request{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxRequestBytes)).Decode(&proposal); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode the request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if proposal.Namespace == "" || proposal.Name == "" || proposal.ClusterID == "" {
+		http.Error(w, "namespace, name and clusterId are required", http.StatusBadRequest)
+		return
+	}
+
+	svcName := types.NamespacedName{Namespace: proposal.Namespace, Name: proposal.Name}
+	svcKRef := klog.KRef(svcName.Namespace, svcName.Name)
+	if err := h.authorize(ctx, user, svcName); err != nil {
+		klog.V(2).InfoS("Rejected the request", "service", svcKRef, "user", user.Username, "error", err)
+		reply(w, err)
+		return
+	}
+	var serviceImport *fleetnetv1alpha1.ServiceImport
+	current := &fleetnetv1alpha1.ServiceImport{}
+	if err := h.Client.Get(ctx, svcName, current); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to get serviceImport", "serviceImport", svcKRef)
+			http.Error(w, "failed to get the serviceImport", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		serviceImport = current
+	}
+
+	// InternalServiceExports are created in the namespaces reserved for the member clusters; they are looked up by
+	// the index of the Services they export, which the ServiceImport controller registers.
+	internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+	if err := h.Client.List(ctx, internalSvcExportList,
+		client.MatchingFields{objectmeta.InternalServiceExportFieldServiceNamespacedName: svcName.String()}); err != nil {
+		klog.ErrorS(err, "Failed to list internalServiceExports", "service", svcKRef)
+		http.Error(w, "failed to list the internalServiceExports", http.StatusInternalServerError)
+		return
+	}
+
+	prediction := exportconflict.Predict(serviceImport, internalSvcExportList.Items, proposal.ClusterID, proposal.Ports)
+	klog.V(2).InfoS("Predicted the conflict outcome of the proposed ports", "service", svcKRef, "clusterID", proposal.ClusterID, "conflict", prediction.Conflict, "affectedClusters", prediction.AffectedClusters)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(prediction); err != nil {
+		klog.ErrorS(err, "Failed to write the response", "service", svcKRef)
+	}
+}
+
+// statusError is an error replied to the caller with the HTTP status code.
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// reply writes the error to the caller; the details of the errors which are not statusErrors are not exposed.
+func reply(w http.ResponseWriter, err error) {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		http.Error(w, "failed to review the credentials of the request", http.StatusInternalServerError)
+		return
+	}
+	http.Error(w, statusErr.Error(), statusErr.code)
+}
+
+// authenticate reviews the bearer token of the request and returns the user it belongs to.
+func (h *Handler) authenticate(ctx context.Context, req *http.Request) (*authenticationv1.UserInfo, error) {
+	token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		return nil, &statusError{code: http.StatusUnauthorized, err: errors.New("a bearer token is required")}
+	}
+	tokenReview := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	if err := h.Client.Create(ctx, tokenReview); err != nil {
+		klog.ErrorS(err, "Failed to create tokenReview")
+		return nil, err
+	}
+	if !tokenReview.Status.Authenticated {
+		return nil, &statusError{code: http.StatusUnauthorized, err: errors.New("the bearer token is not authenticated")}
+	}
+	return &tokenReview.Status.User, nil
+}
+
+// authorize checks that the user is allowed to read everything the endpoint reads to predict the outcome for the
+// service: the serviceImport of the service, and the internalServiceExports of all the member clusters.
+func (h *Handler) authorize(ctx context.Context, user *authenticationv1.UserInfo, svcName types.NamespacedName) error {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	required := []authorizationv1.ResourceAttributes{
+		{
+			Verb:      "get",
+			Group:     fleetnetv1alpha1.GroupVersion.Group,
+			Resource:  "serviceimports",
+			Namespace: svcName.Namespace,
+			Name:      svcName.Name,
+		},
+		{
+			Verb:     "list",
+			Group:    fleetnetv1alpha1.GroupVersion.Group,
+			Resource: "internalserviceexports",
+		},
+	}
+	for i := range required {
+		sar := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &required[i],
+				User:               user.Username,
+				Groups:             user.Groups,
+				UID:                user.UID,
+				Extra:              extra,
+			},
+		}
+		if err := h.Client.Create(ctx, sar); err != nil {
+			klog.ErrorS(err, "Failed to create subjectAccessReview", "user", user.Username)
+			return err
+		}
+		if !sar.Status.Allowed {
+			return &statusError{
+				code: http.StatusForbidden,
+				err:  fmt.Errorf("user %q is not allowed to %s %s", user.Username, required[i].Verb, required[i].Resource),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexportcompatibility
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+const (
+	testNamespace = "work"
+	testSvcName   = "app"
+
+	memberClusterID1 = "member-1"
+	memberClusterID2 = "member-2"
+
+	// adminToken belongs to a user allowed to read the exports of all the member clusters.
+	adminToken = "admin-token"
+	// tenantToken belongs to a user allowed to read the serviceImports of the namespace only.
+	tenantToken = "tenant-token"
+)
+
+var (
+	httpPorts = []fleetnetv1alpha1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+	}
+	// webPorts cannot be merged with httpPorts, as the same port is named differently.
+	webPorts = []fleetnetv1alpha1.ServicePort{
+		{Name: "web", Protocol: corev1.ProtocolTCP, Port: 80},
+	}
+)
+
+func internalServiceExport(clusterID, svcName string, ports []fleetnetv1alpha1.ServicePort) *fleetnetv1alpha1.InternalServiceExport {
+	return &fleetnetv1alpha1.InternalServiceExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  "fleet-member-" + clusterID,
+			Name:       testNamespace + "-" + svcName,
+			Finalizers: []string{objectmeta.InternalServiceExportFinalizer},
+		},
+		Spec: fleetnetv1alpha1.InternalServiceExportSpec{
+			Ports: ports,
+			ServiceReference: fleetnetv1alpha1.ExportedObjectReference{
+				ClusterID:      clusterID,
+				Namespace:      testNamespace,
+				Name:           svcName,
+				NamespacedName: testNamespace + "/" + svcName,
+			},
+		},
+	}
+}
+
+// reviewCreate fakes the TokenReviews and SubjectAccessReviews of the API server.
+func reviewCreate(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		switch review.Spec.Token {
+		case adminToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "admin"}}
+		case tenantToken:
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "tenant"}}
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		attrs := review.Spec.ResourceAttributes
+		switch review.Spec.User {
+		case "admin":
+			review.Status.Allowed = true
+		case "tenant":
+			review.Status.Allowed = attrs.Resource == "serviceimports" && attrs.Namespace == testNamespace
+		}
+		return nil
+	}
+	return c.Create(ctx, obj, opts...)
+}
+
+func newTestHandler(t *testing.T) *Handler {
+	scheme := runtime.NewScheme()
+	if err := fleetnetv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	if err := authenticationv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	if err := authorizationv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() = %v, want no error", err)
+	}
+	serviceImport := &fleetnetv1alpha1.ServiceImport{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      testSvcName,
+		},
+		Status: fleetnetv1alpha1.ServiceImportStatus{
+			Ports:    httpPorts,
+			Clusters: []fleetnetv1alpha1.ClusterStatus{{Cluster: memberClusterID1}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			serviceImport,
+			internalServiceExport(memberClusterID1, testSvcName, httpPorts),
+			// An export of another service with different ports.
+			internalServiceExport(memberClusterID2, "other-app", webPorts),
+		).
+		WithIndex(&fleetnetv1alpha1.InternalServiceExport{}, objectmeta.InternalServiceExportFieldServiceNamespacedName, func(o client.Object) []string {
+			return []string{o.(*fleetnetv1alpha1.InternalServiceExport).Spec.ServiceReference.NamespacedName}
+		}).
+		WithInterceptorFuncs(interceptor.Funcs{Create: reviewCreate}).
+		Build()
+	return &Handler{Client: fakeClient}
+}
+
+func serve(t *testing.T, h *Handler, method, token string, body any) *httptest.ResponseRecorder {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Marshal() = %v, want no error", err)
+	}
+	req := httptest.NewRequest(method, Path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		proposed []fleetnetv1alpha1.ServicePort
+		want     Response
+	}{
+		{
+			name:     "predicted clean",
+			proposed: httpPorts,
+			want: Response{
+				ResolvedPorts: httpPorts,
+			},
+		},
+		{
+			name:     "predicted conflict",
+			proposed: webPorts,
+			want: Response{
+				Conflict:           true,
+				ResolvedPorts:      httpPorts,
+				ConflictedClusters: []string{memberClusterID2},
+				AffectedClusters:   []string{memberClusterID2},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			rec := serve(t, h, http.MethodPost, adminToken, Request{
+				Namespace: testNamespace,
+				Name:      testSvcName,
+				ClusterID: memberClusterID2,
+				Ports:     tc.proposed,
+			})
+			if rec.Code != http.StatusOK {
+				t.Fatalf("ServeHTTP() status code = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			got := Response{}
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("Decode() = %v, want no error", err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ServeHTTP() response mismatch (-want, +got):\n%s", diff)
+			}
+
+			// Nothing is persisted.
+			list := &fleetnetv1alpha1.InternalServiceExportList{}
+			if err := h.Client.List(context.Background(), list); err != nil {
+				t.Fatalf("List() = %v, want no error", err)
+			}
+			if len(list.Items) != 2 {
+				t.Errorf("List() got %d internalServiceExports, want 2", len(list.Items))
+			}
+		})
+	}
+}
+
+func TestServeHTTP_InvalidRequest(t *testing.T) {
+	h := newTestHandler(t)
+	if rec := serve(t, h, http.MethodGet, adminToken, Request{}); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ServeHTTP() status code = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if rec := serve(t, h, http.MethodPost, adminToken, Request{Namespace: testNamespace, Name: testSvcName}); rec.Code != http.StatusBadRequest {
+		t.Errorf("ServeHTTP() status code = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServeHTTP_Unauthorized(t *testing.T) {
+	proposal := Request{
+		Namespace: testNamespace,
+		Name:      testSvcName,
+		ClusterID: memberClusterID2,
+		Ports:     webPorts,
+	}
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "no token",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "unauthenticated token",
+			token:    "unknown-token",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			// The tenant could otherwise learn about the exports of the other member clusters.
+			name:     "not allowed to read the exports of all the member clusters",
+			token:    tenantToken,
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestHandler(t)
+			rec := serve(t, h, http.MethodPost, tc.token, proposal)
+			if rec.Code != tc.wantCode {
+				t.Errorf("ServeHTTP() status code = %d, want %d: %s", rec.Code, tc.wantCode, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package trafficmanagerprofile features the validating and defaulting webhooks for TrafficManagerProfiles.
+package trafficmanagerprofile
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/azuretags"
+	"go.goms.io/fleet-networking/pkg/common/defaulter"
+)
+
+//+kubebuilder:webhook:path=/mutate-networking-fleet-azure-com-v1beta1-trafficmanagerprofile,mutating=true,failurePolicy=fail,sideEffects=None,groups=networking.fleet.azure.com,resources=trafficmanagerprofiles,verbs=create;update,versions=v1beta1,name=mtrafficmanagerprofile.networking.fleet.azure.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-networking-fleet-azure-com-v1beta1-trafficmanagerprofile,mutating=false,failurePolicy=fail,sideEffects=None,groups=networking.fleet.azure.com,resources=trafficmanagerprofiles,verbs=create;update,versions=v1beta1,name=vtrafficmanagerprofile.networking.fleet.azure.com,admissionReviewVersions=v1
+
+// validator validates TrafficManagerProfiles on admission.
+type validator struct{}
+
+var _ admission.CustomValidator = &validator{}
+
+// monitorConfigDefaulter sets the defaults of the monitor config of TrafficManagerProfiles which depend on other
+// fields, and thus cannot be expressed as defaults of the CRD.
+type monitorConfigDefaulter struct{}
+
+var _ admission.CustomDefaulter = &monitorConfigDefaulter{}
+
+// SetupWebhookWithManager registers the validating and defaulting webhooks for TrafficManagerProfiles with the manager.
+func SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&fleetnetv1beta1.TrafficManagerProfile{}).
+		WithValidator(&validator{}).
+		WithDefaulter(&monitorConfigDefaulter{}).
+		Complete()
+}
+
+// Default sets the default path of the monitor config, which depends on the protocol: the path is cleared for TCP,
+// which does not use it, and defaults to "/" for HTTP and HTTPS.
+func (d *monitorConfigDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	profile, ok := obj.(*fleetnetv1beta1.TrafficManagerProfile)
+	if !ok {
+		return fmt.Errorf("expected a TrafficManagerProfile, got %T", obj)
+	}
+	if profile.Spec.MonitorConfig == nil {
+		return nil
+	}
+	defaulter.SetDefaultsMonitorConfigPath(profile.Spec.MonitorConfig)
+	return nil
+}
+
+// ValidateCreate validates a TrafficManagerProfile on creation.
+func (v *validator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	profile, ok := obj.(*fleetnetv1beta1.TrafficManagerProfile)
+	if !ok {
+		return nil, fmt.Errorf("expected a TrafficManagerProfile, got %T", obj)
+	}
+	return nil, validateTrafficManagerProfile(profile)
+}
+
+// ValidateUpdate validates a TrafficManagerProfile on update.
+func (v *validator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldProfile, ok := oldObj.(*fleetnetv1beta1.TrafficManagerProfile)
+	if !ok {
+		return nil, fmt.Errorf("expected a TrafficManagerProfile, got %T", oldObj)
+	}
+	profile, ok := newObj.(*fleetnetv1beta1.TrafficManagerProfile)
+	if !ok {
+		return nil, fmt.Errorf("expected a TrafficManagerProfile, got %T", newObj)
+	}
+	// Objects admitted before the webhook was enabled must still be updatable, e.g. to remove their finalizers, as
+	// long as they do not add more tags.
+	if azuretags.Count(profile.Annotations) <= azuretags.Count(oldProfile.Annotations) {
+		return nil, nil
+	}
+	return nil, validateTrafficManagerProfile(profile)
+}
+
+// ValidateDelete allows the deletion of any TrafficManagerProfile.
+func (v *validator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateTrafficManagerProfile returns an Invalid error if the TrafficManagerProfile is invalid.
+func validateTrafficManagerProfile(profile *fleetnetv1beta1.TrafficManagerProfile) error {
+	allErrs := validateAzureTags(profile.Annotations, field.NewPath("metadata", "annotations"))
+	if len(allErrs) == 0 {
+		return nil
+	}
+	klog.V(2).InfoS("Rejecting invalid trafficManagerProfile", "trafficManagerProfile", klog.KObj(profile), "errors", allErrs)
+	return apierrors.NewInvalid(
+		fleetnetv1beta1.GroupVersion.WithKind("TrafficManagerProfile").GroupKind(),
+		profile.Name,
+		allErrs,
+	)
+}
+
+// validateAzureTags validates that the annotations do not define more Azure tags than a profile may have.
+// Tags exceeding the Azure length limits are not rejected; the controller leaves them out and warns about them.
+func validateAzureTags(annotations map[string]string, fldPath *field.Path) field.ErrorList {
+	if count := azuretags.Count(annotations); count > azuretags.MaxTags {
+		return field.ErrorList{field.TooMany(fldPath, count, azuretags.MaxTags)}
+	}
+	return nil
+}
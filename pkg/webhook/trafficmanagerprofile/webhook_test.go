@@ -0,0 +1,171 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package trafficmanagerprofile
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	fleetnetv1beta1 "go.goms.io/fleet-networking/api/v1beta1"
+	"go.goms.io/fleet-networking/pkg/common/objectmeta"
+)
+
+func trafficManagerProfile(tagCount int) *fleetnetv1beta1.TrafficManagerProfile {
+	annotations := map[string]string{"other": "annotation"}
+	for i := 0; i < tagCount; i++ {
+		annotations[fmt.Sprintf("%stag-%d", objectmeta.AzureTagAnnotationPrefix, i)] = "value"
+	}
+	return &fleetnetv1beta1.TrafficManagerProfile{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "work",
+			Name:        "app",
+			Annotations: annotations,
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	tests := []struct {
+		name     string
+		tagCount int
+		wantErr  bool
+	}{
+		{
+			name: "no tags",
+		},
+		{
+			name:     "maximum number of tags",
+			tagCount: 49,
+		},
+		{
+			name:     "too many tags",
+			tagCount: 50,
+			wantErr:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&validator{}).ValidateCreate(context.Background(), trafficManagerProfile(tc.tagCount))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ValidateCreate(), got error %v, want error %t", err, tc.wantErr)
+			}
+			if tc.wantErr && !apierrors.IsInvalid(err) {
+				t.Errorf("ValidateCreate(), got error %v, want an Invalid error", err)
+			}
+		})
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldTagCount int
+		newTagCount int
+		wantErr     bool
+	}{
+		{
+			name:        "add tags within the limit",
+			oldTagCount: 10,
+			newTagCount: 49,
+		},
+		{
+			name:        "add tags beyond the limit",
+			oldTagCount: 49,
+			newTagCount: 50,
+			wantErr:     true,
+		},
+		{
+			name:        "keep the tags of a profile admitted before the webhook was enabled",
+			oldTagCount: 60,
+			newTagCount: 60,
+		},
+		{
+			name:        "remove tags of a profile admitted before the webhook was enabled",
+			oldTagCount: 60,
+			newTagCount: 55,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := (&validator{}).ValidateUpdate(context.Background(), trafficManagerProfile(tc.oldTagCount), trafficManagerProfile(tc.newTagCount))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("ValidateUpdate(), got error %v, want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		name          string
+		monitorConfig *fleetnetv1beta1.MonitorConfig
+		want          *fleetnetv1beta1.MonitorConfig
+	}{
+		{
+			name: "no monitor config",
+		},
+		{
+			name: "TCP probe with the default path",
+			monitorConfig: &fleetnetv1beta1.MonitorConfig{
+				Path:     ptr.To("/"),
+				Port:     ptr.To(int64(8080)),
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+			},
+			want: &fleetnetv1beta1.MonitorConfig{
+				Port:     ptr.To(int64(8080)),
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+			},
+		},
+		{
+			name: "TCP probe without a path",
+			monitorConfig: &fleetnetv1beta1.MonitorConfig{
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+			},
+			want: &fleetnetv1beta1.MonitorConfig{
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolTCP),
+			},
+		},
+		{
+			name: "HTTP probe without a path",
+			monitorConfig: &fleetnetv1beta1.MonitorConfig{
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+			},
+			want: &fleetnetv1beta1.MonitorConfig{
+				Path:     ptr.To("/"),
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTP),
+			},
+		},
+		{
+			name: "HTTPS probe with a path",
+			monitorConfig: &fleetnetv1beta1.MonitorConfig{
+				Path:     ptr.To("/healthz"),
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+			},
+			want: &fleetnetv1beta1.MonitorConfig{
+				Path:     ptr.To("/healthz"),
+				Protocol: ptr.To(fleetnetv1beta1.TrafficManagerMonitorProtocolHTTPS),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			profile := trafficManagerProfile(0)
+			profile.Spec.MonitorConfig = tc.monitorConfig
+			if err := (&monitorConfigDefaulter{}).Default(context.Background(), profile); err != nil {
+				t.Fatalf("Default() got error %v, want no error", err)
+			}
+			if diff := cmp.Diff(tc.want, profile.Spec.MonitorConfig); diff != "" {
+				t.Errorf("Default() monitor config mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -235,6 +235,41 @@ var _ = Describe("Test networking v1alpha1 API validation", func() {
 			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Create API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
 			Expect(statusErr.Status().Message).Should(ContainSubstring("lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character"))
 		})
+
+		It("should deny updating the version", func() {
+			serviceExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: objectMetaWithNameValid,
+				Spec: fleetnetv1alpha1.ServiceExportSpec{
+					Version: "v1",
+				},
+			}
+			Expect(hubClient.Create(ctx, serviceExport)).Should(Succeed(), "failed to create serviceExport")
+
+			By("expecting denial of UPDATE API with a different version")
+			serviceExport.Spec.Version = "v2"
+			var err = hubClient.Update(ctx, serviceExport)
+			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Update API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
+			Expect(statusErr.Status().Message).Should(ContainSubstring("spec.version is immutable"))
+
+			By("expecting denial of UPDATE API removing the version")
+			serviceExport.Spec.Version = ""
+			err = hubClient.Update(ctx, serviceExport)
+			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Update API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
+			Expect(statusErr.Status().Message).Should(ContainSubstring("spec.version is immutable"))
+			Expect(hubClient.Delete(ctx, serviceExport)).Should(Succeed(), "failed to delete serviceExport")
+		})
+
+		It("should deny creating API with invalid version", func() {
+			serviceExport := &fleetnetv1alpha1.ServiceExport{
+				ObjectMeta: objectMetaWithNameValid,
+				Spec: fleetnetv1alpha1.ServiceExportSpec{
+					Version: "V_2",
+				},
+			}
+			var err = hubClient.Create(ctx, serviceExport)
+			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Create API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
+			Expect(statusErr.Status().Message).Should(ContainSubstring("spec.version"))
+		})
 	})
 
 	Context("Test ServiceExport API validation - valid cases", func() {
@@ -186,6 +186,37 @@ var _ = Describe("Test networking v1alpha1 API validation", func() {
 		})
 	})
 
+	Context("Test TrafficManagerProfile API validation - dnsConfig", func() {
+		It("should deny creating API with invalid relative DNS name", func() {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: objectMetaWithNameValid,
+				Spec:       *trafficManagerProfileSpec.DeepCopy(),
+			}
+			profile.Spec.ResourceGroup = "resource-group"
+			profile.Spec.DNSConfig = &fleetnetv1beta1.DNSConfig{RelativeName: ptr.To(nameEndingWithNonAlphanum)}
+			var err = hubClient.Create(ctx, profile)
+			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Create API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
+			Expect(statusErr.Status().Message).Should(ContainSubstring("spec.dnsConfig.relativeName"))
+		})
+
+		It("should deny updating the relative DNS name", func() {
+			profile := &fleetnetv1beta1.TrafficManagerProfile{
+				ObjectMeta: objectMetaWithNameValid,
+				Spec:       *trafficManagerProfileSpec.DeepCopy(),
+			}
+			profile.Spec.ResourceGroup = "resource-group"
+			profile.Spec.DNSConfig = &fleetnetv1beta1.DNSConfig{RelativeName: ptr.To(nameValidStartingWithAlphabet)}
+			Expect(hubClient.Create(ctx, profile)).Should(Succeed(), "failed to create trafficManagerProfile")
+
+			profile.Spec.DNSConfig.RelativeName = ptr.To(nameValidStartingWithNumber)
+			var err = hubClient.Update(ctx, profile)
+			Expect(errors.As(err, &statusErr)).To(BeTrue(), fmt.Sprintf("Update API call produced error %s. Error type wanted is %s.", reflect.TypeOf(err), reflect.TypeOf(&k8serrors.StatusError{})))
+			Expect(statusErr.Status().Message).Should(ContainSubstring("relativeName is immutable"))
+
+			Expect(hubClient.Delete(ctx, profile)).Should(Succeed(), "failed to delete trafficManagerProfile")
+		})
+	})
+
 	Context("Test TrafficManagerBackend API validation - invalid cases", func() {
 		It("should deny creating API with invalid name size", func() {
 			// Create the API.
@@ -70,7 +70,7 @@ func EndpointDelete(_ context.Context, resourceGroupName string, profileName str
 	return resp, errResp
 }
 
-func EndpointCreateOrUpdate(_ context.Context, resourceGroupName string, profileName string, endpointType armtrafficmanager.EndpointType, endpointName string, _ armtrafficmanager.Endpoint, _ *armtrafficmanager.EndpointsClientCreateOrUpdateOptions) (resp azcorefake.Responder[armtrafficmanager.EndpointsClientCreateOrUpdateResponse], errResp azcorefake.ErrorResponder) {
+func EndpointCreateOrUpdate(_ context.Context, resourceGroupName string, profileName string, endpointType armtrafficmanager.EndpointType, endpointName string, parameters armtrafficmanager.Endpoint, _ *armtrafficmanager.EndpointsClientCreateOrUpdateOptions) (resp azcorefake.Responder[armtrafficmanager.EndpointsClientCreateOrUpdateResponse], errResp azcorefake.ErrorResponder) {
 	if resourceGroupName != DefaultResourceGroupName {
 		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
 		return resp, errResp
@@ -82,8 +82,16 @@ func EndpointCreateOrUpdate(_ context.Context, resourceGroupName string, profile
 		} else if endpointName == CreateInternalServerErrEndpointName {
 			errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
 			return resp, errResp
+		} else if profileName == ValidSubnetProfileName && (parameters.Properties == nil || len(parameters.Properties.Subnets) == 0) {
+			// The endpoints of a profile using the Subnet routing method must map some subnets.
+			errResp.SetResponseError(http.StatusBadRequest, "BadRequest")
+			return resp, errResp
 		}
 
+		var subnets []*armtrafficmanager.EndpointPropertiesSubnetsItem
+		if parameters.Properties != nil {
+			subnets = parameters.Properties.Subnets
+		}
 		endpointResp := armtrafficmanager.EndpointsClientCreateOrUpdateResponse{
 			Endpoint: armtrafficmanager.Endpoint{
 				Name: ptr.To(endpointName),
@@ -91,6 +99,7 @@ func EndpointCreateOrUpdate(_ context.Context, resourceGroupName string, profile
 					TargetResourceID: ptr.To(ValidPublicIPResourceID),
 					Weight:           ptr.To(Weight),
 					Target:           ptr.To(ValidEndpointTarget),
+					Subnets:          subnets,
 				},
 				Type: ptr.To(string(azureTrafficManagerEndpointTypePrefix + armtrafficmanager.EndpointTypeAzureEndpoints)),
 			},
@@ -0,0 +1,127 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azcorefake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/monitor/armmonitor/fake"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	ValidActionGroupResourceID      = "/subscriptions/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Insights/actionGroups/oncall"
+	BadRequestActionGroupResourceID = "/subscriptions/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Insights/actionGroups/not-found"
+)
+
+// MetricAlertResourceID returns the resource ID of the metric alert rule in the default resource group.
+func MetricAlertResourceID(name string) string {
+	return fmt.Sprintf("/subscriptions/sub1/resourceGroups/%s/providers/Microsoft.Insights/metricAlerts/%s", DefaultResourceGroupName, name)
+}
+
+// MetricAlertServer is a fake metric alert server which remembers the metric alert rules.
+type MetricAlertServer struct {
+	mu         sync.Mutex
+	alertRules map[string]armmonitor.MetricAlertResource
+	// calls are the names of the operations called, in order.
+	calls []string
+}
+
+// NewMetricAlertsClient creates a client which talks to a fake metric alert server.
+func NewMetricAlertsClient(subscriptionID string) (*armmonitor.MetricAlertsClient, *MetricAlertServer, error) {
+	s := &MetricAlertServer{alertRules: map[string]armmonitor.MetricAlertResource{}}
+	fakeServer := fake.MetricAlertsServer{
+		Get:            s.get,
+		CreateOrUpdate: s.createOrUpdate,
+		Delete:         s.delete,
+	}
+	clientFactory, err := armmonitor.NewClientFactory(subscriptionID, &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewMetricAlertsServerTransport(&fakeServer),
+			},
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientFactory.NewMetricAlertsClient(), s, nil
+}
+
+// AlertRule returns the metric alert rule and whether it exists.
+func (s *MetricAlertServer) AlertRule(name string) (armmonitor.MetricAlertResource, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alertRule, ok := s.alertRules[name]
+	return alertRule, ok
+}
+
+// Calls returns the names of the operations called since the last call of Calls.
+func (s *MetricAlertServer) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+func (s *MetricAlertServer) get(_ context.Context, resourceGroupName string, ruleName string, _ *armmonitor.MetricAlertsClientGetOptions) (resp azcorefake.Responder[armmonitor.MetricAlertsClientGetResponse], errResp azcorefake.ErrorResponder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "Get")
+	if resourceGroupName != DefaultResourceGroupName {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+		return resp, errResp
+	}
+	alertRule, ok := s.alertRules[ruleName]
+	if !ok {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceNotFound")
+		return resp, errResp
+	}
+	resp.SetResponse(http.StatusOK, armmonitor.MetricAlertsClientGetResponse{MetricAlertResource: alertRule}, nil)
+	return resp, errResp
+}
+
+// createOrUpdate returns BadRequest when the alert rule notifies the BadRequestActionGroupResourceID.
+func (s *MetricAlertServer) createOrUpdate(_ context.Context, resourceGroupName string, ruleName string, parameters armmonitor.MetricAlertResource, _ *armmonitor.MetricAlertsClientCreateOrUpdateOptions) (resp azcorefake.Responder[armmonitor.MetricAlertsClientCreateOrUpdateResponse], errResp azcorefake.ErrorResponder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "CreateOrUpdate")
+	if resourceGroupName != DefaultResourceGroupName {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+		return resp, errResp
+	}
+	for _, action := range parameters.Properties.Actions {
+		if ptr.Deref(action.ActionGroupID, "") == BadRequestActionGroupResourceID {
+			errResp.SetResponseError(http.StatusBadRequest, "BadRequest")
+			return resp, errResp
+		}
+	}
+	parameters.ID = ptr.To(MetricAlertResourceID(ruleName))
+	parameters.Name = ptr.To(ruleName)
+	s.alertRules[ruleName] = parameters
+	resp.SetResponse(http.StatusOK, armmonitor.MetricAlertsClientCreateOrUpdateResponse{MetricAlertResource: parameters}, nil)
+	return resp, errResp
+}
+
+func (s *MetricAlertServer) delete(_ context.Context, resourceGroupName string, ruleName string, _ *armmonitor.MetricAlertsClientDeleteOptions) (resp azcorefake.Responder[armmonitor.MetricAlertsClientDeleteResponse], errResp azcorefake.ErrorResponder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, "Delete")
+	if resourceGroupName != DefaultResourceGroupName {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+		return resp, errResp
+	}
+	delete(s.alertRules, ruleName)
+	resp.SetResponse(http.StatusOK, armmonitor.MetricAlertsClientDeleteResponse{}, nil)
+	return resp, errResp
+}
@@ -30,6 +30,7 @@ const (
 	ValidProfileWithEndpointsName            = "valid-profile-with-endpoints"
 	ValidProfileWithNilPropertiesName        = "valid-profile-with-empty-properties"
 	ValidProfileWithFailToDeleteEndpointName = "valid-profile-with-fail-to-delete-endpoint"
+	ValidSubnetProfileName                   = "valid-profile-with-subnet-routing"
 	ConflictErrProfileName                   = "conflict-err-profile"
 	InternalServerErrProfileName             = "internal-server-err-profile"
 	ThrottledErrProfileName                  = "throttled-err-profile"
@@ -81,7 +82,7 @@ func ProfileGet(_ context.Context, resourceGroupName string, profileName string,
 		return resp, errResp
 	}
 	switch profileName {
-	case ValidProfileName, ValidProfileWithEndpointsName, ValidProfileWithFailToDeleteEndpointName:
+	case ValidProfileName, ValidProfileWithEndpointsName, ValidProfileWithFailToDeleteEndpointName, ValidSubnetProfileName:
 		namespacedName := types.NamespacedName{Name: profileName, Namespace: ProfileNamespace}
 		profileResp := armtrafficmanager.ProfilesClientGetResponse{
 			Profile: armtrafficmanager.Profile{
@@ -115,19 +116,28 @@ func ProfileGet(_ context.Context, resourceGroupName string, profileName string,
 				{
 					Name: ptr.To(strings.ToUpper(ValidEndpointName)), // test case-insensitive
 					Properties: &armtrafficmanager.EndpointProperties{
-						TargetResourceID: ptr.To(ValidPublicIPResourceID),
-						Weight:           ptr.To(Weight),
+						TargetResourceID:      ptr.To(ValidPublicIPResourceID),
+						Weight:                ptr.To(Weight),
+						EndpointMonitorStatus: ptr.To(armtrafficmanager.EndpointMonitorStatusOnline),
 					},
 					Type: ptr.To(string(azureTrafficManagerEndpointTypePrefix + armtrafficmanager.EndpointTypeAzureEndpoints)),
 				},
 				{
 					Name: ptr.To("other-endpoint"),
+					Properties: &armtrafficmanager.EndpointProperties{
+						EndpointMonitorStatus: ptr.To(armtrafficmanager.EndpointMonitorStatusDegraded),
+					},
 				},
 				{
 					// used to test not-found endpoint
 					Name: ptr.To(NotFoundErrEndpointName),
+					Properties: &armtrafficmanager.EndpointProperties{
+						EndpointMonitorStatus: ptr.To(armtrafficmanager.EndpointMonitorStatusCheckingEndpoint),
+					},
 				},
 			}
+		} else if profileName == ValidSubnetProfileName {
+			profileResp.Profile.Properties.TrafficRoutingMethod = ptr.To(armtrafficmanager.TrafficRoutingMethodSubnet)
 		} else if profileName == ValidProfileWithFailToDeleteEndpointName {
 			profileResp.Profile.Properties.Endpoints = []*armtrafficmanager.Endpoint{
 				{
@@ -164,7 +174,7 @@ func ProfileCreateOrUpdate(_ context.Context, resourceGroupName string, profileN
 		errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
 	case ThrottledErrProfileName:
 		errResp.SetResponseError(http.StatusTooManyRequests, "ThrottledError")
-	case ValidProfileName:
+	case ValidProfileName, ValidSubnetProfileName:
 		if parameters.Properties.MonitorConfig.IntervalInSeconds != nil && *parameters.Properties.MonitorConfig.IntervalInSeconds == 10 {
 			if parameters.Properties.MonitorConfig.TimeoutInSeconds != nil && *parameters.Properties.MonitorConfig.TimeoutInSeconds > 9 {
 				errResp.SetResponseError(http.StatusBadRequest, "BadRequestError")
@@ -184,7 +194,7 @@ func ProfileCreateOrUpdate(_ context.Context, resourceGroupName string, profileN
 					Endpoints:                   []*armtrafficmanager.Endpoint{},
 					MonitorConfig:               parameters.Properties.MonitorConfig,
 					ProfileStatus:               ptr.To(armtrafficmanager.ProfileStatusEnabled),
-					TrafficRoutingMethod:        ptr.To(armtrafficmanager.TrafficRoutingMethodWeighted),
+					TrafficRoutingMethod:        parameters.Properties.TrafficRoutingMethod,
 					TrafficViewEnrollmentStatus: ptr.To(armtrafficmanager.TrafficViewEnrollmentStatusDisabled),
 				},
 			}}
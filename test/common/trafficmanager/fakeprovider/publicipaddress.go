@@ -0,0 +1,134 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package fakeprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	azcorefake "github.com/Azure/azure-sdk-for-go/sdk/azcore/fake"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v4/fake"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	ValidPublicIPAddressName             = "valid-public-ip"
+	InternalServerErrPublicIPAddressName = "internal-server-err-public-ip"
+
+	DDoSPlanResourceID = "/subscriptions/sub1/resourceGroups/default-resource-group-name/providers/Microsoft.Network/ddosProtectionPlans/plan"
+)
+
+var (
+	ValidPublicIPAddressResourceID             = PublicIPAddressResourceID(ValidPublicIPAddressName)
+	InternalServerErrPublicIPAddressResourceID = PublicIPAddressResourceID(InternalServerErrPublicIPAddressName)
+)
+
+// PublicIPAddressResourceID returns the resource ID of the public IP address in the default resource group.
+func PublicIPAddressResourceID(name string) string {
+	return fmt.Sprintf("/subscriptions/sub1/resourceGroups/%s/providers/Microsoft.Network/publicIPAddresses/%s", DefaultResourceGroupName, name)
+}
+
+// PublicIPAddressServer is a fake public IP address server which remembers the DDoS settings and the provisioning
+// states of the public IP addresses.
+type PublicIPAddressServer struct {
+	mu                 sync.Mutex
+	ddosSettings       map[string]*armnetwork.DdosSettings
+	provisioningStates map[string]armnetwork.ProvisioningState
+}
+
+// NewPublicIPAddressesClient creates a client which talks to a fake public IP address server.
+func NewPublicIPAddressesClient(subscriptionID string) (*armnetwork.PublicIPAddressesClient, *PublicIPAddressServer, error) {
+	s := &PublicIPAddressServer{
+		ddosSettings:       map[string]*armnetwork.DdosSettings{},
+		provisioningStates: map[string]armnetwork.ProvisioningState{},
+	}
+	fakeServer := fake.PublicIPAddressesServer{
+		Get:                 s.get,
+		BeginCreateOrUpdate: s.beginCreateOrUpdate,
+	}
+	clientFactory, err := armnetwork.NewClientFactory(subscriptionID, &azcorefake.TokenCredential{},
+		&arm.ClientOptions{
+			ClientOptions: azcore.ClientOptions{
+				Transport: fake.NewPublicIPAddressesServerTransport(&fakeServer),
+			},
+		})
+	if err != nil {
+		return nil, nil, err
+	}
+	return clientFactory.NewPublicIPAddressesClient(), s, nil
+}
+
+// DDoSSettings returns the DDoS settings of the public IP address.
+func (s *PublicIPAddressServer) DDoSSettings(name string) *armnetwork.DdosSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ddosSettings[name]
+}
+
+// SetProvisioningState sets the provisioning state of the public IP address, e.g. to simulate an update which is
+// still in progress; it defaults to Succeeded.
+func (s *PublicIPAddressServer) SetProvisioningState(name string, state armnetwork.ProvisioningState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provisioningStates[name] = state
+}
+
+func (s *PublicIPAddressServer) get(_ context.Context, resourceGroupName string, publicIPAddressName string, _ *armnetwork.PublicIPAddressesClientGetOptions) (resp azcorefake.Responder[armnetwork.PublicIPAddressesClientGetResponse], errResp azcorefake.ErrorResponder) {
+	if resourceGroupName != DefaultResourceGroupName {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+		return resp, errResp
+	}
+	switch publicIPAddressName {
+	case ValidPublicIPAddressName, InternalServerErrPublicIPAddressName:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		state, ok := s.provisioningStates[publicIPAddressName]
+		if !ok {
+			state = armnetwork.ProvisioningStateSucceeded
+		}
+		pipResp := armnetwork.PublicIPAddressesClientGetResponse{
+			PublicIPAddress: armnetwork.PublicIPAddress{
+				ID:       ptr.To(PublicIPAddressResourceID(publicIPAddressName)),
+				Name:     ptr.To(publicIPAddressName),
+				Location: ptr.To("westus"),
+				Properties: &armnetwork.PublicIPAddressPropertiesFormat{
+					DdosSettings:      s.ddosSettings[publicIPAddressName],
+					ProvisioningState: ptr.To(state),
+				},
+			},
+		}
+		resp.SetResponse(http.StatusOK, pipResp, nil)
+	default:
+		errResp.SetResponseError(http.StatusNotFound, "NotFoundError")
+	}
+	return resp, errResp
+}
+
+func (s *PublicIPAddressServer) beginCreateOrUpdate(_ context.Context, resourceGroupName string, publicIPAddressName string, parameters armnetwork.PublicIPAddress, _ *armnetwork.PublicIPAddressesClientBeginCreateOrUpdateOptions) (resp azcorefake.PollerResponder[armnetwork.PublicIPAddressesClientCreateOrUpdateResponse], errResp azcorefake.ErrorResponder) {
+	if resourceGroupName != DefaultResourceGroupName {
+		errResp.SetResponseError(http.StatusNotFound, "ResourceGroupNotFound")
+		return resp, errResp
+	}
+	switch publicIPAddressName {
+	case ValidPublicIPAddressName:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if parameters.Properties != nil {
+			s.ddosSettings[publicIPAddressName] = parameters.Properties.DdosSettings
+		}
+		resp.SetTerminalResponse(http.StatusOK, armnetwork.PublicIPAddressesClientCreateOrUpdateResponse{PublicIPAddress: parameters}, nil)
+	case InternalServerErrPublicIPAddressName:
+		errResp.SetResponseError(http.StatusInternalServerError, "InternalServerError")
+	default:
+		errResp.SetResponseError(http.StatusNotFound, "NotFoundError")
+	}
+	return resp, errResp
+}
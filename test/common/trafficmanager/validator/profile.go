@@ -63,6 +63,21 @@ func ValidateTrafficManagerProfile(ctx context.Context, k8sClient client.Client,
 	}, timeout, interval).Should(gomega.Succeed(), "Get() trafficManagerProfile mismatch")
 }
 
+// ValidateTrafficManagerProfileConsistently validates the trafficManagerProfile object consistently.
+func ValidateTrafficManagerProfileConsistently(ctx context.Context, k8sClient client.Client, want *fleetnetv1beta1.TrafficManagerProfile) {
+	key := types.NamespacedName{Name: want.Name, Namespace: want.Namespace}
+	profile := &fleetnetv1beta1.TrafficManagerProfile{}
+	gomega.Consistently(func() error {
+		if err := k8sClient.Get(ctx, key, profile); err != nil {
+			return err
+		}
+		if diff := cmp.Diff(want, profile, cmpTrafficManagerProfileOptions); diff != "" {
+			return fmt.Errorf("trafficManagerProfile mismatch (-want, +got) :\n%s", diff)
+		}
+		return nil
+	}, duration, interval).Should(gomega.Succeed(), "Get() trafficManagerProfile mismatch")
+}
+
 // ValidateIfTrafficManagerProfileIsProgrammed validates the trafficManagerProfile is programmed and returns the DNSName.
 func ValidateIfTrafficManagerProfileIsProgrammed(ctx context.Context, k8sClient client.Client, profileName types.NamespacedName) *fleetnetv1beta1.TrafficManagerProfile {
 	wantDNSName := fmt.Sprintf("%s-%s.trafficmanager.net", profileName.Namespace, profileName.Name)
@@ -86,6 +101,8 @@ func ValidateIfTrafficManagerProfileIsProgrammed(ctx context.Context, k8sClient
 			profile.Status,
 			wantStatus,
 			cmpConditionOptions,
+			// The health of the endpoints depends on the probing of Azure Traffic Manager.
+			cmpopts.IgnoreFields(fleetnetv1beta1.TrafficManagerProfileStatus{}, "HealthyEndpoints", "DegradedEndpoints", "CheckingEndpoints", "TotalEndpoints"),
 		); diff != "" {
 			return fmt.Errorf("trafficManagerProfile status diff (-got, +want): %s", diff)
 		}
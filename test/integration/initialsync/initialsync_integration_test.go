@@ -0,0 +1,82 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package initialsync
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+)
+
+const (
+	eventuallyTimeout  = time.Minute * 2
+	eventuallyInterval = time.Second
+)
+
+var _ = Describe("initial sync", func() {
+	Context("a member cluster joins the fleet with thousands of services to export", func() {
+		It("should export all the services within the hub write budget", func() {
+			By("all the services are exported to the hub cluster")
+			Eventually(func() error {
+				internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+				if err := hubClient.List(ctx, internalSvcExportList, client.InNamespace(hubNSForMember)); err != nil {
+					return err
+				}
+				if len(internalSvcExportList.Items) != coldStartServices {
+					return fmt.Errorf("internalServiceExports, got %d, want %d", len(internalSvcExportList.Items), coldStartServices)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("the services are exported at no more than the hub write rate")
+			hubWritesMu.Lock()
+			writes := append([]time.Time{}, hubWrites...)
+			hubWritesMu.Unlock()
+			Expect(writes).To(HaveLen(coldStartServices))
+			sort.Slice(writes, func(i, j int) bool { return writes[i].Before(writes[j]) })
+			// Count the writes in every one-second window starting at a write.
+			for i, end := 0, 0; i < len(writes); i++ {
+				for end < len(writes) && writes[end].Sub(writes[i]) < time.Second {
+					end++
+				}
+				Expect(end-i).To(BeNumerically("<=", hubWriteRate+hubWriteBurst),
+					"%d services are exported within a second from %v", end-i, writes[i])
+			}
+			// The exports after the burst take at least as long as the rate allows, give or take a second.
+			minDuration := time.Duration(float64(coldStartServices-hubWriteBurst)/hubWriteRate*float64(time.Second)) - time.Second
+			Expect(writes[len(writes)-1].Sub(writes[0])).To(BeNumerically(">=", minDuration))
+
+			By("the pacing ends and the status reports the completion")
+			Eventually(func() error {
+				configMap := &corev1.ConfigMap{}
+				if err := memberClient.Get(ctx, types.NamespacedName{Namespace: fleetSystemNS, Name: initialsync.StatusConfigMapName}, configMap); err != nil {
+					return err
+				}
+				want := map[string]string{
+					"phase":    "Completed",
+					"exported": fmt.Sprint(coldStartServices),
+					"total":    fmt.Sprint(coldStartServices),
+				}
+				for k, v := range want {
+					if configMap.Data[k] != v {
+						return fmt.Errorf("status %s, got %q, want %q", k, configMap.Data[k], v)
+					}
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+			Expect(pacer.IsPacing()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,202 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package initialsync contains integration tests which run the member cluster controllers that export Services
+// against a member cluster and a hub cluster, when the member cluster joins the fleet with a large backlog.
+package initialsync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/common/initialsync"
+	"go.goms.io/fleet-networking/pkg/controllers/member/serviceexport"
+)
+
+const (
+	memberClusterID = "bravelion"
+	memberUserNS    = "work"
+	hubNSForMember  = "bravelion"
+	fleetSystemNS   = "fleet-system"
+
+	// coldStartServices is the number of Services the member cluster exports when it joins the fleet.
+	coldStartServices = 5000
+	// hubWriteRate and hubWriteBurst make up the budget of the writes to the hub cluster during the initial export.
+	hubWriteRate  = 500
+	hubWriteBurst = 50
+)
+
+var (
+	memberTestEnv *envtest.Environment
+	hubTestEnv    *envtest.Environment
+	memberClient  client.Client
+	hubClient     client.Client
+	pacer         *initialsync.Pacer
+	ctx           context.Context
+	cancel        context.CancelFunc
+
+	// hubWrites keeps the times of the InternalServiceExports created in the hub cluster.
+	hubWrites   []time.Time
+	hubWritesMu sync.Mutex
+)
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Initial Sync Integration Suite")
+}
+
+// recordHubWrite records the time an InternalServiceExport is created in the hub cluster.
+func recordHubWrite() {
+	hubWritesMu.Lock()
+	defer hubWritesMu.Unlock()
+	hubWrites = append(hubWrites, time.Now())
+}
+
+var _ = BeforeSuite(func() {
+	klog.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	By("bootstrap the test environment")
+	memberTestEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	memberCfg, err := memberTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(memberCfg).NotTo(BeNil())
+	// The backlog is created with thousands of requests; do not throttle them on the client side.
+	memberCfg.QPS = -1
+
+	hubTestEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	hubCfg, err := hubTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(hubCfg).NotTo(BeNil())
+	// The hub writes are paced by the controllers rather than throttled on the client side.
+	hubCfg.QPS = -1
+
+	Expect(fleetnetv1alpha1.AddToScheme(scheme.Scheme)).Should(Succeed())
+
+	memberClient, err = client.New(memberCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	hubClient, err = client.New(hubCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	By("create the namespaces")
+	Expect(memberClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: memberUserNS}})).Should(Succeed())
+	Expect(memberClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: fleetSystemNS}})).Should(Succeed())
+	Expect(hubClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: hubNSForMember}})).Should(Succeed())
+
+	By("create the backlog of the member cluster")
+	// The Services are headless, as the service IP range of the test API server cannot hold thousands of them.
+	for i := 0; i < coldStartServices; i++ {
+		name := fmt.Sprintf("app-%d", i)
+		Expect(memberClient.Create(ctx, &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      name,
+			},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: corev1.ClusterIPNone,
+				Ports: []corev1.ServicePort{
+					{
+						Name:     "http",
+						Protocol: corev1.ProtocolTCP,
+						Port:     80,
+					},
+				},
+			},
+		})).Should(Succeed())
+		Expect(memberClient.Create(ctx, &fleetnetv1alpha1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      name,
+			},
+		})).Should(Succeed())
+	}
+
+	By("detect the cold start")
+	backlog, err := initialsync.DetectBacklog(ctx, memberClient, hubClient, hubNSForMember, initialsync.DefaultThreshold)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(backlog).NotTo(BeNil())
+	Expect(backlog.ServiceExports).To(HaveLen(coldStartServices))
+	pacer = initialsync.New(memberClusterID, hubWriteRate, hubWriteBurst, backlog)
+
+	By("start the member cluster controllers")
+	memberMgr, err := ctrl.NewManager(memberCfg, ctrl.Options{
+		Scheme: scheme.Scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	// Record the InternalServiceExports the controller creates in the hub cluster; the ServiceExports are paced
+	// when they are first exported only.
+	hubWatchClient, err := client.NewWithWatch(hubCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	recordingHubClient := interceptor.NewClient(hubWatchClient, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if err := c.Create(ctx, obj, opts...); err != nil {
+				return err
+			}
+			if _, ok := obj.(*fleetnetv1alpha1.InternalServiceExport); ok {
+				recordHubWrite()
+			}
+			return nil
+		},
+	})
+
+	Expect((&serviceexport.Reconciler{
+		MemberClusterID:  memberClusterID,
+		MemberClient:     memberMgr.GetClient(),
+		HubClient:        recordingHubClient,
+		HubNamespace:     hubNSForMember,
+		Recorder:         memberMgr.GetEventRecorderFor(serviceexport.ControllerName),
+		InitialSyncPacer: pacer,
+	}).SetupWithManager(memberMgr)).Should(Succeed())
+	Expect(memberMgr.Add(&initialsync.StatusReporter{
+		Pacer:     pacer,
+		Client:    memberClient,
+		Namespace: fleetSystemNS,
+		Interval:  time.Second,
+	})).Should(Succeed())
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(memberMgr.Start(ctx)).Should(Succeed(), "failed to start the member manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	defer klog.Flush()
+	cancel()
+
+	By("tearing down the test environment")
+	Expect(memberTestEnv.Stop()).Should(Succeed())
+	Expect(hubTestEnv.Stop()).Should(Succeed())
+})
@@ -0,0 +1,177 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+package serviceexport
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+)
+
+const (
+	svcName           = "app"
+	endpointSliceName = "app-x2yz"
+
+	eventuallyTimeout  = time.Second * 10
+	eventuallyInterval = time.Millisecond * 250
+)
+
+var _ = Describe("service export", func() {
+	svcExportKey := types.NamespacedName{Namespace: memberUserNS, Name: svcName}
+
+	Context("export a service with endpoints", func() {
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      svcName,
+			},
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{
+					{
+						Name:     "http",
+						Protocol: corev1.ProtocolTCP,
+						Port:     80,
+					},
+				},
+			},
+		}
+		svcExport := &fleetnetv1alpha1.ServiceExport{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      svcName,
+			},
+		}
+		endpointSlice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: memberUserNS,
+				Name:      endpointSliceName,
+				Labels: map[string]string{
+					discoveryv1.LabelServiceName: svcName,
+				},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses: []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{
+						Ready: ptr.To(true),
+					},
+				},
+			},
+			Ports: []discoveryv1.EndpointPort{
+				{
+					Name:     ptr.To("http"),
+					Protocol: ptr.To(corev1.ProtocolTCP),
+					Port:     ptr.To(int32(80)),
+				},
+			},
+		}
+
+		BeforeEach(func() {
+			Expect(memberClient.Create(ctx, svc.DeepCopy())).Should(Succeed())
+			Expect(memberClient.Create(ctx, svcExport.DeepCopy())).Should(Succeed())
+			Expect(memberClient.Create(ctx, endpointSlice.DeepCopy())).Should(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(memberClient.Delete(ctx, endpointSlice.DeepCopy())).Should(Succeed())
+			Expect(memberClient.Delete(ctx, svcExport.DeepCopy())).Should(Succeed())
+			Expect(memberClient.Delete(ctx, svc.DeepCopy())).Should(Succeed())
+		})
+
+		It("should export the service and its endpoint slices to the hub cluster", func() {
+			By("the service export is marked as valid")
+			Eventually(func() error {
+				current := &fleetnetv1alpha1.ServiceExport{}
+				if err := memberClient.Get(ctx, svcExportKey, current); err != nil {
+					return err
+				}
+				validCond := meta.FindStatusCondition(current.Status.Conditions, string(fleetnetv1alpha1.ServiceExportValid))
+				if validCond == nil || validCond.Status != metav1.ConditionTrue || validCond.Reason != "ServiceIsValid" {
+					return fmt.Errorf("valid condition, got %+v, want status True with reason ServiceIsValid", validCond)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("the service is exported to the hub cluster")
+			internalSvcExport := &fleetnetv1alpha1.InternalServiceExport{}
+			Eventually(func() error {
+				internalSvcExportList := &fleetnetv1alpha1.InternalServiceExportList{}
+				if err := hubClient.List(ctx, internalSvcExportList, client.InNamespace(hubNSForMember)); err != nil {
+					return err
+				}
+				if len(internalSvcExportList.Items) != 1 {
+					return fmt.Errorf("internalServiceExports, got %d, want 1", len(internalSvcExportList.Items))
+				}
+				internalSvcExport = &internalSvcExportList.Items[0]
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+
+			By("the endpoint slice is not exported before the export conflict is resolved")
+			Consistently(func() error {
+				endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+				if err := hubClient.List(ctx, endpointSliceExportList, client.InNamespace(hubNSForMember)); err != nil {
+					return err
+				}
+				if len(endpointSliceExportList.Items) != 0 {
+					return fmt.Errorf("endpointSliceExports, got %d, want 0", len(endpointSliceExportList.Items))
+				}
+				return nil
+			}, time.Second, eventuallyInterval).Should(Succeed())
+
+			By("the hub cluster resolves the export with no conflict")
+			meta.SetStatusCondition(&internalSvcExport.Status.Conditions, metav1.Condition{
+				Type:               string(fleetnetv1alpha1.ServiceExportConflict),
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: internalSvcExport.Spec.ServiceReference.Generation,
+				Reason:             "NoConflictFound",
+				Message:            "service is exported without conflict",
+			})
+			Expect(hubClient.Status().Update(ctx, internalSvcExport)).Should(Succeed())
+
+			By("the endpoint slice is exported to the hub cluster")
+			Eventually(func() error {
+				endpointSliceExportList := &fleetnetv1alpha1.EndpointSliceExportList{}
+				if err := hubClient.List(ctx, endpointSliceExportList, client.InNamespace(hubNSForMember)); err != nil {
+					return err
+				}
+				if len(endpointSliceExportList.Items) != 1 {
+					return fmt.Errorf("endpointSliceExports, got %d, want 1", len(endpointSliceExportList.Items))
+				}
+				got := endpointSliceExportList.Items[0].Spec
+				want := fleetnetv1alpha1.EndpointSliceExportSpec{
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints: []fleetnetv1alpha1.Endpoint{
+						{Addresses: []string{"10.0.0.1"}},
+					},
+					Ports:                  endpointSlice.Ports,
+					EndpointSliceReference: got.EndpointSliceReference,
+					OwnerServiceReference: fleetnetv1alpha1.OwnerServiceReference{
+						Namespace:      memberUserNS,
+						Name:           svcName,
+						NamespacedName: svcExportKey.String(),
+					},
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					return fmt.Errorf("endpointSliceExport spec (-want, +got):\n%s", diff)
+				}
+				return nil
+			}, eventuallyTimeout, eventuallyInterval).Should(Succeed())
+		})
+	})
+})
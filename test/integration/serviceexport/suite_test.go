@@ -0,0 +1,147 @@
+/*
+Copyright (c) Microsoft Corporation.
+Licensed under the MIT license.
+*/
+
+// Package serviceexport contains integration tests which run the member cluster controllers that export a Service
+// together, against a member cluster and a hub cluster.
+package serviceexport
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	fleetnetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	"go.goms.io/fleet-networking/pkg/controllers/member/endpointslice"
+	"go.goms.io/fleet-networking/pkg/controllers/member/internalserviceexport"
+	"go.goms.io/fleet-networking/pkg/controllers/member/serviceexport"
+)
+
+const (
+	memberClusterID = "bravelion"
+	memberUserNS    = "work"
+	hubNSForMember  = "bravelion"
+)
+
+var (
+	memberTestEnv *envtest.Environment
+	hubTestEnv    *envtest.Environment
+	memberClient  client.Client
+	hubClient     client.Client
+	ctx           context.Context
+	cancel        context.CancelFunc
+)
+
+func TestAPIs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Service Export Integration Suite")
+}
+
+var _ = BeforeSuite(func() {
+	klog.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+
+	ctx, cancel = context.WithCancel(context.TODO())
+
+	By("bootstrap the test environment")
+	memberTestEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	memberCfg, err := memberTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(memberCfg).NotTo(BeNil())
+
+	hubTestEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+	}
+	hubCfg, err := hubTestEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(hubCfg).NotTo(BeNil())
+
+	Expect(fleetnetv1alpha1.AddToScheme(scheme.Scheme)).Should(Succeed())
+
+	memberClient, err = client.New(memberCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	hubClient, err = client.New(hubCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	By("create the namespaces")
+	Expect(memberClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: memberUserNS}})).Should(Succeed())
+	Expect(hubClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: hubNSForMember}})).Should(Succeed())
+
+	By("start the member cluster controllers")
+	memberMgr, err := ctrl.NewManager(memberCfg, ctrl.Options{
+		Scheme: scheme.Scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	hubMgr, err := ctrl.NewManager(hubCfg, ctrl.Options{
+		Scheme: scheme.Scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: "0",
+		},
+		Cache: cache.Options{
+			DefaultNamespaces: map[string]cache.Config{
+				hubNSForMember: {},
+			},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect((&serviceexport.Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    memberClient,
+		HubClient:       hubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        memberMgr.GetEventRecorderFor(serviceexport.ControllerName),
+	}).SetupWithManager(memberMgr)).Should(Succeed())
+	Expect((&internalserviceexport.Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    memberClient,
+		HubClient:       hubClient,
+		Recorder:        memberMgr.GetEventRecorderFor(internalserviceexport.ControllerName),
+	}).SetupWithManager(hubMgr)).Should(Succeed())
+	Expect((&endpointslice.Reconciler{
+		MemberClusterID: memberClusterID,
+		MemberClient:    memberClient,
+		HubClient:       hubClient,
+		HubNamespace:    hubNSForMember,
+		Recorder:        memberMgr.GetEventRecorderFor(endpointslice.ControllerName),
+	}).SetupWithManager(ctx, memberMgr)).Should(Succeed())
+
+	go func() {
+		defer GinkgoRecover()
+		Expect(memberMgr.Start(ctx)).Should(Succeed(), "failed to start the member manager")
+	}()
+	go func() {
+		defer GinkgoRecover()
+		Expect(hubMgr.Start(ctx)).Should(Succeed(), "failed to start the hub manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	defer klog.Flush()
+	cancel()
+
+	By("tearing down the test environment")
+	Expect(memberTestEnv.Stop()).Should(Succeed())
+	Expect(hubTestEnv.Stop()).Should(Succeed())
+})